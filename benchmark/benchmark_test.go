@@ -12,6 +12,7 @@ import (
 	quic "github.com/lucas-clemente/quic-go"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/testdata"
+	"github.com/lucas-clemente/quic-go/internal/utils"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -45,6 +46,8 @@ var _ = Describe("Benchmarks", func() {
 						"localhost:0",
 						tlsConf,
 						&quic.Config{Versions: []protocol.VersionNumber{version}},
+						utils.ChooseHystart,
+						utils.ChooseCubic,
 					)
 					Expect(err).ToNot(HaveOccurred())
 					serverAddr <- ln.Addr()
@@ -67,6 +70,8 @@ var _ = Describe("Benchmarks", func() {
 					addr.String(),
 					&tls.Config{InsecureSkipVerify: true, NextProtos: []string{"benchmark"}},
 					&quic.Config{Versions: []protocol.VersionNumber{version}},
+					utils.ChooseHystart,
+					utils.ChooseCubic,
 				)
 				Expect(err).ToNot(HaveOccurred())
 				close(handshakeChan)
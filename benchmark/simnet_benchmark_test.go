@@ -0,0 +1,287 @@
+package benchmark
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	quic "github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/testdata"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+// These benchmarks run QUIC connections over the in-memory network (see simNetwork).
+// The network and the data are generated from a fixed seed, so the results only depend on the code and the machine.
+// Run them (without the Ginkgo suite) using
+//   go test ./benchmark -run='^$' -bench=. -count=10
+// and compare the results of two commits with benchstat.
+
+const simSeed = 42
+
+var linkProfiles = []struct {
+	name   string
+	config linkConfig
+}{
+	// no bandwidth limit and no delay: measures the CPU cost
+	{name: "unlimited", config: linkConfig{}},
+	// 1 Gbit/s, 1ms RTT
+	{name: "lan", config: linkConfig{Bandwidth: 125e6, Delay: 500 * time.Microsecond, QueueSize: 250e3}},
+	// 100 Mbit/s, 40ms RTT, with a queue of one bandwidth-delay product
+	{name: "wan", config: linkConfig{Bandwidth: 12.5e6, Delay: 20 * time.Millisecond, QueueSize: 500e3}},
+	// 100 Mbit/s, 10ms RTT, with a queue of one bandwidth-delay product and 0.1% random loss
+	{name: "lossy", config: linkConfig{Bandwidth: 12.5e6, Delay: 5 * time.Millisecond, QueueSize: 125e3, LossRate: 0.001}},
+}
+
+var congestionAlgos = []struct {
+	name string
+	algo utils.CongestionAlgo
+}{
+	{name: "cubic", algo: utils.ChooseCubic},
+	{name: "newreno", algo: utils.ChooseNewReno},
+}
+
+// runSimServer starts a server on the simulated network.
+// Every accepted session is handled in a new go routine.
+func runSimServer(b *testing.B, network *simNetwork, conf *quic.Config, handle func(quic.Session)) (net.Addr, func()) {
+	conn := network.NewConn()
+	tlsConf := testdata.GetTLSConfig()
+	tlsConf.NextProtos = []string{"benchmark"}
+	ln, err := quic.Listen(conn, tlsConf, conf)
+	if err != nil {
+		b.Fatal(err)
+	}
+	go func() {
+		for {
+			sess, err := ln.Accept(context.Background())
+			if err != nil {
+				return
+			}
+			go handle(sess)
+		}
+	}()
+	return conn.LocalAddr(), func() {
+		ln.Close()
+		conn.Close()
+	}
+}
+
+// dialSim dials the server on the simulated network.
+// The session uses its own packet conn, which is closed when the session is closed.
+func dialSim(b *testing.B, network *simNetwork, addr net.Addr, conf *quic.Config, algo utils.CongestionAlgo) quic.Session {
+	conn := network.NewConn()
+	sess, err := quic.Dial(
+		conn,
+		addr,
+		"localhost",
+		&tls.Config{InsecureSkipVerify: true, NextProtos: []string{"benchmark"}},
+		conf,
+		utils.ChooseHystart,
+		algo,
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+	go func() {
+		<-sess.Context().Done()
+		conn.Close()
+	}()
+	return sess
+}
+
+// discardStreams reads all data sent on the streams of a session, and closes the streams when the peer is done.
+func discardStreams(sess quic.Session) {
+	for {
+		str, err := sess.AcceptStream(context.Background())
+		if err != nil {
+			return
+		}
+		go func() {
+			if _, err := io.Copy(ioutil.Discard, str); err != nil {
+				return
+			}
+			str.Close()
+		}()
+	}
+}
+
+// transfer sends data on a new stream, and waits until the peer closed the stream.
+func transfer(sess quic.Session, data []byte) error {
+	str, err := sess.OpenStreamSync(context.Background())
+	if err != nil {
+		return err
+	}
+	if _, err := str.Write(data); err != nil {
+		return err
+	}
+	if err := str.Close(); err != nil {
+		return err
+	}
+	_, err = io.Copy(ioutil.Discard, str)
+	return err
+}
+
+func randomData(size int) []byte {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(simSeed)).Read(data)
+	return data
+}
+
+func BenchmarkHandshake(b *testing.B) {
+	for _, l := range linkProfiles {
+		l := l
+		b.Run(l.name, func(b *testing.B) {
+			network := newSimNetwork(l.config, simSeed)
+			addr, closeServer := runSimServer(b, network, nil, func(sess quic.Session) { <-sess.Context().Done() })
+			defer closeServer()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				sess := dialSim(b, network, addr, nil, utils.ChooseCubic)
+				sess.CloseWithError(0, "")
+			}
+		})
+	}
+}
+
+func BenchmarkSingleStreamThroughput(b *testing.B) {
+	const size = 4 << 20
+	data := randomData(size)
+
+	for _, l := range linkProfiles {
+		for _, c := range congestionAlgos {
+			l, c := l, c
+			b.Run(l.name+"/"+c.name, func(b *testing.B) {
+				network := newSimNetwork(l.config, simSeed)
+				addr, closeServer := runSimServer(b, network, nil, discardStreams)
+				defer closeServer()
+				sess := dialSim(b, network, addr, nil, c.algo)
+				defer sess.CloseWithError(0, "")
+
+				b.SetBytes(size)
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if err := transfer(sess, data); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkManyStreams(b *testing.B) {
+	const (
+		numStreams = 100
+		size       = 16 << 10
+	)
+	data := randomData(size)
+
+	for _, l := range linkProfiles {
+		for _, c := range congestionAlgos {
+			l, c := l, c
+			b.Run(l.name+"/"+c.name, func(b *testing.B) {
+				network := newSimNetwork(l.config, simSeed)
+				conf := &quic.Config{MaxIncomingStreams: numStreams}
+				addr, closeServer := runSimServer(b, network, conf, discardStreams)
+				defer closeServer()
+				sess := dialSim(b, network, addr, nil, c.algo)
+				defer sess.CloseWithError(0, "")
+
+				b.SetBytes(numStreams * size)
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					var wg sync.WaitGroup
+					wg.Add(numStreams)
+					for j := 0; j < numStreams; j++ {
+						go func() {
+							defer wg.Done()
+							if err := transfer(sess, data); err != nil {
+								b.Error(err)
+							}
+						}()
+					}
+					wg.Wait()
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkDatagrams(b *testing.B) {
+	const size = 1000
+	data := randomData(size)
+
+	for _, l := range linkProfiles {
+		for _, c := range congestionAlgos {
+			l, c := l, c
+			b.Run(l.name+"/"+c.name, func(b *testing.B) {
+				network := newSimNetwork(l.config, simSeed)
+				conf := &quic.Config{EnableDatagrams: true}
+				var received uint64
+				addr, closeServer := runSimServer(b, network, conf, func(sess quic.Session) {
+					for {
+						if _, err := sess.ReceiveMessage(); err != nil {
+							return
+						}
+						atomic.AddUint64(&received, 1)
+					}
+				})
+				defer closeServer()
+				sess := dialSim(b, network, addr, conf, c.algo)
+				defer sess.CloseWithError(0, "")
+
+				b.SetBytes(size)
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					if err := sess.SendMessage(data); err != nil {
+						b.Fatal(err)
+					}
+				}
+				// Wait for the datagrams in flight. Datagrams are not retransmitted, so some of them might be lost.
+				deadline := time.Now().Add(4*l.config.Delay + 100*time.Millisecond)
+				for atomic.LoadUint64(&received) < uint64(b.N) && time.Now().Before(deadline) {
+					time.Sleep(time.Millisecond)
+				}
+				b.StopTimer()
+				b.ReportMetric(float64(atomic.LoadUint64(&received))/float64(b.N), "delivered/op")
+			})
+		}
+	}
+}
+
+func BenchmarkPacingGranularity(b *testing.B) {
+	const size = 4 << 20
+	data := randomData(size)
+	lan := linkProfiles[1].config
+
+	for _, granularity := range []time.Duration{time.Millisecond, 2 * time.Millisecond, 5 * time.Millisecond} {
+		granularity := granularity
+		b.Run(granularity.String(), func(b *testing.B) {
+			network := newSimNetwork(lan, simSeed)
+			addr, closeServer := runSimServer(b, network, nil, discardStreams)
+			defer closeServer()
+			sess := dialSim(b, network, addr, &quic.Config{PacingGranularity: granularity}, utils.ChooseCubic)
+			defer sess.CloseWithError(0, "")
+
+			b.SetBytes(size)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := transfer(sess, data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
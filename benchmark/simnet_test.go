@@ -0,0 +1,216 @@
+package benchmark
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// linkConfig configures the link from an endpoint of the simulated network to the other endpoints.
+type linkConfig struct {
+	Bandwidth uint64        // in bytes/s. 0 means unlimited.
+	Delay     time.Duration // one-way delay
+	QueueSize int           // in bytes. Packets that don't fit into the queue are dropped. 0 means unlimited.
+	LossRate  float64       // random loss rate, between 0 and 1
+}
+
+var lastSimAddr uint32
+
+// simAddr is the address of an endpoint of the simulated network.
+// Addresses are unique across all simulated networks, since the QUIC multiplexer identifies packet conns by their address.
+type simAddr string
+
+func newSimAddr() simAddr { return simAddr(fmt.Sprintf("sim-%d", atomic.AddUint32(&lastSimAddr, 1))) }
+
+func (a simAddr) Network() string { return "sim" }
+func (a simAddr) String() string  { return string(a) }
+
+type simPacket struct {
+	data      []byte
+	from, to  net.Addr
+	deliverAt time.Time
+}
+
+// simNetwork is an in-memory network.
+// Every endpoint sends over its own link, with the bandwidth, delay, queue size and loss rate of the linkConfig.
+// Random losses are drawn from a generator seeded with the seed of the network,
+// such that the same sequence of packets experiences the same losses.
+type simNetwork struct {
+	config linkConfig
+	seed   int64
+
+	mutex    sync.Mutex
+	conns    map[simAddr]*simConn
+	numConns int64 // number of endpoints created so far
+}
+
+func newSimNetwork(config linkConfig, seed int64) *simNetwork {
+	return &simNetwork{
+		config: config,
+		seed:   seed,
+		conns:  make(map[simAddr]*simConn),
+	}
+}
+
+// NewConn creates a new endpoint in the network.
+func (n *simNetwork) NewConn() *simConn {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.numConns++
+	c := &simConn{
+		network:  n,
+		addr:     newSimAddr(),
+		rand:     rand.New(rand.NewSource(n.seed + n.numConns)),
+		received: make(chan simPacket, 1024),
+		queue:    make(chan simPacket, 4096),
+		closed:   make(chan struct{}),
+	}
+	n.conns[c.addr] = c
+	go c.runLink()
+	return c
+}
+
+func (n *simNetwork) deliver(p simPacket) {
+	n.mutex.Lock()
+	dst, ok := n.conns[p.to.(simAddr)]
+	n.mutex.Unlock()
+	if !ok {
+		return
+	}
+	select {
+	case dst.received <- p:
+	default: // the receive buffer is full
+	}
+}
+
+func (n *simNetwork) remove(c *simConn) {
+	n.mutex.Lock()
+	delete(n.conns, c.addr)
+	n.mutex.Unlock()
+}
+
+// simConn is a net.PacketConn on the simulated network.
+type simConn struct {
+	network *simNetwork
+	addr    simAddr
+
+	mutex         sync.Mutex
+	rand          *rand.Rand
+	nextDeparture time.Time // when the link is done transmitting the packets that are already queued
+	readDeadline  time.Time
+
+	received  chan simPacket
+	queue     chan simPacket // packets in flight on the link
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+var _ net.PacketConn = &simConn{}
+
+var errSimConnClosed = errors.New("use of closed simulated connection")
+
+func (c *simConn) runLink() {
+	for {
+		select {
+		case p := <-c.queue:
+			if d := time.Until(p.deliverAt); d > 0 {
+				time.Sleep(d)
+			}
+			c.network.deliver(p)
+		case <-c.closed:
+			return
+		}
+	}
+}
+
+func (c *simConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	select {
+	case <-c.closed:
+		return 0, errSimConnClosed
+	default:
+	}
+	to, ok := addr.(simAddr)
+	if !ok {
+		return 0, fmt.Errorf("invalid address for the simulated network: %s", addr)
+	}
+	config := c.network.config
+
+	c.mutex.Lock()
+	now := time.Now()
+	if config.LossRate > 0 && c.rand.Float64() < config.LossRate {
+		c.mutex.Unlock()
+		return len(b), nil
+	}
+	if c.nextDeparture.Before(now) {
+		c.nextDeparture = now
+	}
+	if config.Bandwidth > 0 {
+		if config.QueueSize > 0 {
+			queued := uint64(c.nextDeparture.Sub(now)) * config.Bandwidth / uint64(time.Second)
+			if queued+uint64(len(b)) > uint64(config.QueueSize) {
+				c.mutex.Unlock()
+				return len(b), nil
+			}
+		}
+		c.nextDeparture = c.nextDeparture.Add(time.Duration(uint64(len(b)) * uint64(time.Second) / config.Bandwidth))
+	}
+	deliverAt := c.nextDeparture.Add(config.Delay)
+	c.mutex.Unlock()
+
+	data := make([]byte, len(b))
+	copy(data, b)
+	select {
+	case c.queue <- simPacket{data: data, from: c.addr, to: to, deliverAt: deliverAt}:
+	default: // the link is overloaded
+	}
+	return len(b), nil
+}
+
+func (c *simConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	c.mutex.Lock()
+	deadline := c.readDeadline
+	c.mutex.Unlock()
+
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case p := <-c.received:
+		return copy(b, p.data), p.from, nil
+	case <-c.closed:
+		return 0, nil, errSimConnClosed
+	case <-timeout:
+		return 0, nil, &net.OpError{Op: "read", Net: "sim", Addr: c.addr, Err: errors.New("i/o timeout")}
+	}
+}
+
+func (c *simConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		c.network.remove(c)
+	})
+	return nil
+}
+
+func (c *simConn) LocalAddr() net.Addr { return c.addr }
+
+func (c *simConn) SetDeadline(t time.Time) error {
+	return c.SetReadDeadline(t)
+}
+
+func (c *simConn) SetReadDeadline(t time.Time) error {
+	c.mutex.Lock()
+	c.readDeadline = t
+	c.mutex.Unlock()
+	return nil
+}
+
+func (c *simConn) SetWriteDeadline(time.Time) error { return nil }
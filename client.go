@@ -35,6 +35,9 @@ type client struct {
 
 	handshakeChan chan struct{}
 
+	// set if the handshake was started by PrepareDial
+	prepared *preparedHandshake
+
 	session quicSession
 
 	tracer    logging.ConnectionTracer
@@ -97,6 +100,9 @@ func DialAddrEarlyContext(
 }
 
 // DialAddrContext establishes a new QUIC connection to a server using the provided context.
+// The context is honored while resolving the address and during the handshake,
+// including the new handshake started after a Version Negotiation or a Retry packet.
+// If the context is canceled or its deadline expires, the context's error is returned.
 // See DialAddr for details.
 func DialAddrContext(
 	ctx context.Context,
@@ -118,10 +124,13 @@ func dialAddrContext(
 	startAlgo utils.StartAlgo,
 	congestionAlgo utils.CongestionAlgo,
 ) (quicSession, error) {
-	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	udpAddr, err := resolveUDPAddr(ctx, addr)
 	if err != nil {
 		return nil, err
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	udpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4zero, Port: 0})
 	if err != nil {
 		return nil, err
@@ -129,6 +138,41 @@ func dialAddrContext(
 	return dialContext(ctx, udpConn, udpAddr, addr, tlsConf, config, use0RTT, true, startAlgo, congestionAlgo)
 }
 
+// resolveUDPAddr resolves addr like net.ResolveUDPAddr, but aborts the DNS lookup when the context is canceled.
+func resolveUDPAddr(ctx context.Context, addr string) (*net.UDPAddr, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := net.DefaultResolver.LookupPort(ctx, "udp", portStr)
+	if err != nil {
+		return nil, err
+	}
+	if host == "" {
+		return &net.UDPAddr{Port: port}, nil
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		// return the context error, not the DNS error that it caused
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+	if len(ips) == 0 {
+		return nil, &net.AddrError{Err: "no suitable address found", Addr: host}
+	}
+	// prefer IPv4 addresses, as net.ResolveUDPAddr does
+	ip := ips[0]
+	for _, a := range ips {
+		if a.IP.To4() != nil {
+			ip = a
+			break
+		}
+	}
+	return &net.UDPAddr{IP: ip.IP, Port: port, Zone: ip.Zone}, nil
+}
+
 // Dial establishes a new QUIC connection to a server using a net.PacketConn. If
 // the PacketConn satisfies the OOBCapablePacketConn interface (as a net.UDPConn
 // does), ECN and packet info support will be enabled. In this case, ReadMsgUDP
@@ -182,6 +226,8 @@ func DialEarlyContext(
 }
 
 // DialContext establishes a new QUIC connection to a server using a net.PacketConn using the provided context.
+// The context is honored during the handshake, including the new handshake started after a Version Negotiation or a Retry packet.
+// If the context is canceled or its deadline expires, the context's error is returned.
 // See Dial for details.
 func DialContext(
 	ctx context.Context,
@@ -214,6 +260,12 @@ func dialContext(
 	if err := validateConfig(config); err != nil {
 		return nil, err
 	}
+	if err := ctx.Err(); err != nil {
+		if createdPacketConn {
+			pconn.Close()
+		}
+		return nil, err
+	}
 	config = populateClientConfig(config, createdPacketConn)
 	packetHandlers, err := getMultiplexer().AddConn(pconn, config.ConnectionIDLength, config.StatelessResetKey, config.Tracer)
 	if err != nil {
@@ -316,6 +368,7 @@ func (c *client) dial(ctx context.Context) error {
 		c.initialPacketNumber,
 		c.use0RTT,
 		c.hasNegotiatedVersion,
+		c.prepared,
 		c.tracer,
 		c.tracingID,
 		c.logger,
@@ -323,6 +376,8 @@ func (c *client) dial(ctx context.Context) error {
 		c.congestionAlgo,
 		c.version,
 	)
+	// A session recreated after a Retry or a Version Negotiation packet performs a new handshake.
+	c.prepared = nil
 	c.packetHandlers.Add(c.srcConnID, c.session)
 
 	errorChan := make(chan error, 1)
@@ -349,11 +404,25 @@ func (c *client) dial(ctx context.Context) error {
 	case err := <-errorChan:
 		var recreateErr *errCloseForRecreating
 		if errors.As(err, &recreateErr) {
+			// Don't start a new handshake after a Version Negotiation or a Retry packet if the dial was canceled.
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				if c.tracer != nil {
+					c.tracer.Close()
+				}
+				if c.createdPacketConn {
+					c.packetHandlers.Destroy()
+				}
+				return ctxErr
+			}
 			c.initialPacketNumber = recreateErr.nextPacketNumber
 			c.version = recreateErr.nextVersion
 			c.hasNegotiatedVersion = true
 			return c.dial(ctx)
 		}
+		// The session might have been closed because the context was canceled concurrently.
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
 		return err
 	case <-earlySessionChan:
 		// ready to send 0-RTT data
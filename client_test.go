@@ -41,9 +41,12 @@ var _ = Describe("Client", func() {
 			initialPacketNumber protocol.PacketNumber,
 			enable0RTT bool,
 			hasNegotiatedVersion bool,
+			prepared *preparedHandshake,
 			tracer logging.ConnectionTracer,
 			tracingID uint64,
 			logger utils.Logger,
+			startAlgo utils.StartAlgo,
+			congestionAlgo utils.CongestionAlgo,
 			v protocol.VersionNumber,
 		) quicSession
 	)
@@ -129,9 +132,12 @@ var _ = Describe("Client", func() {
 				_ protocol.PacketNumber,
 				_ bool,
 				_ bool,
+				_ *preparedHandshake,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
+				_ utils.StartAlgo,
+				_ utils.CongestionAlgo,
 				_ protocol.VersionNumber,
 			) quicSession {
 				remoteAddrChan <- conn.RemoteAddr().String()
@@ -140,7 +146,7 @@ var _ = Describe("Client", func() {
 				sess.EXPECT().HandshakeComplete().Return(context.Background())
 				return sess
 			}
-			_, err := DialAddr("localhost:17890", tlsConf, &Config{HandshakeIdleTimeout: time.Millisecond})
+			_, err := DialAddr("localhost:17890", tlsConf, &Config{HandshakeIdleTimeout: time.Millisecond}, utils.ChooseHystart, utils.ChooseNewReno)
 			Expect(err).ToNot(HaveOccurred())
 			Eventually(remoteAddrChan).Should(Receive(Equal("127.0.0.1:17890")))
 		})
@@ -162,9 +168,12 @@ var _ = Describe("Client", func() {
 				_ protocol.PacketNumber,
 				_ bool,
 				_ bool,
+				_ *preparedHandshake,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
+				_ utils.StartAlgo,
+				_ utils.CongestionAlgo,
 				_ protocol.VersionNumber,
 			) quicSession {
 				hostnameChan <- tlsConf.ServerName
@@ -174,7 +183,7 @@ var _ = Describe("Client", func() {
 				return sess
 			}
 			tlsConf.ServerName = "foobar"
-			_, err := DialAddr("localhost:17890", tlsConf, nil)
+			_, err := DialAddr("localhost:17890", tlsConf, nil, utils.ChooseHystart, utils.ChooseNewReno)
 			Expect(err).ToNot(HaveOccurred())
 			Eventually(hostnameChan).Should(Receive(Equal("foobar")))
 		})
@@ -195,9 +204,12 @@ var _ = Describe("Client", func() {
 				_ protocol.PacketNumber,
 				_ bool,
 				_ bool,
+				_ *preparedHandshake,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
+				_ utils.StartAlgo,
+				_ utils.CongestionAlgo,
 				_ protocol.VersionNumber,
 			) quicSession {
 				hostnameChan <- tlsConf.ServerName
@@ -213,6 +225,8 @@ var _ = Describe("Client", func() {
 				"test.com",
 				tlsConf,
 				config,
+				utils.ChooseHystart,
+				utils.ChooseNewReno,
 			)
 			Expect(err).ToNot(HaveOccurred())
 			Eventually(hostnameChan).Should(Receive(Equal("test.com")))
@@ -234,9 +248,12 @@ var _ = Describe("Client", func() {
 				_ protocol.PacketNumber,
 				enable0RTT bool,
 				_ bool,
+				_ *preparedHandshake,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
+				_ utils.StartAlgo,
+				_ utils.CongestionAlgo,
 				_ protocol.VersionNumber,
 			) quicSession {
 				Expect(enable0RTT).To(BeFalse())
@@ -254,6 +271,8 @@ var _ = Describe("Client", func() {
 				"localhost:1337",
 				tlsConf,
 				config,
+				utils.ChooseHystart,
+				utils.ChooseNewReno,
 			)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(s).ToNot(BeNil())
@@ -277,9 +296,12 @@ var _ = Describe("Client", func() {
 				_ protocol.PacketNumber,
 				enable0RTT bool,
 				_ bool,
+				_ *preparedHandshake,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
+				_ utils.StartAlgo,
+				_ utils.CongestionAlgo,
 				_ protocol.VersionNumber,
 			) quicSession {
 				Expect(enable0RTT).To(BeTrue())
@@ -300,6 +322,8 @@ var _ = Describe("Client", func() {
 					"localhost:1337",
 					tlsConf,
 					config,
+					utils.ChooseHystart,
+					utils.ChooseNewReno,
 				)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(s).ToNot(BeNil())
@@ -325,9 +349,12 @@ var _ = Describe("Client", func() {
 				_ protocol.PacketNumber,
 				_ bool,
 				_ bool,
+				_ *preparedHandshake,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
+				_ utils.StartAlgo,
+				_ utils.CongestionAlgo,
 				_ protocol.VersionNumber,
 			) quicSession {
 				sess := NewMockQuicSession(mockCtrl)
@@ -342,6 +369,8 @@ var _ = Describe("Client", func() {
 				"localhost:1337",
 				tlsConf,
 				config,
+				utils.ChooseHystart,
+				utils.ChooseNewReno,
 			)
 			Expect(err).To(MatchError(testErr))
 		})
@@ -368,9 +397,12 @@ var _ = Describe("Client", func() {
 				_ protocol.PacketNumber,
 				_ bool,
 				_ bool,
+				_ *preparedHandshake,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
+				_ utils.StartAlgo,
+				_ utils.CongestionAlgo,
 				_ protocol.VersionNumber,
 			) quicSession {
 				return sess
@@ -387,6 +419,8 @@ var _ = Describe("Client", func() {
 					"localhost:1337",
 					tlsConf,
 					config,
+					utils.ChooseHystart,
+					utils.ChooseNewReno,
 				)
 				Expect(err).To(MatchError(context.Canceled))
 				close(dialed)
@@ -397,6 +431,32 @@ var _ = Describe("Client", func() {
 			Eventually(dialed).Should(BeClosed())
 		})
 
+		It("doesn't dial if the context is already canceled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err := DialContext(ctx, packetConn, addr, "localhost:1337", tlsConf, config, utils.ChooseHystart, utils.ChooseNewReno)
+			Expect(err).To(MatchError(context.Canceled))
+		})
+
+		It("returns the context error when resolving the address is canceled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			_, err := DialAddrContext(ctx, "quic.invalid:443", tlsConf, nil, utils.ChooseHystart, utils.ChooseNewReno)
+			Expect(err).To(MatchError(context.Canceled))
+		})
+
+		It("resolves addresses like net.ResolveUDPAddr", func() {
+			udpAddr, err := resolveUDPAddr(context.Background(), "127.0.0.1:4433")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(udpAddr.IP.Equal(net.IPv4(127, 0, 0, 1))).To(BeTrue())
+			Expect(udpAddr.Port).To(Equal(4433))
+			udpAddr, err = resolveUDPAddr(context.Background(), "[::1]:443")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(udpAddr.IP.Equal(net.IPv6loopback)).To(BeTrue())
+			_, err = resolveUDPAddr(context.Background(), "localhost")
+			Expect(err).To(HaveOccurred())
+		})
+
 		It("closes the connection when it was created by DialAddr", func() {
 			if os.Getenv("APPVEYOR") == "True" {
 				Skip("This test is flaky on AppVeyor.")
@@ -420,9 +480,12 @@ var _ = Describe("Client", func() {
 				_ protocol.PacketNumber,
 				_ bool,
 				_ bool,
+				_ *preparedHandshake,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
+				_ utils.StartAlgo,
+				_ utils.CongestionAlgo,
 				_ protocol.VersionNumber,
 			) quicSession {
 				conn = connP
@@ -437,7 +500,7 @@ var _ = Describe("Client", func() {
 			done := make(chan struct{})
 			go func() {
 				defer GinkgoRecover()
-				_, err := DialAddr("localhost:1337", tlsConf, nil)
+				_, err := DialAddr("localhost:1337", tlsConf, nil, utils.ChooseHystart, utils.ChooseNewReno)
 				Expect(err).ToNot(HaveOccurred())
 				close(done)
 			}()
@@ -483,7 +546,7 @@ var _ = Describe("Client", func() {
 				mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
 
 				version := protocol.VersionNumber(0x1234)
-				_, err := Dial(packetConn, nil, "localhost:1234", tlsConf, &Config{Versions: []protocol.VersionNumber{version}})
+				_, err := Dial(packetConn, nil, "localhost:1234", tlsConf, &Config{Versions: []protocol.VersionNumber{version}}, utils.ChooseHystart, utils.ChooseNewReno)
 				Expect(err).To(MatchError("0x1234 is not a valid QUIC version"))
 			})
 
@@ -540,9 +603,12 @@ var _ = Describe("Client", func() {
 				_ protocol.PacketNumber,
 				_ bool,
 				_ bool,
+				_ *preparedHandshake,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
+				_ utils.StartAlgo,
+				_ utils.CongestionAlgo,
 				versionP protocol.VersionNumber,
 			) quicSession {
 				cconn = connP
@@ -555,7 +621,7 @@ var _ = Describe("Client", func() {
 				sess.EXPECT().HandshakeComplete().Return(context.Background())
 				return sess
 			}
-			_, err := Dial(packetConn, addr, "localhost:1337", tlsConf, config)
+			_, err := Dial(packetConn, addr, "localhost:1337", tlsConf, config, utils.ChooseHystart, utils.ChooseNewReno)
 			Expect(err).ToNot(HaveOccurred())
 			Eventually(c).Should(BeClosed())
 			Expect(cconn.(*spconn).PacketConn).To(Equal(packetConn))
@@ -580,9 +646,12 @@ var _ = Describe("Client", func() {
 				pn protocol.PacketNumber,
 				_ bool,
 				hasNegotiatedVersion bool,
+				_ *preparedHandshake,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
+				_ utils.StartAlgo,
+				_ utils.CongestionAlgo,
 				versionP protocol.VersionNumber,
 			) quicSession {
 				sess := NewMockQuicSession(mockCtrl)
@@ -604,7 +673,7 @@ var _ = Describe("Client", func() {
 			}
 
 			tracer.EXPECT().StartedConnection(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
-			_, err := DialAddr("localhost:7890", tlsConf, config)
+			_, err := DialAddr("localhost:7890", tlsConf, config, utils.ChooseHystart, utils.ChooseNewReno)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(counter).To(Equal(2))
 		})
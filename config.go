@@ -2,8 +2,11 @@ package quic
 
 import (
 	"errors"
+	"math/rand"
 	"time"
 
+	"github.com/lucas-clemente/quic-go/internal/ackhandler"
+	"github.com/lucas-clemente/quic-go/internal/congestion"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 
 	"github.com/lucas-clemente/quic-go/internal/protocol"
@@ -16,19 +19,219 @@ func (c *Config) Clone() *Config {
 }
 
 func (c *Config) handshakeTimeout() time.Duration {
+	if c.HandshakeTimeout > 0 {
+		return c.HandshakeTimeout
+	}
 	return utils.MaxDuration(protocol.DefaultHandshakeTimeout, 2*c.HandshakeIdleTimeout)
 }
 
+// suspendThreshold returns the threshold for detecting a suspension, or 0 if suspensions are not detected.
+func (c *Config) suspendThreshold() time.Duration {
+	if c.SuspendThreshold < 0 {
+		return 0
+	}
+	if c.SuspendThreshold == 0 {
+		return protocol.DefaultSuspendThreshold
+	}
+	return c.SuspendThreshold
+}
+
+// minRTTAging returns the min RTT aging configuration for a start algorithm.
+func (c *Config) minRTTAging(startAlgo utils.StartAlgo) MinRTTAging {
+	if aging, ok := c.MinRTTAgingPerStartAlgo[startAlgo]; ok {
+		return aging
+	}
+	return MinRTTAging{
+		Expiry:          c.MinRTTExpiry,
+		ProbeDuration:   c.MinRTTProbeDuration,
+		DisableProbeDip: c.DisableMinRTTProbeDip,
+	}
+}
+
+// emulationStep returns the step of the EmulationProfile that is active at a time after the start of the profile.
+func (c *Config) emulationStep(elapsed time.Duration, total time.Duration) EmulationStep {
+	elapsed %= total
+	for _, step := range c.EmulationProfile {
+		if elapsed < step.Duration {
+			return step
+		}
+		elapsed -= step.Duration
+	}
+	return c.EmulationProfile[len(c.EmulationProfile)-1]
+}
+
+// emulatedDelay returns a function that returns the delay (including the jitter) of the EmulationProfile, for a profile started at start.
+// It returns nil if the EmulationProfile doesn't delay packets.
+// The returned function is not safe for concurrent use.
+func (c *Config) emulatedDelay(start time.Time) func(time.Time) time.Duration {
+	var total time.Duration
+	var delays bool
+	for _, step := range c.EmulationProfile {
+		total += step.Duration
+		delays = delays || step.Delay > 0 || step.Jitter > 0
+	}
+	if !delays {
+		return nil
+	}
+	r := rand.New(rand.NewSource(start.UnixNano()))
+	return func(now time.Time) time.Duration {
+		step := c.emulationStep(now.Sub(start), total)
+		if step.Jitter <= 0 {
+			return step.Delay
+		}
+		return step.Delay + time.Duration(r.Int63n(int64(step.Jitter)+1))
+	}
+}
+
+// emulatedLoss returns a function that decides if a packet is dropped, for a profile started at start.
+// It returns nil if the EmulationProfile doesn't drop packets.
+// The returned function is not safe for concurrent use.
+func (c *Config) emulatedLoss(start time.Time) func(time.Time) bool {
+	var total time.Duration
+	var drops bool
+	for _, step := range c.EmulationProfile {
+		total += step.Duration
+		drops = drops || step.Loss > 0
+	}
+	if !drops {
+		return nil
+	}
+	r := rand.New(rand.NewSource(start.UnixNano() + 1))
+	return func(now time.Time) bool {
+		return r.Float64() < c.emulationStep(now.Sub(start), total).Loss
+	}
+}
+
+// emulatedRates returns the bandwidth profile of the EmulationProfile.
+func (c *Config) emulatedRates() []congestion.EmulationStep {
+	if len(c.EmulationProfile) == 0 {
+		return nil
+	}
+	steps := make([]congestion.EmulationStep, 0, len(c.EmulationProfile))
+	for _, step := range c.EmulationProfile {
+		steps = append(steps, congestion.EmulationStep{
+			Duration: step.Duration,
+			Rate:     congestion.Bandwidth(step.Bandwidth) * congestion.BytesPerSecond,
+		})
+	}
+	return steps
+}
+
 func validateConfig(config *Config) error {
 	if config == nil {
 		return nil
 	}
+	for _, step := range config.EmulationProfile {
+		if step.Duration <= 0 || step.Delay < 0 || step.Jitter < 0 || step.Loss < 0 || step.Loss > 1 {
+			return errors.New("invalid value for Config.EmulationProfile")
+		}
+	}
+	for i, size := range config.PaddingProfile {
+		if size <= 0 || (i > 0 && size <= config.PaddingProfile[i-1]) {
+			return errors.New("invalid value for Config.PaddingProfile")
+		}
+	}
 	if config.MaxIncomingStreams > 1<<60 {
 		return errors.New("invalid value for Config.MaxIncomingStreams")
 	}
 	if config.MaxIncomingUniStreams > 1<<60 {
 		return errors.New("invalid value for Config.MaxIncomingUniStreams")
 	}
+	if config.HandshakeTimeout < 0 {
+		return errors.New("invalid value for Config.HandshakeTimeout")
+	}
+	if config.FirstFlightTimeout < 0 {
+		return errors.New("invalid value for Config.FirstFlightTimeout")
+	}
+	if config.KeepAlivePeriod < 0 {
+		return errors.New("invalid value for Config.KeepAlivePeriod")
+	}
+	if config.RTTProbeInterval < 0 {
+		return errors.New("invalid value for Config.RTTProbeInterval")
+	}
+	if config.MaxCongestionWindow < 0 {
+		return errors.New("invalid value for Config.MaxCongestionWindow")
+	}
+	maxCongestionWindow := protocol.MaxCongestionWindowPackets
+	if config.MaxCongestionWindow > 0 {
+		maxCongestionWindow = config.MaxCongestionWindow
+	}
+	if config.MinCongestionWindow < 0 || config.MinCongestionWindow > maxCongestionWindow {
+		return errors.New("invalid value for Config.MinCongestionWindow")
+	}
+	if config.InitialCongestionWindow < 0 || config.InitialCongestionWindow > maxCongestionWindow {
+		return errors.New("invalid value for Config.InitialCongestionWindow")
+	}
+	if config.PacketNumberLength < 0 || config.PacketNumberLength > 4 {
+		return errors.New("invalid value for Config.PacketNumberLength")
+	}
+	if config.PacketNumberSkipPeriod > 1<<62 {
+		return errors.New("invalid value for Config.PacketNumberSkipPeriod")
+	}
+	if config.MaxAckRanges < 0 {
+		return errors.New("invalid value for Config.MaxAckRanges")
+	}
+	if config.MaxAckFrameSize < 0 || config.MaxAckFrameSize > int(protocol.MaxAckFrameSize) {
+		return errors.New("invalid value for Config.MaxAckFrameSize")
+	}
+	if config.AckRangeEviction > AckRangeEvictionSmallest {
+		return errors.New("invalid value for Config.AckRangeEviction")
+	}
+	if config.AckThinning != nil && !ackhandler.ResearchHooksEnabled {
+		return errors.New("Config.AckThinning requires building with the quic_research build tag")
+	}
+	if config.HyStartRTTSamples < 0 || config.HyStartLowWindow < 0 || config.HyStartCSSGrowthDivisor < 0 {
+		return errors.New("invalid HyStart parameters")
+	}
+	if config.HyStartMinRTTThreshold < 0 || config.HyStartMaxRTTThreshold < 0 ||
+		(config.HyStartMaxRTTThreshold > 0 && config.HyStartMinRTTThreshold > config.HyStartMaxRTTThreshold) {
+		return errors.New("invalid HyStart RTT thresholds")
+	}
+	if config.RenoBeta < 0 || config.RenoBeta >= 1 {
+		return errors.New("invalid value for Config.RenoBeta")
+	}
+	if config.CubicC < 0 || config.CubicBeta < 0 || config.CubicBeta >= 1 {
+		return errors.New("invalid Cubic parameters")
+	}
+	if config.CongestionWindowValidationPeriod < 0 {
+		return errors.New("invalid value for Config.CongestionWindowValidationPeriod")
+	}
+	if config.MinRTTExpiry < 0 {
+		return errors.New("invalid value for Config.MinRTTExpiry")
+	}
+	if config.MinRTTProbeDuration < 0 {
+		return errors.New("invalid value for Config.MinRTTProbeDuration")
+	}
+	for _, aging := range config.MinRTTAgingPerStartAlgo {
+		if aging.Expiry < 0 || aging.ProbeDuration < 0 {
+			return errors.New("invalid value for Config.MinRTTAgingPerStartAlgo")
+		}
+	}
+	if config.RTTFilter > RTTFilterKalman {
+		return errors.New("invalid value for Config.RTTFilter")
+	}
+	if config.RTTFilterWindow < 0 {
+		return errors.New("invalid value for Config.RTTFilterWindow")
+	}
+	if config.PacerMaxBurst < 0 {
+		return errors.New("invalid value for Config.PacerMaxBurst")
+	}
+	if config.PacingGain < 0 {
+		return errors.New("invalid value for Config.PacingGain")
+	}
+	if config.PacingGranularity < 0 {
+		return errors.New("invalid value for Config.PacingGranularity")
+	}
+	if config.MaxSessions < 0 {
+		return errors.New("invalid value for Config.MaxSessions")
+	}
+	if config.DeliveryRateReportInterval != 0 &&
+		(config.DeliveryRateReportInterval < protocol.MinDeliveryRateReportInterval || config.DeliveryRateReportInterval > protocol.MaxDeliveryRateReportInterval) {
+		return errors.New("invalid value for Config.DeliveryRateReportInterval")
+	}
+	if config.CongestionMetricsInterval < 0 {
+		return errors.New("invalid value for Config.CongestionMetricsInterval")
+	}
 	return nil
 }
 
@@ -79,6 +282,11 @@ func populateConfig(config *Config) *Config {
 	if maxStreamReceiveWindow == 0 {
 		maxStreamReceiveWindow = protocol.DefaultMaxReceiveStreamFlowControlWindow
 	}
+	if config.StreamReceiveBufferSize > 0 {
+		// the receive buffer must be able to hold all data the peer is allowed to send
+		initialStreamReceiveWindow = utils.MinUint64(initialStreamReceiveWindow, config.StreamReceiveBufferSize)
+		maxStreamReceiveWindow = utils.MinUint64(maxStreamReceiveWindow, config.StreamReceiveBufferSize)
+	}
 	initialConnectionReceiveWindow := config.InitialConnectionReceiveWindow
 	if initialConnectionReceiveWindow == 0 {
 		initialConnectionReceiveWindow = protocol.DefaultInitialMaxData
@@ -103,11 +311,17 @@ func populateConfig(config *Config) *Config {
 	return &Config{
 		Versions:                         versions,
 		HandshakeIdleTimeout:             handshakeIdleTimeout,
+		HandshakeTimeout:                 config.HandshakeTimeout,
+		FirstFlightTimeout:               config.FirstFlightTimeout,
+		EnableBlackholeDetection:         config.EnableBlackholeDetection,
 		MaxIdleTimeout:                   idleTimeout,
 		AcceptToken:                      config.AcceptToken,
 		KeepAlive:                        config.KeepAlive,
+		KeepAlivePeriod:                  config.KeepAlivePeriod,
+		RTTProbeInterval:                 config.RTTProbeInterval,
 		InitialStreamReceiveWindow:       initialStreamReceiveWindow,
 		MaxStreamReceiveWindow:           maxStreamReceiveWindow,
+		StreamReceiveBufferSize:          config.StreamReceiveBufferSize,
 		InitialConnectionReceiveWindow:   initialConnectionReceiveWindow,
 		MaxConnectionReceiveWindow:       maxConnectionReceiveWindow,
 		MaxIncomingStreams:               maxIncomingStreams,
@@ -115,9 +329,62 @@ func populateConfig(config *Config) *Config {
 		ConnectionIDLength:               config.ConnectionIDLength,
 		StatelessResetKey:                config.StatelessResetKey,
 		TokenStore:                       config.TokenStore,
+		CongestionStateStore:             config.CongestionStateStore,
+		InitialCongestionWindow:          config.InitialCongestionWindow,
+		MaxCongestionWindow:              config.MaxCongestionWindow,
+		MinCongestionWindow:              config.MinCongestionWindow,
+		PacketNumberLength:               config.PacketNumberLength,
+		DisablePacketNumberSkipping:      config.DisablePacketNumberSkipping,
+		PacketNumberSkipPeriod:           config.PacketNumberSkipPeriod,
+		MaxAckRanges:                     config.MaxAckRanges,
+		MaxAckFrameSize:                  config.MaxAckFrameSize,
+		AckRangeEviction:                 config.AckRangeEviction,
+		AckThinning:                      config.AckThinning,
+		HyStartRTTSamples:                config.HyStartRTTSamples,
+		HyStartMinRTTThreshold:           config.HyStartMinRTTThreshold,
+		HyStartMaxRTTThreshold:           config.HyStartMaxRTTThreshold,
+		HyStartLowWindow:                 config.HyStartLowWindow,
+		HyStartCSSGrowthDivisor:          config.HyStartCSSGrowthDivisor,
+		RenoBeta:                         config.RenoBeta,
+		CubicC:                           config.CubicC,
+		CubicBeta:                        config.CubicBeta,
+		DisableCubicTCPFriendliness:      config.DisableCubicTCPFriendliness,
+		EnableCongestionWindowValidation: config.EnableCongestionWindowValidation,
+		CongestionWindowValidationPeriod: config.CongestionWindowValidationPeriod,
+		EnableLossDifferentiation:        config.EnableLossDifferentiation,
+		MinRTTExpiry:                     config.MinRTTExpiry,
+		MinRTTProbeDuration:              config.MinRTTProbeDuration,
+		DisableMinRTTProbeDip:            config.DisableMinRTTProbeDip,
+		MinRTTAgingPerStartAlgo:          config.MinRTTAgingPerStartAlgo,
+		RTTFilter:                        config.RTTFilter,
+		RTTFilterWindow:                  config.RTTFilterWindow,
+		PacerMaxBurst:                    config.PacerMaxBurst,
+		PacingGain:                       config.PacingGain,
+		PacingGranularity:                config.PacingGranularity,
+		DisablePacing:                    config.DisablePacing,
+		EnableKernelPacing:               config.EnableKernelPacing,
+		MaxSendRate:                      config.MaxSendRate,
+		MaxReceiveRate:                   config.MaxReceiveRate,
+		OnRTTUpdate:                      config.OnRTTUpdate,
+		OnCongestionEvent:                config.OnCongestionEvent,
+		CongestionControl:                config.CongestionControl,
+		OnClose:                          config.OnClose,
+		SuspendThreshold:                 config.SuspendThreshold,
+		ProbeAfterSuspend:                config.ProbeAfterSuspend,
+		EmulationProfile:                 config.EmulationProfile,
+		MaxSessions:                      config.MaxSessions,
+		SessionRunLoopAffinity:           config.SessionRunLoopAffinity,
+		HandshakeSigner:                  config.HandshakeSigner,
+		SessionTicketKeys:                config.SessionTicketKeys,
+		GetRoute:                         config.GetRoute,
+		PaddingProfile:                   config.PaddingProfile,
+		EnableStreamChecksums:            config.EnableStreamChecksums,
 		EnableDatagrams:                  config.EnableDatagrams,
+		EnableAddressDiscovery:           config.EnableAddressDiscovery,
+		DeliveryRateReportInterval:       config.DeliveryRateReportInterval,
 		DisablePathMTUDiscovery:          config.DisablePathMTUDiscovery,
 		DisableVersionNegotiationPackets: config.DisableVersionNegotiationPackets,
 		Tracer:                           config.Tracer,
+		CongestionMetricsInterval:        config.CongestionMetricsInterval,
 	}
 }
@@ -6,8 +6,11 @@ import (
 	"reflect"
 	"time"
 
+	"github.com/lucas-clemente/quic-go/internal/ackhandler"
+	"github.com/lucas-clemente/quic-go/internal/congestion"
 	mocklogging "github.com/lucas-clemente/quic-go/internal/mocks/logging"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -30,6 +33,142 @@ var _ = Describe("Config", func() {
 		It("errors on too large values for MaxIncomingUniStreams", func() {
 			Expect(validateConfig(&Config{MaxIncomingUniStreams: 1<<60 + 1})).To(MatchError("invalid value for Config.MaxIncomingUniStreams"))
 		})
+
+		It("errors on negative keep-alive periods", func() {
+			Expect(validateConfig(&Config{KeepAlivePeriod: -time.Second})).To(MatchError("invalid value for Config.KeepAlivePeriod"))
+		})
+
+		It("errors on negative RTT probe intervals", func() {
+			Expect(validateConfig(&Config{RTTProbeInterval: -time.Second})).To(MatchError("invalid value for Config.RTTProbeInterval"))
+		})
+
+		It("errors on invalid initial congestion windows", func() {
+			Expect(validateConfig(&Config{InitialCongestionWindow: -1})).To(MatchError("invalid value for Config.InitialCongestionWindow"))
+			Expect(validateConfig(&Config{InitialCongestionWindow: protocol.MaxCongestionWindowPackets + 1})).To(MatchError("invalid value for Config.InitialCongestionWindow"))
+			Expect(validateConfig(&Config{InitialCongestionWindow: 10})).To(Succeed())
+			Expect(validateConfig(&Config{InitialCongestionWindow: 100, MaxCongestionWindow: 50})).To(MatchError("invalid value for Config.InitialCongestionWindow"))
+			Expect(validateConfig(&Config{InitialCongestionWindow: 2 * protocol.MaxCongestionWindowPackets, MaxCongestionWindow: 3 * protocol.MaxCongestionWindowPackets})).To(Succeed())
+		})
+
+		It("validates the congestion window limits", func() {
+			Expect(validateConfig(&Config{MaxCongestionWindow: -1})).To(MatchError("invalid value for Config.MaxCongestionWindow"))
+			Expect(validateConfig(&Config{MinCongestionWindow: -1})).To(MatchError("invalid value for Config.MinCongestionWindow"))
+			Expect(validateConfig(&Config{MinCongestionWindow: 20, MaxCongestionWindow: 10})).To(MatchError("invalid value for Config.MinCongestionWindow"))
+			Expect(validateConfig(&Config{MinCongestionWindow: protocol.MaxCongestionWindowPackets + 1})).To(MatchError("invalid value for Config.MinCongestionWindow"))
+			Expect(validateConfig(&Config{MinCongestionWindow: 4, MaxCongestionWindow: 100})).To(Succeed())
+		})
+
+		It("validates the emulation profile", func() {
+			Expect(validateConfig(&Config{EmulationProfile: []EmulationStep{{Duration: time.Second, Delay: time.Millisecond}}})).To(Succeed())
+			Expect(validateConfig(&Config{EmulationProfile: []EmulationStep{{Duration: 0}}})).To(MatchError("invalid value for Config.EmulationProfile"))
+			Expect(validateConfig(&Config{EmulationProfile: []EmulationStep{{Duration: time.Second, Delay: -time.Millisecond}}})).To(MatchError("invalid value for Config.EmulationProfile"))
+			Expect(validateConfig(&Config{EmulationProfile: []EmulationStep{{Duration: time.Second, Jitter: -time.Millisecond}}})).To(MatchError("invalid value for Config.EmulationProfile"))
+			Expect(validateConfig(&Config{EmulationProfile: []EmulationStep{{Duration: time.Second, Loss: 1.5}}})).To(MatchError("invalid value for Config.EmulationProfile"))
+		})
+
+		It("validates the padding profile", func() {
+			Expect(validateConfig(&Config{PaddingProfile: []int{200, 600, 1200}})).To(Succeed())
+			Expect(validateConfig(&Config{PaddingProfile: []int{0}})).To(MatchError("invalid value for Config.PaddingProfile"))
+			Expect(validateConfig(&Config{PaddingProfile: []int{600, 200}})).To(MatchError("invalid value for Config.PaddingProfile"))
+			Expect(validateConfig(&Config{PaddingProfile: []int{600, 600}})).To(MatchError("invalid value for Config.PaddingProfile"))
+		})
+
+		It("errors on invalid packet number lengths", func() {
+			Expect(validateConfig(&Config{PacketNumberLength: 5})).To(MatchError("invalid value for Config.PacketNumberLength"))
+			Expect(validateConfig(&Config{PacketNumberLength: -1})).To(MatchError("invalid value for Config.PacketNumberLength"))
+		})
+
+		It("errors on invalid ACK range limits", func() {
+			Expect(validateConfig(&Config{MaxAckRanges: -1})).To(MatchError("invalid value for Config.MaxAckRanges"))
+			Expect(validateConfig(&Config{MaxAckFrameSize: -1})).To(MatchError("invalid value for Config.MaxAckFrameSize"))
+			Expect(validateConfig(&Config{MaxAckFrameSize: int(protocol.MaxAckFrameSize) + 1})).To(MatchError("invalid value for Config.MaxAckFrameSize"))
+			Expect(validateConfig(&Config{AckRangeEviction: AckRangeEvictionSmallest + 1})).To(MatchError("invalid value for Config.AckRangeEviction"))
+			Expect(validateConfig(&Config{MaxAckRanges: 100, MaxAckFrameSize: 200, AckRangeEviction: AckRangeEvictionSmallest})).To(Succeed())
+		})
+
+		It("only accepts an ACK thinning strategy when built with the research build tag", func() {
+			err := validateConfig(&Config{AckThinning: func(AckThinningInfo) bool { return true }})
+			if ackhandler.ResearchHooksEnabled {
+				Expect(err).ToNot(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError("Config.AckThinning requires building with the quic_research build tag"))
+			}
+		})
+
+		It("errors on invalid delivery rate report intervals", func() {
+			Expect(validateConfig(&Config{DeliveryRateReportInterval: -time.Second})).To(MatchError("invalid value for Config.DeliveryRateReportInterval"))
+			Expect(validateConfig(&Config{DeliveryRateReportInterval: time.Microsecond})).To(MatchError("invalid value for Config.DeliveryRateReportInterval"))
+			Expect(validateConfig(&Config{DeliveryRateReportInterval: time.Hour})).To(MatchError("invalid value for Config.DeliveryRateReportInterval"))
+			Expect(validateConfig(&Config{DeliveryRateReportInterval: 25 * time.Millisecond})).To(Succeed())
+		})
+
+		It("errors on a negative congestion metrics interval", func() {
+			Expect(validateConfig(&Config{CongestionMetricsInterval: -time.Second})).To(MatchError("invalid value for Config.CongestionMetricsInterval"))
+			Expect(validateConfig(&Config{CongestionMetricsInterval: 100 * time.Millisecond})).To(Succeed())
+		})
+
+		It("errors on invalid Reno and Cubic parameters", func() {
+			Expect(validateConfig(&Config{RenoBeta: -0.1})).To(MatchError("invalid value for Config.RenoBeta"))
+			Expect(validateConfig(&Config{RenoBeta: 1})).To(MatchError("invalid value for Config.RenoBeta"))
+			Expect(validateConfig(&Config{CubicC: -1})).To(MatchError("invalid Cubic parameters"))
+			Expect(validateConfig(&Config{CubicBeta: -0.1})).To(MatchError("invalid Cubic parameters"))
+			Expect(validateConfig(&Config{CubicBeta: 1.5})).To(MatchError("invalid Cubic parameters"))
+			Expect(validateConfig(&Config{RenoBeta: 0.5, CubicC: 0.8, CubicBeta: 0.8})).To(Succeed())
+		})
+
+		It("errors on invalid handshake timeouts", func() {
+			Expect(validateConfig(&Config{HandshakeTimeout: -time.Second})).To(MatchError("invalid value for Config.HandshakeTimeout"))
+			Expect(validateConfig(&Config{FirstFlightTimeout: -time.Second})).To(MatchError("invalid value for Config.FirstFlightTimeout"))
+			Expect(validateConfig(&Config{HandshakeTimeout: time.Minute, FirstFlightTimeout: time.Second})).To(Succeed())
+		})
+
+		It("errors on an invalid Congestion Window Validation period", func() {
+			Expect(validateConfig(&Config{CongestionWindowValidationPeriod: -time.Second})).To(MatchError("invalid value for Config.CongestionWindowValidationPeriod"))
+			Expect(validateConfig(&Config{EnableCongestionWindowValidation: true, CongestionWindowValidationPeriod: time.Minute})).To(Succeed())
+		})
+
+		It("errors on invalid HyStart parameters", func() {
+			Expect(validateConfig(&Config{HyStartRTTSamples: -1})).To(MatchError("invalid HyStart parameters"))
+			Expect(validateConfig(&Config{HyStartCSSGrowthDivisor: -1})).To(MatchError("invalid HyStart parameters"))
+			Expect(validateConfig(&Config{HyStartMinRTTThreshold: -time.Millisecond})).To(MatchError("invalid HyStart RTT thresholds"))
+			Expect(validateConfig(&Config{HyStartMinRTTThreshold: 10 * time.Millisecond, HyStartMaxRTTThreshold: 5 * time.Millisecond})).To(MatchError("invalid HyStart RTT thresholds"))
+			Expect(validateConfig(&Config{HyStartMinRTTThreshold: 10 * time.Millisecond})).To(Succeed())
+		})
+
+		It("errors on a negative min RTT expiry", func() {
+			Expect(validateConfig(&Config{MinRTTExpiry: -time.Second})).To(MatchError("invalid value for Config.MinRTTExpiry"))
+			Expect(validateConfig(&Config{MinRTTExpiry: 10 * time.Second})).To(Succeed())
+		})
+
+		It("errors on invalid min RTT aging configurations", func() {
+			Expect(validateConfig(&Config{MinRTTProbeDuration: -time.Second})).To(MatchError("invalid value for Config.MinRTTProbeDuration"))
+			Expect(validateConfig(&Config{
+				MinRTTAgingPerStartAlgo: map[utils.StartAlgo]MinRTTAging{utils.ChooseHystartpp: {Expiry: -time.Second}},
+			})).To(MatchError("invalid value for Config.MinRTTAgingPerStartAlgo"))
+			Expect(validateConfig(&Config{
+				MinRTTAgingPerStartAlgo: map[utils.StartAlgo]MinRTTAging{utils.ChooseHystartpp: {ProbeDuration: -time.Second}},
+			})).To(MatchError("invalid value for Config.MinRTTAgingPerStartAlgo"))
+			Expect(validateConfig(&Config{
+				MinRTTAgingPerStartAlgo: map[utils.StartAlgo]MinRTTAging{utils.ChooseHystartpp: {Expiry: time.Second, ProbeDuration: time.Second}},
+			})).To(Succeed())
+		})
+
+		It("errors on invalid RTT filters", func() {
+			Expect(validateConfig(&Config{RTTFilter: RTTFilterKalman + 1})).To(MatchError("invalid value for Config.RTTFilter"))
+			Expect(validateConfig(&Config{RTTFilterWindow: -1})).To(MatchError("invalid value for Config.RTTFilterWindow"))
+			Expect(validateConfig(&Config{RTTFilter: RTTFilterMedian, RTTFilterWindow: 9})).To(Succeed())
+		})
+
+		It("errors on invalid pacer parameters", func() {
+			Expect(validateConfig(&Config{PacerMaxBurst: -1})).To(MatchError("invalid value for Config.PacerMaxBurst"))
+			Expect(validateConfig(&Config{PacingGain: -0.5})).To(MatchError("invalid value for Config.PacingGain"))
+			Expect(validateConfig(&Config{PacingGranularity: -time.Millisecond})).To(MatchError("invalid value for Config.PacingGranularity"))
+			Expect(validateConfig(&Config{PacerMaxBurst: 2, PacingGain: 1, PacingGranularity: 5 * time.Millisecond})).To(Succeed())
+		})
+
+		It("errors on a negative maximum number of sessions", func() {
+			Expect(validateConfig(&Config{MaxSessions: -1})).To(MatchError("invalid value for Config.MaxSessions"))
+		})
 	})
 
 	configWithNonZeroNonFunctionFields := func() *Config {
@@ -45,7 +184,7 @@ var _ = Describe("Config", func() {
 			}
 
 			switch fn := typ.Field(i).Name; fn {
-			case "AcceptToken", "GetLogWriter":
+			case "AcceptToken", "GetLogWriter", "SessionRunLoopAffinity", "GetRoute", "AckThinning", "OnRTTUpdate", "OnCongestionEvent", "OnClose", "CongestionControl":
 				// Can't compare functions.
 			case "Versions":
 				f.Set(reflect.ValueOf([]VersionNumber{1, 2, 3}))
@@ -57,10 +196,96 @@ var _ = Describe("Config", func() {
 				f.Set(reflect.ValueOf(time.Hour))
 			case "TokenStore":
 				f.Set(reflect.ValueOf(NewLRUTokenStore(2, 3)))
+			case "CongestionStateStore":
+				f.Set(reflect.ValueOf(NewLRUCongestionStateStore(4)))
+			case "InitialCongestionWindow":
+				f.Set(reflect.ValueOf(10))
+			case "MaxCongestionWindow":
+				f.Set(reflect.ValueOf(1000))
+			case "MinCongestionWindow":
+				f.Set(reflect.ValueOf(4))
+			case "PacketNumberLength":
+				f.Set(reflect.ValueOf(2))
+			case "DisablePacketNumberSkipping":
+				f.Set(reflect.ValueOf(true))
+			case "PacketNumberSkipPeriod":
+				f.Set(reflect.ValueOf(uint64(13)))
+			case "MaxAckRanges":
+				f.Set(reflect.ValueOf(64))
+			case "MaxAckFrameSize":
+				f.Set(reflect.ValueOf(500))
+			case "AckRangeEviction":
+				f.Set(reflect.ValueOf(AckRangeEvictionSmallest))
+			case "HyStartRTTSamples":
+				f.Set(reflect.ValueOf(5))
+			case "HyStartMinRTTThreshold":
+				f.Set(reflect.ValueOf(2 * time.Millisecond))
+			case "HyStartMaxRTTThreshold":
+				f.Set(reflect.ValueOf(50 * time.Millisecond))
+			case "HyStartLowWindow":
+				f.Set(reflect.ValueOf(20))
+			case "HyStartCSSGrowthDivisor":
+				f.Set(reflect.ValueOf(2))
+			case "RenoBeta":
+				f.Set(reflect.ValueOf(0.5))
+			case "CubicC":
+				f.Set(reflect.ValueOf(0.8))
+			case "CubicBeta":
+				f.Set(reflect.ValueOf(0.6))
+			case "DisableCubicTCPFriendliness":
+				f.Set(reflect.ValueOf(true))
+			case "EnableCongestionWindowValidation":
+				f.Set(reflect.ValueOf(true))
+			case "EnableLossDifferentiation":
+				f.Set(reflect.ValueOf(true))
+			case "MinRTTExpiry":
+				f.Set(reflect.ValueOf(10 * time.Second))
+			case "MinRTTProbeDuration":
+				f.Set(reflect.ValueOf(time.Second))
+			case "DisableMinRTTProbeDip":
+				f.Set(reflect.ValueOf(true))
+			case "MinRTTAgingPerStartAlgo":
+				f.Set(reflect.ValueOf(map[utils.StartAlgo]MinRTTAging{utils.ChooseHystartpp: {Expiry: time.Minute}}))
+			case "RTTFilter":
+				f.Set(reflect.ValueOf(RTTFilterMedian))
+			case "RTTFilterWindow":
+				f.Set(reflect.ValueOf(7))
+			case "PacerMaxBurst":
+				f.Set(reflect.ValueOf(3))
+			case "PacingGain":
+				f.Set(reflect.ValueOf(1.5))
+			case "PacingGranularity":
+				f.Set(reflect.ValueOf(5 * time.Millisecond))
+			case "DisablePacing":
+				f.Set(reflect.ValueOf(true))
+			case "EnableKernelPacing":
+				f.Set(reflect.ValueOf(true))
+			case "MaxSendRate":
+				f.Set(reflect.ValueOf(uint64(1 << 20)))
+			case "MaxReceiveRate":
+				f.Set(reflect.ValueOf(uint64(1 << 19)))
+			case "EmulationProfile":
+				f.Set(reflect.ValueOf([]EmulationStep{{Duration: time.Second, Bandwidth: 1 << 20, Delay: 20 * time.Millisecond}}))
+			case "HandshakeTimeout":
+				f.Set(reflect.ValueOf(time.Minute))
+			case "FirstFlightTimeout":
+				f.Set(reflect.ValueOf(3 * time.Second))
+			case "EnableBlackholeDetection":
+				f.Set(reflect.ValueOf(true))
+			case "CongestionWindowValidationPeriod":
+				f.Set(reflect.ValueOf(2 * time.Second))
+			case "MaxSessions":
+				f.Set(reflect.ValueOf(3))
+			case "HandshakeSigner":
+				f.Set(reflect.ValueOf(NewHandshakeSigner(nil, 1, 1, time.Second)))
+			case "SessionTicketKeys":
+				f.Set(reflect.ValueOf(NewSessionTicketKeys([32]byte{1})))
 			case "InitialStreamReceiveWindow":
 				f.Set(reflect.ValueOf(uint64(1234)))
 			case "MaxStreamReceiveWindow":
 				f.Set(reflect.ValueOf(uint64(9)))
+			case "StreamReceiveBufferSize":
+				f.Set(reflect.ValueOf(uint64(1 << 20)))
 			case "InitialConnectionReceiveWindow":
 				f.Set(reflect.ValueOf(uint64(4321)))
 			case "MaxConnectionReceiveWindow":
@@ -73,14 +298,32 @@ var _ = Describe("Config", func() {
 				f.Set(reflect.ValueOf([]byte{1, 2, 3, 4}))
 			case "KeepAlive":
 				f.Set(reflect.ValueOf(true))
+			case "KeepAlivePeriod":
+				f.Set(reflect.ValueOf(5 * time.Second))
+			case "RTTProbeInterval":
+				f.Set(reflect.ValueOf(time.Second))
+			case "PaddingProfile":
+				f.Set(reflect.ValueOf([]int{500, 1000}))
+			case "EnableStreamChecksums":
+				f.Set(reflect.ValueOf(true))
 			case "EnableDatagrams":
 				f.Set(reflect.ValueOf(true))
+			case "EnableAddressDiscovery":
+				f.Set(reflect.ValueOf(true))
+			case "DeliveryRateReportInterval":
+				f.Set(reflect.ValueOf(25 * time.Millisecond))
 			case "DisableVersionNegotiationPackets":
 				f.Set(reflect.ValueOf(true))
 			case "DisablePathMTUDiscovery":
 				f.Set(reflect.ValueOf(true))
 			case "Tracer":
 				f.Set(reflect.ValueOf(mocklogging.NewMockTracer(mockCtrl)))
+			case "SuspendThreshold":
+				f.Set(reflect.ValueOf(time.Minute))
+			case "ProbeAfterSuspend":
+				f.Set(reflect.ValueOf(true))
+			case "CongestionMetricsInterval":
+				f.Set(reflect.ValueOf(100 * time.Millisecond))
 			default:
 				Fail(fmt.Sprintf("all fields must be accounted for, but saw unknown field %q", fn))
 			}
@@ -98,6 +341,85 @@ var _ = Describe("Config", func() {
 		Expect(c.handshakeTimeout()).To(Equal(11 * time.Second))
 	})
 
+	It("uses the configured handshake timeout", func() {
+		c := &Config{HandshakeIdleTimeout: time.Minute, HandshakeTimeout: time.Second}
+		Expect(c.handshakeTimeout()).To(Equal(time.Second))
+	})
+
+	It("uses the default suspend threshold", func() {
+		Expect((&Config{}).suspendThreshold()).To(Equal(protocol.DefaultSuspendThreshold))
+		Expect((&Config{SuspendThreshold: time.Minute}).suspendThreshold()).To(Equal(time.Minute))
+		Expect((&Config{SuspendThreshold: -1}).suspendThreshold()).To(BeZero())
+	})
+
+	It("overrides the min RTT aging for specific start algorithms", func() {
+		c := &Config{
+			MinRTTExpiry:        10 * time.Second,
+			MinRTTProbeDuration: time.Second,
+			MinRTTAgingPerStartAlgo: map[utils.StartAlgo]MinRTTAging{
+				utils.ChooseHystartpp: {Expiry: 3 * time.Second, DisableProbeDip: true},
+			},
+		}
+		Expect(c.minRTTAging(utils.ChooseHystart)).To(Equal(MinRTTAging{Expiry: 10 * time.Second, ProbeDuration: time.Second}))
+		Expect(c.minRTTAging(utils.ChooseHystartpp)).To(Equal(MinRTTAging{Expiry: 3 * time.Second, DisableProbeDip: true}))
+	})
+
+	It("returns the emulated delay", func() {
+		Expect((&Config{}).emulatedDelay(time.Now())).To(BeNil())
+		Expect((&Config{EmulationProfile: []EmulationStep{{Duration: time.Second, Bandwidth: 1000}}}).emulatedDelay(time.Now())).To(BeNil())
+		c := &Config{EmulationProfile: []EmulationStep{
+			{Duration: time.Second, Delay: 10 * time.Millisecond},
+			{Duration: 2 * time.Second, Delay: 50 * time.Millisecond},
+		}}
+		start := time.Now()
+		getDelay := c.emulatedDelay(start)
+		Expect(getDelay(start)).To(Equal(10 * time.Millisecond))
+		Expect(getDelay(start.Add(999 * time.Millisecond))).To(Equal(10 * time.Millisecond))
+		Expect(getDelay(start.Add(time.Second))).To(Equal(50 * time.Millisecond))
+		// the profile is repeated
+		Expect(getDelay(start.Add(3500 * time.Millisecond))).To(Equal(10 * time.Millisecond))
+		Expect(getDelay(start.Add(5 * time.Second))).To(Equal(50 * time.Millisecond))
+	})
+
+	It("adds jitter to the emulated delay", func() {
+		c := &Config{EmulationProfile: []EmulationStep{{Duration: time.Second, Delay: 10 * time.Millisecond, Jitter: 5 * time.Millisecond}}}
+		start := time.Now()
+		getDelay := c.emulatedDelay(start)
+		var sawJitter bool
+		for i := 0; i < 100; i++ {
+			d := getDelay(start)
+			Expect(d).To(And(BeNumerically(">=", 10*time.Millisecond), BeNumerically("<=", 15*time.Millisecond)))
+			sawJitter = sawJitter || d != 10*time.Millisecond
+		}
+		Expect(sawJitter).To(BeTrue())
+	})
+
+	It("returns the emulated loss", func() {
+		Expect((&Config{}).emulatedLoss(time.Now())).To(BeNil())
+		Expect((&Config{EmulationProfile: []EmulationStep{{Duration: time.Second, Delay: time.Millisecond}}}).emulatedLoss(time.Now())).To(BeNil())
+		c := &Config{EmulationProfile: []EmulationStep{
+			{Duration: time.Second, Loss: 1},
+			{Duration: time.Second},
+		}}
+		start := time.Now()
+		dropPacket := c.emulatedLoss(start)
+		Expect(dropPacket(start)).To(BeTrue())
+		Expect(dropPacket(start.Add(1500 * time.Millisecond))).To(BeFalse())
+		Expect(dropPacket(start.Add(2500 * time.Millisecond))).To(BeTrue())
+	})
+
+	It("returns the emulated rates", func() {
+		Expect((&Config{}).emulatedRates()).To(BeEmpty())
+		c := &Config{EmulationProfile: []EmulationStep{
+			{Duration: time.Second, Bandwidth: 1000, Delay: 10 * time.Millisecond},
+			{Duration: 2 * time.Second},
+		}}
+		Expect(c.emulatedRates()).To(Equal([]congestion.EmulationStep{
+			{Duration: time.Second, Rate: 1000 * congestion.BytesPerSecond},
+			{Duration: 2 * time.Second},
+		}))
+	})
+
 	Context("cloning", func() {
 		It("clones function fields", func() {
 			var calledAcceptToken bool
@@ -139,6 +461,21 @@ var _ = Describe("Config", func() {
 			Expect(calledAcceptToken).To(BeTrue())
 		})
 
+		It("populates the callbacks", func() {
+			var calledRTTUpdate, calledCongestionEvent, calledClose bool
+			c := populateConfig(&Config{
+				OnRTTUpdate:       func(_, _, _ time.Duration) { calledRTTUpdate = true },
+				OnCongestionEvent: func(CongestionEvent, uint64) { calledCongestionEvent = true },
+				OnClose:           func(ConnectionSummary) { calledClose = true },
+			})
+			c.OnRTTUpdate(time.Second, time.Second, time.Second)
+			c.OnCongestionEvent(CongestionEventLoss, 1000)
+			c.OnClose(ConnectionSummary{})
+			Expect(calledRTTUpdate).To(BeTrue())
+			Expect(calledCongestionEvent).To(BeTrue())
+			Expect(calledClose).To(BeTrue())
+		})
+
 		It("copies non-function fields", func() {
 			c := configWithNonZeroNonFunctionFields()
 			Expect(populateConfig(c)).To(Equal(c))
@@ -158,6 +495,15 @@ var _ = Describe("Config", func() {
 			Expect(c.DisablePathMTUDiscovery).To(BeFalse())
 		})
 
+		It("limits the stream flow control windows to the receive buffer size", func() {
+			c := populateConfig(&Config{StreamReceiveBufferSize: 1 << 20})
+			Expect(c.InitialStreamReceiveWindow).To(BeEquivalentTo(protocol.DefaultInitialMaxStreamData))
+			Expect(c.MaxStreamReceiveWindow).To(BeEquivalentTo(1 << 20))
+			c = populateConfig(&Config{StreamReceiveBufferSize: 1 << 10, MaxStreamReceiveWindow: 1 << 30})
+			Expect(c.InitialStreamReceiveWindow).To(BeEquivalentTo(1 << 10))
+			Expect(c.MaxStreamReceiveWindow).To(BeEquivalentTo(1 << 10))
+		})
+
 		It("populates empty fields with default values, for the server", func() {
 			c := populateServerConfig(&Config{})
 			Expect(c.ConnectionIDLength).To(Equal(protocol.DefaultConnectionIDLength))
@@ -0,0 +1,84 @@
+package quic
+
+import (
+	"container/list"
+	"encoding/hex"
+	"net"
+	"sync"
+)
+
+type lruCongestionStateStoreEntry struct {
+	key   string
+	state *CongestionState
+}
+
+type lruCongestionStateStore struct {
+	mutex sync.Mutex
+
+	m        map[string]*list.Element
+	q        *list.List
+	capacity int
+}
+
+var _ CongestionStateStore = &lruCongestionStateStore{}
+
+// NewLRUCongestionStateStore creates a new LRU cache for congestion states.
+// maxPeers specifies how many peers this cache is saving the congestion state for.
+func NewLRUCongestionStateStore(maxPeers int) CongestionStateStore {
+	return &lruCongestionStateStore{
+		m:        make(map[string]*list.Element),
+		q:        list.New(),
+		capacity: maxPeers,
+	}
+}
+
+func (s *lruCongestionStateStore) Put(key string, state *CongestionState) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if el, ok := s.m[key]; ok {
+		el.Value.(*lruCongestionStateStoreEntry).state = state
+		s.q.MoveToFront(el)
+		return
+	}
+
+	if s.q.Len() < s.capacity {
+		s.m[key] = s.q.PushFront(&lruCongestionStateStoreEntry{key: key, state: state})
+		return
+	}
+
+	elem := s.q.Back()
+	entry := elem.Value.(*lruCongestionStateStoreEntry)
+	delete(s.m, entry.key)
+	entry.key = key
+	entry.state = state
+	s.q.MoveToFront(elem)
+	s.m[key] = elem
+}
+
+func (s *lruCongestionStateStore) Get(key string) *CongestionState {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	el, ok := s.m[key]
+	if !ok {
+		return nil
+	}
+	s.q.MoveToFront(el)
+	return el.Value.(*lruCongestionStateStoreEntry).state
+}
+
+// clientCongestionStateKey is the key used by the server to save the congestion state of a client
+// that can be recognized by the client ID in its token.
+func clientCongestionStateKey(clientID []byte) string {
+	return "client:" + hex.EncodeToString(clientID)
+}
+
+// The congestion state is associated with the path, not with the server name.
+// We therefore use the IP address of the peer as the key.
+func congestionStateKey(addr net.Addr) string {
+	if udpAddr, ok := addr.(*net.UDPAddr); ok {
+		return udpAddr.IP.String()
+	}
+	return addr.String()
+}
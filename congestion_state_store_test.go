@@ -0,0 +1,53 @@
+package quic
+
+import (
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Congestion State Cache", func() {
+	var s CongestionStateStore
+
+	BeforeEach(func() {
+		s = NewLRUCongestionStateStore(2)
+	})
+
+	mockState := func(cwnd uint64) *CongestionState {
+		return &CongestionState{CongestionWindow: cwnd, MinRTT: time.Duration(cwnd) * time.Millisecond}
+	}
+
+	It("adds and gets states", func() {
+		Expect(s.Get("foo")).To(BeNil())
+		s.Put("foo", mockState(1))
+		Expect(s.Get("foo")).To(Equal(mockState(1)))
+		// states are not removed when they are retrieved
+		Expect(s.Get("foo")).To(Equal(mockState(1)))
+	})
+
+	It("overwrites states", func() {
+		s.Put("foo", mockState(1))
+		s.Put("foo", mockState(2))
+		Expect(s.Get("foo")).To(Equal(mockState(2)))
+	})
+
+	It("evicts the least recently used state", func() {
+		s.Put("foo", mockState(1))
+		s.Put("bar", mockState(2))
+		Expect(s.Get("foo")).To(Equal(mockState(1)))
+		s.Put("baz", mockState(3))
+		Expect(s.Get("foo")).To(Equal(mockState(1)))
+		Expect(s.Get("bar")).To(BeNil())
+		Expect(s.Get("baz")).To(Equal(mockState(3)))
+	})
+
+	It("uses the IP address as the key", func() {
+		Expect(congestionStateKey(&net.UDPAddr{IP: net.IPv4(192, 168, 0, 1), Port: 1234})).To(Equal("192.168.0.1"))
+	})
+
+	It("uses the client ID as the key for returning clients", func() {
+		Expect(clientCongestionStateKey([]byte{0xde, 0xad, 0xbe, 0xef})).To(Equal("client:deadbeef"))
+	})
+})
@@ -0,0 +1,69 @@
+// Package congestionext exposes the interfaces of quic-go's congestion control,
+// such that congestion controllers can be implemented outside of quic-go, and used via Config.CongestionControl.
+//
+// The types in this package are aliases of the types used internally.
+// They are kept stable: methods are only added to the interfaces together with a note in the changelog,
+// and existing methods are not changed.
+package congestionext
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/congestion"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+type (
+	// A ByteCount is used to count bytes.
+	ByteCount = protocol.ByteCount
+	// The PacketNumber is the packet number of a packet.
+	PacketNumber = protocol.PacketNumber
+	// The RTTStats contain the RTT estimates of a connection.
+	RTTStats = utils.RTTStats
+
+	// Bandwidth is a rate, in bits per second.
+	Bandwidth = congestion.Bandwidth
+	// A Clock returns the current time.
+	Clock = congestion.Clock
+	// DefaultClock implements the Clock interface using the Go stdlib clock.
+	DefaultClock = congestion.DefaultClock
+
+	// A SendAlgorithm performs congestion control.
+	SendAlgorithm = congestion.SendAlgorithm
+	// A SendAlgorithmWithDebugInfos is a SendAlgorithm that exposes some debug infos.
+	// This is the interface that a congestion controller needs to implement.
+	SendAlgorithmWithDebugInfos = congestion.SendAlgorithmWithDebugInfos
+	// A Pacer paces the packets sent by a congestion controller.
+	Pacer = congestion.Pacer
+
+	// An Event is a reaction of the congestion controller to the network.
+	Event = congestion.Event
+)
+
+const (
+	// BitsPerSecond is 1 bit per second
+	BitsPerSecond = congestion.BitsPerSecond
+	// BytesPerSecond is 1 byte per second
+	BytesPerSecond = congestion.BytesPerSecond
+)
+
+const (
+	// EventLoss means that the congestion window was reduced in response to a packet loss.
+	EventLoss = congestion.EventLoss
+	// EventPersistentCongestion means that the congestion window was reduced to the minimum congestion window.
+	EventPersistentCongestion = congestion.EventPersistentCongestion
+	// EventSlowStartExit means that slow start was exited before a loss occurred.
+	EventSlowStartExit = congestion.EventSlowStartExit
+)
+
+// BandwidthFromDelta calculates the bandwidth from a number of bytes and a time delta.
+func BandwidthFromDelta(bytes ByteCount, delta time.Duration) Bandwidth {
+	return congestion.BandwidthFromDelta(bytes, delta)
+}
+
+// NewPacer creates a token bucket pacer.
+// It paces packets at a rate slightly higher than the bandwidth estimate returned by getBandwidth.
+func NewPacer(getBandwidth func() Bandwidth) Pacer {
+	return congestion.NewPacer(getBandwidth)
+}
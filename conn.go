@@ -17,6 +17,14 @@ type connection interface {
 	io.Closer
 }
 
+// A txTimeConn is a connection that can hand packets to the kernel together with their transmit time.
+// The kernel (i.e. the fq qdisc) then holds back every packet until its transmit time.
+type txTimeConn interface {
+	// EnableTxTime enables transmit times on the socket. It returns false if the socket doesn't support them.
+	EnableTxTime() bool
+	WritePacketAt(b []byte, addr net.Addr, oob []byte, txTime time.Time) (int, error)
+}
+
 // If the PacketConn passed to Dial or Listen satisfies this interface, quic-go will read the ECN bits from the IP header.
 // In this case, ReadMsgUDP() will be used instead of ReadFrom() to read packets.
 type OOBCapablePacketConn interface {
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sync"
 	"syscall"
 	"time"
 
@@ -62,6 +63,10 @@ type oobConn struct {
 	// Packets received from the kernel, but not yet returned by ReadPacket().
 	messages []ipv4.Message
 	buffers  [batchSize]*packetBuffer
+
+	// SO_TXTIME is only supported on Linux, see conn_txtime_linux.go.
+	txTimeOnce    sync.Once
+	txTimeEnabled bool
 }
 
 var _ connection = &oobConn{}
@@ -0,0 +1,70 @@
+//go:build linux
+// +build linux
+
+package quic
+
+import (
+	"net"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+type sockTxtime struct {
+	// struct sock_txtime {
+	// 	__kernel_clockid_t clockid; /* reference clockid */
+	// 	__u32              flags;   /* as defined by enum txtime_flags */
+	// };
+	clockid int32
+	flags   uint32
+}
+
+var _ txTimeConn = &oobConn{}
+
+func (c *oobConn) EnableTxTime() bool {
+	c.txTimeOnce.Do(func() {
+		rawConn, err := c.SyscallConn()
+		if err != nil {
+			return
+		}
+		// The fq qdisc requires CLOCK_MONOTONIC.
+		cfg := sockTxtime{clockid: unix.CLOCK_MONOTONIC}
+		var serr error
+		if err := rawConn.Control(func(fd uintptr) {
+			serr = unix.SetsockoptString(int(fd), unix.SOL_SOCKET, unix.SO_TXTIME, string((*[unsafe.Sizeof(cfg)]byte)(unsafe.Pointer(&cfg))[:]))
+		}); err != nil {
+			return
+		}
+		if serr != nil {
+			utils.DefaultLogger.Debugf("Setting SO_TXTIME failed: %s", serr)
+			return
+		}
+		utils.DefaultLogger.Debugf("Activated SO_TXTIME.")
+		c.txTimeEnabled = true
+	})
+	return c.txTimeEnabled
+}
+
+func (c *oobConn) WritePacketAt(b []byte, addr net.Addr, oob []byte, txTime time.Time) (int, error) {
+	if !c.txTimeEnabled {
+		return c.WritePacket(b, addr, oob)
+	}
+	// The kernel expects the transmit time as a CLOCK_MONOTONIC timestamp.
+	var ts unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &ts); err != nil {
+		return 0, err
+	}
+	mono := ts.Nano() + int64(time.Until(txTime))
+
+	txOOB := make([]byte, len(oob)+unix.CmsgSpace(8))
+	copy(txOOB, oob)
+	h := (*unix.Cmsghdr)(unsafe.Pointer(&txOOB[len(oob)]))
+	h.Level = unix.SOL_SOCKET
+	h.Type = unix.SCM_TXTIME
+	h.SetLen(unix.CmsgLen(8))
+	*(*uint64)(unsafe.Pointer(&txOOB[len(oob)+unix.CmsgLen(0)])) = uint64(mono)
+	return c.WritePacket(b, addr, txOOB)
+}
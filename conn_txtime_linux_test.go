@@ -0,0 +1,60 @@
+//go:build linux
+// +build linux
+
+package quic
+
+import (
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Transmit time conn", func() {
+	It("sends packets with a transmit time", func() {
+		server, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).ToNot(HaveOccurred())
+		defer server.Close()
+
+		udpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).ToNot(HaveOccurred())
+		defer udpConn.Close()
+		conn, err := newConn(udpConn)
+		Expect(err).ToNot(HaveOccurred())
+		if !conn.EnableTxTime() {
+			Skip("SO_TXTIME not supported")
+		}
+		Expect(conn.EnableTxTime()).To(BeTrue())
+
+		// The loopback interface doesn't use the fq qdisc, so the packet is delivered immediately.
+		_, err = conn.WritePacketAt([]byte("foobar"), server.LocalAddr(), nil, time.Now().Add(time.Millisecond))
+		Expect(err).ToNot(HaveOccurred())
+		b := make([]byte, 100)
+		server.SetReadDeadline(time.Now().Add(time.Second))
+		n, addr, err := server.ReadFrom(b)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(addr.String()).To(Equal(udpConn.LocalAddr().String()))
+		Expect(string(b[:n])).To(Equal("foobar"))
+	})
+
+	It("sends packets without a transmit time, if SO_TXTIME is not enabled", func() {
+		server, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).ToNot(HaveOccurred())
+		defer server.Close()
+
+		udpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).ToNot(HaveOccurred())
+		defer udpConn.Close()
+		conn, err := newConn(udpConn)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = conn.WritePacketAt([]byte("foobar"), server.LocalAddr(), nil, time.Now().Add(time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		b := make([]byte, 100)
+		server.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := server.ReadFrom(b)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(b[:n])).To(Equal("foobar"))
+	})
+})
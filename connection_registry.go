@@ -0,0 +1,228 @@
+package quic
+
+import (
+	"net"
+	"sync"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/logging"
+)
+
+// ConnectionInfo describes a connection in the connection registry.
+type ConnectionInfo struct {
+	// TracingID is the value of the SessionTracingKey of the session.
+	TracingID   uint64
+	Perspective logging.Perspective
+	RemoteAddr  net.Addr
+	// ConnectionIDs are the active connection IDs issued by this endpoint.
+	// They are used as the Destination Connection ID of all packets sent by the peer,
+	// and as the Source Connection ID of long header packets sent by this endpoint.
+	// During the handshake, the server also registers the Destination Connection ID chosen by the client.
+	ConnectionIDs [][]byte
+	// Labels are the labels set by the application using Session.SetLabels.
+	Labels map[string]string
+}
+
+// LookupConnectionID looks up the connection that uses a connection ID.
+// All sessions of the process are registered, until they are closed.
+// This allows attributing packet captures and qlogs to users or tenants, e.g. from an admin endpoint.
+func LookupConnectionID(connID []byte) (ConnectionInfo, bool) {
+	return connRegistry.Lookup(connID)
+}
+
+// RegisteredConnections returns all sessions registered in the connection registry.
+func RegisteredConnections() []ConnectionInfo {
+	return connRegistry.Connections()
+}
+
+// SetConnectionLogLevel changes the log level of the session that uses a connection ID, while the session is running.
+// This allows debugging a single connection, by raising its log level to logging.LogLevelDebug,
+// or silencing it using logging.LogLevelNothing.
+// It returns false if no session uses the connection ID.
+func SetConnectionLogLevel(connID []byte, level logging.LogLevel) bool {
+	return connRegistry.SetLogLevel(connID, level)
+}
+
+// ResetConnectionLogLevel makes the session that uses a connection ID use the global log level again.
+// It returns false if no session uses the connection ID.
+func ResetConnectionLogLevel(connID []byte) bool {
+	return connRegistry.ResetLogLevel(connID)
+}
+
+var connRegistry = newConnectionRegistry()
+
+type registeredConnection struct {
+	tracingID   uint64
+	perspective protocol.Perspective
+	remoteAddr  net.Addr
+
+	connIDs []protocol.ConnectionID
+	labels  map[string]string
+	logger  *utils.ConnectionLogger
+}
+
+func (c *registeredConnection) removeConnectionID(connID protocol.ConnectionID) {
+	for i, id := range c.connIDs {
+		if id.Equal(connID) {
+			c.connIDs = append(c.connIDs[:i], c.connIDs[i+1:]...)
+			return
+		}
+	}
+}
+
+func (c *registeredConnection) info() ConnectionInfo {
+	info := ConnectionInfo{
+		TracingID:     c.tracingID,
+		Perspective:   c.perspective,
+		RemoteAddr:    c.remoteAddr,
+		ConnectionIDs: make([][]byte, 0, len(c.connIDs)),
+	}
+	for _, connID := range c.connIDs {
+		info.ConnectionIDs = append(info.ConnectionIDs, connID.Bytes())
+	}
+	if c.labels != nil {
+		info.Labels = make(map[string]string, len(c.labels))
+		for k, v := range c.labels {
+			info.Labels[k] = v
+		}
+	}
+	return info
+}
+
+// The connectionRegistry maps the connection IDs of all sessions to the sessions.
+// The session's entry is only accessed while holding the mutex of the registry.
+type connectionRegistry struct {
+	mutex sync.RWMutex
+
+	byConnID map[string]*registeredConnection // the key is the string representation of the connection ID
+	conns    map[*registeredConnection]struct{}
+}
+
+func newConnectionRegistry() *connectionRegistry {
+	return &connectionRegistry{
+		byConnID: make(map[string]*registeredConnection),
+		conns:    make(map[*registeredConnection]struct{}),
+	}
+}
+
+func (r *connectionRegistry) Register(tracingID uint64, perspective protocol.Perspective, remoteAddr net.Addr) *registeredConnection {
+	c := &registeredConnection{
+		tracingID:   tracingID,
+		perspective: perspective,
+		remoteAddr:  remoteAddr,
+	}
+	r.mutex.Lock()
+	r.conns[c] = struct{}{}
+	r.mutex.Unlock()
+	return c
+}
+
+func (r *connectionRegistry) AddConnectionID(c *registeredConnection, connID protocol.ConnectionID) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.conns[c]; !ok { // already unregistered
+		return
+	}
+	// If a connection ID is reused, the newer session takes it over.
+	if old, ok := r.byConnID[string(connID)]; ok {
+		if old == c {
+			return
+		}
+		old.removeConnectionID(connID)
+	}
+	r.byConnID[string(connID)] = c
+	c.connIDs = append(c.connIDs, connID)
+}
+
+func (r *connectionRegistry) RemoveConnectionID(c *registeredConnection, connID protocol.ConnectionID) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.byConnID[string(connID)] != c {
+		return
+	}
+	delete(r.byConnID, string(connID))
+	c.removeConnectionID(connID)
+}
+
+func (r *connectionRegistry) SetLabels(c *registeredConnection, labels map[string]string) {
+	l := make(map[string]string, len(labels))
+	for k, v := range labels {
+		l[k] = v
+	}
+	r.mutex.Lock()
+	c.labels = l
+	r.mutex.Unlock()
+}
+
+func (r *connectionRegistry) SetLogger(c *registeredConnection, logger *utils.ConnectionLogger) {
+	r.mutex.Lock()
+	c.logger = logger
+	r.mutex.Unlock()
+}
+
+func (r *connectionRegistry) SetLogLevel(connID []byte, level utils.LogLevel) bool {
+	logger := r.lookupLogger(connID)
+	if logger == nil {
+		return false
+	}
+	logger.SetLogLevel(level)
+	return true
+}
+
+func (r *connectionRegistry) ResetLogLevel(connID []byte) bool {
+	logger := r.lookupLogger(connID)
+	if logger == nil {
+		return false
+	}
+	logger.ResetLogLevel()
+	return true
+}
+
+func (r *connectionRegistry) lookupLogger(connID []byte) *utils.ConnectionLogger {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	c, ok := r.byConnID[string(connID)]
+	if !ok {
+		return nil
+	}
+	return c.logger
+}
+
+func (r *connectionRegistry) Unregister(c *registeredConnection) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, connID := range c.connIDs {
+		if r.byConnID[string(connID)] == c {
+			delete(r.byConnID, string(connID))
+		}
+	}
+	c.connIDs = nil
+	delete(r.conns, c)
+}
+
+func (r *connectionRegistry) Lookup(connID []byte) (ConnectionInfo, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	c, ok := r.byConnID[string(connID)]
+	if !ok {
+		return ConnectionInfo{}, false
+	}
+	return c.info(), true
+}
+
+func (r *connectionRegistry) Connections() []ConnectionInfo {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	infos := make([]ConnectionInfo, 0, len(r.conns))
+	for c := range r.conns {
+		infos = append(infos, c.info())
+	}
+	return infos
+}
@@ -0,0 +1,110 @@
+package quic
+
+import (
+	"net"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Connection Registry", func() {
+	var r *connectionRegistry
+	remoteAddr := &net.UDPAddr{IP: net.IPv4(192, 168, 13, 37), Port: 1000}
+
+	BeforeEach(func() {
+		r = newConnectionRegistry()
+	})
+
+	It("looks up connections by connection ID", func() {
+		c := r.Register(42, protocol.PerspectiveServer, remoteAddr)
+		r.AddConnectionID(c, protocol.ConnectionID{1, 2, 3, 4})
+		r.AddConnectionID(c, protocol.ConnectionID{5, 6, 7, 8})
+		info, ok := r.Lookup([]byte{5, 6, 7, 8})
+		Expect(ok).To(BeTrue())
+		Expect(info.TracingID).To(BeEquivalentTo(42))
+		Expect(info.Perspective).To(Equal(protocol.PerspectiveServer))
+		Expect(info.RemoteAddr).To(Equal(remoteAddr))
+		Expect(info.ConnectionIDs).To(Equal([][]byte{{1, 2, 3, 4}, {5, 6, 7, 8}}))
+		Expect(info.Labels).To(BeNil())
+		_, ok = r.Lookup([]byte{1, 3, 3, 7})
+		Expect(ok).To(BeFalse())
+	})
+
+	It("removes connection IDs", func() {
+		c := r.Register(42, protocol.PerspectiveClient, remoteAddr)
+		r.AddConnectionID(c, protocol.ConnectionID{1, 2, 3, 4})
+		r.AddConnectionID(c, protocol.ConnectionID{5, 6, 7, 8})
+		r.RemoveConnectionID(c, protocol.ConnectionID{1, 2, 3, 4})
+		_, ok := r.Lookup([]byte{1, 2, 3, 4})
+		Expect(ok).To(BeFalse())
+		info, ok := r.Lookup([]byte{5, 6, 7, 8})
+		Expect(ok).To(BeTrue())
+		Expect(info.ConnectionIDs).To(Equal([][]byte{{5, 6, 7, 8}}))
+	})
+
+	It("hands over reused connection IDs to the newer connection", func() {
+		c1 := r.Register(1, protocol.PerspectiveServer, remoteAddr)
+		c2 := r.Register(2, protocol.PerspectiveServer, remoteAddr)
+		r.AddConnectionID(c1, protocol.ConnectionID{1, 2, 3, 4})
+		r.AddConnectionID(c2, protocol.ConnectionID{1, 2, 3, 4})
+		info, ok := r.Lookup([]byte{1, 2, 3, 4})
+		Expect(ok).To(BeTrue())
+		Expect(info.TracingID).To(BeEquivalentTo(2))
+		// removing the connection ID from the old connection doesn't affect the new one
+		r.RemoveConnectionID(c1, protocol.ConnectionID{1, 2, 3, 4})
+		r.Unregister(c1)
+		info, ok = r.Lookup([]byte{1, 2, 3, 4})
+		Expect(ok).To(BeTrue())
+		Expect(info.TracingID).To(BeEquivalentTo(2))
+	})
+
+	It("copies the labels", func() {
+		c := r.Register(42, protocol.PerspectiveServer, remoteAddr)
+		r.AddConnectionID(c, protocol.ConnectionID{1, 2, 3, 4})
+		labels := map[string]string{"user": "alice", "tenant": "acme"}
+		r.SetLabels(c, labels)
+		labels["user"] = "bob"
+		info, ok := r.Lookup([]byte{1, 2, 3, 4})
+		Expect(ok).To(BeTrue())
+		Expect(info.Labels).To(Equal(map[string]string{"user": "alice", "tenant": "acme"}))
+		info.Labels["tenant"] = "evil"
+		info, _ = r.Lookup([]byte{1, 2, 3, 4})
+		Expect(info.Labels).To(HaveKeyWithValue("tenant", "acme"))
+	})
+
+	It("sets the log level of a connection", func() {
+		c := r.Register(42, protocol.PerspectiveServer, remoteAddr)
+		r.AddConnectionID(c, protocol.ConnectionID{1, 2, 3, 4})
+		Expect(r.SetLogLevel([]byte{1, 2, 3, 4}, utils.LogLevelDebug)).To(BeFalse()) // no logger set yet
+		logger := utils.NewConnectionLogger(utils.DefaultLogger)
+		r.SetLogger(c, logger)
+		Expect(logger.Debug()).To(BeFalse())
+		Expect(r.SetLogLevel([]byte{1, 2, 3, 4}, utils.LogLevelDebug)).To(BeTrue())
+		Expect(logger.Debug()).To(BeTrue())
+		Expect(r.ResetLogLevel([]byte{1, 2, 3, 4})).To(BeTrue())
+		Expect(logger.Debug()).To(BeFalse())
+		Expect(r.SetLogLevel([]byte{5, 6, 7, 8}, utils.LogLevelDebug)).To(BeFalse())
+		Expect(r.ResetLogLevel([]byte{5, 6, 7, 8})).To(BeFalse())
+	})
+
+	It("unregisters connections", func() {
+		c1 := r.Register(1, protocol.PerspectiveServer, remoteAddr)
+		c2 := r.Register(2, protocol.PerspectiveClient, remoteAddr)
+		r.AddConnectionID(c1, protocol.ConnectionID{1, 2, 3, 4})
+		r.AddConnectionID(c2, protocol.ConnectionID{5, 6, 7, 8})
+		Expect(r.Connections()).To(HaveLen(2))
+		r.Unregister(c1)
+		_, ok := r.Lookup([]byte{1, 2, 3, 4})
+		Expect(ok).To(BeFalse())
+		conns := r.Connections()
+		Expect(conns).To(HaveLen(1))
+		Expect(conns[0].TracingID).To(BeEquivalentTo(2))
+		// connection IDs added after unregistering are ignored
+		r.AddConnectionID(c1, protocol.ConnectionID{1, 2, 3, 4})
+		_, ok = r.Lookup([]byte{1, 2, 3, 4})
+		Expect(ok).To(BeFalse())
+	})
+})
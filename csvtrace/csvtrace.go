@@ -0,0 +1,282 @@
+// Package csvtrace writes time series of the congestion controller state as CSV,
+// such that congestion control experiments can be plotted directly, e.g. using pandas or gnuplot.
+package csvtrace
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/logging"
+)
+
+// The Header is the first row of every time series.
+var Header = []string{"timestamp", "cwnd", "ssthresh", "srtt", "bytes_in_flight", "state", "algo"}
+
+// Config configures the CSV tracer.
+type Config struct {
+	// Interval is the interval between two rows.
+	// If zero, a row is written whenever the congestion controller state changes.
+	Interval time.Duration
+	// Algorithm is written to the algo column, e.g. "hystart++/cubic".
+	// It allows concatenating the time series of connections using different algorithms.
+	Algorithm string
+}
+
+type tracer struct {
+	getLogWriter func(p logging.Perspective, connectionID []byte) io.WriteCloser
+	config       Config
+}
+
+var _ logging.Tracer = &tracer{}
+
+// NewTracer creates a new CSV tracer.
+// The time series of every connection is written to the writer returned by getLogWriter.
+// If getLogWriter returns nil, no time series is written for this connection.
+//
+// Every row contains the time since the start of the connection (in seconds), the congestion window,
+// the slow start threshold and the bytes in flight (in bytes), the smoothed RTT (in milliseconds),
+// the state of the congestion controller and the algorithm.
+// The slow start threshold is empty as long as it is not set, and the smoothed RTT until the first RTT sample.
+func NewTracer(getLogWriter func(p logging.Perspective, connectionID []byte) io.WriteCloser, config Config) logging.Tracer {
+	return &tracer{getLogWriter: getLogWriter, config: config}
+}
+
+func (t *tracer) TracerForConnection(_ context.Context, p logging.Perspective, odcid logging.ConnectionID) logging.ConnectionTracer {
+	w := t.getLogWriter(p, odcid.Bytes())
+	if w == nil {
+		return nil
+	}
+	return newConnectionTracer(w, t.config)
+}
+
+func (t *tracer) SentPacket(net.Addr, *logging.Header, logging.ByteCount, []logging.Frame) {}
+func (t *tracer) DroppedPacket(net.Addr, logging.PacketType, logging.ByteCount, logging.PacketDropReason) {
+}
+
+type connectionTracer struct {
+	w         io.WriteCloser
+	csvWriter *csv.Writer
+	algorithm string
+	interval  time.Duration
+	startTime time.Time
+
+	mutex         sync.Mutex
+	cwnd          logging.ByteCount
+	ssthresh      logging.ByteCount
+	bytesInFlight logging.ByteCount
+	srtt          time.Duration
+	state         logging.CongestionState
+	hasMetrics    bool // set once the congestion window is known
+	err           error
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	done      chan struct{}
+}
+
+var _ logging.ConnectionTracer = &connectionTracer{}
+
+func newConnectionTracer(w io.WriteCloser, config Config) *connectionTracer {
+	t := &connectionTracer{
+		w:         w,
+		csvWriter: csv.NewWriter(w),
+		algorithm: config.Algorithm,
+		interval:  config.Interval,
+		startTime: time.Now(),
+		ssthresh:  protocol.MaxByteCount,
+		closed:    make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	t.writeRow(Header)
+	if t.interval > 0 {
+		go t.run()
+	} else {
+		close(t.done)
+	}
+	return t
+}
+
+func (t *connectionTracer) run() {
+	defer close(t.done)
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.closed:
+			return
+		case now := <-ticker.C:
+			t.mutex.Lock()
+			t.maybeWriteMetrics(now)
+			t.mutex.Unlock()
+		}
+	}
+}
+
+// writeRow writes a row. It must be called with the mutex held, or before the ticker is started.
+func (t *connectionTracer) writeRow(row []string) {
+	if t.err != nil {
+		return
+	}
+	if err := t.csvWriter.Write(row); err != nil {
+		t.err = err
+		return
+	}
+	t.csvWriter.Flush()
+	if err := t.csvWriter.Error(); err != nil {
+		t.err = err
+	}
+}
+
+func (t *connectionTracer) maybeWriteMetrics(now time.Time) {
+	if !t.hasMetrics {
+		return
+	}
+	var ssthresh, srtt string
+	if t.ssthresh != protocol.MaxByteCount {
+		ssthresh = strconv.FormatInt(int64(t.ssthresh), 10)
+	}
+	if t.srtt != 0 {
+		srtt = strconv.FormatFloat(float64(t.srtt)/float64(time.Millisecond), 'f', 3, 64)
+	}
+	t.writeRow([]string{
+		strconv.FormatFloat(now.Sub(t.startTime).Seconds(), 'f', 6, 64),
+		strconv.FormatInt(int64(t.cwnd), 10),
+		ssthresh,
+		srtt,
+		strconv.FormatInt(int64(t.bytesInFlight), 10),
+		congestionStateString(t.state),
+		t.algorithm,
+	})
+}
+
+// updated is called with the mutex held, after the state of the congestion controller was updated.
+func (t *connectionTracer) updated(changed bool) {
+	if t.interval == 0 && changed {
+		t.maybeWriteMetrics(time.Now())
+	}
+}
+
+func (t *connectionTracer) UpdatedCongestionMetrics(cwnd, ssthresh, bytesInFlight logging.ByteCount, _ uint64) {
+	t.mutex.Lock()
+	changed := !t.hasMetrics || cwnd != t.cwnd || ssthresh != t.ssthresh || bytesInFlight != t.bytesInFlight
+	t.cwnd = cwnd
+	t.ssthresh = ssthresh
+	t.bytesInFlight = bytesInFlight
+	t.hasMetrics = true
+	t.updated(changed)
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) UpdatedMetrics(rttStats *logging.RTTStats, _, _ logging.ByteCount, _ int) {
+	t.mutex.Lock()
+	srtt := rttStats.SmoothedRTT()
+	changed := srtt != t.srtt
+	t.srtt = srtt
+	t.updated(changed)
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) UpdatedCongestionState(state logging.CongestionState) {
+	t.mutex.Lock()
+	changed := state != t.state
+	t.state = state
+	t.updated(changed)
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) Close() {
+	t.closeOnce.Do(func() {
+		close(t.closed)
+		<-t.done
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+		// write the final state
+		if t.interval > 0 {
+			t.maybeWriteMetrics(time.Now())
+		}
+		if t.err != nil {
+			log.Printf("writing the CSV time series failed: %s", t.err)
+		}
+		if err := t.w.Close(); err != nil {
+			log.Printf("closing the CSV time series failed: %s", err)
+		}
+	})
+}
+
+func (t *connectionTracer) StartedConnection(net.Addr, net.Addr, logging.ConnectionID, logging.ConnectionID) {
+}
+func (t *connectionTracer) NegotiatedVersion(logging.VersionNumber, []logging.VersionNumber, []logging.VersionNumber) {
+}
+func (t *connectionTracer) ClosedConnection(error)                                   {}
+func (t *connectionTracer) SentTransportParameters(*logging.TransportParameters)     {}
+func (t *connectionTracer) ReceivedTransportParameters(*logging.TransportParameters) {}
+func (t *connectionTracer) RestoredTransportParameters(*logging.TransportParameters) {}
+func (t *connectionTracer) SentPacket(*logging.ExtendedHeader, logging.ByteCount, *logging.AckFrame, []logging.Frame) {
+}
+func (t *connectionTracer) ComposedPacket(logging.EncryptionLevel, logging.PacketNumber, *logging.PacketComposition) {
+}
+func (t *connectionTracer) ReceivedVersionNegotiationPacket(*logging.Header, []logging.VersionNumber) {
+}
+func (t *connectionTracer) ReceivedRetry(*logging.Header) {}
+func (t *connectionTracer) ReceivedPacket(*logging.ExtendedHeader, logging.ByteCount, []logging.Frame) {
+}
+func (t *connectionTracer) SentDatagram([]byte)                                                {}
+func (t *connectionTracer) ReceivedDatagram([]byte)                                            {}
+func (t *connectionTracer) SentPlaintextPacket(logging.EncryptionLevel, []byte)                {}
+func (t *connectionTracer) ReceivedPlaintextPacket(logging.EncryptionLevel, []byte)            {}
+func (t *connectionTracer) SentDatagramFrame(logging.ByteCount)                                {}
+func (t *connectionTracer) ReceivedDatagramFrame(logging.ByteCount)                            {}
+func (t *connectionTracer) DroppedDatagramFrame(logging.ByteCount, logging.DatagramDropReason) {}
+func (t *connectionTracer) SentFlowControlUpdate(logging.StreamID, logging.ByteCount, logging.ByteCount) {
+}
+func (t *connectionTracer) ReceivedFlowControlUpdate(logging.StreamID, logging.ByteCount, time.Duration) {
+}
+func (t *connectionTracer) BlockedByFlowControl(logging.StreamID, logging.ByteCount) {}
+func (t *connectionTracer) BufferedPacket(logging.PacketType)                        {}
+func (t *connectionTracer) DroppedPacket(logging.PacketType, logging.ByteCount, logging.PacketDropReason) {
+}
+func (t *connectionTracer) AcknowledgedPacket(logging.EncryptionLevel, logging.PacketNumber) {}
+func (t *connectionTracer) LostPacket(logging.EncryptionLevel, logging.PacketNumber, logging.PacketLossReason) {
+}
+func (t *connectionTracer) DetectedPersistentCongestion(time.Duration)                         {}
+func (t *connectionTracer) DetectedSuspend(time.Duration)                                      {}
+func (t *connectionTracer) ClassifiedPacketLoss(logging.PacketNumber, bool)                    {}
+func (t *connectionTracer) PacerDelayedSend(logging.ByteCount, time.Time)                      {}
+func (t *connectionTracer) LimitedAckRanges(logging.EncryptionLevel, int, int)                 {}
+func (t *connectionTracer) SkippedPacketNumber(logging.PacketNumber)                           {}
+func (t *connectionTracer) UpdatedPTOCount(uint32)                                             {}
+func (t *connectionTracer) UpdatedMaxDatagramSize(logging.ByteCount)                           {}
+func (t *connectionTracer) UpdatedKeyFromTLS(logging.EncryptionLevel, logging.Perspective)     {}
+func (t *connectionTracer) UpdatedKey(logging.KeyPhase, bool)                                  {}
+func (t *connectionTracer) DroppedEncryptionLevel(logging.EncryptionLevel)                     {}
+func (t *connectionTracer) DroppedKey(logging.KeyPhase)                                        {}
+func (t *connectionTracer) Accepted0RTT()                                                      {}
+func (t *connectionTracer) Rejected0RTT(logging.ZeroRTTRejectReason)                           {}
+func (t *connectionTracer) SetLossTimer(logging.TimerType, logging.EncryptionLevel, time.Time) {}
+func (t *connectionTracer) LossTimerExpired(logging.TimerType, logging.EncryptionLevel)        {}
+func (t *connectionTracer) LossTimerCanceled()                                                 {}
+func (t *connectionTracer) Debug(string, string)                                               {}
+
+func congestionStateString(s logging.CongestionState) string {
+	switch s {
+	case logging.CongestionStateSlowStart:
+		return "slow_start"
+	case logging.CongestionStateLowSlowStart:
+		return "low_slow_start"
+	case logging.CongestionStateCongestionAvoidance:
+		return "congestion_avoidance"
+	case logging.CongestionStateRecovery:
+		return "recovery"
+	case logging.CongestionStateApplicationLimited:
+		return "application_limited"
+	default:
+		return "unknown"
+	}
+}
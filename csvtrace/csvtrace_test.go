@@ -0,0 +1,116 @@
+package csvtrace
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type bufferWriter struct {
+	io.Writer
+	mutex  sync.Mutex
+	closed bool
+}
+
+func (w *bufferWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.Writer.Write(p)
+}
+
+func (w *bufferWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.closed = true
+	return nil
+}
+
+var _ = Describe("CSV Tracer", func() {
+	var (
+		buf *bytes.Buffer
+		w   *bufferWriter
+	)
+
+	BeforeEach(func() {
+		buf = &bytes.Buffer{}
+		w = &bufferWriter{Writer: buf}
+	})
+
+	readRows := func() [][]string {
+		w.mutex.Lock()
+		defer w.mutex.Unlock()
+		rows, err := csv.NewReader(bytes.NewReader(buf.Bytes())).ReadAll()
+		Expect(err).ToNot(HaveOccurred())
+		return rows
+	}
+
+	newTracer := func(config Config) logging.ConnectionTracer {
+		var perspective logging.Perspective
+		var connID []byte
+		tracer := NewTracer(func(p logging.Perspective, c []byte) io.WriteCloser {
+			perspective = p
+			connID = c
+			return w
+		}, config)
+		t := tracer.TracerForConnection(nil, logging.PerspectiveClient, logging.ConnectionID{0xde, 0xad})
+		Expect(perspective).To(Equal(logging.PerspectiveClient))
+		Expect(connID).To(Equal([]byte{0xde, 0xad}))
+		return t
+	}
+
+	It("doesn't trace the connection if no writer is returned", func() {
+		tracer := NewTracer(func(logging.Perspective, []byte) io.WriteCloser { return nil }, Config{})
+		Expect(tracer.TracerForConnection(nil, logging.PerspectiveServer, logging.ConnectionID{1})).To(BeNil())
+	})
+
+	It("writes a row whenever the congestion controller state changes", func() {
+		t := newTracer(Config{Algorithm: "hystart/cubic"})
+		Expect(readRows()).To(Equal([][]string{Header}))
+		// no row is written until the congestion window is known
+		t.UpdatedCongestionState(logging.CongestionStateSlowStart)
+		t.UpdatedCongestionMetrics(12000, protocol.MaxByteCount, 1200, 0)
+		rttStats := &utils.RTTStats{}
+		rttStats.UpdateRTT(20*time.Millisecond, 0, time.Now())
+		t.UpdatedMetrics(rttStats, 12000, 1200, 1)
+		// no change
+		t.UpdatedMetrics(rttStats, 12000, 1200, 1)
+		t.UpdatedCongestionState(logging.CongestionStateSlowStart)
+		t.UpdatedCongestionMetrics(6000, 6000, 2400, 0)
+		t.UpdatedCongestionState(logging.CongestionStateRecovery)
+		t.Close()
+		Expect(w.closed).To(BeTrue())
+		rows := readRows()
+		Expect(rows).To(HaveLen(5))
+		Expect(rows[1][1:]).To(Equal([]string{"12000", "", "", "1200", "slow_start", "hystart/cubic"}))
+		Expect(rows[2][1:]).To(Equal([]string{"12000", "", "20.000", "1200", "slow_start", "hystart/cubic"}))
+		Expect(rows[3][1:]).To(Equal([]string{"6000", "6000", "20.000", "2400", "slow_start", "hystart/cubic"}))
+		Expect(rows[4][1:]).To(Equal([]string{"6000", "6000", "20.000", "2400", "recovery", "hystart/cubic"}))
+		for _, row := range rows[1:] {
+			Expect(row[0]).To(MatchRegexp(`^\d+\.\d{6}$`))
+		}
+	})
+
+	It("writes rows at the configured interval", func() {
+		t := newTracer(Config{Interval: scaleDuration(10 * time.Millisecond)})
+		t.UpdatedCongestionMetrics(12000, protocol.MaxByteCount, 1200, 0)
+		Eventually(func() int { return len(readRows()) }).Should(BeNumerically(">=", 4))
+		t.UpdatedCongestionMetrics(24000, protocol.MaxByteCount, 1200, 0)
+		time.Sleep(scaleDuration(15 * time.Millisecond))
+		t.Close()
+		rows := readRows()
+		Expect(rows[1][1]).To(Equal("12000"))
+		Expect(rows[len(rows)-1][1]).To(Equal("24000"))
+		// no rows are written after closing
+		time.Sleep(scaleDuration(20 * time.Millisecond))
+		Expect(readRows()).To(HaveLen(len(rows)))
+	})
+})
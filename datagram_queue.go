@@ -4,6 +4,7 @@ import (
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/lucas-clemente/quic-go/internal/wire"
+	"github.com/lucas-clemente/quic-go/logging"
 )
 
 type datagramQueue struct {
@@ -17,12 +18,14 @@ type datagramQueue struct {
 
 	dequeued chan struct{}
 
+	tracer logging.ConnectionTracer // may be nil
 	logger utils.Logger
 }
 
-func newDatagramQueue(hasData func(), logger utils.Logger) *datagramQueue {
+func newDatagramQueue(hasData func(), tracer logging.ConnectionTracer, logger utils.Logger) *datagramQueue {
 	return &datagramQueue{
 		hasData:   hasData,
+		tracer:    tracer,
 		sendQueue: make(chan *wire.DatagramFrame, 1),
 		rcvQueue:  make(chan []byte, protocol.DatagramRcvQueueLen),
 		dequeued:  make(chan struct{}),
@@ -66,8 +69,14 @@ func (h *datagramQueue) HandleDatagramFrame(f *wire.DatagramFrame) {
 	copy(data, f.Data)
 	select {
 	case h.rcvQueue <- data:
+		if h.tracer != nil {
+			h.tracer.ReceivedDatagramFrame(protocol.ByteCount(len(f.Data)))
+		}
 	default:
 		h.logger.Debugf("Discarding DATAGRAM frame (%d bytes payload)", len(f.Data))
+		if h.tracer != nil {
+			h.tracer.DroppedDatagramFrame(protocol.ByteCount(len(f.Data)), logging.DatagramDropReceiveQueueFull)
+		}
 	}
 }
 
@@ -84,4 +93,11 @@ func (h *datagramQueue) Receive() ([]byte, error) {
 func (h *datagramQueue) CloseWithError(e error) {
 	h.closeErr = e
 	close(h.closed)
+	select {
+	case f := <-h.sendQueue:
+		if h.tracer != nil {
+			h.tracer.DroppedDatagramFrame(protocol.ByteCount(len(f.Data)), logging.DatagramDropConnectionClosed)
+		}
+	default:
+	}
 }
@@ -3,8 +3,11 @@ package quic
 import (
 	"errors"
 
+	mocklogging "github.com/lucas-clemente/quic-go/internal/mocks/logging"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/lucas-clemente/quic-go/internal/wire"
+	"github.com/lucas-clemente/quic-go/logging"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -13,12 +16,14 @@ import (
 var _ = Describe("Datagram Queue", func() {
 	var queue *datagramQueue
 	var queued chan struct{}
+	var tracer *mocklogging.MockConnectionTracer
 
 	BeforeEach(func() {
 		queued = make(chan struct{}, 100)
+		tracer = mocklogging.NewMockConnectionTracer(mockCtrl)
 		queue = newDatagramQueue(func() {
 			queued <- struct{}{}
-		}, utils.DefaultLogger)
+		}, tracer, utils.DefaultLogger)
 	})
 
 	Context("sending", func() {
@@ -51,6 +56,7 @@ var _ = Describe("Datagram Queue", func() {
 			}()
 
 			Consistently(errChan).ShouldNot(Receive())
+			tracer.EXPECT().DroppedDatagramFrame(protocol.ByteCount(6), logging.DatagramDropConnectionClosed)
 			queue.CloseWithError(errors.New("test error"))
 			Eventually(errChan).Should(Receive(MatchError("test error")))
 		})
@@ -58,6 +64,7 @@ var _ = Describe("Datagram Queue", func() {
 
 	Context("receiving", func() {
 		It("receives DATAGRAM frames", func() {
+			tracer.EXPECT().ReceivedDatagramFrame(protocol.ByteCount(3)).Times(2)
 			queue.HandleDatagramFrame(&wire.DatagramFrame{Data: []byte("foo")})
 			queue.HandleDatagramFrame(&wire.DatagramFrame{Data: []byte("bar")})
 			data, err := queue.Receive()
@@ -78,10 +85,20 @@ var _ = Describe("Datagram Queue", func() {
 			}()
 
 			Consistently(c).ShouldNot(Receive())
+			tracer.EXPECT().ReceivedDatagramFrame(protocol.ByteCount(6))
 			queue.HandleDatagramFrame(&wire.DatagramFrame{Data: []byte("foobar")})
 			Eventually(c).Should(Receive(Equal([]byte("foobar"))))
 		})
 
+		It("drops DATAGRAM frames when the receive queue is full", func() {
+			tracer.EXPECT().ReceivedDatagramFrame(protocol.ByteCount(3)).Times(protocol.DatagramRcvQueueLen)
+			for i := 0; i < protocol.DatagramRcvQueueLen; i++ {
+				queue.HandleDatagramFrame(&wire.DatagramFrame{Data: []byte("foo")})
+			}
+			tracer.EXPECT().DroppedDatagramFrame(protocol.ByteCount(6), logging.DatagramDropReceiveQueueFull)
+			queue.HandleDatagramFrame(&wire.DatagramFrame{Data: []byte("foobar")})
+		})
+
 		It("closes", func() {
 			errChan := make(chan error, 1)
 			go func() {
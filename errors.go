@@ -13,6 +13,8 @@ type (
 	StatelessResetError     = qerr.StatelessResetError
 	IdleTimeoutError        = qerr.IdleTimeoutError
 	HandshakeTimeoutError   = qerr.HandshakeTimeoutError
+	FirstFlightTimeoutError = qerr.FirstFlightTimeoutError
+	FallbackAdvisedError    = qerr.FallbackAdvisedError
 )
 
 type (
@@ -56,3 +58,20 @@ func (e *StreamError) Is(target error) bool {
 func (e *StreamError) Error() string {
 	return fmt.Sprintf("stream %d canceled with error code %d", e.StreamID, e.ErrorCode)
 }
+
+// A StreamChecksumError is returned from Stream.Read if Config.EnableStreamChecksums is set,
+// and the checksum of a chunk of stream data doesn't match the data received.
+type StreamChecksumError struct {
+	StreamID StreamID
+	// Chunk is the number of the chunk, starting at 0.
+	Chunk uint64
+	// Offset is the offset of the chunk in the stream data, excluding the checksum headers.
+	Offset   uint64
+	Length   int
+	Expected uint32
+	Actual   uint32
+}
+
+func (e *StreamChecksumError) Error() string {
+	return fmt.Sprintf("stream %d: checksum mismatch for chunk %d (offset %d, %d bytes): expected %#08x, got %#08x", e.StreamID, e.Chunk, e.Offset, e.Length, e.Expected, e.Actual)
+}
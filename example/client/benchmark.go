@@ -0,0 +1,221 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/http3"
+	"github.com/lucas-clemente/quic-go/internal/bulkdata"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+// A benchmarkConfig describes the transfers run in benchmark mode.
+// Example:
+//
+//	{"origins": [
+//	  {"origin": "https://a.example.org:6121", "paths": ["/10000000"], "start": "hystart", "congestion": "cubic", "repetitions": 5},
+//	  {"origin": "https://b.example.org:6121", "paths": ["/1000000", "/50000000"], "start": "search"}
+//	]}
+type benchmarkConfig struct {
+	Origins []benchmarkOrigin `json:"origins"`
+}
+
+type benchmarkOrigin struct {
+	Origin string `json:"origin"`
+	// Paths are requested one after the other, for every repetition. Defaults to "/".
+	Paths []string `json:"paths"`
+	// Start and Congestion are the algorithms used by the client, see utils.String2Start and utils.String2Congestion.
+	Start      string `json:"start"`
+	Congestion string `json:"congestion"`
+	// Repetitions is the number of times every path is requested. Defaults to 1.
+	Repetitions int `json:"repetitions"`
+}
+
+type benchmarkResult struct {
+	Origin         string    `json:"origin"`
+	URL            string    `json:"url"`
+	Start          string    `json:"start"`
+	Congestion     string    `json:"congestion"`
+	Repetition     int       `json:"repetition"`
+	StartedAt      time.Time `json:"started_at"`
+	DurationMs     float64   `json:"duration_ms"`
+	Bytes          int64     `json:"bytes"`
+	ThroughputMbps float64   `json:"throughput_mbps"`
+	Status         int       `json:"status,omitempty"`
+	Error          string    `json:"error,omitempty"`
+}
+
+type benchmarkResults struct {
+	StartedAt   time.Time         `json:"started_at"`
+	Concurrency int               `json:"concurrency"`
+	Results     []benchmarkResult `json:"results"`
+}
+
+type benchmarkTransfer struct {
+	origin     benchmarkOrigin
+	url        string
+	repetition int
+}
+
+func loadBenchmarkConfig(filename string) (*benchmarkConfig, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var config benchmarkConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("parsing %s failed: %w", filename, err)
+	}
+	if len(config.Origins) == 0 {
+		return nil, fmt.Errorf("%s doesn't contain any origins", filename)
+	}
+	for _, o := range config.Origins {
+		if !strings.HasPrefix(o.Origin, "https://") {
+			return nil, fmt.Errorf("invalid origin: %q", o.Origin)
+		}
+	}
+	return &config, nil
+}
+
+func (c *benchmarkConfig) transfers() []benchmarkTransfer {
+	var transfers []benchmarkTransfer
+	for _, o := range c.Origins {
+		paths := o.Paths
+		if len(paths) == 0 {
+			paths = []string{"/"}
+		}
+		repetitions := o.Repetitions
+		if repetitions <= 0 {
+			repetitions = 1
+		}
+		for i := 0; i < repetitions; i++ {
+			for _, p := range paths {
+				transfers = append(transfers, benchmarkTransfer{
+					origin:     o,
+					url:        strings.TrimSuffix(o.Origin, "/") + "/" + strings.TrimPrefix(p, "/"),
+					repetition: i,
+				})
+			}
+		}
+	}
+	return transfers
+}
+
+// runBenchmark runs the transfers of the benchmark config, with up to concurrency transfers at the same time.
+// Every transfer uses a new QUIC connection, such that the start algorithm is used for every transfer.
+// The results of all transfers are written to w, in the order of the config.
+func runBenchmark(config *benchmarkConfig, concurrency int, tlsConf *tls.Config, qconf *quic.Config, verify bool, w io.Writer) error {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	transfers := config.transfers()
+	results := benchmarkResults{
+		StartedAt:   time.Now(),
+		Concurrency: concurrency,
+		Results:     make([]benchmarkResult, len(transfers)),
+	}
+
+	queue := make(chan int, len(transfers))
+	for i := range transfers {
+		queue <- i
+	}
+	close(queue)
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for idx := range queue {
+				results.Results[idx] = runBenchmarkTransfer(transfers[idx], tlsConf, qconf, verify)
+			}
+		}()
+	}
+	wg.Wait()
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(&results)
+}
+
+func runBenchmarkTransfer(t benchmarkTransfer, tlsConf *tls.Config, qconf *quic.Config, verify bool) benchmarkResult {
+	startAlgo := utils.String2Start(t.origin.Start)
+	congestionAlgo := utils.String2Congestion(t.origin.Congestion)
+	res := benchmarkResult{
+		Origin:     t.origin.Origin,
+		URL:        t.url,
+		Start:      startAlgo.String(),
+		Congestion: congestionAlgo.String(),
+		Repetition: t.repetition,
+		StartedAt:  time.Now(),
+	}
+	rt := &http3.RoundTripper{
+		TLSClientConfig: tlsConf,
+		QuicConfig:      qconf,
+		EstartAlgo:      startAlgo,
+		EcongestionAlgo: congestionAlgo,
+	}
+	defer rt.Close()
+
+	n, status, err := benchmarkGet(rt, t.url, verify)
+	duration := time.Since(res.StartedAt)
+	res.DurationMs = float64(duration) / float64(time.Millisecond)
+	res.Bytes = n
+	res.ThroughputMbps = float64(n) * 8 / duration.Seconds() / 1e6
+	res.Status = status
+	if err != nil {
+		res.Error = err.Error()
+		utils.DefaultLogger.Errorf("GET %s (%s / %s) failed: %s", t.url, res.Start, res.Congestion, err)
+	} else {
+		utils.DefaultLogger.Infof("GET %s (%s / %s): %d bytes in %s (%.2f Mbit/s)", t.url, res.Start, res.Congestion, n, duration, res.ThroughputMbps)
+	}
+	return res
+}
+
+func benchmarkGet(rt http.RoundTripper, addr string, verify bool) (int64, int, error) {
+	req, err := http.NewRequest(http.MethodGet, addr, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	rsp, err := rt.RoundTrip(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode != http.StatusOK {
+		return 0, rsp.StatusCode, fmt.Errorf("unexpected status: %s", rsp.Status)
+	}
+	if !verify {
+		n, err := io.Copy(ioutil.Discard, rsp.Body)
+		return n, rsp.StatusCode, err
+	}
+	seed, length, err := bulkDataParams(addr)
+	if err != nil {
+		return 0, rsp.StatusCode, err
+	}
+	n, err := bulkdata.Verify(rsp.Body, seed, length)
+	return n, rsp.StatusCode, err
+}
+
+func writeBenchmarkResults(filename string, run func(io.Writer) error) error {
+	if filename == "" || filename == "-" {
+		return run(os.Stdout)
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := run(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
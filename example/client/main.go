@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
 	"crypto/tls"
 	"crypto/x509"
@@ -10,14 +9,18 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/lucas-clemente/quic-go"
 	"github.com/lucas-clemente/quic-go/http3"
+	"github.com/lucas-clemente/quic-go/internal/bulkdata"
 	"github.com/lucas-clemente/quic-go/internal/testdata"
 	"github.com/lucas-clemente/quic-go/internal/utils"
-	"github.com/lucas-clemente/quic-go/logging"
 	"github.com/lucas-clemente/quic-go/qlog"
 )
 
@@ -30,6 +33,15 @@ func main() {
 	saveOutput := flag.String("o", "", "save data in file")
 	startAlgostr := flag.String("start", "", "choose start algo amongst defined start algos in utils.algorithms")
 	congestionAlgostr := flag.String("congestion", "", "choose congestion algo amongst defined start algos in utils.algorithms")
+	raceStartAlgostr := flag.String("race-start", "", "race every request on a second connection using this start algo")
+	raceCongestionAlgostr := flag.String("race-congestion", "", "race every request on a second connection using this congestion algo")
+	maxReceiveRate := flag.Uint64("max-receive-rate", 0, "limit the rate (in bytes/s) at which response bodies are read, to emulate a slow receiver")
+	injectLoss := flag.Float64("inject-loss", 0, "drop packets sent by the client with this probability (between 0 and 1)")
+	injectJitter := flag.Duration("inject-jitter", 0, "delay packets sent by the client by a random duration up to this value")
+	verify := flag.Bool("verify", false, "verify the pseudo-random data served by the example server for /<N>[?seed=<S>], instead of printing it")
+	benchmarkFile := flag.String("benchmark", "", "run the transfers listed in this JSON file, instead of requesting the URLs given as arguments")
+	concurrency := flag.Int("concurrency", 1, "number of transfers that are run at the same time in benchmark mode")
+	resultsFile := flag.String("results", "benchmark_results.json", "file that the results of the benchmark are written to (- for stdout)")
 	flag.Parse()
 	urls := flag.Args()
 
@@ -71,44 +83,97 @@ func main() {
 	}
 	testdata.AddRootCA(pool)
 
-	var qconf quic.Config
+	qconf := quic.Config{MaxReceiveRate: *maxReceiveRate}
+	if *injectLoss > 0 || *injectJitter > 0 {
+		// The profile consists of a single step, that is repeated for the lifetime of the connection.
+		qconf.EmulationProfile = []quic.EmulationStep{{Duration: time.Hour, Loss: *injectLoss, Jitter: *injectJitter}}
+	}
 	if *enableQlog {
-		qconf.Tracer = qlog.NewTracer(func(_ logging.Perspective, connID []byte) io.WriteCloser {
-			filename := fmt.Sprintf("client_%x.qlog", connID)
-			f, err := os.Create(filename)
-			if err != nil {
-				log.Fatal(err)
-			}
-			log.Printf("Creating qlog file %s.\n", filename)
-			return utils.NewBufferedWriteCloser(bufio.NewWriter(f), f)
-		})
+		qconf.Tracer = qlog.NewTracer(qlog.NewFileSink(".", "{role}_{odcid}.qlog"))
+	}
+	tlsConf := &tls.Config{
+		RootCAs:            pool,
+		InsecureSkipVerify: *insecure,
+		KeyLogWriter:       keyLog,
+	}
+
+	if len(*benchmarkFile) > 0 {
+		config, err := loadBenchmarkConfig(*benchmarkFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := writeBenchmarkResults(*resultsFile, func(w io.Writer) error {
+			return runBenchmark(config, *concurrency, tlsConf, &qconf, *verify, w)
+		}); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
+
 	roundTripper := &http3.RoundTripper{
-		TLSClientConfig: &tls.Config{
-			RootCAs:            pool,
-			InsecureSkipVerify: *insecure,
-			KeyLogWriter:       keyLog,
-		},
+		TLSClientConfig: tlsConf,
 		QuicConfig: &qconf,
 		EstartAlgo: startAlgo,
 		EcongestionAlgo: congestionAlgo,
 	}
 	defer roundTripper.Close()
+	var transport http.RoundTripper = roundTripper
+
+	// In racing mode, every request is sent on a second connection as well, and the timings of both responses are compared.
+	racing := len(*raceStartAlgostr) > 0 || len(*raceCongestionAlgostr) > 0
+	var raceWg sync.WaitGroup
+	if racing {
+		if len(*raceStartAlgostr) == 0 {
+			raceStartAlgostr = startAlgostr
+		}
+		if len(*raceCongestionAlgostr) == 0 {
+			raceCongestionAlgostr = congestionAlgostr
+		}
+		secondary := &http3.RoundTripper{
+			TLSClientConfig: roundTripper.TLSClientConfig,
+			QuicConfig:      &qconf,
+			EstartAlgo:      utils.String2Start(*raceStartAlgostr),
+			EcongestionAlgo: utils.String2Congestion(*raceCongestionAlgostr),
+		}
+		defer secondary.Close()
+		transport = &http3.RacingRoundTripper{
+			Primary:   roundTripper,
+			Secondary: secondary,
+			Report: func(req *http.Request, res http3.RaceResult) {
+				logger.Infof("Race for %s:", req.URL)
+				logger.Infof("\t%s/%s: headers %s, body %s (%d bytes), error: %v", *startAlgostr, *congestionAlgostr, res.Primary.Headers, res.Primary.Body, res.Primary.BodyBytes, res.Primary.Err)
+				logger.Infof("\t%s/%s: headers %s, body %s (%d bytes), error: %v", *raceStartAlgostr, *raceCongestionAlgostr, res.Secondary.Headers, res.Secondary.Body, res.Secondary.BodyBytes, res.Secondary.Err)
+				raceWg.Done()
+			},
+		}
+	}
 	hclient := &http.Client{
-		Transport: roundTripper,
+		Transport: transport,
 	}
 
 	var wg sync.WaitGroup
 	wg.Add(len(urls))
 	for _, addr := range urls {
 		logger.Infof("GET %s", addr)
+		if racing {
+			raceWg.Add(1)
+		}
 		go func(addr string) {
+			start := time.Now()
 			rsp, err := hclient.Get(addr)
 			if err != nil {
 				log.Fatal(err)
 			}
 			logger.Infof("Got response for %s: %#v", addr, rsp)
 
+			if *verify {
+				if err := verifyBulkData(addr, rsp.Body, start); err != nil {
+					log.Fatal(err)
+				}
+				wg.Done()
+				return
+			}
+
 			body := &bytes.Buffer{}
 			_, err = io.Copy(body, rsp.Body)
 			if err != nil {
@@ -130,4 +195,41 @@ func main() {
 		}(addr)
 	}
 	wg.Wait()
+	raceWg.Wait()
+}
+
+// verifyBulkData checks that body contains the pseudo-random data that the example server generates for addr,
+// and logs the throughput of the transfer.
+func verifyBulkData(addr string, body io.Reader, start time.Time) error {
+	seed, length, err := bulkDataParams(addr)
+	if err != nil {
+		return err
+	}
+	n, err := bulkdata.Verify(body, seed, length)
+	if err != nil {
+		return fmt.Errorf("verifying %s failed after %d bytes: %w", addr, n, err)
+	}
+	duration := time.Since(start)
+	utils.DefaultLogger.Infof("Verified %d bytes from %s in %s (%.2f Mbit/s)", n, addr, duration, float64(n)*8/duration.Seconds()/1e6)
+	return nil
+}
+
+// bulkDataParams returns the seed and the length of the pseudo-random data that the example server generates for addr.
+func bulkDataParams(addr string) (uint64, int64, error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return 0, 0, err
+	}
+	length, err := strconv.ParseInt(strings.ReplaceAll(u.Path, "/", ""), 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s doesn't request generated data: %w", addr, err)
+	}
+	seed := uint64(bulkdata.DefaultSeed)
+	if s := u.Query().Get("seed"); s != "" {
+		seed, err = strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid seed: %w", err)
+		}
+	}
+	return seed, length, nil
 }
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bufio"
 	"crypto/md5"
 	"errors"
 	"flag"
@@ -11,18 +10,18 @@ import (
 	"log"
 	"mime/multipart"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	_ "net/http/pprof"
 
 	"github.com/lucas-clemente/quic-go"
 	"github.com/lucas-clemente/quic-go/http3"
+	"github.com/lucas-clemente/quic-go/internal/bulkdata"
 	"github.com/lucas-clemente/quic-go/internal/testdata"
 	"github.com/lucas-clemente/quic-go/internal/utils"
-	"github.com/lucas-clemente/quic-go/logging"
 	"github.com/lucas-clemente/quic-go/qlog"
 )
 
@@ -42,32 +41,32 @@ type Size interface {
 	Size() int64
 }
 
-// See https://en.wikipedia.org/wiki/Lehmer_random_number_generator
-func generatePRData(l int) []byte {
-	res := make([]byte, l)
-	seed := uint64(1)
-	for i := 0; i < l; i++ {
-		seed = seed * 48271 % 2147483647
-		res[i] = byte(seed)
-	}
-	return res
-}
-
 func setupHandler(www string) http.Handler {
 	mux := http.NewServeMux()
 
 	if len(www) > 0 {
 		mux.Handle("/", http.FileServer(http.Dir(www)))
 	} else {
+		// Serve /<N>[?seed=<S>] with N bytes of pseudo-random data, generated on the fly.
+		// The client can verify the data using the seed (bulkdata.DefaultSeed, if not set).
 		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 			fmt.Printf("%#v\n", r)
-			const maxSize = 1 << 30 // 1 GB
-			num, err := strconv.ParseInt(strings.ReplaceAll(r.RequestURI, "/", ""), 10, 64)
+			const maxSize = 1 << 40 // 1 TB
+			num, err := strconv.ParseInt(strings.ReplaceAll(r.URL.Path, "/", ""), 10, 64)
 			if err != nil || num <= 0 || num > maxSize {
 				w.WriteHeader(400)
 				return
 			}
-			w.Write(generatePRData(int(num)))
+			seed := uint64(bulkdata.DefaultSeed)
+			if s := r.URL.Query().Get("seed"); s != "" {
+				seed, err = strconv.ParseUint(s, 10, 64)
+				if err != nil {
+					w.WriteHeader(400)
+					return
+				}
+			}
+			w.Header().Set("Content-Length", strconv.FormatInt(num, 10))
+			io.Copy(w, bulkdata.NewReader(seed, num))
 		})
 	}
 
@@ -147,6 +146,8 @@ func main() {
 	enableQlog := flag.Bool("qlog", false, "output a qlog (in the same directory)")
 	startAlgostr := flag.String("start", "", "choose start algo amongst defined start algos in utils.algorithms")
 	congestionAlgostr := flag.String("congestion", "", "choose congestion algo amongst defined start algos in utils.algorithms")
+	injectLoss := flag.Float64("inject-loss", 0, "drop packets sent by the server with this probability (between 0 and 1)")
+	injectJitter := flag.Duration("inject-jitter", 0, "delay packets sent by the server by a random duration up to this value")
 	flag.Parse()
 
 	logger := utils.DefaultLogger
@@ -165,17 +166,13 @@ func main() {
 	handler := setupHandler(*www)
 	log.Printf("access to files : %s\n", *www)
 	quicConf := &quic.Config{}
+	if *injectLoss > 0 || *injectJitter > 0 {
+		// The profile consists of a single step, that is repeated for the lifetime of the connection.
+		quicConf.EmulationProfile = []quic.EmulationStep{{Duration: time.Hour, Loss: *injectLoss, Jitter: *injectJitter}}
+	}
 	if *enableQlog {
 		nomQlog := fmt.Sprintf("%s_%s", *startAlgostr, *congestionAlgostr)
-		quicConf.Tracer = qlog.NewTracer(func(_ logging.Perspective, connID []byte) io.WriteCloser {
-			filename := fmt.Sprintf("server_%s_%x.qlog", nomQlog, connID)
-			f, err := os.Create(filename)
-			if err != nil {
-				log.Fatal(err)
-			}
-			log.Printf("Creating qlog file %s.\n", filename)
-			return utils.NewBufferedWriteCloser(bufio.NewWriter(f), f)
-		})
+		quicConf.Tracer = qlog.NewTracer(qlog.NewFileSink(".", "{role}_"+nomQlog+"_{odcid}.qlog"))
 	}
 
 	startAlgo := utils.String2Start(*startAlgostr)
@@ -0,0 +1,144 @@
+// quicperf measures the throughput of raw QUIC streams.
+// The client requests a number of bytes on a new stream, the server generates them on the fly,
+// and the client verifies the data, so that neither side is limited by file system I/O.
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"flag"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/bulkdata"
+	"github.com/lucas-clemente/quic-go/internal/testdata"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+const alpn = "quicperf"
+
+// A request consists of the number of bytes requested and the seed, both encoded as 64 bit big endian integers.
+const requestLen = 16
+
+func main() {
+	server := flag.Bool("server", false, "run as server")
+	addr := flag.String("addr", "localhost:4433", "address to listen on / to connect to")
+	length := flag.Int64("bytes", 100<<20, "number of bytes to request on each stream")
+	seed := flag.Uint64("seed", bulkdata.DefaultSeed, "seed of the generated data")
+	streams := flag.Int("streams", 1, "number of parallel streams")
+	insecure := flag.Bool("insecure", false, "skip certificate verification")
+	startAlgostr := flag.String("start", "", "choose start algo amongst defined start algos in utils.algorithms")
+	congestionAlgostr := flag.String("congestion", "", "choose congestion algo amongst defined start algos in utils.algorithms")
+	flag.Parse()
+
+	startAlgo := utils.String2Start(*startAlgostr)
+	congestionAlgo := utils.String2Congestion(*congestionAlgostr)
+
+	var err error
+	if *server {
+		err = runServer(*addr, startAlgo, congestionAlgo)
+	} else {
+		err = runClient(*addr, *length, *seed, *streams, *insecure, startAlgo, congestionAlgo)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func runServer(addr string, startAlgo utils.StartAlgo, congestionAlgo utils.CongestionAlgo) error {
+	tlsConf := testdata.GetTLSConfig()
+	tlsConf.NextProtos = []string{alpn}
+	ln, err := quic.ListenAddr(addr, tlsConf, nil, startAlgo, congestionAlgo)
+	if err != nil {
+		return err
+	}
+	log.Printf("Listening on %s", ln.Addr())
+	for {
+		sess, err := ln.Accept(context.Background())
+		if err != nil {
+			return err
+		}
+		go func() {
+			for {
+				str, err := sess.AcceptStream(context.Background())
+				if err != nil {
+					return
+				}
+				go handleStream(str)
+			}
+		}()
+	}
+}
+
+func handleStream(str quic.Stream) {
+	defer str.Close()
+	req := make([]byte, requestLen)
+	if _, err := io.ReadFull(str, req); err != nil {
+		log.Printf("Error reading request: %s", err)
+		str.CancelWrite(0)
+		return
+	}
+	length := int64(binary.BigEndian.Uint64(req[:8]))
+	seed := binary.BigEndian.Uint64(req[8:])
+	if _, err := io.Copy(str, bulkdata.NewReader(seed, length)); err != nil {
+		log.Printf("Error sending data: %s", err)
+	}
+}
+
+func runClient(addr string, length int64, seed uint64, streams int, insecure bool, startAlgo utils.StartAlgo, congestionAlgo utils.CongestionAlgo) error {
+	tlsConf := &tls.Config{
+		RootCAs:            testdata.GetRootCA(),
+		InsecureSkipVerify: insecure,
+		NextProtos:         []string{alpn},
+	}
+	start := time.Now()
+	sess, err := quic.DialAddr(addr, tlsConf, nil, startAlgo, congestionAlgo)
+	if err != nil {
+		return err
+	}
+	defer sess.CloseWithError(0, "")
+	log.Printf("Handshake completed after %s", time.Since(start))
+
+	var wg sync.WaitGroup
+	errChan := make(chan error, streams)
+	for i := 0; i < streams; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errChan <- request(sess, length, seed)
+		}()
+	}
+	wg.Wait()
+	close(errChan)
+	for err := range errChan {
+		if err != nil {
+			return err
+		}
+	}
+	duration := time.Since(start)
+	total := length * int64(streams)
+	log.Printf("Transferred and verified %d bytes in %s (%.2f Mbit/s)", total, duration, float64(total)*8/duration.Seconds()/1e6)
+	return nil
+}
+
+func request(sess quic.Session, length int64, seed uint64) error {
+	str, err := sess.OpenStreamSync(context.Background())
+	if err != nil {
+		return err
+	}
+	req := make([]byte, requestLen)
+	binary.BigEndian.PutUint64(req[:8], uint64(length))
+	binary.BigEndian.PutUint64(req[8:], seed)
+	if _, err := str.Write(req); err != nil {
+		return err
+	}
+	if err := str.Close(); err != nil {
+		return err
+	}
+	_, err = bulkdata.Verify(str, seed, length)
+	return err
+}
@@ -17,6 +17,9 @@ type framer interface {
 	AppendControlFrames([]ackhandler.Frame, protocol.ByteCount) ([]ackhandler.Frame, protocol.ByteCount)
 
 	AddActiveStream(protocol.StreamID)
+	// SetStreamPriority sets the priority used to schedule the stream.
+	// Setting the DefaultStreamPriority removes the stream's entry.
+	SetStreamPriority(protocol.StreamID, StreamPriority)
 	AppendStreamFrames([]ackhandler.Frame, protocol.ByteCount) ([]ackhandler.Frame, protocol.ByteCount)
 
 	Handle0RTTRejection() error
@@ -30,6 +33,7 @@ type framerI struct {
 
 	activeStreams map[protocol.StreamID]struct{}
 	streamQueue   []protocol.StreamID
+	priorities    map[protocol.StreamID]StreamPriority // only contains streams that don't use the DefaultStreamPriority
 
 	controlFrameMutex sync.Mutex
 	controlFrames     []wire.Frame
@@ -44,6 +48,7 @@ func newFramer(
 	return &framerI{
 		streamGetter:  streamGetter,
 		activeStreams: make(map[protocol.StreamID]struct{}),
+		priorities:    make(map[protocol.StreamID]StreamPriority),
 		version:       v,
 	}
 }
@@ -103,8 +108,9 @@ func (f *framerI) AppendStreamFrames(frames []ackhandler.Frame, maxLen protocol.
 		if protocol.MinStreamFrameSize+length > maxLen {
 			break
 		}
-		id := f.streamQueue[0]
-		f.streamQueue = f.streamQueue[1:]
+		idx := f.nextStream()
+		id := f.streamQueue[idx]
+		f.streamQueue = append(f.streamQueue[:idx], f.streamQueue[idx+1:]...)
 		// This should never return an error. Better check it anyway.
 		// The stream will only be in the streamQueue, if it enqueued itself there.
 		str, err := f.streamGetter.GetOrOpenSendStream(id)
@@ -144,6 +150,46 @@ func (f *framerI) AppendStreamFrames(frames []ackhandler.Frame, maxLen protocol.
 	return frames, length
 }
 
+// nextStream returns the index (in the streamQueue) of the stream that is sent next.
+// Streams with a lower urgency are sent first.
+// At the same urgency, non-incremental streams are sent first, in the order of their stream IDs,
+// and incremental streams are sent round-robin.
+// must be called with the mutex locked
+func (f *framerI) nextStream() int {
+	if len(f.priorities) == 0 {
+		return 0
+	}
+	var next int
+	nextPrio := f.priority(f.streamQueue[0])
+	for i, id := range f.streamQueue[1:] {
+		prio := f.priority(id)
+		if prio.Urgency < nextPrio.Urgency ||
+			(prio.Urgency == nextPrio.Urgency && !prio.Incremental && (nextPrio.Incremental || id < f.streamQueue[next])) {
+			next = i + 1
+			nextPrio = prio
+		}
+	}
+	return next
+}
+
+// must be called with the mutex locked
+func (f *framerI) priority(id protocol.StreamID) StreamPriority {
+	if prio, ok := f.priorities[id]; ok {
+		return prio
+	}
+	return DefaultStreamPriority
+}
+
+func (f *framerI) SetStreamPriority(id protocol.StreamID, prio StreamPriority) {
+	f.mutex.Lock()
+	if prio == DefaultStreamPriority {
+		delete(f.priorities, id)
+	} else {
+		f.priorities[id] = prio
+	}
+	f.mutex.Unlock()
+}
+
 func (f *framerI) Handle0RTTRejection() error {
 	f.mutex.Lock()
 	defer f.mutex.Unlock()
@@ -153,6 +199,9 @@ func (f *framerI) Handle0RTTRejection() error {
 	for id := range f.activeStreams {
 		delete(f.activeStreams, id)
 	}
+	for id := range f.priorities {
+		delete(f.priorities, id)
+	}
 	var j int
 	for i, frame := range f.controlFrames {
 		switch frame.(type) {
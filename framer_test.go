@@ -374,6 +374,83 @@ var _ = Describe("Framer", func() {
 			Expect(length).To(Equal(f.Length(version)))
 		})
 
+		Context("priorities", func() {
+			const id3 = protocol.StreamID(12)
+
+			var stream3 *MockSendStreamI
+
+			BeforeEach(func() {
+				stream3 = NewMockSendStreamI(mockCtrl)
+				streamGetter.EXPECT().GetOrOpenSendStream(id1).Return(stream1, nil).AnyTimes()
+				streamGetter.EXPECT().GetOrOpenSendStream(id2).Return(stream2, nil).AnyTimes()
+				streamGetter.EXPECT().GetOrOpenSendStream(id3).Return(stream3, nil).AnyTimes()
+			})
+
+			// popFrom makes the stream return a frame with the given data, and says that it has more data
+			popFrom := func(str *MockSendStreamI, id protocol.StreamID) {
+				str.EXPECT().popStreamFrame(gomock.Any()).Return(&ackhandler.Frame{Frame: &wire.StreamFrame{StreamID: id, Data: []byte("foobar")}}, true)
+			}
+
+			nextStreamID := func() protocol.StreamID {
+				frames, _ := framer.AppendStreamFrames(nil, protocol.MinStreamFrameSize)
+				ExpectWithOffset(1, frames).To(HaveLen(1))
+				return frames[0].Frame.(*wire.StreamFrame).StreamID
+			}
+
+			It("sends streams with a lower urgency first", func() {
+				framer.SetStreamPriority(id2, StreamPriority{Urgency: 1, Incremental: true})
+				framer.AddActiveStream(id1)
+				framer.AddActiveStream(id2)
+				popFrom(stream2, id2)
+				popFrom(stream2, id2)
+				Expect(nextStreamID()).To(Equal(id2))
+				Expect(nextStreamID()).To(Equal(id2))
+				framer.SetStreamPriority(id2, DefaultStreamPriority)
+				popFrom(stream1, id1)
+				Expect(nextStreamID()).To(Equal(id1))
+			})
+
+			It("sends non-incremental streams one after the other, in the order of their stream IDs", func() {
+				framer.SetStreamPriority(id1, StreamPriority{Urgency: 3})
+				framer.SetStreamPriority(id2, StreamPriority{Urgency: 3})
+				framer.AddActiveStream(id3) // uses the default priority (incremental)
+				framer.AddActiveStream(id2)
+				framer.AddActiveStream(id1)
+				popFrom(stream1, id1)
+				popFrom(stream1, id1)
+				Expect(nextStreamID()).To(Equal(id1))
+				Expect(nextStreamID()).To(Equal(id1))
+				stream1.EXPECT().popStreamFrame(gomock.Any()).Return(&ackhandler.Frame{Frame: &wire.StreamFrame{StreamID: id1}}, false)
+				Expect(nextStreamID()).To(Equal(id1))
+				popFrom(stream2, id2)
+				Expect(nextStreamID()).To(Equal(id2))
+			})
+
+			It("sends incremental streams round-robin", func() {
+				framer.SetStreamPriority(id1, StreamPriority{Urgency: 5, Incremental: true})
+				framer.SetStreamPriority(id2, StreamPriority{Urgency: 5, Incremental: true})
+				framer.SetStreamPriority(id3, StreamPriority{Urgency: 6})
+				framer.AddActiveStream(id3)
+				framer.AddActiveStream(id2)
+				framer.AddActiveStream(id1)
+				popFrom(stream2, id2)
+				popFrom(stream1, id1)
+				popFrom(stream2, id2)
+				Expect(nextStreamID()).To(Equal(id2))
+				Expect(nextStreamID()).To(Equal(id1))
+				Expect(nextStreamID()).To(Equal(id2))
+			})
+
+			It("forgets priorities when 0-RTT is rejected", func() {
+				framer.SetStreamPriority(id2, StreamPriority{Urgency: 0})
+				Expect(framer.Handle0RTTRejection()).To(Succeed())
+				framer.AddActiveStream(id1)
+				framer.AddActiveStream(id2)
+				popFrom(stream1, id1)
+				Expect(nextStreamID()).To(Equal(id1))
+			})
+		})
+
 		It("drops all STREAM frames when 0-RTT is rejected", func() {
 			framer.AddActiveStream(id1)
 			Expect(framer.Handle0RTTRejection()).To(Succeed())
@@ -0,0 +1,94 @@
+package quic
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrSignerBusy is returned by a HandshakeSigner if too many signing operations are pending.
+var ErrSignerBusy = errors.New("quic: handshake signer busy")
+
+// A ContextSigner performs private-key operations that can be canceled,
+// e.g. using a hardware security module (HSM) or a remote key management service (KMS).
+type ContextSigner interface {
+	// Public returns the public key corresponding to the private key.
+	Public() crypto.PublicKey
+	// SignContext signs digest. The context is canceled when the signing operation times out.
+	SignContext(ctx context.Context, digest []byte, opts crypto.SignerOpts) ([]byte, error)
+}
+
+// A HandshakeSigner offloads the private-key operations of the server's certificate to a ContextSigner.
+// It implements crypto.Signer, and is used as the PrivateKey of a tls.Certificate.
+// The TLS handshake runs on its own go routine, so a slow signer doesn't block the event loop of the session.
+//
+// To apply backpressure, the number of concurrent signing operations is limited.
+// Operations that can't be started immediately are queued.
+// If the queue is full, signing fails with ErrSignerBusy, which aborts the handshake.
+// If the HandshakeSigner is set in the Config, the server refuses new connections while the queue is full.
+type HandshakeSigner struct {
+	signer  ContextSigner
+	timeout time.Duration
+
+	// running limits the number of concurrent signing operations
+	running chan struct{}
+	// pending limits the number of running and queued signing operations
+	pending chan struct{}
+}
+
+var _ crypto.Signer = &HandshakeSigner{}
+
+// NewHandshakeSigner creates a new HandshakeSigner.
+// At most maxConcurrent signing operations are run at the same time, and at most maxQueued operations are queued.
+// If timeout is set, it applies to each signing operation, including the time spent in the queue.
+func NewHandshakeSigner(signer ContextSigner, maxConcurrent, maxQueued int, timeout time.Duration) *HandshakeSigner {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	if maxQueued < 0 {
+		maxQueued = 0
+	}
+	return &HandshakeSigner{
+		signer:  signer,
+		timeout: timeout,
+		running: make(chan struct{}, maxConcurrent),
+		pending: make(chan struct{}, maxConcurrent+maxQueued),
+	}
+}
+
+// Public returns the public key of the signer.
+func (s *HandshakeSigner) Public() crypto.PublicKey {
+	return s.signer.Public()
+}
+
+// Sign signs digest using the ContextSigner.
+// The io.Reader is ignored.
+func (s *HandshakeSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	select {
+	case s.pending <- struct{}{}:
+	default:
+		return nil, ErrSignerBusy
+	}
+	defer func() { <-s.pending }()
+
+	ctx := context.Background()
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	select {
+	case s.running <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-s.running }()
+	return s.signer.SignContext(ctx, digest, opts)
+}
+
+// Busy says if the queue is full, i.e. if a new signing operation would fail.
+func (s *HandshakeSigner) Busy() bool {
+	return len(s.pending) >= cap(s.pending)
+}
@@ -0,0 +1,131 @@
+package quic
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/tls"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/testdata"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type testContextSigner struct {
+	crypto.Signer
+	block    chan struct{}
+	numCalls int32
+}
+
+var _ ContextSigner = &testContextSigner{}
+
+func (s *testContextSigner) SignContext(ctx context.Context, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	atomic.AddInt32(&s.numCalls, 1)
+	if s.block != nil {
+		select {
+		case <-s.block:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return s.Signer.Sign(rand.Reader, digest, opts)
+}
+
+var _ = Describe("Handshake signer", func() {
+	var key crypto.Signer
+
+	BeforeEach(func() {
+		key = testdata.GetTLSConfig().Certificates[0].PrivateKey.(crypto.Signer)
+	})
+
+	It("uses the public key of the signer", func() {
+		s := NewHandshakeSigner(&testContextSigner{Signer: key}, 1, 0, 0)
+		Expect(s.Public()).To(Equal(key.Public()))
+	})
+
+	It("signs", func() {
+		cs := &testContextSigner{Signer: key}
+		s := NewHandshakeSigner(cs, 1, 0, 0)
+		digest := make([]byte, 32)
+		sig, err := s.Sign(nil, digest, crypto.SHA256)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sig).ToNot(BeEmpty())
+		Expect(atomic.LoadInt32(&cs.numCalls)).To(BeEquivalentTo(1))
+		Expect(s.Busy()).To(BeFalse())
+	})
+
+	It("queues signing operations, and rejects them when the queue is full", func() {
+		cs := &testContextSigner{Signer: key, block: make(chan struct{})}
+		s := NewHandshakeSigner(cs, 1, 1, 0)
+		errChan := make(chan error, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				_, err := s.Sign(nil, make([]byte, 32), crypto.SHA256)
+				errChan <- err
+			}()
+		}
+		Eventually(s.Busy).Should(BeTrue())
+		// only one operation is run at a time
+		Consistently(func() int32 { return atomic.LoadInt32(&cs.numCalls) }).Should(BeEquivalentTo(1))
+		_, err := s.Sign(nil, make([]byte, 32), crypto.SHA256)
+		Expect(err).To(MatchError(ErrSignerBusy))
+		close(cs.block)
+		Eventually(errChan).Should(Receive(BeNil()))
+		Eventually(errChan).Should(Receive(BeNil()))
+		Expect(atomic.LoadInt32(&cs.numCalls)).To(BeEquivalentTo(2))
+		Expect(s.Busy()).To(BeFalse())
+	})
+
+	It("times out", func() {
+		cs := &testContextSigner{Signer: key, block: make(chan struct{})}
+		s := NewHandshakeSigner(cs, 1, 1, 50*time.Millisecond)
+		errChan := make(chan error, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				_, err := s.Sign(nil, make([]byte, 32), crypto.SHA256)
+				errChan <- err
+			}()
+		}
+		// the time spent in the queue counts towards the timeout
+		Eventually(errChan).Should(Receive(MatchError(context.DeadlineExceeded)))
+		Eventually(errChan).Should(Receive(MatchError(context.DeadlineExceeded)))
+		Expect(s.Busy()).To(BeFalse())
+	})
+
+	It("is used for the handshake", func() {
+		cs := &testContextSigner{Signer: key}
+		tlsConf := testdata.GetTLSConfig()
+		tlsConf.Certificates[0].PrivateKey = NewHandshakeSigner(cs, 2, 10, 0)
+		tlsConf.NextProtos = []string{"signer"}
+		serverConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).ToNot(HaveOccurred())
+		defer serverConn.Close()
+		ln, err := Listen(serverConn, tlsConf, &Config{HandshakeSigner: tlsConf.Certificates[0].PrivateKey.(*HandshakeSigner)})
+		Expect(err).ToNot(HaveOccurred())
+		defer ln.Close()
+
+		clientConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).ToNot(HaveOccurred())
+		defer clientConn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		sess, err := DialContext(
+			ctx,
+			clientConn,
+			ln.Addr(),
+			"localhost",
+			&tls.Config{RootCAs: testdata.GetRootCA(), NextProtos: []string{"signer"}},
+			nil,
+			utils.ChooseHystart,
+			utils.ChooseNewReno,
+		)
+		Expect(err).ToNot(HaveOccurred())
+		defer sess.CloseWithError(0, "")
+		Expect(atomic.LoadInt32(&cs.numCalls)).To(BeEquivalentTo(1))
+	})
+})
@@ -0,0 +1,55 @@
+package http3
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/lucas-clemente/quic-go"
+)
+
+// An AccessLogEntry describes a request handled by the Server, see Server.AccessLog.
+// In addition to the usual fields of an access log, it contains statistics of the QUIC connection and stream,
+// allowing operators to correlate slow requests with the health of the network path.
+type AccessLogEntry struct {
+	Method     string
+	Host       string
+	Path       string // the request URI
+	RemoteAddr string
+	StreamID   quic.StreamID
+	// Status is the status code of the response.
+	// It is 0 if the handler took over the stream (see DataStreamer) without writing a response header.
+	Status int
+	// BytesSent is the number of bytes of the response body written by the handler.
+	BytesSent int64
+	// Duration is the time from receiving the request until the response was written.
+	Duration time.Duration
+
+	// HandshakeRTT is the smoothed RTT of the connection when the handshake completed.
+	HandshakeRTT time.Duration
+	// SmoothedRTT is the smoothed RTT of the connection when the response was written.
+	SmoothedRTT time.Duration
+	// BytesRetransmitted is the number of bytes of stream data that were retransmitted on the request stream.
+	// Data that is lost after the response was written is not counted.
+	BytesRetransmitted uint64
+}
+
+func newAccessLogEntry(sess quic.Session, str quic.Stream, req *http.Request, w *responseWriter, received time.Time) AccessLogEntry {
+	connState := sess.ConnectionState()
+	status := w.status
+	if !w.headerWritten {
+		status = 0
+	}
+	return AccessLogEntry{
+		Method:             req.Method,
+		Host:               req.Host,
+		Path:               req.RequestURI,
+		RemoteAddr:         req.RemoteAddr,
+		StreamID:           str.StreamID(),
+		Status:             status,
+		BytesSent:          w.bytesWritten,
+		Duration:           time.Since(received),
+		HandshakeRTT:       connState.HandshakeRTT,
+		SmoothedRTT:        connState.SmoothedRTT,
+		BytesRetransmitted: str.Stats().BytesRetransmitted,
+	}
+}
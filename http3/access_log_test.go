@@ -0,0 +1,70 @@
+package http3
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/testdata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Access log", func() {
+	It("logs requests with statistics of the connection", func() {
+		entries := make(chan AccessLogEntry, 2)
+		mux := http.NewServeMux()
+		mux.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("foobar"))
+		})
+		server := &Server{
+			Server:    &http.Server{Handler: mux, TLSConfig: testdata.GetTLSConfig()},
+			AccessLog: func(e AccessLogEntry) { entries <- e },
+		}
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).ToNot(HaveOccurred())
+		defer conn.Close()
+		go server.Serve(conn)
+		defer server.Close()
+
+		rt := &RoundTripper{
+			TLSClientConfig: &tls.Config{RootCAs: testdata.GetRootCA()},
+			QuicConfig:      &quic.Config{Versions: []quic.VersionNumber{quic.VersionDraft29}},
+		}
+		defer rt.Close()
+		host := fmt.Sprintf("localhost:%d", conn.LocalAddr().(*net.UDPAddr).Port)
+		for _, path := range []string{"/hello?foo=bar", "/notfound"} {
+			req, err := http.NewRequest(http.MethodGet, "https://"+host+path, nil)
+			Expect(err).ToNot(HaveOccurred())
+			rsp, err := rt.RoundTrip(req)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = ioutil.ReadAll(rsp.Body)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		var entry AccessLogEntry
+		Eventually(entries).Should(Receive(&entry))
+		Expect(entry.Method).To(Equal(http.MethodGet))
+		Expect(entry.Host).To(Equal(host))
+		Expect(entry.Path).To(Equal("/hello?foo=bar"))
+		Expect(entry.StreamID).To(Equal(quic.StreamID(0)))
+		Expect(entry.Status).To(Equal(http.StatusOK))
+		Expect(entry.BytesSent).To(BeEquivalentTo(6))
+		Expect(entry.Duration).To(BeNumerically(">", 0))
+		Expect(entry.SmoothedRTT).To(BeNumerically(">", 0))
+		Expect(entry.BytesRetransmitted).To(BeZero())
+		remoteAddr := entry.RemoteAddr
+
+		Eventually(entries).Should(Receive(&entry))
+		Expect(entry.Path).To(Equal("/notfound"))
+		Expect(entry.StreamID).To(Equal(quic.StreamID(4)))
+		Expect(entry.Status).To(Equal(http.StatusNotFound))
+		Expect(entry.RemoteAddr).To(Equal(remoteAddr))
+		// The first request might be handled before the handshake completed on the server side.
+		Expect(entry.HandshakeRTT).To(BeNumerically(">", 0))
+	})
+})
@@ -1,28 +1,55 @@
 package http3
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
 
 	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/qpack"
 )
 
 // The body of a http.Request or http.Response.
 type body struct {
 	str quic.Stream
 
+	// only set for the http.Response
+	sess quic.Session
+
 	// only set for the http.Response
 	// The channel is closed when the user is done with this response:
 	// either when Read() errors, or when Close() is called.
 	reqDone       chan<- struct{}
 	reqDoneClosed bool
+	onDone        func() // called right after reqDone was closed
 
 	onFrameError func()
 
+	// only set for the http.Response
+	priorityMutex      sync.Mutex
+	priority           Priority
+	sendPriorityUpdate func(Priority) error
+	earlyData          EarlyDataStatus
+
+	// The trailer section is decoded into the Trailer of the http.Request or http.Response.
+	trailer          *http.Header
+	decoder          *qpack.Decoder
+	maxTrailerBytes  uint64
+	trailersReceived bool
+
 	bytesRemainingInFrame uint64
 }
 
-var _ io.ReadCloser = &body{}
+var (
+	_ io.ReadCloser = &body{}
+	_ DataStreamer  = &body{}
+	_ Hijacker      = &body{}
+	_ StreamInfo    = &body{}
+	_ Prioritizer   = &body{}
+)
 
 func newRequestBody(str quic.Stream, onFrameError func()) *body {
 	return &body{
@@ -31,8 +58,9 @@ func newRequestBody(str quic.Stream, onFrameError func()) *body {
 	}
 }
 
-func newResponseBody(str quic.Stream, done chan<- struct{}, onFrameError func()) *body {
+func newResponseBody(sess quic.Session, str quic.Stream, done chan<- struct{}, onFrameError func()) *body {
 	return &body{
+		sess:         sess,
 		str:          str,
 		onFrameError: onFrameError,
 		reqDone:      done,
@@ -44,7 +72,14 @@ func (r *body) Read(b []byte) (int, error) {
 	if err != nil {
 		r.requestDone()
 	}
-	return n, err
+	return n, wrapStreamError(err)
+}
+
+// setTrailer sets the header that the trailer section is decoded into.
+func (r *body) setTrailer(trailer *http.Header, decoder *qpack.Decoder, maxTrailerBytes uint64) {
+	r.trailer = trailer
+	r.decoder = decoder
+	r.maxTrailerBytes = maxTrailerBytes
 }
 
 func (r *body) readImpl(b []byte) (int, error) {
@@ -55,9 +90,16 @@ func (r *body) readImpl(b []byte) (int, error) {
 			if err != nil {
 				return 0, err
 			}
+			if r.trailersReceived {
+				// The trailer section is the last frame on the stream.
+				r.onFrameError()
+				return 0, fmt.Errorf("peer sent a frame after the trailers: %T", frame)
+			}
 			switch f := frame.(type) {
 			case *headersFrame:
-				// skip HEADERS frames
+				if err := r.readTrailers(f); err != nil {
+					return 0, err
+				}
 				continue
 			case *dataFrame:
 				r.bytesRemainingInFrame = f.Length
@@ -88,11 +130,99 @@ func (r *body) requestDone() {
 	}
 	close(r.reqDone)
 	r.reqDoneClosed = true
+	if r.onDone != nil {
+		r.onDone()
+	}
+}
+
+// DataStream takes over the stream of the response.
+// This is used for responses to extended CONNECT requests,
+// where the request stream is used by the protocol after the response headers.
+// After a call to DataStream, the body must not be read anymore,
+// and canceling the request context doesn't cancel the stream.
+func (r *body) DataStream() quic.Stream {
+	r.requestDone()
+	return r.str
+}
+
+// Session returns the QUIC session that the response was received on.
+func (r *body) Session() quic.Session {
+	return r.sess
+}
+
+// StreamID returns the ID of the request stream.
+func (r *body) StreamID() quic.StreamID {
+	return r.str.StreamID()
+}
+
+// StreamStats returns statistics about the data sent on the request stream.
+// For a response, this is the request body sent by the client.
+func (r *body) StreamStats() quic.StreamStats {
+	return r.str.Stats()
+}
+
+// Priority returns the priority of the request.
+func (r *body) Priority() Priority {
+	r.priorityMutex.Lock()
+	defer r.priorityMutex.Unlock()
+	return r.priority
+}
+
+// SetPriority sends a PRIORITY_UPDATE frame to the server.
+// It also changes the priority of the request body.
+func (r *body) SetPriority(p Priority) error {
+	if r.sendPriorityUpdate == nil {
+		return errors.New("http3: the priority can only be changed for responses")
+	}
+	if err := r.sendPriorityUpdate(p); err != nil {
+		return err
+	}
+	r.priorityMutex.Lock()
+	r.priority = p
+	r.priorityMutex.Unlock()
+	r.str.SetPriority(p.streamPriority())
+	return nil
+}
+
+func (r *body) earlyDataStatus() EarlyDataStatus {
+	return r.earlyData
+}
+
+// readTrailers reads the trailer section.
+func (r *body) readTrailers(f *headersFrame) error {
+	if r.decoder == nil {
+		// Trailers are not decoded for this body. Skip them.
+		_, err := io.CopyN(ioutil.Discard, r.str, int64(f.Length))
+		return err
+	}
+	if f.Length > r.maxTrailerBytes {
+		return fmt.Errorf("HEADERS frame too large: %d bytes (max: %d)", f.Length, r.maxTrailerBytes)
+	}
+	headerBlock := make([]byte, f.Length)
+	if _, err := io.ReadFull(r.str, headerBlock); err != nil {
+		return err
+	}
+	hfs, err := r.decoder.DecodeStream(r.str.Context(), uint64(r.str.StreamID()), headerBlock)
+	if err != nil {
+		return err
+	}
+	r.trailersReceived = true
+	if *r.trailer == nil {
+		*r.trailer = make(http.Header, len(hfs))
+	}
+	for _, hf := range hfs {
+		if len(hf.Name) > 0 && hf.Name[0] == ':' {
+			return fmt.Errorf("invalid pseudo header in trailers: %s", hf.Name)
+		}
+		key := http.CanonicalHeaderKey(hf.Name)
+		(*r.trailer)[key] = append((*r.trailer)[key], hf.Value)
+	}
+	return nil
 }
 
 func (r *body) Close() error {
 	r.requestDone()
 	// If the EOF was read, CancelRead() is a no-op.
-	r.str.CancelRead(quic.StreamErrorCode(errorRequestCanceled))
+	r.str.CancelRead(quic.StreamErrorCode(ErrCodeRequestCanceled))
 	return nil
 }
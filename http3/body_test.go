@@ -2,12 +2,16 @@ package http3
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net/http"
 
 	"github.com/golang/mock/gomock"
 	"github.com/lucas-clemente/quic-go"
 	mockquic "github.com/lucas-clemente/quic-go/internal/mocks/quic"
+	"github.com/lucas-clemente/quic-go/internal/qpack"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -68,7 +72,7 @@ var _ = Describe("Body", func() {
 					rb = newRequestBody(str, errorCb)
 				case bodyTypeResponse:
 					reqDone = make(chan struct{})
-					rb = newResponseBody(str, reqDone, errorCb)
+					rb = newResponseBody(nil, str, reqDone, errorCb)
 				}
 			})
 
@@ -142,6 +146,76 @@ var _ = Describe("Body", func() {
 				Expect(b).To(Equal([]byte("foobar")))
 			})
 
+			Context("trailers", func() {
+				var trailer http.Header
+
+				getTrailersFrame := func(fields ...qpack.HeaderField) []byte {
+					headers := &bytes.Buffer{}
+					enc := qpack.NewEncoder(headers)
+					for _, f := range fields {
+						Expect(enc.WriteField(f)).To(Succeed())
+					}
+					b := &bytes.Buffer{}
+					(&headersFrame{Length: uint64(headers.Len())}).Write(b)
+					b.Write(headers.Bytes())
+					return b.Bytes()
+				}
+
+				BeforeEach(func() {
+					str.EXPECT().StreamID().AnyTimes()
+					str.EXPECT().Context().Return(context.Background()).AnyTimes()
+					trailer = http.Header{"Foo": nil}
+					rb.setTrailer(&trailer, qpack.NewDecoder(nil), 1000)
+				})
+
+				It("reads trailers", func() {
+					buf.Write(getDataFrame([]byte("foobar")))
+					buf.Write(getTrailersFrame(
+						qpack.HeaderField{Name: "foo", Value: "bar"},
+						qpack.HeaderField{Name: "grpc-status", Value: "0"},
+					))
+					data, err := ioutil.ReadAll(rb)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(data).To(Equal([]byte("foobar")))
+					Expect(trailer).To(Equal(http.Header{"Foo": []string{"bar"}, "Grpc-Status": []string{"0"}}))
+				})
+
+				It("creates the trailer header, if no trailers were declared", func() {
+					trailer = nil
+					buf.Write(getTrailersFrame(qpack.HeaderField{Name: "foo", Value: "bar"}))
+					_, err := ioutil.ReadAll(rb)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(trailer).To(Equal(http.Header{"Foo": []string{"bar"}}))
+				})
+
+				It("errors on frames after the trailers", func() {
+					buf.Write(getTrailersFrame(qpack.HeaderField{Name: "foo", Value: "bar"}))
+					buf.Write(getDataFrame([]byte("foobar")))
+					_, err := rb.Read([]byte{0})
+					Expect(err).To(MatchError("peer sent a frame after the trailers: *http3.dataFrame"))
+					Expect(errorCbCalled).To(BeTrue())
+				})
+
+				It("rejects pseudo headers", func() {
+					buf.Write(getTrailersFrame(qpack.HeaderField{Name: ":status", Value: "200"}))
+					_, err := rb.Read([]byte{0})
+					Expect(err).To(MatchError("invalid pseudo header in trailers: :status"))
+				})
+
+				It("errors on too large trailers", func() {
+					(&headersFrame{Length: 1001}).Write(buf)
+					_, err := rb.Read([]byte{0})
+					Expect(err).To(MatchError("HEADERS frame too large: 1001 bytes (max: 1000)"))
+				})
+			})
+
+			It("exposes the stream ID and statistics", func() {
+				str.EXPECT().StreamID().Return(quic.StreamID(8))
+				str.EXPECT().Stats().Return(quic.StreamStats{BytesSent: 42})
+				Expect(rb.StreamID()).To(Equal(quic.StreamID(8)))
+				Expect(rb.StreamStats().BytesSent).To(BeEquivalentTo(42))
+			})
+
 			It("errors when it can't parse the frame", func() {
 				buf.Write([]byte("invalid"))
 				_, err := rb.Read([]byte{0})
@@ -172,13 +246,20 @@ var _ = Describe("Body", func() {
 					Expect(err).To(HaveOccurred())
 				})
 
+				It("exposes the stream of gzipped responses", func() {
+					str.EXPECT().StreamID().Return(quic.StreamID(8))
+					gz := newGzipReader(rb)
+					Expect(gz.(StreamInfo).StreamID()).To(Equal(quic.StreamID(8)))
+					Expect(gz.(Hijacker).Session()).To(BeNil())
+				})
+
 				It("closes responses", func() {
-					str.EXPECT().CancelRead(quic.StreamErrorCode(errorRequestCanceled))
+					str.EXPECT().CancelRead(quic.StreamErrorCode(ErrCodeRequestCanceled))
 					Expect(rb.Close()).To(Succeed())
 				})
 
 				It("allows multiple calls to Close", func() {
-					str.EXPECT().CancelRead(quic.StreamErrorCode(errorRequestCanceled)).MaxTimes(2)
+					str.EXPECT().CancelRead(quic.StreamErrorCode(ErrCodeRequestCanceled)).MaxTimes(2)
 					Expect(rb.Close()).To(Succeed())
 					Expect(reqDone).To(BeClosed())
 					Expect(rb.Close()).To(Succeed())
@@ -0,0 +1,51 @@
+package http3
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/lucas-clemente/quic-go"
+)
+
+type cancelErrorCodeKey struct{}
+
+// WithCancelErrorCode returns a shallow copy of the request that uses code to reset the request stream,
+// and to stop reading from it, when the request context is canceled.
+// By default, ErrCodeRequestCanceled is used.
+// Proxies can use this to propagate the error code of a canceled downstream request to the upstream server.
+func WithCancelErrorCode(req *http.Request, code ErrCode) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), cancelErrorCodeKey{}, code))
+}
+
+func cancelErrorCode(ctx context.Context) ErrCode {
+	if code, ok := ctx.Value(cancelErrorCodeKey{}).(ErrCode); ok {
+		return code
+	}
+	return ErrCodeRequestCanceled
+}
+
+// A StreamError is returned when reading a request or response body,
+// or from RoundTrip, if the peer reset the request stream or stopped reading from it.
+// It unwraps to a *quic.StreamError.
+type StreamError struct {
+	StreamID  quic.StreamID
+	ErrorCode ErrCode
+}
+
+func (e *StreamError) Error() string {
+	return fmt.Sprintf("http3: stream %d canceled by peer with error code %s", e.StreamID, e.ErrorCode)
+}
+
+func (e *StreamError) Unwrap() error {
+	return &quic.StreamError{StreamID: e.StreamID, ErrorCode: quic.StreamErrorCode(e.ErrorCode)}
+}
+
+// wrapStreamError converts a *quic.StreamError into a *StreamError.
+// All other errors are returned unchanged.
+func wrapStreamError(err error) error {
+	if serr, ok := err.(*quic.StreamError); ok {
+		return &StreamError{StreamID: serr.StreamID, ErrorCode: ErrCode(serr.ErrorCode)}
+	}
+	return err
+}
@@ -0,0 +1,112 @@
+package http3
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/testdata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Request cancellation", func() {
+	var (
+		server  *Server
+		conn    *net.UDPConn
+		rt      *RoundTripper
+		port    int
+		bodyErr chan error
+		reset   chan struct{}
+	)
+
+	BeforeEach(func() {
+		bodyErr = make(chan error, 1)
+		reset = make(chan struct{})
+		mux := http.NewServeMux()
+		mux.HandleFunc("/upload", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			_, err := ioutil.ReadAll(r.Body)
+			bodyErr <- err
+		})
+		mux.HandleFunc("/reset", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			str := w.(DataStreamer).DataStream()
+			<-reset
+			str.CancelWrite(quic.StreamErrorCode(ErrCodeExcessiveLoad))
+		})
+		server = &Server{Server: &http.Server{Handler: mux, TLSConfig: testdata.GetTLSConfig()}}
+		var err error
+		conn, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).ToNot(HaveOccurred())
+		go server.Serve(conn)
+		port = conn.LocalAddr().(*net.UDPAddr).Port
+		rt = &RoundTripper{
+			TLSClientConfig: &tls.Config{RootCAs: testdata.GetRootCA()},
+			QuicConfig:      &quic.Config{Versions: []quic.VersionNumber{quic.VersionDraft29}},
+		}
+	})
+
+	AfterEach(func() {
+		rt.Close()
+		server.Close()
+		conn.Close()
+	})
+
+	// upload starts a request with a body that is never finished, and cancels it after receiving the response header.
+	upload := func(modify func(*http.Request) *http.Request) {
+		ctx, cancel := context.WithCancel(context.Background())
+		pr, pw := io.Pipe()
+		defer pw.Close()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("https://localhost:%d/upload", port), pr)
+		ExpectWithOffset(1, err).ToNot(HaveOccurred())
+		if modify != nil {
+			req = modify(req)
+		}
+		rsp, err := rt.RoundTrip(req)
+		ExpectWithOffset(1, err).ToNot(HaveOccurred())
+		ExpectWithOffset(1, rsp.StatusCode).To(Equal(http.StatusOK))
+		cancel()
+	}
+
+	It("resets the stream with H3_REQUEST_CANCELLED by default", func() {
+		upload(nil)
+		var err error
+		Eventually(bodyErr).Should(Receive(&err))
+		var serr *StreamError
+		Expect(errors.As(err, &serr)).To(BeTrue())
+		Expect(serr.ErrorCode).To(Equal(ErrCodeRequestCanceled))
+		Expect(serr.StreamID).To(Equal(quic.StreamID(0)))
+	})
+
+	It("resets the stream with the configured error code", func() {
+		upload(func(req *http.Request) *http.Request { return WithCancelErrorCode(req, ErrCodeConnectError) })
+		var err error
+		Eventually(bodyErr).Should(Receive(&err))
+		var serr *StreamError
+		Expect(errors.As(err, &serr)).To(BeTrue())
+		Expect(serr.ErrorCode).To(Equal(ErrCodeConnectError))
+		Expect(serr.Error()).To(ContainSubstring("H3_CONNECT_ERROR"))
+		Expect(errors.Is(err, &quic.StreamError{})).To(BeTrue())
+	})
+
+	It("returns the error code when the server resets the stream", func() {
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("https://localhost:%d/reset", port), nil)
+		Expect(err).ToNot(HaveOccurred())
+		rsp, err := rt.RoundTrip(req)
+		Expect(err).ToNot(HaveOccurred())
+		close(reset)
+		_, err = ioutil.ReadAll(rsp.Body)
+		var serr *StreamError
+		Expect(errors.As(err, &serr)).To(BeTrue())
+		Expect(serr.ErrorCode).To(Equal(ErrCodeExcessiveLoad))
+	})
+})
@@ -0,0 +1,59 @@
+package http3
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/quicvarint"
+)
+
+// CapsuleType is the type of a capsule, see RFC 9297, section 3.2.
+type CapsuleType uint64
+
+// WriteCapsule writes a capsule to the request stream.
+// Capsules are sent in HTTP/3 DATA frames, so w is the stream returned by DataStreamer.DataStream.
+func WriteCapsule(w io.Writer, ct CapsuleType, value []byte) error {
+	capsuleLen := quicvarint.Len(uint64(ct)) + quicvarint.Len(uint64(len(value))) + protocol.ByteCount(len(value))
+	b := &bytes.Buffer{}
+	(&dataFrame{Length: uint64(capsuleLen)}).Write(b)
+	quicvarint.Write(b, uint64(ct))
+	quicvarint.Write(b, uint64(len(value)))
+	b.Write(value)
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// ParseCapsule parses the header of the next capsule.
+// r reads the payload of the DATA frames, i.e. the body of the request or the response.
+// The returned reader reads the capsule value. It must be read until io.EOF before parsing the next capsule.
+// If r ends before the capsule is complete, io.ErrUnexpectedEOF is returned.
+func ParseCapsule(r quicvarint.Reader) (CapsuleType, io.Reader, error) {
+	ct, err := quicvarint.Read(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	l, err := quicvarint.Read(r)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+		return 0, nil, err
+	}
+	return CapsuleType(ct), &capsuleReader{r: &io.LimitedReader{R: r, N: int64(l)}}, nil
+}
+
+// capsuleReader reads the value of a capsule.
+// It returns io.ErrUnexpectedEOF if the underlying reader ends before the value was read completely.
+type capsuleReader struct {
+	r *io.LimitedReader
+}
+
+func (r *capsuleReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	if err == io.EOF && r.r.N > 0 {
+		return n, io.ErrUnexpectedEOF
+	}
+	return n, err
+}
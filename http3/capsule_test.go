@@ -0,0 +1,62 @@
+package http3
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	"github.com/lucas-clemente/quic-go/quicvarint"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Capsules", func() {
+	It("writes capsules in DATA frames", func() {
+		buf := &bytes.Buffer{}
+		Expect(WriteCapsule(buf, 1337, []byte("foobar"))).To(Succeed())
+		f, err := parseNextFrame(buf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(f).To(BeAssignableToTypeOf(&dataFrame{}))
+		Expect(f.(*dataFrame).Length).To(BeEquivalentTo(buf.Len()))
+		ct, r, err := ParseCapsule(quicvarint.NewReader(buf))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ct).To(BeEquivalentTo(1337))
+		val, err := ioutil.ReadAll(r)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(val).To(Equal([]byte("foobar")))
+		Expect(buf.Len()).To(BeZero())
+	})
+
+	It("only reads the value of the capsule", func() {
+		buf := &bytes.Buffer{}
+		quicvarint.Write(buf, 1)
+		quicvarint.Write(buf, 3)
+		buf.Write([]byte("foobar"))
+		_, r, err := ParseCapsule(quicvarint.NewReader(buf))
+		Expect(err).ToNot(HaveOccurred())
+		val, err := ioutil.ReadAll(r)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(val).To(Equal([]byte("foo")))
+		Expect(buf.String()).To(Equal("bar"))
+	})
+
+	It("returns io.EOF if there are no more capsules", func() {
+		_, _, err := ParseCapsule(quicvarint.NewReader(&bytes.Buffer{}))
+		Expect(err).To(MatchError(io.EOF))
+	})
+
+	It("errors on truncated capsules", func() {
+		buf := &bytes.Buffer{}
+		quicvarint.Write(buf, 1)
+		_, _, err := ParseCapsule(quicvarint.NewReader(bytes.NewReader(buf.Bytes())))
+		Expect(err).To(MatchError(io.ErrUnexpectedEOF))
+
+		quicvarint.Write(buf, 6)
+		buf.Write([]byte("foo"))
+		_, r, err := ParseCapsule(quicvarint.NewReader(buf))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = ioutil.ReadAll(r)
+		Expect(err).To(MatchError(io.ErrUnexpectedEOF))
+	})
+})
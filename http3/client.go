@@ -7,16 +7,20 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/lucas-clemente/quic-go"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/qpack"
 	"github.com/lucas-clemente/quic-go/internal/qtls"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/lucas-clemente/quic-go/quicvarint"
-	"github.com/marten-seemann/qpack"
 )
 
 // MethodGet0RTT allows a GET request to be sent using 0-RTT.
@@ -26,6 +30,7 @@ const MethodGet0RTT = "GET_0RTT"
 const (
 	defaultUserAgent              = "quic-go HTTP/3"
 	defaultMaxResponseHeaderBytes = 10 * 1 << 20 // 10 MB
+	max1xxResponses               = 5            // arbitrary bound on the number of interim responses, as in net/http
 )
 
 var defaultQuicConfig = &quic.Config{
@@ -37,19 +42,43 @@ var defaultQuicConfig = &quic.Config{
 var dialAddr = quic.DialAddrEarly
 
 type roundTripperOpts struct {
-	DisableCompression bool
-	EnableDatagram     bool
-	MaxHeaderBytes     int64
+	DisableCompression    bool
+	EnableDatagram        bool
+	MaxHeaderBytes        int64
+	SettingsTimeout       time.Duration
+	IdleConnTimeout       time.Duration
+	ExpectContinueTimeout time.Duration
+	AdditionalSettings    map[uint64]uint64
+	QPACKMaxTableCapacity uint64
+	QPACKBlockedStreams   uint64
+	StreamHijacker        func(FrameType, quic.Session, quic.Stream) bool
+	UniStreamHijacker     func(StreamType, quic.Session, quic.ReceiveStream) bool
+	Allow0RTT             func(*http.Request) bool
 }
 
+// A SettingsTimeoutError is returned when the server's SETTINGS frame isn't received within the RoundTripper.SettingsTimeout.
+type SettingsTimeoutError struct{}
+
+var _ net.Error = &SettingsTimeoutError{}
+
+func (e *SettingsTimeoutError) Timeout() bool   { return true }
+func (e *SettingsTimeoutError) Temporary() bool { return false }
+func (e *SettingsTimeoutError) Error() string   { return "http3: SETTINGS frame not received in time" }
+
 // client is a HTTP3 client doing requests
 type client struct {
 	tlsConf *tls.Config
 	config  *quic.Config
 	opts    *roundTripperOpts
 
-	dialOnce     sync.Once
-	dialer       func(network, addr string, tlsCfg *tls.Config, cfg *quic.Config, startAlgo utils.StartAlgo, congestionAlgo utils.CongestionAlgo) (quic.EarlySession, error)
+	dialOnce    sync.Once
+	dialed      chan struct{} // closed when dialing finished, handshakeErr is set then
+	dialer      func(network, addr string, tlsCfg *tls.Config, cfg *quic.Config, startAlgo utils.StartAlgo, congestionAlgo utils.CongestionAlgo) (quic.EarlySession, error)
+	dialContext func(ctx context.Context, addr string, tlsCfg *tls.Config, cfg *quic.Config) (quic.EarlySession, error)
+	// The dial isn't tied to the request that triggered it, since other requests might use the connection.
+	// It is canceled when the client is closed.
+	dialCtx      context.Context
+	cancelDial   context.CancelFunc
 	handshakeErr error
 
 	requestWriter *requestWriter
@@ -58,6 +87,22 @@ type client struct {
 
 	hostname string
 	session  quic.EarlySession
+	// set if the session was returned before the handshake completed, i.e. 0-RTT is possible
+	dialedEarly bool
+
+	controlStrMutex sync.Mutex
+	controlStr      quic.SendStream // needed to send PRIORITY_UPDATE frames
+
+	settingsReceivedOnce sync.Once
+	settingsReceived     chan struct{} // closed when the server's SETTINGS frame was received
+	settingsTimedOut     chan struct{} // closed when the SettingsTimeout expired
+
+	// keeps track of the requests on the connection, see pooledClient
+	poolMutex      sync.Mutex
+	activeRequests int
+	idleSince      time.Time
+	idleTimer      *time.Timer
+	closed         bool
 
 	logger utils.Logger
 	startAlgo utils.StartAlgo
@@ -70,6 +115,7 @@ func newClient(
 	opts *roundTripperOpts,
 	quicConfig *quic.Config,
 	dialer func(network, addr string, tlsCfg *tls.Config, cfg *quic.Config, startAlgo utils.StartAlgo, congestionAlgo utils.CongestionAlgo) (quic.EarlySession, error),
+	dialContext func(ctx context.Context, addr string, tlsCfg *tls.Config, cfg *quic.Config) (quic.EarlySession, error),
 	startAlgo utils.StartAlgo,
 	congestionAlgo utils.CongestionAlgo,
 ) (*client, error) {
@@ -82,7 +128,11 @@ func newClient(
 	if len(quicConfig.Versions) != 1 {
 		return nil, errors.New("can only use a single QUIC version for dialing a HTTP/3 connection")
 	}
-	quicConfig.MaxIncomingStreams = -1 // don't allow any bidirectional streams
+	if opts.StreamHijacker == nil {
+		quicConfig.MaxIncomingStreams = -1 // don't allow any bidirectional streams
+	} else if quicConfig.MaxIncomingStreams < 0 {
+		quicConfig.MaxIncomingStreams = 0 // use the default value
+	}
 	quicConfig.EnableDatagrams = opts.EnableDatagram
 	logger := utils.DefaultLogger.WithPrefix("h3 client")
 
@@ -94,44 +144,213 @@ func newClient(
 	// Replace existing ALPNs by H3
 	tlsConf.NextProtos = []string{versionToALPN(quicConfig.Versions[0])}
 
+	requestWriter := newRequestWriter(logger)
+	requestWriter.encoder = qpack.NewDynamicEncoder(opts.QPACKMaxTableCapacity)
+	dialCtx, cancelDial := context.WithCancel(context.Background())
 	return &client{
 		hostname:      authorityAddr("https", hostname),
 		tlsConf:       tlsConf,
-		requestWriter: newRequestWriter(logger),
-		decoder:       qpack.NewDecoder(func(hf qpack.HeaderField) {}),
+		requestWriter: requestWriter,
+		decoder:       newQPACKDecoder(opts.QPACKMaxTableCapacity, opts.QPACKBlockedStreams),
 		config:        quicConfig,
 		opts:          opts,
 		dialer:        dialer,
+		dialContext:   dialContext,
+		dialCtx:       dialCtx,
+		cancelDial:    cancelDial,
+
 		logger:        logger,
 		startAlgo:	   startAlgo,
 		congestionAlgo: congestionAlgo,
 	}, nil
 }
 
-func (c *client) dial() error {
+// startDial starts dialing the QUIC connection in the background, if it wasn't dialed yet.
+// The events of establishing the connection are reported to trace, which may be nil.
+func (c *client) startDial(trace *httptrace.ClientTrace) {
+	c.dialOnce.Do(func() {
+		c.dialed = make(chan struct{})
+		go func() {
+			c.handshakeErr = c.dial(trace)
+			close(c.dialed)
+		}()
+	})
+}
+
+// connect dials the QUIC connection, if it wasn't dialed yet.
+// It returns the error that dialing failed with.
+// If the context is canceled first, it returns the context error.
+// The dial then continues in the background, since other requests might use the connection.
+func (c *client) connect(ctx context.Context) error {
+	c.startDial(httptrace.ContextClientTrace(ctx))
+	select {
+	case <-c.dialed:
+		return c.handshakeErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *client) dial(trace *httptrace.ClientTrace) error {
+	addr := c.hostname
+	tlsConf := c.tlsConf
 	var err error
-	if c.dialer != nil {
-		c.session, err = c.dialer("udp", c.hostname, c.tlsConf, c.config, c.startAlgo, c.congestionAlgo)
+	if c.dialContext == nil && c.dialer == nil && trace != nil && (trace.DNSStart != nil || trace.DNSDone != nil) {
+		addr, tlsConf, err = c.resolve(trace)
+		if err != nil {
+			c.setClosed()
+			return err
+		}
+	}
+	traceConnectStart(trace, "udp", addr)
+	traceTLSHandshakeStart(trace)
+	if c.dialContext != nil {
+		c.session, err = c.dialContext(c.dialCtx, addr, tlsConf, c.config)
+	} else if c.dialer != nil {
+		c.session, err = c.dialer("udp", addr, tlsConf, c.config, c.startAlgo, c.congestionAlgo)
 	} else {
-		c.session, err = dialAddr(c.hostname, c.tlsConf, c.config, c.startAlgo, c.congestionAlgo)
+		c.session, err = dialAddr(addr, tlsConf, c.config, c.startAlgo, c.congestionAlgo)
 	}
+	traceConnectDone(trace, "udp", addr, err)
 	if err != nil {
+		traceTLSHandshakeDone(trace, tls.ConnectionState{}, err)
+		c.setClosed()
 		return err
 	}
+	if trace != nil && trace.TLSHandshakeDone != nil {
+		go c.traceHandshake(trace)
+	}
+	c.dialedEarly = !isClosed(c.session.HandshakeComplete().Done())
+	c.settingsReceived = make(chan struct{})
+	c.settingsTimedOut = make(chan struct{})
 
 	// send the SETTINGs frame, using 0-RTT data, if possible
 	go func() {
+		if err := c.setupSession(); err != nil && !errors.Is(err, quic.Err0RTTRejected) {
+			c.logger.Debugf("Setting up session failed: %s", err)
+			c.session.CloseWithError(quic.ApplicationErrorCode(ErrCodeInternalError), "")
+			return
+		}
+		// If the server rejected 0-RTT, the control stream was lost.
+		if !c.rejected0RTT() {
+			return
+		}
+		c.logger.Debugf("0-RTT rejected. Opening the control stream again.")
 		if err := c.setupSession(); err != nil {
 			c.logger.Debugf("Setting up session failed: %s", err)
-			c.session.CloseWithError(quic.ApplicationErrorCode(errorInternalError), "")
+			c.session.CloseWithError(quic.ApplicationErrorCode(ErrCodeInternalError), "")
 		}
 	}()
 
 	go c.handleUnidirectionalStreams()
+	if c.opts.StreamHijacker != nil {
+		go c.handleBidirectionalStreams()
+	}
+	if c.opts.SettingsTimeout > 0 {
+		go c.watchSettingsTimeout()
+	}
+	return nil
+}
+
+// resolve resolves the hostname, such that the DNS lookup can be traced.
+// Otherwise, quic.DialAddrEarly resolves the hostname internally.
+// It returns the address to dial, and the TLS config that uses the hostname for SNI.
+func (c *client) resolve(trace *httptrace.ClientTrace) (string, *tls.Config, error) {
+	host, port, err := net.SplitHostPort(c.hostname)
+	if err != nil {
+		return "", nil, err
+	}
+	if net.ParseIP(host) != nil {
+		return c.hostname, c.tlsConf, nil
+	}
+	traceDNSStart(trace, host)
+	ips, err := net.DefaultResolver.LookupIPAddr(c.dialCtx, host)
+	traceDNSDone(trace, ips, err)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(ips) == 0 {
+		return "", nil, &net.AddrError{Err: "no suitable address found", Addr: host}
+	}
+	// prefer IPv4 addresses, as net.ResolveUDPAddr does
+	ip := ips[0]
+	for _, a := range ips {
+		if a.IP.To4() != nil {
+			ip = a
+			break
+		}
+	}
+	tlsConf := c.tlsConf
+	if tlsConf.ServerName == "" {
+		tlsConf = tlsConf.Clone()
+		tlsConf.ServerName = host
+	}
+	return net.JoinHostPort(ip.String(), port), tlsConf, nil
+}
+
+// traceHandshake reports the completion of the handshake of a session that was returned early.
+func (c *client) traceHandshake(trace *httptrace.ClientTrace) {
+	select {
+	case <-c.session.HandshakeComplete().Done():
+		traceTLSHandshakeDone(trace, qtls.ToTLSConnectionState(c.session.ConnectionState().TLS), nil)
+	case <-c.session.Context().Done():
+		traceTLSHandshakeDone(trace, tls.ConnectionState{}, errHandshakeAborted)
+	}
+}
+
+// watchSettingsTimeout closes the session if the server's SETTINGS frame isn't received
+// within the SettingsTimeout after the handshake completed.
+func (c *client) watchSettingsTimeout() {
+	select {
+	case <-c.session.HandshakeComplete().Done():
+	case <-c.session.Context().Done():
+		return
+	}
+	timer := time.NewTimer(c.opts.SettingsTimeout)
+	defer timer.Stop()
+	select {
+	case <-c.settingsReceived:
+	case <-c.session.Context().Done():
+	case <-timer.C:
+		c.logger.Debugf("SETTINGS frame not received within %s", c.opts.SettingsTimeout)
+		close(c.settingsTimedOut)
+		c.session.CloseWithError(quic.ApplicationErrorCode(ErrCodeNoError), "SETTINGS frame not received in time")
+	}
+}
+
+// waitForSettings waits until the server's SETTINGS frame was received.
+func (c *client) waitForSettings(ctx context.Context) error {
+	select {
+	case <-c.settingsReceived:
+	case <-c.settingsTimedOut:
+		return &SettingsTimeoutError{}
+	case <-c.session.Context().Done():
+		// The SettingsTimeout might have caused the session to be closed.
+		select {
+		case <-c.settingsTimedOut:
+			return &SettingsTimeoutError{}
+		default:
+		}
+		// Opening the stream will return the error that closed the session.
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 	return nil
 }
 
 func (c *client) setupSession() error {
+	c.controlStrMutex.Lock()
+	defer c.controlStrMutex.Unlock()
+
+	// The decoder stream must be open before the server's encoder inserts entries into the dynamic table.
+	if c.opts.QPACKMaxTableCapacity > 0 {
+		decoderStr, err := openQPACKStream(c.session, streamTypeQPACKDecoderStream)
+		if err != nil {
+			return err
+		}
+		c.decoder.SetDecoderStream(decoderStr)
+	}
+
 	// open the control stream
 	str, err := c.session.OpenUniStream()
 	if err != nil {
@@ -140,16 +359,92 @@ func (c *client) setupSession() error {
 	buf := &bytes.Buffer{}
 	quicvarint.Write(buf, streamTypeControlStream)
 	// send the SETTINGS frame
-	(&settingsFrame{Datagram: c.opts.EnableDatagram}).Write(buf)
-	_, err = str.Write(buf.Bytes())
+	(&settingsFrame{
+		Datagram:              c.opts.EnableDatagram,
+		QPACKMaxTableCapacity: c.opts.QPACKMaxTableCapacity,
+		QPACKBlockedStreams:   c.opts.QPACKBlockedStreams,
+		other:                 c.opts.AdditionalSettings,
+	}).Write(buf)
+	if _, err := str.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	c.controlStr = str
+	return nil
+}
+
+// rejected0RTT waits for the handshake to complete, and says if the session attempted 0-RTT, but the server rejected it.
+// All streams opened in 0-RTT were then closed with quic.Err0RTTRejected.
+// After rejected0RTT returned, new streams can be opened and accepted.
+func (c *client) rejected0RTT() bool {
+	if !c.dialedEarly {
+		return false
+	}
+	select {
+	case <-c.session.HandshakeComplete().Done():
+	case <-c.session.Context().Done(): // the session was closed before the handshake completed
+		return false
+	}
+	return c.reset0RTTStreams()
+}
+
+// reset0RTTStreams must only be called after the handshake of a session that was dialed early completed.
+// If the server rejected 0-RTT, it allows opening and accepting streams again, and returns true.
+func (c *client) reset0RTTStreams() bool {
+	if c.session.ConnectionState().TLS.Used0RTT {
+		return false
+	}
+	c.session.NextSession()
+	return true
+}
+
+// sendPriorityUpdate sends a PRIORITY_UPDATE frame for a request on the control stream.
+func (c *client) sendPriorityUpdate(id quic.StreamID, p Priority) error {
+	c.controlStrMutex.Lock()
+	defer c.controlStrMutex.Unlock()
+
+	if c.controlStr == nil {
+		return errors.New("http3: control stream not open")
+	}
+	buf := &bytes.Buffer{}
+	(&priorityUpdateFrame{StreamID: uint64(id), Priority: p.String()}).Write(buf)
+	_, err := c.controlStr.Write(buf.Bytes())
 	return err
 }
 
+// handleBidirectionalStreams passes bidirectional streams opened by the server to the StreamHijacker.
+func (c *client) handleBidirectionalStreams() {
+	for {
+		str, err := c.session.AcceptStream(context.Background())
+		if errors.Is(err, quic.Err0RTTRejected) && c.rejected0RTT() {
+			continue
+		}
+		if err != nil {
+			c.logger.Debugf("accepting bidirectional stream failed: %s", err)
+			return
+		}
+		go func() {
+			_, err := parseNextFrameWithHandler(str, func(ft FrameType) bool {
+				return c.opts.StreamHijacker(ft, c.session, str)
+			})
+			if err == errHijacked {
+				return
+			}
+			c.logger.Debugf("received an unexpected bidirectional stream %d: %v", str.StreamID(), err)
+			c.session.CloseWithError(quic.ApplicationErrorCode(ErrCodeStreamCreationError), "")
+		}()
+	}
+}
+
 func (c *client) handleUnidirectionalStreams() {
 	for {
 		str, err := c.session.AcceptUniStream(context.Background())
+		if errors.Is(err, quic.Err0RTTRejected) && c.rejected0RTT() {
+			continue
+		}
 		if err != nil {
 			c.logger.Debugf("accepting unidirectional stream failed: %s", err)
+			// Accepting only fails once the session is closed.
+			c.setClosed()
 			return
 		}
 
@@ -159,31 +454,40 @@ func (c *client) handleUnidirectionalStreams() {
 				c.logger.Debugf("reading stream type on stream %d failed: %s", str.StreamID(), err)
 				return
 			}
-			// We're only interested in the control stream here.
 			switch streamType {
 			case streamTypeControlStream:
 			case streamTypeQPACKEncoderStream, streamTypeQPACKDecoderStream:
-				// Our QPACK implementation doesn't use the dynamic table yet.
 				// TODO: check that only one stream of each type is opened.
+				handleQPACKStream(c.session, str, streamType, c.decoder, c.requestWriter.encoder)
 				return
 			case streamTypePushStream:
 				// We never increased the Push ID, so we don't expect any push streams.
-				c.session.CloseWithError(quic.ApplicationErrorCode(errorIDError), "")
+				c.session.CloseWithError(quic.ApplicationErrorCode(ErrCodeIDError), "")
 				return
 			default:
-				str.CancelRead(quic.StreamErrorCode(errorStreamCreationError))
+				if c.opts.UniStreamHijacker != nil && c.opts.UniStreamHijacker(StreamType(streamType), c.session, str) {
+					return
+				}
+				str.CancelRead(quic.StreamErrorCode(ErrCodeStreamCreationError))
 				return
 			}
 			f, err := parseNextFrame(str)
 			if err != nil {
-				c.session.CloseWithError(quic.ApplicationErrorCode(errorFrameError), "")
+				c.session.CloseWithError(quic.ApplicationErrorCode(ErrCodeFrameError), "")
 				return
 			}
 			sf, ok := f.(*settingsFrame)
 			if !ok {
-				c.session.CloseWithError(quic.ApplicationErrorCode(errorMissingSettings), "")
+				c.session.CloseWithError(quic.ApplicationErrorCode(ErrCodeMissingSettings), "")
 				return
 			}
+			// Enable the dynamic table before unblocking requests waiting for the SETTINGS frame,
+			// so that these requests can make use of it.
+			if err := c.enableQPACKEncoder(sf); err != nil {
+				c.logger.Debugf("Opening the QPACK encoder stream failed: %s", err)
+				return
+			}
+			c.settingsReceivedOnce.Do(func() { close(c.settingsReceived) })
 			if !sf.Datagram {
 				return
 			}
@@ -191,17 +495,32 @@ func (c *client) handleUnidirectionalStreams() {
 			// we can expect it to have been negotiated both on the transport and on the HTTP/3 layer.
 			// Note: ConnectionState() will block until the handshake is complete (relevant when using 0-RTT).
 			if c.opts.EnableDatagram && !c.session.ConnectionState().SupportsDatagrams {
-				c.session.CloseWithError(quic.ApplicationErrorCode(errorSettingsError), "missing QUIC Datagram support")
+				c.session.CloseWithError(quic.ApplicationErrorCode(ErrCodeSettingsError), "missing QUIC Datagram support")
 			}
 		}()
 	}
 }
 
+// enableQPACKEncoder enables the dynamic table used to compress request headers.
+// The encoder stream is only opened after the handshake completed,
+// since its state would be lost if the server rejected 0-RTT.
+func (c *client) enableQPACKEncoder(sf *settingsFrame) error {
+	if c.dialedEarly {
+		select {
+		case <-c.session.HandshakeComplete().Done():
+		case <-c.session.Context().Done():
+			return c.session.Context().Err()
+		}
+	}
+	return enableQPACKEncoder(c.session, c.requestWriter.encoder, c.opts.QPACKMaxTableCapacity, sf)
+}
+
 func (c *client) Close() error {
+	c.cancelDial()
 	if c.session == nil {
 		return nil
 	}
-	return c.session.CloseWithError(quic.ApplicationErrorCode(errorNoError), "")
+	return c.session.CloseWithError(quic.ApplicationErrorCode(ErrCodeNoError), "")
 }
 
 func (c *client) maxHeaderBytes() uint64 {
@@ -217,47 +536,120 @@ func (c *client) RoundTrip(req *http.Request) (*http.Response, error) {
 		return nil, fmt.Errorf("http3 client BUG: RoundTrip called for the wrong client (expected %s, got %s)", c.hostname, req.Host)
 	}
 
-	c.dialOnce.Do(func() {
-		c.handshakeErr = c.dial()
-	})
-
-	if c.handshakeErr != nil {
-		return nil, c.handshakeErr
+	if err := c.connect(req.Context()); err != nil {
+		return nil, err
 	}
 
-	// Immediately send out this request, if this is a 0-RTT request.
+	// Immediately send out this request, if it may be sent in 0-RTT.
+	early := c.allow0RTT(req) && !isClosed(c.session.HandshakeComplete().Done())
 	if req.Method == MethodGet0RTT {
 		req.Method = http.MethodGet
-	} else {
-		// wait for the handshake to complete
+	}
+	if !early {
+		if err := c.waitForHandshake(req.Context()); err != nil {
+			return nil, err
+		}
+		return c.roundTrip(req)
+	}
+
+	rsp, err := c.roundTrip(req)
+	if err == nil && rsp.StatusCode != http.StatusTooEarly {
+		setEarlyDataStatus(rsp, EarlyDataAccepted)
+		return rsp, nil
+	}
+	if err != nil && !errors.Is(err, quic.Err0RTTRejected) {
+		return nil, err
+	}
+	// The server rejected 0-RTT, or responded with 425 (Too Early).
+	// Send the request again after the handshake completed, if it can be replayed.
+	if !isReplayable(req) {
+		return rsp, err
+	}
+	if rsp != nil {
+		rsp.Body.Close()
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = body
+	}
+	c.logger.Debugf("Replaying request sent in 0-RTT: %s %s", req.Method, req.URL)
+	if err := c.waitForHandshake(req.Context()); err != nil {
+		return nil, err
+	}
+	rsp, err = c.roundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	setEarlyDataStatus(rsp, EarlyDataReplayed)
+	return rsp, nil
+}
+
+// allow0RTT says if a request may be sent in 0-RTT.
+func (c *client) allow0RTT(req *http.Request) bool {
+	if req.Method == MethodGet0RTT {
+		return true
+	}
+	if allowed, ok := req.Context().Value(allow0RTTKey{}).(bool); ok && allowed {
+		return true
+	}
+	return c.opts.Allow0RTT != nil && c.opts.Allow0RTT(req)
+}
+
+// waitForHandshake waits for the handshake to complete, and for the SETTINGS frame (if configured).
+func (c *client) waitForHandshake(ctx context.Context) error {
+	// If the session wasn't returned early, the handshake has already completed when dialing.
+	if c.dialedEarly {
 		select {
 		case <-c.session.HandshakeComplete().Done():
-		case <-req.Context().Done():
-			return nil, req.Context().Err()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		c.reset0RTTStreams()
+	}
+	if c.opts.SettingsTimeout > 0 {
+		if err := c.waitForSettings(ctx); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
+func (c *client) roundTrip(req *http.Request) (*http.Response, error) {
+	c.startRequest()
 	str, err := c.session.OpenStreamSync(req.Context())
 	if err != nil {
+		c.endRequest()
 		return nil, err
 	}
+	if _, ok := req.Header["Priority"]; ok {
+		str.SetPriority(RequestPriority(req).streamPriority())
+	}
 
 	// Request Cancellation:
 	// This go routine keeps running even after RoundTrip() returns.
 	// It is shut down when the application is done processing the body.
 	reqDone := make(chan struct{})
+	// The request is done when the application is done processing the body, or when it is canceled.
+	var endRequestOnce sync.Once
+	endRequest := func() { endRequestOnce.Do(c.endRequest) }
 	go func() {
+		defer endRequest()
 		select {
 		case <-req.Context().Done():
-			str.CancelWrite(quic.StreamErrorCode(errorRequestCanceled))
-			str.CancelRead(quic.StreamErrorCode(errorRequestCanceled))
+			code := quic.StreamErrorCode(cancelErrorCode(req.Context()))
+			str.CancelWrite(code)
+			str.CancelRead(code)
 		case <-reqDone:
 		}
 	}()
 
-	rsp, rerr := c.doRequest(req, str, reqDone)
+	rsp, rerr := c.doRequest(req, str, reqDone, endRequest)
 	if rerr.err != nil { // if any error occurred
 		close(reqDone)
+		endRequest()
 		if rerr.streamErr != 0 { // if it was a stream error
 			str.CancelWrite(quic.StreamErrorCode(rerr.streamErr))
 		}
@@ -269,66 +661,112 @@ func (c *client) RoundTrip(req *http.Request) (*http.Response, error) {
 			c.session.CloseWithError(quic.ApplicationErrorCode(rerr.connErr), reason)
 		}
 	}
-	return rsp, rerr.err
+	return rsp, wrapStreamError(rerr.err)
 }
 
-func (c *client) doRequest(
-	req *http.Request,
-	str quic.Stream,
-	reqDone chan struct{},
-) (*http.Response, requestError) {
-	var requestGzip bool
-	if !c.opts.DisableCompression && req.Method != "HEAD" && req.Header.Get("Accept-Encoding") == "" && req.Header.Get("Range") == "" {
-		requestGzip = true
-	}
-	if err := c.requestWriter.WriteRequest(str, req, requestGzip); err != nil {
-		return nil, newStreamError(errorInternalError, err)
-	}
-
-	frame, err := parseNextFrame(str)
+// readHeaders reads a HEADERS frame from r, and decodes the field section.
+func (c *client) readHeaders(r io.Reader, str quic.Stream, req *http.Request) ([]qpack.HeaderField, requestError) {
+	frame, err := parseNextFrame(r)
 	if err != nil {
-		return nil, newStreamError(errorFrameError, err)
+		return nil, newStreamError(ErrCodeFrameError, err)
 	}
 	hf, ok := frame.(*headersFrame)
 	if !ok {
-		return nil, newConnError(errorFrameUnexpected, errors.New("expected first frame to be a HEADERS frame"))
+		return nil, newConnError(ErrCodeFrameUnexpected, errors.New("expected first frame to be a HEADERS frame"))
 	}
 	if hf.Length > c.maxHeaderBytes() {
-		return nil, newStreamError(errorFrameError, fmt.Errorf("HEADERS frame too large: %d bytes (max: %d)", hf.Length, c.maxHeaderBytes()))
+		return nil, newStreamError(ErrCodeFrameError, fmt.Errorf("HEADERS frame too large: %d bytes (max: %d)", hf.Length, c.maxHeaderBytes()))
 	}
 	headerBlock := make([]byte, hf.Length)
 	if _, err := io.ReadFull(str, headerBlock); err != nil {
-		return nil, newStreamError(errorRequestIncomplete, err)
+		return nil, newStreamError(ErrCodeRequestIncomplete, err)
 	}
-	hfs, err := c.decoder.DecodeFull(headerBlock)
+	hfs, err := c.decoder.DecodeStream(req.Context(), uint64(str.StreamID()), headerBlock)
 	if err != nil {
-		// TODO: use the right error code
-		return nil, newConnError(errorGeneralProtocolError, err)
+		if ctxErr := req.Context().Err(); ctxErr != nil {
+			c.decoder.CancelStream(uint64(str.StreamID()))
+			return nil, newStreamError(cancelErrorCode(req.Context()), ctxErr)
+		}
+		return nil, newConnError(ErrCodeQPACKDecompressionFailed, err)
 	}
+	return hfs, requestError{}
+}
 
-	connState := qtls.ToTLSConnectionState(c.session.ConnectionState().TLS)
-	res := &http.Response{
-		Proto:      "HTTP/3",
-		ProtoMajor: 3,
-		Header:     http.Header{},
-		TLS:        &connState,
+func (c *client) doRequest(
+	req *http.Request,
+	str quic.Stream,
+	reqDone chan struct{},
+	onDone func(),
+) (*http.Response, requestError) {
+	var requestGzip bool
+	if !c.opts.DisableCompression && req.Method != "HEAD" && req.Method != http.MethodConnect && req.Header.Get("Accept-Encoding") == "" && req.Header.Get("Range") == "" {
+		requestGzip = true
 	}
-	for _, hf := range hfs {
-		switch hf.Name {
-		case ":status":
-			status, err := strconv.Atoi(hf.Value)
-			if err != nil {
-				return nil, newStreamError(errorGeneralProtocolError, errors.New("malformed non-numeric status pseudo header"))
+	var continueSig *continueSignal
+	var continueCh <-chan bool
+	if c.opts.ExpectContinueTimeout > 0 && req.Body != nil && req.Body != http.NoBody && expectsContinue(req) {
+		continueSig = newContinueSignal(c.opts.ExpectContinueTimeout)
+		defer continueSig.done()
+		continueCh = continueSig.C
+	}
+	if err := c.requestWriter.WriteRequest(str, req, requestGzip, continueCh); err != nil {
+		return nil, newStreamError(ErrCodeInternalError, err)
+	}
+
+	trace := httptrace.ContextClientTrace(req.Context())
+	r := traceFirstResponseByte(trace, str)
+	var res *http.Response
+	// Interim (1xx) responses are reported to the trace, and skipped.
+	for num1xx := 0; ; num1xx++ {
+		hfs, rerr := c.readHeaders(r, str, req)
+		if rerr.err != nil {
+			return nil, rerr
+		}
+		connState := qtls.ToTLSConnectionState(c.session.ConnectionState().TLS)
+		res = &http.Response{
+			Proto:      "HTTP/3",
+			ProtoMajor: 3,
+			Header:     http.Header{},
+			TLS:        &connState,
+		}
+		for _, hf := range hfs {
+			switch hf.Name {
+			case ":status":
+				status, err := strconv.Atoi(hf.Value)
+				if err != nil {
+					return nil, newStreamError(ErrCodeGeneralProtocolError, errors.New("malformed non-numeric status pseudo header"))
+				}
+				res.StatusCode = status
+				res.Status = hf.Value + " " + http.StatusText(status)
+			default:
+				res.Header.Add(hf.Name, hf.Value)
+			}
+		}
+		// HTTP/3 doesn't support 101 (Switching Protocols), it is treated as a final response, as net/http does.
+		if res.StatusCode < 100 || res.StatusCode >= 200 || res.StatusCode == http.StatusSwitchingProtocols {
+			break
+		}
+		if res.StatusCode == http.StatusContinue && continueSig != nil {
+			traceGot100Continue(trace)
+			continueSig.send(true)
+		}
+		if num1xx >= max1xxResponses {
+			return nil, newStreamError(ErrCodeExcessiveLoad, errors.New("http3: too many 1xx informational responses"))
+		}
+		if trace != nil && trace.Got1xxResponse != nil {
+			if err := trace.Got1xxResponse(res.StatusCode, textproto.MIMEHeader(res.Header)); err != nil {
+				return nil, newStreamError(ErrCodeRequestCanceled, err)
 			}
-			res.StatusCode = status
-			res.Status = hf.Value + " " + http.StatusText(status)
-		default:
-			res.Header.Add(hf.Name, hf.Value)
 		}
 	}
-	respBody := newResponseBody(str, reqDone, func() {
-		c.session.CloseWithError(quic.ApplicationErrorCode(errorFrameUnexpected), "")
+	res.Trailer = extractTrailers(res.Header)
+	respBody := newResponseBody(c.session, str, reqDone, func() {
+		c.session.CloseWithError(quic.ApplicationErrorCode(ErrCodeFrameUnexpected), "")
 	})
+	respBody.onDone = onDone
+	respBody.priority = RequestPriority(req)
+	respBody.setTrailer(&res.Trailer, c.decoder, c.maxHeaderBytes())
+	respBody.sendPriorityUpdate = func(p Priority) error { return c.sendPriorityUpdate(str.StreamID(), p) }
 
 	// Rules for when to set Content-Length are defined in https://tools.ietf.org/html/rfc7230#section-3.3.2.
 	_, hasTransferEncoding := res.Header["Transfer-Encoding"]
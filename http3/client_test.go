@@ -18,8 +18,8 @@ import (
 	"github.com/lucas-clemente/quic-go/quicvarint"
 
 	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/qpack"
 	"github.com/lucas-clemente/quic-go/internal/utils"
-	"github.com/marten-seemann/qpack"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -37,7 +37,7 @@ var _ = Describe("Client", func() {
 		origDialAddr = dialAddr
 		hostname := "quic.clemente.io:1337"
 		var err error
-		client, err = newClient(hostname, nil, &roundTripperOpts{MaxHeaderBytes: 1337}, nil, nil)
+		client, err = newClient(hostname, nil, &roundTripperOpts{MaxHeaderBytes: 1337}, nil, nil, nil, utils.ChooseHystart, utils.ChooseNewReno)
 		Expect(err).ToNot(HaveOccurred())
 		Expect(client.hostname).To(Equal(hostname))
 
@@ -57,15 +57,15 @@ var _ = Describe("Client", func() {
 		qconf := &quic.Config{
 			Versions: []quic.VersionNumber{protocol.VersionDraft29, protocol.Version1},
 		}
-		_, err := newClient("localhost:1337", nil, &roundTripperOpts{}, qconf, nil)
+		_, err := newClient("localhost:1337", nil, &roundTripperOpts{}, qconf, nil, nil, utils.ChooseHystart, utils.ChooseNewReno)
 		Expect(err).To(MatchError("can only use a single QUIC version for dialing a HTTP/3 connection"))
 	})
 
 	It("uses the default QUIC and TLS config if none is give", func() {
-		client, err := newClient("localhost:1337", nil, &roundTripperOpts{}, nil, nil)
+		client, err := newClient("localhost:1337", nil, &roundTripperOpts{}, nil, nil, nil, utils.ChooseHystart, utils.ChooseNewReno)
 		Expect(err).ToNot(HaveOccurred())
 		var dialAddrCalled bool
-		dialAddr = func(_ string, tlsConf *tls.Config, quicConf *quic.Config) (quic.EarlySession, error) {
+		dialAddr = func(_ string, tlsConf *tls.Config, quicConf *quic.Config, _ utils.StartAlgo, _ utils.CongestionAlgo) (quic.EarlySession, error) {
 			Expect(quicConf).To(Equal(defaultQuicConfig))
 			Expect(tlsConf.NextProtos).To(Equal([]string{nextProtoH3}))
 			Expect(quicConf.Versions).To(Equal([]protocol.VersionNumber{protocol.Version1}))
@@ -77,10 +77,10 @@ var _ = Describe("Client", func() {
 	})
 
 	It("adds the port to the hostname, if none is given", func() {
-		client, err := newClient("quic.clemente.io", nil, &roundTripperOpts{}, nil, nil)
+		client, err := newClient("quic.clemente.io", nil, &roundTripperOpts{}, nil, nil, nil, utils.ChooseHystart, utils.ChooseNewReno)
 		Expect(err).ToNot(HaveOccurred())
 		var dialAddrCalled bool
-		dialAddr = func(hostname string, _ *tls.Config, _ *quic.Config) (quic.EarlySession, error) {
+		dialAddr = func(hostname string, _ *tls.Config, _ *quic.Config, _ utils.StartAlgo, _ utils.CongestionAlgo) (quic.EarlySession, error) {
 			Expect(hostname).To(Equal("quic.clemente.io:443"))
 			dialAddrCalled = true
 			return nil, errors.New("test done")
@@ -97,13 +97,15 @@ var _ = Describe("Client", func() {
 			NextProtos: []string{"proto foo", "proto bar"},
 		}
 		quicConf := &quic.Config{MaxIdleTimeout: time.Nanosecond}
-		client, err := newClient("localhost:1337", tlsConf, &roundTripperOpts{}, quicConf, nil)
+		client, err := newClient("localhost:1337", tlsConf, &roundTripperOpts{}, quicConf, nil, nil, utils.ChooseHystart, utils.ChooseNewReno)
 		Expect(err).ToNot(HaveOccurred())
 		var dialAddrCalled bool
 		dialAddr = func(
 			hostname string,
 			tlsConfP *tls.Config,
 			quicConfP *quic.Config,
+			_ utils.StartAlgo,
+			_ utils.CongestionAlgo,
 		) (quic.EarlySession, error) {
 			Expect(hostname).To(Equal("localhost:1337"))
 			Expect(tlsConfP.ServerName).To(Equal(tlsConf.ServerName))
@@ -118,12 +120,30 @@ var _ = Describe("Client", func() {
 		Expect(tlsConf.NextProtos).To(Equal([]string{"proto foo", "proto bar"}))
 	})
 
+	It("returns when the request is canceled while dialing", func() {
+		client, err := newClient("localhost:1337", nil, &roundTripperOpts{}, nil, nil, nil, utils.ChooseHystart, utils.ChooseNewReno)
+		Expect(err).ToNot(HaveOccurred())
+		dialing := make(chan struct{})
+		dialAddr = func(string, *tls.Config, *quic.Config, utils.StartAlgo, utils.CongestionAlgo) (quic.EarlySession, error) {
+			<-dialing
+			return nil, errors.New("test done")
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = client.RoundTrip(req.WithContext(ctx))
+		Expect(err).To(MatchError(context.Canceled))
+		close(dialing)
+		// the dial continues in the background
+		_, err = client.RoundTrip(req)
+		Expect(err).To(MatchError("test done"))
+	})
+
 	It("uses the custom dialer, if provided", func() {
 		testErr := errors.New("test done")
 		tlsConf := &tls.Config{ServerName: "foo.bar"}
 		quicConf := &quic.Config{MaxIdleTimeout: 1337 * time.Second}
 		var dialerCalled bool
-		dialer := func(network, address string, tlsConfP *tls.Config, quicConfP *quic.Config) (quic.EarlySession, error) {
+		dialer := func(network, address string, tlsConfP *tls.Config, quicConfP *quic.Config, _ utils.StartAlgo, _ utils.CongestionAlgo) (quic.EarlySession, error) {
 			Expect(network).To(Equal("udp"))
 			Expect(address).To(Equal("localhost:1337"))
 			Expect(tlsConfP.ServerName).To(Equal("foo.bar"))
@@ -131,7 +151,7 @@ var _ = Describe("Client", func() {
 			dialerCalled = true
 			return nil, testErr
 		}
-		client, err := newClient("localhost:1337", tlsConf, &roundTripperOpts{}, quicConf, dialer)
+		client, err := newClient("localhost:1337", tlsConf, &roundTripperOpts{}, quicConf, dialer, nil, utils.ChooseHystart, utils.ChooseNewReno)
 		Expect(err).ToNot(HaveOccurred())
 		_, err = client.RoundTrip(req)
 		Expect(err).To(MatchError(testErr))
@@ -140,9 +160,9 @@ var _ = Describe("Client", func() {
 
 	It("enables HTTP/3 Datagrams", func() {
 		testErr := errors.New("handshake error")
-		client, err := newClient("localhost:1337", nil, &roundTripperOpts{EnableDatagram: true}, nil, nil)
+		client, err := newClient("localhost:1337", nil, &roundTripperOpts{EnableDatagram: true}, nil, nil, nil, utils.ChooseHystart, utils.ChooseNewReno)
 		Expect(err).ToNot(HaveOccurred())
-		dialAddr = func(hostname string, _ *tls.Config, quicConf *quic.Config) (quic.EarlySession, error) {
+		dialAddr = func(hostname string, _ *tls.Config, quicConf *quic.Config, _ utils.StartAlgo, _ utils.CongestionAlgo) (quic.EarlySession, error) {
 			Expect(quicConf.EnableDatagrams).To(BeTrue())
 			return nil, testErr
 		}
@@ -152,9 +172,9 @@ var _ = Describe("Client", func() {
 
 	It("errors when dialing fails", func() {
 		testErr := errors.New("handshake error")
-		client, err := newClient("localhost:1337", nil, &roundTripperOpts{}, nil, nil)
+		client, err := newClient("localhost:1337", nil, &roundTripperOpts{}, nil, nil, nil, utils.ChooseHystart, utils.ChooseNewReno)
 		Expect(err).ToNot(HaveOccurred())
-		dialAddr = func(hostname string, _ *tls.Config, _ *quic.Config) (quic.EarlySession, error) {
+		dialAddr = func(hostname string, _ *tls.Config, _ *quic.Config, _ utils.StartAlgo, _ utils.CongestionAlgo) (quic.EarlySession, error) {
 			return nil, testErr
 		}
 		_, err = client.RoundTrip(req)
@@ -162,7 +182,7 @@ var _ = Describe("Client", func() {
 	})
 
 	It("closes correctly if session was not created", func() {
-		client, err := newClient("localhost:1337", nil, &roundTripperOpts{}, nil, nil)
+		client, err := newClient("localhost:1337", nil, &roundTripperOpts{}, nil, nil, nil, utils.ChooseHystart, utils.ChooseNewReno)
 		Expect(err).ToNot(HaveOccurred())
 		Expect(client.Close()).To(Succeed())
 	})
@@ -179,7 +199,7 @@ var _ = Describe("Client", func() {
 			testErr := errors.New("handshake error")
 			req, err := http.NewRequest("masque", "masque://quic.clemente.io:1337/foobar.html", nil)
 			Expect(err).ToNot(HaveOccurred())
-			dialAddr = func(hostname string, _ *tls.Config, _ *quic.Config) (quic.EarlySession, error) {
+			dialAddr = func(hostname string, _ *tls.Config, _ *quic.Config, _ utils.StartAlgo, _ utils.CongestionAlgo) (quic.EarlySession, error) {
 				return nil, testErr
 			}
 			_, err = client.RoundTrip(req)
@@ -206,7 +226,9 @@ var _ = Describe("Client", func() {
 			sess.EXPECT().OpenUniStream().Return(controlStr, nil)
 			sess.EXPECT().HandshakeComplete().Return(handshakeCtx)
 			sess.EXPECT().OpenStreamSync(gomock.Any()).Return(nil, errors.New("done"))
-			dialAddr = func(hostname string, _ *tls.Config, _ *quic.Config) (quic.EarlySession, error) { return sess, nil }
+			dialAddr = func(hostname string, _ *tls.Config, _ *quic.Config, _ utils.StartAlgo, _ utils.CongestionAlgo) (quic.EarlySession, error) {
+				return sess, nil
+			}
 			var err error
 			request, err = http.NewRequest("GET", "https://quic.clemente.io:1337/file1.dat", nil)
 			Expect(err).ToNot(HaveOccurred())
@@ -242,11 +264,13 @@ var _ = Describe("Client", func() {
 				name = "decoder"
 			}
 
-			It(fmt.Sprintf("ignores the QPACK %s streams", name), func() {
+			It(fmt.Sprintf("closes the connection when the QPACK %s stream is closed", name), func() {
 				buf := &bytes.Buffer{}
 				quicvarint.Write(buf, streamType)
 				str := mockquic.NewMockStream(mockCtrl)
 				str.EXPECT().Read(gomock.Any()).DoAndReturn(buf.Read).AnyTimes()
+				closed := make(chan struct{})
+				sess.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeClosedCriticalStream), gomock.Any()).Do(func(quic.ApplicationErrorCode, string) { close(closed) })
 
 				sess.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
 					return str, nil
@@ -257,7 +281,7 @@ var _ = Describe("Client", func() {
 				})
 				_, err := client.RoundTrip(request)
 				Expect(err).To(MatchError("done"))
-				time.Sleep(scaleDuration(20 * time.Millisecond)) // don't EXPECT any calls to str.CancelRead
+				Eventually(closed).Should(BeClosed())
 			})
 		}
 
@@ -267,7 +291,7 @@ var _ = Describe("Client", func() {
 			str := mockquic.NewMockStream(mockCtrl)
 			str.EXPECT().Read(gomock.Any()).DoAndReturn(buf.Read).AnyTimes()
 			done := make(chan struct{})
-			str.EXPECT().CancelRead(quic.StreamErrorCode(errorStreamCreationError)).Do(func(code quic.StreamErrorCode) {
+			str.EXPECT().CancelRead(quic.StreamErrorCode(ErrCodeStreamCreationError)).Do(func(code quic.StreamErrorCode) {
 				close(done)
 			})
 
@@ -299,7 +323,7 @@ var _ = Describe("Client", func() {
 			done := make(chan struct{})
 			sess.EXPECT().CloseWithError(gomock.Any(), gomock.Any()).Do(func(code quic.ApplicationErrorCode, _ string) {
 				defer GinkgoRecover()
-				Expect(code).To(BeEquivalentTo(errorMissingSettings))
+				Expect(code).To(BeEquivalentTo(ErrCodeMissingSettings))
 				close(done)
 			})
 			_, err := client.RoundTrip(request)
@@ -325,7 +349,7 @@ var _ = Describe("Client", func() {
 			done := make(chan struct{})
 			sess.EXPECT().CloseWithError(gomock.Any(), gomock.Any()).Do(func(code quic.ApplicationErrorCode, _ string) {
 				defer GinkgoRecover()
-				Expect(code).To(BeEquivalentTo(errorFrameError))
+				Expect(code).To(BeEquivalentTo(ErrCodeFrameError))
 				close(done)
 			})
 			_, err := client.RoundTrip(request)
@@ -348,7 +372,7 @@ var _ = Describe("Client", func() {
 			done := make(chan struct{})
 			sess.EXPECT().CloseWithError(gomock.Any(), gomock.Any()).Do(func(code quic.ApplicationErrorCode, _ string) {
 				defer GinkgoRecover()
-				Expect(code).To(BeEquivalentTo(errorIDError))
+				Expect(code).To(BeEquivalentTo(ErrCodeIDError))
 				close(done)
 			})
 			_, err := client.RoundTrip(request)
@@ -374,7 +398,7 @@ var _ = Describe("Client", func() {
 			done := make(chan struct{})
 			sess.EXPECT().CloseWithError(gomock.Any(), gomock.Any()).Do(func(code quic.ApplicationErrorCode, reason string) {
 				defer GinkgoRecover()
-				Expect(code).To(BeEquivalentTo(errorSettingsError))
+				Expect(code).To(BeEquivalentTo(ErrCodeSettingsError))
 				Expect(reason).To(Equal("missing QUIC Datagram support"))
 				close(done)
 			})
@@ -429,7 +453,7 @@ var _ = Describe("Client", func() {
 			buf := &bytes.Buffer{}
 			rstr := mockquic.NewMockStream(mockCtrl)
 			rstr.EXPECT().Write(gomock.Any()).Do(buf.Write).AnyTimes()
-			rw := newResponseWriter(rstr, utils.DefaultLogger)
+			rw := newResponseWriter(nil, rstr, utils.DefaultLogger)
 			rw.WriteHeader(status)
 			rw.Flush()
 			return buf.Bytes()
@@ -447,13 +471,16 @@ var _ = Describe("Client", func() {
 				close(settingsFrameWritten)
 			}) // SETTINGS frame
 			str = mockquic.NewMockStream(mockCtrl)
+			str.EXPECT().StreamID().AnyTimes() // used by the QPACK encoder and decoder
 			sess = mockquic.NewMockEarlySession(mockCtrl)
 			sess.EXPECT().OpenUniStream().Return(controlStr, nil)
 			sess.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
 				<-testDone
 				return nil, errors.New("test done")
 			})
-			dialAddr = func(hostname string, _ *tls.Config, _ *quic.Config) (quic.EarlySession, error) { return sess, nil }
+			dialAddr = func(hostname string, _ *tls.Config, _ *quic.Config, _ utils.StartAlgo, _ utils.CongestionAlgo) (quic.EarlySession, error) {
+				return sess, nil
+			}
 			var err error
 			request, err = http.NewRequest("GET", "https://quic.clemente.io:1337/file1.dat", nil)
 			Expect(err).ToNot(HaveOccurred())
@@ -476,7 +503,9 @@ var _ = Describe("Client", func() {
 		It("performs a 0-RTT request", func() {
 			testErr := errors.New("stream open error")
 			request.Method = MethodGet0RTT
-			// don't EXPECT any calls to HandshakeComplete()
+			// the handshake never completes
+			sess.EXPECT().HandshakeComplete().Return(context.Background()).AnyTimes()
+			sess.EXPECT().Context().Return(context.Background()).AnyTimes()
 			sess.EXPECT().OpenStreamSync(context.Background()).Return(str, nil)
 			buf := &bytes.Buffer{}
 			str.EXPECT().Write(gomock.Any()).DoAndReturn(buf.Write).AnyTimes()
@@ -546,7 +575,7 @@ var _ = Describe("Client", func() {
 				request.Body.(*mockBody).readErr = errors.New("testErr")
 				done := make(chan struct{})
 				gomock.InOrder(
-					str.EXPECT().CancelWrite(quic.StreamErrorCode(errorRequestCanceled)).Do(func(quic.StreamErrorCode) {
+					str.EXPECT().CancelWrite(quic.StreamErrorCode(ErrCodeRequestCanceled)).Do(func(quic.StreamErrorCode) {
 						close(done)
 					}),
 					str.EXPECT().CancelWrite(gomock.Any()),
@@ -584,7 +613,7 @@ var _ = Describe("Client", func() {
 			It("closes the connection when the first frame is not a HEADERS frame", func() {
 				buf := &bytes.Buffer{}
 				(&dataFrame{Length: 0x42}).Write(buf)
-				sess.EXPECT().CloseWithError(quic.ApplicationErrorCode(errorFrameUnexpected), gomock.Any())
+				sess.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeFrameUnexpected), gomock.Any())
 				closed := make(chan struct{})
 				str.EXPECT().Close().Do(func() { close(closed) })
 				str.EXPECT().Read(gomock.Any()).DoAndReturn(buf.Read).AnyTimes()
@@ -596,7 +625,7 @@ var _ = Describe("Client", func() {
 			It("cancels the stream when the HEADERS frame is too large", func() {
 				buf := &bytes.Buffer{}
 				(&headersFrame{Length: 1338}).Write(buf)
-				str.EXPECT().CancelWrite(quic.StreamErrorCode(errorFrameError))
+				str.EXPECT().CancelWrite(quic.StreamErrorCode(ErrCodeFrameError))
 				closed := make(chan struct{})
 				str.EXPECT().Close().Do(func() { close(closed) })
 				str.EXPECT().Read(gomock.Any()).DoAndReturn(buf.Read).AnyTimes()
@@ -610,7 +639,8 @@ var _ = Describe("Client", func() {
 			It("cancels a request while waiting for the handshake to complete", func() {
 				ctx, cancel := context.WithCancel(context.Background())
 				req := request.WithContext(ctx)
-				sess.EXPECT().HandshakeComplete().Return(context.Background())
+				sess.EXPECT().HandshakeComplete().Return(context.Background()).AnyTimes()
+				sess.EXPECT().Context().Return(context.Background()).AnyTimes()
 
 				errChan := make(chan error)
 				go func() {
@@ -635,8 +665,8 @@ var _ = Describe("Client", func() {
 				done := make(chan struct{})
 				canceled := make(chan struct{})
 				gomock.InOrder(
-					str.EXPECT().CancelWrite(quic.StreamErrorCode(errorRequestCanceled)).Do(func(quic.StreamErrorCode) { close(canceled) }),
-					str.EXPECT().CancelRead(quic.StreamErrorCode(errorRequestCanceled)).Do(func(quic.StreamErrorCode) { close(done) }),
+					str.EXPECT().CancelWrite(quic.StreamErrorCode(ErrCodeRequestCanceled)).Do(func(quic.StreamErrorCode) { close(canceled) }),
+					str.EXPECT().CancelRead(quic.StreamErrorCode(ErrCodeRequestCanceled)).Do(func(quic.StreamErrorCode) { close(done) }),
 				)
 				str.EXPECT().CancelWrite(gomock.Any()).MaxTimes(1)
 				str.EXPECT().Read(gomock.Any()).DoAndReturn(func([]byte) (int, error) {
@@ -663,8 +693,8 @@ var _ = Describe("Client", func() {
 				done := make(chan struct{})
 				str.EXPECT().Write(gomock.Any()).DoAndReturn(buf.Write)
 				str.EXPECT().Read(gomock.Any()).DoAndReturn(rspBuf.Read).AnyTimes()
-				str.EXPECT().CancelWrite(quic.StreamErrorCode(errorRequestCanceled))
-				str.EXPECT().CancelRead(quic.StreamErrorCode(errorRequestCanceled)).Do(func(quic.StreamErrorCode) { close(done) })
+				str.EXPECT().CancelWrite(quic.StreamErrorCode(ErrCodeRequestCanceled))
+				str.EXPECT().CancelRead(quic.StreamErrorCode(ErrCodeRequestCanceled)).Do(func(quic.StreamErrorCode) { close(done) })
 				_, err := client.RoundTrip(req)
 				Expect(err).ToNot(HaveOccurred())
 				cancel()
@@ -693,7 +723,7 @@ var _ = Describe("Client", func() {
 			})
 
 			It("doesn't add gzip if the header disable it", func() {
-				client, err := newClient("quic.clemente.io:1337", nil, &roundTripperOpts{DisableCompression: true}, nil, nil)
+				client, err := newClient("quic.clemente.io:1337", nil, &roundTripperOpts{DisableCompression: true}, nil, nil, nil, utils.ChooseHystart, utils.ChooseNewReno)
 				Expect(err).ToNot(HaveOccurred())
 				sess.EXPECT().OpenStreamSync(context.Background()).Return(str, nil)
 				buf := &bytes.Buffer{}
@@ -715,7 +745,7 @@ var _ = Describe("Client", func() {
 				buf := &bytes.Buffer{}
 				rstr := mockquic.NewMockStream(mockCtrl)
 				rstr.EXPECT().Write(gomock.Any()).Do(buf.Write).AnyTimes()
-				rw := newResponseWriter(rstr, utils.DefaultLogger)
+				rw := newResponseWriter(nil, rstr, utils.DefaultLogger)
 				rw.Header().Set("Content-Encoding", "gzip")
 				gz := gzip.NewWriter(rw)
 				gz.Write([]byte("gzipped response"))
@@ -741,7 +771,7 @@ var _ = Describe("Client", func() {
 				buf := &bytes.Buffer{}
 				rstr := mockquic.NewMockStream(mockCtrl)
 				rstr.EXPECT().Write(gomock.Any()).Do(buf.Write).AnyTimes()
-				rw := newResponseWriter(rstr, utils.DefaultLogger)
+				rw := newResponseWriter(nil, rstr, utils.DefaultLogger)
 				rw.Write([]byte("not gzipped"))
 				rw.Flush()
 				str.EXPECT().Write(gomock.Any()).AnyTimes().DoAndReturn(func(p []byte) (int, error) { return len(p), nil })
@@ -0,0 +1,136 @@
+package http3
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync/atomic"
+)
+
+// AltSvcHandler returns a handler that adds the Alt-Svc header advertising s to every response,
+// before calling handler. http.DefaultServeMux is used when handler is nil.
+// It is meant to be used with the TCP (HTTP/1.1 and HTTP/2) server that runs alongside s.
+func (s *Server) AltSvcHandler(handler http.Handler) http.Handler {
+	if handler == nil {
+		handler = http.DefaultServeMux
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.SetQuicHeaders(w.Header())
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// A CombinedServer serves HTTP/1.1 and HTTP/2 over TLS, and HTTP/3 over QUIC, using the same handler and certificate.
+// Responses sent over TCP advertise the HTTP/3 server using the Alt-Svc header.
+type CombinedServer struct {
+	// TCP is the server for HTTP/1.1 and HTTP/2 connections.
+	TCP *http.Server
+	// H3 is the server for HTTP/3 connections.
+	H3 *Server
+}
+
+// NewCombinedServer creates a CombinedServer from an existing net/http server.
+// The HTTP/3 server uses the Addr, Handler, TLSConfig and MaxHeaderBytes of srv.
+// srv.Handler is replaced by a handler that sets the Alt-Svc header (see Server.AltSvcHandler).
+// Options of the HTTP/3 server (e.g. the QUIC config) can be set on the H3 field, before the server is started.
+func NewCombinedServer(srv *http.Server) *CombinedServer {
+	h3 := &Server{
+		Server: &http.Server{
+			Addr:           srv.Addr,
+			Handler:        srv.Handler,
+			TLSConfig:      srv.TLSConfig,
+			MaxHeaderBytes: srv.MaxHeaderBytes,
+			ErrorLog:       srv.ErrorLog,
+		},
+	}
+	srv.Handler = h3.AltSvcHandler(srv.Handler)
+	return &CombinedServer{TCP: srv, H3: h3}
+}
+
+// ListenAndServeTLS listens on the TCP and the UDP address s.TCP.Addr, and serves requests on both.
+// If certFile and keyFile are not empty, the certificate is added to the TLS config of both servers.
+// It returns when one of the servers returns an error, after closing the other server.
+func (s *CombinedServer) ListenAndServeTLS(certFile, keyFile string) error {
+	config := &tls.Config{}
+	if s.TCP.TLSConfig != nil {
+		config = s.TCP.TLSConfig.Clone()
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return err
+		}
+		config.Certificates = append(config.Certificates, cert)
+	}
+	s.TCP.TLSConfig = config
+	s.H3.TLSConfig = config
+
+	addr := s.TCP.Addr
+	if addr == "" {
+		addr = ":https"
+	}
+	udpConn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	defer udpConn.Close()
+	tcpLn, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer tcpLn.Close()
+
+	return s.Serve(tcpLn, udpConn)
+}
+
+// Serve serves TLS connections accepted on tcpLn, and QUIC connections on udpConn.
+// The certificate must be configured in s.TCP.TLSConfig and s.H3.TLSConfig.
+// If s.H3.Port is not set, the port of udpConn is advertised in the Alt-Svc header.
+// It returns when one of the servers returns an error, after closing the other server.
+// Neither tcpLn nor udpConn are closed.
+func (s *CombinedServer) Serve(tcpLn net.Listener, udpConn net.PacketConn) error {
+	if addr, ok := udpConn.LocalAddr().(*net.UDPAddr); ok {
+		atomic.CompareAndSwapUint32(&s.H3.Port, 0, uint32(addr.Port))
+	}
+
+	tcpErr := make(chan error, 1)
+	quicErr := make(chan error, 1)
+	go func() {
+		tcpErr <- s.TCP.ServeTLS(tcpLn, "", "")
+	}()
+	go func() {
+		quicErr <- s.H3.Serve(udpConn)
+	}()
+
+	select {
+	case err := <-tcpErr:
+		s.H3.Close()
+		return err
+	case err := <-quicErr:
+		s.TCP.Close()
+		return err
+	}
+}
+
+// Shutdown gracefully shuts down both servers (see http.Server.Shutdown and Server.Shutdown).
+func (s *CombinedServer) Shutdown(ctx context.Context) error {
+	quicErr := make(chan error, 1)
+	go func() {
+		quicErr <- s.H3.Shutdown(ctx)
+	}()
+	err := s.TCP.Shutdown(ctx)
+	if qerr := <-quicErr; err == nil {
+		err = qerr
+	}
+	return err
+}
+
+// Close immediately closes both servers.
+func (s *CombinedServer) Close() error {
+	err := s.TCP.Close()
+	if qerr := s.H3.Close(); err == nil {
+		err = qerr
+	}
+	return err
+}
@@ -0,0 +1,68 @@
+package http3
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/testdata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Combined server", func() {
+	It("adds the Alt-Svc header", func() {
+		s := &Server{Server: &http.Server{Addr: "localhost:1337"}}
+		h := s.AltSvcHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Foo", "bar")
+		}))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, &http.Request{})
+		Expect(w.Header().Get("Foo")).To(Equal("bar"))
+		Expect(w.Header().Get("Alt-Svc")).To(ContainSubstring(`":1337"; ma=2592000`))
+	})
+
+	It("serves the same handler over TCP and QUIC", func() {
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "Hello, %s", r.Proto)
+		})
+		s := NewCombinedServer(&http.Server{Handler: handler, TLSConfig: testdata.GetTLSConfig()})
+		s.H3.TLSConfig = testdata.GetTLSConfig()
+		tcpLn, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		defer tcpLn.Close()
+		udpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).ToNot(HaveOccurred())
+		defer udpConn.Close()
+		serveErr := make(chan error, 1)
+		go func() { serveErr <- s.Serve(tcpLn, udpConn) }()
+
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: testdata.GetRootCA()}}}
+		rsp, err := client.Get(fmt.Sprintf("https://localhost:%d/", tcpLn.Addr().(*net.TCPAddr).Port))
+		Expect(err).ToNot(HaveOccurred())
+		data, err := ioutil.ReadAll(rsp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("Hello, HTTP/1.1"))
+		port := udpConn.LocalAddr().(*net.UDPAddr).Port
+		Expect(rsp.Header.Get("Alt-Svc")).To(ContainSubstring(fmt.Sprintf(`":%d"`, port)))
+
+		rt := &RoundTripper{
+			TLSClientConfig: &tls.Config{RootCAs: testdata.GetRootCA()},
+			QuicConfig:      &quic.Config{Versions: []quic.VersionNumber{quic.VersionDraft29}},
+		}
+		defer rt.Close()
+		rsp, err = (&http.Client{Transport: rt}).Get(fmt.Sprintf("https://localhost:%d/", port))
+		Expect(err).ToNot(HaveOccurred())
+		data, err = ioutil.ReadAll(rsp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("Hello, HTTP/3"))
+
+		Expect(s.Close()).To(Succeed())
+		Eventually(serveErr).Should(Receive(Equal(http.ErrServerClosed)))
+	})
+})
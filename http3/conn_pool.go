@@ -0,0 +1,212 @@
+package http3
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/lucas-clemente/quic-go"
+)
+
+var errClientClosed = errors.New("http3: client closed")
+
+// A pooledClient is a client that keeps track of its requests,
+// such that the RoundTripper can distribute requests across multiple connections to an origin,
+// and close idle connections.
+type pooledClient interface {
+	roundTripCloser
+	// numActiveRequests returns the number of active requests, and if the connection was closed.
+	numActiveRequests() (n int, closed bool)
+	// acquire reserves the client for a request that is about to be sent.
+	// It fails if the connection was closed.
+	acquire() bool
+	// endRequest releases a request reserved by acquire.
+	endRequest()
+	// closeIfIdle closes the connection if there are no active requests.
+	closeIfIdle() bool
+}
+
+var _ pooledClient = &client{}
+
+func (c *client) numActiveRequests() (int, bool) {
+	c.poolMutex.Lock()
+	defer c.poolMutex.Unlock()
+	return c.activeRequests, c.closed
+}
+
+func (c *client) acquire() bool {
+	c.poolMutex.Lock()
+	defer c.poolMutex.Unlock()
+	if c.closed {
+		return false
+	}
+	c.startRequestLocked()
+	return true
+}
+
+func (c *client) startRequest() {
+	c.poolMutex.Lock()
+	c.startRequestLocked()
+	c.poolMutex.Unlock()
+}
+
+func (c *client) startRequestLocked() {
+	c.activeRequests++
+	if c.idleTimer != nil {
+		c.idleTimer.Stop()
+		c.idleTimer = nil
+	}
+}
+
+func (c *client) endRequest() {
+	c.poolMutex.Lock()
+	defer c.poolMutex.Unlock()
+	c.activeRequests--
+	if c.activeRequests > 0 {
+		return
+	}
+	c.idleSince = time.Now()
+	if c.opts.IdleConnTimeout > 0 && !c.closed {
+		c.idleTimer = time.AfterFunc(c.opts.IdleConnTimeout, c.closeIfIdleTimeout)
+	}
+}
+
+// setClosed is called when the connection was closed, or when dialing failed.
+// The RoundTripper then stops using the client.
+func (c *client) setClosed() {
+	c.poolMutex.Lock()
+	c.closed = true
+	c.poolMutex.Unlock()
+}
+
+func (c *client) closeIfIdle() bool {
+	c.poolMutex.Lock()
+	if c.activeRequests > 0 || c.closed {
+		c.poolMutex.Unlock()
+		return false
+	}
+	c.closed = true
+	c.poolMutex.Unlock()
+	c.closeWhenDialed()
+	return true
+}
+
+func (c *client) closeIfIdleTimeout() {
+	c.poolMutex.Lock()
+	// The timer might have fired right before a new request was started.
+	if c.activeRequests > 0 || c.closed || time.Since(c.idleSince) < c.opts.IdleConnTimeout {
+		c.poolMutex.Unlock()
+		return
+	}
+	c.closed = true
+	c.poolMutex.Unlock()
+	c.logger.Debugf("Closing connection to %s after being idle for %s", c.hostname, c.opts.IdleConnTimeout)
+	c.closeWhenDialed()
+}
+
+// closeWhenDialed closes the connection, once dialing finished.
+// If dialing didn't start yet, the connection is never dialed.
+func (c *client) closeWhenDialed() {
+	c.cancelDial()
+	c.dialOnce.Do(func() {
+		c.dialed = make(chan struct{})
+		c.handshakeErr = errClientClosed
+		close(c.dialed)
+	})
+	go func() {
+		<-c.dialed
+		if c.handshakeErr == nil {
+			c.session.CloseWithError(quic.ApplicationErrorCode(ErrCodeNoError), "")
+		}
+	}()
+}
+
+// pickClient returns the client that a request to an origin is sent on.
+// Requests are sent on the connection with the fewest active requests.
+// A new connection is opened if all connections have active requests, as long as there are fewer than MaxConnsPerHost connections.
+// Closed connections are removed from the pool.
+// The client is reserved for the request, and must be released by calling the release function.
+// It must be called with the mutex held.
+func (r *RoundTripper) pickClient(hostname string, onlyCached bool) (http.RoundTripper, func(), error) {
+	for {
+		var (
+			alive      []roundTripCloser
+			best       roundTripCloser
+			bestActive int
+		)
+		for _, cl := range r.clients[hostname] {
+			var active int
+			if pc, ok := cl.(pooledClient); ok {
+				var closed bool
+				active, closed = pc.numActiveRequests()
+				if closed {
+					continue
+				}
+			}
+			alive = append(alive, cl)
+			if best == nil || active < bestActive {
+				best = cl
+				bestActive = active
+			}
+		}
+		r.clients[hostname] = alive
+
+		if best == nil || (bestActive > 0 && len(alive) < r.maxConnsPerHost() && !onlyCached) {
+			if onlyCached {
+				return nil, nil, ErrNoCachedConn
+			}
+			cl, err := newClient(
+				hostname,
+				r.TLSClientConfig,
+				r.roundTripperOpts(),
+				r.QuicConfig,
+				r.Dial,
+				r.DialContext,
+				r.EstartAlgo,
+				r.EcongestionAlgo,
+			)
+			if err != nil {
+				return nil, nil, err
+			}
+			r.clients[hostname] = append(alive, cl)
+			best = cl
+		}
+		pc, ok := best.(pooledClient)
+		if !ok {
+			return best, func() {}, nil
+		}
+		// The connection might have been closed in the meantime, e.g. because the IdleConnTimeout expired.
+		if pc.acquire() {
+			return best, pc.endRequest, nil
+		}
+	}
+}
+
+func (r *RoundTripper) maxConnsPerHost() int {
+	if r.MaxConnsPerHost <= 0 {
+		return 1
+	}
+	return r.MaxConnsPerHost
+}
+
+// CloseIdleConnections closes all connections that don't have any active requests.
+// Requests are active until their response body was read completely, or closed.
+// Standby connections (see EnableStandby) are not closed.
+func (r *RoundTripper) CloseIdleConnections() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for hostname, clients := range r.clients {
+		var remaining []roundTripCloser
+		for _, cl := range clients {
+			if pc, ok := cl.(pooledClient); ok && pc.closeIfIdle() {
+				continue
+			}
+			remaining = append(remaining, cl)
+		}
+		if len(remaining) == 0 {
+			delete(r.clients, hostname)
+			continue
+		}
+		r.clients[hostname] = remaining
+	}
+}
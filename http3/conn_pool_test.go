@@ -0,0 +1,162 @@
+package http3
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/testdata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Connection pool", func() {
+	var (
+		server      *Server
+		conn        *net.UDPConn
+		rt          *RoundTripper
+		host        string
+		remoteAddrs chan string // receives the client's address of every request
+		unblock     chan struct{}
+	)
+
+	BeforeEach(func() {
+		remoteAddrs = make(chan string, 10)
+		unblock = make(chan struct{})
+		mux := http.NewServeMux()
+		mux.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+			remoteAddrs <- r.RemoteAddr
+			w.Write([]byte("foobar"))
+		})
+		mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+			remoteAddrs <- r.RemoteAddr
+			<-unblock
+			w.Write([]byte("foobar"))
+		})
+		server = &Server{Server: &http.Server{Handler: mux, TLSConfig: testdata.GetTLSConfig()}}
+		var err error
+		conn, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).ToNot(HaveOccurred())
+		go server.Serve(conn)
+		host = fmt.Sprintf("localhost:%d", conn.LocalAddr().(*net.UDPAddr).Port)
+		rt = &RoundTripper{
+			TLSClientConfig: &tls.Config{RootCAs: testdata.GetRootCA()},
+			QuicConfig:      &quic.Config{Versions: []quic.VersionNumber{quic.VersionDraft29}},
+		}
+	})
+
+	AfterEach(func() {
+		rt.Close()
+		server.Close()
+		conn.Close()
+	})
+
+	get := func(path string) {
+		defer GinkgoRecover()
+		req, err := http.NewRequest(http.MethodGet, "https://"+host+path, nil)
+		Expect(err).ToNot(HaveOccurred())
+		rsp, err := rt.RoundTrip(req)
+		Expect(err).ToNot(HaveOccurred())
+		data, err := ioutil.ReadAll(rsp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal([]byte("foobar")))
+	}
+
+	// receiveAddrs receives the client's addresses of n requests
+	receiveAddrs := func(n int) map[string]struct{} {
+		addrs := make(map[string]struct{})
+		for i := 0; i < n; i++ {
+			var addr string
+			EventuallyWithOffset(1, remoteAddrs).Should(Receive(&addr))
+			addrs[addr] = struct{}{}
+		}
+		return addrs
+	}
+
+	// getParallel sends n requests in parallel, and returns a channel that is closed when all of them completed
+	getParallel := func(path string, n int) <-chan struct{} {
+		var wg sync.WaitGroup
+		wg.Add(n)
+		for i := 0; i < n; i++ {
+			go func() {
+				defer wg.Done()
+				get(path)
+			}()
+		}
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		return done
+	}
+
+	It("uses a single connection by default", func() {
+		done := getParallel("/slow", 3)
+		Expect(receiveAddrs(3)).To(HaveLen(1))
+		close(unblock)
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("opens multiple connections for parallel requests", func() {
+		rt.MaxConnsPerHost = 2
+		done := getParallel("/slow", 3)
+		Expect(receiveAddrs(3)).To(HaveLen(2))
+		close(unblock)
+		Eventually(done).Should(BeClosed())
+		// sequential requests use the same connection
+		get("/hello")
+		get("/hello")
+		Expect(receiveAddrs(2)).To(HaveLen(1))
+	})
+
+	It("closes idle connections", func() {
+		get("/hello")
+		rt.CloseIdleConnections()
+		Expect(rt.clients).To(BeEmpty())
+		get("/hello")
+		Expect(receiveAddrs(2)).To(HaveLen(2))
+	})
+
+	It("doesn't close connections with active requests", func() {
+		done := getParallel("/slow", 1)
+		receiveAddrs(1)
+		rt.CloseIdleConnections()
+		Expect(rt.clients).To(HaveLen(1))
+		close(unblock)
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("closes connections after the IdleConnTimeout", func() {
+		rt.IdleConnTimeout = scaleDuration(20 * time.Millisecond)
+		get("/hello")
+		Eventually(func() bool {
+			rt.mutex.Lock()
+			defer rt.mutex.Unlock()
+			_, closed := rt.clients[host][0].(pooledClient).numActiveRequests()
+			return closed
+		}).Should(BeTrue())
+		get("/hello")
+		Expect(receiveAddrs(2)).To(HaveLen(2))
+	})
+
+	It("stops using connections that were closed", func() {
+		get("/hello")
+		rt.mutex.Lock()
+		cl := rt.clients[host][0].(*client)
+		rt.mutex.Unlock()
+		Expect(cl.session.CloseWithError(0, "")).To(Succeed())
+		Eventually(func() bool {
+			_, closed := cl.numActiveRequests()
+			return closed
+		}).Should(BeTrue())
+		get("/hello")
+		Expect(receiveAddrs(2)).To(HaveLen(2))
+	})
+})
@@ -0,0 +1,94 @@
+package http3
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/quicvarint"
+)
+
+// datagramDemuxers holds the datagramDemuxer of every QUIC connection that HTTP Datagrams are received on.
+var datagramDemuxers = struct {
+	mutex    sync.Mutex
+	demuxers map[quic.Session]*datagramDemuxer
+}{demuxers: make(map[quic.Session]*datagramDemuxer)}
+
+// RegisterDatagramHandler registers the handler for the HTTP Datagrams (RFC 9297) associated with a request stream.
+// The QUIC datagrams of a connection are received by a single goroutine,
+// which removes the quarter stream ID and passes the payload to the handler registered for that request stream.
+// Datagrams for request streams without a handler are dropped.
+// Every user of HTTP Datagrams on a QUIC connection needs to register here,
+// otherwise they would compete for the QUIC datagrams of the connection.
+// The handler must not block. It is replaced if it is registered again for the same stream.
+// The returned function unregisters the handler.
+func RegisterDatagramHandler(sess quic.Session, id quic.StreamID, handler func([]byte)) (unregister func()) {
+	datagramDemuxers.mutex.Lock()
+	d, ok := datagramDemuxers.demuxers[sess]
+	if !ok {
+		d = &datagramDemuxer{
+			sess:     sess,
+			handlers: make(map[uint64]func([]byte)),
+		}
+		datagramDemuxers.demuxers[sess] = d
+		go func() {
+			d.run()
+			<-sess.Context().Done()
+			datagramDemuxers.mutex.Lock()
+			delete(datagramDemuxers.demuxers, sess)
+			datagramDemuxers.mutex.Unlock()
+		}()
+	}
+	datagramDemuxers.mutex.Unlock()
+
+	quarterStreamID := uint64(id) / 4
+	d.mutex.Lock()
+	d.handlers[quarterStreamID] = handler
+	d.mutex.Unlock()
+	return func() {
+		d.mutex.Lock()
+		delete(d.handlers, quarterStreamID)
+		d.mutex.Unlock()
+	}
+}
+
+// A datagramDemuxer passes the HTTP Datagrams received on a QUIC connection to the handler of the request stream.
+type datagramDemuxer struct {
+	sess quic.Session
+
+	mutex    sync.Mutex
+	handlers map[uint64]func([]byte) // indexed by the quarter stream ID
+}
+
+// run receives datagrams until the QUIC connection is closed.
+func (d *datagramDemuxer) run() {
+	if esess, ok := d.sess.(quic.EarlySession); ok {
+		select {
+		case <-esess.HandshakeComplete().Done():
+		case <-d.sess.Context().Done():
+			return
+		}
+	}
+	if !d.sess.ConnectionState().SupportsDatagrams {
+		return
+	}
+	for {
+		data, err := d.sess.ReceiveMessage()
+		if err != nil {
+			return
+		}
+		r := bytes.NewReader(data)
+		quarterStreamID, err := quicvarint.Read(r)
+		if err != nil {
+			// a datagram without a quarter stream ID
+			continue
+		}
+		d.mutex.Lock()
+		handler, ok := d.handlers[quarterStreamID]
+		d.mutex.Unlock()
+		if !ok {
+			continue
+		}
+		handler(data[len(data)-r.Len():])
+	}
+}
@@ -0,0 +1,74 @@
+package http3
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lucas-clemente/quic-go"
+	mockquic "github.com/lucas-clemente/quic-go/internal/mocks/quic"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Datagrams", func() {
+	var (
+		sess      *mockquic.MockEarlySession
+		datagrams chan []byte
+		closeSess context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		datagrams = make(chan []byte, 10)
+		var sessCtx context.Context
+		sessCtx, closeSess = context.WithCancel(context.Background())
+		handshakeCtx, handshakeComplete := context.WithCancel(context.Background())
+		handshakeComplete()
+		sess = mockquic.NewMockEarlySession(mockCtrl)
+		sess.EXPECT().HandshakeComplete().Return(handshakeCtx).AnyTimes()
+		sess.EXPECT().Context().Return(sessCtx).AnyTimes()
+		sess.EXPECT().ConnectionState().Return(quic.ConnectionState{SupportsDatagrams: true}).AnyTimes()
+		sess.EXPECT().ReceiveMessage().DoAndReturn(func() ([]byte, error) {
+			select {
+			case data := <-datagrams:
+				return data, nil
+			case <-sessCtx.Done():
+				return nil, errors.New("closed")
+			}
+		}).AnyTimes()
+	})
+
+	AfterEach(func() {
+		closeSess()
+		Eventually(func() bool {
+			datagramDemuxers.mutex.Lock()
+			defer datagramDemuxers.mutex.Unlock()
+			_, ok := datagramDemuxers.demuxers[sess]
+			return ok
+		}).Should(BeFalse())
+	})
+
+	It("passes datagrams to the handler of the request stream", func() {
+		received0 := make(chan []byte, 10)
+		received4 := make(chan []byte, 10)
+		defer RegisterDatagramHandler(sess, 0, func(b []byte) { received0 <- b })()
+		defer RegisterDatagramHandler(sess, 4, func(b []byte) { received4 <- b })()
+		datagrams <- []byte{1, 'f', 'o', 'o'} // quarter stream ID 1
+		datagrams <- []byte{0, 'b', 'a', 'r'} // quarter stream ID 0
+		Eventually(received4).Should(Receive(Equal([]byte("foo"))))
+		Eventually(received0).Should(Receive(Equal([]byte("bar"))))
+	})
+
+	It("drops datagrams for request streams without a handler", func() {
+		received := make(chan []byte, 10)
+		unregister := RegisterDatagramHandler(sess, 4, func(b []byte) { received <- b })
+		datagrams <- []byte{2, 'f', 'o', 'o'} // quarter stream ID 2
+		datagrams <- []byte{}                 // no quarter stream ID
+		datagrams <- []byte{1, 'b', 'a', 'r'}
+		Eventually(received).Should(Receive(Equal([]byte("bar"))))
+		unregister()
+		datagrams <- []byte{1, 'b', 'a', 'z'}
+		Eventually(datagrams).Should(BeEmpty())
+		Consistently(received).ShouldNot(Receive())
+	})
+})
@@ -0,0 +1,102 @@
+package http3
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/testdata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Custom dial function", func() {
+	var (
+		server *Server
+		conn   *net.UDPConn
+		url    string
+	)
+
+	BeforeEach(func() {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/addr", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(r.RemoteAddr))
+		})
+		server = &Server{Server: &http.Server{Handler: mux, TLSConfig: testdata.GetTLSConfig()}}
+		var err error
+		conn, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).ToNot(HaveOccurred())
+		go server.Serve(conn)
+		url = fmt.Sprintf("https://example.com:%d/addr", conn.LocalAddr().(*net.UDPAddr).Port)
+	})
+
+	AfterEach(func() {
+		server.Close()
+		conn.Close()
+	})
+
+	newRoundTripper := func() *RoundTripper {
+		return &RoundTripper{
+			TLSClientConfig: &tls.Config{RootCAs: testdata.GetRootCA(), ServerName: "localhost"},
+			QuicConfig:      &quic.Config{Versions: []quic.VersionNumber{quic.VersionDraft29}},
+		}
+	}
+
+	It("uses the dial function to resolve the name and choose the socket", func() {
+		pconn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).ToNot(HaveOccurred())
+		defer pconn.Close()
+		rt := newRoundTripper()
+		defer rt.Close()
+		dialedAddrs := make(chan string, 1)
+		rt.DialContext = func(ctx context.Context, addr string, tlsConf *tls.Config, quicConf *quic.Config) (quic.EarlySession, error) {
+			dialedAddrs <- addr
+			_, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			raddr, err := net.ResolveUDPAddr("udp", net.JoinHostPort("127.0.0.1", port))
+			if err != nil {
+				return nil, err
+			}
+			return quic.DialEarlyContext(ctx, pconn, raddr, "localhost", tlsConf, quicConf, rt.EstartAlgo, rt.EcongestionAlgo)
+		}
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		Expect(err).ToNot(HaveOccurred())
+		rsp, err := rt.RoundTrip(req)
+		Expect(err).ToNot(HaveOccurred())
+		data, err := ioutil.ReadAll(rsp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal(pconn.LocalAddr().String()))
+		Expect(dialedAddrs).To(Receive(Equal(req.URL.Host)))
+	})
+
+	It("cancels the dial when the RoundTripper is closed", func() {
+		rt := newRoundTripper()
+		dialCtx := make(chan context.Context, 1)
+		rt.DialContext = func(ctx context.Context, _ string, _ *tls.Config, _ *quic.Config) (quic.EarlySession, error) {
+			dialCtx <- ctx
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		errChan := make(chan error, 1)
+		go func() {
+			defer GinkgoRecover()
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = rt.RoundTrip(req)
+			errChan <- err
+		}()
+		var ctx context.Context
+		Eventually(dialCtx).Should(Receive(&ctx))
+		Consistently(ctx.Done()).ShouldNot(BeClosed())
+		Expect(rt.Close()).To(Succeed())
+		Eventually(ctx.Done()).Should(BeClosed())
+		Eventually(errChan).Should(Receive(MatchError(context.Canceled)))
+	})
+})
@@ -0,0 +1,76 @@
+package http3
+
+import (
+	"context"
+	"net/http"
+)
+
+// EarlyDataStatus says if a request was sent in 0-RTT, and if the server accepted it.
+type EarlyDataStatus uint8
+
+const (
+	// EarlyDataNotUsed means that the request was sent after the handshake completed.
+	EarlyDataNotUsed EarlyDataStatus = iota
+	// EarlyDataAccepted means that the request was sent in 0-RTT, and the server processed it.
+	EarlyDataAccepted
+	// EarlyDataReplayed means that the request was sent in 0-RTT, but the server rejected 0-RTT,
+	// or responded with 425 (Too Early). The request was sent again after the handshake completed.
+	EarlyDataReplayed
+)
+
+func (s EarlyDataStatus) String() string {
+	switch s {
+	case EarlyDataNotUsed:
+		return "not used"
+	case EarlyDataAccepted:
+		return "accepted"
+	case EarlyDataReplayed:
+		return "replayed"
+	default:
+		return "unknown early data status"
+	}
+}
+
+type allow0RTTKey struct{}
+
+// With0RTT returns a shallow copy of the request that may be sent in 0-RTT,
+// independent of the RoundTripper.Allow0RTT policy.
+// 0-RTT data can be replayed by an attacker, so only requests that are safe to replay should opt in.
+func With0RTT(req *http.Request) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), allow0RTTKey{}, true))
+}
+
+// SafeMethodPolicy is a policy for RoundTripper.Allow0RTT.
+// It allows requests with a safe method (GET, HEAD, OPTIONS and TRACE) and without a body to be sent in 0-RTT,
+// following the recommendations of RFC 8470.
+func SafeMethodPolicy(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody {
+		return false
+	}
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// ResponseEarlyData returns if the request of a response was sent in 0-RTT,
+// and if the server accepted it.
+// It returns EarlyDataNotUsed for responses that weren't received by an HTTP/3 RoundTripper.
+func ResponseEarlyData(rsp *http.Response) EarlyDataStatus {
+	if b, ok := rsp.Body.(interface{ earlyDataStatus() EarlyDataStatus }); ok {
+		return b.earlyDataStatus()
+	}
+	return EarlyDataNotUsed
+}
+
+func setEarlyDataStatus(rsp *http.Response, status EarlyDataStatus) {
+	switch b := rsp.Body.(type) {
+	case *body:
+		b.earlyData = status
+	case *gzipReader:
+		if rb, ok := b.body.(*body); ok {
+			rb.earlyData = status
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package http3
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/testdata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("0-RTT requests", func() {
+	It("names the early data status", func() {
+		Expect(EarlyDataNotUsed.String()).To(Equal("not used"))
+		Expect(EarlyDataAccepted.String()).To(Equal("accepted"))
+		Expect(EarlyDataReplayed.String()).To(Equal("replayed"))
+		Expect(EarlyDataStatus(42).String()).To(Equal("unknown early data status"))
+	})
+
+	It("allows requests with safe methods", func() {
+		for _, m := range []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace} {
+			req, err := http.NewRequest(m, "https://quic.clemente.io", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(SafeMethodPolicy(req)).To(BeTrue())
+		}
+		req, err := http.NewRequest(http.MethodPost, "https://quic.clemente.io", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(SafeMethodPolicy(req)).To(BeFalse())
+		req, err = http.NewRequest(http.MethodGet, "https://quic.clemente.io", bytes.NewReader([]byte("foobar")))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(SafeMethodPolicy(req)).To(BeFalse())
+	})
+
+	It("uses the policy and the per-request opt-in", func() {
+		c := &client{opts: &roundTripperOpts{}}
+		req, err := http.NewRequest(http.MethodPost, "https://quic.clemente.io", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.allow0RTT(req)).To(BeFalse())
+		Expect(c.allow0RTT(With0RTT(req))).To(BeTrue())
+		c.opts.Allow0RTT = func(r *http.Request) bool { return r.Method == http.MethodPost }
+		Expect(c.allow0RTT(req)).To(BeTrue())
+	})
+
+	It("reports that responses of other RoundTrippers didn't use 0-RTT", func() {
+		Expect(ResponseEarlyData(&http.Response{Body: ioutil.NopCloser(&bytes.Buffer{})})).To(Equal(EarlyDataNotUsed))
+	})
+
+	Context("using a server", func() {
+		var (
+			server       *Server
+			conn         *net.UDPConn
+			sessionCache tls.ClientSessionCache
+		)
+
+		startServer := func(addr *net.UDPAddr) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("Hello World!"))
+			})
+			server = &Server{Server: &http.Server{Handler: mux, TLSConfig: testdata.GetTLSConfig()}}
+			var err error
+			conn, err = net.ListenUDP("udp", addr)
+			Expect(err).ToNot(HaveOccurred())
+			go server.Serve(conn)
+		}
+
+		newRoundTripper := func() *RoundTripper {
+			return &RoundTripper{
+				TLSClientConfig: &tls.Config{RootCAs: testdata.GetRootCA(), ClientSessionCache: sessionCache},
+				QuicConfig:      &quic.Config{Versions: []quic.VersionNumber{quic.VersionDraft29}},
+				Allow0RTT:       SafeMethodPolicy,
+			}
+		}
+
+		get := func(rt *RoundTripper) *http.Response {
+			url := fmt.Sprintf("https://localhost:%d/hello", conn.LocalAddr().(*net.UDPAddr).Port)
+			rsp, err := (&http.Client{Transport: rt}).Get(url)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+			data, err := ioutil.ReadAll(rsp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal("Hello World!"))
+			return rsp
+		}
+
+		BeforeEach(func() {
+			sessionCache = tls.NewLRUClientSessionCache(10)
+			startServer(&net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		})
+
+		AfterEach(func() {
+			Expect(server.Close()).To(Succeed())
+			conn.Close()
+		})
+
+		It("sends requests in 0-RTT", func() {
+			rt := newRoundTripper()
+			Expect(ResponseEarlyData(get(rt))).To(Equal(EarlyDataNotUsed))
+			Expect(rt.Close()).To(Succeed())
+			Expect(server.EarlyResponseStats().Requests).To(BeZero())
+
+			rt = newRoundTripper()
+			defer rt.Close()
+			Expect(ResponseEarlyData(get(rt))).To(Equal(EarlyDataAccepted))
+			Expect(server.EarlyResponseStats().Requests).To(BeEquivalentTo(1))
+			// requests sent after the handshake don't use 0-RTT
+			Expect(ResponseEarlyData(get(rt))).To(Equal(EarlyDataNotUsed))
+		})
+
+		It("replays requests when 0-RTT is rejected", func() {
+			rt := newRoundTripper()
+			Expect(ResponseEarlyData(get(rt))).To(Equal(EarlyDataNotUsed))
+			Expect(rt.Close()).To(Succeed())
+
+			// A new server can't decrypt the session ticket issued by the old server.
+			addr := conn.LocalAddr().(*net.UDPAddr)
+			Expect(server.Close()).To(Succeed())
+			Expect(conn.Close()).To(Succeed())
+			startServer(addr)
+
+			rt = newRoundTripper()
+			defer rt.Close()
+			Expect(ResponseEarlyData(get(rt))).To(Equal(EarlyDataReplayed))
+			Expect(ResponseEarlyData(get(rt))).To(Equal(EarlyDataNotUsed))
+		})
+	})
+})
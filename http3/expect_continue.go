@@ -0,0 +1,62 @@
+package http3
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// expectsContinue says if the request has an "Expect: 100-continue" header.
+func expectsContinue(req *http.Request) bool {
+	for _, v := range req.Header["Expect"] {
+		if strings.EqualFold(strings.TrimSpace(v), "100-continue") {
+			return true
+		}
+	}
+	return false
+}
+
+// A continueSignal tells the request writer if the body of a request with an "Expect: 100-continue" header should be sent.
+// The body is sent once the server responds with 100 (Continue), or when the timeout expires.
+// It is not sent if the server responds with a final status code first, or if the request fails.
+type continueSignal struct {
+	C chan bool
+
+	once  sync.Once
+	timer *time.Timer
+}
+
+func newContinueSignal(timeout time.Duration) *continueSignal {
+	s := &continueSignal{C: make(chan bool, 1)}
+	s.timer = time.AfterFunc(timeout, func() { s.send(true) })
+	return s
+}
+
+func (s *continueSignal) send(sendBody bool) {
+	s.once.Do(func() { s.C <- sendBody })
+}
+
+// done is called when the response header was received, or the request failed.
+// If the body wasn't sent by then, it won't be sent.
+func (s *continueSignal) done() {
+	s.timer.Stop()
+	s.send(false)
+}
+
+// expectContinueBody is the body of a request with an "Expect: 100-continue" header.
+// The first time the handler reads the body, a 100 (Continue) response is sent,
+// unless the handler already sent the response header.
+// Like net/http, handlers that respond without reading the body don't send a 100 (Continue) response,
+// such that the client doesn't send the body.
+type expectContinueBody struct {
+	*body
+
+	once          sync.Once
+	writeContinue func()
+}
+
+func (b *expectContinueBody) Read(p []byte) (int, error) {
+	b.once.Do(b.writeContinue)
+	return b.body.Read(p)
+}
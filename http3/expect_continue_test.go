@@ -0,0 +1,127 @@
+package http3
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/testdata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// countingReader counts how often Read was called
+type countingReader struct {
+	io.Reader
+	reads int32
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	atomic.AddInt32(&r.reads, 1)
+	return r.Reader.Read(p)
+}
+
+var _ = Describe("Expect: 100-continue", func() {
+	var (
+		server *Server
+		conn   *net.UDPConn
+		rt     *RoundTripper
+		port   int
+	)
+
+	BeforeEach(func() {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+			data, _ := ioutil.ReadAll(r.Body)
+			w.Write(data)
+		})
+		mux.HandleFunc("/reject", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+		mux.HandleFunc("/silent", func(w http.ResponseWriter, r *http.Request) {
+			// read the body without sending a 100 (Continue) response
+			data, _ := ioutil.ReadAll(r.Body.(*expectContinueBody).body)
+			w.Write(data)
+		})
+		server = &Server{Server: &http.Server{Handler: mux, TLSConfig: testdata.GetTLSConfig()}}
+		var err error
+		conn, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).ToNot(HaveOccurred())
+		go server.Serve(conn)
+		port = conn.LocalAddr().(*net.UDPAddr).Port
+		rt = &RoundTripper{
+			TLSClientConfig:       &tls.Config{RootCAs: testdata.GetRootCA()},
+			QuicConfig:            &quic.Config{Versions: []quic.VersionNumber{quic.VersionDraft29}},
+			ExpectContinueTimeout: scaleDuration(10 * time.Second),
+		}
+	})
+
+	AfterEach(func() {
+		rt.Close()
+		server.Close()
+		conn.Close()
+	})
+
+	newRequest := func(path string, body io.Reader, trace *httptrace.ClientTrace) *http.Request {
+		req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("https://localhost:%d%s", port, path), body)
+		ExpectWithOffset(1, err).ToNot(HaveOccurred())
+		req.Header.Set("Expect", "100-continue")
+		if trace != nil {
+			req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+		}
+		return req
+	}
+
+	It("sends the body after receiving a 100 (Continue) response", func() {
+		var waited, gotContinue bool
+		trace := &httptrace.ClientTrace{
+			Wait100Continue: func() { waited = true },
+			Got100Continue:  func() { gotContinue = true },
+		}
+		rsp, err := rt.RoundTrip(newRequest("/echo", bytes.NewReader([]byte("foobar")), trace))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+		data, err := ioutil.ReadAll(rsp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal([]byte("foobar")))
+		Expect(waited).To(BeTrue())
+		Expect(gotContinue).To(BeTrue())
+	})
+
+	It("doesn't send the body if the server responds with a final status code", func() {
+		body := &countingReader{Reader: bytes.NewReader([]byte("foobar"))}
+		rsp, err := rt.RoundTrip(newRequest("/reject", ioutil.NopCloser(body), nil))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rsp.StatusCode).To(Equal(http.StatusUnauthorized))
+		Consistently(func() int32 { return atomic.LoadInt32(&body.reads) }).Should(BeZero())
+	})
+
+	It("sends the body when the timeout expires", func() {
+		rt.ExpectContinueTimeout = scaleDuration(50 * time.Millisecond)
+		start := time.Now()
+		rsp, err := rt.RoundTrip(newRequest("/silent", bytes.NewReader([]byte("foobar")), nil))
+		Expect(err).ToNot(HaveOccurred())
+		data, err := ioutil.ReadAll(rsp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal([]byte("foobar")))
+		Expect(time.Since(start)).To(BeNumerically(">=", rt.ExpectContinueTimeout))
+	})
+
+	It("sends the body immediately if no timeout is set", func() {
+		rt.ExpectContinueTimeout = 0
+		rsp, err := rt.RoundTrip(newRequest("/silent", bytes.NewReader([]byte("foobar")), nil))
+		Expect(err).ToNot(HaveOccurred())
+		data, err := ioutil.ReadAll(rsp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal([]byte("foobar")))
+	})
+})
@@ -0,0 +1,127 @@
+package http3
+
+import (
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/lucas-clemente/quic-go"
+)
+
+// addStandby creates the standby client for an origin, and starts dialing its connection.
+// It must be called with the mutex held.
+func (r *RoundTripper) addStandby(hostname string) error {
+	if r.standbys == nil {
+		r.standbys = make(map[string]roundTripCloser)
+	}
+	if _, ok := r.standbys[hostname]; ok {
+		return nil
+	}
+	dial := r.StandbyDial
+	dialContext := r.DialContext
+	if dial == nil {
+		dial = r.Dial
+	} else {
+		dialContext = nil
+	}
+	// The standby connection is idle until it becomes the active connection.
+	quicConf := defaultQuicConfig.Clone()
+	if r.QuicConfig != nil {
+		quicConf = r.QuicConfig.Clone()
+	}
+	quicConf.KeepAlive = true
+	cl, err := newClient(hostname, r.TLSClientConfig, r.roundTripperOpts(), quicConf, dial, dialContext, r.EstartAlgo, r.EcongestionAlgo)
+	if err != nil {
+		return err
+	}
+	r.standbys[hostname] = cl
+	cl.startDial(nil)
+	return nil
+}
+
+// failover is called when a request failed because its connection failed.
+// The standby client becomes the active client, and a new standby client is created.
+// The request is then retried, if possible.
+func (r *RoundTripper) failover(hostname string, failed http.RoundTripper, req *http.Request, opt RoundTripOpt, reqErr error) (*http.Response, error) {
+	r.mutex.Lock()
+	// Another request might already have failed over.
+	clients := r.clients[hostname]
+	for i, cl := range clients {
+		if cl != failed {
+			continue
+		}
+		cl.Close()
+		clients = append(clients[:i:i], clients[i+1:]...)
+		if standby, ok := r.standbys[hostname]; ok {
+			clients = append(clients, standby)
+			delete(r.standbys, hostname)
+			if err := r.addStandby(hostname); err != nil {
+				r.clients[hostname] = clients
+				r.mutex.Unlock()
+				return nil, err
+			}
+		}
+		r.clients[hostname] = clients
+		break
+	}
+	r.mutex.Unlock()
+
+	if !isReplayable(req) {
+		return nil, reqErr
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = body
+	}
+	cl, release, err := r.getClient(hostname, opt.OnlyCachedConn)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return cl.RoundTrip(req)
+}
+
+// isConnectionError says if a request failed because its connection failed (or couldn't be established),
+// as opposed to an error that only affected the request.
+func isConnectionError(err error) bool {
+	var (
+		transportErr          *quic.TransportError
+		applicationErr        *quic.ApplicationError
+		idleTimeoutErr        *quic.IdleTimeoutError
+		handshakeTimeoutErr   *quic.HandshakeTimeoutError
+		firstFlightTimeoutErr *quic.FirstFlightTimeoutError
+		statelessResetErr     *quic.StatelessResetError
+		opErr                 *net.OpError
+	)
+	return errors.As(err, &transportErr) ||
+		errors.As(err, &applicationErr) ||
+		errors.As(err, &idleTimeoutErr) ||
+		errors.As(err, &handshakeTimeoutErr) ||
+		errors.As(err, &firstFlightTimeoutErr) ||
+		errors.As(err, &statelessResetErr) ||
+		errors.As(err, &opErr)
+}
+
+// isReplayable says if a request can be retried on another connection.
+// Like net/http, only idempotent requests (or requests with an idempotency key) with a body that can be replayed are retried.
+func isReplayable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, MethodGet0RTT:
+		return true
+	}
+	// See https://datatracker.ietf.org/doc/draft-ietf-httpapi-idempotency-key-header/.
+	if _, ok := req.Header["Idempotency-Key"]; ok {
+		return true
+	}
+	if _, ok := req.Header["X-Idempotency-Key"]; ok {
+		return true
+	}
+	return false
+}
@@ -2,6 +2,7 @@ package http3
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -10,14 +11,33 @@ import (
 	"github.com/lucas-clemente/quic-go/quicvarint"
 )
 
+// FrameType is the frame type of a HTTP/3 frame
+type FrameType uint64
+
 type frame interface{}
 
+// unknownFrameHandlerFunc is called for unknown frame types, before the frame length is read.
+// If it returns true, the stream was taken over by the handler, and parsing stops.
+type unknownFrameHandlerFunc func(FrameType) (hijacked bool)
+
+// errHijacked is returned when a stream was taken over by a hijacker.
+var errHijacked = errors.New("hijacked")
+
 func parseNextFrame(r io.Reader) (frame, error) {
+	return parseNextFrameWithHandler(r, nil)
+}
+
+func parseNextFrameWithHandler(r io.Reader, unknownFrameHandler unknownFrameHandlerFunc) (frame, error) {
 	qr := quicvarint.NewReader(r)
 	t, err := quicvarint.Read(qr)
 	if err != nil {
 		return nil, err
 	}
+	if unknownFrameHandler != nil && t != 0x0 && t != 0x1 && t != 0x4 && t != 0x7 && t != 0xf0700 {
+		if unknownFrameHandler(FrameType(t)) {
+			return nil, errHijacked
+		}
+	}
 	l, err := quicvarint.Read(qr)
 	if err != nil {
 		return nil, err
@@ -30,12 +50,14 @@ func parseNextFrame(r io.Reader) (frame, error) {
 		return &headersFrame{Length: l}, nil
 	case 0x4:
 		return parseSettingsFrame(r, l)
+	case 0x7:
+		return parseGoAwayFrame(r, l)
+	case 0xf0700:
+		return parsePriorityUpdateFrame(r, l)
 	case 0x3: // CANCEL_PUSH
 		fallthrough
 	case 0x5: // PUSH_PROMISE
 		fallthrough
-	case 0x7: // GOAWAY
-		fallthrough
 	case 0xd: // MAX_PUSH_ID
 		fallthrough
 	case 0xe: // DUPLICATE_PUSH
@@ -45,7 +67,7 @@ func parseNextFrame(r io.Reader) (frame, error) {
 		if _, err := io.CopyN(ioutil.Discard, qr, int64(l)); err != nil {
 			return nil, err
 		}
-		return parseNextFrame(qr)
+		return parseNextFrameWithHandler(qr, unknownFrameHandler)
 	}
 }
 
@@ -67,11 +89,19 @@ func (f *headersFrame) Write(b *bytes.Buffer) {
 	quicvarint.Write(b, f.Length)
 }
 
-const settingDatagram = 0x276
+const (
+	settingQPACKMaxTableCapacity = 0x1
+	settingQPACKBlockedStreams   = 0x7
+	settingDatagram              = 0x276
+	settingExtendedConnect       = 0x8
+)
 
 type settingsFrame struct {
-	Datagram bool
-	other    map[uint64]uint64 // all settings that we don't explicitly recognize
+	QPACKMaxTableCapacity uint64 // see RFC 9204
+	QPACKBlockedStreams   uint64
+	Datagram              bool
+	ExtendedConnect       bool              // see RFC 9220
+	other                 map[uint64]uint64 // all settings that we don't explicitly recognize
 }
 
 func parseSettingsFrame(r io.Reader, l uint64) (*settingsFrame, error) {
@@ -87,7 +117,7 @@ func parseSettingsFrame(r io.Reader, l uint64) (*settingsFrame, error) {
 	}
 	frame := &settingsFrame{}
 	b := bytes.NewReader(buf)
-	var readDatagram bool
+	var readDatagram, readExtendedConnect, readMaxTableCapacity, readBlockedStreams bool
 	for b.Len() > 0 {
 		id, err := quicvarint.Read(b)
 		if err != nil { // should not happen. We allocated the whole frame already.
@@ -99,6 +129,18 @@ func parseSettingsFrame(r io.Reader, l uint64) (*settingsFrame, error) {
 		}
 
 		switch id {
+		case settingQPACKMaxTableCapacity:
+			if readMaxTableCapacity {
+				return nil, fmt.Errorf("duplicate setting: %d", id)
+			}
+			readMaxTableCapacity = true
+			frame.QPACKMaxTableCapacity = val
+		case settingQPACKBlockedStreams:
+			if readBlockedStreams {
+				return nil, fmt.Errorf("duplicate setting: %d", id)
+			}
+			readBlockedStreams = true
+			frame.QPACKBlockedStreams = val
 		case settingDatagram:
 			if readDatagram {
 				return nil, fmt.Errorf("duplicate setting: %d", id)
@@ -108,6 +150,15 @@ func parseSettingsFrame(r io.Reader, l uint64) (*settingsFrame, error) {
 				return nil, fmt.Errorf("invalid value for H3_DATAGRAM: %d", val)
 			}
 			frame.Datagram = val == 1
+		case settingExtendedConnect:
+			if readExtendedConnect {
+				return nil, fmt.Errorf("duplicate setting: %d", id)
+			}
+			readExtendedConnect = true
+			if val != 0 && val != 1 {
+				return nil, fmt.Errorf("invalid value for SETTINGS_ENABLE_CONNECT_PROTOCOL: %d", val)
+			}
+			frame.ExtendedConnect = val == 1
 		default:
 			if _, ok := frame.other[id]; ok {
 				return nil, fmt.Errorf("duplicate setting: %d", id)
@@ -127,16 +178,107 @@ func (f *settingsFrame) Write(b *bytes.Buffer) {
 	for id, val := range f.other {
 		l += quicvarint.Len(id) + quicvarint.Len(val)
 	}
+	if f.QPACKMaxTableCapacity > 0 {
+		l += quicvarint.Len(settingQPACKMaxTableCapacity) + quicvarint.Len(f.QPACKMaxTableCapacity)
+	}
+	if f.QPACKBlockedStreams > 0 {
+		l += quicvarint.Len(settingQPACKBlockedStreams) + quicvarint.Len(f.QPACKBlockedStreams)
+	}
 	if f.Datagram {
 		l += quicvarint.Len(settingDatagram) + quicvarint.Len(1)
 	}
+	if f.ExtendedConnect {
+		l += quicvarint.Len(settingExtendedConnect) + quicvarint.Len(1)
+	}
 	quicvarint.Write(b, uint64(l))
+	if f.QPACKMaxTableCapacity > 0 {
+		quicvarint.Write(b, settingQPACKMaxTableCapacity)
+		quicvarint.Write(b, f.QPACKMaxTableCapacity)
+	}
+	if f.QPACKBlockedStreams > 0 {
+		quicvarint.Write(b, settingQPACKBlockedStreams)
+		quicvarint.Write(b, f.QPACKBlockedStreams)
+	}
 	if f.Datagram {
 		quicvarint.Write(b, settingDatagram)
 		quicvarint.Write(b, 1)
 	}
+	if f.ExtendedConnect {
+		quicvarint.Write(b, settingExtendedConnect)
+		quicvarint.Write(b, 1)
+	}
 	for id, val := range f.other {
 		quicvarint.Write(b, id)
 		quicvarint.Write(b, val)
 	}
 }
+
+// A priorityUpdateFrame is a PRIORITY_UPDATE frame for a request stream, see RFC 9218.
+type priorityUpdateFrame struct {
+	StreamID uint64
+	Priority string // the value of the Priority header field
+}
+
+func parsePriorityUpdateFrame(r io.Reader, l uint64) (*priorityUpdateFrame, error) {
+	if l > 1024 {
+		return nil, fmt.Errorf("unexpected size for PRIORITY_UPDATE frame: %d", l)
+	}
+	buf := make([]byte, l)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	b := bytes.NewReader(buf)
+	id, err := quicvarint.Read(b)
+	if err != nil {
+		return nil, err
+	}
+	return &priorityUpdateFrame{
+		StreamID: id,
+		Priority: string(buf[len(buf)-b.Len():]),
+	}, nil
+}
+
+func (f *priorityUpdateFrame) Write(b *bytes.Buffer) {
+	quicvarint.Write(b, 0xf0700)
+	quicvarint.Write(b, uint64(quicvarint.Len(f.StreamID))+uint64(len(f.Priority)))
+	quicvarint.Write(b, f.StreamID)
+	b.WriteString(f.Priority)
+}
+
+// A goAwayFrame is a GOAWAY frame.
+// When sent by the server, the ID is a stream ID: requests on this and higher stream IDs are not processed.
+// When sent by the client, it is a push ID.
+type goAwayFrame struct {
+	ID uint64
+}
+
+func parseGoAwayFrame(r io.Reader, l uint64) (*goAwayFrame, error) {
+	if l > 8 {
+		return nil, fmt.Errorf("unexpected size for GOAWAY frame: %d", l)
+	}
+	buf := make([]byte, l)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+	b := bytes.NewReader(buf)
+	id, err := quicvarint.Read(b)
+	if err != nil {
+		return nil, err
+	}
+	if b.Len() > 0 {
+		return nil, errors.New("GOAWAY frame has trailing data")
+	}
+	return &goAwayFrame{ID: id}, nil
+}
+
+func (f *goAwayFrame) Write(b *bytes.Buffer) {
+	quicvarint.Write(b, 0x7)
+	quicvarint.Write(b, uint64(quicvarint.Len(f.ID)))
+	quicvarint.Write(b, f.ID)
+}
@@ -30,6 +30,48 @@ var _ = Describe("Frames", func() {
 		Expect(frame.(*dataFrame).Length).To(Equal(uint64(0x1234)))
 	})
 
+	It("passes unknown frame types to the handler", func() {
+		data := appendVarInt(nil, 0x41) // type byte
+		data = appendVarInt(data, 1337)
+		var handled FrameType
+		r := bytes.NewReader(data)
+		_, err := parseNextFrameWithHandler(r, func(ft FrameType) bool {
+			handled = ft
+			return true
+		})
+		Expect(err).To(Equal(errHijacked))
+		Expect(handled).To(Equal(FrameType(0x41)))
+		Expect(r.Len()).To(Equal(2)) // the handler reads the rest of the stream
+	})
+
+	It("skips unknown frames that the handler doesn't hijack", func() {
+		data := appendVarInt(nil, 0x41) // type byte
+		data = appendVarInt(data, 0x2)
+		data = append(data, []byte{0x13, 0x37}...)
+		buf := bytes.NewBuffer(data)
+		(&dataFrame{Length: 0x1234}).Write(buf)
+		frame, err := parseNextFrameWithHandler(buf, func(FrameType) bool { return false })
+		Expect(err).ToNot(HaveOccurred())
+		Expect(frame).To(Equal(&dataFrame{Length: 0x1234}))
+	})
+
+	It("passes unknown frame types following a reserved frame to the handler", func() {
+		data := appendVarInt(nil, 0x21) // a reserved frame type, see section 7.2.8 of RFC 9114
+		data = appendVarInt(data, 0x2)
+		data = append(data, []byte{0x13, 0x37}...)
+		data = appendVarInt(data, 0x41)
+		data = appendVarInt(data, 1337)
+		var handled []FrameType
+		r := bytes.NewReader(data)
+		_, err := parseNextFrameWithHandler(r, func(ft FrameType) bool {
+			handled = append(handled, ft)
+			return ft == 0x41
+		})
+		Expect(err).To(Equal(errHijacked))
+		Expect(handled).To(Equal([]FrameType{0x21, 0x41}))
+		Expect(r.Len()).To(Equal(2)) // the handler reads the rest of the stream
+	})
+
 	Context("DATA frames", func() {
 		It("parses", func() {
 			data := appendVarInt(nil, 0) // type byte
@@ -103,7 +145,7 @@ var _ = Describe("Frames", func() {
 
 		It("writes", func() {
 			sf := &settingsFrame{other: map[uint64]uint64{
-				1:  2,
+				3:  2,
 				99: 999,
 				13: 37,
 			}}
@@ -179,5 +221,119 @@ var _ = Describe("Frames", func() {
 				Expect(frame).To(Equal(sf))
 			})
 		})
+
+		Context("QPACK settings", func() {
+			It("writes and parses the settings", func() {
+				sf := &settingsFrame{QPACKMaxTableCapacity: 4096, QPACKBlockedStreams: 100}
+				buf := &bytes.Buffer{}
+				sf.Write(buf)
+				frame, err := parseNextFrame(buf)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(frame).To(Equal(sf))
+			})
+
+			It("rejects duplicate settings", func() {
+				settings := appendVarInt(nil, settingQPACKBlockedStreams)
+				settings = appendVarInt(settings, 10)
+				settings = appendVarInt(settings, settingQPACKBlockedStreams)
+				settings = appendVarInt(settings, 10)
+				data := appendVarInt(nil, 4) // type byte
+				data = appendVarInt(data, uint64(len(settings)))
+				data = append(data, settings...)
+				_, err := parseNextFrame(bytes.NewReader(data))
+				Expect(err).To(MatchError(fmt.Sprintf("duplicate setting: %d", settingQPACKBlockedStreams)))
+			})
+		})
+
+		Context("SETTINGS_ENABLE_CONNECT_PROTOCOL", func() {
+			It("rejects invalid values", func() {
+				settings := appendVarInt(nil, settingExtendedConnect)
+				settings = appendVarInt(settings, 2)
+				data := appendVarInt(nil, 4) // type byte
+				data = appendVarInt(data, uint64(len(settings)))
+				data = append(data, settings...)
+				_, err := parseNextFrame(bytes.NewReader(data))
+				Expect(err).To(MatchError("invalid value for SETTINGS_ENABLE_CONNECT_PROTOCOL: 2"))
+			})
+
+			It("writes and parses the setting", func() {
+				sf := &settingsFrame{ExtendedConnect: true}
+				buf := &bytes.Buffer{}
+				sf.Write(buf)
+				frame, err := parseNextFrame(buf)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(frame).To(Equal(sf))
+			})
+		})
+	})
+
+	Context("PRIORITY_UPDATE frames", func() {
+		It("writes and parses", func() {
+			f := &priorityUpdateFrame{StreamID: 1337, Priority: "u=1, i"}
+			buf := &bytes.Buffer{}
+			f.Write(buf)
+			Expect(buf.Bytes()[:4]).To(Equal([]byte{0x80, 0x0f, 0x07, 0x00})) // frame type
+			frame, err := parseNextFrame(buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(f))
+			Expect(buf.Len()).To(BeZero())
+		})
+
+		It("errors on EOF", func() {
+			buf := &bytes.Buffer{}
+			(&priorityUpdateFrame{StreamID: 1337, Priority: "u=1"}).Write(buf)
+			data := buf.Bytes()
+			_, err := parseNextFrame(bytes.NewReader(data))
+			Expect(err).ToNot(HaveOccurred())
+			for i := range data {
+				_, err := parseNextFrame(bytes.NewReader(data[:i]))
+				Expect(err).To(MatchError(io.EOF))
+			}
+		})
+
+		It("rejects frames that are too large", func() {
+			data := appendVarInt(nil, 0xf0700)
+			data = appendVarInt(data, 2000)
+			_, err := parseNextFrame(bytes.NewReader(data))
+			Expect(err).To(MatchError("unexpected size for PRIORITY_UPDATE frame: 2000"))
+		})
+	})
+
+	Context("GOAWAY frames", func() {
+		It("writes and parses", func() {
+			f := &goAwayFrame{ID: 1337}
+			buf := &bytes.Buffer{}
+			f.Write(buf)
+			Expect(buf.Bytes()[0]).To(BeEquivalentTo(0x7)) // frame type
+			frame, err := parseNextFrame(buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(frame).To(Equal(f))
+			Expect(buf.Len()).To(BeZero())
+		})
+
+		It("errors on EOF", func() {
+			buf := &bytes.Buffer{}
+			(&goAwayFrame{ID: 1337}).Write(buf)
+			data := buf.Bytes()
+			for i := range data {
+				_, err := parseNextFrame(bytes.NewReader(data[:i]))
+				Expect(err).To(MatchError(io.EOF))
+			}
+		})
+
+		It("rejects frames with trailing data", func() {
+			data := appendVarInt(nil, 0x7)
+			data = appendVarInt(data, 2)
+			data = append(data, 0x1, 0x2)
+			_, err := parseNextFrame(bytes.NewReader(data))
+			Expect(err).To(MatchError("GOAWAY frame has trailing data"))
+		})
+
+		It("rejects frames that are too large", func() {
+			data := appendVarInt(nil, 0x7)
+			data = appendVarInt(data, 9)
+			_, err := parseNextFrame(bytes.NewReader(data))
+			Expect(err).To(MatchError("unexpected size for GOAWAY frame: 9"))
+		})
 	})
 })
@@ -6,7 +6,10 @@ package http3
 // call gzip.NewReader on the first call to Read
 import (
 	"compress/gzip"
+	"errors"
 	"io"
+
+	"github.com/lucas-clemente/quic-go"
 )
 
 // call gzip.NewReader on the first call to Read
@@ -37,3 +40,45 @@ func (gz *gzipReader) Read(p []byte) (n int, err error) {
 func (gz *gzipReader) Close() error {
 	return gz.body.Close()
 }
+
+func (gz *gzipReader) Priority() Priority {
+	if p, ok := gz.body.(Prioritizer); ok {
+		return p.Priority()
+	}
+	return DefaultPriority
+}
+
+func (gz *gzipReader) SetPriority(p Priority) error {
+	if pr, ok := gz.body.(Prioritizer); ok {
+		return pr.SetPriority(p)
+	}
+	return errors.New("http3: the priority can't be changed")
+}
+
+func (gz *gzipReader) Session() quic.Session {
+	if h, ok := gz.body.(Hijacker); ok {
+		return h.Session()
+	}
+	return nil
+}
+
+func (gz *gzipReader) StreamID() quic.StreamID {
+	if si, ok := gz.body.(StreamInfo); ok {
+		return si.StreamID()
+	}
+	return 0
+}
+
+func (gz *gzipReader) StreamStats() quic.StreamStats {
+	if si, ok := gz.body.(StreamInfo); ok {
+		return si.StreamStats()
+	}
+	return quic.StreamStats{}
+}
+
+func (gz *gzipReader) earlyDataStatus() EarlyDataStatus {
+	if b, ok := gz.body.(interface{ earlyDataStatus() EarlyDataStatus }); ok {
+		return b.earlyDataStatus()
+	}
+	return EarlyDataNotUsed
+}
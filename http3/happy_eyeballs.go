@@ -0,0 +1,195 @@
+package http3
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lucas-clemente/quic-go"
+)
+
+const (
+	// RFC 8305 recommends a Connection Attempt Delay of 250ms.
+	defaultFallbackDelay  = 250 * time.Millisecond
+	defaultBrokenDuration = 5 * time.Minute
+)
+
+// A HappyEyeballsRoundTripper sends requests over HTTP/3 if possible, and falls back to HTTP/2 (or HTTP/1.1) over TCP otherwise.
+// Similar to Happy Eyeballs (RFC 8305), the HTTP/3 connection gets a head start of FallbackDelay.
+// If it isn't established by then, the request is sent using the Fallback RoundTripper instead,
+// while the HTTP/3 connection continues to be established in the background.
+// Requests are never sent twice: once a request was handed to one of the RoundTrippers, the outcome of that attempt is returned.
+//
+// The outcome of every connection attempt is remembered. HTTP/3 is not used for an origin for BrokenDuration
+// after a connection to it failed. If the connection attempt indicates that UDP is blocked on the network
+// (see quic.FallbackAdvisedError and quic.FirstFlightTimeoutError), HTTP/3 isn't used for any origin for BrokenDuration.
+// Enabling quic.Config.EnableBlackholeDetection or setting quic.Config.FirstFlightTimeout on the H3 RoundTripper allows detecting this quickly.
+type HappyEyeballsRoundTripper struct {
+	// H3 is the RoundTripper used for HTTP/3 requests.
+	H3 *RoundTripper
+
+	// Fallback is the RoundTripper used when HTTP/3 is not available.
+	// If nil, an http.Transport that uses HTTP/2 (and the TLSClientConfig of the H3 RoundTripper) is used.
+	Fallback http.RoundTripper
+
+	// FallbackDelay is the time the HTTP/3 connection is given to be established before falling back.
+	// Zero means that a default value of 250ms is used.
+	FallbackDelay time.Duration
+
+	// BrokenDuration is the time HTTP/3 isn't used after a failed connection attempt.
+	// Zero means that a default value of 5 minutes is used.
+	BrokenDuration time.Duration
+
+	mutex           sync.Mutex
+	brokenOrigins   map[string]time.Time // origins for which HTTP/3 isn't used until the respective time
+	udpBlockedUntil time.Time
+
+	fallbackOnce sync.Once
+	fallback     http.RoundTripper
+}
+
+var _ roundTripCloser = &HappyEyeballsRoundTripper{}
+
+// RoundTrip sends the request over HTTP/3, or using the Fallback RoundTripper.
+func (r *HappyEyeballsRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL == nil || req.URL.Scheme != "https" {
+		return r.getFallback().RoundTrip(req)
+	}
+	origin := authorityAddr("https", hostnameFromRequest(req))
+	if r.isBroken(origin) {
+		return r.getFallback().RoundTrip(req)
+	}
+
+	dialed := make(chan error, 1)
+	go func() {
+		err := r.dialH3(origin)
+		r.recordOutcome(origin, err)
+		dialed <- err
+	}()
+	timer := time.NewTimer(r.fallbackDelay())
+	defer timer.Stop()
+	select {
+	case err := <-dialed:
+		if err != nil {
+			return r.getFallback().RoundTrip(req)
+		}
+		return r.H3.RoundTrip(req)
+	case <-timer.C:
+		return r.getFallback().RoundTrip(req)
+	case <-req.Context().Done():
+		closeRequestBody(req)
+		return nil, req.Context().Err()
+	}
+}
+
+// dialH3 establishes the HTTP/3 connection to an origin, if there's none yet.
+// It returns the error that the connection attempt failed with.
+func (r *HappyEyeballsRoundTripper) dialH3(origin string) error {
+	cl, release, err := r.H3.getClient(origin, false)
+	if err != nil {
+		return err
+	}
+	defer release()
+	c, ok := cl.(*client)
+	if !ok {
+		return nil
+	}
+	// The connection attempt is not tied to a request, since later requests will use the connection.
+	// It is bounded by the handshake timeout.
+	return c.connect(context.Background())
+}
+
+func (r *HappyEyeballsRoundTripper) recordOutcome(origin string, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if err == nil {
+		delete(r.brokenOrigins, origin)
+		r.udpBlockedUntil = time.Time{}
+		return
+	}
+	// The connection was closed by the RoundTripper, this doesn't say anything about the origin.
+	if errors.Is(err, errClientClosed) {
+		return
+	}
+	until := time.Now().Add(r.brokenDuration())
+	if isUDPBlocked(err) {
+		r.udpBlockedUntil = until
+	}
+	if r.brokenOrigins == nil {
+		r.brokenOrigins = make(map[string]time.Time)
+	}
+	r.brokenOrigins[origin] = until
+}
+
+// isBroken says if HTTP/3 shouldn't be used for an origin, because a recent connection attempt failed.
+func (r *HappyEyeballsRoundTripper) isBroken(origin string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	now := time.Now()
+	if now.Before(r.udpBlockedUntil) {
+		return true
+	}
+	until, ok := r.brokenOrigins[origin]
+	if !ok {
+		return false
+	}
+	if !now.Before(until) {
+		delete(r.brokenOrigins, origin)
+		return false
+	}
+	return true
+}
+
+// isUDPBlocked says if a connection attempt failed because no packets were received from the server.
+func isUDPBlocked(err error) bool {
+	var (
+		fallbackAdvisedErr    *quic.FallbackAdvisedError
+		firstFlightTimeoutErr *quic.FirstFlightTimeoutError
+	)
+	return errors.As(err, &fallbackAdvisedErr) || errors.As(err, &firstFlightTimeoutErr)
+}
+
+func (r *HappyEyeballsRoundTripper) getFallback() http.RoundTripper {
+	r.fallbackOnce.Do(func() {
+		if r.Fallback != nil {
+			r.fallback = r.Fallback
+			return
+		}
+		var tlsConf *tls.Config
+		if r.H3.TLSClientConfig != nil {
+			tlsConf = r.H3.TLSClientConfig.Clone()
+			tlsConf.NextProtos = nil
+		}
+		r.fallback = &http.Transport{
+			Proxy:             http.ProxyFromEnvironment,
+			TLSClientConfig:   tlsConf,
+			ForceAttemptHTTP2: true,
+		}
+	})
+	return r.fallback
+}
+
+func (r *HappyEyeballsRoundTripper) fallbackDelay() time.Duration {
+	if r.FallbackDelay <= 0 {
+		return defaultFallbackDelay
+	}
+	return r.FallbackDelay
+}
+
+func (r *HappyEyeballsRoundTripper) brokenDuration() time.Duration {
+	if r.BrokenDuration <= 0 {
+		return defaultBrokenDuration
+	}
+	return r.BrokenDuration
+}
+
+// Close closes the HTTP/3 connections, and the idle connections of the Fallback RoundTripper.
+func (r *HappyEyeballsRoundTripper) Close() error {
+	if t, ok := r.getFallback().(interface{ CloseIdleConnections() }); ok {
+		t.CloseIdleConnections()
+	}
+	return r.H3.Close()
+}
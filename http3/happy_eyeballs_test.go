@@ -0,0 +1,125 @@
+package http3
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/testdata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Happy Eyeballs", func() {
+	var (
+		tcpServer *http.Server
+		h3Server  *Server
+		tcpLn     net.Listener
+		udpConn   *net.UDPConn
+		url       string
+		rt        *HappyEyeballsRoundTripper
+		numDials  int32
+	)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	})
+
+	BeforeEach(func() {
+		var err error
+		tcpLn, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		port := tcpLn.Addr().(*net.TCPAddr).Port
+		udpConn, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port})
+		Expect(err).ToNot(HaveOccurred())
+		tcpServer = &http.Server{Handler: handler, TLSConfig: testdata.GetTLSConfig()}
+		go tcpServer.ServeTLS(tcpLn, "", "")
+		h3Server = &Server{Server: &http.Server{Handler: handler, TLSConfig: testdata.GetTLSConfig()}}
+		url = fmt.Sprintf("https://localhost:%d/", port)
+
+		atomic.StoreInt32(&numDials, 0)
+		rt = &HappyEyeballsRoundTripper{
+			H3: &RoundTripper{
+				TLSClientConfig: &tls.Config{RootCAs: testdata.GetRootCA()},
+				QuicConfig:      &quic.Config{Versions: []quic.VersionNumber{quic.VersionDraft29}},
+			},
+			FallbackDelay: scaleDuration(100 * time.Millisecond),
+		}
+		rt.H3.DialContext = func(ctx context.Context, addr string, tlsConf *tls.Config, quicConf *quic.Config) (quic.EarlySession, error) {
+			atomic.AddInt32(&numDials, 1)
+			return quic.DialAddrEarlyContext(ctx, addr, tlsConf, quicConf, rt.H3.EstartAlgo, rt.H3.EcongestionAlgo)
+		}
+	})
+
+	AfterEach(func() {
+		rt.Close()
+		h3Server.Close()
+		tcpServer.Close()
+		udpConn.Close()
+	})
+
+	// get performs a GET request, and returns the protocol used
+	get := func() string {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		ExpectWithOffset(1, err).ToNot(HaveOccurred())
+		rsp, err := rt.RoundTrip(req)
+		ExpectWithOffset(1, err).ToNot(HaveOccurred())
+		defer rsp.Body.Close()
+		return rsp.Proto
+	}
+
+	It("uses HTTP/3 if possible", func() {
+		go h3Server.Serve(udpConn)
+		Expect(get()).To(Equal("HTTP/3"))
+		Expect(get()).To(Equal("HTTP/3"))
+		Expect(atomic.LoadInt32(&numDials)).To(BeEquivalentTo(1))
+	})
+
+	It("falls back to HTTP/2 and remembers that UDP is blocked", func() {
+		// nothing is served on the UDP socket, all packets are dropped
+		rt.H3.QuicConfig.FirstFlightTimeout = scaleDuration(200 * time.Millisecond)
+		Expect(get()).To(Equal("HTTP/2.0"))
+		Eventually(func() bool {
+			rt.mutex.Lock()
+			defer rt.mutex.Unlock()
+			return time.Now().Before(rt.udpBlockedUntil)
+		}).Should(BeTrue())
+		Expect(get()).To(Equal("HTTP/2.0"))
+		Expect(atomic.LoadInt32(&numDials)).To(BeEquivalentTo(1))
+	})
+
+	It("remembers failed connection attempts per origin", func() {
+		rt.BrokenDuration = scaleDuration(200 * time.Millisecond)
+		rt.H3.DialContext = func(context.Context, string, *tls.Config, *quic.Config) (quic.EarlySession, error) {
+			atomic.AddInt32(&numDials, 1)
+			return nil, errors.New("dial failed")
+		}
+		Expect(get()).To(Equal("HTTP/2.0"))
+		Expect(get()).To(Equal("HTTP/2.0"))
+		Expect(atomic.LoadInt32(&numDials)).To(BeEquivalentTo(1))
+		Expect(rt.udpBlockedUntil.IsZero()).To(BeTrue())
+		// HTTP/3 is tried again after the BrokenDuration
+		time.Sleep(rt.BrokenDuration)
+		Expect(get()).To(Equal("HTTP/2.0"))
+		Expect(atomic.LoadInt32(&numDials)).To(BeEquivalentTo(2))
+	})
+
+	It("uses HTTP/3 once the connection was established in the background", func() {
+		go h3Server.Serve(udpConn)
+		dial := rt.H3.DialContext
+		rt.H3.DialContext = func(ctx context.Context, addr string, tlsConf *tls.Config, quicConf *quic.Config) (quic.EarlySession, error) {
+			time.Sleep(2 * rt.FallbackDelay)
+			return dial(ctx, addr, tlsConf, quicConf)
+		}
+		Expect(get()).To(Equal("HTTP/2.0"))
+		Eventually(get).Should(Equal("HTTP/3"))
+		Expect(atomic.LoadInt32(&numDials)).To(BeEquivalentTo(1))
+	})
+})
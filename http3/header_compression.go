@@ -0,0 +1,79 @@
+package http3
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/qpack"
+	"github.com/lucas-clemente/quic-go/quicvarint"
+)
+
+// newQPACKDecoder creates the QPACK decoder used for all streams of a connection.
+// If maxTableCapacity is 0, the peer's encoder may only use the static table.
+func newQPACKDecoder(maxTableCapacity, maxBlockedStreams uint64) *qpack.Decoder {
+	if maxTableCapacity == 0 {
+		return qpack.NewDecoder(nil)
+	}
+	return qpack.NewDynamicDecoder(maxTableCapacity, maxBlockedStreams)
+}
+
+// encodeFieldSection encodes the fields sent on a stream.
+// If encoder is nil, or the dynamic table is not enabled, only the static table is used.
+func encodeFieldSection(encoder *qpack.DynamicEncoder, str quic.Stream, fields []qpack.HeaderField) []byte {
+	if encoder == nil || !encoder.Enabled() {
+		buf := &bytes.Buffer{}
+		enc := qpack.NewEncoder(buf)
+		for _, f := range fields {
+			enc.WriteField(f)
+		}
+		return buf.Bytes()
+	}
+	return encoder.Encode(uint64(str.StreamID()), fields)
+}
+
+// openQPACKStream opens the QPACK encoder or decoder stream.
+func openQPACKStream(sess quic.EarlySession, streamType uint64) (quic.SendStream, error) {
+	str, err := sess.OpenUniStream()
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	quicvarint.Write(buf, streamType)
+	if _, err := str.Write(buf.Bytes()); err != nil {
+		return nil, err
+	}
+	return str, nil
+}
+
+// enableQPACKEncoder enables the dynamic table of the encoder, once the peer's SETTINGS frame was received.
+// The encoder stream is only opened if both sides allow the use of the dynamic table.
+func enableQPACKEncoder(sess quic.EarlySession, encoder *qpack.DynamicEncoder, maxTableCapacity uint64, sf *settingsFrame) error {
+	if maxTableCapacity == 0 || sf.QPACKMaxTableCapacity == 0 {
+		return nil
+	}
+	str, err := openQPACKStream(sess, streamTypeQPACKEncoderStream)
+	if err != nil {
+		return err
+	}
+	encoder.SetPeerSettings(sf.QPACKMaxTableCapacity, sf.QPACKBlockedStreams, str)
+	return nil
+}
+
+// handleQPACKStream processes the peer's QPACK encoder or decoder stream.
+// Since these are critical streams, the connection is closed when processing fails.
+func handleQPACKStream(sess quic.EarlySession, str quic.ReceiveStream, streamType uint64, decoder *qpack.Decoder, encoder *qpack.DynamicEncoder) {
+	var err error
+	errCode := ErrCodeQPACKEncoderStreamError
+	if streamType == streamTypeQPACKEncoderStream {
+		err = decoder.HandleEncoderStream(str)
+	} else {
+		errCode = ErrCodeQPACKDecoderStreamError
+		err = encoder.HandleDecoderStream(str)
+	}
+	if _, ok := err.(*quic.StreamError); ok || err == io.EOF {
+		sess.CloseWithError(quic.ApplicationErrorCode(ErrCodeClosedCriticalStream), "")
+		return
+	}
+	sess.CloseWithError(quic.ApplicationErrorCode(errCode), err.Error())
+}
@@ -0,0 +1,97 @@
+package http3
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/testdata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Header compression", func() {
+	var (
+		server *Server
+		conn   *net.UDPConn
+		url    string
+	)
+
+	// Even when Huffman encoded, this value is larger than the maximum header size.
+	// It can only be sent if it is inserted into the dynamic table.
+	token := strings.Repeat("foobar", 200)
+
+	BeforeEach(func() {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Echo", r.Header.Get("X-Token"))
+			w.Write([]byte("foobar"))
+		})
+		server = &Server{
+			Server: &http.Server{
+				Handler:        mux,
+				TLSConfig:      testdata.GetTLSConfig(),
+				MaxHeaderBytes: 500,
+			},
+			QPACKMaxTableCapacity: 4096,
+			QPACKBlockedStreams:   10,
+		}
+		var err error
+		conn, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).ToNot(HaveOccurred())
+		go server.Serve(conn)
+		url = fmt.Sprintf("https://localhost:%d/echo", conn.LocalAddr().(*net.UDPAddr).Port)
+	})
+
+	AfterEach(func() {
+		server.Close()
+		conn.Close()
+	})
+
+	newRoundTripper := func(maxTableCapacity uint64) *RoundTripper {
+		return &RoundTripper{
+			TLSClientConfig:        &tls.Config{RootCAs: testdata.GetRootCA()},
+			QuicConfig:             &quic.Config{Versions: []quic.VersionNumber{quic.VersionDraft29}},
+			MaxResponseHeaderBytes: 500,
+			SettingsTimeout:        scaleDuration(time.Second),
+			QPACKMaxTableCapacity:  maxTableCapacity,
+			QPACKBlockedStreams:    10,
+		}
+	}
+
+	request := func(rt *RoundTripper) (*http.Response, error) {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		Expect(err).ToNot(HaveOccurred())
+		req.Header.Set("X-Token", token)
+		return rt.RoundTrip(req)
+	}
+
+	// Requests are only sent after the SETTINGS frame was received,
+	// since the dynamic table can't be used before.
+	It("uses the dynamic table for request and response headers", func() {
+		rt := newRoundTripper(4096)
+		defer rt.Close()
+		for i := 0; i < 5; i++ {
+			rsp, err := request(rt)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+			Expect(rsp.Header.Get("X-Echo")).To(Equal(token))
+			data, err := ioutil.ReadAll(rsp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal([]byte("foobar")))
+		}
+	})
+
+	It("only uses the static table if the client disables the dynamic table", func() {
+		rt := newRoundTripper(0)
+		defer rt.Close()
+		_, err := request(rt)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,145 @@
+package http3
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lucas-clemente/quic-go"
+)
+
+// maxPendingPriorityUpdates is the maximum number of PRIORITY_UPDATE frames that are buffered
+// for requests that haven't been received yet.
+const maxPendingPriorityUpdates = 32
+
+// Priority is the priority of a request, as defined by the Extensible Priority Scheme for HTTP (RFC 9218).
+// The server uses it to schedule the responses, the client to schedule the request bodies.
+type Priority struct {
+	// Urgency ranges from 0 (most urgent) to 7 (least urgent).
+	Urgency uint8
+	// Incremental says if the response can be processed before it was received completely.
+	// Responses of the same urgency that are incremental share the bandwidth,
+	// all other responses are sent one after the other.
+	Incremental bool
+}
+
+// DefaultPriority is the priority of requests that don't carry a priority.
+var DefaultPriority = Priority{Urgency: 3}
+
+// ParsePriority parses the value of a Priority header field.
+// Parameters that are missing or invalid take the value of the DefaultPriority, unknown parameters are ignored.
+func ParsePriority(v string) Priority {
+	p := DefaultPriority
+	for _, member := range strings.Split(v, ",") {
+		member = strings.TrimSpace(member)
+		// ignore the parameters of the dictionary member
+		if i := strings.IndexByte(member, ';'); i >= 0 {
+			member = member[:i]
+		}
+		key, value := member, ""
+		if i := strings.IndexByte(member, '='); i >= 0 {
+			key, value = member[:i], member[i+1:]
+		}
+		switch key {
+		case "u":
+			if u, err := strconv.ParseUint(value, 10, 8); err == nil && u <= 7 {
+				p.Urgency = uint8(u)
+			}
+		case "i":
+			switch value {
+			case "", "?1":
+				p.Incremental = true
+			case "?0":
+				p.Incremental = false
+			}
+		}
+	}
+	return p
+}
+
+// String returns the value of the Priority header field.
+func (p Priority) String() string {
+	s := "u=" + strconv.Itoa(int(p.Urgency))
+	if p.Incremental {
+		s += ", i"
+	}
+	return s
+}
+
+func (p Priority) streamPriority() quic.StreamPriority {
+	return quic.StreamPriority{Urgency: p.Urgency, Incremental: p.Incremental}
+}
+
+// SetRequestPriority sets the Priority header of a request.
+func SetRequestPriority(req *http.Request, p Priority) {
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	req.Header.Set("Priority", p.String())
+}
+
+// RequestPriority returns the priority of a request, as set in the Priority header.
+// If the request doesn't have a Priority header, the DefaultPriority is returned.
+// On the server side, Prioritizer.Priority also takes into account priority updates sent by the client.
+func RequestPriority(req *http.Request) Priority {
+	return ParsePriority(strings.Join(req.Header.Values("Priority"), ","))
+}
+
+// A Prioritizer allows reading and changing the priority of a request while it is in progress.
+// It is implemented by the http.ResponseWriter passed to server handlers,
+// and by the body of responses received by the client.
+// On the server side, SetPriority changes how the response is scheduled.
+// On the client side, SetPriority sends a PRIORITY_UPDATE frame to the server.
+type Prioritizer interface {
+	Priority() Priority
+	SetPriority(Priority) error
+}
+
+// priorityUpdater applies the PRIORITY_UPDATE frames that the server receives on a connection to the requests.
+type priorityUpdater struct {
+	mutex    sync.Mutex
+	requests map[quic.StreamID]Prioritizer
+	pending  map[quic.StreamID]Priority // PRIORITY_UPDATE frames received before the request
+}
+
+func newPriorityUpdater() *priorityUpdater {
+	return &priorityUpdater{
+		requests: make(map[quic.StreamID]Prioritizer),
+		pending:  make(map[quic.StreamID]Priority),
+	}
+}
+
+// addRequest adds a request.
+// If a PRIORITY_UPDATE frame was received for the request, it takes precedence over the Priority header.
+func (u *priorityUpdater) addRequest(id quic.StreamID, r Prioritizer, headerPriority Priority) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	p := headerPriority
+	if pending, ok := u.pending[id]; ok {
+		p = pending
+		delete(u.pending, id)
+	}
+	r.SetPriority(p)
+	u.requests[id] = r
+}
+
+func (u *priorityUpdater) removeRequest(id quic.StreamID) {
+	u.mutex.Lock()
+	delete(u.requests, id)
+	u.mutex.Unlock()
+}
+
+func (u *priorityUpdater) handlePriorityUpdate(id quic.StreamID, p Priority) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	if r, ok := u.requests[id]; ok {
+		r.SetPriority(p)
+		return
+	}
+	if _, ok := u.pending[id]; ok || len(u.pending) < maxPendingPriorityUpdates {
+		u.pending[id] = p
+	}
+}
@@ -0,0 +1,153 @@
+package http3
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lucas-clemente/quic-go"
+	mockquic "github.com/lucas-clemente/quic-go/internal/mocks/quic"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Priorities", func() {
+	Context("parsing", func() {
+		It("uses the default priority for empty values", func() {
+			Expect(ParsePriority("")).To(Equal(DefaultPriority))
+		})
+
+		It("parses the urgency and the incremental flag", func() {
+			Expect(ParsePriority("u=5")).To(Equal(Priority{Urgency: 5}))
+			Expect(ParsePriority("i")).To(Equal(Priority{Urgency: 3, Incremental: true}))
+			Expect(ParsePriority("u=0, i")).To(Equal(Priority{Urgency: 0, Incremental: true}))
+			Expect(ParsePriority("i=?1,u=7")).To(Equal(Priority{Urgency: 7, Incremental: true}))
+			Expect(ParsePriority("u=1, i=?0")).To(Equal(Priority{Urgency: 1}))
+		})
+
+		It("uses the last value of a parameter", func() {
+			Expect(ParsePriority("u=1, u=2")).To(Equal(Priority{Urgency: 2}))
+			Expect(ParsePriority("i, i=?0")).To(Equal(Priority{Urgency: 3}))
+		})
+
+		It("ignores invalid values, unknown keys and parameters", func() {
+			Expect(ParsePriority("u=8")).To(Equal(DefaultPriority))
+			Expect(ParsePriority("u=-1, i=foo")).To(Equal(DefaultPriority))
+			Expect(ParsePriority("u")).To(Equal(DefaultPriority))
+			Expect(ParsePriority("foo=bar, u=2;foo=bar, i")).To(Equal(Priority{Urgency: 2, Incremental: true}))
+		})
+
+		It("converts priorities to strings", func() {
+			for _, p := range []Priority{{Urgency: 0}, {Urgency: 7, Incremental: true}, DefaultPriority} {
+				Expect(ParsePriority(p.String())).To(Equal(p))
+			}
+			Expect(Priority{Urgency: 1, Incremental: true}.String()).To(Equal("u=1, i"))
+		})
+
+		It("sets and reads the priority of requests", func() {
+			req, err := http.NewRequest(http.MethodGet, "https://quic.clemente.io", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(RequestPriority(req)).To(Equal(DefaultPriority))
+			SetRequestPriority(req, Priority{Urgency: 1, Incremental: true})
+			Expect(req.Header.Get("Priority")).To(Equal("u=1, i"))
+			Expect(RequestPriority(req)).To(Equal(Priority{Urgency: 1, Incremental: true}))
+		})
+	})
+
+	Context("applying PRIORITY_UPDATE frames", func() {
+		var (
+			updater *priorityUpdater
+			str     *mockquic.MockStream
+			rw      *responseWriter
+		)
+
+		BeforeEach(func() {
+			updater = newPriorityUpdater()
+			str = mockquic.NewMockStream(mockCtrl)
+			rw = newResponseWriter(nil, str, utils.DefaultLogger)
+		})
+
+		It("uses the priority of the request", func() {
+			str.EXPECT().SetPriority(quic.StreamPriority{Urgency: 5})
+			updater.addRequest(4, rw, Priority{Urgency: 5})
+			Expect(rw.Priority()).To(Equal(Priority{Urgency: 5}))
+		})
+
+		It("applies updates to requests", func() {
+			str.EXPECT().SetPriority(quic.StreamPriority{Urgency: 3})
+			updater.addRequest(4, rw, DefaultPriority)
+			str.EXPECT().SetPriority(quic.StreamPriority{Urgency: 1, Incremental: true})
+			updater.handlePriorityUpdate(4, Priority{Urgency: 1, Incremental: true})
+			Expect(rw.Priority()).To(Equal(Priority{Urgency: 1, Incremental: true}))
+			// updates for other requests don't change the priority
+			updater.handlePriorityUpdate(8, Priority{Urgency: 7})
+			Expect(rw.Priority()).To(Equal(Priority{Urgency: 1, Incremental: true}))
+		})
+
+		It("applies updates received before the request", func() {
+			updater.handlePriorityUpdate(4, Priority{Urgency: 6})
+			str.EXPECT().SetPriority(quic.StreamPriority{Urgency: 6})
+			updater.addRequest(4, rw, Priority{Urgency: 1})
+			Expect(rw.Priority()).To(Equal(Priority{Urgency: 6}))
+		})
+
+		It("doesn't apply updates after the request was removed", func() {
+			str.EXPECT().SetPriority(gomock.Any())
+			updater.addRequest(4, rw, DefaultPriority)
+			updater.removeRequest(4)
+			updater.handlePriorityUpdate(4, Priority{Urgency: 1})
+			Expect(rw.Priority()).To(Equal(DefaultPriority))
+		})
+
+		It("limits the number of buffered updates", func() {
+			for i := 0; i < 2*maxPendingPriorityUpdates; i++ {
+				updater.handlePriorityUpdate(quic.StreamID(4*i), Priority{Urgency: 1})
+			}
+			Expect(updater.pending).To(HaveLen(maxPendingPriorityUpdates))
+			// updates for requests that already have a buffered update are still applied
+			updater.handlePriorityUpdate(0, Priority{Urgency: 2})
+			Expect(updater.pending).To(HaveKeyWithValue(quic.StreamID(0), Priority{Urgency: 2}))
+		})
+	})
+
+	Context("changing the priority of responses", func() {
+		It("sends a PRIORITY_UPDATE frame", func() {
+			str := mockquic.NewMockStream(mockCtrl)
+			rb := newResponseBody(nil, str, make(chan struct{}), nil)
+			var sent []Priority
+			rb.sendPriorityUpdate = func(p Priority) error {
+				sent = append(sent, p)
+				return nil
+			}
+			str.EXPECT().SetPriority(quic.StreamPriority{Urgency: 1, Incremental: true})
+			Expect(rb.SetPriority(Priority{Urgency: 1, Incremental: true})).To(Succeed())
+			Expect(sent).To(Equal([]Priority{{Urgency: 1, Incremental: true}}))
+			Expect(rb.Priority()).To(Equal(Priority{Urgency: 1, Incremental: true}))
+		})
+
+		It("doesn't change the priority if sending the PRIORITY_UPDATE frame fails", func() {
+			rb := newResponseBody(nil, mockquic.NewMockStream(mockCtrl), make(chan struct{}), nil)
+			rb.priority = DefaultPriority
+			rb.sendPriorityUpdate = func(Priority) error { return errors.New("test err") }
+			Expect(rb.SetPriority(Priority{Urgency: 1})).To(MatchError("test err"))
+			Expect(rb.Priority()).To(Equal(DefaultPriority))
+		})
+
+		It("doesn't allow changing the priority of request bodies", func() {
+			rb := newRequestBody(mockquic.NewMockStream(mockCtrl), nil)
+			Expect(rb.SetPriority(Priority{Urgency: 1})).To(MatchError("http3: the priority can only be changed for responses"))
+		})
+
+		It("changes the priority of gzipped responses", func() {
+			str := mockquic.NewMockStream(mockCtrl)
+			rb := newResponseBody(nil, str, make(chan struct{}), nil)
+			rb.sendPriorityUpdate = func(Priority) error { return nil }
+			gz := newGzipReader(rb).(Prioritizer)
+			str.EXPECT().SetPriority(quic.StreamPriority{Urgency: 6})
+			Expect(gz.SetPriority(Priority{Urgency: 6})).To(Succeed())
+			Expect(gz.Priority()).To(Equal(Priority{Urgency: 6}))
+		})
+	})
+})
@@ -0,0 +1,154 @@
+package http3
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// errBodyClosedEarly is reported when the primary response body is closed before it was read completely.
+var errBodyClosedEarly = errors.New("http3: response body closed before it was read completely")
+
+// A RacingRoundTripper sends every request over two connections to the same origin, and reports the timing of both responses.
+// The connections are established by two RoundTrippers, which typically use different start and congestion control algorithms
+// (see RoundTripper.EstartAlgo and RoundTripper.EcongestionAlgo).
+// This allows paired comparisons of these algorithms under identical network conditions.
+// Only the response received by the Primary RoundTripper is returned.
+// The response received by the Secondary RoundTripper is read and discarded.
+// Requests with a body are only raced if the body can be obtained again using Request.GetBody.
+// Other requests are only sent by the Primary RoundTripper, and not reported.
+type RacingRoundTripper struct {
+	Primary   *RoundTripper
+	Secondary *RoundTripper
+
+	// Report is called once both responses were received completely, or failed.
+	// Note that the time until the primary response body is received depends on how fast the caller reads it.
+	Report func(*http.Request, RaceResult)
+}
+
+// RaceResult is the timing of both responses of a raced request.
+type RaceResult struct {
+	Primary   RaceTiming
+	Secondary RaceTiming
+}
+
+// RaceTiming is the timing of one of the responses of a raced request.
+// All durations are measured from the time when the request was sent.
+type RaceTiming struct {
+	// Headers is the time until the response headers were received.
+	Headers time.Duration
+	// Body is the time until the response body was received completely.
+	Body time.Duration
+	// BodyBytes is the number of bytes of the response body that were received.
+	BodyBytes int64
+	// Err is the error that the request failed with, if any.
+	Err error
+}
+
+var _ roundTripCloser = &RacingRoundTripper{}
+
+// RoundTrip sends the request using both RoundTrippers, and returns the response received by the Primary RoundTripper.
+func (r *RacingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return r.Primary.RoundTrip(req)
+	}
+	// The secondary request must not be canceled when the caller is done with the primary response.
+	secondaryReq := req.Clone(context.Background())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			closeRequestBody(req)
+			return nil, err
+		}
+		secondaryReq.Body = body
+	}
+
+	var result RaceResult
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		wg.Wait()
+		if r.Report != nil {
+			r.Report(req, result)
+		}
+	}()
+
+	start := time.Now()
+	go func() {
+		defer wg.Done()
+		rsp, err := r.Secondary.RoundTrip(secondaryReq)
+		result.Secondary.Headers = time.Since(start)
+		if err != nil {
+			result.Secondary.Err = err
+			return
+		}
+		defer rsp.Body.Close()
+		n, err := io.Copy(ioutil.Discard, rsp.Body)
+		result.Secondary.Body = time.Since(start)
+		result.Secondary.BodyBytes = n
+		result.Secondary.Err = err
+	}()
+
+	rsp, err := r.Primary.RoundTrip(req)
+	result.Primary.Headers = time.Since(start)
+	if err != nil {
+		result.Primary.Err = err
+		wg.Done()
+		return nil, err
+	}
+	rsp.Body = &racingBody{
+		ReadCloser: rsp.Body,
+		start:      start,
+		timing:     &result.Primary,
+		done:       wg.Done,
+	}
+	return rsp, nil
+}
+
+// Close closes the QUIC connections of both RoundTrippers.
+func (r *RacingRoundTripper) Close() error {
+	err1 := r.Primary.Close()
+	err2 := r.Secondary.Close()
+	if err1 != nil {
+		return err1
+	}
+	return err2
+}
+
+// racingBody records the time when the primary response body was received completely.
+type racingBody struct {
+	io.ReadCloser
+
+	start  time.Time
+	timing *RaceTiming
+	once   sync.Once
+	done   func()
+}
+
+func (b *racingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.timing.BodyBytes += int64(n)
+	if err != nil {
+		b.finish(err)
+	}
+	return n, err
+}
+
+func (b *racingBody) Close() error {
+	b.finish(errBodyClosedEarly)
+	return b.ReadCloser.Close()
+}
+
+func (b *racingBody) finish(err error) {
+	b.once.Do(func() {
+		b.timing.Body = time.Since(b.start)
+		if err != io.EOF {
+			b.timing.Err = err
+		}
+		b.done()
+	})
+}
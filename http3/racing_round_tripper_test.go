@@ -0,0 +1,114 @@
+package http3
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type racingMockClient struct {
+	delay time.Duration
+	body  string
+	err   error
+	reqs  chan *http.Request
+}
+
+func (m *racingMockClient) RoundTrip(req *http.Request) (*http.Response, error) {
+	m.reqs <- req
+	time.Sleep(m.delay)
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &http.Response{Request: req, Body: ioutil.NopCloser(strings.NewReader(m.body))}, nil
+}
+
+func (m *racingMockClient) Close() error { return nil }
+
+var _ = Describe("Racing RoundTripper", func() {
+	var (
+		rt                 *RacingRoundTripper
+		primary, secondary *racingMockClient
+		results            chan RaceResult
+	)
+
+	BeforeEach(func() {
+		primary = &racingMockClient{body: "primary", reqs: make(chan *http.Request, 1)}
+		secondary = &racingMockClient{body: "second", reqs: make(chan *http.Request, 1)}
+		reports := make(chan RaceResult, 1)
+		results = reports
+		rt = &RacingRoundTripper{
+			Primary:   &RoundTripper{clients: map[string][]roundTripCloser{"example.com:443": {primary}}},
+			Secondary: &RoundTripper{clients: map[string][]roundTripCloser{"example.com:443": {secondary}}},
+			Report:    func(_ *http.Request, r RaceResult) { reports <- r },
+		}
+	})
+
+	It("sends the request on both connections, and returns the primary response", func() {
+		secondary.delay = scaleDuration(20 * time.Millisecond)
+		req, err := http.NewRequest(http.MethodGet, "https://example.com/foo", nil)
+		Expect(err).ToNot(HaveOccurred())
+		rsp, err := rt.RoundTrip(req)
+		Expect(err).ToNot(HaveOccurred())
+		var secondaryReq *http.Request
+		Eventually(secondary.reqs).Should(Receive(&secondaryReq))
+		Expect(secondaryReq).ToNot(BeIdenticalTo(req))
+		Expect(secondaryReq.URL.Path).To(Equal("/foo"))
+		Expect(primary.reqs).To(Receive(Equal(req)))
+		data, err := ioutil.ReadAll(rsp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("primary"))
+		var result RaceResult
+		Eventually(results).Should(Receive(&result))
+		Expect(result.Primary.BodyBytes).To(BeEquivalentTo(7))
+		Expect(result.Primary.Err).ToNot(HaveOccurred())
+		Expect(result.Secondary.BodyBytes).To(BeEquivalentTo(6))
+		Expect(result.Secondary.Err).ToNot(HaveOccurred())
+		Expect(result.Secondary.Headers).To(BeNumerically(">=", scaleDuration(20*time.Millisecond)))
+		Expect(result.Secondary.Body).To(BeNumerically(">=", result.Secondary.Headers))
+		Expect(result.Primary.Body).To(BeNumerically(">=", result.Primary.Headers))
+	})
+
+	It("reports errors", func() {
+		testErr := errors.New("test error")
+		secondary.err = testErr
+		req, err := http.NewRequest(http.MethodGet, "https://example.com/foo", nil)
+		Expect(err).ToNot(HaveOccurred())
+		rsp, err := rt.RoundTrip(req)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rsp.Body.Close()).To(Succeed())
+		var result RaceResult
+		Eventually(results).Should(Receive(&result))
+		Expect(result.Primary.Err).To(MatchError(errBodyClosedEarly))
+		Expect(result.Secondary.Err).To(MatchError(testErr))
+	})
+
+	It("replays request bodies", func() {
+		req, err := http.NewRequest(http.MethodPost, "https://example.com/foo", bytes.NewReader([]byte("foobar")))
+		Expect(err).ToNot(HaveOccurred())
+		rsp, err := rt.RoundTrip(req)
+		Expect(err).ToNot(HaveOccurred())
+		defer rsp.Body.Close()
+		var secondaryReq *http.Request
+		Eventually(secondary.reqs).Should(Receive(&secondaryReq))
+		data, err := ioutil.ReadAll(secondaryReq.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("foobar"))
+	})
+
+	It("only uses the primary connection for requests with a body that can't be replayed", func() {
+		req, err := http.NewRequest(http.MethodPost, "https://example.com/foo", &mockBody{})
+		Expect(err).ToNot(HaveOccurred())
+		rsp, err := rt.RoundTrip(req)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rsp.Body.Close()).To(Succeed())
+		Expect(primary.reqs).To(Receive())
+		Consistently(secondary.reqs).ShouldNot(Receive())
+		Expect(results).ToNot(Receive())
+	})
+})
@@ -4,15 +4,17 @@ import (
 	"crypto/tls"
 	"errors"
 	"net/http"
+	"net/textproto"
 	"net/url"
 	"strconv"
 	"strings"
 
-	"github.com/marten-seemann/qpack"
+	"github.com/lucas-clemente/quic-go/internal/qpack"
+	"golang.org/x/net/http/httpguts"
 )
 
 func requestFromHeaders(headers []qpack.HeaderField) (*http.Request, error) {
-	var path, authority, method, contentLengthStr string
+	var path, authority, method, protocol, scheme, contentLengthStr string
 	httpHeaders := http.Header{}
 
 	for _, h := range headers {
@@ -23,6 +25,10 @@ func requestFromHeaders(headers []qpack.HeaderField) (*http.Request, error) {
 			method = h.Value
 		case ":authority":
 			authority = h.Value
+		case ":protocol":
+			protocol = h.Value
+		case ":scheme":
+			scheme = h.Value
 		case "content-length":
 			contentLengthStr = h.Value
 		default:
@@ -38,7 +44,13 @@ func requestFromHeaders(headers []qpack.HeaderField) (*http.Request, error) {
 	}
 
 	isConnect := method == http.MethodConnect
-	if isConnect {
+	// Extended CONNECT, see RFC 8441 and RFC 9220
+	isExtendedConnect := isConnect && len(protocol) > 0
+	if isExtendedConnect {
+		if len(path) == 0 || len(authority) == 0 || len(scheme) == 0 {
+			return nil, errors.New("extended CONNECT: :path, :authority and :scheme must not be empty")
+		}
+	} else if isConnect {
 		if path != "" || authority == "" {
 			return nil, errors.New(":path must be empty and :authority must not be empty")
 		}
@@ -50,7 +62,15 @@ func requestFromHeaders(headers []qpack.HeaderField) (*http.Request, error) {
 	var requestURI string
 	var err error
 
-	if isConnect {
+	if isExtendedConnect {
+		u, err = url.ParseRequestURI(path)
+		if err != nil {
+			return nil, err
+		}
+		u.Scheme = scheme
+		u.Host = authority
+		requestURI = path
+	} else if isConnect {
 		u = &url.URL{Host: authority}
 		requestURI = authority
 	} else {
@@ -69,13 +89,19 @@ func requestFromHeaders(headers []qpack.HeaderField) (*http.Request, error) {
 		}
 	}
 
+	proto := "HTTP/3"
+	if isExtendedConnect {
+		proto = protocol
+	}
+
 	return &http.Request{
 		Method:        method,
 		URL:           u,
-		Proto:         "HTTP/3",
+		Proto:         proto,
 		ProtoMajor:    3,
 		ProtoMinor:    0,
 		Header:        httpHeaders,
+		Trailer:       extractTrailers(httpHeaders),
 		Body:          nil,
 		ContentLength: contentLength,
 		Host:          authority,
@@ -84,6 +110,30 @@ func requestFromHeaders(headers []qpack.HeaderField) (*http.Request, error) {
 	}, nil
 }
 
+// extractTrailers removes the Trailer header, and returns the declared trailers.
+// Following net/http conventions, the values of the declared trailers are nil until the trailers are received.
+func extractTrailers(header http.Header) http.Header {
+	vv, ok := header["Trailer"]
+	if !ok {
+		return nil
+	}
+	delete(header, "Trailer")
+	var trailer http.Header
+	for _, v := range vv {
+		for _, key := range strings.Split(v, ",") {
+			key = http.CanonicalHeaderKey(textproto.TrimString(key))
+			if key == "" || !httpguts.ValidTrailerHeader(key) {
+				continue
+			}
+			if trailer == nil {
+				trailer = http.Header{}
+			}
+			trailer[key] = nil
+		}
+	}
+	return trailer
+}
+
 func hostnameFromRequest(req *http.Request) string {
 	if req.URL != nil {
 		return req.URL.Host
@@ -4,7 +4,7 @@ import (
 	"net/http"
 	"net/url"
 
-	"github.com/marten-seemann/qpack"
+	"github.com/lucas-clemente/quic-go/internal/qpack"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -92,6 +92,33 @@ var _ = Describe("Request", func() {
 		Expect(req.RequestURI).To(Equal("quic.clemente.io"))
 	})
 
+	It("handles extended CONNECT requests", func() {
+		headers := []qpack.HeaderField{
+			{Name: ":authority", Value: "quic.clemente.io"},
+			{Name: ":method", Value: http.MethodConnect},
+			{Name: ":protocol", Value: "webtransport"},
+			{Name: ":scheme", Value: "https"},
+			{Name: ":path", Value: "/foo?bar=baz"},
+		}
+		req, err := requestFromHeaders(headers)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(req.Method).To(Equal(http.MethodConnect))
+		Expect(req.Proto).To(Equal("webtransport"))
+		Expect(req.URL.String()).To(Equal("https://quic.clemente.io/foo?bar=baz"))
+		Expect(req.RequestURI).To(Equal("/foo?bar=baz"))
+	})
+
+	It("errors with missing path in extended CONNECT requests", func() {
+		headers := []qpack.HeaderField{
+			{Name: ":authority", Value: "quic.clemente.io"},
+			{Name: ":method", Value: http.MethodConnect},
+			{Name: ":protocol", Value: "webtransport"},
+			{Name: ":scheme", Value: "https"},
+		}
+		_, err := requestFromHeaders(headers)
+		Expect(err).To(MatchError("extended CONNECT: :path, :authority and :scheme must not be empty"))
+	})
+
 	It("errors with missing path", func() {
 		headers := []qpack.HeaderField{
 			{Name: ":authority", Value: "quic.clemente.io"},
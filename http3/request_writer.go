@@ -6,13 +6,14 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/http/httptrace"
+	"sort"
 	"strconv"
 	"strings"
-	"sync"
 
 	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/qpack"
 	"github.com/lucas-clemente/quic-go/internal/utils"
-	"github.com/marten-seemann/qpack"
 	"golang.org/x/net/http/httpguts"
 	"golang.org/x/net/http2/hpack"
 	"golang.org/x/net/idna"
@@ -21,120 +22,183 @@ import (
 const bodyCopyBufferSize = 8 * 1024
 
 type requestWriter struct {
-	mutex     sync.Mutex
-	encoder   *qpack.Encoder
-	headerBuf *bytes.Buffer
+	encoder *qpack.DynamicEncoder // nil if only the static table is used
 
 	logger utils.Logger
 }
 
 func newRequestWriter(logger utils.Logger) *requestWriter {
-	headerBuf := &bytes.Buffer{}
-	encoder := qpack.NewEncoder(headerBuf)
-	return &requestWriter{
-		encoder:   encoder,
-		headerBuf: headerBuf,
-		logger:    logger,
-	}
+	return &requestWriter{logger: logger}
 }
 
-func (w *requestWriter) WriteRequest(str quic.Stream, req *http.Request, gzip bool) error {
+// WriteRequest writes the request headers, and sends the body asynchronously.
+// If continueCh is not nil, the body is only sent once it receives true (see continueSignal).
+func (w *requestWriter) WriteRequest(str quic.Stream, req *http.Request, gzip bool, continueCh <-chan bool) error {
+	trace := httptrace.ContextClientTrace(req.Context())
 	buf := &bytes.Buffer{}
-	if err := w.writeHeaders(buf, req, gzip); err != nil {
+	if err := w.writeHeaders(buf, str, req, gzip); err != nil {
+		traceWroteRequest(trace, err)
 		return err
 	}
 	if _, err := str.Write(buf.Bytes()); err != nil {
+		traceWroteRequest(trace, err)
 		return err
 	}
-	// TODO: add support for trailers
+	traceWroteHeaders(trace)
 	if req.Body == nil {
-		str.Close()
+		// The request stream of a CONNECT request stays open, it carries the tunnel,
+		// or the protocol of an extended CONNECT request.
+		if req.Method != http.MethodConnect {
+			str.Close()
+		}
+		traceWroteRequest(trace, nil)
 		return nil
 	}
 
 	// send the request body asynchronously
 	go func() {
 		defer req.Body.Close()
-		b := make([]byte, bodyCopyBufferSize)
-		for {
-			n, rerr := req.Body.Read(b)
-			if n == 0 {
-				if rerr == nil {
-					continue
-				} else if rerr == io.EOF {
-					break
-				}
-			}
-			buf := &bytes.Buffer{}
-			(&dataFrame{Length: uint64(n)}).Write(buf)
-			if _, err := str.Write(buf.Bytes()); err != nil {
-				w.logger.Errorf("Error writing request: %s", err)
-				return
-			}
-			if _, err := str.Write(b[:n]); err != nil {
-				w.logger.Errorf("Error writing request: %s", err)
-				return
-			}
-			if rerr != nil {
-				if rerr == io.EOF {
-					break
-				}
-				str.CancelWrite(quic.StreamErrorCode(errorRequestCanceled))
-				w.logger.Errorf("Error writing request: %s", rerr)
+		if continueCh != nil {
+			traceWait100Continue(trace)
+			if !<-continueCh {
+				// The server responded without asking for the body.
+				str.CancelWrite(quic.StreamErrorCode(ErrCodeNoError))
+				traceWroteRequest(trace, nil)
 				return
 			}
 		}
+		if err := w.writeBody(str, req); err != nil {
+			w.logger.Errorf("Error writing request: %s", err)
+			traceWroteRequest(trace, err)
+			return
+		}
 		str.Close()
+		traceWroteRequest(trace, nil)
 	}()
 
 	return nil
 }
 
-func (w *requestWriter) writeHeaders(wr io.Writer, req *http.Request, gzip bool) error {
-	w.mutex.Lock()
-	defer w.mutex.Unlock()
-	defer w.encoder.Close()
+// writeBody writes the request body and the trailers.
+func (w *requestWriter) writeBody(str quic.Stream, req *http.Request) error {
+	b := make([]byte, bodyCopyBufferSize)
+	for {
+		n, rerr := req.Body.Read(b)
+		if n == 0 {
+			if rerr == nil {
+				continue
+			} else if rerr == io.EOF {
+				break
+			}
+		}
+		buf := &bytes.Buffer{}
+		(&dataFrame{Length: uint64(n)}).Write(buf)
+		if _, err := str.Write(buf.Bytes()); err != nil {
+			return err
+		}
+		if _, err := str.Write(b[:n]); err != nil {
+			return err
+		}
+		if rerr != nil {
+			if rerr == io.EOF {
+				break
+			}
+			str.CancelWrite(quic.StreamErrorCode(ErrCodeRequestCanceled))
+			return rerr
+		}
+	}
+	if len(req.Trailer) > 0 {
+		if err := w.writeTrailers(str, req.Trailer); err != nil {
+			return fmt.Errorf("writing trailers failed: %w", err)
+		}
+	}
+	return nil
+}
 
-	if err := w.encodeHeaders(req, gzip, "", actualContentLength(req)); err != nil {
+// writeHeaders writes the HEADERS frame of the request sent on str to wr.
+func (w *requestWriter) writeHeaders(wr io.Writer, str quic.Stream, req *http.Request, gzip bool) error {
+	trailers, err := commaSeparatedTrailers(req)
+	if err != nil {
+		return err
+	}
+	fields, err := w.encodeHeaders(req, gzip, trailers, actualContentLength(req))
+	if err != nil {
 		return err
 	}
+	headers := encodeFieldSection(w.encoder, str, fields)
 
 	buf := &bytes.Buffer{}
-	hf := headersFrame{Length: uint64(w.headerBuf.Len())}
+	hf := headersFrame{Length: uint64(len(headers))}
 	hf.Write(buf)
 	if _, err := wr.Write(buf.Bytes()); err != nil {
 		return err
 	}
-	if _, err := wr.Write(w.headerBuf.Bytes()); err != nil {
-		return err
+	_, err = wr.Write(headers)
+	return err
+}
+
+// writeTrailers writes the trailer section, after the request body was sent.
+func (w *requestWriter) writeTrailers(str quic.Stream, trailer http.Header) error {
+	var fields []qpack.HeaderField
+	for k, vv := range trailer {
+		for _, v := range vv {
+			fields = append(fields, qpack.HeaderField{Name: strings.ToLower(k), Value: v})
+		}
 	}
-	w.headerBuf.Reset()
-	return nil
+	// All values might be empty.
+	if len(fields) == 0 {
+		return nil
+	}
+	headers := encodeFieldSection(w.encoder, str, fields)
+
+	buf := &bytes.Buffer{}
+	(&headersFrame{Length: uint64(len(headers))}).Write(buf)
+	buf.Write(headers)
+	_, err := str.Write(buf.Bytes())
+	return err
+}
+
+// copied from net/http2/transport.go
+func commaSeparatedTrailers(req *http.Request) (string, error) {
+	keys := make([]string, 0, len(req.Trailer))
+	for k := range req.Trailer {
+		k = http.CanonicalHeaderKey(k)
+		switch k {
+		case "Transfer-Encoding", "Trailer", "Content-Length":
+			return "", fmt.Errorf("invalid Trailer key %q", k)
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) > 0 {
+		sort.Strings(keys)
+		return strings.Join(keys, ","), nil
+	}
+	return "", nil
 }
 
 // copied from net/transport.go
 
-func (w *requestWriter) encodeHeaders(req *http.Request, addGzipHeader bool, trailers string, contentLength int64) error {
+func (w *requestWriter) encodeHeaders(req *http.Request, addGzipHeader bool, trailers string, contentLength int64) ([]qpack.HeaderField, error) {
 	host := req.Host
 	if host == "" {
 		host = req.URL.Host
 	}
 	host, err := httpguts.PunycodeHostPort(host)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var path string
-	if req.Method != "CONNECT" {
+	if req.Method != "CONNECT" || isExtendedConnect(req) {
 		path = req.URL.RequestURI()
 		if !validPseudoPath(path) {
 			orig := path
 			path = strings.TrimPrefix(path, req.URL.Scheme+"://"+host)
 			if !validPseudoPath(path) {
 				if req.URL.Opaque != "" {
-					return fmt.Errorf("invalid request :path %q from URL.Opaque = %q", orig, req.URL.Opaque)
+					return nil, fmt.Errorf("invalid request :path %q from URL.Opaque = %q", orig, req.URL.Opaque)
 				} else {
-					return fmt.Errorf("invalid request :path %q", orig)
+					return nil, fmt.Errorf("invalid request :path %q", orig)
 				}
 			}
 		}
@@ -145,11 +209,11 @@ func (w *requestWriter) encodeHeaders(req *http.Request, addGzipHeader bool, tra
 	// continue to reuse the hpack encoder for future requests)
 	for k, vv := range req.Header {
 		if !httpguts.ValidHeaderFieldName(k) {
-			return fmt.Errorf("invalid HTTP header name %q", k)
+			return nil, fmt.Errorf("invalid HTTP header name %q", k)
 		}
 		for _, v := range vv {
 			if !httpguts.ValidHeaderFieldValue(v) {
-				return fmt.Errorf("invalid HTTP header value %q for header %q", v, k)
+				return nil, fmt.Errorf("invalid HTTP header value %q for header %q", v, k)
 			}
 		}
 	}
@@ -162,10 +226,13 @@ func (w *requestWriter) encodeHeaders(req *http.Request, addGzipHeader bool, tra
 		// [RFC3986]).
 		f(":authority", host)
 		f(":method", req.Method)
-		if req.Method != "CONNECT" {
+		if req.Method != "CONNECT" || isExtendedConnect(req) {
 			f(":path", path)
 			f(":scheme", req.URL.Scheme)
 		}
+		if isExtendedConnect(req) {
+			f(":protocol", req.Proto)
+		}
 		if trailers != "" {
 			f("trailer", trailers)
 		}
@@ -234,15 +301,16 @@ func (w *requestWriter) encodeHeaders(req *http.Request, addGzipHeader bool, tra
 	// traceHeaders := traceHasWroteHeaderField(trace)
 
 	// Header list size is ok. Write the headers.
+	var fields []qpack.HeaderField
 	enumerateHeaders(func(name, value string) {
 		name = strings.ToLower(name)
-		w.encoder.WriteField(qpack.HeaderField{Name: name, Value: value})
+		fields = append(fields, qpack.HeaderField{Name: name, Value: value})
 		// if traceHeaders {
 		// 	traceWroteHeaderField(trace, name, value)
 		// }
 	})
 
-	return nil
+	return fields, nil
 }
 
 // authorityAddr returns a given authority (a host/IP, or host:port / ip:port)
@@ -317,3 +385,9 @@ func shouldSendReqContentLength(method string, contentLength int64) bool {
 		return false
 	}
 }
+
+// isExtendedConnect says if the request is an extended CONNECT request (see RFC 9220).
+// For extended CONNECT requests, the protocol is set in the Proto field of the request.
+func isExtendedConnect(req *http.Request) bool {
+	return req.Method == http.MethodConnect && req.Proto != "" && !strings.HasPrefix(req.Proto, "HTTP/")
+}
@@ -4,12 +4,12 @@ import (
 	"bytes"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
 
-	"github.com/marten-seemann/qpack"
-
 	"github.com/golang/mock/gomock"
 	mockquic "github.com/lucas-clemente/quic-go/internal/mocks/quic"
+	"github.com/lucas-clemente/quic-go/internal/qpack"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 
 	. "github.com/onsi/ginkgo"
@@ -60,7 +60,7 @@ var _ = Describe("Request Writer", func() {
 		str.EXPECT().Close()
 		req, err := http.NewRequest("GET", "https://quic.clemente.io/index.html?foo=bar", nil)
 		Expect(err).ToNot(HaveOccurred())
-		Expect(rw.WriteRequest(str, req, false)).To(Succeed())
+		Expect(rw.WriteRequest(str, req, false, nil)).To(Succeed())
 		headerFields := decode(strBuf)
 		Expect(headerFields).To(HaveKeyWithValue(":authority", "quic.clemente.io"))
 		Expect(headerFields).To(HaveKeyWithValue(":method", "GET"))
@@ -69,13 +69,41 @@ var _ = Describe("Request Writer", func() {
 		Expect(headerFields).ToNot(HaveKey("accept-encoding"))
 	})
 
+	It("writes an extended CONNECT request, without closing the stream", func() {
+		req, err := http.NewRequest(http.MethodConnect, "https://quic.clemente.io/webtransport", nil)
+		Expect(err).ToNot(HaveOccurred())
+		req.Proto = "webtransport"
+		Expect(rw.WriteRequest(str, req, false, nil)).To(Succeed())
+		headerFields := decode(strBuf)
+		Expect(headerFields).To(HaveKeyWithValue(":authority", "quic.clemente.io"))
+		Expect(headerFields).To(HaveKeyWithValue(":method", http.MethodConnect))
+		Expect(headerFields).To(HaveKeyWithValue(":protocol", "webtransport"))
+		Expect(headerFields).To(HaveKeyWithValue(":path", "/webtransport"))
+		Expect(headerFields).To(HaveKeyWithValue(":scheme", "https"))
+	})
+
+	It("writes a CONNECT request, without closing the stream", func() {
+		req := &http.Request{
+			Method: http.MethodConnect,
+			URL:    &url.URL{Scheme: "https", Host: "proxy.clemente.io:443"},
+			Host:   "quic.clemente.io:1337",
+			Header: http.Header{},
+		}
+		Expect(rw.WriteRequest(str, req, false, nil)).To(Succeed())
+		headerFields := decode(strBuf)
+		Expect(headerFields).To(HaveKeyWithValue(":authority", "quic.clemente.io:1337"))
+		Expect(headerFields).To(HaveKeyWithValue(":method", http.MethodConnect))
+		Expect(headerFields).ToNot(HaveKey(":path"))
+		Expect(headerFields).ToNot(HaveKey(":scheme"))
+	})
+
 	It("writes a POST request", func() {
 		closed := make(chan struct{})
 		str.EXPECT().Close().Do(func() { close(closed) })
 		postData := bytes.NewReader([]byte("foobar"))
 		req, err := http.NewRequest("POST", "https://quic.clemente.io/upload.html", postData)
 		Expect(err).ToNot(HaveOccurred())
-		Expect(rw.WriteRequest(str, req, false)).To(Succeed())
+		Expect(rw.WriteRequest(str, req, false, nil)).To(Succeed())
 
 		Eventually(closed).Should(BeClosed())
 		headerFields := decode(strBuf)
@@ -91,12 +119,38 @@ var _ = Describe("Request Writer", func() {
 		Expect(frame.(*dataFrame).Length).To(BeEquivalentTo(6))
 	})
 
+	It("writes a POST request with trailers", func() {
+		closed := make(chan struct{})
+		str.EXPECT().Close().Do(func() { close(closed) })
+		req, err := http.NewRequest("POST", "https://quic.clemente.io/upload.html", bytes.NewReader([]byte("foobar")))
+		Expect(err).ToNot(HaveOccurred())
+		req.Trailer = http.Header{"Foo": nil, "Checksum": []string{"1337"}}
+		Expect(rw.WriteRequest(str, req, false, nil)).To(Succeed())
+
+		Eventually(closed).Should(BeClosed())
+		headerFields := decode(strBuf)
+		Expect(headerFields).To(HaveKeyWithValue("trailer", "Checksum,Foo"))
+		frame, err := parseNextFrame(strBuf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(frame).To(BeAssignableToTypeOf(&dataFrame{}))
+		strBuf.Next(6)
+		Expect(decode(strBuf)).To(Equal(map[string]string{"checksum": "1337"}))
+		Expect(strBuf.Len()).To(BeZero())
+	})
+
+	It("rejects invalid trailers", func() {
+		req, err := http.NewRequest("POST", "https://quic.clemente.io/upload.html", bytes.NewReader([]byte("foobar")))
+		Expect(err).ToNot(HaveOccurred())
+		req.Trailer = http.Header{"Content-Length": nil}
+		Expect(rw.WriteRequest(str, req, false, nil)).To(MatchError(`invalid Trailer key "Content-Length"`))
+	})
+
 	It("writes a POST request, if the Body returns an EOF immediately", func() {
 		closed := make(chan struct{})
 		str.EXPECT().Close().Do(func() { close(closed) })
 		req, err := http.NewRequest("POST", "https://quic.clemente.io/upload.html", &foobarReader{})
 		Expect(err).ToNot(HaveOccurred())
-		Expect(rw.WriteRequest(str, req, false)).To(Succeed())
+		Expect(rw.WriteRequest(str, req, false, nil)).To(Succeed())
 
 		Eventually(closed).Should(BeClosed())
 		headerFields := decode(strBuf)
@@ -122,7 +176,7 @@ var _ = Describe("Request Writer", func() {
 		}
 		req.AddCookie(cookie1)
 		req.AddCookie(cookie2)
-		Expect(rw.WriteRequest(str, req, false)).To(Succeed())
+		Expect(rw.WriteRequest(str, req, false, nil)).To(Succeed())
 		headerFields := decode(strBuf)
 		Expect(headerFields).To(HaveKeyWithValue("cookie", `Cookie #1="Value #1"; Cookie #2="Value #2"`))
 	})
@@ -131,7 +185,7 @@ var _ = Describe("Request Writer", func() {
 		str.EXPECT().Close()
 		req, err := http.NewRequest("GET", "https://quic.clemente.io/", nil)
 		Expect(err).ToNot(HaveOccurred())
-		Expect(rw.WriteRequest(str, req, true)).To(Succeed())
+		Expect(rw.WriteRequest(str, req, true, nil)).To(Succeed())
 		headerFields := decode(strBuf)
 		Expect(headerFields).To(HaveKeyWithValue("accept-encoding", "gzip"))
 	})
@@ -3,13 +3,18 @@ package http3
 import (
 	"bufio"
 	"bytes"
+	"io"
 	"net/http"
+	"net/textproto"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/qpack"
 	"github.com/lucas-clemente/quic-go/internal/utils"
-	"github.com/marten-seemann/qpack"
+	"golang.org/x/net/http/httpguts"
 )
 
 // DataStreamer lets the caller take over the stream. After a call to DataStream
@@ -22,14 +27,44 @@ type DataStreamer interface {
 	DataStream() quic.Stream
 }
 
+// Hijacker gives access to the QUIC session that a request was sent on.
+// It is implemented by the http.ResponseWriter passed to server handlers,
+// and by the body of responses to extended CONNECT requests.
+// It allows protocols built on top of HTTP/3 (e.g. WebTransport) to open streams and send datagrams on the session.
+type Hijacker interface {
+	Session() quic.Session
+}
+
+// StreamInfo gives access to the ID and the statistics of the QUIC stream that a request was sent on,
+// e.g. to correlate a request with transport metrics.
+// It is implemented by the http.ResponseWriter passed to server handlers, and by the body of responses.
+// Unlike DataStreamer, it doesn't take over the stream.
+type StreamInfo interface {
+	StreamID() quic.StreamID
+	StreamStats() quic.StreamStats
+}
+
 type responseWriter struct {
-	stream         quic.Stream // needed for DataStream()
+	session        quic.Session // needed for Session()
+	stream         quic.Stream  // needed for DataStream()
 	bufferedStream *bufio.Writer
+	encoder        *qpack.DynamicEncoder // nil if only the static table is used
 
 	header         http.Header
 	status         int // status code passed to WriteHeader
 	headerWritten  bool
-	dataStreamUsed bool // set when DataSteam() is called
+	bytesWritten   int64    // number of bytes of the response body written
+	dataStreamUsed bool     // set when DataSteam() is called
+	trailers       []string // the declared trailers, in canonical form
+
+	// If set, every write is flushed to the stream until this channel is closed.
+	// Used to send responses to requests received in 0-RTT as 0.5-RTT data.
+	flushUntil <-chan struct{}
+
+	priorityMutex sync.Mutex // PRIORITY_UPDATE frames are applied from the control stream's go routine
+	priority      Priority
+
+	slo *sloEnforcer
 
 	logger utils.Logger
 }
@@ -38,13 +73,20 @@ var (
 	_ http.ResponseWriter = &responseWriter{}
 	_ http.Flusher        = &responseWriter{}
 	_ DataStreamer        = &responseWriter{}
+	_ Hijacker            = &responseWriter{}
+	_ StreamInfo          = &responseWriter{}
+	_ Prioritizer         = &responseWriter{}
+	_ SLOSetter           = &responseWriter{}
 )
 
-func newResponseWriter(stream quic.Stream, logger utils.Logger) *responseWriter {
+func newResponseWriter(sess quic.Session, stream quic.Stream, logger utils.Logger) *responseWriter {
 	return &responseWriter{
+		session:        sess,
 		header:         http.Header{},
 		stream:         stream,
 		bufferedStream: bufio.NewWriter(stream),
+		priority:       DefaultPriority,
+		slo:            newSLOEnforcer(stream, time.Now(), nil),
 		logger:         logger,
 	}
 }
@@ -57,34 +99,51 @@ func (w *responseWriter) WriteHeader(status int) {
 	if w.headerWritten {
 		return
 	}
+	// HTTP/3 doesn't support the 101 (Switching Protocols) status code, see Section 4.5 of RFC 9114.
+	// Other interim (1xx) responses, e.g. 103 (Early Hints), are sent immediately.
+	if status == http.StatusSwitchingProtocols {
+		w.logger.Errorf("Ignoring status 101 (Switching Protocols), which is not supported by HTTP/3")
+		return
+	}
 
 	if status < 100 || status >= 200 {
 		w.headerWritten = true
 	}
 	w.status = status
 
-	var headers bytes.Buffer
-	enc := qpack.NewEncoder(&headers)
-	enc.WriteField(qpack.HeaderField{Name: ":status", Value: strconv.Itoa(status)})
-
+	fields := []qpack.HeaderField{{Name: ":status", Value: strconv.Itoa(status)}}
 	for k, v := range w.header {
+		// Trailers set using the http.TrailerPrefix are sent after the body.
+		if strings.HasPrefix(k, http.TrailerPrefix) {
+			continue
+		}
 		for index := range v {
-			enc.WriteField(qpack.HeaderField{Name: strings.ToLower(k), Value: v[index]})
+			fields = append(fields, qpack.HeaderField{Name: strings.ToLower(k), Value: v[index]})
+		}
+	}
+	headers := encodeFieldSection(w.encoder, w.stream, fields)
+	if w.headerWritten {
+		for _, v := range w.header["Trailer"] {
+			for _, key := range strings.Split(v, ",") {
+				w.declareTrailer(textproto.TrimString(key))
+			}
 		}
 	}
 
 	buf := &bytes.Buffer{}
-	(&headersFrame{Length: uint64(headers.Len())}).Write(buf)
+	(&headersFrame{Length: uint64(len(headers))}).Write(buf)
 	w.logger.Infof("Responding with %d", status)
 	if _, err := w.bufferedStream.Write(buf.Bytes()); err != nil {
 		w.logger.Errorf("could not write headers frame: %s", err.Error())
 	}
-	if _, err := w.bufferedStream.Write(headers.Bytes()); err != nil {
+	if _, err := w.bufferedStream.Write(headers); err != nil {
 		w.logger.Errorf("could not write header frame payload: %s", err.Error())
 	}
 	if !w.headerWritten {
 		w.Flush()
+		return
 	}
+	w.maybeFlushEarly()
 }
 
 func (w *responseWriter) Write(p []byte) (int, error) {
@@ -100,7 +159,86 @@ func (w *responseWriter) Write(p []byte) (int, error) {
 	if _, err := w.bufferedStream.Write(buf.Bytes()); err != nil {
 		return 0, err
 	}
-	return w.bufferedStream.Write(p)
+	n, err := w.bufferedStream.Write(p)
+	w.bytesWritten += int64(n)
+	w.slo.addBytes(n)
+	if err != nil {
+		return n, err
+	}
+	w.maybeFlushEarly()
+	return n, nil
+}
+
+// sendEarly makes the response writer flush every write, until the handshake completes.
+// Bytes that are written to the stream before completion of the handshake are reported to onEarlyBytes.
+func (w *responseWriter) sendEarly(handshakeComplete <-chan struct{}, onEarlyBytes func(int)) {
+	w.flushUntil = handshakeComplete
+	w.bufferedStream = bufio.NewWriter(&earlyDataCounter{
+		Writer:            w.stream,
+		handshakeComplete: handshakeComplete,
+		onEarlyBytes:      onEarlyBytes,
+	})
+}
+
+func (w *responseWriter) maybeFlushEarly() {
+	if w.flushUntil == nil {
+		return
+	}
+	select {
+	case <-w.flushUntil:
+		w.flushUntil = nil
+	default:
+		w.Flush()
+	}
+}
+
+func (w *responseWriter) declareTrailer(key string) {
+	key = http.CanonicalHeaderKey(key)
+	if key == "" || !httpguts.ValidTrailerHeader(key) {
+		return
+	}
+	for _, k := range w.trailers {
+		if k == key {
+			return
+		}
+	}
+	w.trailers = append(w.trailers, key)
+}
+
+// writeTrailers writes the trailer section, after the handler returned.
+// Following net/http conventions, trailers are either declared in the Trailer header before
+// writing the response header, or set using the http.TrailerPrefix.
+func (w *responseWriter) writeTrailers() {
+	for k, vv := range w.header {
+		if !strings.HasPrefix(k, http.TrailerPrefix) {
+			continue
+		}
+		key := http.CanonicalHeaderKey(strings.TrimPrefix(k, http.TrailerPrefix))
+		w.declareTrailer(key)
+		w.header[key] = vv
+	}
+	if len(w.trailers) == 0 {
+		return
+	}
+
+	var fields []qpack.HeaderField
+	for _, k := range w.trailers {
+		for _, v := range w.header[k] {
+			fields = append(fields, qpack.HeaderField{Name: strings.ToLower(k), Value: v})
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+	headers := encodeFieldSection(w.encoder, w.stream, fields)
+	buf := &bytes.Buffer{}
+	(&headersFrame{Length: uint64(len(headers))}).Write(buf)
+	if _, err := w.bufferedStream.Write(buf.Bytes()); err != nil {
+		w.logger.Errorf("could not write trailers frame: %s", err.Error())
+	}
+	if _, err := w.bufferedStream.Write(headers); err != nil {
+		w.logger.Errorf("could not write trailers frame payload: %s", err.Error())
+	}
 }
 
 func (w *responseWriter) Flush() {
@@ -119,6 +257,59 @@ func (w *responseWriter) DataStream() quic.Stream {
 	return w.stream
 }
 
+func (w *responseWriter) Session() quic.Session {
+	return w.session
+}
+
+func (w *responseWriter) StreamID() quic.StreamID {
+	return w.stream.StreamID()
+}
+
+// StreamStats returns statistics about the response data sent so far.
+func (w *responseWriter) StreamStats() quic.StreamStats {
+	return w.stream.Stats()
+}
+
+func (w *responseWriter) Priority() Priority {
+	w.priorityMutex.Lock()
+	defer w.priorityMutex.Unlock()
+	return w.priority
+}
+
+// SetPriority changes the priority of the response.
+// The priority is also changed when the client sends a PRIORITY_UPDATE frame.
+func (w *responseWriter) SetPriority(p Priority) error {
+	w.priorityMutex.Lock()
+	w.priority = p
+	w.priorityMutex.Unlock()
+	w.stream.SetPriority(p.streamPriority())
+	return nil
+}
+
+// SetSLO sets the SLO for the response.
+// The duration is measured from the time the request was received, the throughput from the call to SetSLO.
+func (w *responseWriter) SetSLO(slo SLO) {
+	w.slo.setSLO(slo)
+}
+
+// earlyDataCounter counts the bytes written before completion of the handshake.
+type earlyDataCounter struct {
+	io.Writer
+
+	handshakeComplete <-chan struct{}
+	onEarlyBytes      func(int)
+}
+
+func (c *earlyDataCounter) Write(p []byte) (int, error) {
+	n, err := c.Writer.Write(p)
+	select {
+	case <-c.handshakeComplete:
+	default:
+		c.onEarlyBytes(n)
+	}
+	return n, err
+}
+
 // copied from http2/http2.go
 // bodyAllowedForStatus reports whether a given response status code
 // permits a body. See RFC 2616, section 4.4.
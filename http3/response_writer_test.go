@@ -5,11 +5,12 @@ import (
 	"io"
 	"net/http"
 
+	"github.com/lucas-clemente/quic-go"
 	mockquic "github.com/lucas-clemente/quic-go/internal/mocks/quic"
+	"github.com/lucas-clemente/quic-go/internal/qpack"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 
 	"github.com/golang/mock/gomock"
-	"github.com/marten-seemann/qpack"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -25,7 +26,7 @@ var _ = Describe("Response Writer", func() {
 		strBuf = &bytes.Buffer{}
 		str := mockquic.NewMockStream(mockCtrl)
 		str.EXPECT().Write(gomock.Any()).DoAndReturn(strBuf.Write).AnyTimes()
-		rw = newResponseWriter(str, utils.DefaultLogger)
+		rw = newResponseWriter(nil, str, utils.DefaultLogger)
 	})
 
 	decodeHeader := func(str io.Reader) map[string][]string {
@@ -117,6 +118,37 @@ var _ = Describe("Response Writer", func() {
 		Expect(fields).To(HaveKeyWithValue(":status", []string{"200"}))
 	})
 
+	It("writes declared trailers", func() {
+		rw.Header().Set("Trailer", "Foo, Bar")
+		rw.Header().Add("Trailer", "Baz")
+		_, err := rw.Write([]byte("foobar"))
+		Expect(err).ToNot(HaveOccurred())
+		rw.Header().Set("Foo", "foo")
+		rw.Header().Set("Baz", "baz")
+		rw.writeTrailers()
+		fields := decodeHeader(strBuf)
+		Expect(fields).To(HaveKeyWithValue("trailer", []string{"Foo, Bar", "Baz"}))
+		Expect(getData(strBuf)).To(Equal([]byte("foobar")))
+		Expect(decodeHeader(strBuf)).To(Equal(map[string][]string{"foo": {"foo"}, "baz": {"baz"}}))
+	})
+
+	It("writes trailers set using the TrailerPrefix", func() {
+		rw.WriteHeader(http.StatusOK)
+		rw.Header().Set(http.TrailerPrefix+"Foo", "bar")
+		rw.writeTrailers()
+		fields := decodeHeader(strBuf)
+		Expect(fields).To(Equal(map[string][]string{":status": {"200"}}))
+		Expect(decodeHeader(strBuf)).To(Equal(map[string][]string{"foo": {"bar"}}))
+	})
+
+	It("doesn't write trailers if no trailers are set", func() {
+		rw.Header().Set("Trailer", "Foo")
+		rw.WriteHeader(http.StatusOK)
+		rw.writeTrailers()
+		decodeHeader(strBuf)
+		Expect(strBuf.Len()).To(BeZero())
+	})
+
 	It("allows calling WriteHeader() several times when using the 103 status code", func() {
 		rw.Header().Add("Link", "</style.css>; rel=preload; as=style")
 		rw.Header().Add("Link", "</script.js>; rel=preload; as=script")
@@ -141,10 +173,49 @@ var _ = Describe("Response Writer", func() {
 		Expect(getData(strBuf)).To(Equal([]byte("foobar")))
 	})
 
+	It("ignores the 101 status code", func() {
+		rw.WriteHeader(http.StatusSwitchingProtocols)
+		Expect(strBuf.Len()).To(BeZero())
+		rw.WriteHeader(http.StatusOK)
+		fields := decodeHeader(strBuf)
+		Expect(fields).To(HaveKeyWithValue(":status", []string{"200"}))
+	})
+
 	It("doesn't allow writes if the status code doesn't allow a body", func() {
 		rw.WriteHeader(304)
 		n, err := rw.Write([]byte("foobar"))
 		Expect(n).To(BeZero())
 		Expect(err).To(MatchError(http.ErrBodyNotAllowed))
 	})
+
+	It("exposes the stream ID and statistics", func() {
+		str := mockquic.NewMockStream(mockCtrl)
+		str.EXPECT().StreamID().Return(quic.StreamID(4))
+		str.EXPECT().Stats().Return(quic.StreamStats{BytesSent: 1337})
+		rw = newResponseWriter(nil, str, utils.DefaultLogger)
+		var si StreamInfo = rw
+		Expect(si.StreamID()).To(Equal(quic.StreamID(4)))
+		Expect(si.StreamStats().BytesSent).To(BeEquivalentTo(1337))
+	})
+
+	It("flushes writes immediately before the handshake completes", func() {
+		handshakeComplete := make(chan struct{})
+		var earlyBytes int
+		rw.sendEarly(handshakeComplete, func(n int) { earlyBytes += n })
+		rw.WriteHeader(http.StatusOK)
+		Expect(strBuf.Len()).ToNot(BeZero())
+		n, err := rw.Write([]byte("foo"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(n).To(Equal(3))
+		Expect(earlyBytes).To(Equal(strBuf.Len()))
+		close(handshakeComplete)
+		// after completion of the handshake, writes are buffered again
+		l := strBuf.Len()
+		_, err = rw.Write([]byte("bar"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(strBuf.Len()).To(Equal(l))
+		rw.Flush()
+		Expect(strBuf.Len()).To(BeNumerically(">", l))
+		Expect(earlyBytes).To(Equal(l))
+	})
 })
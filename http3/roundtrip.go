@@ -1,6 +1,7 @@
 package http3
 
 import (
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
@@ -8,6 +9,7 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"time"
 
 	quic "github.com/lucas-clemente/quic-go"
 	"github.com/lucas-clemente/quic-go/internal/utils"
@@ -52,12 +54,97 @@ type RoundTripper struct {
 	// If Dial is nil, quic.DialAddrEarly will be used.
 	Dial func(network, addr string, tlsCfg *tls.Config, cfg *quic.Config, startAlgo utils.StartAlgo, congestionAlgo utils.CongestionAlgo) (quic.EarlySession, error)
 
+	// DialContext specifies an optional dial function for creating QUIC connections for requests.
+	// It allows controlling name resolution, the reuse of UDP sockets (see quic.DialEarlyContext)
+	// and the selection of the source address. It takes precedence over Dial.
+	// The context is not the context of a request, since the connection might be used by other requests.
+	// It is canceled when the connection is closed before dialing finished, e.g. by Close.
+	// The dial function is responsible for choosing the congestion control algorithms.
+	DialContext func(ctx context.Context, addr string, tlsCfg *tls.Config, cfg *quic.Config) (quic.EarlySession, error)
+
 	// MaxResponseHeaderBytes specifies a limit on how many response bytes are
 	// allowed in the server's response header.
 	// Zero means to use a default limit.
 	MaxResponseHeaderBytes int64
 
-	clients map[string]roundTripCloser
+	// SettingsTimeout is the maximum duration to wait for the server's SETTINGS frame after the handshake completed.
+	// If set, requests are only sent once the SETTINGS frame was received.
+	// If the SETTINGS frame isn't received in time, the connection is closed,
+	// and requests fail with a SettingsTimeoutError.
+	// Zero means that requests don't wait for the SETTINGS frame.
+	// Handshake timeouts are configured in the QuicConfig.
+	SettingsTimeout time.Duration
+
+	// EnableStandby enables a warm standby connection to every origin.
+	// The standby connection is established together with the first connection to the origin, and kept alive.
+	// When a request fails because its connection failed, the standby connection becomes the active connection,
+	// and a new standby connection is established.
+	// The request is retried on the new active connection, if it is idempotent and its body can be replayed (see http.Request.GetBody).
+	// Requests whose response headers were already received are not retried.
+	EnableStandby bool
+
+	// StandbyDial specifies an optional dial function for creating the standby connections, e.g. over another network interface.
+	// If StandbyDial is nil, DialContext or Dial will be used.
+	StandbyDial func(network, addr string, tlsCfg *tls.Config, cfg *quic.Config, startAlgo utils.StartAlgo, congestionAlgo utils.CongestionAlgo) (quic.EarlySession, error)
+
+	// MaxConnsPerHost is the maximum number of connections to an origin.
+	// Requests are sent on the connection with the fewest active requests. If all connections have active requests,
+	// a new connection is opened, as long as there are fewer than MaxConnsPerHost connections.
+	// This allows aggregating the bandwidth of multiple connections.
+	// Zero means that a single connection is used.
+	MaxConnsPerHost int
+
+	// IdleConnTimeout is the maximum amount of time a connection without any active requests is kept open.
+	// Unlike quic.Config.MaxIdleTimeout, which closes connections that don't receive any packets,
+	// this also closes connections that are kept alive.
+	// Requests are active until their response body was read completely, or closed.
+	// Zero means no limit.
+	IdleConnTimeout time.Duration
+
+	// ExpectContinueTimeout, if non-zero, specifies the amount of time to wait for the server's first response headers
+	// after fully writing the request headers, if the request has an "Expect: 100-continue" header.
+	// The body is sent once the server responds with 100 (Continue), or when the timeout expires.
+	// If the server sends a final response first, the body is not sent.
+	// Zero means no timeout and causes the body to be sent immediately, without waiting for the server to approve.
+	ExpectContinueTimeout time.Duration
+
+	// AdditionalSettings are sent in the SETTINGS frame, in addition to the settings used by the client itself.
+	AdditionalSettings map[uint64]uint64
+
+	// QPACKMaxTableCapacity is the maximum capacity of the QPACK dynamic table, in bytes.
+	// It limits the dynamic table used to compress request headers as well as the one used by the server to compress responses.
+	// Zero means that the dynamic table is not used, and headers are only compressed using the static table.
+	QPACKMaxTableCapacity uint64
+
+	// QPACKBlockedStreams is the maximum number of request streams that may wait for
+	// entries of the dynamic table to be received.
+	// It is only used if QPACKMaxTableCapacity is set.
+	QPACKBlockedStreams uint64
+
+	// StreamHijacker, if set, is called for bidirectional streams opened by the server.
+	// It is called right after the frame type of the first frame was read.
+	// If it returns true, the stream is taken over. Otherwise, the connection is closed,
+	// since HTTP/3 servers must not open bidirectional streams.
+	// Setting StreamHijacker allows the server to open bidirectional streams.
+	StreamHijacker func(FrameType, quic.Session, quic.Stream) (hijacked bool)
+
+	// UniStreamHijacker, if set, is called for unidirectional streams of an unknown stream type.
+	// It is called right after the stream type was read. If it returns true, the stream is taken over.
+	// Otherwise, the stream is rejected.
+	UniStreamHijacker func(StreamType, quic.Session, quic.ReceiveStream) (hijacked bool)
+
+	// Allow0RTT decides if a request may be sent in 0-RTT, when resuming a session using a session ticket
+	// (see tls.Config.ClientSessionCache).
+	// 0-RTT data can be replayed by an attacker, so only requests that are safe to replay should be sent in 0-RTT.
+	// SafeMethodPolicy implements the policy recommended by RFC 8470.
+	// Requests can also opt in individually, see With0RTT.
+	// If nil, only requests that opted in are sent in 0-RTT.
+	// If the server rejects 0-RTT, or responds with 425 (Too Early), requests that can be replayed
+	// are sent again after the handshake completed. ResponseEarlyData tells what happened to a request.
+	Allow0RTT func(*http.Request) bool
+
+	clients  map[string][]roundTripCloser
+	standbys map[string]roundTripCloser
 	
 	// congestion algorithms, 'E' allows to Export attribute  
 	EstartAlgo utils.StartAlgo
@@ -116,11 +203,16 @@ func (r *RoundTripper) RoundTripOpt(req *http.Request, opt RoundTripOpt) (*http.
 	}
 
 	hostname := authorityAddr("https", hostnameFromRequest(req))
-	cl, err := r.getClient(hostname, opt.OnlyCachedConn)
+	cl, release, err := r.getClient(hostname, opt.OnlyCachedConn)
 	if err != nil {
 		return nil, err
 	}
-	return cl.RoundTrip(req)
+	rsp, err := cl.RoundTrip(req)
+	release()
+	if err != nil && r.EnableStandby && isConnectionError(err) {
+		return r.failover(hostname, cl, req, opt, err)
+	}
+	return rsp, err
 }
 
 // RoundTrip does a round trip.
@@ -128,51 +220,63 @@ func (r *RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 	return r.RoundTripOpt(req, RoundTripOpt{})
 }
 
-func (r *RoundTripper) getClient(hostname string, onlyCached bool) (http.RoundTripper, error) {
+// getClient returns the client that a request is sent on, see pickClient.
+// The release function must be called once RoundTrip returned.
+func (r *RoundTripper) getClient(hostname string, onlyCached bool) (http.RoundTripper, func(), error) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
 	if r.clients == nil {
-		r.clients = make(map[string]roundTripCloser)
+		r.clients = make(map[string][]roundTripCloser)
 	}
-
-	client, ok := r.clients[hostname]
-	if !ok {
-		if onlyCached {
-			return nil, ErrNoCachedConn
-		}
-		var err error
-		client, err = newClient(
-			hostname,
-			r.TLSClientConfig,
-			&roundTripperOpts{
-				EnableDatagram:     r.EnableDatagrams,
-				DisableCompression: r.DisableCompression,
-				MaxHeaderBytes:     r.MaxResponseHeaderBytes,
-			},
-			r.QuicConfig,
-			r.Dial,
-			r.EstartAlgo,
-			r.EcongestionAlgo,
-		)
-		if err != nil {
-			return nil, err
+	client, release, err := r.pickClient(hostname, onlyCached)
+	if err != nil {
+		return nil, nil, err
+	}
+	if r.EnableStandby {
+		if err := r.addStandby(hostname); err != nil {
+			release()
+			return nil, nil, err
 		}
-		r.clients[hostname] = client
 	}
-	return client, nil
+	return client, release, nil
+}
+
+func (r *RoundTripper) roundTripperOpts() *roundTripperOpts {
+	return &roundTripperOpts{
+		EnableDatagram:        r.EnableDatagrams,
+		DisableCompression:    r.DisableCompression,
+		MaxHeaderBytes:        r.MaxResponseHeaderBytes,
+		SettingsTimeout:       r.SettingsTimeout,
+		IdleConnTimeout:       r.IdleConnTimeout,
+		ExpectContinueTimeout: r.ExpectContinueTimeout,
+		AdditionalSettings:    r.AdditionalSettings,
+		QPACKMaxTableCapacity: r.QPACKMaxTableCapacity,
+		QPACKBlockedStreams:   r.QPACKBlockedStreams,
+		StreamHijacker:        r.StreamHijacker,
+		UniStreamHijacker:     r.UniStreamHijacker,
+		Allow0RTT:             r.Allow0RTT,
+	}
 }
 
 // Close closes the QUIC connections that this RoundTripper has used
 func (r *RoundTripper) Close() error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
-	for _, client := range r.clients {
+	for _, clients := range r.clients {
+		for _, client := range clients {
+			if err := client.Close(); err != nil {
+				return err
+			}
+		}
+	}
+	r.clients = nil
+	for _, client := range r.standbys {
 		if err := client.Close(); err != nil {
 			return err
 		}
 	}
-	r.clients = nil
+	r.standbys = nil
 	return nil
 }
 
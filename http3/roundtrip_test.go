@@ -6,12 +6,14 @@ import (
 	"crypto/tls"
 	"errors"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/lucas-clemente/quic-go"
 	mockquic "github.com/lucas-clemente/quic-go/internal/mocks/quic"
+	"github.com/lucas-clemente/quic-go/internal/utils"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -31,6 +33,31 @@ func (m *mockClient) Close() error {
 
 var _ roundTripCloser = &mockClient{}
 
+type failoverMockClient struct {
+	err    error
+	bodies chan []byte
+	closed bool
+}
+
+func (m *failoverMockClient) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		m.bodies <- body
+	}
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &http.Response{Request: req}, nil
+}
+
+func (m *failoverMockClient) Close() error {
+	m.closed = true
+	return nil
+}
+
 type mockBody struct {
 	reader   bytes.Reader
 	readErr  error
@@ -82,7 +109,7 @@ var _ = Describe("RoundTripper", func() {
 		BeforeEach(func() {
 			session = mockquic.NewMockEarlySession(mockCtrl)
 			origDialAddr = dialAddr
-			dialAddr = func(addr string, tlsConf *tls.Config, config *quic.Config) (quic.EarlySession, error) {
+			dialAddr = func(addr string, tlsConf *tls.Config, config *quic.Config, _ utils.StartAlgo, _ utils.CongestionAlgo) (quic.EarlySession, error) {
 				// return an error when trying to open a stream
 				// we don't want to test all the dial logic here, just that dialing happens at all
 				return session, nil
@@ -115,7 +142,7 @@ var _ = Describe("RoundTripper", func() {
 		It("uses the quic.Config, if provided", func() {
 			config := &quic.Config{HandshakeIdleTimeout: time.Millisecond}
 			var receivedConfig *quic.Config
-			dialAddr = func(addr string, tlsConf *tls.Config, config *quic.Config) (quic.EarlySession, error) {
+			dialAddr = func(addr string, tlsConf *tls.Config, config *quic.Config, _ utils.StartAlgo, _ utils.CongestionAlgo) (quic.EarlySession, error) {
 				receivedConfig = config
 				return nil, errors.New("handshake error")
 			}
@@ -127,7 +154,7 @@ var _ = Describe("RoundTripper", func() {
 
 		It("uses the custom dialer, if provided", func() {
 			var dialed bool
-			dialer := func(_, _ string, tlsCfgP *tls.Config, cfg *quic.Config) (quic.EarlySession, error) {
+			dialer := func(_, _ string, tlsCfgP *tls.Config, cfg *quic.Config, _ utils.StartAlgo, _ utils.CongestionAlgo) (quic.EarlySession, error) {
 				dialed = true
 				return nil, errors.New("handshake error")
 			}
@@ -141,7 +168,7 @@ var _ = Describe("RoundTripper", func() {
 			closed := make(chan struct{})
 			testErr := errors.New("test err")
 			session.EXPECT().OpenUniStream().AnyTimes().Return(nil, testErr)
-			session.EXPECT().HandshakeComplete().Return(handshakeCtx).Times(2)
+			session.EXPECT().HandshakeComplete().Return(handshakeCtx)
 			session.EXPECT().OpenStreamSync(context.Background()).Return(nil, testErr).Times(2)
 			session.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
 				<-closed
@@ -240,11 +267,121 @@ var _ = Describe("RoundTripper", func() {
 		})
 	})
 
+	Context("failing over to the standby connection", func() {
+		var (
+			active, standby *failoverMockClient
+			standbyDials    chan *quic.Config
+		)
+
+		BeforeEach(func() {
+			active = &failoverMockClient{err: &quic.IdleTimeoutError{}, bodies: make(chan []byte, 1)}
+			standby = &failoverMockClient{bodies: make(chan []byte, 1)}
+			standbyDials = make(chan *quic.Config, 1)
+			dials := standbyDials
+			rt.EnableStandby = true
+			rt.StandbyDial = func(_, _ string, _ *tls.Config, cfg *quic.Config, _ utils.StartAlgo, _ utils.CongestionAlgo) (quic.EarlySession, error) {
+				dials <- cfg
+				return nil, errors.New("standby dial error")
+			}
+			rt.clients = map[string][]roundTripCloser{"www.example.org:443": {active}}
+			rt.standbys = map[string]roundTripCloser{"www.example.org:443": standby}
+		})
+
+		It("promotes the standby connection and retries idempotent requests", func() {
+			rsp, err := rt.RoundTrip(req1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rsp.Request.URL).To(Equal(req1.URL))
+			Expect(active.closed).To(BeTrue())
+			Expect(rt.clients["www.example.org:443"]).To(Equal([]roundTripCloser{standby}))
+			// a new standby connection is established
+			Expect(rt.standbys).To(HaveKey("www.example.org:443"))
+			Expect(rt.standbys["www.example.org:443"]).ToNot(BeIdenticalTo(standby))
+			var cfg *quic.Config
+			Eventually(standbyDials).Should(Receive(&cfg))
+			Expect(cfg.KeepAlive).To(BeTrue())
+		})
+
+		It("replays the body of requests with an idempotency key", func() {
+			req, err := http.NewRequest(http.MethodPost, "https://www.example.org/upload", bytes.NewReader([]byte("foobar")))
+			Expect(err).ToNot(HaveOccurred())
+			req.Header.Set("Idempotency-Key", "42")
+			_, err = rt.RoundTrip(req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(active.bodies).To(Receive(Equal([]byte("foobar"))))
+			Expect(standby.bodies).To(Receive(Equal([]byte("foobar"))))
+		})
+
+		It("doesn't retry non-idempotent requests", func() {
+			req, err := http.NewRequest(http.MethodPost, "https://www.example.org/upload", bytes.NewReader([]byte("foobar")))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = rt.RoundTrip(req)
+			Expect(err).To(MatchError(&quic.IdleTimeoutError{}))
+			Expect(standby.bodies).ToNot(Receive())
+			// the next request uses the standby connection
+			Expect(rt.clients["www.example.org:443"]).To(Equal([]roundTripCloser{standby}))
+		})
+
+		It("doesn't retry requests with a body that can't be replayed", func() {
+			req, err := http.NewRequest(http.MethodGet, "https://www.example.org/file1.html", &mockBody{})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = rt.RoundTrip(req)
+			Expect(err).To(MatchError(&quic.IdleTimeoutError{}))
+			Expect(standby.bodies).ToNot(Receive())
+		})
+
+		It("doesn't fail over if the request failed for other reasons", func() {
+			testErr := errors.New("test error")
+			active.err = testErr
+			_, err := rt.RoundTrip(req1)
+			Expect(err).To(MatchError(testErr))
+			Expect(active.closed).To(BeFalse())
+			Expect(rt.clients["www.example.org:443"]).To(Equal([]roundTripCloser{active}))
+			Expect(rt.standbys["www.example.org:443"]).To(BeIdenticalTo(standby))
+		})
+
+		It("doesn't fail over if disabled", func() {
+			rt.EnableStandby = false
+			_, err := rt.RoundTrip(req1)
+			Expect(err).To(MatchError(&quic.IdleTimeoutError{}))
+			Expect(rt.clients["www.example.org:443"]).To(Equal([]roundTripCloser{active}))
+		})
+
+		It("only fails over once if multiple requests fail", func() {
+			rt.clients["www.example.org:443"] = []roundTripCloser{standby}
+			rt.standbys["www.example.org:443"] = active
+			// active is not the active client any more, so failing over doesn't replace the standby
+			_, err := rt.failover("www.example.org:443", active, req1, RoundTripOpt{}, &quic.IdleTimeoutError{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rt.clients["www.example.org:443"]).To(Equal([]roundTripCloser{standby}))
+			Expect(rt.standbys["www.example.org:443"]).To(BeIdenticalTo(active))
+			Expect(standbyDials).ToNot(Receive())
+		})
+
+		It("dials the standby connection when creating a new client", func() {
+			rt.clients = nil
+			rt.standbys = nil
+			rt.Dial = func(_, _ string, _ *tls.Config, _ *quic.Config, _ utils.StartAlgo, _ utils.CongestionAlgo) (quic.EarlySession, error) {
+				return nil, errors.New("handshake error")
+			}
+			_, err := rt.RoundTrip(req1)
+			Expect(err).To(MatchError("handshake error"))
+			Expect(rt.standbys).To(HaveKey("www.example.org:443"))
+			Eventually(standbyDials).Should(Receive())
+		})
+
+		It("closes the standby connections", func() {
+			Expect(rt.Close()).To(Succeed())
+			Expect(active.closed).To(BeTrue())
+			Expect(standby.closed).To(BeTrue())
+			Expect(rt.standbys).To(BeEmpty())
+		})
+	})
+
 	Context("closing", func() {
 		It("closes", func() {
-			rt.clients = make(map[string]roundTripCloser)
+			rt.clients = make(map[string][]roundTripCloser)
 			cl := &mockClient{}
-			rt.clients["foo.bar"] = cl
+			rt.clients["foo.bar"] = []roundTripCloser{cl}
 			err := rt.Close()
 			Expect(err).ToNot(HaveOccurred())
 			Expect(len(rt.clients)).To(BeZero())
@@ -18,9 +18,9 @@ import (
 	"github.com/lucas-clemente/quic-go"
 	"github.com/lucas-clemente/quic-go/internal/handshake"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/qpack"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/lucas-clemente/quic-go/quicvarint"
-	"github.com/marten-seemann/qpack"
 )
 
 // allows mocking of quic.Listen and quic.ListenAddr
@@ -34,6 +34,9 @@ const (
 	nextProtoH3        = "h3"
 )
 
+// StreamType is the stream type of a unidirectional stream.
+type StreamType uint64
+
 const (
 	streamTypeControlStream      = 0
 	streamTypePushStream         = 1
@@ -65,20 +68,42 @@ func (k *contextKey) String() string { return "quic-go/http3 context value " + k
 // type *http3.Server.
 var ServerContextKey = &contextKey{"http3-server"}
 
+// EarlyResponseStats contains statistics about responses sent before completion of the handshake.
+type EarlyResponseStats struct {
+	// Requests is the number of requests that were received before completion of the handshake.
+	Requests uint64
+	// Bytes is the number of response bytes (including the HTTP/3 framing)
+	// that were written to the stream before completion of the handshake.
+	Bytes uint64
+}
+
 type requestError struct {
 	err       error
-	streamErr errorCode
-	connErr   errorCode
+	streamErr ErrCode
+	connErr   ErrCode
 }
 
-func newStreamError(code errorCode, err error) requestError {
+func newStreamError(code ErrCode, err error) requestError {
 	return requestError{err: err, streamErr: code}
 }
 
-func newConnError(code errorCode, err error) requestError {
+func newConnError(code ErrCode, err error) requestError {
 	return requestError{err: err, connErr: code}
 }
 
+// A Route is the configuration used for the connections to a server name.
+type Route struct {
+	// TLSConfig is used for the TLS handshake.
+	// If nil, the tls.Config of the Server is used.
+	TLSConfig *tls.Config
+	// QuicConfig is used for the QUIC connection.
+	// If nil, the QuicConfig of the Server is used.
+	QuicConfig *quic.Config
+	// Handler handles the requests.
+	// If nil, the Handler of the Server is used.
+	Handler http.Handler
+}
+
 // Server is a HTTP/3 server.
 type Server struct {
 	*http.Server
@@ -92,6 +117,56 @@ type Server struct {
 	// See https://www.ietf.org/archive/id/draft-schinazi-masque-h3-datagram-02.html.
 	EnableDatagrams bool
 
+	// FlushEarlyResponses makes the server flush responses to requests that were received
+	// before completion of the handshake (i.e. in 0-RTT) immediately after every write.
+	// These responses are then sent as 0.5-RTT data, as early as the protocol permits,
+	// instead of waiting for the response buffer to fill up.
+	// The amount of data sent that way can be obtained using EarlyResponseStats.
+	FlushEarlyResponses bool
+
+	// Routes selects the configuration of a connection based on the server name sent by the client (SNI).
+	// A server name starting with "*." is a wildcard that matches all server names with one additional label.
+	// Connections for server names without a route use the tls.Config, the QuicConfig and the Handler of the Server.
+	// Routes must not be modified after the Server was started.
+	Routes map[string]*Route
+
+	// TraceRequest is called for every request before it is handled.
+	// The context is the context of the QUIC session.
+	// It can be used to enable detailed tracing for selected requests, see qlog.StreamScope.SelectRequest.
+	TraceRequest func(sessCtx context.Context, id quic.StreamID, r *http.Request)
+
+	// OnSLOViolation is called when a response doesn't meet the SLO that the handler set using the SLOSetter.
+	// When it is called, the request stream was already reset.
+	OnSLOViolation func(r *http.Request, v SLOViolation)
+
+	// AccessLog is called for every request after the response was written, see AccessLogEntry.
+	// It is called from the go routine that handled the request, and should not block.
+	AccessLog func(AccessLogEntry)
+
+	// AdditionalSettings are sent in the SETTINGS frame, in addition to the settings used by the server itself.
+	AdditionalSettings map[uint64]uint64
+
+	// QPACKMaxTableCapacity is the maximum capacity of the QPACK dynamic table, in bytes.
+	// It limits the dynamic table used to compress the client's headers as well as the one used to compress responses.
+	// Zero means that the dynamic table is not used, and headers are only compressed using the static table.
+	QPACKMaxTableCapacity uint64
+
+	// QPACKBlockedStreams is the maximum number of request streams that may wait for
+	// entries of the dynamic table to be received.
+	// It is only used if QPACKMaxTableCapacity is set.
+	QPACKBlockedStreams uint64
+
+	// StreamHijacker, if set, is called for bidirectional streams that start with a frame of an unknown type.
+	// It is called right after the frame type was read. If it returns true, the stream is taken over,
+	// and the server doesn't read from or write to the stream anymore.
+	// This is used by extensions that define their own stream types, e.g. WebTransport.
+	StreamHijacker func(FrameType, quic.Session, quic.Stream) (hijacked bool)
+
+	// UniStreamHijacker, if set, is called for unidirectional streams of an unknown stream type.
+	// It is called right after the stream type was read. If it returns true, the stream is taken over.
+	// Otherwise, the stream is rejected.
+	UniStreamHijacker func(StreamType, quic.Session, quic.ReceiveStream) (hijacked bool)
+
 	// The port to use in Alt-Svc response headers.
 	// If needed Port can be manually set when the Server is created.
 	// This is useful when a Layer 4 firewall is redirecting UDP traffic and clients must use
@@ -100,11 +175,19 @@ type Server struct {
 
 	mutex     sync.Mutex
 	listeners map[*quic.EarlyListener]struct{}
-	closed    utils.AtomicBool
+	conns     map[*serverConn]struct{}
+	// only set if Routes is set
+	router        *quic.ServerNameRouter
+	routeHandlers map[*quic.Route]http.Handler
+
+	closed utils.AtomicBool
 
 	loggerOnce sync.Once
 	logger     utils.Logger
 
+	earlyRequests uint64 // accessed atomically
+	earlyBytes    uint64 // accessed atomically
+
 	//Export algorithms to reach them when creating the server. By default Hystart and NewReno
 	EstartAlgo utils.StartAlgo
 	EcongestionAlgo utils.CongestionAlgo
@@ -152,9 +235,51 @@ func (s *Server) serveImpl(tlsConf *tls.Config, conn net.PacketConn) error {
 		s.logger = utils.DefaultLogger.WithPrefix("server")
 	})
 
-	// The tls.Config we pass to Listen needs to have the GetConfigForClient callback set.
-	// That way, we can get the QUIC version and set the correct ALPN value.
-	baseConf := &tls.Config{
+	baseConf := tlsConfigWithALPN(tlsConf)
+
+	var ln quic.EarlyListener
+	var err error
+	quicConf := s.QuicConfig
+	if quicConf == nil {
+		quicConf = &quic.Config{}
+	} else {
+		quicConf = s.QuicConfig.Clone()
+	}
+	if s.EnableDatagrams {
+		quicConf.EnableDatagrams = true
+	}
+	if len(s.Routes) > 0 {
+		router, err := s.getRouter()
+		if err != nil {
+			return err
+		}
+		quicConf.GetRoute = router.GetRoute
+	}
+	if conn == nil {
+		ln, err = quicListenAddr(s.Addr, baseConf, quicConf, s.EstartAlgo, s.EcongestionAlgo)
+	} else {
+		ln, err = quicListen(conn, baseConf, quicConf, s.EstartAlgo, s.EcongestionAlgo)
+	}
+	if err != nil {
+		return err
+	}
+	s.addListener(&ln)
+	defer s.removeListener(&ln)
+
+	for {
+		sess, err := ln.Accept(context.Background())
+		if err != nil {
+			return err
+		}
+		go s.handleConn(sess)
+	}
+}
+
+// tlsConfigWithALPN returns the tls.Config passed to Listen.
+// It needs to have the GetConfigForClient callback set.
+// That way, we can get the QUIC version and set the correct ALPN value.
+func tlsConfigWithALPN(tlsConf *tls.Config) *tls.Config {
+	return &tls.Config{
 		GetConfigForClient: func(ch *tls.ClientHelloInfo) (*tls.Config, error) {
 			// determine the ALPN from the QUIC version used
 			proto := nextProtoH3Draft29
@@ -183,36 +308,60 @@ func (s *Server) serveImpl(tlsConf *tls.Config, conn net.PacketConn) error {
 			return config, nil
 		},
 	}
+}
 
-	var ln quic.EarlyListener
-	var err error
-	quicConf := s.QuicConfig
-	if quicConf == nil {
-		quicConf = &quic.Config{}
-	} else {
-		quicConf = s.QuicConfig.Clone()
-	}
-	if s.EnableDatagrams {
-		quicConf.EnableDatagrams = true
-	}
-	if conn == nil {
-		ln, err = quicListenAddr(s.Addr, baseConf, quicConf, s.EstartAlgo, s.EcongestionAlgo)
-	} else {
-		ln, err = quicListen(conn, baseConf, quicConf, s.EstartAlgo, s.EcongestionAlgo)
+// getRouter returns the router for the Routes.
+// It is created the first time it is needed, and shared by all listeners.
+func (s *Server) getRouter() (*quic.ServerNameRouter, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.router != nil {
+		return s.router, nil
 	}
-	if err != nil {
-		return err
+	router := quic.NewServerNameRouter()
+	routeHandlers := make(map[*quic.Route]http.Handler, len(s.Routes))
+	for serverName, route := range s.Routes {
+		if route == nil {
+			return nil, fmt.Errorf("http3: no route for %s", serverName)
+		}
+		r := &quic.Route{}
+		if route.TLSConfig != nil {
+			r.TLSConfig = tlsConfigWithALPN(route.TLSConfig)
+		}
+		if route.QuicConfig != nil {
+			r.Config = route.QuicConfig.Clone()
+			if s.EnableDatagrams {
+				r.Config.EnableDatagrams = true
+			}
+		}
+		if err := router.Add(serverName, r); err != nil {
+			return nil, err
+		}
+		routeHandlers[r] = route.Handler
 	}
-	s.addListener(&ln)
-	defer s.removeListener(&ln)
+	s.router = router
+	s.routeHandlers = routeHandlers
+	return router, nil
+}
 
-	for {
-		sess, err := ln.Accept(context.Background())
-		if err != nil {
-			return err
+// getHandler returns the handler for the requests on a session.
+func (s *Server) getHandler(sess quic.Session) http.Handler {
+	handler := s.Handler
+	s.mutex.Lock()
+	router := s.router
+	s.mutex.Unlock()
+	if router != nil {
+		if route := router.Lookup(sess.ConnectionState().TLS.ServerName); route != nil {
+			if h := s.routeHandlers[route]; h != nil {
+				handler = h
+			}
 		}
-		go s.handleConn(sess)
 	}
+	if handler == nil {
+		handler = http.DefaultServeMux
+	}
+	return handler
 }
 
 // We store a pointer to interface in the map set. This is safe because we only
@@ -234,7 +383,16 @@ func (s *Server) removeListener(l *quic.EarlyListener) {
 }
 
 func (s *Server) handleConn(sess quic.EarlySession) {
-	decoder := qpack.NewDecoder(nil)
+	decoder := newQPACKDecoder(s.QPACKMaxTableCapacity, s.QPACKBlockedStreams)
+	encoder := qpack.NewDynamicEncoder(s.QPACKMaxTableCapacity)
+	if s.QPACKMaxTableCapacity > 0 {
+		decoderStr, err := openQPACKStream(sess, streamTypeQPACKDecoderStream)
+		if err != nil {
+			s.logger.Debugf("Opening the QPACK decoder stream failed.")
+			return
+		}
+		decoder.SetDecoderStream(decoderStr)
+	}
 
 	// send a SETTINGS frame
 	str, err := sess.OpenUniStream()
@@ -244,10 +402,24 @@ func (s *Server) handleConn(sess quic.EarlySession) {
 	}
 	buf := &bytes.Buffer{}
 	quicvarint.Write(buf, streamTypeControlStream) // stream type
-	(&settingsFrame{Datagram: s.EnableDatagrams}).Write(buf)
+	(&settingsFrame{
+		Datagram:              s.EnableDatagrams,
+		ExtendedConnect:       true,
+		QPACKMaxTableCapacity: s.QPACKMaxTableCapacity,
+		QPACKBlockedStreams:   s.QPACKBlockedStreams,
+		other:                 s.AdditionalSettings,
+	}).Write(buf)
 	str.Write(buf.Bytes())
 
-	go s.handleUnidirectionalStreams(sess)
+	conn := newServerConn(sess, str)
+	if !s.addConn(conn) {
+		sess.CloseWithError(quic.ApplicationErrorCode(ErrCodeNoError), "server shutting down")
+		return
+	}
+	defer s.removeConn(conn)
+
+	priorities := newPriorityUpdater()
+	go s.handleUnidirectionalStreams(sess, decoder, encoder, priorities)
 
 	// Process all requests immediately.
 	// It's the client's responsibility to decide which requests are eligible for 0-RTT.
@@ -257,10 +429,21 @@ func (s *Server) handleConn(sess quic.EarlySession) {
 			s.logger.Debugf("Accepting stream failed: %s", err)
 			return
 		}
+		if !conn.startRequest(str.StreamID()) {
+			// The request was sent after the GOAWAY frame. The client can retry it on a new connection.
+			decoder.CancelStream(uint64(str.StreamID()))
+			str.CancelRead(quic.StreamErrorCode(ErrCodeRequestRejected))
+			str.CancelWrite(quic.StreamErrorCode(ErrCodeRequestRejected))
+			continue
+		}
 		go func() {
-			rerr := s.handleRequest(sess, str, decoder, func() {
-				sess.CloseWithError(quic.ApplicationErrorCode(errorFrameUnexpected), "")
+			defer conn.endRequest()
+			rerr := s.handleRequest(sess, str, decoder, encoder, priorities, func() {
+				sess.CloseWithError(quic.ApplicationErrorCode(ErrCodeFrameUnexpected), "")
 			})
+			if rerr.err == errHijacked {
+				return
+			}
 			if rerr.err != nil || rerr.streamErr != 0 || rerr.connErr != 0 {
 				s.logger.Debugf("Handling request failed: %s", err)
 				if rerr.streamErr != 0 {
@@ -280,7 +463,7 @@ func (s *Server) handleConn(sess quic.EarlySession) {
 	}
 }
 
-func (s *Server) handleUnidirectionalStreams(sess quic.EarlySession) {
+func (s *Server) handleUnidirectionalStreams(sess quic.EarlySession, decoder *qpack.Decoder, encoder *qpack.DynamicEncoder, priorities *priorityUpdater) {
 	for {
 		str, err := sess.AcceptUniStream(context.Background())
 		if err != nil {
@@ -294,43 +477,68 @@ func (s *Server) handleUnidirectionalStreams(sess quic.EarlySession) {
 				s.logger.Debugf("reading stream type on stream %d failed: %s", str.StreamID(), err)
 				return
 			}
-			// We're only interested in the control stream here.
 			switch streamType {
 			case streamTypeControlStream:
 			case streamTypeQPACKEncoderStream, streamTypeQPACKDecoderStream:
-				// Our QPACK implementation doesn't use the dynamic table yet.
 				// TODO: check that only one stream of each type is opened.
+				handleQPACKStream(sess, str, streamType, decoder, encoder)
 				return
 			case streamTypePushStream: // only the server can push
-				sess.CloseWithError(quic.ApplicationErrorCode(errorStreamCreationError), "")
+				sess.CloseWithError(quic.ApplicationErrorCode(ErrCodeStreamCreationError), "")
 				return
 			default:
-				str.CancelRead(quic.StreamErrorCode(errorStreamCreationError))
+				if s.UniStreamHijacker != nil && s.UniStreamHijacker(StreamType(streamType), sess, str) {
+					return
+				}
+				str.CancelRead(quic.StreamErrorCode(ErrCodeStreamCreationError))
 				return
 			}
 			f, err := parseNextFrame(str)
 			if err != nil {
-				sess.CloseWithError(quic.ApplicationErrorCode(errorFrameError), "")
+				sess.CloseWithError(quic.ApplicationErrorCode(ErrCodeFrameError), "")
 				return
 			}
 			sf, ok := f.(*settingsFrame)
 			if !ok {
-				sess.CloseWithError(quic.ApplicationErrorCode(errorMissingSettings), "")
-				return
-			}
-			if !sf.Datagram {
+				sess.CloseWithError(quic.ApplicationErrorCode(ErrCodeMissingSettings), "")
 				return
 			}
 			// If datagram support was enabled on our side as well as on the client side,
 			// we can expect it to have been negotiated both on the transport and on the HTTP/3 layer.
 			// Note: ConnectionState() will block until the handshake is complete (relevant when using 0-RTT).
-			if s.EnableDatagrams && !sess.ConnectionState().SupportsDatagrams {
-				sess.CloseWithError(quic.ApplicationErrorCode(errorSettingsError), "missing QUIC Datagram support")
+			if sf.Datagram && s.EnableDatagrams && !sess.ConnectionState().SupportsDatagrams {
+				sess.CloseWithError(quic.ApplicationErrorCode(ErrCodeSettingsError), "missing QUIC Datagram support")
+				return
+			}
+			if err := enableQPACKEncoder(sess, encoder, s.QPACKMaxTableCapacity, sf); err != nil {
+				s.logger.Debugf("Opening the QPACK encoder stream failed: %s", err)
+				return
 			}
+			s.handleControlFrames(sess, str, priorities)
 		}(str)
 	}
 }
 
+// handleControlFrames handles the frames sent on the client's control stream after the SETTINGS frame.
+func (s *Server) handleControlFrames(sess quic.EarlySession, str quic.ReceiveStream, priorities *priorityUpdater) {
+	for {
+		f, err := parseNextFrame(str)
+		if err != nil {
+			s.logger.Debugf("reading from the control stream failed: %s", err)
+			return
+		}
+		switch f := f.(type) {
+		case *priorityUpdateFrame:
+			priorities.handlePriorityUpdate(quic.StreamID(f.StreamID), ParsePriority(f.Priority))
+		case *goAwayFrame:
+			// The client's GOAWAY frame limits server push, which we don't use.
+		default:
+			sess.CloseWithError(quic.ApplicationErrorCode(ErrCodeFrameUnexpected), "")
+			return
+		}
+	}
+}
+
 func (s *Server) maxHeaderBytes() uint64 {
 	if s.Server.MaxHeaderBytes <= 0 {
 		return http.DefaultMaxHeaderBytes
@@ -338,35 +546,49 @@ func (s *Server) maxHeaderBytes() uint64 {
 	return uint64(s.Server.MaxHeaderBytes)
 }
 
-func (s *Server) handleRequest(sess quic.Session, str quic.Stream, decoder *qpack.Decoder, onFrameError func()) requestError {
-	frame, err := parseNextFrame(str)
+func (s *Server) handleRequest(sess quic.Session, str quic.Stream, decoder *qpack.Decoder, encoder *qpack.DynamicEncoder, priorities *priorityUpdater, onFrameError func()) requestError {
+	received := time.Now()
+	var unknownFrameHandler unknownFrameHandlerFunc
+	if s.StreamHijacker != nil {
+		unknownFrameHandler = func(ft FrameType) bool { return s.StreamHijacker(ft, sess, str) }
+	}
+	frame, err := parseNextFrameWithHandler(str, unknownFrameHandler)
+	if err == errHijacked {
+		return requestError{err: errHijacked}
+	}
 	if err != nil {
-		return newStreamError(errorRequestIncomplete, err)
+		return newStreamError(ErrCodeRequestIncomplete, err)
 	}
 	hf, ok := frame.(*headersFrame)
 	if !ok {
-		return newConnError(errorFrameUnexpected, errors.New("expected first frame to be a HEADERS frame"))
+		return newConnError(ErrCodeFrameUnexpected, errors.New("expected first frame to be a HEADERS frame"))
 	}
 	if hf.Length > s.maxHeaderBytes() {
-		return newStreamError(errorFrameError, fmt.Errorf("HEADERS frame too large: %d bytes (max: %d)", hf.Length, s.maxHeaderBytes()))
+		return newStreamError(ErrCodeFrameError, fmt.Errorf("HEADERS frame too large: %d bytes (max: %d)", hf.Length, s.maxHeaderBytes()))
 	}
 	headerBlock := make([]byte, hf.Length)
 	if _, err := io.ReadFull(str, headerBlock); err != nil {
-		return newStreamError(errorRequestIncomplete, err)
+		return newStreamError(ErrCodeRequestIncomplete, err)
 	}
-	hfs, err := decoder.DecodeFull(headerBlock)
+	strCtx := str.Context()
+	hfs, err := decoder.DecodeStream(strCtx, uint64(str.StreamID()), headerBlock)
 	if err != nil {
-		// TODO: use the right error code
-		return newConnError(errorGeneralProtocolError, err)
+		if ctxErr := strCtx.Err(); ctxErr != nil {
+			decoder.CancelStream(uint64(str.StreamID()))
+			return newStreamError(ErrCodeRequestCanceled, ctxErr)
+		}
+		return newConnError(ErrCodeQPACKDecompressionFailed, err)
 	}
 	req, err := requestFromHeaders(hfs)
 	if err != nil {
 		// TODO: use the right error code
-		return newStreamError(errorGeneralProtocolError, err)
+		return newStreamError(ErrCodeGeneralProtocolError, err)
 	}
 
 	req.RemoteAddr = sess.RemoteAddr().String()
-	req.Body = newRequestBody(str, onFrameError)
+	body := newRequestBody(str, onFrameError)
+	body.setTrailer(&req.Trailer, decoder, s.maxHeaderBytes())
+	req.Body = body
 
 	if s.logger.Debug() {
 		s.logger.Infof("%s %s%s, on stream %d", req.Method, req.Host, req.RequestURI, str.StreamID())
@@ -374,20 +596,53 @@ func (s *Server) handleRequest(sess quic.Session, str quic.Stream, decoder *qpac
 		s.logger.Infof("%s %s%s", req.Method, req.Host, req.RequestURI)
 	}
 
-	ctx := str.Context()
-	ctx = context.WithValue(ctx, ServerContextKey, s)
+	if s.TraceRequest != nil {
+		s.TraceRequest(sess.Context(), str.StreamID(), req)
+	}
+
+	ctx := context.WithValue(strCtx, ServerContextKey, s)
 	ctx = context.WithValue(ctx, http.LocalAddrContextKey, sess.LocalAddr())
 	req = req.WithContext(ctx)
-	r := newResponseWriter(str, s.logger)
+	r := newResponseWriter(sess, str, s.logger)
+	r.encoder = encoder
+	if s.AccessLog != nil {
+		// Registered before all other deferred functions, so that it's called after the response was flushed.
+		defer func() { s.AccessLog(newAccessLogEntry(sess, str, req, r, received)) }()
+	}
+	if expectsContinue(req) && req.ContentLength != 0 {
+		req.Body = &expectContinueBody{
+			body: body,
+			writeContinue: func() {
+				if !r.headerWritten {
+					r.WriteHeader(http.StatusContinue)
+				}
+			},
+		}
+	}
+	r.slo = newSLOEnforcer(str, received, func(v SLOViolation) {
+		s.logger.Debugf("Response to %s %s%s violated its SLO (%s) after %s", req.Method, req.Host, req.RequestURI, v.Reason, v.Elapsed)
+		if s.OnSLOViolation != nil {
+			s.OnSLOViolation(req, v)
+		}
+	})
+	// Registered before the deferred Flush, so that it's called after the response was flushed.
+	defer r.slo.stop()
+	priorities.addRequest(str.StreamID(), r, RequestPriority(req))
+	defer priorities.removeRequest(str.StreamID())
+	if esess, ok := sess.(quic.EarlySession); ok {
+		if handshakeComplete := esess.HandshakeComplete().Done(); !isClosed(handshakeComplete) {
+			atomic.AddUint64(&s.earlyRequests, 1)
+			if s.FlushEarlyResponses {
+				r.sendEarly(handshakeComplete, func(n int) { atomic.AddUint64(&s.earlyBytes, uint64(n)) })
+			}
+		}
+	}
 	defer func() {
 		if !r.usedDataStream() {
 			r.Flush()
 		}
 	}()
-	handler := s.Handler
-	if handler == nil {
-		handler = http.DefaultServeMux
-	}
+	handler := s.getHandler(sess)
 
 	var panicked bool
 	func() {
@@ -404,18 +659,39 @@ func (s *Server) handleRequest(sess quic.Session, str quic.Stream, decoder *qpac
 		handler.ServeHTTP(r, req)
 	}()
 
-	if !r.usedDataStream() {
-		if panicked {
-			r.WriteHeader(500)
-		} else {
-			r.WriteHeader(200)
-		}
-		// If the EOF was read by the handler, CancelRead() is a no-op.
-		str.CancelRead(quic.StreamErrorCode(errorNoError))
+	if r.usedDataStream() {
+		// The handler took over the stream.
+		return requestError{err: errHijacked}
 	}
+	if panicked {
+		r.WriteHeader(500)
+	} else {
+		r.WriteHeader(200)
+		r.writeTrailers()
+	}
+	// If the EOF was read by the handler, CancelRead() is a no-op.
+	str.CancelRead(quic.StreamErrorCode(ErrCodeNoError))
 	return requestError{}
 }
 
+// EarlyResponseStats returns statistics about responses sent before completion of the handshake.
+// Response bytes are only counted if FlushEarlyResponses is set.
+func (s *Server) EarlyResponseStats() EarlyResponseStats {
+	return EarlyResponseStats{
+		Requests: atomic.LoadUint64(&s.earlyRequests),
+		Bytes:    atomic.LoadUint64(&s.earlyBytes),
+	}
+}
+
+func isClosed(c <-chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
 // Close the server immediately, aborting requests and sending CONNECTION_CLOSE frames to connected clients.
 // Close in combination with ListenAndServe() (instead of Serve()) may race if it is called before a UDP socket is established.
 func (s *Server) Close() error {
@@ -436,7 +712,11 @@ func (s *Server) Close() error {
 // CloseGracefully shuts down the server gracefully. The server sends a GOAWAY frame first, then waits for either timeout to trigger, or for all running requests to complete.
 // CloseGracefully in combination with ListenAndServe() (instead of Serve()) may race if it is called before a UDP socket is established.
 func (s *Server) CloseGracefully(timeout time.Duration) error {
-	// TODO: implement
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil && err != context.DeadlineExceeded {
+		return err
+	}
 	return nil
 }
 
@@ -494,76 +774,8 @@ func ListenAndServeQUIC(addr, certFile, keyFile string, handler http.Handler) er
 // http.DefaultServeMux is used when handler is nil.
 // The correct Alt-Svc headers for QUIC are set.
 func ListenAndServe(addr, certFile, keyFile string, handler http.Handler) error {
-	// Load certs
-	var err error
-	certs := make([]tls.Certificate, 1)
-	certs[0], err = tls.LoadX509KeyPair(certFile, keyFile)
-	if err != nil {
-		return err
-	}
-	// We currently only use the cert-related stuff from tls.Config,
-	// so we don't need to make a full copy.
-	config := &tls.Config{
-		Certificates: certs,
-	}
-
-	// Open the listeners
-	udpAddr, err := net.ResolveUDPAddr("udp", addr)
-	if err != nil {
-		return err
-	}
-	udpConn, err := net.ListenUDP("udp", udpAddr)
-	if err != nil {
-		return err
-	}
-	defer udpConn.Close()
-
-	tcpAddr, err := net.ResolveTCPAddr("tcp", addr)
-	if err != nil {
-		return err
-	}
-	tcpConn, err := net.ListenTCP("tcp", tcpAddr)
-	if err != nil {
-		return err
-	}
-	defer tcpConn.Close()
-
-	tlsConn := tls.NewListener(tcpConn, config)
-	defer tlsConn.Close()
-
-	// Start the servers
-	httpServer := &http.Server{
-		Addr:      addr,
-		TLSConfig: config,
-	}
-
-	quicServer := &Server{
-		Server: httpServer,
-	}
-
-	if handler == nil {
-		handler = http.DefaultServeMux
-	}
-	httpServer.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		quicServer.SetQuicHeaders(w.Header())
-		handler.ServeHTTP(w, r)
-	})
-
-	hErr := make(chan error)
-	qErr := make(chan error)
-	go func() {
-		hErr <- httpServer.Serve(tlsConn)
-	}()
-	go func() {
-		qErr <- quicServer.Serve(udpConn)
-	}()
-
-	select {
-	case err := <-hErr:
-		quicServer.Close()
-		return err
-	case err := <-qErr:
-		// Cannot close the HTTP server or wait for requests to complete properly :/
-		return err
-	}
+	return NewCombinedServer(&http.Server{
+		Addr:    addr,
+		Handler: handler,
+	}).ListenAndServeTLS(certFile, keyFile)
 }
@@ -14,12 +14,12 @@ import (
 	"github.com/lucas-clemente/quic-go"
 	mockquic "github.com/lucas-clemente/quic-go/internal/mocks/quic"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/qpack"
 	"github.com/lucas-clemente/quic-go/internal/testdata"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/lucas-clemente/quic-go/quicvarint"
 
 	"github.com/golang/mock/gomock"
-	"github.com/marten-seemann/qpack"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -67,6 +67,9 @@ var _ = Describe("Server", func() {
 			examplePostRequest *http.Request
 		)
 		reqContext := context.Background()
+		// an already canceled context, i.e. the handshake is complete
+		handshakeCtx, handshakeComplete := context.WithCancel(context.Background())
+		handshakeComplete()
 
 		decodeHeader := func(str io.Reader) map[string][]string {
 			fields := make(map[string][]string)
@@ -94,7 +97,7 @@ var _ = Describe("Server", func() {
 			closed := make(chan struct{})
 			str.EXPECT().Close().Do(func() { close(closed) })
 			rw := newRequestWriter(utils.DefaultLogger)
-			Expect(rw.WriteRequest(str, req, false)).To(Succeed())
+			Expect(rw.WriteRequest(str, req, false, nil)).To(Succeed())
 			Eventually(closed).Should(BeClosed())
 			return buf.Bytes()
 		}
@@ -118,11 +121,14 @@ var _ = Describe("Server", func() {
 
 			qpackDecoder = qpack.NewDecoder(nil)
 			str = mockquic.NewMockStream(mockCtrl)
+			str.EXPECT().StreamID().Return(quic.StreamID(4)).AnyTimes()
+			str.EXPECT().SetPriority(gomock.Any()).AnyTimes()
 
 			sess = mockquic.NewMockEarlySession(mockCtrl)
 			addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1337}
 			sess.EXPECT().RemoteAddr().Return(addr).AnyTimes()
 			sess.EXPECT().LocalAddr().AnyTimes()
+			sess.EXPECT().HandshakeComplete().Return(handshakeCtx).AnyTimes()
 		})
 
 		It("calls the HTTP handler function", func() {
@@ -138,7 +144,7 @@ var _ = Describe("Server", func() {
 			}).AnyTimes()
 			str.EXPECT().CancelRead(gomock.Any())
 
-			Expect(s.handleRequest(sess, str, qpackDecoder, nil)).To(Equal(requestError{}))
+			Expect(s.handleRequest(sess, str, qpackDecoder, qpack.NewDynamicEncoder(0), newPriorityUpdater(), nil)).To(Equal(requestError{}))
 			var req *http.Request
 			Eventually(requestChan).Should(Receive(&req))
 			Expect(req.Host).To(Equal("www.example.com"))
@@ -146,6 +152,32 @@ var _ = Describe("Server", func() {
 			Expect(req.Context().Value(ServerContextKey)).To(Equal(s))
 		})
 
+		It("calls the TraceRequest callback before handling the request", func() {
+			sessCtx := context.WithValue(context.Background(), quic.SessionTracingKey, uint64(1337))
+			sess.EXPECT().Context().Return(sessCtx)
+			str.EXPECT().StreamID().Return(quic.StreamID(4)).AnyTimes()
+			traced := make(chan struct{})
+			s.TraceRequest = func(ctx context.Context, id quic.StreamID, r *http.Request) {
+				defer close(traced)
+				Expect(ctx).To(Equal(sessCtx))
+				Expect(id).To(Equal(quic.StreamID(4)))
+				Expect(r.Host).To(Equal("www.example.com"))
+			}
+			s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(traced).To(BeClosed())
+			})
+
+			setRequest(encodeRequest(exampleGetRequest))
+			str.EXPECT().Context().Return(reqContext)
+			str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
+				return len(p), nil
+			}).AnyTimes()
+			str.EXPECT().CancelRead(gomock.Any())
+
+			Expect(s.handleRequest(sess, str, qpackDecoder, qpack.NewDynamicEncoder(0), newPriorityUpdater(), nil)).To(Equal(requestError{}))
+			Expect(traced).To(BeClosed())
+		})
+
 		It("returns 200 with an empty handler", func() {
 			s.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
 
@@ -155,7 +187,7 @@ var _ = Describe("Server", func() {
 			str.EXPECT().Write(gomock.Any()).DoAndReturn(responseBuf.Write).AnyTimes()
 			str.EXPECT().CancelRead(gomock.Any())
 
-			serr := s.handleRequest(sess, str, qpackDecoder, nil)
+			serr := s.handleRequest(sess, str, qpackDecoder, qpack.NewDynamicEncoder(0), newPriorityUpdater(), nil)
 			Expect(serr.err).ToNot(HaveOccurred())
 			hfs := decodeHeader(responseBuf)
 			Expect(hfs).To(HaveKeyWithValue(":status", []string{"200"}))
@@ -172,7 +204,7 @@ var _ = Describe("Server", func() {
 			str.EXPECT().Write(gomock.Any()).DoAndReturn(responseBuf.Write).AnyTimes()
 			str.EXPECT().CancelRead(gomock.Any())
 
-			serr := s.handleRequest(sess, str, qpackDecoder, nil)
+			serr := s.handleRequest(sess, str, qpackDecoder, qpack.NewDynamicEncoder(0), newPriorityUpdater(), nil)
 			Expect(serr.err).ToNot(HaveOccurred())
 			hfs := decodeHeader(responseBuf)
 			Expect(hfs).To(HaveKeyWithValue(":status", []string{"500"}))
@@ -189,8 +221,8 @@ var _ = Describe("Server", func() {
 			str.EXPECT().Write([]byte("foobar"))
 			// don't EXPECT CancelRead()
 
-			serr := s.handleRequest(sess, str, qpackDecoder, nil)
-			Expect(serr.err).ToNot(HaveOccurred())
+			serr := s.handleRequest(sess, str, qpackDecoder, qpack.NewDynamicEncoder(0), newPriorityUpdater(), nil)
+			Expect(serr.err).To(Equal(errHijacked))
 		})
 
 		Context("control stream handling", func() {
@@ -233,11 +265,13 @@ var _ = Describe("Server", func() {
 					name = "decoder"
 				}
 
-				It(fmt.Sprintf("ignores the QPACK %s streams", name), func() {
+				It(fmt.Sprintf("closes the connection when the QPACK %s stream is closed", name), func() {
 					buf := &bytes.Buffer{}
 					quicvarint.Write(buf, streamType)
 					str := mockquic.NewMockStream(mockCtrl)
 					str.EXPECT().Read(gomock.Any()).DoAndReturn(buf.Read).AnyTimes()
+					closed := make(chan struct{})
+					sess.EXPECT().CloseWithError(quic.ApplicationErrorCode(ErrCodeClosedCriticalStream), gomock.Any()).Do(func(quic.ApplicationErrorCode, string) { close(closed) })
 
 					sess.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
 						return str, nil
@@ -247,7 +281,7 @@ var _ = Describe("Server", func() {
 						return nil, errors.New("test done")
 					})
 					s.handleConn(sess)
-					time.Sleep(scaleDuration(20 * time.Millisecond)) // don't EXPECT any calls to str.CancelRead
+					Eventually(closed).Should(BeClosed())
 				})
 			}
 
@@ -257,7 +291,7 @@ var _ = Describe("Server", func() {
 				str := mockquic.NewMockStream(mockCtrl)
 				str.EXPECT().Read(gomock.Any()).DoAndReturn(buf.Read).AnyTimes()
 				done := make(chan struct{})
-				str.EXPECT().CancelRead(quic.StreamErrorCode(errorStreamCreationError)).Do(func(code quic.StreamErrorCode) {
+				str.EXPECT().CancelRead(quic.StreamErrorCode(ErrCodeStreamCreationError)).Do(func(code quic.StreamErrorCode) {
 					close(done)
 				})
 
@@ -272,6 +306,56 @@ var _ = Describe("Server", func() {
 				Eventually(done).Should(BeClosed())
 			})
 
+			It("applies PRIORITY_UPDATE frames", func() {
+				buf := &bytes.Buffer{}
+				quicvarint.Write(buf, streamTypeControlStream)
+				(&settingsFrame{}).Write(buf)
+				(&priorityUpdateFrame{StreamID: 4, Priority: "u=1"}).Write(buf)
+				controlStr := mockquic.NewMockStream(mockCtrl)
+				read := make(chan struct{})
+				controlStr.EXPECT().Read(gomock.Any()).DoAndReturn(func(b []byte) (int, error) {
+					if buf.Len() == 0 {
+						close(read)
+						return 0, io.EOF
+					}
+					return buf.Read(b)
+				}).AnyTimes()
+				sess.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+					return controlStr, nil
+				})
+				sess.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+					<-testDone
+					return nil, errors.New("test done")
+				})
+				s.handleConn(sess)
+				Eventually(read).Should(BeClosed())
+				time.Sleep(scaleDuration(20 * time.Millisecond)) // don't EXPECT any calls to sess.CloseWithError
+			})
+
+			It("errors when the control stream contains an unexpected frame", func() {
+				buf := &bytes.Buffer{}
+				quicvarint.Write(buf, streamTypeControlStream)
+				(&settingsFrame{}).Write(buf)
+				(&headersFrame{Length: 10}).Write(buf)
+				controlStr := mockquic.NewMockStream(mockCtrl)
+				controlStr.EXPECT().Read(gomock.Any()).DoAndReturn(buf.Read).AnyTimes()
+				sess.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+					return controlStr, nil
+				})
+				sess.EXPECT().AcceptUniStream(gomock.Any()).DoAndReturn(func(context.Context) (quic.ReceiveStream, error) {
+					<-testDone
+					return nil, errors.New("test done")
+				})
+				done := make(chan struct{})
+				sess.EXPECT().CloseWithError(gomock.Any(), gomock.Any()).Do(func(code quic.ApplicationErrorCode, _ string) {
+					defer GinkgoRecover()
+					Expect(code).To(BeEquivalentTo(ErrCodeFrameUnexpected))
+					close(done)
+				})
+				s.handleConn(sess)
+				Eventually(done).Should(BeClosed())
+			})
+
 			It("errors when the first frame on the control stream is not a SETTINGS frame", func() {
 				buf := &bytes.Buffer{}
 				quicvarint.Write(buf, streamTypeControlStream)
@@ -288,7 +372,7 @@ var _ = Describe("Server", func() {
 				done := make(chan struct{})
 				sess.EXPECT().CloseWithError(gomock.Any(), gomock.Any()).Do(func(code quic.ApplicationErrorCode, _ string) {
 					defer GinkgoRecover()
-					Expect(code).To(BeEquivalentTo(errorMissingSettings))
+					Expect(code).To(BeEquivalentTo(ErrCodeMissingSettings))
 					close(done)
 				})
 				s.handleConn(sess)
@@ -313,7 +397,7 @@ var _ = Describe("Server", func() {
 				done := make(chan struct{})
 				sess.EXPECT().CloseWithError(gomock.Any(), gomock.Any()).Do(func(code quic.ApplicationErrorCode, _ string) {
 					defer GinkgoRecover()
-					Expect(code).To(BeEquivalentTo(errorFrameError))
+					Expect(code).To(BeEquivalentTo(ErrCodeFrameError))
 					close(done)
 				})
 				s.handleConn(sess)
@@ -336,7 +420,7 @@ var _ = Describe("Server", func() {
 				done := make(chan struct{})
 				sess.EXPECT().CloseWithError(gomock.Any(), gomock.Any()).Do(func(code quic.ApplicationErrorCode, _ string) {
 					defer GinkgoRecover()
-					Expect(code).To(BeEquivalentTo(errorStreamCreationError))
+					Expect(code).To(BeEquivalentTo(ErrCodeStreamCreationError))
 					close(done)
 				})
 				s.handleConn(sess)
@@ -361,7 +445,7 @@ var _ = Describe("Server", func() {
 				done := make(chan struct{})
 				sess.EXPECT().CloseWithError(gomock.Any(), gomock.Any()).Do(func(code quic.ApplicationErrorCode, reason string) {
 					defer GinkgoRecover()
-					Expect(code).To(BeEquivalentTo(errorSettingsError))
+					Expect(code).To(BeEquivalentTo(ErrCodeSettingsError))
 					Expect(reason).To(Equal("missing QUIC Datagram support"))
 					close(done)
 				})
@@ -389,6 +473,7 @@ var _ = Describe("Server", func() {
 				sess.EXPECT().AcceptStream(gomock.Any()).Return(nil, errors.New("done"))
 				sess.EXPECT().RemoteAddr().Return(addr).AnyTimes()
 				sess.EXPECT().LocalAddr().AnyTimes()
+				sess.EXPECT().HandshakeComplete().Return(handshakeCtx).AnyTimes()
 			})
 
 			AfterEach(func() { testDone <- struct{}{} })
@@ -408,7 +493,7 @@ var _ = Describe("Server", func() {
 				done := make(chan struct{})
 				str.EXPECT().Context().Return(reqContext)
 				str.EXPECT().Write(gomock.Any()).DoAndReturn(responseBuf.Write).AnyTimes()
-				str.EXPECT().CancelRead(quic.StreamErrorCode(errorNoError))
+				str.EXPECT().CancelRead(quic.StreamErrorCode(ErrCodeNoError))
 				str.EXPECT().Close().Do(func() { close(done) })
 
 				s.handleConn(sess)
@@ -431,7 +516,7 @@ var _ = Describe("Server", func() {
 				setRequest(append(requestData, buf.Bytes()...))
 				done := make(chan struct{})
 				str.EXPECT().Write(gomock.Any()).DoAndReturn(responseBuf.Write).AnyTimes()
-				str.EXPECT().CancelWrite(quic.StreamErrorCode(errorFrameError)).Do(func(quic.StreamErrorCode) { close(done) })
+				str.EXPECT().CancelWrite(quic.StreamErrorCode(ErrCodeFrameError)).Do(func(quic.StreamErrorCode) { close(done) })
 
 				s.handleConn(sess)
 				Eventually(done).Should(BeClosed())
@@ -446,7 +531,7 @@ var _ = Describe("Server", func() {
 				testErr := errors.New("stream reset")
 				done := make(chan struct{})
 				str.EXPECT().Read(gomock.Any()).Return(0, testErr)
-				str.EXPECT().CancelWrite(quic.StreamErrorCode(errorRequestIncomplete)).Do(func(quic.StreamErrorCode) { close(done) })
+				str.EXPECT().CancelWrite(quic.StreamErrorCode(ErrCodeRequestIncomplete)).Do(func(quic.StreamErrorCode) { close(done) })
 
 				s.handleConn(sess)
 				Consistently(handlerCalled).ShouldNot(BeClosed())
@@ -467,7 +552,7 @@ var _ = Describe("Server", func() {
 
 				done := make(chan struct{})
 				sess.EXPECT().CloseWithError(gomock.Any(), gomock.Any()).Do(func(code quic.ApplicationErrorCode, _ string) {
-					Expect(code).To(Equal(quic.ApplicationErrorCode(errorFrameUnexpected)))
+					Expect(code).To(Equal(quic.ApplicationErrorCode(ErrCodeFrameUnexpected)))
 					close(done)
 				})
 				s.handleConn(sess)
@@ -491,7 +576,7 @@ var _ = Describe("Server", func() {
 					return len(p), nil
 				}).AnyTimes()
 				done := make(chan struct{})
-				str.EXPECT().CancelWrite(quic.StreamErrorCode(errorFrameError)).Do(func(quic.StreamErrorCode) { close(done) })
+				str.EXPECT().CancelWrite(quic.StreamErrorCode(ErrCodeFrameError)).Do(func(quic.StreamErrorCode) { close(done) })
 
 				s.handleConn(sess)
 				Eventually(done).Should(BeClosed())
@@ -513,9 +598,9 @@ var _ = Describe("Server", func() {
 			str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
 				return len(p), nil
 			}).AnyTimes()
-			str.EXPECT().CancelRead(quic.StreamErrorCode(errorNoError))
+			str.EXPECT().CancelRead(quic.StreamErrorCode(ErrCodeNoError))
 
-			serr := s.handleRequest(sess, str, qpackDecoder, nil)
+			serr := s.handleRequest(sess, str, qpackDecoder, qpack.NewDynamicEncoder(0), newPriorityUpdater(), nil)
 			Expect(serr.err).ToNot(HaveOccurred())
 			Eventually(handlerCalled).Should(BeClosed())
 		})
@@ -536,9 +621,9 @@ var _ = Describe("Server", func() {
 			str.EXPECT().Write(gomock.Any()).DoAndReturn(func(p []byte) (int, error) {
 				return len(p), nil
 			}).AnyTimes()
-			str.EXPECT().CancelRead(quic.StreamErrorCode(errorNoError))
+			str.EXPECT().CancelRead(quic.StreamErrorCode(ErrCodeNoError))
 
-			serr := s.handleRequest(sess, str, qpackDecoder, nil)
+			serr := s.handleRequest(sess, str, qpackDecoder, qpack.NewDynamicEncoder(0), newPriorityUpdater(), nil)
 			Expect(serr.err).ToNot(HaveOccurred())
 			Eventually(handlerCalled).Should(BeClosed())
 		})
@@ -629,7 +714,7 @@ var _ = Describe("Server", func() {
 		It("serves a packet conn", func() {
 			ln := mockquic.NewMockEarlyListener(mockCtrl)
 			conn := &net.UDPConn{}
-			quicListen = func(c net.PacketConn, tlsConf *tls.Config, config *quic.Config) (quic.EarlyListener, error) {
+			quicListen = func(c net.PacketConn, tlsConf *tls.Config, config *quic.Config, _ utils.StartAlgo, _ utils.CongestionAlgo) (quic.EarlyListener, error) {
 				Expect(c).To(Equal(conn))
 				return ln, nil
 			}
@@ -663,7 +748,7 @@ var _ = Describe("Server", func() {
 			lns <- ln2
 			conn1 := &net.UDPConn{}
 			conn2 := &net.UDPConn{}
-			quicListen = func(c net.PacketConn, tlsConf *tls.Config, config *quic.Config) (quic.EarlyListener, error) {
+			quicListen = func(c net.PacketConn, tlsConf *tls.Config, config *quic.Config, _ utils.StartAlgo, _ utils.CongestionAlgo) (quic.EarlyListener, error) {
 				return <-lns, nil
 			}
 
@@ -725,7 +810,7 @@ var _ = Describe("Server", func() {
 		It("uses the quic.Config to start the QUIC server", func() {
 			conf := &quic.Config{HandshakeIdleTimeout: time.Nanosecond}
 			var receivedConf *quic.Config
-			quicListenAddr = func(addr string, _ *tls.Config, config *quic.Config) (quic.EarlyListener, error) {
+			quicListenAddr = func(addr string, _ *tls.Config, config *quic.Config, _ utils.StartAlgo, _ utils.CongestionAlgo) (quic.EarlyListener, error) {
 				receivedConf = config
 				return nil, errors.New("listen err")
 			}
@@ -740,7 +825,7 @@ var _ = Describe("Server", func() {
 				NextProtos: []string{"foo", "bar"},
 			}
 			var receivedConf *tls.Config
-			quicListenAddr = func(addr string, tlsConf *tls.Config, _ *quic.Config) (quic.EarlyListener, error) {
+			quicListenAddr = func(addr string, tlsConf *tls.Config, _ *quic.Config, _ utils.StartAlgo, _ utils.CongestionAlgo) (quic.EarlyListener, error) {
 				receivedConf = tlsConf
 				return nil, errors.New("listen err")
 			}
@@ -759,7 +844,7 @@ var _ = Describe("Server", func() {
 
 		It("sets the GetConfigForClient callback if no tls.Config is given", func() {
 			var receivedConf *tls.Config
-			quicListenAddr = func(addr string, tlsConf *tls.Config, _ *quic.Config) (quic.EarlyListener, error) {
+			quicListenAddr = func(addr string, tlsConf *tls.Config, _ *quic.Config, _ utils.StartAlgo, _ utils.CongestionAlgo) (quic.EarlyListener, error) {
 				receivedConf = tlsConf
 				return nil, errors.New("listen err")
 			}
@@ -779,7 +864,7 @@ var _ = Describe("Server", func() {
 			}
 
 			var receivedConf *tls.Config
-			quicListenAddr = func(addr string, conf *tls.Config, _ *quic.Config) (quic.EarlyListener, error) {
+			quicListenAddr = func(addr string, conf *tls.Config, _ *quic.Config, _ utils.StartAlgo, _ utils.CongestionAlgo) (quic.EarlyListener, error) {
 				receivedConf = conf
 				return nil, errors.New("listen err")
 			}
@@ -805,7 +890,7 @@ var _ = Describe("Server", func() {
 			}
 
 			var receivedConf *tls.Config
-			quicListenAddr = func(addr string, conf *tls.Config, _ *quic.Config) (quic.EarlyListener, error) {
+			quicListenAddr = func(addr string, conf *tls.Config, _ *quic.Config, _ utils.StartAlgo, _ utils.CongestionAlgo) (quic.EarlyListener, error) {
 				receivedConf = conf
 				return nil, errors.New("listen err")
 			}
@@ -822,7 +907,7 @@ var _ = Describe("Server", func() {
 			tlsConf := &tls.Config{GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) { return nil, nil }}
 
 			var receivedConf *tls.Config
-			quicListenAddr = func(addr string, conf *tls.Config, _ *quic.Config) (quic.EarlyListener, error) {
+			quicListenAddr = func(addr string, conf *tls.Config, _ *quic.Config, _ utils.StartAlgo, _ utils.CongestionAlgo) (quic.EarlyListener, error) {
 				receivedConf = conf
 				return nil, errors.New("listen err")
 			}
@@ -841,7 +926,7 @@ var _ = Describe("Server", func() {
 
 	It("errors when listening fails", func() {
 		testErr := errors.New("listen error")
-		quicListenAddr = func(addr string, tlsConf *tls.Config, config *quic.Config) (quic.EarlyListener, error) {
+		quicListenAddr = func(addr string, tlsConf *tls.Config, config *quic.Config, _ utils.StartAlgo, _ utils.CongestionAlgo) (quic.EarlyListener, error) {
 			return nil, testErr
 		}
 		fullpem, privkey := testdata.GetCertificatePaths()
@@ -851,7 +936,7 @@ var _ = Describe("Server", func() {
 	It("supports H3_DATAGRAM", func() {
 		s.EnableDatagrams = true
 		var receivedConf *quic.Config
-		quicListenAddr = func(addr string, _ *tls.Config, config *quic.Config) (quic.EarlyListener, error) {
+		quicListenAddr = func(addr string, _ *tls.Config, config *quic.Config, _ utils.StartAlgo, _ utils.CongestionAlgo) (quic.EarlyListener, error) {
 			receivedConf = config
 			return nil, errors.New("listen err")
 		}
@@ -0,0 +1,178 @@
+package http3
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lucas-clemente/quic-go"
+)
+
+// shutdownDrainPeriod is the time the server waits for the client to close an idle connection during Shutdown.
+const shutdownDrainPeriod = time.Second
+
+// Shutdown gracefully shuts down the server without interrupting any active requests.
+// The server sends a GOAWAY frame on every connection, such that clients stop sending new requests on it.
+// Requests that are received after the GOAWAY frame are rejected, and can be retried on a new connection.
+// New connections are refused. Once all active requests on a connection have completed, the server waits
+// for the client to close the connection, but for no longer than shutdownDrainPeriod, before closing it.
+// When all connections are closed, the listeners are closed.
+//
+// If the context expires before all requests have completed, the server is closed immediately (see Close),
+// and the context's error is returned.
+//
+// Shutdown doesn't wait for requests that took over the stream, e.g. using the DataStreamer.
+// Shutdown in combination with ListenAndServe() (instead of Serve()) may race if it is called before a UDP socket is established.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.closed.Set(true)
+
+	s.mutex.Lock()
+	conns := make([]*serverConn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mutex.Unlock()
+
+	for _, c := range conns {
+		c.goAway()
+	}
+	var wg sync.WaitGroup
+	wg.Add(len(conns))
+	for _, c := range conns {
+		go func(c *serverConn) {
+			defer wg.Done()
+			c.closeWhenIdle(ctx)
+		}(c)
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.Close()
+		return ctx.Err()
+	}
+	return s.Close()
+}
+
+// addConn tracks a connection, so it can be shut down gracefully.
+// It returns false if the server is already closed.
+func (s *Server) addConn(c *serverConn) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed.Get() {
+		return false
+	}
+	if s.conns == nil {
+		s.conns = make(map[*serverConn]struct{})
+	}
+	s.conns[c] = struct{}{}
+	return true
+}
+
+func (s *Server) removeConn(c *serverConn) {
+	s.mutex.Lock()
+	delete(s.conns, c)
+	s.mutex.Unlock()
+}
+
+// A serverConn tracks the active requests on a connection, so it can be shut down gracefully.
+type serverConn struct {
+	sess       quic.EarlySession
+	controlStr quic.SendStream
+
+	mutex          sync.Mutex
+	nextStreamID   quic.StreamID // the stream ID following the highest request stream ID accepted so far
+	goingAway      bool
+	goAwayID       quic.StreamID // only valid if goingAway is set
+	activeRequests int
+	idleClosed     bool
+
+	idle chan struct{} // closed when no requests are active anymore, after the GOAWAY frame was sent
+}
+
+func newServerConn(sess quic.EarlySession, controlStr quic.SendStream) *serverConn {
+	return &serverConn{
+		sess:       sess,
+		controlStr: controlStr,
+		idle:       make(chan struct{}),
+	}
+}
+
+// startRequest is called when a request stream is accepted.
+// It returns false if the request must be rejected, since it was received after the GOAWAY frame.
+func (c *serverConn) startRequest(id quic.StreamID) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.goingAway && id >= c.goAwayID {
+		return false
+	}
+	c.activeRequests++
+	if id >= c.nextStreamID {
+		c.nextStreamID = id + 4
+	}
+	return true
+}
+
+func (c *serverConn) endRequest() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.activeRequests--
+	c.maybeSignalIdle()
+}
+
+// goAway sends a GOAWAY frame.
+// All requests that were accepted so far are processed, all later requests are rejected.
+func (c *serverConn) goAway() {
+	c.mutex.Lock()
+	if c.goingAway {
+		c.mutex.Unlock()
+		return
+	}
+	c.goingAway = true
+	c.goAwayID = c.nextStreamID
+	c.mutex.Unlock()
+
+	buf := &bytes.Buffer{}
+	(&goAwayFrame{ID: uint64(c.goAwayID)}).Write(buf)
+	c.controlStr.Write(buf.Bytes())
+
+	c.mutex.Lock()
+	c.maybeSignalIdle()
+	c.mutex.Unlock()
+}
+
+// closeWhenIdle closes the connection when all active requests have completed.
+// The responses might still be in flight at that point, so the client is given some time to close the connection first.
+func (c *serverConn) closeWhenIdle(ctx context.Context) {
+	select {
+	case <-c.idle:
+	case <-c.sess.Context().Done():
+		return
+	case <-ctx.Done():
+		return
+	}
+	timer := time.NewTimer(shutdownDrainPeriod)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-c.sess.Context().Done():
+		return
+	case <-ctx.Done():
+		return
+	}
+	c.sess.CloseWithError(quic.ApplicationErrorCode(ErrCodeNoError), "")
+}
+
+func (c *serverConn) maybeSignalIdle() {
+	if c.goingAway && c.activeRequests == 0 && !c.idleClosed {
+		c.idleClosed = true
+		close(c.idle)
+	}
+}
@@ -0,0 +1,160 @@
+package http3
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lucas-clemente/quic-go"
+	mockquic "github.com/lucas-clemente/quic-go/internal/mocks/quic"
+	"github.com/lucas-clemente/quic-go/internal/testdata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Graceful shutdown", func() {
+	Context("tracking requests", func() {
+		var (
+			conn    *serverConn
+			ctrlBuf *bytes.Buffer
+		)
+
+		BeforeEach(func() {
+			ctrlBuf = &bytes.Buffer{}
+			str := mockquic.NewMockStream(mockCtrl)
+			str.EXPECT().Write(gomock.Any()).DoAndReturn(ctrlBuf.Write).AnyTimes()
+			conn = newServerConn(nil, str)
+		})
+
+		expectGoAway := func(id uint64) {
+			f, err := parseNextFrame(ctrlBuf)
+			ExpectWithOffset(1, err).ToNot(HaveOccurred())
+			ExpectWithOffset(1, f).To(Equal(&goAwayFrame{ID: id}))
+		}
+
+		It("sends a GOAWAY frame with stream ID 0, if no requests were received", func() {
+			conn.goAway()
+			expectGoAway(0)
+			Expect(conn.idle).To(BeClosed())
+			Expect(conn.startRequest(0)).To(BeFalse())
+		})
+
+		It("processes requests received before the GOAWAY frame", func() {
+			Expect(conn.startRequest(0)).To(BeTrue())
+			Expect(conn.startRequest(8)).To(BeTrue())
+			conn.endRequest()
+			conn.goAway()
+			expectGoAway(12)
+			Expect(conn.idle).ToNot(BeClosed())
+			// a stream that was opened before the GOAWAY frame was sent, but accepted later
+			Expect(conn.startRequest(4)).To(BeTrue())
+			Expect(conn.startRequest(12)).To(BeFalse())
+			conn.endRequest()
+			Expect(conn.idle).ToNot(BeClosed())
+			conn.endRequest()
+			Expect(conn.idle).To(BeClosed())
+		})
+
+		It("only sends a single GOAWAY frame", func() {
+			conn.goAway()
+			conn.goAway()
+			expectGoAway(0)
+			Expect(ctrlBuf.Len()).To(BeZero())
+		})
+	})
+
+	Context("using a server", func() {
+		var (
+			server      *Server
+			conn        *net.UDPConn
+			rt          *RoundTripper
+			url         string
+			handlerChan chan struct{} // receives a value when the handler is called
+			unblock     chan struct{}
+		)
+
+		BeforeEach(func() {
+			handlerChan = make(chan struct{}, 10)
+			unblock = make(chan struct{})
+			mux := http.NewServeMux()
+			mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+				handlerChan <- struct{}{}
+				<-unblock
+				w.Write([]byte("foobar"))
+			})
+			server = &Server{Server: &http.Server{Handler: mux, TLSConfig: testdata.GetTLSConfig()}}
+			var err error
+			conn, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+			Expect(err).ToNot(HaveOccurred())
+			go server.Serve(conn)
+			url = fmt.Sprintf("https://localhost:%d/slow", conn.LocalAddr().(*net.UDPAddr).Port)
+			rt = &RoundTripper{
+				TLSClientConfig: &tls.Config{RootCAs: testdata.GetRootCA()},
+				QuicConfig:      &quic.Config{Versions: []quic.VersionNumber{quic.VersionDraft29}},
+			}
+		})
+
+		AfterEach(func() {
+			rt.Close()
+			server.Close()
+			conn.Close()
+		})
+
+		startRequest := func() <-chan *http.Response {
+			rspChan := make(chan *http.Response, 1)
+			go func() {
+				defer GinkgoRecover()
+				req, err := http.NewRequest(http.MethodGet, url, nil)
+				Expect(err).ToNot(HaveOccurred())
+				rsp, err := rt.RoundTrip(req)
+				if err != nil {
+					close(rspChan)
+					return
+				}
+				rspChan <- rsp
+			}()
+			Eventually(handlerChan).Should(Receive())
+			return rspChan
+		}
+
+		It("lets active requests complete", func() {
+			rspChan := startRequest()
+			done := make(chan error, 1)
+			go func() { done <- server.Shutdown(context.Background()) }()
+			Consistently(done, scaleDuration(50*time.Millisecond)).ShouldNot(Receive())
+
+			// new requests on the same connection are rejected
+			req, err := http.NewRequest(http.MethodGet, url, nil)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = rt.RoundTrip(req)
+			Expect(err).To(HaveOccurred())
+			Consistently(handlerChan).ShouldNot(Receive())
+
+			close(unblock)
+			var rsp *http.Response
+			Eventually(rspChan).Should(Receive(&rsp))
+			Expect(rsp).ToNot(BeNil())
+			data, err := ioutil.ReadAll(rsp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal([]byte("foobar")))
+			// the client doesn't close the connection, so the server waits for the drain period
+			Eventually(done, shutdownDrainPeriod+scaleDuration(time.Second)).Should(Receive(BeNil()))
+		})
+
+		It("closes the server when the context expires", func() {
+			defer close(unblock)
+			rspChan := startRequest()
+			ctx, cancel := context.WithTimeout(context.Background(), scaleDuration(50*time.Millisecond))
+			defer cancel()
+			Expect(server.Shutdown(ctx)).To(MatchError(context.DeadlineExceeded))
+			Eventually(rspChan).Should(BeClosed())
+		})
+	})
+})
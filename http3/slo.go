@@ -0,0 +1,192 @@
+package http3
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lucas-clemente/quic-go"
+)
+
+const (
+	// sloCheckInterval is the interval at which the throughput of a response is checked against its SLO.
+	sloCheckInterval = 50 * time.Millisecond
+	// defaultSLOThroughputGracePeriod is used when SLO.ThroughputGracePeriod is not set.
+	defaultSLOThroughputGracePeriod = time.Second
+)
+
+// An SLO is a service level objective for a response.
+// If the server can't meet the SLO, the request stream is reset with H3_REQUEST_CANCELLED,
+// and the violation is reported to Server.OnSLOViolation.
+// The SLO is met once the handler returns and the response was passed to the QUIC stream.
+type SLO struct {
+	// MaxDuration is the maximum time between receiving the request and sending the complete response.
+	// If zero, the duration is not limited.
+	MaxDuration time.Duration
+	// MinThroughput is the minimum average rate (in bytes/s) at which the response body is sent,
+	// measured from the time the SLO was set.
+	// If zero, the throughput is not checked.
+	MinThroughput uint64
+	// ThroughputGracePeriod is the time after setting the SLO during which the throughput is not checked.
+	// This gives the congestion controller the time to ramp up.
+	// If zero, a default value of 1 second is used.
+	ThroughputGracePeriod time.Duration
+}
+
+func (s SLO) throughputGracePeriod() time.Duration {
+	if s.ThroughputGracePeriod == 0 {
+		return defaultSLOThroughputGracePeriod
+	}
+	return s.ThroughputGracePeriod
+}
+
+// SLOViolationReason is the reason why an SLO was violated.
+type SLOViolationReason uint8
+
+const (
+	// SLOViolationDuration means that the response wasn't sent within the SLO.MaxDuration.
+	SLOViolationDuration SLOViolationReason = iota + 1
+	// SLOViolationThroughput means that the response body was sent slower than the SLO.MinThroughput.
+	SLOViolationThroughput
+)
+
+func (r SLOViolationReason) String() string {
+	switch r {
+	case SLOViolationDuration:
+		return "duration"
+	case SLOViolationThroughput:
+		return "throughput"
+	default:
+		return "unknown reason"
+	}
+}
+
+// An SLOViolation describes a response that violated its SLO.
+type SLOViolation struct {
+	SLO    SLO
+	Reason SLOViolationReason
+	// Elapsed is the time since the request was received.
+	Elapsed time.Duration
+	// BytesWritten is the number of bytes of the response body written by the handler.
+	BytesWritten uint64
+}
+
+// An SLOSetter allows handlers to declare an SLO for the response.
+// It is implemented by the http.ResponseWriter passed to server handlers.
+// Calling SetSLO again replaces the previous SLO.
+type SLOSetter interface {
+	SetSLO(SLO)
+}
+
+// sloEnforcer checks that a response meets its SLO.
+type sloEnforcer struct {
+	bytesWritten uint64 // accessed atomically, first field for 64-bit alignment
+
+	mutex    sync.Mutex
+	stopped  bool          // set when the response was sent, or the SLO was violated
+	stopChan chan struct{} // closed when the SLO is replaced, or when the enforcer is stopped
+
+	received    time.Time // when the request was received
+	onViolation func(SLOViolation)
+	str         quic.Stream
+}
+
+func newSLOEnforcer(str quic.Stream, received time.Time, onViolation func(SLOViolation)) *sloEnforcer {
+	return &sloEnforcer{
+		str:         str,
+		received:    received,
+		onViolation: onViolation,
+	}
+}
+
+func (e *sloEnforcer) addBytes(n int) {
+	atomic.AddUint64(&e.bytesWritten, uint64(n))
+}
+
+func (e *sloEnforcer) setSLO(slo SLO) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.stopped {
+		return
+	}
+	if e.stopChan != nil {
+		close(e.stopChan)
+	}
+	e.stopChan = make(chan struct{})
+	go e.run(slo, time.Now(), atomic.LoadUint64(&e.bytesWritten), e.stopChan)
+}
+
+// stop is called when the response was sent.
+func (e *sloEnforcer) stop() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.stopped {
+		return
+	}
+	e.stopped = true
+	if e.stopChan != nil {
+		close(e.stopChan)
+	}
+}
+
+func (e *sloEnforcer) run(slo SLO, start time.Time, startBytes uint64, stopChan <-chan struct{}) {
+	var deadline <-chan time.Time
+	if slo.MaxDuration > 0 {
+		timer := time.NewTimer(time.Until(e.received.Add(slo.MaxDuration)))
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	var check <-chan time.Time
+	if slo.MinThroughput > 0 {
+		ticker := time.NewTicker(sloCheckInterval)
+		defer ticker.Stop()
+		check = ticker.C
+	}
+
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-deadline:
+			e.violate(slo, SLOViolationDuration, stopChan)
+			return
+		case now := <-check:
+			elapsed := now.Sub(start)
+			if elapsed < slo.throughputGracePeriod() {
+				continue
+			}
+			sent := atomic.LoadUint64(&e.bytesWritten) - startBytes
+			if float64(sent)/elapsed.Seconds() < float64(slo.MinThroughput) {
+				e.violate(slo, SLOViolationThroughput, stopChan)
+				return
+			}
+		}
+	}
+}
+
+func (e *sloEnforcer) violate(slo SLO, reason SLOViolationReason, stopChan <-chan struct{}) {
+	e.mutex.Lock()
+	// Check that the response wasn't sent (or the SLO replaced) in the meantime.
+	select {
+	case <-stopChan:
+		e.mutex.Unlock()
+		return
+	default:
+	}
+	e.stopped = true
+	close(e.stopChan)
+	e.mutex.Unlock()
+
+	e.str.CancelWrite(quic.StreamErrorCode(ErrCodeRequestCanceled))
+	e.str.CancelRead(quic.StreamErrorCode(ErrCodeRequestCanceled))
+	if e.onViolation != nil {
+		e.onViolation(SLOViolation{
+			SLO:          slo,
+			Reason:       reason,
+			Elapsed:      time.Since(e.received),
+			BytesWritten: atomic.LoadUint64(&e.bytesWritten),
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package http3
+
+import (
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lucas-clemente/quic-go"
+	mockquic "github.com/lucas-clemente/quic-go/internal/mocks/quic"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SLOs", func() {
+	var (
+		str        *mockquic.MockStream
+		enforcer   *sloEnforcer
+		violations chan SLOViolation
+	)
+
+	BeforeEach(func() {
+		str = mockquic.NewMockStream(mockCtrl)
+		violations = make(chan SLOViolation, 1)
+		enforcer = newSLOEnforcer(str, time.Now(), func(v SLOViolation) { violations <- v })
+	})
+
+	expectReset := func() {
+		str.EXPECT().CancelWrite(quic.StreamErrorCode(ErrCodeRequestCanceled))
+		str.EXPECT().CancelRead(quic.StreamErrorCode(ErrCodeRequestCanceled))
+	}
+
+	It("resets the stream when the response takes too long", func() {
+		expectReset()
+		slo := SLO{MaxDuration: scaleDuration(50 * time.Millisecond)}
+		enforcer.setSLO(slo)
+		enforcer.addBytes(1337)
+		var v SLOViolation
+		Eventually(violations).Should(Receive(&v))
+		Expect(v.SLO).To(Equal(slo))
+		Expect(v.Reason).To(Equal(SLOViolationDuration))
+		Expect(v.Elapsed).To(BeNumerically(">=", slo.MaxDuration))
+		Expect(v.BytesWritten).To(BeEquivalentTo(1337))
+		// stopping after the violation is a no-op
+		enforcer.stop()
+	})
+
+	It("doesn't reset the stream when the response was sent in time", func() {
+		enforcer.setSLO(SLO{MaxDuration: scaleDuration(50 * time.Millisecond)})
+		enforcer.stop()
+		Consistently(violations, scaleDuration(100*time.Millisecond)).ShouldNot(Receive())
+	})
+
+	It("resets the stream when the response is sent too slowly", func() {
+		expectReset()
+		enforcer.setSLO(SLO{MinThroughput: 1e6, ThroughputGracePeriod: scaleDuration(50 * time.Millisecond)})
+		enforcer.addBytes(100)
+		var v SLOViolation
+		Eventually(violations).Should(Receive(&v))
+		Expect(v.Reason).To(Equal(SLOViolationThroughput))
+		Expect(v.BytesWritten).To(BeEquivalentTo(100))
+	})
+
+	It("doesn't check the throughput during the grace period", func() {
+		enforcer.setSLO(SLO{MinThroughput: 1e6, ThroughputGracePeriod: scaleDuration(200 * time.Millisecond)})
+		Consistently(violations, scaleDuration(100*time.Millisecond)).ShouldNot(Receive())
+		enforcer.stop()
+	})
+
+	It("doesn't reset the stream when the throughput is high enough", func() {
+		enforcer.setSLO(SLO{MinThroughput: 1000, ThroughputGracePeriod: scaleDuration(20 * time.Millisecond)})
+		enforcer.addBytes(1e9)
+		Consistently(violations, scaleDuration(150*time.Millisecond)).ShouldNot(Receive())
+		enforcer.stop()
+	})
+
+	It("replaces the SLO", func() {
+		enforcer.setSLO(SLO{MaxDuration: scaleDuration(50 * time.Millisecond)})
+		enforcer.setSLO(SLO{})
+		Consistently(violations, scaleDuration(100*time.Millisecond)).ShouldNot(Receive())
+		enforcer.stop()
+	})
+
+	It("doesn't set an SLO after the response was sent", func() {
+		enforcer.stop()
+		enforcer.setSLO(SLO{MaxDuration: time.Nanosecond})
+		Consistently(violations, scaleDuration(50*time.Millisecond)).ShouldNot(Receive())
+	})
+
+	It("counts the bytes written to the response writer", func() {
+		str.EXPECT().Write(gomock.Any()).DoAndReturn(func(b []byte) (int, error) { return len(b), nil }).AnyTimes()
+		rw := newResponseWriter(nil, str, utils.DefaultLogger)
+		rw.slo = enforcer
+		expectReset()
+		rw.SetSLO(SLO{MaxDuration: scaleDuration(50 * time.Millisecond)})
+		_, err := rw.Write([]byte("foobar"))
+		Expect(err).ToNot(HaveOccurred())
+		var v SLOViolation
+		Eventually(violations).Should(Receive(&v))
+		Expect(v.BytesWritten).To(BeEquivalentTo(6))
+	})
+})
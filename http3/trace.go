@@ -0,0 +1,98 @@
+package http3
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http/httptrace"
+	"sync"
+)
+
+// The functions in this file call the callbacks of a httptrace.ClientTrace, if the trace and the respective callback are set.
+// The events of establishing a connection are only reported to the request that triggered the dial.
+
+var errHandshakeAborted = errors.New("http3: connection closed before the handshake completed")
+
+func traceDNSStart(trace *httptrace.ClientTrace, host string) {
+	if trace != nil && trace.DNSStart != nil {
+		trace.DNSStart(httptrace.DNSStartInfo{Host: host})
+	}
+}
+
+func traceDNSDone(trace *httptrace.ClientTrace, addrs []net.IPAddr, err error) {
+	if trace != nil && trace.DNSDone != nil {
+		trace.DNSDone(httptrace.DNSDoneInfo{Addrs: addrs, Err: err})
+	}
+}
+
+func traceConnectStart(trace *httptrace.ClientTrace, network, addr string) {
+	if trace != nil && trace.ConnectStart != nil {
+		trace.ConnectStart(network, addr)
+	}
+}
+
+func traceConnectDone(trace *httptrace.ClientTrace, network, addr string, err error) {
+	if trace != nil && trace.ConnectDone != nil {
+		trace.ConnectDone(network, addr, err)
+	}
+}
+
+func traceTLSHandshakeStart(trace *httptrace.ClientTrace) {
+	if trace != nil && trace.TLSHandshakeStart != nil {
+		trace.TLSHandshakeStart()
+	}
+}
+
+func traceTLSHandshakeDone(trace *httptrace.ClientTrace, state tls.ConnectionState, err error) {
+	if trace != nil && trace.TLSHandshakeDone != nil {
+		trace.TLSHandshakeDone(state, err)
+	}
+}
+
+func traceWroteHeaders(trace *httptrace.ClientTrace) {
+	if trace != nil && trace.WroteHeaders != nil {
+		trace.WroteHeaders()
+	}
+}
+
+func traceWroteRequest(trace *httptrace.ClientTrace, err error) {
+	if trace != nil && trace.WroteRequest != nil {
+		trace.WroteRequest(httptrace.WroteRequestInfo{Err: err})
+	}
+}
+
+func traceWait100Continue(trace *httptrace.ClientTrace) {
+	if trace != nil && trace.Wait100Continue != nil {
+		trace.Wait100Continue()
+	}
+}
+
+func traceGot100Continue(trace *httptrace.ClientTrace) {
+	if trace != nil && trace.Got100Continue != nil {
+		trace.Got100Continue()
+	}
+}
+
+// traceFirstResponseByte returns a reader that calls the GotFirstResponseByte callback when the first byte is read from r.
+func traceFirstResponseByte(trace *httptrace.ClientTrace, r io.Reader) io.Reader {
+	if trace == nil || trace.GotFirstResponseByte == nil {
+		return r
+	}
+	return &firstByteReader{Reader: r, onFirstByte: trace.GotFirstResponseByte}
+}
+
+type firstByteReader struct {
+	io.Reader
+
+	once        sync.Once
+	onFirstByte func()
+}
+
+func (r *firstByteReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		r.once.Do(r.onFirstByte)
+	}
+	return n, err
+}
@@ -0,0 +1,203 @@
+package http3
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/testdata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("httptrace", func() {
+	var (
+		server *Server
+		conn   *net.UDPConn
+		rt     *RoundTripper
+		port   int
+	)
+
+	BeforeEach(func() {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+			data, _ := ioutil.ReadAll(r.Body)
+			w.Write(data)
+		})
+		mux.HandleFunc("/early-hints", func(w http.ResponseWriter, r *http.Request) {
+			n, _ := strconv.Atoi(r.URL.Query().Get("n"))
+			w.Header().Set("Link", "</style.css>; rel=preload; as=style")
+			for i := 0; i < n; i++ {
+				w.WriteHeader(http.StatusEarlyHints)
+			}
+			w.Write([]byte("foobar"))
+		})
+		server = &Server{Server: &http.Server{Handler: mux, TLSConfig: testdata.GetTLSConfig()}}
+		var err error
+		conn, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).ToNot(HaveOccurred())
+		go server.Serve(conn)
+		port = conn.LocalAddr().(*net.UDPAddr).Port
+		rt = &RoundTripper{
+			TLSClientConfig: &tls.Config{RootCAs: testdata.GetRootCA()},
+			QuicConfig:      &quic.Config{Versions: []quic.VersionNumber{quic.VersionDraft29}},
+		}
+	})
+
+	AfterEach(func() {
+		rt.Close()
+		server.Close()
+		conn.Close()
+	})
+
+	type event struct {
+		name string
+		info interface{}
+	}
+
+	// newTrace returns a trace that records all events, and a function returning the recorded events
+	newTrace := func() (*httptrace.ClientTrace, func() []event) {
+		var mutex sync.Mutex
+		var events []event
+		record := func(name string, info interface{}) {
+			mutex.Lock()
+			events = append(events, event{name: name, info: info})
+			mutex.Unlock()
+		}
+		trace := &httptrace.ClientTrace{
+			DNSStart:             func(info httptrace.DNSStartInfo) { record("DNSStart", info) },
+			DNSDone:              func(info httptrace.DNSDoneInfo) { record("DNSDone", info) },
+			ConnectStart:         func(network, addr string) { record("ConnectStart", network+" "+addr) },
+			ConnectDone:          func(network, addr string, err error) { record("ConnectDone", err) },
+			TLSHandshakeStart:    func() { record("TLSHandshakeStart", nil) },
+			TLSHandshakeDone:     func(state tls.ConnectionState, err error) { record("TLSHandshakeDone", state) },
+			WroteHeaders:         func() { record("WroteHeaders", nil) },
+			WroteRequest:         func(info httptrace.WroteRequestInfo) { record("WroteRequest", info) },
+			GotFirstResponseByte: func() { record("GotFirstResponseByte", nil) },
+		}
+		return trace, func() []event {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return append([]event{}, events...)
+		}
+	}
+
+	names := func(events []event) []string {
+		var n []string
+		for _, e := range events {
+			n = append(n, e.name)
+		}
+		return n
+	}
+
+	request := func(trace *httptrace.ClientTrace, body []byte) {
+		req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("https://localhost:%d/echo", port), bytes.NewReader(body))
+		ExpectWithOffset(1, err).ToNot(HaveOccurred())
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+		rsp, err := rt.RoundTrip(req)
+		ExpectWithOffset(1, err).ToNot(HaveOccurred())
+		data, err := ioutil.ReadAll(rsp.Body)
+		ExpectWithOffset(1, err).ToNot(HaveOccurred())
+		ExpectWithOffset(1, data).To(Equal(body))
+	}
+
+	It("reports establishing the connection to the first request", func() {
+		trace, getEvents := newTrace()
+		request(trace, []byte("foobar"))
+		events := getEvents()
+		Expect(names(events)).To(ConsistOf(
+			"DNSStart",
+			"DNSDone",
+			"ConnectStart",
+			"TLSHandshakeStart",
+			"ConnectDone",
+			"TLSHandshakeDone",
+			"WroteHeaders",
+			"WroteRequest",
+			"GotFirstResponseByte",
+		))
+		// The handshake completes concurrently with the request being sent.
+		Expect(names(events)[:5]).To(Equal([]string{"DNSStart", "DNSDone", "ConnectStart", "TLSHandshakeStart", "ConnectDone"}))
+		Expect(events[0].info).To(Equal(httptrace.DNSStartInfo{Host: "localhost"}))
+		Expect(events[1].info.(httptrace.DNSDoneInfo).Err).ToNot(HaveOccurred())
+		Expect(events[2].info).To(Equal(fmt.Sprintf("udp 127.0.0.1:%d", port)))
+		Expect(events[4].info).To(BeNil())
+		for _, e := range events {
+			switch e.name {
+			case "TLSHandshakeDone":
+				Expect(e.info.(tls.ConnectionState).HandshakeComplete).To(BeTrue())
+			case "WroteRequest":
+				Expect(e.info).To(Equal(httptrace.WroteRequestInfo{}))
+			}
+		}
+
+		// The second request uses the existing connection.
+		trace, getEvents = newTrace()
+		request(trace, nil)
+		Expect(names(getEvents())).To(Equal([]string{"WroteHeaders", "WroteRequest", "GotFirstResponseByte"}))
+	})
+
+	Context("interim responses", func() {
+		earlyHints := func(n int, got1xx func(int, textproto.MIMEHeader) error) (*http.Response, error) {
+			req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("https://localhost:%d/early-hints?n=%d", port, n), nil)
+			Expect(err).ToNot(HaveOccurred())
+			trace := &httptrace.ClientTrace{Got1xxResponse: got1xx}
+			return rt.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+		}
+
+		It("reports 1xx responses", func() {
+			var codes []int
+			var headers []textproto.MIMEHeader
+			rsp, err := earlyHints(2, func(code int, header textproto.MIMEHeader) error {
+				codes = append(codes, code)
+				headers = append(headers, header)
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+			Expect(rsp.Header.Get("Link")).To(Equal("</style.css>; rel=preload; as=style"))
+			data, err := ioutil.ReadAll(rsp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal([]byte("foobar")))
+			Expect(codes).To(Equal([]int{http.StatusEarlyHints, http.StatusEarlyHints}))
+			Expect(headers[0].Get("Link")).To(Equal("</style.css>; rel=preload; as=style"))
+		})
+
+		It("aborts the request if Got1xxResponse returns an error", func() {
+			testErr := errors.New("test error")
+			_, err := earlyHints(1, func(int, textproto.MIMEHeader) error { return testErr })
+			Expect(err).To(MatchError(testErr))
+		})
+
+		It("limits the number of 1xx responses", func() {
+			_, err := earlyHints(max1xxResponses+1, func(int, textproto.MIMEHeader) error { return nil })
+			Expect(err).To(MatchError("http3: too many 1xx informational responses"))
+		})
+	})
+
+	It("reports a failed dial", func() {
+		conn.Close()
+		rt.QuicConfig.HandshakeIdleTimeout = scaleDuration(50 * time.Millisecond)
+		trace, getEvents := newTrace()
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("https://127.0.0.1:%d/echo", port), nil)
+		Expect(err).ToNot(HaveOccurred())
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+		_, err = rt.RoundTrip(req)
+		Expect(err).To(HaveOccurred())
+		events := getEvents()
+		// IP addresses don't need to be resolved
+		Expect(names(events)).To(Equal([]string{"ConnectStart", "TLSHandshakeStart", "ConnectDone", "TLSHandshakeDone"}))
+		Expect(events[2].info).To(MatchError(err))
+	})
+})
@@ -0,0 +1,95 @@
+package http3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/lucas-clemente/quic-go"
+)
+
+// A tunnel carries the data of a CONNECT request.
+// The data is sent in DATA frames on the request stream.
+type tunnel struct {
+	str quic.Stream
+	r   io.Reader // reads the payload of the DATA frames
+}
+
+var _ io.ReadWriteCloser = &tunnel{}
+
+func (t *tunnel) Read(b []byte) (int, error) {
+	return t.r.Read(b)
+}
+
+func (t *tunnel) Write(b []byte) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	buf := &bytes.Buffer{}
+	(&dataFrame{Length: uint64(len(b))}).Write(buf)
+	buf.Write(b)
+	if _, err := t.str.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// CloseWrite closes the sending side of the tunnel.
+// The peer reads an io.EOF, and can continue sending data.
+func (t *tunnel) CloseWrite() error {
+	return t.str.Close()
+}
+
+// Close closes both directions of the tunnel.
+func (t *tunnel) Close() error {
+	t.str.CancelRead(quic.StreamErrorCode(ErrCodeNoError))
+	return t.str.Close()
+}
+
+// AcceptTunnel accepts a CONNECT request.
+// It must be called from an HTTP/3 handler. It responds with status 200,
+// and returns the tunnel carried on the request stream.
+// The tunnel also implements CloseWrite() error, which closes the sending side only.
+// The handler may return before the tunnel is closed.
+func AcceptTunnel(w http.ResponseWriter, r *http.Request) (io.ReadWriteCloser, error) {
+	if r.Method != http.MethodConnect || isExtendedConnect(r) {
+		return nil, fmt.Errorf("http3: expected a CONNECT request, got %s", r.Method)
+	}
+	streamer, ok := w.(DataStreamer)
+	if !ok {
+		return nil, errors.New("http3: response writer doesn't implement DataStreamer")
+	}
+	w.WriteHeader(http.StatusOK)
+	return &tunnel{str: streamer.DataStream(), r: r.Body}, nil
+}
+
+// DialTunnel establishes a tunnel to target (host:port) through the HTTP/3 proxy at proxyAddr (host:port),
+// using the CONNECT method.
+// The context is used for establishing the tunnel. The tunnel is independent from the context once it was established.
+// The tunnel also implements CloseWrite() error, which closes the sending side only.
+func (r *RoundTripper) DialTunnel(ctx context.Context, proxyAddr, target string) (io.ReadWriteCloser, error) {
+	req := (&http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Scheme: "https", Host: proxyAddr},
+		Host:   target,
+		Header: http.Header{},
+	}).WithContext(ctx)
+	rsp, err := r.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if rsp.StatusCode < 200 || rsp.StatusCode >= 300 {
+		rsp.Body.Close()
+		return nil, fmt.Errorf("http3: CONNECT to %s failed with status %d", target, rsp.StatusCode)
+	}
+	b, ok := rsp.Body.(*body)
+	if !ok {
+		rsp.Body.Close()
+		return nil, errors.New("http3: unexpected response body")
+	}
+	return &tunnel{str: b.DataStream(), r: b}, nil
+}
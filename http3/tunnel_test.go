@@ -0,0 +1,114 @@
+package http3
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/testdata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CONNECT tunnels", func() {
+	var (
+		server    *Server
+		conn      *net.UDPConn
+		echoLn    net.Listener
+		rt        *RoundTripper
+		proxyAddr string
+	)
+
+	BeforeEach(func() {
+		var err error
+		echoLn, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+		go func() {
+			defer GinkgoRecover()
+			for {
+				c, err := echoLn.Accept()
+				if err != nil {
+					return
+				}
+				go func() {
+					defer c.Close()
+					io.Copy(c, c)
+				}()
+			}
+		}()
+
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Host == "forbidden:443" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			target, err := net.Dial("tcp", r.Host)
+			if err != nil {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			tun, err := AcceptTunnel(w, r)
+			if err != nil {
+				target.Close()
+				return
+			}
+			go func() {
+				io.Copy(target, tun)
+				target.(*net.TCPConn).CloseWrite()
+			}()
+			go func() {
+				defer target.Close()
+				defer tun.Close()
+				io.Copy(tun, target)
+			}()
+		})
+		server = &Server{Server: &http.Server{Handler: handler, TLSConfig: testdata.GetTLSConfig()}}
+		conn, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).ToNot(HaveOccurred())
+		go server.Serve(conn)
+		proxyAddr = fmt.Sprintf("localhost:%d", conn.LocalAddr().(*net.UDPAddr).Port)
+		rt = &RoundTripper{
+			TLSClientConfig: &tls.Config{RootCAs: testdata.GetRootCA()},
+			QuicConfig:      &quic.Config{Versions: []quic.VersionNumber{quic.VersionDraft29}},
+		}
+	})
+
+	AfterEach(func() {
+		Expect(rt.Close()).To(Succeed())
+		Expect(server.Close()).To(Succeed())
+		conn.Close()
+		echoLn.Close()
+	})
+
+	It("tunnels a TCP connection", func() {
+		tun, err := rt.DialTunnel(context.Background(), proxyAddr, echoLn.Addr().String())
+		Expect(err).ToNot(HaveOccurred())
+		defer tun.Close()
+		_, err = tun.Write([]byte("foo"))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = tun.Write([]byte("bar"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tun.(interface{ CloseWrite() error }).CloseWrite()).To(Succeed())
+		data, err := ioutil.ReadAll(tun)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("foobar"))
+	})
+
+	It("errors when the proxy refuses the tunnel", func() {
+		_, err := rt.DialTunnel(context.Background(), proxyAddr, "forbidden:443")
+		Expect(err).To(MatchError("http3: CONNECT to forbidden:443 failed with status 403"))
+	})
+
+	It("refuses to accept other requests", func() {
+		req, err := http.NewRequest(http.MethodGet, "https://quic.clemente.io", nil)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = AcceptTunnel(nil, req)
+		Expect(err).To(MatchError("http3: expected a CONNECT request, got GET"))
+	})
+})
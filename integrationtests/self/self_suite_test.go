@@ -350,27 +350,51 @@ func (t *connTracer) ReceivedTransportParameters(*logging.TransportParameters) {
 func (t *connTracer) RestoredTransportParameters(*logging.TransportParameters) {}
 func (t *connTracer) SentPacket(hdr *logging.ExtendedHeader, size logging.ByteCount, ack *logging.AckFrame, frames []logging.Frame) {
 }
+func (t *connTracer) ComposedPacket(logging.EncryptionLevel, logging.PacketNumber, *logging.PacketComposition) {
+}
 func (t *connTracer) ReceivedVersionNegotiationPacket(*logging.Header, []logging.VersionNumber) {}
 func (t *connTracer) ReceivedRetry(*logging.Header)                                             {}
 func (t *connTracer) ReceivedPacket(hdr *logging.ExtendedHeader, size logging.ByteCount, frames []logging.Frame) {
 }
+func (t *connTracer) SentDatagram([]byte)                                                           {}
+func (t *connTracer) ReceivedDatagram([]byte)                                                       {}
+func (t *connTracer) SentPlaintextPacket(logging.EncryptionLevel, []byte)                           {}
+func (t *connTracer) ReceivedPlaintextPacket(logging.EncryptionLevel, []byte)                       {}
+func (t *connTracer) SentDatagramFrame(logging.ByteCount)                                           {}
+func (t *connTracer) ReceivedDatagramFrame(logging.ByteCount)                                       {}
+func (t *connTracer) DroppedDatagramFrame(logging.ByteCount, logging.DatagramDropReason)            {}
+func (t *connTracer) SentFlowControlUpdate(logging.StreamID, logging.ByteCount, logging.ByteCount)  {}
+func (t *connTracer) ReceivedFlowControlUpdate(logging.StreamID, logging.ByteCount, time.Duration)  {}
+func (t *connTracer) BlockedByFlowControl(logging.StreamID, logging.ByteCount)                      {}
 func (t *connTracer) BufferedPacket(logging.PacketType)                                             {}
 func (t *connTracer) DroppedPacket(logging.PacketType, logging.ByteCount, logging.PacketDropReason) {}
 func (t *connTracer) UpdatedMetrics(rttStats *logging.RTTStats, cwnd, bytesInFlight logging.ByteCount, packetsInFlight int) {
 }
 
+func (t *connTracer) UpdatedCongestionMetrics(cwnd, ssthresh, bytesInFlight logging.ByteCount, pacingRate uint64) {
+}
+
 func (t *connTracer) AcknowledgedPacket(logging.EncryptionLevel, logging.PacketNumber) {}
 func (t *connTracer) LostPacket(logging.EncryptionLevel, logging.PacketNumber, logging.PacketLossReason) {
 }
+func (t *connTracer) DetectedPersistentCongestion(time.Duration)                         {}
+func (t *connTracer) DetectedSuspend(time.Duration)                                      {}
+func (t *connTracer) ClassifiedPacketLoss(logging.PacketNumber, bool)                    {}
+func (t *connTracer) PacerDelayedSend(logging.ByteCount, time.Time)                      {}
+func (t *connTracer) LimitedAckRanges(logging.EncryptionLevel, int, int)                 {}
 func (t *connTracer) UpdatedCongestionState(logging.CongestionState)                     {}
 func (t *connTracer) UpdatedPTOCount(value uint32)                                       {}
+func (t *connTracer) UpdatedMaxDatagramSize(logging.ByteCount)                           {}
 func (t *connTracer) UpdatedKeyFromTLS(logging.EncryptionLevel, logging.Perspective)     {}
 func (t *connTracer) UpdatedKey(generation logging.KeyPhase, remote bool)                {}
 func (t *connTracer) DroppedEncryptionLevel(logging.EncryptionLevel)                     {}
 func (t *connTracer) DroppedKey(logging.KeyPhase)                                        {}
+func (t *connTracer) Accepted0RTT()                                                      {}
+func (t *connTracer) Rejected0RTT(logging.ZeroRTTRejectReason)                           {}
 func (t *connTracer) SetLossTimer(logging.TimerType, logging.EncryptionLevel, time.Time) {}
 func (t *connTracer) LossTimerExpired(logging.TimerType, logging.EncryptionLevel)        {}
 func (t *connTracer) LossTimerCanceled()                                                 {}
+func (t *connTracer) SkippedPacketNumber(logging.PacketNumber)                           {}
 func (t *connTracer) Debug(string, string)                                               {}
 func (t *connTracer) Close()                                                             {}
 
@@ -48,30 +48,57 @@ func (t *customConnTracer) RestoredTransportParameters(*logging.TransportParamet
 func (t *customConnTracer) SentPacket(hdr *logging.ExtendedHeader, size logging.ByteCount, ack *logging.AckFrame, frames []logging.Frame) {
 }
 
+func (t *customConnTracer) ComposedPacket(logging.EncryptionLevel, logging.PacketNumber, *logging.PacketComposition) {
+}
+
 func (t *customConnTracer) ReceivedVersionNegotiationPacket(*logging.Header, []logging.VersionNumber) {
 }
 func (t *customConnTracer) ReceivedRetry(*logging.Header) {}
 func (t *customConnTracer) ReceivedPacket(hdr *logging.ExtendedHeader, size logging.ByteCount, frames []logging.Frame) {
 }
-func (t *customConnTracer) BufferedPacket(logging.PacketType) {}
+func (t *customConnTracer) SentDatagram([]byte)                                                {}
+func (t *customConnTracer) ReceivedDatagram([]byte)                                            {}
+func (t *customConnTracer) SentPlaintextPacket(logging.EncryptionLevel, []byte)                {}
+func (t *customConnTracer) ReceivedPlaintextPacket(logging.EncryptionLevel, []byte)            {}
+func (t *customConnTracer) SentDatagramFrame(logging.ByteCount)                                {}
+func (t *customConnTracer) ReceivedDatagramFrame(logging.ByteCount)                            {}
+func (t *customConnTracer) DroppedDatagramFrame(logging.ByteCount, logging.DatagramDropReason) {}
+func (t *customConnTracer) SentFlowControlUpdate(logging.StreamID, logging.ByteCount, logging.ByteCount) {
+}
+func (t *customConnTracer) ReceivedFlowControlUpdate(logging.StreamID, logging.ByteCount, time.Duration) {
+}
+func (t *customConnTracer) BlockedByFlowControl(logging.StreamID, logging.ByteCount) {}
+func (t *customConnTracer) BufferedPacket(logging.PacketType)                        {}
 func (t *customConnTracer) DroppedPacket(logging.PacketType, logging.ByteCount, logging.PacketDropReason) {
 }
 
 func (t *customConnTracer) UpdatedMetrics(rttStats *logging.RTTStats, cwnd, bytesInFlight logging.ByteCount, packetsInFlight int) {
 }
 
+func (t *customConnTracer) UpdatedCongestionMetrics(cwnd, ssthresh, bytesInFlight logging.ByteCount, pacingRate uint64) {
+}
+
 func (t *customConnTracer) AcknowledgedPacket(logging.EncryptionLevel, logging.PacketNumber) {}
 func (t *customConnTracer) LostPacket(logging.EncryptionLevel, logging.PacketNumber, logging.PacketLossReason) {
 }
+func (t *customConnTracer) DetectedPersistentCongestion(time.Duration)                         {}
+func (t *customConnTracer) DetectedSuspend(time.Duration)                                      {}
+func (t *customConnTracer) ClassifiedPacketLoss(logging.PacketNumber, bool)                    {}
+func (t *customConnTracer) PacerDelayedSend(logging.ByteCount, time.Time)                      {}
+func (t *customConnTracer) LimitedAckRanges(logging.EncryptionLevel, int, int)                 {}
 func (t *customConnTracer) UpdatedCongestionState(logging.CongestionState)                     {}
 func (t *customConnTracer) UpdatedPTOCount(value uint32)                                       {}
+func (t *customConnTracer) UpdatedMaxDatagramSize(logging.ByteCount)                           {}
 func (t *customConnTracer) UpdatedKeyFromTLS(logging.EncryptionLevel, logging.Perspective)     {}
 func (t *customConnTracer) UpdatedKey(generation logging.KeyPhase, remote bool)                {}
 func (t *customConnTracer) DroppedEncryptionLevel(logging.EncryptionLevel)                     {}
 func (t *customConnTracer) DroppedKey(logging.KeyPhase)                                        {}
+func (t *customConnTracer) Accepted0RTT()                                                      {}
+func (t *customConnTracer) Rejected0RTT(logging.ZeroRTTRejectReason)                           {}
 func (t *customConnTracer) SetLossTimer(logging.TimerType, logging.EncryptionLevel, time.Time) {}
 func (t *customConnTracer) LossTimerExpired(logging.TimerType, logging.EncryptionLevel)        {}
 func (t *customConnTracer) LossTimerCanceled()                                                 {}
+func (t *customConnTracer) SkippedPacketNumber(logging.PacketNumber)                           {}
 func (t *customConnTracer) Debug(string, string)                                               {}
 func (t *customConnTracer) Close()                                                             {}
 
@@ -7,8 +7,12 @@ import (
 	"net"
 	"time"
 
+	"github.com/lucas-clemente/quic-go/congestionext"
+	"github.com/lucas-clemente/quic-go/internal/ackhandler"
+	"github.com/lucas-clemente/quic-go/internal/congestion"
 	"github.com/lucas-clemente/quic-go/internal/handshake"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/lucas-clemente/quic-go/logging"
 )
 
@@ -52,6 +56,32 @@ type TokenStore interface {
 	Put(key string, token *ClientToken)
 }
 
+// CongestionState is the congestion state of a previous connection on the same path.
+// It is used by Careful Resume (draft-ietf-tsvwg-careful-resume) to jump-start new connections.
+type CongestionState struct {
+	// CongestionWindow is the congestion window (in bytes) at the end of the connection.
+	CongestionWindow uint64
+	// MinRTT is the minimum RTT measured on the connection.
+	MinRTT time.Duration
+	// SavedTime is the time when the state was saved.
+	SavedTime time.Time
+	// StartAlgo and CongestionAlgo are the algorithms used on the connection.
+	// If the algorithms are selected automatically, new connections reuse the algorithms chosen before.
+	StartAlgo      utils.StartAlgo
+	CongestionAlgo utils.CongestionAlgo
+}
+
+// A CongestionStateStore stores the congestion state of previous connections.
+type CongestionStateStore interface {
+	// Get returns the CongestionState associated with the given key.
+	// It returns nil when no state is found.
+	Get(key string) *CongestionState
+
+	// Put saves the CongestionState for the given key.
+	// It is called when a connection is closed.
+	Put(key string, state *CongestionState)
+}
+
 // Err0RTTRejected is the returned from:
 // * Open{Uni}Stream{Sync}
 // * Accept{Uni}Stream
@@ -135,6 +165,45 @@ type SendStream interface {
 	// some of the data was successfully written.
 	// A zero value for t means Write will not time out.
 	SetWriteDeadline(t time.Time) error
+	// Stats returns statistics about the data sent on this stream.
+	Stats() StreamStats
+	// SetPriority sets the priority of the stream.
+	// It is used to decide which stream's data is sent next, see StreamPriority.
+	SetPriority(StreamPriority)
+	// Priority returns the priority of the stream.
+	Priority() StreamPriority
+}
+
+// StreamPriority is the priority used to schedule the data of a stream.
+// It follows the model of the Extensible Priority Scheme for HTTP (RFC 9218).
+type StreamPriority struct {
+	// Urgency ranges from 0 (most urgent) to 7 (least urgent).
+	// Data of streams with a lower urgency is sent before data of streams with a higher urgency.
+	Urgency uint8
+	// Incremental streams of the same urgency share the available bandwidth (round-robin).
+	// Non-incremental streams are sent one after the other, in the order of their stream IDs.
+	// If streams of the same urgency have both, data of the non-incremental streams is sent first.
+	Incremental bool
+}
+
+// DefaultStreamPriority is the priority of new streams.
+// All streams share the available bandwidth, unless the priority is changed.
+var DefaultStreamPriority = StreamPriority{Urgency: 3, Incremental: true}
+
+// StreamStats are statistics about the data sent on a stream.
+type StreamStats struct {
+	// BytesSent is the number of bytes of stream data sent, not counting retransmissions.
+	BytesSent uint64
+	// BytesRetransmitted is the number of bytes of stream data that were retransmitted.
+	BytesRetransmitted uint64
+	// FlowControlBlocked is the time during which data couldn't be sent, because the stream or the connection was blocked by flow control.
+	FlowControlBlocked time.Duration
+	// CongestionBlocked is the time that Write was waiting for data to be sent, while not blocked by flow control.
+	// This is the time spent waiting for the congestion controller and the pacer, and for other streams to be sent.
+	CongestionBlocked time.Duration
+	// CompletionTime is the time from opening the stream until all data (including the FIN) was acknowledged by the peer.
+	// It is 0 until the stream was completed, and if sending was canceled.
+	CompletionTime time.Duration
 }
 
 // A Session is a QUIC connection between two peers.
@@ -143,6 +212,8 @@ type SendStream interface {
 // * TransportError: for errors triggered by the QUIC transport (in many cases a misbehaving peer)
 // * IdleTimeoutError: when the peer goes away unexpectedly (this is a net.Error timeout error)
 // * HandshakeTimeoutError: when the cryptographic handshake takes too long (this is a net.Error timeout error)
+// * FirstFlightTimeoutError: returned by the client, when the server doesn't respond in time (this is a net.Error timeout error)
+// * FallbackAdvisedError: returned by the client, when a UDP blackhole was detected (this is a net.Error timeout error)
 // * StatelessResetError: when we receive a stateless reset (this is a net.Error temporary error)
 // * VersionNegotiationError: returned by the client, when there's no version overlap between the peers
 type Session interface {
@@ -197,6 +268,20 @@ type Session interface {
 	// ReceiveMessage gets a message received in a datagram.
 	// See https://datatracker.ietf.org/doc/draft-pauly-quic-datagram/.
 	ReceiveMessage() ([]byte, error)
+	// SetApplicationLimited marks the session as application-limited, i.e. the application has no data to send,
+	// although the congestion controller would allow it (e.g. a video sender between two frames).
+	// While the session is application-limited, the congestion window isn't increased,
+	// since the acknowledgements don't show if the network could handle a larger window.
+	SetApplicationLimited(bool)
+	// SetMaxSendRate limits the rate (in bytes/s) at which the session sends data, independent of the congestion controller.
+	// It overrides Config.MaxSendRate. 0 removes the limit.
+	SetMaxSendRate(bytesPerSecond uint64)
+	// SetMaxReceiveRate limits the rate (in bytes/s) at which the application can read data from the streams of the session.
+	// It overrides Config.MaxReceiveRate. 0 removes the limit.
+	SetMaxReceiveRate(bytesPerSecond uint64)
+	// SetLabels sets labels that identify the session, e.g. the user ID or the tenant.
+	// The labels are reported by LookupConnectionID and RegisteredConnections.
+	SetLabels(map[string]string)
 }
 
 // An EarlySession is a session that is handshaking.
@@ -214,6 +299,92 @@ type EarlySession interface {
 	NextSession() Session
 }
 
+// An AckRangeEvictionPolicy selects the ACK ranges that are discarded when more than Config.MaxAckRanges ranges are tracked.
+type AckRangeEvictionPolicy = ackhandler.AckRangeEvictionPolicy
+
+const (
+	// AckRangeEvictionOldest discards the ranges with the lowest packet numbers.
+	AckRangeEvictionOldest = ackhandler.EvictOldestAckRanges
+	// AckRangeEvictionSmallest discards the ranges containing the fewest packets, starting with the oldest one.
+	// The range containing the largest packet number received is never discarded.
+	AckRangeEvictionSmallest = ackhandler.EvictSmallestAckRanges
+)
+
+// An RTTFilter selects how the RTT samples used by HyStart and HyStart++ are filtered.
+type RTTFilter = utils.RTTFilter
+
+const (
+	// RTTFilterNone uses every RTT sample as it is.
+	RTTFilterNone = utils.RTTFilterNone
+	// RTTFilterMedian uses the median of the most recent Config.RTTFilterWindow RTT samples.
+	RTTFilterMedian = utils.RTTFilterMedian
+	// RTTFilterKalman uses a Kalman filter, that limits the effect of a single RTT spike.
+	RTTFilterKalman = utils.RTTFilterKalman
+)
+
+// A ConnectionSummary summarizes a connection when it is closed, see Config.OnClose.
+type ConnectionSummary struct {
+	// Duration is the time from the creation of the session until it was closed.
+	Duration time.Duration
+	// BytesSent and BytesReceived count the UDP payload of all packets, including packets that were lost.
+	BytesSent     uint64
+	BytesReceived uint64
+	// PaddingBytesSent is the number of padding bytes sent (included in BytesSent),
+	// including the padding added according to Config.PaddingProfile.
+	PaddingBytesSent uint64
+	PacketsSent      uint64
+	PacketsLost      uint64
+	// LossRate is the fraction of the packets sent that were declared lost.
+	LossRate float64
+	// MaxCongestionWindow is the largest congestion window (in bytes) used to send a packet.
+	MaxCongestionWindow uint64
+	// Err is the reason the connection was closed.
+	// It is an ApplicationError with error code 0 if the connection was closed by calling Session.CloseWithError(0, "").
+	Err error
+}
+
+// A CongestionEvent is a reaction of the congestion controller to the network, see Config.OnCongestionEvent.
+type CongestionEvent = congestion.Event
+
+const (
+	// CongestionEventLoss means that the congestion window was reduced in response to a packet loss.
+	CongestionEventLoss = congestion.EventLoss
+	// CongestionEventPersistentCongestion means that the congestion window was reduced to the minimum,
+	// because persistent congestion was established.
+	CongestionEventPersistentCongestion = congestion.EventPersistentCongestion
+	// CongestionEventSlowStartExit means that the start algorithm (e.g. HyStart) exited slow start before a loss occurred.
+	CongestionEventSlowStartExit = congestion.EventSlowStartExit
+)
+
+// MinRTTAging configures how long the minimum RTT is trusted, and how a new minimum RTT is measured after it expired.
+// See Config.MinRTTExpiry, Config.MinRTTProbeDuration and Config.DisableMinRTTProbeDip.
+type MinRTTAging struct {
+	Expiry          time.Duration
+	ProbeDuration   time.Duration
+	DisableProbeDip bool
+}
+
+// An EmulationStep is a step of a network emulation profile, see Config.EmulationProfile.
+type EmulationStep struct {
+	Duration time.Duration
+	// Bandwidth is the rate (in bytes/s) at which packets are sent. If 0, the rate is not limited.
+	Bandwidth uint64
+	// Delay is the time by which every packet is held back before it is sent.
+	Delay time.Duration
+	// Jitter is the maximum random delay that is added to Delay.
+	// Since packets are not reordered, a packet is never sent before the packet queued before it.
+	Jitter time.Duration
+	// Loss is the probability (between 0 and 1) that a packet is dropped instead of being sent.
+	Loss float64
+}
+
+// An AckThinningStrategy decides if an ACK is sent after receiving an ack-eliciting application data packet.
+// See Config.AckThinning.
+type AckThinningStrategy = ackhandler.AckThinningStrategy
+
+// AckThinningInfo describes a received packet to an AckThinningStrategy.
+type AckThinningInfo = ackhandler.AckThinningInfo
+
 // Config contains all configuration data needed for a QUIC server or client.
 type Config struct {
 	// The QUIC versions that can be negotiated.
@@ -231,6 +402,22 @@ type Config struct {
 	// Specifically, if we don't receive any packet from the peer within this time, the connection attempt is aborted.
 	// If this value is zero, the timeout is set to 5 seconds.
 	HandshakeIdleTimeout time.Duration
+	// HandshakeTimeout is the maximum duration of the handshake.
+	// If the handshake doesn't complete within this time, the connection attempt fails with a HandshakeTimeoutError.
+	// If this value is zero, the timeout is set to twice the HandshakeIdleTimeout, but at least 10 seconds.
+	HandshakeTimeout time.Duration
+	// FirstFlightTimeout is the maximum duration the client waits for the first packet from the server.
+	// If no packet is received within this time, the connection attempt fails with a FirstFlightTimeoutError.
+	// This allows distinguishing a server that is not reachable (e.g. because UDP is blocked) from a slow handshake.
+	// If this value is zero, only the HandshakeIdleTimeout applies. It is ignored by servers.
+	FirstFlightTimeout time.Duration
+	// EnableBlackholeDetection makes the client detect paths that drop all UDP packets.
+	// If no packet is received from the server after 3 probe timeouts (PTOs), during which the Initial is retransmitted,
+	// the connection attempt fails with a FallbackAdvisedError.
+	// With the default initial RTT, this takes about 2 seconds, much shorter than the handshake timeout.
+	// Applications can use this error to fall back to HTTP/1.1 or HTTP/2 over TCP.
+	// It is ignored by servers.
+	EnableBlackholeDetection bool
 	// MaxIdleTimeout is the maximum duration that may pass without any incoming network activity.
 	// The actual value for the idle timeout is the minimum of this value and the peer's.
 	// This value only applies after the handshake has completed.
@@ -250,6 +437,217 @@ type Config struct {
 	// The key used to store tokens is the ServerName from the tls.Config, if set
 	// otherwise the token is associated with the server's IP address.
 	TokenStore TokenStore
+	// The CongestionStateStore stores the congestion window and the RTT at the end of a connection.
+	// If set, new connections to the same peer address use Careful Resume to reuse these values,
+	// after validating that the RTT of the path didn't change significantly.
+	// Saved values are only used for up to 1 hour.
+	// Servers recognize returning clients by the token the client received in a NEW_TOKEN frame,
+	// and fall back to the client's IP address for clients that don't present a token.
+	CongestionStateStore CongestionStateStore
+	// InitialCongestionWindow is the initial congestion window, in packets.
+	// Values above the maximum congestion window are invalid.
+	// If not set, it defaults to 32 packets.
+	InitialCongestionWindow int
+	// MaxCongestionWindow is the maximum congestion window, in packets.
+	// It can be lowered to cap the sending rate on shared links, or raised for paths with a large bandwidth-delay product.
+	// If not set, it defaults to 10000 packets.
+	MaxCongestionWindow int
+	// MinCongestionWindow is the minimum congestion window, in packets.
+	// The congestion window is reduced to this value after a retransmission timeout.
+	// Values above the maximum congestion window are invalid.
+	// If not set, it defaults to 2 packets.
+	MinCongestionWindow int
+	// CongestionControl creates the congestion controller of a connection, replacing the built-in Cubic / Reno sender.
+	// It allows using congestion controllers implemented outside of quic-go, see the congestionext package.
+	// The other congestion control options (including MaxSendRate and EmulationProfile) only apply to the built-in sender.
+	CongestionControl func(clock congestionext.Clock, rttStats *congestionext.RTTStats, initialMaxDatagramSize congestionext.ByteCount) congestionext.SendAlgorithmWithDebugInfos
+	// PacketNumberLength is the minimum length (in bytes) used to encode packet numbers.
+	// Valid values are 1, 2, 3 and 4. A longer encoding is used if necessary.
+	// If not set, the shortest possible encoding is used.
+	PacketNumberLength int
+	// DisablePacketNumberSkipping disables the skipping of packet numbers.
+	// By default, packet numbers are skipped at random intervals to detect optimistic ACK attacks.
+	DisablePacketNumberSkipping bool
+	// PacketNumberSkipPeriod makes packet number skipping deterministic:
+	// One packet number is skipped after every PacketNumberSkipPeriod packets.
+	// If not set, packet numbers are skipped at random, with an exponentially increasing period.
+	PacketNumberSkipPeriod uint64
+	// MaxAckRanges is the maximum number of ACK ranges tracked for every packet number space.
+	// Packets in ranges that are discarded according to the AckRangeEviction policy are not acknowledged anymore.
+	// If not set, it defaults to 32.
+	MaxAckRanges int
+	// MaxAckFrameSize is the maximum size (in bytes) of the ACK frames sent.
+	// Ranges that don't fit into the frame are omitted, starting with the lowest one.
+	// Values above 1000 bytes are invalid. If not set, it defaults to 1000 bytes.
+	MaxAckFrameSize int
+	// AckRangeEviction selects the ACK ranges discarded when more than MaxAckRanges ranges are tracked.
+	// Discarded and omitted ranges are reported to the ConnectionTracer.
+	// If not set, the oldest ranges are discarded.
+	AckRangeEviction AckRangeEvictionPolicy
+	// AckThinning is a research hook to experiment with reducing the number of ACKs sent (ACK thinning).
+	// It replaces the default of acknowledging every second ack-eliciting packet.
+	// To avoid triggering the peer's timers, ACKs are never delayed by more than the max_ack_delay,
+	// nor for more than 64 ack-eliciting packets, and packets received out of order are acknowledged immediately.
+	// It is only available when quic-go is built with the quic_research build tag.
+	AckThinning AckThinningStrategy
+	// RTTFilter selects the filter applied to the RTT samples used by HyStart and HyStart++.
+	// Filtering prevents single RTT spikes (e.g. on WiFi links) from making them exit slow start too early.
+	// If not set, the RTT samples are not filtered.
+	RTTFilter RTTFilter
+	// RTTFilterWindow is the number of RTT samples used by the RTTFilterMedian filter.
+	// If not set, it defaults to 5.
+	RTTFilterWindow int
+	// HyStartRTTSamples is the number of RTT samples per round that HyStart and HyStart++ take
+	// before checking for an RTT increase (N_RTT_SAMPLE in RFC 9406).
+	// If not set, it defaults to 8.
+	HyStartRTTSamples int
+	// HyStartMinRTTThreshold and HyStartMaxRTTThreshold clamp the RTT increase that makes HyStart exit slow start,
+	// and HyStart++ enter Conservative Slow Start (MIN_RTT_THRESH and MAX_RTT_THRESH in RFC 9406).
+	// If not set, they default to 4ms and 16ms.
+	HyStartMinRTTThreshold time.Duration
+	HyStartMaxRTTThreshold time.Duration
+	// HyStartLowWindow is the minimum congestion window (in packets) for HyStart to exit slow start.
+	// It doesn't apply to HyStart++.
+	// If not set, it defaults to 16 packets.
+	HyStartLowWindow int
+	// HyStartCSSGrowthDivisor is the factor by which HyStart++ slows down the growth of the congestion window
+	// during Conservative Slow Start (CSS_GROWTH_DIVISOR in RFC 9406).
+	// If not set, it defaults to 4.
+	HyStartCSSGrowthDivisor int
+	// RenoBeta is the factor by which Reno reduces the congestion window after a loss.
+	// Valid values are between 0 and 1 (exclusive). If not set, it defaults to 0.7.
+	RenoBeta float64
+	// CubicC is the scaling constant C of Cubic (RFC 8312). If not set, it defaults to 0.4.
+	CubicC float64
+	// CubicBeta is the factor by which Cubic reduces the congestion window after a loss (beta_cubic in RFC 8312).
+	// Valid values are between 0 and 1 (exclusive). If not set, it defaults to 0.7.
+	CubicBeta float64
+	// DisableCubicTCPFriendliness disables the TCP-friendly region of Cubic (Section 4.2 of RFC 8312).
+	// The congestion window then strictly follows the cubic function.
+	DisableCubicTCPFriendliness bool
+	// EnableCongestionWindowValidation enables Congestion Window Validation (RFC 7661).
+	// When the sender is application-limited or idle, the congestion window is halved for every
+	// CongestionWindowValidationPeriod, but not below the initial congestion window.
+	// This prevents a connection that was idle for a long time from sending a burst using a stale congestion window.
+	EnableCongestionWindowValidation bool
+	// CongestionWindowValidationPeriod is the non-validated period of Congestion Window Validation.
+	// If not set, the PTO is used.
+	CongestionWindowValidationPeriod time.Duration
+	// EnableLossDifferentiation makes the congestion controller distinguish random losses from congestion losses.
+	// Losses that happen while the RTT is close to the min RTT, and not increasing, are classified as random losses,
+	// e.g. caused by interference on a wireless link, and don't reduce the congestion window.
+	// The classification of every loss is reported to the ConnectionTracer.
+	EnableLossDifferentiation bool
+	// MinRTTExpiry is the time after which the minimum RTT expires.
+	// The sender then briefly reduces the data in flight to the minimum congestion window, and measures a new minimum RTT.
+	// This prevents delay-based algorithms (e.g. HyStart++) from using a stale minimum RTT after the path RTT increased.
+	// If not set, the minimum RTT never expires.
+	MinRTTExpiry time.Duration
+	// MinRTTProbeDuration is the duration of the probe for a new minimum RTT, after the minimum RTT expired.
+	// The probe lasts at least one smoothed RTT.
+	// If not set, it defaults to 200ms.
+	MinRTTProbeDuration time.Duration
+	// DisableMinRTTProbeDip keeps the data in flight unchanged while probing for a new minimum RTT.
+	// The minimum RTT is then simply re-measured during the probe, which is less accurate if there's a queue at the bottleneck.
+	DisableMinRTTProbeDip bool
+	// MinRTTAgingPerStartAlgo overrides MinRTTExpiry, MinRTTProbeDuration and DisableMinRTTProbeDip
+	// for sessions that use a specific start algorithm.
+	MinRTTAgingPerStartAlgo map[utils.StartAlgo]MinRTTAging
+	// PacerMaxBurst is the maximum number of packets that the pacer sends in a single burst.
+	// Small bursts reduce the queueing delay, large bursts reduce the number of syscalls and timer wake-ups.
+	// Bursts are never smaller than the data that can be sent during the pacing granularity.
+	// If not set, it defaults to 10 packets.
+	PacerMaxBurst int
+	// PacingGain is the factor by which the pacing rate exceeds the bandwidth estimate of the congestion controller.
+	// Values above 1 prevent RTT variations from leaving the congestion window under-utilized.
+	// If not set, it defaults to 1.25.
+	PacingGain float64
+	// PacingGranularity is the minimum time between two wake-ups of the pacing timer.
+	// All packets that become due within this time are sent in a single burst.
+	// At high rates, a coarser granularity saves CPU time spent on timer wake-ups, at the cost of larger bursts.
+	// If not set, it defaults to 1ms.
+	PacingGranularity time.Duration
+	// DisablePacing disables the pacer. Packets are sent as soon as the congestion window allows it.
+	// This can make sense for experiments, and on links with a very low RTT, where the pacing timers are too costly.
+	DisablePacing bool
+	// EnableKernelPacing hands pacing to the kernel on Linux.
+	// Packets are passed to the socket ahead of time, with their pacing deadline as the transmit time (SO_TXTIME),
+	// such that the session doesn't wait for the pacing timer before sending every packet.
+	// This requires the fq qdisc on the outgoing interface. Without it, the transmit time is ignored and packets are not paced.
+	// If the socket doesn't support SO_TXTIME, the user-space pacer is used.
+	EnableKernelPacing bool
+	// MaxSendRate is the maximum rate (in bytes/s) at which each session sends data.
+	// It is enforced by a token bucket, independent of the congestion controller.
+	// Servers can limit individual clients by returning a Config with a MaxSendRate from GetRoute,
+	// or by calling Session.SetMaxSendRate.
+	// If not set, the send rate is not limited.
+	MaxSendRate uint64
+	// MaxReceiveRate is the maximum rate (in bytes/s) at which the application can read data from the streams of each session.
+	// Reads block until the rate allows reading more data, which delays the flow control window updates sent to the peer.
+	// This emulates a slow receiver, e.g. to study how the peer's congestion controller behaves when it is flow-control limited.
+	// It can be changed for a running session by calling Session.SetMaxReceiveRate.
+	// If not set, the receive rate is not limited.
+	MaxReceiveRate uint64
+	// OnRTTUpdate is called for every RTT sample, with the smoothed RTT, the latest RTT sample and the minimum RTT.
+	// It allows applications to adapt to the path (e.g. by changing the bitrate of a video) without implementing a logging.Tracer.
+	// It is called from the session's run loop, and must not block.
+	OnRTTUpdate func(smoothed, latest, min time.Duration)
+	// OnCongestionEvent is called when the congestion controller reduces the congestion window in response to congestion,
+	// and when it exits slow start. The congestion window is passed in bytes.
+	// It is called from the session's run loop, and must not block.
+	OnCongestionEvent func(event CongestionEvent, congestionWindow uint64)
+	// OnClose is called when the session is closed, with a summary of the connection.
+	// This is much cheaper than keeping a logging.Tracer attached to learn how a connection ended.
+	// It is called from the session's run loop, and must not block.
+	OnClose func(ConnectionSummary)
+	// SuspendThreshold is the duration for which the system has to be suspended (e.g. when the lid of a laptop is closed)
+	// until the session notices the suspension.
+	// After a suspension, the loss detection and idle timers are shifted by the time spent suspended,
+	// so that packets aren't declared lost and the connection doesn't time out immediately after the system wakes up.
+	// If this value is zero, a threshold of 5 seconds is used. If it is negative, suspensions are not detected.
+	SuspendThreshold time.Duration
+	// ProbeAfterSuspend makes the session probe the path after a suspension, before resuming full-rate sending.
+	// The congestion controller is restarted from the initial congestion window, since the path might have changed in the meantime.
+	ProbeAfterSuspend bool
+	// EmulationProfile emulates a network whose bandwidth and latency change over time, e.g. following a recorded LTE trace.
+	// This allows emulating a network on machines where netem can't be configured.
+	// The profile starts when the session is created, and is repeated after its last step.
+	// The bandwidth is enforced by the pacer, in addition to MaxSendRate.
+	// The delay is added to every packet this endpoint sends, without reordering packets, so it increases the RTT by that delay.
+	// Random jitter and packet loss are applied to the packets this endpoint sends as well.
+	EmulationProfile []EmulationStep
+	// MaxSessions is the maximum number of sessions that a server handles at the same time.
+	// When this limit is reached, new connection attempts are refused with a CONNECTION_REFUSED error.
+	// The run loops of the sessions are run on MaxSessions goroutines that are started with the listener.
+	// If not set, every session runs on its own goroutine, and the number of sessions is not limited.
+	// This option is only valid for the server.
+	MaxSessions int
+	// SessionRunLoopAffinity is called on each of the MaxSessions goroutines that run the sessions' run loops,
+	// with the index of the goroutine. The goroutine is locked to an OS thread,
+	// so this callback can be used to set the CPU affinity of that thread, e.g. using unix.SchedSetaffinity on Linux.
+	// Only the run loop is pinned: the other goroutines of a session, e.g. the ones sending packets
+	// and running the TLS handshake, as well as the application's stream I/O, run on any thread.
+	// It is only used if MaxSessions is set.
+	SessionRunLoopAffinity func(slot int)
+	// HandshakeSigner is the signer used for the private-key operations of the server's certificate.
+	// It must also be set as the PrivateKey of the certificate in the tls.Config.
+	// If set, the server refuses new connections while too many signing operations are pending.
+	// This option is only valid for the server.
+	HandshakeSigner *HandshakeSigner
+	// SessionTicketKeys are the keys used to encrypt and decrypt session tickets.
+	// They are installed on the tls.Config, replacing the keys managed by crypto/tls.
+	// Servers that share these keys can resume each other's sessions.
+	// This option is only valid for the server.
+	SessionTicketKeys *SessionTicketKeys
+	// GetRoute is called for every new connection, with the server name that the client sent in the ClientHello.
+	// It selects the tls.Config and the Config used for the connection,
+	// e.g. to terminate connections for multiple tenants on a single listener (see ServerNameRouter).
+	// If it returns nil, the tls.Config and the Config of the listener are used.
+	// The Versions, ConnectionIDLength, StatelessResetKey, AcceptToken, Tracer, MaxSessions, SessionRunLoopAffinity,
+	// HandshakeSigner and SessionTicketKeys apply to the listener, and are always taken from the Config of the listener.
+	// If the ClientHello spans multiple Initial packets, these packets are buffered until the ClientHello is complete.
+	// This option is only valid for the server.
+	GetRoute func(*ClientHelloInfo) *Route
 	// InitialStreamReceiveWindow is the initial size of the stream-level flow control window for receiving data.
 	// If the application is consuming data quickly enough, the flow control auto-tuning algorithm
 	// will increase the window up to MaxStreamReceiveWindow.
@@ -258,6 +656,14 @@ type Config struct {
 	// MaxStreamReceiveWindow is the maximum stream-level flow control window for receiving data.
 	// If this value is zero, it will default to 6 MB.
 	MaxStreamReceiveWindow uint64
+	// StreamReceiveBufferSize enables contiguous stream receive buffers.
+	// Every stream allocates a buffer of this size when it receives its first STREAM frame,
+	// and the data of all STREAM frames is copied into it.
+	// This avoids the cost of queueing and reassembling individual frames,
+	// which is significant for single streams at very high data rates, at the expense of memory.
+	// The stream-level flow control windows are limited to this size.
+	// If not set, frames are queued as they are received.
+	StreamReceiveBufferSize uint64
 	// InitialConnectionReceiveWindow is the initial size of the stream-level flow control window for receiving data.
 	// If the application is consuming data quickly enough, the flow control auto-tuning algorithm
 	// will increase the window up to MaxConnectionReceiveWindow.
@@ -281,6 +687,18 @@ type Config struct {
 	StatelessResetKey []byte
 	// KeepAlive defines whether this peer will periodically send a packet to keep the connection alive.
 	KeepAlive bool
+	// KeepAlivePeriod is the period after which a keep-alive packet is sent.
+	// It should be shorter than the time that NATs on the path keep their bindings.
+	// It is capped at half the idle timeout.
+	// If not set, it defaults to the minimum of 20 seconds and half the idle timeout.
+	KeepAlivePeriod time.Duration
+	// RTTProbeInterval enables RTT probes: if no RTT sample was taken for this interval,
+	// e.g. because the connection is idle or application-limited, a PING is sent to take a new sample,
+	// and then at most one PING per interval until a new sample is taken.
+	// This keeps the RTT samples used by delay-based algorithms (e.g. HyStart++) fresh.
+	// Note that the PINGs keep the connection alive.
+	// If not set, no RTT probes are sent.
+	RTTProbeInterval time.Duration
 	// DisablePathMTUDiscovery disables Path MTU Discovery (RFC 8899).
 	// Packets will then be at most 1252 (IPv4) / 1232 (IPv6) bytes in size.
 	// Note that Path MTU discovery is always disabled on Windows, see https://github.com/lucas-clemente/quic-go/issues/3273.
@@ -289,16 +707,63 @@ type Config struct {
 	// This can be useful if version information is exchanged out-of-band.
 	// It has no effect for a client.
 	DisableVersionNegotiationPackets bool
+	// PaddingProfile pads packets to a fixed set of sizes (in bytes), such that an on-path observer can't learn
+	// the size of the data sent from the size of the packets.
+	// Every packet is padded to the smallest size in the profile that fits the packet.
+	// Packets that are larger than the largest size are padded to the maximum packet size,
+	// and sizes larger than the maximum packet size are capped to it.
+	// A profile that consists of a single size pads all packets to the same size.
+	// The sizes must be positive and in increasing order.
+	// Padding counts towards the congestion window, except for packets that only contain ACK frames,
+	// which are padded as well, but are never congestion controlled.
+	// Path MTU probe packets are not affected.
+	// If not set, packets are only padded where required by the protocol.
+	PaddingProfile []int
+	// EnableStreamChecksums is a debug mode that adds a checksum to every chunk of data written to a stream.
+	// The checksums are validated by the receiver before the data is passed to the application,
+	// and Stream.Read returns a StreamChecksumError on mismatch.
+	// This helps to tell corruption in the application's buffers apart from corruption in the transport.
+	// It changes the data sent on the wire, and can only be used if both peers enable it.
+	EnableStreamChecksums bool
 	// See https://datatracker.ietf.org/doc/draft-ietf-quic-datagram/.
 	// Datagrams will only be available when both peers enable datagram support.
 	EnableDatagrams bool
-	Tracer          logging.Tracer
+	// EnableAddressDiscovery enables the address discovery extension (experimental),
+	// see https://datatracker.ietf.org/doc/draft-ietf-quic-address-discovery/.
+	// When both peers enable it, every peer reports the address it observes for the other peer in an OBSERVED_ADDRESS frame.
+	// This allows endpoints behind a NAT to learn their reflexive address without using STUN.
+	// The address is exposed via ConnectionState.ObservedAddress.
+	EnableAddressDiscovery bool
+	// DeliveryRateReportInterval enables the (experimental) delivery rate reporting extension.
+	// When both peers enable it, every peer periodically sends DELIVERY_RATE frames, reporting the goodput
+	// at which it received stream data during the last interval.
+	// The reports are passed to the congestion controller of the sender.
+	// The value is the interval at which this endpoint wants the peer to send reports.
+	// It must be between 1ms and 1 minute. 0 disables the extension.
+	DeliveryRateReportInterval time.Duration
+	Tracer                     logging.Tracer
+	// CongestionMetricsInterval is the minimum interval between two updates of the congestion metrics
+	// (congestion window, slow start threshold, bytes in flight and pacing rate) passed to the Tracer.
+	// If not set, every change is traced.
+	CongestionMetricsInterval time.Duration
 }
 
 // ConnectionState records basic details about a QUIC connection
 type ConnectionState struct {
 	TLS               handshake.ConnectionState
 	SupportsDatagrams bool
+	// ObservedAddress is the address of this endpoint, as observed by the peer.
+	// It is only set if both peers enabled address discovery, and the peer already reported the address.
+	ObservedAddress net.Addr
+	// The start and the congestion control algorithm used on this connection.
+	// Algorithms that are selected automatically (utils.ChooseAutoStart and utils.ChooseAutoCongestion)
+	// are reported as such until they were selected, which happens one round trip after the handshake.
+	StartAlgo      utils.StartAlgo
+	CongestionAlgo utils.CongestionAlgo
+	// SmoothedRTT is the current smoothed RTT of the connection. It is 0 until the first RTT sample was taken.
+	SmoothedRTT time.Duration
+	// HandshakeRTT is the smoothed RTT when the handshake completed. It is 0 until the handshake completed.
+	HandshakeRTT time.Duration
 }
 
 // A Listener for incoming QUIC connections
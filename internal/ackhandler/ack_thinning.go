@@ -0,0 +1,23 @@
+package ackhandler
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+)
+
+// AckThinningInfo describes a received ack-eliciting packet to an AckThinningStrategy.
+type AckThinningInfo struct {
+	PacketNumber protocol.PacketNumber
+	ReceiveTime  time.Time
+	// The number of ack-eliciting packets received since the last ACK was sent, including this packet.
+	PacketsSinceLastAck int
+}
+
+// An AckThinningStrategy decides if an ACK is queued after receiving an ack-eliciting application data packet.
+// It replaces the default of acknowledging every second ack-eliciting packet.
+// To make sure that the peer's timers don't fire, ACKs are never delayed by more than the max_ack_delay,
+// nor for more than protocol.MaxAckThinningPackets packets.
+// Packets that arrive out of order are always acknowledged immediately.
+// It is only used when built with the quic_research build tag.
+type AckThinningStrategy func(AckThinningInfo) bool
@@ -1,6 +1,7 @@
 package ackhandler
 
 import (
+	"github.com/lucas-clemente/quic-go/internal/congestion"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/lucas-clemente/quic-go/logging"
@@ -16,8 +17,10 @@ func NewAckHandler(
 	logger utils.Logger,
 	startAlgo utils.StartAlgo,
 	congestionAlgo utils.CongestionAlgo,
+	congestionConf *congestion.Config,
+	conf *Config,
 	version protocol.VersionNumber,
 ) (SentPacketHandler, ReceivedPacketHandler) {
-	sph := newSentPacketHandler(initialPacketNumber, initialMaxDatagramSize, rttStats, pers, tracer, logger, startAlgo, congestionAlgo)
-	return sph, newReceivedPacketHandler(sph, rttStats, logger, version)
+	sph := newSentPacketHandler(initialPacketNumber, initialMaxDatagramSize, rttStats, pers, tracer, logger, startAlgo, congestionAlgo, congestionConf, conf)
+	return sph, newReceivedPacketHandler(sph, rttStats, tracer, logger, conf, version)
 }
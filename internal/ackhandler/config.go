@@ -0,0 +1,74 @@
+package ackhandler
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+)
+
+// An AckRangeEvictionPolicy selects the ACK ranges that are discarded
+// when more than MaxAckRanges ranges are tracked for a packet number space.
+type AckRangeEvictionPolicy uint8
+
+const (
+	// EvictOldestAckRanges discards the ranges with the lowest packet numbers.
+	EvictOldestAckRanges AckRangeEvictionPolicy = iota
+	// EvictSmallestAckRanges discards the ranges containing the fewest packets.
+	// Ties are broken by discarding the older range. The highest range is never discarded.
+	EvictSmallestAckRanges
+)
+
+// Config contains the packet number and ACK parameters of the ack handlers.
+// The zero value of each field selects the default behavior.
+type Config struct {
+	// The minimum length used to encode packet numbers.
+	// A longer encoding is used if the number of outstanding packets requires it.
+	MinPacketNumberLength protocol.PacketNumberLen
+	// Don't skip any packet numbers in the application data packet number space.
+	DisablePacketNumberSkipping bool
+	// If set, a packet number is skipped after exactly every PacketNumberSkipPeriod packets,
+	// instead of randomly, with an exponentially increasing period.
+	PacketNumberSkipPeriod protocol.PacketNumber
+	// The maximum number of ACK ranges tracked per packet number space.
+	// Defaults to protocol.MaxNumAckRanges.
+	MaxAckRanges int
+	// The maximum size of an ACK frame. Ranges that don't fit are omitted, starting with the lowest one.
+	// Defaults to (and must not exceed) protocol.MaxAckFrameSize.
+	MaxAckFrameSize protocol.ByteCount
+	// The policy used to discard ACK ranges when more than MaxAckRanges ranges are tracked.
+	AckRangeEviction AckRangeEvictionPolicy
+	// The minimum interval between two congestion metrics updates passed to the tracer.
+	// If zero, every change of the congestion window, the slow start threshold, the bytes in flight or the pacing rate is traced.
+	CongestionMetricsInterval time.Duration
+	// The strategy used to decide when application data packets are acknowledged.
+	// It is ignored unless built with the quic_research build tag.
+	AckThinning AckThinningStrategy
+}
+
+func (c *Config) maxAckRanges() int {
+	if c == nil || c.MaxAckRanges == 0 {
+		return protocol.MaxNumAckRanges
+	}
+	return c.MaxAckRanges
+}
+
+func (c *Config) maxAckFrameSize() protocol.ByteCount {
+	if c == nil || c.MaxAckFrameSize == 0 || c.MaxAckFrameSize > protocol.MaxAckFrameSize {
+		return protocol.MaxAckFrameSize
+	}
+	return c.MaxAckFrameSize
+}
+
+func (c *Config) ackRangeEviction() AckRangeEvictionPolicy {
+	if c == nil {
+		return EvictOldestAckRanges
+	}
+	return c.AckRangeEviction
+}
+
+func (c *Config) ackThinning() AckThinningStrategy {
+	if c == nil || !ResearchHooksEnabled {
+		return nil
+	}
+	return c.AckThinning
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/wire"
+	"github.com/lucas-clemente/quic-go/logging"
 )
 
 // A Packet is a packet
@@ -13,16 +14,31 @@ type Packet struct {
 	Frames          []Frame
 	LargestAcked    protocol.PacketNumber // InvalidPacketNumber if the packet doesn't contain an ACK
 	Length          protocol.ByteCount
+	PaddingLength   protocol.ByteCount // the number of padding bytes included in Length
 	EncryptionLevel protocol.EncryptionLevel
 	SendTime        time.Time
 
 	IsPathMTUProbePacket bool // We don't report the loss of Path MTU probe packets to the congestion controller.
 
+	// Composition describes how the packet was composed. It is only set if the connection is traced.
+	// The sentPacketHandler adds the send budgets, passes it to the tracer and then discards it.
+	Composition *logging.PacketComposition
+
 	includedInBytesInFlight bool
 	declaredLost            bool
 	skippedPacket           bool
 }
 
+// Stats are statistics about the packets sent and received on a connection.
+type Stats struct {
+	PacketsSent         uint64
+	PacketsLost         uint64
+	BytesSent           protocol.ByteCount
+	BytesReceived       protocol.ByteCount
+	PaddingBytesSent    protocol.ByteCount // included in BytesSent
+	MaxCongestionWindow protocol.ByteCount // the largest congestion window used to send a packet
+}
+
 // SentPacketHandler handles ACKs received for outgoing packets
 type SentPacketHandler interface {
 	// SentPacket may modify the packet
@@ -40,7 +56,25 @@ type SentPacketHandler interface {
 	TimeUntilSend() time.Time
 	// HasPacingBudget says if the pacer allows sending of a (full size) packet at this moment.
 	HasPacingBudget() bool
+	// SetMaxDatagramSize is called when the maximum datagram size increases or decreases.
 	SetMaxDatagramSize(count protocol.ByteCount)
+	// GetCongestionWindow returns the current congestion window.
+	GetCongestionWindow() protocol.ByteCount
+	// SetApplicationLimited is called when the application marks the connection as (no longer) application-limited.
+	SetApplicationLimited(bool)
+	// SetMaxSendRate limits the send rate (in bytes/s). 0 removes the limit.
+	SetMaxSendRate(uint64)
+	// ReceivedDeliveryRate is called when the peer reports the rate (in bytes/s) at which it received data during the last interval.
+	ReceivedDeliveryRate(rate uint64, interval time.Duration, rcvTime time.Time)
+	// Stats returns statistics about the packets sent and received so far.
+	Stats() Stats
+	// Compact releases memory that is no longer needed.
+	// It is called periodically on long-lived connections.
+	Compact()
+	// OnSuspend is called after the system was suspended.
+	// All timestamps are shifted by clockJump, such that the suspension isn't mistaken for packet loss.
+	// If restartCongestion is set, the congestion controller is reset, since the path might have changed in the meantime.
+	OnSuspend(clockJump time.Duration, restartCongestion bool)
 
 	// only to be called once the handshake is complete
 	QueueProbePacket(protocol.EncryptionLevel) bool /* was a packet queued */
@@ -62,6 +96,9 @@ type ReceivedPacketHandler interface {
 	IsPotentiallyDuplicate(protocol.PacketNumber, protocol.EncryptionLevel) bool
 	ReceivedPacket(pn protocol.PacketNumber, ecn protocol.ECN, encLevel protocol.EncryptionLevel, rcvTime time.Time, shouldInstigateAck bool) error
 	DropPackets(protocol.EncryptionLevel)
+	// Compact stops acknowledging packets that were acknowledged for a long time.
+	// It is called periodically on long-lived connections.
+	Compact()
 
 	GetAlarmTimeout() time.Time
 	GetAckFrame(encLevel protocol.EncryptionLevel, onlyIfQueued bool) *wire.AckFrame
@@ -0,0 +1,7 @@
+//go:build !quic_research
+// +build !quic_research
+
+package ackhandler
+
+// ResearchHooksEnabled reports if the research hooks (e.g. the AckThinningStrategy) are compiled in.
+const ResearchHooksEnabled = false
@@ -74,3 +74,36 @@ func (p *skippingPacketNumberGenerator) generateNewSkip() {
 	p.nextToSkip = p.next + 2 + protocol.PacketNumber(p.rng.Int31n(int32(2*p.period)))
 	p.period = utils.MinPacketNumber(2*p.period, p.maxPeriod)
 }
+
+// The periodicPacketNumberGenerator skips a packet number after exactly every period packets.
+// Since the period is at least 1, it never skips two consecutive packet numbers.
+type periodicPacketNumberGenerator struct {
+	period protocol.PacketNumber
+
+	next       protocol.PacketNumber
+	nextToSkip protocol.PacketNumber
+}
+
+var _ packetNumberGenerator = &periodicPacketNumberGenerator{}
+
+func newPeriodicPacketNumberGenerator(initial, period protocol.PacketNumber) packetNumberGenerator {
+	return &periodicPacketNumberGenerator{
+		next:       initial,
+		period:     period,
+		nextToSkip: initial + period,
+	}
+}
+
+func (p *periodicPacketNumberGenerator) Peek() protocol.PacketNumber {
+	return p.next
+}
+
+func (p *periodicPacketNumberGenerator) Pop() protocol.PacketNumber {
+	next := p.next
+	p.next++
+	if p.next == p.nextToSkip {
+		p.next++
+		p.nextToSkip = p.next + p.period
+	}
+	return next
+}
@@ -97,3 +97,22 @@ var _ = Describe("Skipping Packet Number Generator", func() {
 		}
 	})
 })
+
+var _ = Describe("Periodic Packet Number Generator", func() {
+	It("skips a packet number after every period packets", func() {
+		png := newPeriodicPacketNumberGenerator(10, 3)
+		var pns []protocol.PacketNumber
+		for i := 0; i < 9; i++ {
+			pns = append(pns, png.Pop())
+		}
+		Expect(pns).To(Equal([]protocol.PacketNumber{10, 11, 12, 14, 15, 16, 18, 19, 20}))
+	})
+
+	It("never skips two consecutive packet numbers", func() {
+		png := newPeriodicPacketNumberGenerator(0, 1)
+		for i := protocol.PacketNumber(0); i < 10; i++ {
+			Expect(png.Peek()).To(Equal(2 * i))
+			Expect(png.Pop()).To(Equal(2 * i))
+		}
+	})
+})
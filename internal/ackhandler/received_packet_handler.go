@@ -7,6 +7,7 @@ import (
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/lucas-clemente/quic-go/internal/wire"
+	"github.com/lucas-clemente/quic-go/logging"
 )
 
 type receivedPacketHandler struct {
@@ -24,14 +25,16 @@ var _ ReceivedPacketHandler = &receivedPacketHandler{}
 func newReceivedPacketHandler(
 	sentPackets sentPacketTracker,
 	rttStats *utils.RTTStats,
+	tracer logging.ConnectionTracer,
 	logger utils.Logger,
+	conf *Config,
 	version protocol.VersionNumber,
 ) ReceivedPacketHandler {
 	return &receivedPacketHandler{
 		sentPackets:      sentPackets,
-		initialPackets:   newReceivedPacketTracker(rttStats, logger, version),
-		handshakePackets: newReceivedPacketTracker(rttStats, logger, version),
-		appDataPackets:   newReceivedPacketTracker(rttStats, logger, version),
+		initialPackets:   newReceivedPacketTracker(protocol.EncryptionInitial, rttStats, tracer, logger, conf, version),
+		handshakePackets: newReceivedPacketTracker(protocol.EncryptionHandshake, rttStats, tracer, logger, conf, version),
+		appDataPackets:   newReceivedPacketTracker(protocol.Encryption1RTT, rttStats, tracer, logger, conf, version),
 		lowest1RTTPacket: protocol.InvalidPacketNumber,
 	}
 }
@@ -81,6 +84,10 @@ func (h *receivedPacketHandler) DropPackets(encLevel protocol.EncryptionLevel) {
 	}
 }
 
+func (h *receivedPacketHandler) Compact() {
+	h.appDataPackets.Compact()
+}
+
 func (h *receivedPacketHandler) GetAlarmTimeout() time.Time {
 	var initialAlarm, handshakeAlarm time.Time
 	if h.initialPackets != nil {
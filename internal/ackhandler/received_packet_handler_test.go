@@ -22,7 +22,9 @@ var _ = Describe("Received Packet Handler", func() {
 		handler = newReceivedPacketHandler(
 			sentPackets,
 			&utils.RTTStats{},
+			nil,
 			utils.DefaultLogger,
+			nil,
 			protocol.VersionWhatever,
 		)
 	})
@@ -12,12 +12,19 @@ import (
 type receivedPacketHistory struct {
 	ranges *utils.PacketIntervalList
 
+	maxRanges int
+	eviction  AckRangeEvictionPolicy
+	// number of ranges evicted since the last call to PopNumEvicted
+	numEvicted int
+
 	deletedBelow protocol.PacketNumber
 }
 
-func newReceivedPacketHistory() *receivedPacketHistory {
+func newReceivedPacketHistory(maxRanges int, eviction AckRangeEvictionPolicy) *receivedPacketHistory {
 	return &receivedPacketHistory{
-		ranges: utils.NewPacketIntervalList(),
+		ranges:    utils.NewPacketIntervalList(),
+		maxRanges: maxRanges,
+		eviction:  eviction,
 	}
 }
 
@@ -71,12 +78,37 @@ func (h *receivedPacketHistory) addToRanges(p protocol.PacketNumber) bool /* is
 	return true
 }
 
-// Delete old ranges, if we're tracking more than 500 of them.
+// Delete ranges, if we're tracking more than maxRanges of them.
 // This is a DoS defense against a peer that sends us too many gaps.
 func (h *receivedPacketHistory) maybeDeleteOldRanges() {
-	for h.ranges.Len() > protocol.MaxNumAckRanges {
-		h.ranges.Remove(h.ranges.Front())
+	for h.ranges.Len() > h.maxRanges {
+		switch h.eviction {
+		case EvictSmallestAckRanges:
+			h.ranges.Remove(h.smallestRange())
+		default:
+			h.ranges.Remove(h.ranges.Front())
+		}
+		h.numEvicted++
+	}
+}
+
+// smallestRange returns the range containing the fewest packets, not considering the highest range.
+// If multiple ranges have the same length, the lowest one is returned.
+func (h *receivedPacketHistory) smallestRange() *utils.PacketIntervalElement {
+	smallest := h.ranges.Front()
+	for el := smallest.Next(); el != nil && el != h.ranges.Back(); el = el.Next() {
+		if el.Value.End-el.Value.Start < smallest.Value.End-smallest.Value.Start {
+			smallest = el
+		}
 	}
+	return smallest
+}
+
+// PopNumEvicted returns the number of ranges evicted since the last call, and resets the counter.
+func (h *receivedPacketHistory) PopNumEvicted() int {
+	n := h.numEvicted
+	h.numEvicted = 0
+	return n
 }
 
 // DeleteBelow deletes all entries below (but not including) p
@@ -16,7 +16,7 @@ var _ = Describe("receivedPacketHistory", func() {
 	var hist *receivedPacketHistory
 
 	BeforeEach(func() {
-		hist = newReceivedPacketHistory()
+		hist = newReceivedPacketHistory(protocol.MaxNumAckRanges, EvictOldestAckRanges)
 	})
 
 	Context("ranges", func() {
@@ -199,6 +199,45 @@ var _ = Describe("receivedPacketHistory", func() {
 			// check that the oldest ACK range was deleted
 			Expect(hist.ranges.Len()).To(Equal(protocol.MaxNumAckRanges))
 			Expect(hist.ranges.Front().Value).To(Equal(utils.PacketInterval{Start: 2, End: 2}))
+			Expect(hist.PopNumEvicted()).To(Equal(1))
+			Expect(hist.PopNumEvicted()).To(BeZero())
+		})
+
+		It("uses a configurable number of ranges", func() {
+			hist = newReceivedPacketHistory(3, EvictOldestAckRanges)
+			for i := protocol.PacketNumber(0); i < 5; i++ {
+				hist.ReceivedPacket(2 * i)
+			}
+			Expect(hist.GetAckRanges()).To(Equal([]wire.AckRange{
+				{Smallest: 8, Largest: 8},
+				{Smallest: 6, Largest: 6},
+				{Smallest: 4, Largest: 4},
+			}))
+			Expect(hist.PopNumEvicted()).To(Equal(2))
+		})
+
+		It("evicts the smallest ranges", func() {
+			hist = newReceivedPacketHistory(3, EvictSmallestAckRanges)
+			for _, pn := range []protocol.PacketNumber{0, 1, 2, 4, 6, 7, 9} {
+				hist.ReceivedPacket(pn)
+			}
+			// the range 4-4 is the smallest, and the range 9-9 is the highest range
+			Expect(hist.GetAckRanges()).To(Equal([]wire.AckRange{
+				{Smallest: 9, Largest: 9},
+				{Smallest: 6, Largest: 7},
+				{Smallest: 0, Largest: 2},
+			}))
+			Expect(hist.PopNumEvicted()).To(Equal(1))
+			// when all ranges have the same length, the oldest range is evicted
+			hist.ReceivedPacket(10)
+			hist.ReceivedPacket(12)
+			hist.ReceivedPacket(13)
+			Expect(hist.GetAckRanges()).To(Equal([]wire.AckRange{
+				{Smallest: 12, Largest: 13},
+				{Smallest: 9, Largest: 10},
+				{Smallest: 0, Largest: 2},
+			}))
+			Expect(hist.PopNumEvicted()).To(Equal(1))
 		})
 	})
 
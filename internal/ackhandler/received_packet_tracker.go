@@ -6,6 +6,7 @@ import (
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/lucas-clemente/quic-go/internal/wire"
+	"github.com/lucas-clemente/quic-go/logging"
 )
 
 // number of ack-eliciting packets received before sending an ack.
@@ -17,7 +18,8 @@ type receivedPacketTracker struct {
 	largestObservedReceivedTime time.Time
 	ect0, ect1, ecnce           uint64
 
-	packetHistory *receivedPacketHistory
+	packetHistory   *receivedPacketHistory
+	maxAckFrameSize protocol.ByteCount
 
 	maxAckDelay time.Duration
 	rttStats    *utils.RTTStats
@@ -26,26 +28,41 @@ type receivedPacketTracker struct {
 	ackQueued bool // true once we received more than 2 (or later in the connection 10) ack-eliciting packets
 
 	ackElicitingPacketsReceivedSinceLastAck int
+	ackThinning                             AckThinningStrategy // only set for the application data packet number space
 	ackAlarm                                time.Time
 	lastAck                                 *wire.AckFrame
+	// the largest packet acknowledged in the last ACK frame sent before Compact was called
+	largestAckedAtCompaction protocol.PacketNumber
 
-	logger utils.Logger
+	encLevel protocol.EncryptionLevel
+	tracer   logging.ConnectionTracer
+	logger   utils.Logger
 
 	version protocol.VersionNumber
 }
 
 func newReceivedPacketTracker(
+	encLevel protocol.EncryptionLevel,
 	rttStats *utils.RTTStats,
+	tracer logging.ConnectionTracer,
 	logger utils.Logger,
+	conf *Config,
 	version protocol.VersionNumber,
 ) *receivedPacketTracker {
-	return &receivedPacketTracker{
-		packetHistory: newReceivedPacketHistory(),
-		maxAckDelay:   protocol.MaxAckDelay,
-		rttStats:      rttStats,
-		logger:        logger,
-		version:       version,
-	}
+	h := &receivedPacketTracker{
+		packetHistory:   newReceivedPacketHistory(conf.maxAckRanges(), conf.ackRangeEviction()),
+		maxAckFrameSize: conf.maxAckFrameSize(),
+		maxAckDelay:     protocol.MaxAckDelay,
+		rttStats:        rttStats,
+		encLevel:        encLevel,
+		tracer:          tracer,
+		logger:          logger,
+		version:         version,
+	}
+	if encLevel == protocol.Encryption1RTT {
+		h.ackThinning = conf.ackThinning()
+	}
+	return h
 }
 
 func (h *receivedPacketTracker) ReceivedPacket(packetNumber protocol.PacketNumber, ecn protocol.ECN, rcvTime time.Time, shouldInstigateAck bool) {
@@ -89,6 +106,20 @@ func (h *receivedPacketTracker) IgnoreBelow(p protocol.PacketNumber) {
 	}
 }
 
+// Compact stops acknowledging packets that were already acknowledged in an ACK frame sent before the previous call to Compact.
+// Usually, these ACK ranges are deleted once the peer acknowledges a packet containing an ACK frame (see IgnoreBelow).
+// If that doesn't happen, e.g. because the packets were lost, the ranges would be sent until they're evicted.
+// Since Compact is called at a long interval, the peer has either received one of these ACK frames,
+// or declared the packets lost a long time ago.
+func (h *receivedPacketTracker) Compact() {
+	if h.largestAckedAtCompaction > 0 {
+		h.IgnoreBelow(h.largestAckedAtCompaction + 1)
+	}
+	if h.lastAck != nil {
+		h.largestAckedAtCompaction = h.lastAck.LargestAcked()
+	}
+}
+
 // isMissing says if a packet was reported missing in the last ACK.
 func (h *receivedPacketTracker) isMissing(p protocol.PacketNumber) bool {
 	if h.lastAck == nil || p < h.ignoreBelow {
@@ -133,11 +164,16 @@ func (h *receivedPacketTracker) maybeQueueAck(pn protocol.PacketNumber, rcvTime
 	}
 
 	// send an ACK every 2 ack-eliciting packets
-	if h.ackElicitingPacketsReceivedSinceLastAck >= packetsBeforeAck {
+	if h.ackThinning == nil && h.ackElicitingPacketsReceivedSinceLastAck >= packetsBeforeAck {
 		if h.logger.Debug() {
 			h.logger.Debugf("\tQueueing ACK because packet %d packets were received after the last ACK (using initial threshold: %d).", h.ackElicitingPacketsReceivedSinceLastAck, packetsBeforeAck)
 		}
 		h.ackQueued = true
+	} else if h.ackThinning != nil && h.thinnedAckDue(pn, rcvTime) {
+		if h.logger.Debug() {
+			h.logger.Debugf("\tQueueing ACK for packet %d, as requested by the ACK thinning strategy (%d packets received after the last ACK).", pn, h.ackElicitingPacketsReceivedSinceLastAck)
+		}
+		h.ackQueued = true
 	} else if h.ackAlarm.IsZero() {
 		if h.logger.Debug() {
 			h.logger.Debugf("\tSetting ACK timer to max ack delay: %s", h.maxAckDelay)
@@ -157,6 +193,19 @@ func (h *receivedPacketTracker) maybeQueueAck(pn protocol.PacketNumber, rcvTime
 	}
 }
 
+// thinnedAckDue says if an ACK should be queued when using an AckThinningStrategy.
+func (h *receivedPacketTracker) thinnedAckDue(pn protocol.PacketNumber, rcvTime time.Time) bool {
+	// Don't withhold ACKs for too many packets, the peer might be blocked by its congestion window.
+	if h.ackElicitingPacketsReceivedSinceLastAck >= protocol.MaxAckThinningPackets {
+		return true
+	}
+	return h.ackThinning(AckThinningInfo{
+		PacketNumber:        pn,
+		ReceiveTime:         rcvTime,
+		PacketsSinceLastAck: h.ackElicitingPacketsReceivedSinceLastAck,
+	})
+}
+
 func (h *receivedPacketTracker) GetAckFrame(onlyIfQueued bool) *wire.AckFrame {
 	if !h.hasNewAck {
 		return nil
@@ -180,6 +229,15 @@ func (h *receivedPacketTracker) GetAckFrame(onlyIfQueued bool) *wire.AckFrame {
 		ECT1:      h.ect1,
 		ECNCE:     h.ecnce,
 	}
+	omitted := ack.Truncate(h.maxAckFrameSize)
+	if evicted := h.packetHistory.PopNumEvicted(); evicted > 0 || omitted > 0 {
+		if h.logger.Debug() {
+			h.logger.Debugf("\tACK frame doesn't report all packets: %d ranges evicted since the last ACK, %d ranges omitted", evicted, omitted)
+		}
+		if h.tracer != nil {
+			h.tracer.LimitedAckRanges(h.encLevel, evicted, omitted)
+		}
+	}
 
 	h.lastAck = ack
 	h.ackAlarm = time.Time{}
@@ -3,6 +3,9 @@ package ackhandler
 import (
 	"time"
 
+	"github.com/golang/mock/gomock"
+
+	mocklogging "github.com/lucas-clemente/quic-go/internal/mocks/logging"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/lucas-clemente/quic-go/internal/wire"
@@ -19,7 +22,7 @@ var _ = Describe("Received Packet Tracker", func() {
 
 	BeforeEach(func() {
 		rttStats = &utils.RTTStats{}
-		tracker = newReceivedPacketTracker(rttStats, utils.DefaultLogger, protocol.VersionWhatever)
+		tracker = newReceivedPacketTracker(protocol.Encryption1RTT, rttStats, nil, utils.DefaultLogger, nil, protocol.VersionWhatever)
 	})
 
 	Context("accepting packets", func() {
@@ -104,6 +107,50 @@ var _ = Describe("Received Packet Tracker", func() {
 				}
 			})
 
+			Context("ACK thinning", func() {
+				var infos []AckThinningInfo
+
+				BeforeEach(func() {
+					infos = nil
+					tracker.ackThinning = func(info AckThinningInfo) bool {
+						infos = append(infos, info)
+						return info.PacketsSinceLastAck >= 4
+					}
+				})
+
+				It("queues ACKs as requested by the strategy", func() {
+					receiveAndAck10Packets()
+					rcvTime := time.Now()
+					for i := 11; i <= 13; i++ {
+						tracker.ReceivedPacket(protocol.PacketNumber(i), protocol.ECNNon, rcvTime, true)
+						Expect(tracker.ackQueued).To(BeFalse())
+					}
+					Expect(tracker.GetAlarmTimeout()).To(Equal(rcvTime.Add(protocol.MaxAckDelay)))
+					tracker.ReceivedPacket(14, protocol.ECNNon, rcvTime, true)
+					Expect(tracker.ackQueued).To(BeTrue())
+					Expect(infos).To(HaveLen(4))
+					Expect(infos[3]).To(Equal(AckThinningInfo{PacketNumber: 14, ReceiveTime: rcvTime, PacketsSinceLastAck: 4}))
+				})
+
+				It("doesn't withhold ACKs for too many packets", func() {
+					tracker.ackThinning = func(AckThinningInfo) bool { return false }
+					receiveAndAck10Packets()
+					for i := 1; i < protocol.MaxAckThinningPackets; i++ {
+						tracker.ReceivedPacket(protocol.PacketNumber(10+i), protocol.ECNNon, time.Now(), true)
+						Expect(tracker.ackQueued).To(BeFalse())
+					}
+					tracker.ReceivedPacket(10+protocol.MaxAckThinningPackets, protocol.ECNNon, time.Now(), true)
+					Expect(tracker.ackQueued).To(BeTrue())
+				})
+
+				It("is only used for application data when built with the research build tag", func() {
+					conf := &Config{AckThinning: func(AckThinningInfo) bool { return true }}
+					Expect(newReceivedPacketTracker(protocol.EncryptionHandshake, rttStats, nil, utils.DefaultLogger, conf, protocol.VersionWhatever).ackThinning).To(BeNil())
+					appData := newReceivedPacketTracker(protocol.Encryption1RTT, rttStats, nil, utils.DefaultLogger, conf, protocol.VersionWhatever)
+					Expect(appData.ackThinning != nil).To(Equal(ResearchHooksEnabled))
+				})
+			})
+
 			It("resets the counter when a non-queued ACK frame is generated", func() {
 				receiveAndAck10Packets()
 				rcvTime := time.Now()
@@ -251,6 +298,40 @@ var _ = Describe("Received Packet Tracker", func() {
 					Expect(ack.DelayTime).To(BeZero())
 				})
 
+				It("limits the size of the ACK frame", func() {
+					tracer := mocklogging.NewMockConnectionTracer(mockCtrl)
+					tracker = newReceivedPacketTracker(protocol.EncryptionHandshake, rttStats, tracer, utils.DefaultLogger, &Config{MaxAckFrameSize: 20}, protocol.VersionWhatever)
+					tracker.ackQueued = true
+					for i := protocol.PacketNumber(0); i < 20; i++ {
+						tracker.ReceivedPacket(2*i, protocol.ECNNon, time.Now(), true)
+					}
+					var omitted int
+					tracer.EXPECT().LimitedAckRanges(protocol.EncryptionHandshake, 0, gomock.Any()).Do(func(_ protocol.EncryptionLevel, _, o int) { omitted = o })
+					ack := tracker.GetAckFrame(true)
+					Expect(ack).ToNot(BeNil())
+					Expect(ack.Length(protocol.VersionWhatever)).To(BeNumerically("<=", 20))
+					Expect(ack.LargestAcked()).To(Equal(protocol.PacketNumber(38)))
+					Expect(omitted).To(BeNumerically(">", 0))
+					Expect(ack.AckRanges).To(HaveLen(20 - omitted))
+				})
+
+				It("reports evicted ACK ranges", func() {
+					tracer := mocklogging.NewMockConnectionTracer(mockCtrl)
+					tracker = newReceivedPacketTracker(protocol.Encryption1RTT, rttStats, tracer, utils.DefaultLogger, &Config{MaxAckRanges: 2}, protocol.VersionWhatever)
+					tracker.ackQueued = true
+					for i := protocol.PacketNumber(0); i < 5; i++ {
+						tracker.ReceivedPacket(2*i, protocol.ECNNon, time.Now(), true)
+					}
+					tracer.EXPECT().LimitedAckRanges(protocol.Encryption1RTT, 3, 0)
+					ack := tracker.GetAckFrame(true)
+					Expect(ack).ToNot(BeNil())
+					Expect(ack.AckRanges).To(Equal([]wire.AckRange{{Smallest: 8, Largest: 8}, {Smallest: 6, Largest: 6}}))
+					// the counter is reset after every ACK
+					tracker.ReceivedPacket(7, protocol.ECNNon, time.Now(), true)
+					tracker.ackQueued = true
+					Expect(tracker.GetAckFrame(true)).ToNot(BeNil())
+				})
+
 				It("saves the last sent ACK", func() {
 					tracker.ReceivedPacket(1, protocol.ECNNon, time.Now(), true)
 					ack := tracker.GetAckFrame(true)
@@ -313,6 +394,29 @@ var _ = Describe("Received Packet Tracker", func() {
 					Expect(ack.HasMissingRanges()).To(BeFalse())
 				})
 
+				It("stops acknowledging packets that were acknowledged before the previous compaction", func() {
+					for i := 1; i <= 5; i++ {
+						tracker.ReceivedPacket(protocol.PacketNumber(i), protocol.ECNNon, time.Now(), true)
+					}
+					Expect(tracker.GetAckFrame(true)).ToNot(BeNil())
+					tracker.Compact()
+					for i := 8; i <= 10; i++ {
+						tracker.ReceivedPacket(protocol.PacketNumber(i), protocol.ECNNon, time.Now(), true)
+					}
+					ack := tracker.GetAckFrame(true)
+					Expect(ack).ToNot(BeNil())
+					Expect(ack.AckRanges).To(Equal([]wire.AckRange{{Smallest: 8, Largest: 10}, {Smallest: 1, Largest: 5}}))
+					tracker.Compact()
+					tracker.ReceivedPacket(12, protocol.ECNNon, time.Now(), true)
+					tracker.ackQueued = true
+					ack = tracker.GetAckFrame(true)
+					Expect(ack).ToNot(BeNil())
+					Expect(ack.AckRanges).To(Equal([]wire.AckRange{{Smallest: 12, Largest: 12}, {Smallest: 8, Largest: 10}}))
+					// packets acknowledged before the previous compaction are ignored
+					tracker.ReceivedPacket(4, protocol.ECNNon, time.Now(), true)
+					Expect(tracker.IsPotentiallyDuplicate(4)).To(BeTrue())
+				})
+
 				It("resets all counters needed for the ACK queueing decision when sending an ACK", func() {
 					tracker.ReceivedPacket(1, protocol.ECNNon, time.Now(), true)
 					tracker.ackAlarm = time.Now().Add(-time.Minute)
@@ -19,6 +19,8 @@ const (
 	timeThreshold = 9.0 / 8
 	// Maximum reordering in packets before packet threshold loss detection considers a packet lost.
 	packetThreshold = 3
+	// The number of PTOs that packets need to be declared lost for, to establish persistent congestion.
+	persistentCongestionThreshold = 3
 	// Before validating the client's address, the server won't send more than 3x bytes than it received.
 	amplificationFactor = 3
 	// We use Retry packets to derive an RTT estimate. Make sure we don't set the RTT to a super low value yet.
@@ -36,12 +38,15 @@ type packetNumberSpace struct {
 	largestSent  protocol.PacketNumber
 }
 
-func newPacketNumberSpace(initialPN protocol.PacketNumber, skipPNs bool, rttStats *utils.RTTStats) *packetNumberSpace {
+func newPacketNumberSpace(initialPN protocol.PacketNumber, skipPNs bool, conf *Config, rttStats *utils.RTTStats) *packetNumberSpace {
 	var pns packetNumberGenerator
-	if skipPNs {
-		pns = newSkippingPacketNumberGenerator(initialPN, protocol.SkipPacketInitialPeriod, protocol.SkipPacketMaxPeriod)
-	} else {
+	switch {
+	case !skipPNs || conf.DisablePacketNumberSkipping:
 		pns = newSequentialPacketNumberGenerator(initialPN)
+	case conf.PacketNumberSkipPeriod > 0:
+		pns = newPeriodicPacketNumberGenerator(initialPN, conf.PacketNumberSkipPeriod)
+	default:
+		pns = newSkippingPacketNumberGenerator(initialPN, protocol.SkipPacketInitialPeriod, protocol.SkipPacketMaxPeriod)
 	}
 	return &packetNumberSpace{
 		history:      newSentPacketHistory(rttStats),
@@ -77,8 +82,16 @@ type sentPacketHandler struct {
 
 	bytesInFlight protocol.ByteCount
 
+	// statistics, see Stats
+	packetsSent      uint64
+	packetsLost      uint64
+	paddingBytesSent protocol.ByteCount
+
 	congestion congestion.SendAlgorithmWithDebugInfos
 	rttStats   *utils.RTTStats
+	// the time of the first RTT sample
+	// Only packets sent after this time are considered for persistent congestion.
+	firstRTTSampleTime time.Time
 
 	// The number of times a PTO has been sent without receiving an ack.
 	ptoCount uint32
@@ -90,12 +103,29 @@ type sentPacketHandler struct {
 	// The alarm timeout
 	alarm time.Time
 
+	// the congestion metrics that were last passed to the tracer, and when
+	lastCongestionMetrics     congestionMetrics
+	lastCongestionMetricsTime time.Time
+
 	perspective protocol.Perspective
+	config      *Config
+
+	// The limits on the number of tracked packets, see protocol.MaxOutstandingSentPackets and protocol.MaxTrackedSentPackets.
+	// They are raised if the maximum congestion window is larger than the default.
+	maxOutstandingSentPackets int
+	maxTrackedSentPackets     int
 
 	tracer logging.ConnectionTracer
 	logger utils.Logger
 }
 
+type congestionMetrics struct {
+	congestionWindow   protocol.ByteCount
+	slowStartThreshold protocol.ByteCount
+	bytesInFlight      protocol.ByteCount
+	pacingRate         congestion.Bandwidth
+}
+
 var (
 	_ SentPacketHandler = &sentPacketHandler{}
 	_ sentPacketTracker = &sentPacketHandler{}
@@ -110,24 +140,45 @@ func newSentPacketHandler(
 	logger utils.Logger,
 	startAlgo utils.StartAlgo,
 	congestionAlgo utils.CongestionAlgo,
+	congestionConf *congestion.Config,
+	conf *Config,
 ) *sentPacketHandler {
-	congestion := congestion.NewCubicSender(
-		congestion.DefaultClock{},
-		rttStats,
-		initialMaxDatagramSize,
-		startAlgo, // use Hystart
-		congestionAlgo, // use Reno
-		tracer,
-	)
+	if conf == nil {
+		conf = &Config{}
+	}
+	var cong congestion.SendAlgorithmWithDebugInfos
+	if congestionConf != nil && congestionConf.NewSendAlgorithm != nil {
+		cong = congestionConf.NewSendAlgorithm(congestion.DefaultClock{}, rttStats, initialMaxDatagramSize)
+	} else {
+		cong = congestion.NewCubicSender(
+			congestion.DefaultClock{},
+			rttStats,
+			initialMaxDatagramSize,
+			startAlgo, // use Hystart
+			congestionAlgo, // use Reno
+			congestionConf,
+			tracer,
+		)
+	}
+
+	maxOutstandingSentPackets := protocol.MaxOutstandingSentPackets
+	maxTrackedSentPackets := protocol.MaxTrackedSentPackets
+	if congestionConf != nil && congestionConf.MaxCongestionWindow > protocol.MaxCongestionWindowPackets {
+		maxOutstandingSentPackets = 2 * int(congestionConf.MaxCongestionWindow)
+		maxTrackedSentPackets = maxOutstandingSentPackets * 5 / 4
+	}
 
 	return &sentPacketHandler{
 		peerCompletedAddressValidation: pers == protocol.PerspectiveServer,
 		peerAddressValidated:           pers == protocol.PerspectiveClient,
-		initialPackets:                 newPacketNumberSpace(initialPN, false, rttStats),
-		handshakePackets:               newPacketNumberSpace(0, false, rttStats),
-		appDataPackets:                 newPacketNumberSpace(0, true, rttStats),
+		initialPackets:                 newPacketNumberSpace(initialPN, false, conf, rttStats),
+		handshakePackets:               newPacketNumberSpace(0, false, conf, rttStats),
+		appDataPackets:                 newPacketNumberSpace(0, true, conf, rttStats),
 		rttStats:                       rttStats,
-		congestion:                     congestion,
+		config:                         conf,
+		congestion:                     cong,
+		maxOutstandingSentPackets:      maxOutstandingSentPackets,
+		maxTrackedSentPackets:          maxTrackedSentPackets,
 		perspective:                    pers,
 		tracer:                         tracer,
 		logger:                         logger,
@@ -197,6 +248,7 @@ func (h *sentPacketHandler) dropPackets(encLevel protocol.EncryptionLevel) {
 	h.ptoCount = 0
 	h.numProbesToSend = 0
 	h.ptoMode = SendNone
+	h.maybeTraceCongestionMetrics(time.Now())
 	h.setLossDetectionTimer()
 }
 
@@ -227,7 +279,13 @@ func (h *sentPacketHandler) packetsInFlight() int {
 }
 
 func (h *sentPacketHandler) SentPacket(packet *Packet) {
+	if packet.Composition != nil {
+		h.traceComposition(packet)
+		packet.Composition = nil
+	}
 	h.bytesSent += packet.Length
+	h.paddingBytesSent += packet.PaddingLength
+	h.packetsSent++
 	// For the client, drop the Initial packet number space when the first Handshake packet is sent.
 	if h.perspective == protocol.PerspectiveClient && packet.EncryptionLevel == protocol.EncryptionHandshake && h.initialPackets != nil {
 		h.dropPackets(protocol.EncryptionInitial)
@@ -237,11 +295,34 @@ func (h *sentPacketHandler) SentPacket(packet *Packet) {
 	if h.tracer != nil && isAckEliciting {
 		h.tracer.UpdatedMetrics(h.rttStats, h.congestion.GetCongestionWindow(), h.bytesInFlight, h.packetsInFlight())
 	}
+	h.maybeTraceCongestionMetrics(packet.SendTime)
 	if isAckEliciting || !h.peerCompletedAddressValidation {
 		h.setLossDetectionTimer()
 	}
 }
 
+// traceComposition passes the composition of a packet to the tracer,
+// together with the budgets of the pacer, the congestion window and the anti-amplification limit before the packet was sent.
+func (h *sentPacketHandler) traceComposition(packet *Packet) {
+	if h.tracer == nil {
+		return
+	}
+	c := packet.Composition
+	c.PacingBudget = h.congestion.PacingBudget()
+	c.CongestionWindowBudget = 0
+	if cwnd := h.congestion.GetCongestionWindow(); cwnd > h.bytesInFlight {
+		c.CongestionWindowBudget = cwnd - h.bytesInFlight
+	}
+	c.AmplificationBudget = protocol.MaxByteCount
+	if !h.peerAddressValidated {
+		c.AmplificationBudget = 0
+		if limit := amplificationFactor * h.bytesReceived; limit > h.bytesSent {
+			c.AmplificationBudget = limit - h.bytesSent
+		}
+	}
+	h.tracer.ComposedPacket(packet.EncryptionLevel, packet.PacketNumber, c)
+}
+
 func (h *sentPacketHandler) getPacketNumberSpace(encLevel protocol.EncryptionLevel) *packetNumberSpace {
 	switch encLevel {
 	case protocol.EncryptionInitial:
@@ -270,6 +351,7 @@ func (h *sentPacketHandler) sentPacketImpl(packet *Packet) bool /* is ack-elicit
 	if isAckEliciting {
 		pnSpace.lastAckElicitingPacketTime = packet.SendTime
 		packet.includedInBytesInFlight = true
+		// The length includes the padding, so padding consumes congestion window just like any other data.
 		h.bytesInFlight += packet.Length
 		if h.numProbesToSend > 0 {
 			h.numProbesToSend--
@@ -316,6 +398,9 @@ func (h *sentPacketHandler) ReceivedAck(ack *wire.AckFrame, encLevel protocol.En
 				ackDelay = utils.MinDuration(ack.DelayTime, h.rttStats.MaxAckDelay())
 			}
 			h.rttStats.UpdateRTT(rcvTime.Sub(p.SendTime), ackDelay, rcvTime)
+			if h.firstRTTSampleTime.IsZero() {
+				h.firstRTTSampleTime = rcvTime
+			}
 			if h.logger.Debug() {
 				h.logger.Debugf("\tupdated RTT: %s (σ: %s)", h.rttStats.SmoothedRTT(), h.rttStats.MeanDeviation())
 			}
@@ -348,6 +433,7 @@ func (h *sentPacketHandler) ReceivedAck(ack *wire.AckFrame, encLevel protocol.En
 	if h.tracer != nil {
 		h.tracer.UpdatedMetrics(h.rttStats, h.congestion.GetCongestionWindow(), h.bytesInFlight, h.packetsInFlight())
 	}
+	h.maybeTraceCongestionMetrics(rcvTime)
 
 	pnSpace.history.DeleteOldPackets(rcvTime)
 	h.setLossDetectionTimer()
@@ -566,12 +652,43 @@ func (h *sentPacketHandler) detectLostPackets(now time.Time, encLevel protocol.E
 	// Packets sent before this time are deemed lost.
 	lostSendTime := now.Add(-lossDelay)
 
+	// Persistent congestion is established if all packets sent during the persistent congestion duration are lost,
+	// see section 7.6 of RFC 9002.
+	// The packets are iterated in send order. Packets that are not in the history
+	// (because they were acknowledged, or because they were not ack-eliciting) end the period.
+	persistentCongestionDuration := persistentCongestionThreshold * h.rttStats.PTO(encLevel == protocol.Encryption1RTT)
+	var persistentCongestionStart time.Time
+	var inPersistentCongestion bool
+	var congestionPeriod time.Duration
+	prevPN := protocol.InvalidPacketNumber
+	onLostPacket := func(p *Packet) {
+		if p.IsPathMTUProbePacket || h.firstRTTSampleTime.IsZero() || !p.SendTime.After(h.firstRTTSampleTime) {
+			return
+		}
+		if persistentCongestionStart.IsZero() {
+			persistentCongestionStart = p.SendTime
+			return
+		}
+		if d := p.SendTime.Sub(persistentCongestionStart); !p.declaredLost && d > persistentCongestionDuration {
+			inPersistentCongestion = true
+			congestionPeriod = d
+		}
+	}
+
 	priorInFlight := h.bytesInFlight
-	return pnSpace.history.Iterate(func(p *Packet) (bool, error) {
+	err := pnSpace.history.Iterate(func(p *Packet) (bool, error) {
 		if p.PacketNumber > pnSpace.largestAcked {
 			return false, nil
 		}
-		if p.declaredLost || p.skippedPacket {
+		if prevPN != protocol.InvalidPacketNumber && p.PacketNumber != prevPN+1 {
+			persistentCongestionStart = time.Time{}
+		}
+		prevPN = p.PacketNumber
+		if p.skippedPacket {
+			return true, nil
+		}
+		if p.declaredLost {
+			onLostPacket(p)
 			return true, nil
 		}
 
@@ -600,17 +717,58 @@ func (h *sentPacketHandler) detectLostPackets(now time.Time, encLevel protocol.E
 			}
 			pnSpace.lossTime = lossTime
 		}
-		if packetLost {
-			p.declaredLost = true
-			// the bytes in flight need to be reduced no matter if the frames in this packet will be retransmitted
-			h.removeFromBytesInFlight(p)
-			h.queueFramesForRetransmission(p)
-			if !p.IsPathMTUProbePacket {
-				h.congestion.OnPacketLost(p.PacketNumber, p.Length, priorInFlight)
-			}
+		if !packetLost {
+			persistentCongestionStart = time.Time{}
+			return true, nil
+		}
+		onLostPacket(p)
+		p.declaredLost = true
+		h.packetsLost++
+		// the bytes in flight need to be reduced no matter if the frames in this packet will be retransmitted
+		h.removeFromBytesInFlight(p)
+		h.queueFramesForRetransmission(p)
+		if !p.IsPathMTUProbePacket {
+			h.congestion.OnPacketLost(p.PacketNumber, p.Length, priorInFlight)
 		}
 		return true, nil
 	})
+	if err != nil {
+		return err
+	}
+	if inPersistentCongestion {
+		if h.logger.Debug() {
+			h.logger.Debugf("	persistent congestion: all packets sent during %s were lost", congestionPeriod)
+		}
+		if h.tracer != nil {
+			h.tracer.DetectedPersistentCongestion(congestionPeriod)
+		}
+		h.congestion.OnPersistentCongestion()
+	}
+	h.maybeTraceCongestionMetrics(now)
+	return nil
+}
+
+// maybeTraceCongestionMetrics passes the congestion metrics to the tracer, if they changed.
+// If a CongestionMetricsInterval is configured, changes are only traced once per interval.
+func (h *sentPacketHandler) maybeTraceCongestionMetrics(now time.Time) {
+	if h.tracer == nil {
+		return
+	}
+	m := congestionMetrics{
+		congestionWindow:   h.congestion.GetCongestionWindow(),
+		slowStartThreshold: h.congestion.GetSlowStartThreshold(),
+		bytesInFlight:      h.bytesInFlight,
+		pacingRate:         h.congestion.PacingRate(),
+	}
+	if m == h.lastCongestionMetrics {
+		return
+	}
+	if interval := h.config.CongestionMetricsInterval; interval > 0 && now.Sub(h.lastCongestionMetricsTime) < interval {
+		return
+	}
+	h.lastCongestionMetrics = m
+	h.lastCongestionMetricsTime = now
+	h.tracer.UpdatedCongestionMetrics(m.congestionWindow, m.slowStartThreshold, m.bytesInFlight, uint64(m.pacingRate))
 }
 
 func (h *sentPacketHandler) OnLossDetectionTimeout() error {
@@ -692,11 +850,20 @@ func (h *sentPacketHandler) PeekPacketNumber(encLevel protocol.EncryptionLevel)
 	}
 
 	pn := pnSpace.pns.Peek()
-	return pn, protocol.GetPacketNumberLengthForHeader(pn, lowestUnacked)
+	pnLen := protocol.GetPacketNumberLengthForHeader(pn, lowestUnacked)
+	if pnLen < h.config.MinPacketNumberLength {
+		pnLen = h.config.MinPacketNumberLength
+	}
+	return pn, pnLen
 }
 
 func (h *sentPacketHandler) PopPacketNumber(encLevel protocol.EncryptionLevel) protocol.PacketNumber {
-	return h.getPacketNumberSpace(encLevel).pns.Pop()
+	pns := h.getPacketNumberSpace(encLevel).pns
+	pn := pns.Pop()
+	if next := pns.Peek(); next != pn+1 && h.tracer != nil {
+		h.tracer.SkippedPacketNumber(pn + 1)
+	}
+	return pn
 }
 
 func (h *sentPacketHandler) SendMode() SendMode {
@@ -716,9 +883,9 @@ func (h *sentPacketHandler) SendMode() SendMode {
 	// Note that since MaxOutstandingSentPackets is smaller than MaxTrackedSentPackets,
 	// we will stop sending out new data when reaching MaxOutstandingSentPackets,
 	// but still allow sending of retransmissions and ACKs.
-	if numTrackedPackets >= protocol.MaxTrackedSentPackets {
+	if numTrackedPackets >= h.maxTrackedSentPackets {
 		if h.logger.Debug() {
-			h.logger.Debugf("Limited by the number of tracked packets: tracking %d packets, maximum %d", numTrackedPackets, protocol.MaxTrackedSentPackets)
+			h.logger.Debugf("Limited by the number of tracked packets: tracking %d packets, maximum %d", numTrackedPackets, h.maxTrackedSentPackets)
 		}
 		return SendNone
 	}
@@ -732,9 +899,9 @@ func (h *sentPacketHandler) SendMode() SendMode {
 		}
 		return SendAck
 	}
-	if numTrackedPackets >= protocol.MaxOutstandingSentPackets {
+	if numTrackedPackets >= h.maxOutstandingSentPackets {
 		if h.logger.Debug() {
-			h.logger.Debugf("Max outstanding limited: tracking %d packets, maximum: %d", numTrackedPackets, protocol.MaxOutstandingSentPackets)
+			h.logger.Debugf("Max outstanding limited: tracking %d packets, maximum: %d", numTrackedPackets, h.maxOutstandingSentPackets)
 		}
 		return SendAck
 	}
@@ -751,6 +918,74 @@ func (h *sentPacketHandler) HasPacingBudget() bool {
 
 func (h *sentPacketHandler) SetMaxDatagramSize(s protocol.ByteCount) {
 	h.congestion.SetMaxDatagramSize(s)
+	h.maybeTraceCongestionMetrics(time.Now())
+}
+
+func (h *sentPacketHandler) GetCongestionWindow() protocol.ByteCount {
+	return h.congestion.GetCongestionWindow()
+}
+
+func (h *sentPacketHandler) SetApplicationLimited(limited bool) {
+	h.congestion.SetApplicationLimited(limited, h.bytesInFlight)
+}
+
+func (h *sentPacketHandler) SetMaxSendRate(rate uint64) {
+	h.congestion.SetMaxSendRate(congestion.Bandwidth(rate) * congestion.BytesPerSecond)
+}
+
+func (h *sentPacketHandler) ReceivedDeliveryRate(rate uint64, interval time.Duration, rcvTime time.Time) {
+	if h.logger.Debug() {
+		h.logger.Debugf("Peer received %d bytes/s during the last %s", rate, interval)
+	}
+	h.congestion.OnDeliveryRateReport(congestion.Bandwidth(rate)*congestion.BytesPerSecond, interval, rcvTime)
+}
+
+func (h *sentPacketHandler) Stats() Stats {
+	return Stats{
+		PacketsSent:         h.packetsSent,
+		PacketsLost:         h.packetsLost,
+		BytesSent:           h.bytesSent,
+		BytesReceived:       h.bytesReceived,
+		PaddingBytesSent:    h.paddingBytesSent,
+		MaxCongestionWindow: h.congestion.MaxCongestionWindowUsed(),
+	}
+}
+
+func (h *sentPacketHandler) Compact() {
+	for _, pnSpace := range []*packetNumberSpace{h.initialPackets, h.handshakePackets, h.appDataPackets} {
+		if pnSpace != nil {
+			pnSpace.history.Compact()
+		}
+	}
+}
+
+func (h *sentPacketHandler) OnSuspend(clockJump time.Duration, restartCongestion bool) {
+	if clockJump > 0 {
+		// Pretend that the time spent suspended never passed.
+		// Otherwise, all outstanding packets would be declared lost, and the RTT samples would be bogus.
+		for _, pnSpace := range []*packetNumberSpace{h.initialPackets, h.handshakePackets, h.appDataPackets} {
+			if pnSpace == nil {
+				continue
+			}
+			pnSpace.history.Iterate(func(p *Packet) (bool, error) {
+				p.SendTime = p.SendTime.Add(clockJump)
+				return true, nil
+			})
+			if !pnSpace.lossTime.IsZero() {
+				pnSpace.lossTime = pnSpace.lossTime.Add(clockJump)
+			}
+			if !pnSpace.lastAckElicitingPacketTime.IsZero() {
+				pnSpace.lastAckElicitingPacketTime = pnSpace.lastAckElicitingPacketTime.Add(clockJump)
+			}
+		}
+		if !h.firstRTTSampleTime.IsZero() {
+			h.firstRTTSampleTime = h.firstRTTSampleTime.Add(clockJump)
+		}
+		h.setLossDetectionTimer()
+	}
+	if restartCongestion {
+		h.congestion.OnConnectionMigration()
+	}
 }
 
 func (h *sentPacketHandler) isAmplificationLimited() bool {
@@ -819,8 +1054,8 @@ func (h *sentPacketHandler) ResetForRetry() error {
 			h.tracer.UpdatedMetrics(h.rttStats, h.congestion.GetCongestionWindow(), h.bytesInFlight, h.packetsInFlight())
 		}
 	}
-	h.initialPackets = newPacketNumberSpace(h.initialPackets.pns.Pop(), false, h.rttStats)
-	h.appDataPackets = newPacketNumberSpace(h.appDataPackets.pns.Pop(), true, h.rttStats)
+	h.initialPackets = newPacketNumberSpace(h.initialPackets.pns.Pop(), false, h.config, h.rttStats)
+	h.appDataPackets = newPacketNumberSpace(h.appDataPackets.pns.Pop(), true, h.config, h.rttStats)
 	oldAlarm := h.alarm
 	h.alarm = time.Time{}
 	if h.tracer != nil {
@@ -6,11 +6,14 @@ import (
 
 	"github.com/golang/mock/gomock"
 
+	"github.com/lucas-clemente/quic-go/internal/congestion"
 	"github.com/lucas-clemente/quic-go/internal/mocks"
+	mocklogging "github.com/lucas-clemente/quic-go/internal/mocks/logging"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/qerr"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/lucas-clemente/quic-go/internal/wire"
+	"github.com/lucas-clemente/quic-go/logging"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -29,7 +32,7 @@ var _ = Describe("SentPacketHandler", func() {
 	JustBeforeEach(func() {
 		lostPackets = nil
 		rttStats := utils.NewRTTStats()
-		handler = newSentPacketHandler(42, protocol.InitialPacketSizeIPv4, rttStats, perspective, nil, utils.DefaultLogger)
+		handler = newSentPacketHandler(42, protocol.InitialPacketSizeIPv4, rttStats, perspective, nil, utils.DefaultLogger, utils.ChooseHystart, utils.ChooseNewReno, nil, nil)
 		streamFrame = wire.StreamFrame{
 			StreamID: 5,
 			Data:     []byte{0x13, 0x37},
@@ -506,6 +509,102 @@ var _ = Describe("SentPacketHandler", func() {
 			})
 		})
 
+		It("uses the congestion controller created by the config", func() {
+			rttStats := &utils.RTTStats{}
+			conf := &congestion.Config{
+				NewSendAlgorithm: func(_ congestion.Clock, r *utils.RTTStats, size protocol.ByteCount) congestion.SendAlgorithmWithDebugInfos {
+					Expect(r).To(Equal(rttStats))
+					Expect(size).To(Equal(protocol.ByteCount(1234)))
+					return cong
+				},
+			}
+			h := newSentPacketHandler(42, 1234, rttStats, perspective, nil, utils.DefaultLogger, utils.ChooseHystart, utils.ChooseNewReno, conf, nil)
+			Expect(h.congestion).To(Equal(cong))
+		})
+
+		It("restarts the congestion controller after a suspension", func() {
+			cong.EXPECT().OnConnectionMigration()
+			handler.OnSuspend(time.Minute, true)
+		})
+
+		It("doesn't restart the congestion controller after a suspension, if not requested", func() {
+			handler.OnSuspend(time.Minute, false)
+		})
+
+		Context("tracing congestion metrics", func() {
+			var (
+				tracer *mocklogging.MockConnectionTracer
+				cwnd   protocol.ByteCount
+			)
+
+			JustBeforeEach(func() {
+				tracer = mocklogging.NewMockConnectionTracer(mockCtrl)
+				handler.tracer = tracer
+				tracer.EXPECT().UpdatedMetrics(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+				cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+				cwnd = 10000
+				cong.EXPECT().GetCongestionWindow().DoAndReturn(func() protocol.ByteCount { return cwnd }).AnyTimes()
+				cong.EXPECT().GetSlowStartThreshold().Return(protocol.MaxByteCount).AnyTimes()
+				cong.EXPECT().PacingRate().Return(congestion.Bandwidth(1e6)).AnyTimes()
+			})
+
+			It("traces the metrics when they change", func() {
+				tracer.EXPECT().UpdatedCongestionMetrics(protocol.ByteCount(10000), protocol.MaxByteCount, protocol.ByteCount(100), uint64(1e6))
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 1, Length: 100}))
+				// sending a packet that is not included in bytes in flight doesn't change any metric
+				handler.SentPacket(nonAckElicitingPacket(&Packet{PacketNumber: 2}))
+				cwnd = 12000
+				tracer.EXPECT().UpdatedCongestionMetrics(protocol.ByteCount(12000), protocol.MaxByteCount, protocol.ByteCount(200), uint64(1e6))
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 3, Length: 100}))
+			})
+
+			It("traces the metrics at most once per interval", func() {
+				handler.config = &Config{CongestionMetricsInterval: 100 * time.Millisecond}
+				now := time.Now()
+				tracer.EXPECT().UpdatedCongestionMetrics(protocol.ByteCount(10000), protocol.MaxByteCount, protocol.ByteCount(100), uint64(1e6))
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 1, Length: 100, SendTime: now}))
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2, Length: 100, SendTime: now.Add(50 * time.Millisecond)}))
+				tracer.EXPECT().UpdatedCongestionMetrics(protocol.ByteCount(10000), protocol.MaxByteCount, protocol.ByteCount(300), uint64(1e6))
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 3, Length: 100, SendTime: now.Add(100 * time.Millisecond)}))
+			})
+		})
+
+		It("traces the composition of packets", func() {
+			tracer := mocklogging.NewMockConnectionTracer(mockCtrl)
+			handler.tracer = tracer
+			tracer.EXPECT().UpdatedMetrics(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+			tracer.EXPECT().UpdatedCongestionMetrics(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+			cong.EXPECT().GetCongestionWindow().Return(protocol.ByteCount(1000)).AnyTimes()
+			cong.EXPECT().GetSlowStartThreshold().Return(protocol.MaxByteCount).AnyTimes()
+			cong.EXPECT().PacingRate().AnyTimes()
+			cong.EXPECT().PacingBudget().Return(protocol.ByteCount(500)).Times(2)
+			handler.ReceivedBytes(200)
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 1, Length: 400}))
+			p := ackElicitingPacket(&Packet{
+				PacketNumber: 2,
+				Length:       100,
+				Composition:  &logging.PacketComposition{Considered: logging.FrameSourceData, MaxSize: 1200},
+			})
+			tracer.EXPECT().ComposedPacket(protocol.Encryption1RTT, protocol.PacketNumber(2), &logging.PacketComposition{
+				Considered:             logging.FrameSourceData,
+				MaxSize:                1200,
+				PacingBudget:           500,
+				CongestionWindowBudget: 600,
+				AmplificationBudget:    200,
+			})
+			handler.SentPacket(p)
+			Expect(p.Composition).To(BeNil())
+			// once the address is validated, the anti-amplification limit doesn't apply any more
+			handler.peerAddressValidated = true
+			tracer.EXPECT().ComposedPacket(protocol.Encryption1RTT, protocol.PacketNumber(3), &logging.PacketComposition{
+				PacingBudget:           500,
+				CongestionWindowBudget: 500,
+				AmplificationBudget:    protocol.MaxByteCount,
+			})
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 3, Length: 100, Composition: &logging.PacketComposition{}}))
+		})
+
 		It("should call MaybeExitSlowStart and OnPacketAcked", func() {
 			rcvTime := time.Now().Add(-5 * time.Second)
 			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(3)
@@ -589,6 +688,100 @@ var _ = Describe("SentPacketHandler", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 
+		It("counts the packets sent and lost", func() {
+			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 1, Length: 100, SendTime: time.Now().Add(-time.Hour)}))
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2, Length: 200, PaddingLength: 50, SendTime: time.Now()}))
+			handler.ReceivedBytes(1000)
+			cong.EXPECT().MaybeExitSlowStart()
+			cong.EXPECT().OnPacketLost(protocol.PacketNumber(1), protocol.ByteCount(100), gomock.Any())
+			cong.EXPECT().OnPacketAcked(protocol.PacketNumber(2), protocol.ByteCount(200), gomock.Any(), gomock.Any())
+			ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 2, Largest: 2}}}
+			_, err := handler.ReceivedAck(ack, protocol.Encryption1RTT, time.Now())
+			Expect(err).ToNot(HaveOccurred())
+			cong.EXPECT().MaxCongestionWindowUsed().Return(protocol.ByteCount(12345))
+			Expect(handler.Stats()).To(Equal(Stats{
+				PacketsSent:         2,
+				PacketsLost:         1,
+				BytesSent:           300,
+				BytesReceived:       1000,
+				PaddingBytesSent:    50,
+				MaxCongestionWindow: 12345,
+			}))
+		})
+
+		It("counts padding towards bytes in flight", func() {
+			cong.EXPECT().OnPacketSent(gomock.Any(), protocol.ByteCount(1200), protocol.PacketNumber(1), protocol.ByteCount(1200), true)
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 1, Length: 1200, PaddingLength: 1000}))
+			Expect(handler.bytesInFlight).To(Equal(protocol.ByteCount(1200)))
+		})
+
+		Context("persistent congestion", func() {
+			var now time.Time
+
+			JustBeforeEach(func() {
+				now = time.Now()
+				cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+				cong.EXPECT().MaybeExitSlowStart().AnyTimes()
+				cong.EXPECT().OnPacketAcked(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+				cong.EXPECT().OnPacketLost(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+				// take the first RTT sample
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 1, SendTime: now.Add(-20 * time.Second)}))
+				ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 1, Largest: 1}}}
+				_, err := handler.ReceivedAck(ack, protocol.Encryption1RTT, now.Add(-19*time.Second))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(handler.rttStats.SmoothedRTT()).To(Equal(time.Second))
+			})
+
+			It("establishes persistent congestion if all packets sent during the persistent congestion duration are lost", func() {
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2, SendTime: now.Add(-15 * time.Second)}))
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 3, SendTime: now.Add(-10 * time.Second)}))
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 4, SendTime: now.Add(-2 * time.Second)}))
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 5, SendTime: now.Add(-time.Second)}))
+				cong.EXPECT().OnPersistentCongestion()
+				ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 5, Largest: 5}}}
+				_, err := handler.ReceivedAck(ack, protocol.Encryption1RTT, now)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lostPackets).To(Equal([]protocol.PacketNumber{2, 3, 4}))
+			})
+
+			It("doesn't establish persistent congestion if a packet in between was acknowledged", func() {
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2, SendTime: now.Add(-15 * time.Second)}))
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 3, SendTime: now.Add(-10 * time.Second)}))
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 4, SendTime: now.Add(-2 * time.Second)}))
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 5, SendTime: now.Add(-time.Second)}))
+				// don't EXPECT a call to OnPersistentCongestion
+				ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 5, Largest: 5}, {Smallest: 3, Largest: 3}}}
+				_, err := handler.ReceivedAck(ack, protocol.Encryption1RTT, now)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lostPackets).To(Equal([]protocol.PacketNumber{2, 4}))
+			})
+
+			It("doesn't establish persistent congestion if the lost packets were sent within the persistent congestion duration", func() {
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2, SendTime: now.Add(-5 * time.Second)}))
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 3, SendTime: now.Add(-2 * time.Second)}))
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 4, SendTime: now.Add(-time.Second)}))
+				// don't EXPECT a call to OnPersistentCongestion
+				ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 4, Largest: 4}}}
+				_, err := handler.ReceivedAck(ack, protocol.Encryption1RTT, now)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lostPackets).To(Equal([]protocol.PacketNumber{2, 3}))
+			})
+
+			It("only considers packets sent after the first RTT sample", func() {
+				handler.firstRTTSampleTime = now.Add(-12 * time.Second)
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2, SendTime: now.Add(-15 * time.Second)}))
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 3, SendTime: now.Add(-10 * time.Second)}))
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 4, SendTime: now.Add(-5 * time.Second)}))
+				handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 5, SendTime: now.Add(-time.Second)}))
+				// don't EXPECT a call to OnPersistentCongestion
+				ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 5, Largest: 5}}}
+				_, err := handler.ReceivedAck(ack, protocol.Encryption1RTT, now)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(lostPackets).To(Equal([]protocol.PacketNumber{2, 3, 4}))
+			})
+		})
+
 		It("passes the bytes in flight to the congestion controller", func() {
 			handler.ReceivedPacket(protocol.EncryptionHandshake)
 			cong.EXPECT().OnPacketSent(gomock.Any(), protocol.ByteCount(42), gomock.Any(), protocol.ByteCount(42), true)
@@ -602,6 +795,30 @@ var _ = Describe("SentPacketHandler", func() {
 			handler.SendMode()
 		})
 
+		It("passes the application-limited state to the congestion controller", func() {
+			handler.ReceivedPacket(protocol.EncryptionHandshake)
+			cong.EXPECT().OnPacketSent(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			handler.SentPacket(&Packet{
+				Length:          42,
+				EncryptionLevel: protocol.EncryptionInitial,
+				Frames:          []Frame{{Frame: &wire.PingFrame{}}},
+				SendTime:        time.Now(),
+			})
+			cong.EXPECT().SetApplicationLimited(true, protocol.ByteCount(42))
+			handler.SetApplicationLimited(true)
+		})
+
+		It("passes the maximum send rate to the congestion controller", func() {
+			cong.EXPECT().SetMaxSendRate(congestion.Bandwidth(1e6) * congestion.BytesPerSecond)
+			handler.SetMaxSendRate(1e6)
+		})
+
+		It("passes delivery rate reports to the congestion controller", func() {
+			now := time.Now()
+			cong.EXPECT().OnDeliveryRateReport(congestion.Bandwidth(1e6)*congestion.BytesPerSecond, 25*time.Millisecond, now)
+			handler.ReceivedDeliveryRate(1e6, 25*time.Millisecond, now)
+		})
+
 		It("allows sending of ACKs when congestion limited", func() {
 			handler.ReceivedPacket(protocol.EncryptionHandshake)
 			cong.EXPECT().CanSend(gomock.Any()).Return(true)
@@ -621,6 +838,15 @@ var _ = Describe("SentPacketHandler", func() {
 			Expect(handler.SendMode()).To(Equal(SendAck))
 		})
 
+		It("raises the limit on outstanding packets for large maximum congestion windows", func() {
+			handler = newSentPacketHandler(42, protocol.InitialPacketSizeIPv4, utils.NewRTTStats(), perspective, nil, utils.DefaultLogger, utils.ChooseHystart, utils.ChooseNewReno, &congestion.Config{MaxCongestionWindow: 3 * protocol.MaxCongestionWindowPackets}, nil)
+			Expect(handler.maxOutstandingSentPackets).To(Equal(6 * protocol.MaxCongestionWindowPackets))
+			Expect(handler.maxTrackedSentPackets).To(BeNumerically(">", handler.maxOutstandingSentPackets))
+			handler = newSentPacketHandler(42, protocol.InitialPacketSizeIPv4, utils.NewRTTStats(), perspective, nil, utils.DefaultLogger, utils.ChooseHystart, utils.ChooseNewReno, &congestion.Config{MaxCongestionWindow: 100}, nil)
+			Expect(handler.maxOutstandingSentPackets).To(Equal(protocol.MaxOutstandingSentPackets))
+			Expect(handler.maxTrackedSentPackets).To(Equal(protocol.MaxTrackedSentPackets))
+		})
+
 		It("allows PTOs, even when congestion limited", func() {
 			handler.ReceivedPacket(protocol.EncryptionHandshake)
 			// note that we don't EXPECT a call to GetCongestionWindow
@@ -1098,6 +1324,25 @@ var _ = Describe("SentPacketHandler", func() {
 			Expect(handler.SendMode()).To(Equal(SendAny))
 		})
 
+		It("shifts the loss detection alarm when the system was suspended", func() {
+			handler.ReceivedPacket(protocol.EncryptionHandshake)
+			handler.handshakeConfirmed = true
+			now := time.Now()
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 1, SendTime: now.Add(-2 * time.Second)}))
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 2, SendTime: now.Add(-2 * time.Second)}))
+			handler.SentPacket(ackElicitingPacket(&Packet{PacketNumber: 3, SendTime: now}))
+			ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 2, Largest: 2}}}
+			_, err := handler.ReceivedAck(ack, protocol.Encryption1RTT, now.Add(-time.Second))
+			Expect(err).ToNot(HaveOccurred())
+			timeout := handler.GetLossDetectionTimeout()
+			Expect(timeout).ToNot(BeZero())
+
+			handler.OnSuspend(time.Hour, false)
+			Expect(handler.GetLossDetectionTimeout()).To(Equal(timeout.Add(time.Hour)))
+			Expect(getPacket(1, protocol.Encryption1RTT).SendTime).To(Equal(now.Add(-2 * time.Second).Add(time.Hour)))
+			Expect(getPacket(3, protocol.Encryption1RTT).SendTime).To(Equal(now.Add(time.Hour)))
+		})
+
 		It("sets the early retransmit alarm for crypto packets", func() {
 			handler.ReceivedBytes(1000)
 			now := time.Now()
@@ -1282,6 +1527,32 @@ var _ = Describe("SentPacketHandler", func() {
 			Expect(pn).To(BeZero())
 			Expect(handler.PopPacketNumber(protocol.Encryption1RTT)).To(BeZero())
 		})
+
+		It("uses the configured minimum packet number length", func() {
+			handler = newSentPacketHandler(42, protocol.InitialPacketSizeIPv4, utils.NewRTTStats(), perspective, nil, utils.DefaultLogger, utils.ChooseHystart, utils.ChooseNewReno, nil, &Config{MinPacketNumberLength: protocol.PacketNumberLen3})
+			_, pnLen := handler.PeekPacketNumber(protocol.Encryption1RTT)
+			Expect(pnLen).To(Equal(protocol.PacketNumberLen3))
+		})
+
+		It("doesn't skip packet numbers, if disabled", func() {
+			handler = newSentPacketHandler(42, protocol.InitialPacketSizeIPv4, utils.NewRTTStats(), perspective, nil, utils.DefaultLogger, utils.ChooseHystart, utils.ChooseNewReno, nil, &Config{DisablePacketNumberSkipping: true})
+			for i := protocol.PacketNumber(0); i < 10*protocol.SkipPacketInitialPeriod; i++ {
+				Expect(handler.PopPacketNumber(protocol.Encryption1RTT)).To(Equal(i))
+			}
+		})
+
+		It("traces skipped packet numbers", func() {
+			mockCtrl := gomock.NewController(GinkgoT())
+			defer mockCtrl.Finish()
+			tracer := mocklogging.NewMockConnectionTracer(mockCtrl)
+			tracer.EXPECT().UpdatedCongestionState(gomock.Any()).AnyTimes()
+			handler = newSentPacketHandler(42, protocol.InitialPacketSizeIPv4, utils.NewRTTStats(), perspective, tracer, utils.DefaultLogger, utils.ChooseHystart, utils.ChooseNewReno, nil, &Config{PacketNumberSkipPeriod: 5})
+			tracer.EXPECT().SkippedPacketNumber(protocol.PacketNumber(5))
+			for i := protocol.PacketNumber(0); i < 5; i++ {
+				Expect(handler.PopPacketNumber(protocol.Encryption1RTT)).To(Equal(i))
+			}
+			Expect(handler.PopPacketNumber(protocol.Encryption1RTT)).To(Equal(protocol.PacketNumber(6)))
+		})
 	})
 
 	Context("for the client", func() {
@@ -13,6 +13,9 @@ type sentPacketHistory struct {
 	packetList  *PacketList
 	packetMap   map[protocol.PacketNumber]*PacketElement
 	highestSent protocol.PacketNumber
+
+	// the maximum number of entries in packetMap since the map was allocated
+	peakPacketMapSize int
 }
 
 func newSentPacketHistory(rttStats *utils.RTTStats) *sentPacketHistory {
@@ -44,6 +47,9 @@ func (h *sentPacketHistory) SentPacket(p *Packet, isAckEliciting bool) {
 		el := h.packetList.PushBack(*p)
 		h.packetMap[p.PacketNumber] = el
 	}
+	if l := len(h.packetMap); l > h.peakPacketMapSize {
+		h.peakPacketMapSize = l
+	}
 }
 
 // Iterate iterates through all packets.
@@ -106,3 +112,17 @@ func (h *sentPacketHistory) DeleteOldPackets(now time.Time) {
 		h.packetList.Remove(el)
 	}
 }
+
+// Compact reallocates the packet map, if most of the packets were removed since it was allocated.
+// Go maps never shrink, so the memory used by a large congestion window would otherwise be retained.
+func (h *sentPacketHistory) Compact() {
+	if h.peakPacketMapSize < protocol.MinMapCompactionSize || len(h.packetMap) > h.peakPacketMapSize/4 {
+		return
+	}
+	packetMap := make(map[protocol.PacketNumber]*PacketElement, len(h.packetMap))
+	for pn, el := range h.packetMap {
+		packetMap[pn] = el
+	}
+	h.packetMap = packetMap
+	h.peakPacketMapSize = len(packetMap)
+}
@@ -112,6 +112,21 @@ var _ = Describe("SentPacketHistory", func() {
 		Expect(err).To(MatchError("packet 2 not found in sent packet history"))
 	})
 
+	It("compacts the packet map after most packets were removed", func() {
+		const num = 2 * protocol.MinMapCompactionSize
+		for pn := protocol.PacketNumber(0); pn < num; pn++ {
+			hist.SentPacket(&Packet{PacketNumber: pn}, true)
+		}
+		hist.Compact()
+		Expect(hist.peakPacketMapSize).To(Equal(num))
+		for pn := protocol.PacketNumber(0); pn < num-2; pn++ {
+			Expect(hist.Remove(pn)).To(Succeed())
+		}
+		hist.Compact()
+		Expect(hist.peakPacketMapSize).To(Equal(2))
+		expectInHistory([]protocol.PacketNumber{num - 2, num - 1})
+	})
+
 	Context("iterating", func() {
 		BeforeEach(func() {
 			hist.SentPacket(&Packet{PacketNumber: 1}, true)
@@ -0,0 +1,108 @@
+// Package bulkdata generates pseudo-random test data on the fly.
+// It is used for throughput tests, where serving files would make the file system (or its cache) part of the measurement.
+// The data is fully determined by a seed and can therefore be verified by the receiver without transmitting a checksum.
+package bulkdata
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The generator is a Lehmer random number generator,
+// see https://en.wikipedia.org/wiki/Lehmer_random_number_generator.
+const (
+	lehmerMultiplier = 48271
+	lehmerModulus    = 2147483647
+)
+
+// DefaultSeed is the seed used by the example server if the client doesn't request a seed.
+const DefaultSeed = 1
+
+// ErrTooShort is returned by Verify if the data ended before the expected length was reached.
+var ErrTooShort = errors.New("bulkdata: data too short")
+
+// A MismatchError is returned by Verify if the data differs from the generated data.
+type MismatchError struct {
+	Offset int64
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("bulkdata: data differs at offset %d", e.Offset)
+}
+
+type generator struct {
+	state uint64
+}
+
+func newGenerator(seed uint64) *generator {
+	state := seed % lehmerModulus
+	if state == 0 { // 0 is a fixed point of the generator
+		state = 1
+	}
+	return &generator{state: state}
+}
+
+func (g *generator) fill(b []byte) {
+	for i := range b {
+		g.state = g.state * lehmerMultiplier % lehmerModulus
+		b[i] = byte(g.state)
+	}
+}
+
+type reader struct {
+	gen       *generator
+	remaining int64
+}
+
+// NewReader returns a reader that generates length bytes of pseudo-random data from the seed.
+// The data is generated when it is read, it is never held in memory as a whole.
+func NewReader(seed uint64, length int64) io.Reader {
+	return &reader{gen: newGenerator(seed), remaining: length}
+}
+
+func (r *reader) Read(b []byte) (int, error) {
+	if r.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(b)) > r.remaining {
+		b = b[:r.remaining]
+	}
+	r.gen.fill(b)
+	r.remaining -= int64(len(b))
+	return len(b), nil
+}
+
+// Verify reads from r until io.EOF and checks that it returns exactly the length bytes generated from the seed.
+// It returns the number of bytes read.
+// If the data differs, a *MismatchError is returned. If it ends early, ErrTooShort is returned.
+func Verify(r io.Reader, seed uint64, length int64) (int64, error) {
+	gen := newGenerator(seed)
+	buf := make([]byte, 32*1024)
+	expected := make([]byte, len(buf))
+	var offset int64
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if offset+int64(n) > length {
+				return offset + int64(n), &MismatchError{Offset: length}
+			}
+			gen.fill(expected[:n])
+			for i := 0; i < n; i++ {
+				if buf[i] != expected[i] {
+					return offset + int64(n), &MismatchError{Offset: offset + int64(i)}
+				}
+			}
+			offset += int64(n)
+		}
+		if err == io.EOF {
+			if offset < length {
+				return offset, ErrTooShort
+			}
+			return offset, nil
+		}
+		if err != nil {
+			return offset, err
+		}
+	}
+}
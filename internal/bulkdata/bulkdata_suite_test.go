@@ -0,0 +1,13 @@
+package bulkdata
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestBulkdata(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Bulkdata Suite")
+}
@@ -0,0 +1,82 @@
+package bulkdata
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing/iotest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bulk data", func() {
+	It("generates the requested number of bytes", func() {
+		data, err := ioutil.ReadAll(NewReader(42, 12345))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(HaveLen(12345))
+	})
+
+	It("generates the same data for the same seed, regardless of how it is read", func() {
+		data, err := ioutil.ReadAll(NewReader(42, 10000))
+		Expect(err).ToNot(HaveOccurred())
+		data2, err := ioutil.ReadAll(iotest.OneByteReader(NewReader(42, 10000)))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data2).To(Equal(data))
+		data3, err := ioutil.ReadAll(NewReader(43, 10000))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data3).ToNot(Equal(data))
+	})
+
+	It("treats the seed 0 like the seed 1", func() {
+		data, err := ioutil.ReadAll(NewReader(0, 100))
+		Expect(err).ToNot(HaveOccurred())
+		data2, err := ioutil.ReadAll(NewReader(1, 100))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal(data2))
+	})
+
+	It("generates the Lehmer sequence", func() {
+		data, err := ioutil.ReadAll(NewReader(1, 3))
+		Expect(err).ToNot(HaveOccurred())
+		// the lowest byte of 48271, 182605794 and 1291394886
+		Expect(data).To(Equal([]byte{0x8f, 0xe2, 0x46}))
+	})
+
+	It("verifies data", func() {
+		n, err := Verify(iotest.HalfReader(NewReader(7, 100000)), 7, 100000)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(n).To(BeEquivalentTo(100000))
+	})
+
+	It("detects corrupted data", func() {
+		data, err := ioutil.ReadAll(NewReader(7, 100000))
+		Expect(err).ToNot(HaveOccurred())
+		data[54321]++
+		_, err = Verify(bytes.NewReader(data), 7, 100000)
+		Expect(err).To(MatchError(&MismatchError{Offset: 54321}))
+	})
+
+	It("detects data generated from a different seed", func() {
+		_, err := Verify(NewReader(8, 1000), 7, 1000)
+		Expect(err).To(BeAssignableToTypeOf(&MismatchError{}))
+	})
+
+	It("detects data that is too short", func() {
+		n, err := Verify(NewReader(7, 999), 7, 1000)
+		Expect(err).To(MatchError(ErrTooShort))
+		Expect(n).To(BeEquivalentTo(999))
+	})
+
+	It("detects data that is too long", func() {
+		_, err := Verify(NewReader(7, 1001), 7, 1000)
+		Expect(err).To(MatchError(&MismatchError{Offset: 1000}))
+	})
+
+	It("returns read errors", func() {
+		testErr := errors.New("test error")
+		_, err := Verify(io.MultiReader(NewReader(7, 100), iotest.ErrReader(testErr)), 7, 1000)
+		Expect(err).To(MatchError(testErr))
+	})
+})
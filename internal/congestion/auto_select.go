@@ -0,0 +1,93 @@
+package congestion
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+const (
+	// Paths with a min RTT of at least autoLongRTT use SEARCH and Cubic.
+	// The RTT increase threshold of HyStart is clamped to 16ms, which is easily exceeded by the jitter on such paths,
+	// and the growth of Reno in congestion avoidance is proportional to the RTT.
+	autoLongRTT = 300 * time.Millisecond
+	// Paths with a bandwidth-delay product of at least autoHighBDPPackets use Cubic.
+	autoHighBDPPackets = 100
+)
+
+// The autoSelector selects the start and the congestion control algorithm, based on the path characteristics
+// measured during the first round trip after the first RTT sample.
+// The bandwidth is estimated from the dispersion of the ACKs for the initial window, which is sent without pacing.
+// Until the selection is made, the sender uses standard slow start.
+type autoSelector struct {
+	selectStart      bool
+	selectCongestion bool
+
+	firstAckTime time.Time
+	lastAckTime  time.Time
+	ackedBytes   protocol.ByteCount // acknowledged after the first ACK of the probe round
+}
+
+func newAutoSelector(startAlgo utils.StartAlgo, congestionAlgo utils.CongestionAlgo) *autoSelector {
+	if startAlgo != utils.ChooseAutoStart && congestionAlgo != utils.ChooseAutoCongestion {
+		return nil
+	}
+	return &autoSelector{
+		selectStart:      startAlgo == utils.ChooseAutoStart,
+		selectCongestion: congestionAlgo == utils.ChooseAutoCongestion,
+	}
+}
+
+// OnPacketAcked is called for every acknowledged packet.
+// It returns true once the probe round is complete and the algorithms can be selected.
+func (s *autoSelector) OnPacketAcked(ackedBytes protocol.ByteCount, eventTime time.Time, minRTT time.Duration) bool {
+	if minRTT == 0 {
+		return false
+	}
+	if s.firstAckTime.IsZero() {
+		s.firstAckTime = eventTime
+		s.lastAckTime = eventTime
+		return false
+	}
+	if eventTime.Sub(s.firstAckTime) >= minRTT {
+		return true
+	}
+	s.ackedBytes += ackedBytes
+	s.lastAckTime = eventTime
+	return false
+}
+
+// BandwidthEstimate is the bandwidth estimated from the dispersion of the ACKs.
+// It is 0 if the bandwidth couldn't be estimated.
+func (s *autoSelector) BandwidthEstimate() Bandwidth {
+	if s.ackedBytes == 0 || !s.lastAckTime.After(s.firstAckTime) {
+		return 0
+	}
+	return BandwidthFromDelta(s.ackedBytes, s.lastAckTime.Sub(s.firstAckTime))
+}
+
+// Select selects the algorithms for a path with the given min RTT.
+// The algorithms that are not selected automatically are returned unchanged.
+func (s *autoSelector) Select(
+	startAlgo utils.StartAlgo,
+	congestionAlgo utils.CongestionAlgo,
+	minRTT time.Duration,
+	maxDatagramSize protocol.ByteCount,
+) (utils.StartAlgo, utils.CongestionAlgo) {
+	longRTT := minRTT >= autoLongRTT
+	bdp := float64(s.BandwidthEstimate()/BytesPerSecond) * minRTT.Seconds()
+	if s.selectStart {
+		startAlgo = utils.ChooseHystartpp
+		if longRTT {
+			startAlgo = utils.ChooseSearch
+		}
+	}
+	if s.selectCongestion {
+		congestionAlgo = utils.ChooseNewReno
+		if longRTT || bdp >= float64(autoHighBDPPackets*maxDatagramSize) {
+			congestionAlgo = utils.ChooseCubic
+		}
+	}
+	return startAlgo, congestionAlgo
+}
@@ -0,0 +1,83 @@
+package congestion
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Automatic algorithm selection", func() {
+	It("is only used if an algorithm is selected automatically", func() {
+		Expect(newAutoSelector(utils.ChooseHystart, utils.ChooseCubic)).To(BeNil())
+		Expect(newAutoSelector(utils.ChooseAutoStart, utils.ChooseCubic)).ToNot(BeNil())
+		Expect(newAutoSelector(utils.ChooseHystart, utils.ChooseAutoCongestion)).ToNot(BeNil())
+	})
+
+	It("waits for an RTT sample", func() {
+		s := newAutoSelector(utils.ChooseAutoStart, utils.ChooseAutoCongestion)
+		now := time.Now()
+		Expect(s.OnPacketAcked(1000, now, 0)).To(BeFalse())
+		Expect(s.OnPacketAcked(1000, now.Add(time.Second), 0)).To(BeFalse())
+		Expect(s.BandwidthEstimate()).To(BeZero())
+	})
+
+	It("estimates the bandwidth from the dispersion of the ACKs during the probe round", func() {
+		s := newAutoSelector(utils.ChooseAutoStart, utils.ChooseAutoCongestion)
+		now := time.Now()
+		Expect(s.OnPacketAcked(1000, now, 50*time.Millisecond)).To(BeFalse())
+		// one packet acknowledged every millisecond: 1 MB/s
+		for i := 1; i <= 10; i++ {
+			Expect(s.OnPacketAcked(1000, now.Add(time.Duration(i)*time.Millisecond), 50*time.Millisecond)).To(BeFalse())
+		}
+		Expect(s.BandwidthEstimate()).To(Equal(1000 * 1000 * BytesPerSecond))
+		// the probe round is complete after one min RTT
+		Expect(s.OnPacketAcked(1000, now.Add(50*time.Millisecond), 50*time.Millisecond)).To(BeTrue())
+		Expect(s.BandwidthEstimate()).To(Equal(1000 * 1000 * BytesPerSecond))
+	})
+
+	Context("selecting", func() {
+		selectFor := func(minRTT time.Duration, bandwidth Bandwidth) (utils.StartAlgo, utils.CongestionAlgo) {
+			s := newAutoSelector(utils.ChooseAutoStart, utils.ChooseAutoCongestion)
+			now := time.Now()
+			s.OnPacketAcked(1000, now, minRTT)
+			s.OnPacketAcked(protocol.ByteCount(bandwidth/BytesPerSecond/1000), now.Add(time.Millisecond), minRTT)
+			Expect(s.BandwidthEstimate()).To(Equal(bandwidth))
+			return s.Select(utils.ChooseAutoStart, utils.ChooseAutoCongestion, minRTT, 1000)
+		}
+
+		It("uses HyStart++ and NewReno on paths with a small bandwidth-delay product", func() {
+			// BDP: 50 kB
+			startAlgo, congestionAlgo := selectFor(50*time.Millisecond, 1000*1000*BytesPerSecond)
+			Expect(startAlgo).To(Equal(utils.ChooseHystartpp))
+			Expect(congestionAlgo).To(Equal(utils.ChooseNewReno))
+		})
+
+		It("uses Cubic on paths with a large bandwidth-delay product", func() {
+			// BDP: 500 kB
+			startAlgo, congestionAlgo := selectFor(50*time.Millisecond, 10*1000*1000*BytesPerSecond)
+			Expect(startAlgo).To(Equal(utils.ChooseHystartpp))
+			Expect(congestionAlgo).To(Equal(utils.ChooseCubic))
+		})
+
+		It("uses SEARCH and Cubic on paths with a long RTT", func() {
+			startAlgo, congestionAlgo := selectFor(autoLongRTT, 1000*BytesPerSecond)
+			Expect(startAlgo).To(Equal(utils.ChooseSearch))
+			Expect(congestionAlgo).To(Equal(utils.ChooseCubic))
+		})
+
+		It("doesn't change algorithms that are not selected automatically", func() {
+			s := newAutoSelector(utils.ChooseHystart, utils.ChooseAutoCongestion)
+			startAlgo, congestionAlgo := s.Select(utils.ChooseHystart, utils.ChooseNewReno, autoLongRTT, 1000)
+			Expect(startAlgo).To(Equal(utils.ChooseHystart))
+			Expect(congestionAlgo).To(Equal(utils.ChooseCubic))
+			s = newAutoSelector(utils.ChooseAutoStart, utils.ChooseNewReno)
+			startAlgo, congestionAlgo = s.Select(utils.ChooseSlowStart, utils.ChooseNewReno, autoLongRTT, 1000)
+			Expect(startAlgo).To(Equal(utils.ChooseSearch))
+			Expect(congestionAlgo).To(Equal(utils.ChooseNewReno))
+		})
+	})
+})
@@ -0,0 +1,161 @@
+package congestion
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+// The phases of Careful Resume, see draft-ietf-tsvwg-careful-resume.
+type carefulResumePhase uint8
+
+const (
+	// Reconnaissance: the sender uses the normal congestion window, while it validates that the path didn't change.
+	carefulResumeReconnaissance carefulResumePhase = iota
+	// Unvalidated: the sender jumped to the saved congestion window, and waits for the first ACK for data sent using this window.
+	carefulResumeUnvalidated
+	// Validating: the sender waits for all data sent in the Unvalidated phase to be acknowledged.
+	carefulResumeValidating
+	// Normal: Careful Resume is done (or was aborted).
+	carefulResumeNormal
+)
+
+func (p carefulResumePhase) String() string {
+	switch p {
+	case carefulResumeReconnaissance:
+		return "reconnaissance"
+	case carefulResumeUnvalidated:
+		return "unvalidated"
+	case carefulResumeValidating:
+		return "validating"
+	case carefulResumeNormal:
+		return "normal"
+	default:
+		return "unknown"
+	}
+}
+
+// The saved RTT is only used if the current RTT is within
+// [savedRTT / carefulResumeRTTLowFactor, savedRTT * carefulResumeRTTHighFactor].
+const (
+	carefulResumeRTTLowFactor  = 2
+	carefulResumeRTTHighFactor = 10
+)
+
+// carefulResume implements the Careful Resume state machine.
+type carefulResume struct {
+	phase carefulResumePhase
+
+	savedCongestionWindow protocol.ByteCount
+	savedRTT              time.Duration
+
+	// the number of bytes acknowledged in the Reconnaissance phase
+	ackedBytes protocol.ByteCount
+
+	lastSentPacketNumber protocol.PacketNumber
+	// the first and the last packet number sent in the Unvalidated phase
+	firstUnvalidatedPacketNumber protocol.PacketNumber
+	lastUnvalidatedPacketNumber  protocol.PacketNumber
+
+	// pipeSize is the number of bytes acknowledged since entering the Unvalidated phase.
+	pipeSize protocol.ByteCount
+}
+
+func newCarefulResume(savedCongestionWindow protocol.ByteCount, savedRTT time.Duration) *carefulResume {
+	return &carefulResume{
+		savedCongestionWindow:        savedCongestionWindow,
+		savedRTT:                     savedRTT,
+		lastSentPacketNumber:         protocol.InvalidPacketNumber,
+		firstUnvalidatedPacketNumber: protocol.InvalidPacketNumber,
+		lastUnvalidatedPacketNumber:  protocol.InvalidPacketNumber,
+	}
+}
+
+// Active says if Careful Resume is still in progress.
+func (r *carefulResume) Active() bool {
+	return r.phase != carefulResumeNormal
+}
+
+// InUnvalidated says if the sender is using the unvalidated congestion window.
+// The congestion window must not be increased during this phase.
+func (r *carefulResume) InUnvalidated() bool {
+	return r.phase == carefulResumeUnvalidated
+}
+
+func (r *carefulResume) OnPacketSent(packetNumber protocol.PacketNumber) {
+	r.lastSentPacketNumber = packetNumber
+	if r.phase == carefulResumeUnvalidated {
+		if r.firstUnvalidatedPacketNumber == protocol.InvalidPacketNumber {
+			r.firstUnvalidatedPacketNumber = packetNumber
+		}
+		r.lastUnvalidatedPacketNumber = packetNumber
+	}
+}
+
+// OnPacketAcked is called for every acknowledged packet.
+// It returns the new congestion window, if the congestion window needs to be changed.
+func (r *carefulResume) OnPacketAcked(
+	packetNumber protocol.PacketNumber,
+	ackedBytes protocol.ByteCount,
+	congestionWindow protocol.ByteCount,
+	initialCongestionWindow protocol.ByteCount,
+	priorInFlight protocol.ByteCount,
+	cwndLimited bool,
+	rtt time.Duration,
+) (protocol.ByteCount, bool) {
+	switch r.phase {
+	case carefulResumeReconnaissance:
+		r.ackedBytes += ackedBytes
+		// Confirm that the RTT of the path is comparable to the RTT of the previous connection.
+		if rtt < r.savedRTT/carefulResumeRTTLowFactor || rtt > r.savedRTT*carefulResumeRTTHighFactor {
+			r.phase = carefulResumeNormal
+			return 0, false
+		}
+		// Wait until the initial window was acknowledged, and the sender is limited by the congestion window.
+		if r.ackedBytes < initialCongestionWindow || !cwndLimited {
+			return 0, false
+		}
+		jumpWindow := r.savedCongestionWindow / 2
+		if jumpWindow <= congestionWindow {
+			r.phase = carefulResumeNormal
+			return 0, false
+		}
+		r.phase = carefulResumeUnvalidated
+		r.pipeSize = priorInFlight
+		return jumpWindow, true
+	case carefulResumeUnvalidated:
+		r.pipeSize += ackedBytes
+		if r.firstUnvalidatedPacketNumber == protocol.InvalidPacketNumber || packetNumber < r.firstUnvalidatedPacketNumber {
+			return 0, false
+		}
+		// The first packet sent using the jump window was acknowledged.
+		// Reduce the congestion window to the number of bytes actually in flight.
+		r.phase = carefulResumeValidating
+		return utils.MaxByteCount(priorInFlight, r.pipeSize), true
+	case carefulResumeValidating:
+		r.pipeSize += ackedBytes
+		if packetNumber >= r.lastUnvalidatedPacketNumber {
+			r.phase = carefulResumeNormal
+		}
+	}
+	return 0, false
+}
+
+// OnPacketLost is called when a packet is declared lost.
+// It returns the new congestion window, if a Safe Retreat is necessary.
+func (r *carefulResume) OnPacketLost() (protocol.ByteCount, bool) {
+	switch r.phase {
+	case carefulResumeReconnaissance:
+		r.phase = carefulResumeNormal
+	case carefulResumeUnvalidated, carefulResumeValidating:
+		r.phase = carefulResumeNormal
+		return r.pipeSize / 2, true
+	}
+	return 0, false
+}
+
+// Abort aborts Careful Resume, e.g. after a retransmission timeout.
+func (r *carefulResume) Abort() {
+	r.phase = carefulResumeNormal
+}
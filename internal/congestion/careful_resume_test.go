@@ -0,0 +1,94 @@
+package congestion
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Careful Resume", func() {
+	const (
+		savedCwnd   = 100 * maxDatagramSize
+		initialCwnd = 10 * maxDatagramSize
+		savedRTT    = 100 * time.Millisecond
+	)
+
+	var cr *carefulResume
+
+	BeforeEach(func() {
+		cr = newCarefulResume(savedCwnd, savedRTT)
+	})
+
+	// jump sends 10 packets, acknowledges all of them and jumps to the saved window
+	jump := func() {
+		for pn := protocol.PacketNumber(1); pn <= 10; pn++ {
+			cr.OnPacketSent(pn)
+		}
+		for pn := protocol.PacketNumber(1); pn < 10; pn++ {
+			_, ok := cr.OnPacketAcked(pn, maxDatagramSize, initialCwnd, initialCwnd, initialCwnd, true, savedRTT)
+			Expect(ok).To(BeFalse())
+		}
+		cwnd, ok := cr.OnPacketAcked(10, maxDatagramSize, initialCwnd, initialCwnd, initialCwnd, true, savedRTT)
+		Expect(ok).To(BeTrue())
+		Expect(cwnd).To(Equal(savedCwnd / 2))
+		Expect(cr.InUnvalidated()).To(BeTrue())
+	}
+
+	It("jumps to half the saved window after the initial window was acknowledged", func() {
+		jump()
+	})
+
+	It("doesn't jump if the RTT changed too much", func() {
+		cr.OnPacketSent(1)
+		_, ok := cr.OnPacketAcked(1, initialCwnd, initialCwnd, initialCwnd, initialCwnd, true, savedRTT*11)
+		Expect(ok).To(BeFalse())
+		Expect(cr.Active()).To(BeFalse())
+	})
+
+	It("doesn't jump if the sender is not cwnd limited", func() {
+		cr.OnPacketSent(1)
+		_, ok := cr.OnPacketAcked(1, initialCwnd, initialCwnd, initialCwnd, 0, false, savedRTT)
+		Expect(ok).To(BeFalse())
+		Expect(cr.Active()).To(BeTrue())
+	})
+
+	It("validates the jump window", func() {
+		jump()
+		for pn := protocol.PacketNumber(11); pn <= 50; pn++ {
+			cr.OnPacketSent(pn)
+		}
+		_, ok := cr.OnPacketAcked(10, maxDatagramSize, savedCwnd/2, initialCwnd, 40*maxDatagramSize, true, savedRTT)
+		Expect(ok).To(BeFalse())
+		cwnd, ok := cr.OnPacketAcked(11, maxDatagramSize, savedCwnd/2, initialCwnd, 40*maxDatagramSize, true, savedRTT)
+		Expect(ok).To(BeTrue())
+		Expect(cwnd).To(Equal(40 * maxDatagramSize))
+		Expect(cr.InUnvalidated()).To(BeFalse())
+		Expect(cr.Active()).To(BeTrue())
+		// once the last packet sent in the unvalidated phase is acknowledged, Careful Resume is done
+		for pn := protocol.PacketNumber(12); pn <= 50; pn++ {
+			_, ok := cr.OnPacketAcked(pn, maxDatagramSize, 40*maxDatagramSize, initialCwnd, 40*maxDatagramSize, true, savedRTT)
+			Expect(ok).To(BeFalse())
+		}
+		Expect(cr.Active()).To(BeFalse())
+	})
+
+	It("performs a safe retreat on packet loss", func() {
+		jump()
+		for pn := protocol.PacketNumber(11); pn <= 20; pn++ {
+			cr.OnPacketSent(pn)
+		}
+		cwnd, ok := cr.OnPacketLost()
+		Expect(ok).To(BeTrue())
+		Expect(cwnd).To(Equal(initialCwnd / 2))
+		Expect(cr.Active()).To(BeFalse())
+	})
+
+	It("aborts when a packet is lost before the jump", func() {
+		cr.OnPacketSent(1)
+		_, ok := cr.OnPacketLost()
+		Expect(ok).To(BeFalse())
+		Expect(cr.Active()).To(BeFalse())
+	})
+})
@@ -0,0 +1,185 @@
+package congestion
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+// Config contains the parameters of the congestion controller.
+// The zero value of each field selects the default behavior.
+type Config struct {
+	// The congestion window and the RTT of a previous connection on the same path.
+	// If both are set, the sender uses Careful Resume (draft-ietf-tsvwg-careful-resume) to jump-start the connection.
+	ResumeCongestionWindow protocol.ByteCount
+	ResumeRTT              time.Duration
+
+	// The initial congestion window, in packets.
+	InitialCongestionWindow protocol.ByteCount
+	// The maximum and the minimum congestion window, in packets.
+	MaxCongestionWindow protocol.ByteCount
+	MinCongestionWindow protocol.ByteCount
+
+	// The backoff factor of Reno.
+	RenoBeta float64
+	// The scaling constant C and the backoff factor of Cubic (RFC 8312).
+	CubicC    float64
+	CubicBeta float64
+	// Don't use the TCP-friendly region of Cubic.
+	DisableCubicTCPFriendliness bool
+
+	// Use Congestion Window Validation (RFC 7661).
+	// The congestion window is halved for every non-validated period that the sender is application-limited or idle.
+	CongestionWindowValidation bool
+	// The non-validated period. If not set, the PTO is used.
+	NonValidatedPeriod time.Duration
+
+	// Don't reduce the congestion window for losses that are classified as random losses.
+	// LossClassifier is used to classify the losses. If it is nil, the losses are classified based on the trend of the RTT.
+	LossDifferentiation bool
+	LossClassifier      LossClassifier
+
+	// The maximum burst size of the pacer, in packets.
+	PacerMaxBurst protocol.ByteCount
+	// The factor by which the pacing rate exceeds the bandwidth estimate.
+	PacingGain float64
+	// The minimum time between two wake-ups of the pacing timer.
+	// Packets that are due in the meantime are sent in a single burst.
+	PacingGranularity time.Duration
+	// Send packets as soon as the congestion window allows it.
+	// Paced Chirping still paces the chirps it sends during slow start.
+	DisablePacing bool
+	// The maximum send rate. It is enforced by a token bucket, independent of the congestion controller.
+	MaxSendRate Bandwidth
+	// The emulated bandwidth profile. It is repeated after its last step.
+	// The send rate is limited to the lower of the rate of the current step and the MaxSendRate.
+	EmulationProfile []EmulationStep
+	// Don't reduce the bytes in flight while probing for a new min RTT.
+	DisableMinRTTProbeDip bool
+
+	// Called when the congestion window was reduced in response to congestion, or when slow start was exited.
+	// The congestion window is passed in bytes.
+	OnCongestionEvent func(Event, protocol.ByteCount)
+
+	// Called when the algorithms were selected, if the start or the congestion control algorithm is selected automatically
+	// (utils.ChooseAutoStart and utils.ChooseAutoCongestion).
+	OnAlgorithmsSelected func(utils.StartAlgo, utils.CongestionAlgo)
+
+	// Creates the congestion controller, instead of the built-in Cubic / Reno sender.
+	// All other parameters are only used by the built-in sender.
+	NewSendAlgorithm func(clock Clock, rttStats *utils.RTTStats, initialMaxDatagramSize protocol.ByteCount) SendAlgorithmWithDebugInfos
+
+	// Parameters of HyStart and HyStart++ (RFC 9406).
+	// The number of RTT samples per round needed before checking for an RTT increase (N_RTT_SAMPLE).
+	HybridStartRTTSamples uint32
+	// The clamping of the RTT increase threshold (MIN_RTT_THRESH and MAX_RTT_THRESH).
+	HybridStartMinRTTThreshold time.Duration
+	HybridStartMaxRTTThreshold time.Duration
+	// The minimum congestion window (in packets) for HyStart to exit slow start.
+	HybridStartLowWindow protocol.ByteCount
+	// The congestion window grows this many times slower during Conservative Slow Start (CSS_GROWTH_DIVISOR).
+	HybridStartCSSGrowthDivisor uint32
+}
+
+func (c *Config) initialCongestionWindowPackets() protocol.ByteCount {
+	if c == nil || c.InitialCongestionWindow == 0 {
+		return initialCongestionWindow
+	}
+	return c.InitialCongestionWindow
+}
+
+func (c *Config) maxCongestionWindowPackets() protocol.ByteCount {
+	if c == nil || c.MaxCongestionWindow == 0 {
+		return protocol.MaxCongestionWindowPackets
+	}
+	return c.MaxCongestionWindow
+}
+
+func (c *Config) minCongestionWindowPackets() protocol.ByteCount {
+	if c == nil || c.MinCongestionWindow == 0 {
+		return minCongestionWindowPackets
+	}
+	return c.MinCongestionWindow
+}
+
+func (c *Config) renoBeta() float64 {
+	if c == nil || c.RenoBeta == 0 {
+		return renoBeta
+	}
+	return c.RenoBeta
+}
+
+func (c *Config) cubicC() float64 {
+	if c == nil || c.CubicC == 0 {
+		return cubicC
+	}
+	return c.CubicC
+}
+
+func (c *Config) cubicBeta() float32 {
+	if c == nil || c.CubicBeta == 0 {
+		return beta
+	}
+	return float32(c.CubicBeta)
+}
+
+func (c *Config) disableCubicTCPFriendliness() bool {
+	return c != nil && c.DisableCubicTCPFriendliness
+}
+
+func (c *Config) pacerMaxBurstPackets() protocol.ByteCount {
+	if c == nil || c.PacerMaxBurst == 0 {
+		return maxBurstSizePackets
+	}
+	return c.PacerMaxBurst
+}
+
+func (c *Config) pacingGain() float64 {
+	if c == nil || c.PacingGain == 0 {
+		return pacingGain
+	}
+	return c.PacingGain
+}
+
+func (c *Config) pacingGranularity() time.Duration {
+	if c == nil || c.PacingGranularity == 0 {
+		return protocol.MinPacingDelay
+	}
+	return c.PacingGranularity
+}
+
+func (c *Config) hybridStartRTTSamples(def uint32) uint32 {
+	if c == nil || c.HybridStartRTTSamples == 0 {
+		return def
+	}
+	return c.HybridStartRTTSamples
+}
+
+func (c *Config) hybridStartMinRTTThreshold(def time.Duration) time.Duration {
+	if c == nil || c.HybridStartMinRTTThreshold == 0 {
+		return def
+	}
+	return c.HybridStartMinRTTThreshold
+}
+
+func (c *Config) hybridStartMaxRTTThreshold(def time.Duration) time.Duration {
+	if c == nil || c.HybridStartMaxRTTThreshold == 0 {
+		return def
+	}
+	return c.HybridStartMaxRTTThreshold
+}
+
+func (c *Config) hybridStartLowWindow() protocol.ByteCount {
+	if c == nil || c.HybridStartLowWindow == 0 {
+		return hybridStartLowWindow
+	}
+	return c.HybridStartLowWindow
+}
+
+func (c *Config) hybridStartCSSGrowthDivisor() protocol.ByteCount {
+	if c == nil || c.HybridStartCSSGrowthDivisor == 0 {
+		return hybridStartppCSSGrowthDivisor
+	}
+	return protocol.ByteCount(c.HybridStartCSSGrowthDivisor)
+}
@@ -18,9 +18,8 @@ import (
 // 1024*1024^3 (first 1024 is from 0.100^3)
 // where 0.100 is 100 ms which is the scaling round trip time.
 const (
-	cubeScale                                    = 40
-	cubeCongestionWindowScale                    = 410
-	cubeFactor                protocol.ByteCount = 1 << cubeScale / cubeCongestionWindowScale / maxDatagramSize
+	cubeScale                 = 40
+	cubeCongestionWindowScale = 410
 	// TODO: when re-enabling cubic, make sure to use the actual packet size here
 	maxDatagramSize = protocol.ByteCount(protocol.InitialPacketSizeIPv4)
 )
@@ -35,6 +34,9 @@ const beta float32 = 0.7
 // new concurrent flows and speed up convergence.
 const betaLastMax float32 = 0.85
 
+// Default Cubic scaling constant C
+const cubicC = 0.4
+
 // Cubic implements the cubic algorithm from TCP
 type Cubic struct {
 	clock Clock
@@ -42,6 +44,14 @@ type Cubic struct {
 	// Number of connections to simulate.
 	numConnections int
 
+	// The backoff factor, and the additional backoff factor (see betaLastMax).
+	backoff            float32
+	backoffLastMax     float32
+	congestionWinScale int64
+	cubeFactor         protocol.ByteCount
+	// Use the TCP-friendly region, see Section 4.2 of RFC 8312.
+	tcpFriendly bool
+
 	// Time when this cycle started, after last loss event.
 	epoch time.Time
 
@@ -68,9 +78,28 @@ type Cubic struct {
 
 // NewCubic returns a new Cubic instance
 func NewCubic(clock Clock) *Cubic {
+	return newCubic(clock, nil)
+}
+
+func newCubic(clock Clock, config *Config) *Cubic {
+	backoff := config.cubicBeta()
+	backoffLastMax := betaLastMax
+	if backoff != beta {
+		// fast convergence, see Section 4.6 of RFC 8312
+		backoffLastMax = (1 + backoff) / 2
+	}
+	congestionWinScale := cubeCongestionWindowScale
+	if cc := config.cubicC(); cc != cubicC {
+		congestionWinScale = utils.Max(int(math.Round(cc*1024)), 1)
+	}
 	c := &Cubic{
-		clock:          clock,
-		numConnections: defaultNumConnections,
+		clock:              clock,
+		numConnections:     defaultNumConnections,
+		backoff:            backoff,
+		backoffLastMax:     backoffLastMax,
+		congestionWinScale: int64(congestionWinScale),
+		cubeFactor:         1 << cubeScale / protocol.ByteCount(congestionWinScale) / maxDatagramSize,
+		tcpFriendly:        !config.disableCubicTCPFriendliness(),
 	}
 	c.Reset()
 	return c
@@ -100,7 +129,7 @@ func (c *Cubic) beta() float32 {
 	// emulation, which emulates the effective backoff of an ensemble of N
 	// TCP-Reno connections on a single loss event. The effective multiplier is
 	// computed as:
-	return (float32(c.numConnections) - 1 + beta) / float32(c.numConnections)
+	return (float32(c.numConnections) - 1 + c.backoff) / float32(c.numConnections)
 }
 
 func (c *Cubic) betaLastMax() float32 {
@@ -108,7 +137,7 @@ func (c *Cubic) betaLastMax() float32 {
 	// N-connection emulation, which emulates the additional backoff of
 	// an ensemble of N TCP-Reno connections on a single loss event. The
 	// effective multiplier is computed as:
-	return (float32(c.numConnections) - 1 + betaLastMax) / float32(c.numConnections)
+	return (float32(c.numConnections) - 1 + c.backoffLastMax) / float32(c.numConnections)
 }
 
 // OnApplicationLimited is called on ack arrival when sender is unable to use
@@ -162,7 +191,7 @@ func (c *Cubic) CongestionWindowAfterAck(
 			c.timeToOriginPoint = 0
 			c.originPointCongestionWindow = currentCongestionWindow
 		} else {
-			c.timeToOriginPoint = uint32(math.Cbrt(float64(cubeFactor * (c.lastMaxCongestionWindow - currentCongestionWindow))))
+			c.timeToOriginPoint = uint32(math.Cbrt(float64(c.cubeFactor * (c.lastMaxCongestionWindow - currentCongestionWindow))))
 			c.originPointCongestionWindow = c.lastMaxCongestionWindow
 		}
 	}
@@ -179,7 +208,7 @@ func (c *Cubic) CongestionWindowAfterAck(
 		offset = -offset
 	}
 
-	deltaCongestionWindow := protocol.ByteCount(cubeCongestionWindowScale*offset*offset*offset) * maxDatagramSize >> cubeScale
+	deltaCongestionWindow := protocol.ByteCount(c.congestionWinScale*offset*offset*offset) * maxDatagramSize >> cubeScale
 	var targetCongestionWindow protocol.ByteCount
 	if elapsedTime > int64(c.timeToOriginPoint) {
 		targetCongestionWindow = c.originPointCongestionWindow + deltaCongestionWindow
@@ -202,7 +231,7 @@ func (c *Cubic) CongestionWindowAfterAck(
 
 	// Compute target congestion_window based on cubic target and estimated TCP
 	// congestion_window, use highest (fastest).
-	if targetCongestionWindow < c.estimatedTCPcongestionWindow {
+	if c.tcpFriendly && targetCongestionWindow < c.estimatedTCPcongestionWindow {
 		targetCongestionWindow = c.estimatedTCPcongestionWindow
 	}
 	return targetCongestionWindow
@@ -22,14 +22,49 @@ const (
 type cubicSender struct {
 	hybridSlowStart HybridSlowStart
 	hybridSlowStartpp HybridSlowStartpp
+	pacedChirping   PacedChirping
+	search          Search
 	rttStats        *utils.RTTStats
 	cubic           *Cubic
 	pacer           *pacer
 	clock           Clock
 
+	// only set when the send rate is limited
+	rateLimiter          *pacer
+	pacerMaxBurstPackets protocol.ByteCount
+	pacingGranularity    time.Duration
+	// the rate set by MaxSendRate or SetMaxSendRate, 0 if the rate is not limited
+	maxSendRate Bandwidth
+
+	// only set when a bandwidth profile is emulated
+	emulation         *emulationProfile
+	emulatedRate      Bandwidth
+	emulationNextStep time.Time
+
+	// if set, the bytes in flight are not reduced while probing for a new min RTT
+	disableMinRTTProbeDip bool
+
 	chosenStartAlgo utils.StartAlgo
 	chosenCongestionAlgo utils.CongestionAlgo
 
+	// only set until the algorithms are selected, if they are selected automatically
+	autoSelector         *autoSelector
+	onAlgorithmsSelected func(utils.StartAlgo, utils.CongestionAlgo)
+
+	onCongestionEvent func(Event, protocol.ByteCount)
+
+	// the largest congestion window that was in use when a packet was sent
+	maxCongestionWindowUsed protocol.ByteCount
+
+	// only set when resuming the congestion state of a previous connection
+	carefulResume *carefulResume
+
+	// only set when Congestion Window Validation is used
+	cwndValidation *cwndValidation
+
+	// only set when loss differentiation is used
+	lossClassifier LossClassifier
+
 	// Track the largest packet that has been sent.
 	largestSentPacketNumber protocol.PacketNumber
 
@@ -39,6 +74,14 @@ type cubicSender struct {
 	// Track the largest packet number outstanding when a CWND cutback occurs.
 	largestSentAtLastCutback protocol.PacketNumber
 
+	// Set by the application. The congestion window isn't increased while the sender is application-limited.
+	appLimited bool
+
+	// the last delivery rate reported by the receiver
+	receiverRate         Bandwidth
+	receiverRateInterval time.Duration
+	receiverRateTime     time.Time
+
 	// Whether the last loss event caused us to exit slowstart.
 	// Used for stats collection of slowstartPacketsLost
 	lastCutbackExitedSlowstart bool
@@ -55,10 +98,19 @@ type cubicSender struct {
 	initialCongestionWindow    protocol.ByteCount
 	initialMaxCongestionWindow protocol.ByteCount
 
+	// The backoff factor of Reno
+	renoBeta float64
+
+	// The limits of the congestion window, in packets.
+	maxCongestionWindowPackets protocol.ByteCount
+	minCongestionWindowPackets protocol.ByteCount
+
 	maxDatagramSize protocol.ByteCount
 
 	lastState logging.CongestionState
-	tracer    logging.ConnectionTracer
+	// the next send time reported by the last PacerDelayedSend event
+	lastPacerDelay time.Time
+	tracer         logging.ConnectionTracer
 }
 
 var (
@@ -73,8 +125,13 @@ func NewCubicSender(
 	initialMaxDatagramSize protocol.ByteCount,
 	chosenStartAlgo utils.StartAlgo,
 	chosenCongestionAlgo utils.CongestionAlgo,
+	config *Config,
 	tracer logging.ConnectionTracer,
 ) *cubicSender {
+	initialCongestionWindow := utils.MinByteCount(
+		utils.MaxByteCount(config.initialCongestionWindowPackets(), config.minCongestionWindowPackets()),
+		config.maxCongestionWindowPackets(),
+	)
 	return newCubicSender(
 		clock,
 		rttStats,
@@ -82,7 +139,8 @@ func NewCubicSender(
 		chosenCongestionAlgo,
 		initialMaxDatagramSize,
 		initialCongestionWindow*initialMaxDatagramSize,
-		protocol.MaxCongestionWindowPackets*initialMaxDatagramSize,
+		config.maxCongestionWindowPackets()*initialMaxDatagramSize,
+		config,
 		tracer,
 	)
 }
@@ -95,8 +153,12 @@ func newCubicSender(
 	initialMaxDatagramSize,
 	initialCongestionWindow,
 	initialMaxCongestionWindow protocol.ByteCount,
+	config *Config,
 	tracer logging.ConnectionTracer,
 ) *cubicSender {
+	if config == nil {
+		config = &Config{}
+	}
 	c := &cubicSender{
 		rttStats:                   rttStats,
 		largestSentPacketNumber:    protocol.InvalidPacketNumber,
@@ -104,16 +166,55 @@ func newCubicSender(
 		largestSentAtLastCutback:   protocol.InvalidPacketNumber,
 		initialCongestionWindow:    initialCongestionWindow,
 		initialMaxCongestionWindow: initialMaxCongestionWindow,
+		renoBeta:                   config.renoBeta(),
+		maxCongestionWindowPackets: config.maxCongestionWindowPackets(),
+		minCongestionWindowPackets: config.minCongestionWindowPackets(),
 		congestionWindow:           initialCongestionWindow,
 		slowStartThreshold:         protocol.MaxByteCount,
-		cubic:                      NewCubic(clock),
+		cubic:                      newCubic(clock, config),
 		clock:                      clock,
 		chosenStartAlgo:			chosenStartAlgo,
 		chosenCongestionAlgo:		chosenCongestionAlgo,
 		tracer:                     tracer,
 		maxDatagramSize:            initialMaxDatagramSize,
+		pacerMaxBurstPackets:       config.pacerMaxBurstPackets(),
+		pacingGranularity:          config.pacingGranularity(),
+		disableMinRTTProbeDip:      config.DisableMinRTTProbeDip,
+	}
+	c.hybridSlowStart.config = config
+	if c.autoSelector = newAutoSelector(chosenStartAlgo, chosenCongestionAlgo); c.autoSelector != nil {
+		// Use standard slow start until the algorithms are selected.
+		if chosenStartAlgo == utils.ChooseAutoStart {
+			c.chosenStartAlgo = utils.ChooseSlowStart
+		}
+		if chosenCongestionAlgo == utils.ChooseAutoCongestion {
+			c.chosenCongestionAlgo = utils.ChooseNewReno
+		}
+		c.onAlgorithmsSelected = config.OnAlgorithmsSelected
+	}
+	c.hybridSlowStartpp.config = config
+	c.onCongestionEvent = config.OnCongestionEvent
+	if config.ResumeCongestionWindow > 0 && config.ResumeRTT > 0 {
+		c.carefulResume = newCarefulResume(config.ResumeCongestionWindow, config.ResumeRTT)
+	}
+	if config.CongestionWindowValidation {
+		c.cwndValidation = newCwndValidation(rttStats, config.NonValidatedPeriod)
+	}
+	if config.LossDifferentiation {
+		c.lossClassifier = config.LossClassifier
+		if c.lossClassifier == nil {
+			c.lossClassifier = newDelayLossClassifier(rttStats)
+		}
+	}
+	if !config.DisablePacing {
+		c.pacer = newPacer(c.BandwidthEstimate, config)
+	}
+	c.maxSendRate = config.MaxSendRate
+	if len(config.EmulationProfile) > 0 {
+		c.emulation = newEmulationProfile(config.EmulationProfile, clock.Now())
+		c.emulatedRate, c.emulationNextStep = c.emulation.At(clock.Now())
 	}
-	c.pacer = newPacer(c.BandwidthEstimate)
+	c.applySendRate()
 	if c.tracer != nil {
 		c.lastState = logging.CongestionStateSlowStart
 		c.tracer.UpdatedCongestionState(logging.CongestionStateSlowStart)
@@ -123,19 +224,90 @@ func newCubicSender(
 
 // TimeUntilSend returns when the next packet should be sent.
 func (c *cubicSender) TimeUntilSend(_ protocol.ByteCount) time.Time {
-	return c.pacer.TimeUntilSend()
+	c.updateEmulatedRate(c.clock.Now())
+	var t time.Time
+	if c.inPacedChirping() {
+		t = c.pacedChirping.TimeUntilSend()
+	} else if c.pacer != nil {
+		t = c.pacer.TimeUntilSend()
+	}
+	if c.rateLimiter != nil {
+		t = utils.MaxTime(t, c.rateLimiter.TimeUntilSend())
+	}
+	// The send rate changes when the next step of the emulated profile starts.
+	if c.emulation != nil && t.After(c.emulationNextStep) {
+		t = c.emulationNextStep
+	}
+	return t
 }
 
 func (c *cubicSender) HasPacingBudget() bool {
+	if c.hasPacingBudget() {
+		return true
+	}
+	c.maybeTracePacerDelay()
+	return false
+}
+
+func (c *cubicSender) hasPacingBudget() bool {
+	c.updateEmulatedRate(c.clock.Now())
+	if c.rateLimiter != nil && c.rateLimiter.Budget(c.clock.Now()) < c.maxDatagramSize {
+		return false
+	}
+	if c.inPacedChirping() {
+		return !c.clock.Now().Before(c.pacedChirping.TimeUntilSend())
+	}
+	if c.pacer == nil {
+		return true
+	}
 	return c.pacer.Budget(c.clock.Now()) >= c.maxDatagramSize
 }
 
+// maybeTracePacerDelay traces that the pacer delayed sending.
+// Since HasPacingBudget is called repeatedly while waiting for the pacing timer, it is traced once per next send time.
+func (c *cubicSender) maybeTracePacerDelay() {
+	if c.tracer == nil {
+		return
+	}
+	nextSendTime := c.TimeUntilSend(0)
+	if nextSendTime.Equal(c.lastPacerDelay) {
+		return
+	}
+	c.lastPacerDelay = nextSendTime
+	c.tracer.PacerDelayedSend(c.PacingBudget(), nextSendTime)
+}
+
+// PacingBudget returns the number of bytes that the pacer (or Paced Chirping) and the rate limiter allow to be sent.
+// It returns protocol.MaxByteCount if packets are not paced.
+func (c *cubicSender) PacingBudget() protocol.ByteCount {
+	now := c.clock.Now()
+	c.updateEmulatedRate(now)
+	budget := protocol.MaxByteCount
+	if c.inPacedChirping() {
+		budget = 0
+		if !now.Before(c.pacedChirping.TimeUntilSend()) {
+			budget = c.maxDatagramSize
+		}
+	} else if c.pacer != nil {
+		budget = c.pacer.Budget(now)
+	}
+	if c.rateLimiter != nil {
+		budget = utils.MinByteCount(budget, c.rateLimiter.Budget(now))
+	}
+	return budget
+}
+
+// inPacedChirping says if the packets are paced by Paced Chirping, instead of the pacer.
+func (c *cubicSender) inPacedChirping() bool {
+	return c.chosenStartAlgo == utils.ChoosePacedChirping && c.InSlowStart() && c.pacedChirping.Active()
+}
+
 func (c *cubicSender) maxCongestionWindow() protocol.ByteCount {
-	return c.maxDatagramSize * protocol.MaxCongestionWindowPackets
+	return c.maxDatagramSize * c.maxCongestionWindowPackets
 }
 
 func (c *cubicSender) minCongestionWindow() protocol.ByteCount {
-	return c.maxDatagramSize * minCongestionWindowPackets
+	return c.maxDatagramSize * c.minCongestionWindowPackets
 }
 
 func (c *cubicSender) OnPacketSent(
@@ -145,20 +317,62 @@ func (c *cubicSender) OnPacketSent(
 	bytes protocol.ByteCount,
 	isRetransmittable bool,
 ) {
-	c.pacer.SentPacket(sentTime, bytes)
+	if c.pacer != nil {
+		c.pacer.SentPacket(sentTime, bytes)
+	}
+	if c.rateLimiter != nil {
+		c.rateLimiter.SentPacket(sentTime, bytes)
+	}
 	if !isRetransmittable {
 		return
 	}
 	c.largestSentPacketNumber = packetNumber
+	if c.congestionWindow > c.maxCongestionWindowUsed {
+		c.maxCongestionWindowUsed = c.congestionWindow
+	}
+	if c.cwndValidation != nil {
+		c.maybeDecayCwnd(sentTime)
+	}
+	if c.carefulResume != nil {
+		c.carefulResume.OnPacketSent(packetNumber)
+	}
 	switch(c.chosenStartAlgo){
 	case utils.ChooseHystart:
 		c.hybridSlowStart.OnPacketSent(packetNumber)
 	case utils.ChooseHystartpp:
 		c.hybridSlowStartpp.OnPacketSent(packetNumber)
+	case utils.ChoosePacedChirping:
+		if c.InSlowStart() {
+			c.pacedChirping.OnPacketSent(sentTime, packetNumber)
+		}
+	}
+}
+
+// maybeDecayCwnd decays the congestion window if it wasn't validated recently.
+// This prevents a sender that was application-limited or idle from sending a burst with a stale congestion window.
+func (c *cubicSender) maybeDecayCwnd(sentTime time.Time) {
+	if c.carefulResume != nil && c.carefulResume.Active() {
+		return
+	}
+	wasInSlowStart := c.InSlowStart()
+	c.congestionWindow, c.slowStartThreshold = c.cwndValidation.Update(
+		sentTime,
+		c.congestionWindow,
+		c.slowStartThreshold,
+		utils.MaxByteCount(c.initialCongestionWindow, c.minCongestionWindow()),
+	)
+	if !wasInSlowStart && c.InSlowStart() {
+		c.cubic.Reset()
+		c.maybeTraceStateChange(logging.CongestionStateSlowStart)
 	}
 }
 
 func (c *cubicSender) CanSend(bytesInFlight protocol.ByteCount) bool {
+	// While probing for a new min RTT, only keep the minimum congestion window in flight.
+	// This drains the queue at the bottleneck, such that the RTT samples reflect the path RTT.
+	if c.rttStats.ProbingMinRTT() && !c.disableMinRTTProbeDip {
+		return bytesInFlight < utils.MinByteCount(c.minCongestionWindow(), c.GetCongestionWindow())
+	}
 	return bytesInFlight < c.GetCongestionWindow()
 }
 
@@ -170,14 +384,36 @@ func (c *cubicSender) InSlowStart() bool {
 	return c.GetCongestionWindow() < c.slowStartThreshold
 }
 
+// InLowSlowStart says if the sender is in the Conservative Slow Start phase of HyStart++.
 func (c *cubicSender) InLowSlowStart() bool {
-	return c.chosenStartAlgo == utils.ChooseHystartpp && c.hybridSlowStartpp.IsInLSS()
+	return c.chosenStartAlgo == utils.ChooseHystartpp && c.InSlowStart() && c.hybridSlowStartpp.InCSS()
 }
 
 func (c *cubicSender) GetCongestionWindow() protocol.ByteCount {
 	return c.congestionWindow
 }
 
+func (c *cubicSender) MaxCongestionWindowUsed() protocol.ByteCount {
+	return c.maxCongestionWindowUsed
+}
+
+func (c *cubicSender) GetSlowStartThreshold() protocol.ByteCount {
+	return c.slowStartThreshold
+}
+
+// PacingRate returns the rate of the pacer, or of the rate limiter, if it imposes a lower rate.
+// Until the first RTT sample, the bandwidth estimate is unknown, and only the rate limiter paces packets.
+func (c *cubicSender) PacingRate() Bandwidth {
+	var rate Bandwidth
+	if c.pacer != nil && c.rttStats.SmoothedRTT() != 0 {
+		rate = c.pacer.Rate()
+	}
+	if c.rateLimiter != nil && (rate == 0 || c.rateLimiter.Rate() < rate) {
+		rate = c.rateLimiter.Rate()
+	}
+	return rate
+}
+
 func (c *cubicSender) MaybeExitSlowStart() {
 	if c.InSlowStart(){
 		switch c.chosenStartAlgo {
@@ -185,16 +421,42 @@ func (c *cubicSender) MaybeExitSlowStart() {
 			// do not exit slow start
 			break
 		case utils.ChooseHystart:
-			if c.hybridSlowStart.ShouldExitSlowStart(c.rttStats.LatestRTT(), c.rttStats.MinRTT(), c.GetCongestionWindow()/c.maxDatagramSize) {
+			if c.hybridSlowStart.ShouldExitSlowStart(c.rttStats.FilteredRTT(), c.rttStats.MinRTT(), c.GetCongestionWindow()/c.maxDatagramSize) {
 				// exit slow start
 				c.slowStartThreshold = c.congestionWindow
 				c.maybeTraceStateChange(logging.CongestionStateCongestionAvoidance)
+				c.reportCongestionEvent(EventSlowStartExit)
 			}
 			break
 		case utils.ChooseHystartpp:
-			if c.hybridSlowStartpp.ShouldExitSlowStart(c.rttStats.LatestRTT(), c.rttStats.MinRTT(), c.GetCongestionWindow()/c.maxDatagramSize){
+			if c.hybridSlowStartpp.ShouldExitSlowStart(c.rttStats.FilteredRTT()) {
+				// Conservative Slow Start is complete
 				c.slowStartThreshold = c.congestionWindow
-				c.maybeTraceStateChange(logging.CongestionStateLowSlowStart)
+				c.maybeTraceStateChange(logging.CongestionStateCongestionAvoidance)
+				c.reportCongestionEvent(EventSlowStartExit)
+			}
+			break
+		case utils.ChoosePacedChirping:
+			minRTT := c.rttStats.MinRTT()
+			if minRTT == 0 {
+				break
+			}
+			// The first chirp is sent at the rate of the initial congestion window.
+			c.pacedChirping.Start(minRTT * time.Duration(c.maxDatagramSize) / time.Duration(c.congestionWindow))
+			if gap, ok := c.pacedChirping.CapacityEstimate(); ok {
+				// Set the congestion window to the estimated bandwidth-delay product.
+				cwnd := protocol.ByteCount(uint64(minRTT) * uint64(c.maxDatagramSize) / uint64(gap))
+				c.congestionWindow = utils.MinByteCount(utils.MaxByteCount(cwnd, c.minCongestionWindow()), c.maxCongestionWindow())
+				c.slowStartThreshold = c.congestionWindow
+				c.maybeTraceStateChange(logging.CongestionStateCongestionAvoidance)
+				c.reportCongestionEvent(EventSlowStartExit)
+			}
+			break
+		case utils.ChooseSearch:
+			if c.search.ShouldExitSlowStart() {
+				c.slowStartThreshold = c.congestionWindow
+				c.maybeTraceStateChange(logging.CongestionStateCongestionAvoidance)
+				c.reportCongestionEvent(EventSlowStartExit)
 			}
 			break
 		} 
@@ -208,9 +470,25 @@ func (c *cubicSender) OnPacketAcked(
 	eventTime time.Time,
 ) {
 	c.largestAckedPacketNumber = utils.MaxPacketNumber(ackedPacketNumber, c.largestAckedPacketNumber)
+	if c.cwndValidation != nil {
+		c.cwndValidation.OnPacketAcked(ackedBytes, eventTime)
+	}
+	if c.lossClassifier != nil {
+		c.lossClassifier.OnPacketAcked(eventTime)
+	}
+	if c.autoSelector != nil && c.autoSelector.OnPacketAcked(ackedBytes, eventTime, c.rttStats.MinRTT()) {
+		c.selectAlgorithms()
+	}
 	if c.InRecovery() {
 		return
 	}
+	if c.carefulResume != nil && c.carefulResume.Active() {
+		c.onPacketAckedCarefulResume(ackedPacketNumber, ackedBytes, priorInFlight)
+		if c.carefulResume.InUnvalidated() {
+			// The congestion window is not increased while it is unvalidated.
+			return
+		}
+	}
 	c.maybeIncreaseCwnd(ackedPacketNumber, ackedBytes, priorInFlight, eventTime)
 	if c.InSlowStart() {
 		switch c.chosenStartAlgo {
@@ -218,19 +496,82 @@ func (c *cubicSender) OnPacketAcked(
 			c.hybridSlowStart.OnPacketAcked(ackedPacketNumber)
 		case utils.ChooseHystartpp:
 			c.hybridSlowStartpp.OnPacketAcked(ackedPacketNumber)
+		case utils.ChoosePacedChirping:
+			c.pacedChirping.OnPacketAcked(ackedPacketNumber, eventTime)
+		case utils.ChooseSearch:
+			c.search.OnPacketAcked(ackedBytes, eventTime, c.rttStats.SmoothedRTT())
 		}
 	}
 }
 
+func (c *cubicSender) onPacketAckedCarefulResume(ackedPacketNumber protocol.PacketNumber, ackedBytes, priorInFlight protocol.ByteCount) {
+	phase := c.carefulResume.phase
+	cwnd, ok := c.carefulResume.OnPacketAcked(
+		ackedPacketNumber,
+		ackedBytes,
+		c.congestionWindow,
+		c.initialCongestionWindow,
+		priorInFlight,
+		c.isCwndLimited(priorInFlight),
+		c.rttStats.LatestRTT(),
+	)
+	if ok {
+		c.congestionWindow = utils.MinByteCount(utils.MaxByteCount(cwnd, c.minCongestionWindow()), c.maxCongestionWindow())
+	}
+	c.maybeTraceCarefulResume(phase)
+}
+
+// carefulResumeSafeRetreat is called when a packet is lost while Careful Resume is active.
+// It returns true if the congestion window was reduced.
+func (c *cubicSender) carefulResumeSafeRetreat() bool {
+	phase := c.carefulResume.phase
+	cwnd, ok := c.carefulResume.OnPacketLost()
+	c.maybeTraceCarefulResume(phase)
+	if !ok {
+		return false
+	}
+	c.congestionWindow = utils.MaxByteCount(cwnd, c.minCongestionWindow())
+	c.slowStartThreshold = c.congestionWindow
+	c.largestSentAtLastCutback = c.largestSentPacketNumber
+	c.numAckedPackets = 0
+	c.maybeTraceStateChange(logging.CongestionStateRecovery)
+	c.reportCongestionEvent(EventLoss)
+	return true
+}
+
+func (c *cubicSender) maybeTraceCarefulResume(oldPhase carefulResumePhase) {
+	if c.tracer == nil || c.carefulResume.phase == oldPhase {
+		return
+	}
+	c.tracer.Debug("careful_resume", fmt.Sprintf("%s -> %s (cwnd: %d)", oldPhase, c.carefulResume.phase, c.congestionWindow))
+}
+
 func (c *cubicSender) OnPacketLost(packetNumber protocol.PacketNumber, lostBytes, priorInFlight protocol.ByteCount) {
-	// TCP NewReno (RFC6582) says that once a loss occurs, any losses in packets
-	// already sent should be treated as a single loss event, since it's expected.
-	if c.InLowSlowStart() {
-		//hystart++ should only be used once. After getting in congestion avoidance, we switch to standard Slow Start
-		c.hybridSlowStartpp.QuitLSS()
+	// A loss ends the probe round.
+	if c.autoSelector != nil {
+		c.selectAlgorithms()
+	}
+	if c.carefulResume != nil && c.carefulResume.Active() && packetNumber > c.largestSentAtLastCutback {
+		if c.carefulResumeSafeRetreat() {
+			return
+		}
+	}
+	if c.lossClassifier != nil && packetNumber > c.largestSentAtLastCutback {
+		random := c.lossClassifier.IsRandomLoss()
+		if c.tracer != nil {
+			c.tracer.ClassifiedPacketLoss(packetNumber, random)
+		}
+		if random {
+			return
+		}
+	}
+	// HyStart++ is only used for the initial slow start, see section 4.4 of RFC 9406.
+	// The same applies to Paced Chirping.
+	if c.chosenStartAlgo == utils.ChooseHystartpp || c.chosenStartAlgo == utils.ChoosePacedChirping {
 		c.chosenStartAlgo = utils.ChooseSlowStart
-		c.maybeTraceStateChange(logging.CongestionStateCongestionAvoidance)
 	}
+	// TCP NewReno (RFC6582) says that once a loss occurs, any losses in packets
+	// already sent should be treated as a single loss event, since it's expected.
 	switch c.chosenCongestionAlgo {
 	case utils.ChooseNewReno:
 		if packetNumber <= c.largestSentAtLastCutback {
@@ -239,7 +580,7 @@ func (c *cubicSender) OnPacketLost(packetNumber protocol.PacketNumber, lostBytes
 		c.lastCutbackExitedSlowstart = c.InSlowStart()
 		c.maybeTraceStateChange(logging.CongestionStateRecovery)
 
-		c.congestionWindow = protocol.ByteCount(float64(c.congestionWindow) * renoBeta)
+		c.congestionWindow = protocol.ByteCount(float64(c.congestionWindow) * c.renoBeta)
 
 		if minCwnd := c.minCongestionWindow(); c.congestionWindow < minCwnd {
 			c.congestionWindow = minCwnd
@@ -249,6 +590,7 @@ func (c *cubicSender) OnPacketLost(packetNumber protocol.PacketNumber, lostBytes
 		// reset packet count from congestion avoidance mode. We start
 		// counting again when we're out of recovery.
 		c.numAckedPackets = 0
+		c.reportCongestionEvent(EventLoss)
 		break
 	
 	case utils.ChooseCubic:
@@ -268,10 +610,24 @@ func (c *cubicSender) OnPacketLost(packetNumber protocol.PacketNumber, lostBytes
 		// reset packet count from congestion avoidance mode. We start
 		// counting again when we're out of recovery.
 		c.numAckedPackets = 0
+		c.reportCongestionEvent(EventLoss)
 		break
 	}
 }
 
+// selectAlgorithms selects the algorithms that are selected automatically, based on the measurements of the probe round.
+func (c *cubicSender) selectAlgorithms() {
+	bandwidth := c.autoSelector.BandwidthEstimate()
+	c.chosenStartAlgo, c.chosenCongestionAlgo = c.autoSelector.Select(c.chosenStartAlgo, c.chosenCongestionAlgo, c.rttStats.MinRTT(), c.maxDatagramSize)
+	c.autoSelector = nil
+	if c.tracer != nil {
+		c.tracer.Debug("algorithm_selection", fmt.Sprintf("start: %s, congestion: %s (min RTT: %s, bandwidth: %d bit/s)", c.chosenStartAlgo, c.chosenCongestionAlgo, c.rttStats.MinRTT(), bandwidth))
+	}
+	if c.onAlgorithmsSelected != nil {
+		c.onAlgorithmsSelected(c.chosenStartAlgo, c.chosenCongestionAlgo)
+	}
+}
+
 // Called when we receive an ack. Normal TCP tracks how many packets one ack
 // represents, but quic has a separate ack for each packet.
 func (c *cubicSender) maybeIncreaseCwnd(
@@ -291,34 +647,19 @@ func (c *cubicSender) maybeIncreaseCwnd(
 		return
 	}
 	if c.InSlowStart() {
-		c.maybeTraceStateChange(logging.CongestionStateSlowStart)
 		// TCP slow start, exponential growth, increase by one for each ACK.
-		switch c.chosenStartAlgo{
-		case utils.ChooseSlowStart, utils.ChooseHystart:
+		switch c.chosenStartAlgo {
+		case utils.ChooseSlowStart, utils.ChooseHystart, utils.ChoosePacedChirping, utils.ChooseSearch:
+			c.maybeTraceStateChange(logging.CongestionStateSlowStart)
 			c.congestionWindow += c.maxDatagramSize
 		case utils.ChooseHystartpp:
-			c.congestionWindow = c.hybridSlowStartpp.UpdateCwndHystartppSlowStart(ackedBytes, c.congestionWindow, c.maxDatagramSize)		
-		}
-	
-	} else if c.InLowSlowStart() {
-		//RFC recommends to compare hystartpp Cwnd to Congestion Avoidance algorithm computed Cwnd
-		c.maybeTraceStateChange(logging.CongestionStateLowSlowStart)
-		caWindow := c.congestionWindow
-		switch c.chosenCongestionAlgo{
-		case utils.ChooseNewReno:
-			if c.numAckedPackets >= uint64(c.congestionWindow/c.maxDatagramSize) {
-				caWindow = c.congestionWindow + c.maxDatagramSize
+			if c.hybridSlowStartpp.InCSS() {
+				c.maybeTraceStateChange(logging.CongestionStateLowSlowStart)
+			} else {
+				c.maybeTraceStateChange(logging.CongestionStateSlowStart)
 			}
-			//else: keep caWindow = c.congestionWindow
-		case utils.ChooseCubic:
-			caWindow = utils.MinByteCount(c.maxCongestionWindow(), c.cubic.CongestionWindowAfterAck(ackedBytes, c.congestionWindow, c.rttStats.MinRTT(), eventTime))
-			//fallthrough
-		default:
-			//cubic window is used 
-			caWindow = utils.MinByteCount(c.maxCongestionWindow(), c.cubic.CongestionWindowAfterAck(ackedBytes, c.congestionWindow, c.rttStats.MinRTT(), eventTime))
+			c.congestionWindow = utils.MinByteCount(c.maxCongestionWindow(), c.hybridSlowStartpp.CongestionWindowAfterAck(ackedBytes, c.congestionWindow, c.maxDatagramSize))
 		}
-		c.congestionWindow = c.hybridSlowStartpp.UpdateCwndHystartppLowSlowStart(ackedBytes, c.congestionWindow, c.maxDatagramSize, c.slowStartThreshold, caWindow)		
-	
 	} else {
 		// Congestion avoidance
 		c.maybeTraceStateChange(logging.CongestionStateCongestionAvoidance)
@@ -337,6 +678,9 @@ func (c *cubicSender) maybeIncreaseCwnd(
 }
 
 func (c *cubicSender) isCwndLimited(bytesInFlight protocol.ByteCount) bool {
+	if c.appLimited {
+		return false
+	}
 	congestionWindow := c.GetCongestionWindow()
 	if bytesInFlight >= congestionWindow {
 		return true
@@ -346,6 +690,57 @@ func (c *cubicSender) isCwndLimited(bytesInFlight protocol.ByteCount) bool {
 	return slowStartLimited || availableBytes <= maxBurstPackets*c.maxDatagramSize
 }
 
+func (c *cubicSender) SetApplicationLimited(limited bool, _ protocol.ByteCount) {
+	c.appLimited = limited
+}
+
+func (c *cubicSender) SetMaxSendRate(rate Bandwidth) {
+	c.maxSendRate = rate
+	c.applySendRate()
+}
+
+// updateEmulatedRate applies the rate of the emulated bandwidth profile, when the next step started.
+func (c *cubicSender) updateEmulatedRate(now time.Time) {
+	if c.emulation == nil || now.Before(c.emulationNextStep) {
+		return
+	}
+	c.emulatedRate, c.emulationNextStep = c.emulation.At(now)
+	c.applySendRate()
+}
+
+// applySendRate configures the rate limiter to the lower of the maximum send rate and the emulated rate.
+func (c *cubicSender) applySendRate() {
+	rate := c.maxSendRate
+	if c.emulatedRate > 0 && (rate == 0 || c.emulatedRate < rate) {
+		rate = c.emulatedRate
+	}
+	if rate == 0 {
+		c.rateLimiter = nil
+		return
+	}
+	if c.rateLimiter != nil {
+		c.rateLimiter.SetRate(rate)
+		return
+	}
+	c.rateLimiter = newRateLimiter(rate, c.pacerMaxBurstPackets, c.pacingGranularity)
+	c.rateLimiter.SetMaxDatagramSize(c.maxDatagramSize)
+}
+
+// OnDeliveryRateReport stores the delivery rate reported by the receiver.
+// Cubic and NewReno don't use it to adjust the congestion window.
+func (c *cubicSender) OnDeliveryRateReport(rate Bandwidth, interval time.Duration, eventTime time.Time) {
+	c.receiverRate = rate
+	c.receiverRateInterval = interval
+	c.receiverRateTime = eventTime
+}
+
+// ReceiverDeliveryRate returns the last delivery rate reported by the receiver,
+// and the time when the report was received.
+// It returns a zero time if the receiver didn't report any delivery rate (yet).
+func (c *cubicSender) ReceiverDeliveryRate() (Bandwidth, time.Time) {
+	return c.receiverRate, c.receiverRateTime
+}
+
 // BandwidthEstimate returns the current bandwidth estimate
 func (c *cubicSender) BandwidthEstimate() Bandwidth {
 	srtt := c.rttStats.SmoothedRTT()
@@ -362,15 +757,34 @@ func (c *cubicSender) OnRetransmissionTimeout(packetsRetransmitted bool) {
 	if !packetsRetransmitted {
 		return
 	}
+	c.slowStartThreshold = c.congestionWindow / 2
+	c.collapseCongestionWindow()
+}
+
+// OnPersistentCongestion is called when persistent congestion is established.
+// The congestion window is reduced to the minimum congestion window, see section 7.6.2 of RFC 9002.
+// The slow start threshold was already reduced when the lost packets were reported.
+func (c *cubicSender) OnPersistentCongestion() {
+	c.largestSentAtLastCutback = protocol.InvalidPacketNumber
+	c.collapseCongestionWindow()
+	c.reportCongestionEvent(EventPersistentCongestion)
+}
+
+func (c *cubicSender) collapseCongestionWindow() {
+	if c.carefulResume != nil {
+		c.carefulResume.Abort()
+	}
 	switch c.chosenStartAlgo{
-	case utils.ChooseHystartpp:
-		c.hybridSlowStartpp.Restart()
+	case utils.ChooseHystartpp, utils.ChoosePacedChirping:
+		// HyStart++ and Paced Chirping are only used for the initial slow start, see section 4.4 of RFC 9406.
+		c.chosenStartAlgo = utils.ChooseSlowStart
 	case utils.ChooseHystart:
 		c.hybridSlowStart.Restart()
+	case utils.ChooseSearch:
+		c.search.Restart()
 	}
 	
 	c.cubic.Reset()
-	c.slowStartThreshold = c.congestionWindow / 2
 	c.congestionWindow = c.minCongestionWindow()
 }
 
@@ -381,6 +795,10 @@ func (c *cubicSender) OnConnectionMigration() {
 		c.hybridSlowStart.Restart()
 	case utils.ChooseHystartpp:
 		c.hybridSlowStartpp.Restart()
+	case utils.ChoosePacedChirping:
+		c.pacedChirping.Restart()
+	case utils.ChooseSearch:
+		c.search.Restart()
 	}
 	
 	c.largestSentPacketNumber = protocol.InvalidPacketNumber
@@ -393,6 +811,12 @@ func (c *cubicSender) OnConnectionMigration() {
 	c.slowStartThreshold = c.initialMaxCongestionWindow
 }
 
+func (c *cubicSender) reportCongestionEvent(e Event) {
+	if c.onCongestionEvent != nil {
+		c.onCongestionEvent(e, c.congestionWindow)
+	}
+}
+
 func (c *cubicSender) maybeTraceStateChange(new logging.CongestionState) {
 	if c.tracer == nil || new == c.lastState {
 		return
@@ -401,14 +825,27 @@ func (c *cubicSender) maybeTraceStateChange(new logging.CongestionState) {
 	c.lastState = new
 }
 
+// SetMaxDatagramSize is called when Path MTU Discovery found a larger MTU, or when the MTU decreased.
 func (c *cubicSender) SetMaxDatagramSize(s protocol.ByteCount) {
 	if s < c.maxDatagramSize {
-		panic(fmt.Sprintf("congestion BUG: decreased max datagram size from %d to %d", c.maxDatagramSize, s))
+		// Scale the congestion window, such that the number of packets that can be sent doesn't change.
+		// The path might have changed, so the Cubic epoch is restarted as well.
+		c.congestionWindow = c.congestionWindow * s / c.maxDatagramSize
+		c.slowStartThreshold = c.slowStartThreshold * s / c.maxDatagramSize
+		c.maxDatagramSize = s
+		c.congestionWindow = utils.MaxByteCount(c.congestionWindow, c.minCongestionWindow())
+		c.cubic.Reset()
+	} else {
+		cwndIsMinCwnd := c.congestionWindow == c.minCongestionWindow()
+		c.maxDatagramSize = s
+		if cwndIsMinCwnd {
+			c.congestionWindow = c.minCongestionWindow()
+		}
+	}
+	if c.pacer != nil {
+		c.pacer.SetMaxDatagramSize(s)
 	}
-	cwndIsMinCwnd := c.congestionWindow == c.minCongestionWindow()
-	c.maxDatagramSize = s
-	if cwndIsMinCwnd {
-		c.congestionWindow = c.minCongestionWindow()
+	if c.rateLimiter != nil {
+		c.rateLimiter.SetMaxDatagramSize(s)
 	}
-	c.pacer.SetMaxDatagramSize(s)
 }
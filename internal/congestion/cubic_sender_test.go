@@ -3,6 +3,8 @@ package congestion
 import (
 	"time"
 
+	"github.com/golang/mock/gomock"
+	mocklogging "github.com/lucas-clemente/quic-go/internal/mocks/logging"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	. "github.com/onsi/ginkgo"
@@ -16,6 +18,14 @@ const (
 
 type mockClock time.Time
 
+type mockLossClassifier struct {
+	acked  int
+	random bool
+}
+
+func (c *mockLossClassifier) OnPacketAcked(time.Time) { c.acked++ }
+func (c *mockLossClassifier) IsRandomLoss() bool      { return c.random }
+
 func (c *mockClock) Now() time.Time {
 	return time.Time(*c)
 }
@@ -45,11 +55,13 @@ var _ = Describe("Cubic Sender", func() {
 		sender = newCubicSender(
 			&clock,
 			rttStats,
-			true, /*reno*/
+			utils.ChooseHystart,
+			utils.ChooseNewReno,
 			protocol.InitialPacketSizeIPv4,
 			initialCongestionWindowPackets*maxDatagramSize,
 			MaxCongestionWindow,
 			nil,
+			nil,
 		)
 	})
 
@@ -118,6 +130,169 @@ var _ = Describe("Cubic Sender", func() {
 		Expect(delay).ToNot(Equal(utils.InfDuration))
 	})
 
+	It("doesn't pace if pacing is disabled", func() {
+		sender = newCubicSender(&clock, rttStats, utils.ChooseHystart, utils.ChooseNewReno, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, MaxCongestionWindow, &Config{DisablePacing: true}, nil)
+		rttStats.UpdateRTT(10*time.Millisecond, 0, time.Now())
+		clock.Advance(time.Hour)
+		SendAvailableSendWindow()
+		AckNPackets(1)
+		Expect(sender.TimeUntilSend(bytesInFlight)).To(BeZero())
+		Expect(sender.HasPacingBudget()).To(BeTrue())
+		// the pacer would have used up its budget after the first flight
+		SendAvailableSendWindow()
+		Expect(sender.HasPacingBudget()).To(BeTrue())
+	})
+
+	It("limits the send rate", func() {
+		sender = newCubicSender(&clock, rttStats, utils.ChooseHystart, utils.ChooseNewReno, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, MaxCongestionWindow, &Config{DisablePacing: true, MaxSendRate: Bandwidth(100*maxDatagramSize) * BytesPerSecond}, nil)
+		rttStats.UpdateRTT(10*time.Millisecond, 0, time.Now())
+		clock.Advance(time.Hour)
+		// the rate limiter allows a burst of 10 packets
+		var packetsSent int
+		for sender.HasPacingBudget() {
+			sender.OnPacketSent(clock.Now(), bytesInFlight, packetNumber, maxDatagramSize, true)
+			packetNumber++
+			packetsSent++
+			bytesInFlight += maxDatagramSize
+		}
+		Expect(packetsSent).To(Equal(maxBurstSizePackets))
+		Expect(sender.TimeUntilSend(bytesInFlight)).To(BeTemporally("~", clock.Now().Add(10*time.Millisecond), time.Microsecond))
+		clock.Advance(10 * time.Millisecond)
+		Expect(sender.HasPacingBudget()).To(BeTrue())
+	})
+
+	It("changes the maximum send rate", func() {
+		rttStats.UpdateRTT(10*time.Millisecond, 0, time.Now())
+		clock.Advance(time.Hour)
+		sender.SetMaxSendRate(Bandwidth(10*maxDatagramSize) * BytesPerSecond)
+		for sender.HasPacingBudget() {
+			sender.OnPacketSent(clock.Now(), bytesInFlight, packetNumber, maxDatagramSize, true)
+			packetNumber++
+			bytesInFlight += maxDatagramSize
+		}
+		Expect(sender.TimeUntilSend(bytesInFlight)).To(BeTemporally("~", clock.Now().Add(100*time.Millisecond), time.Microsecond))
+		sender.SetMaxSendRate(Bandwidth(20*maxDatagramSize) * BytesPerSecond)
+		Expect(sender.TimeUntilSend(bytesInFlight)).To(BeTemporally("~", clock.Now().Add(50*time.Millisecond), time.Microsecond))
+		// remove the limit
+		sender.SetMaxSendRate(0)
+		Expect(sender.rateLimiter).To(BeNil())
+	})
+
+	It("emulates a bandwidth profile", func() {
+		clock.Advance(time.Hour)
+		sender = newCubicSender(&clock, rttStats, utils.ChooseHystart, utils.ChooseNewReno, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, MaxCongestionWindow, &Config{
+			DisablePacing: true,
+			EmulationProfile: []EmulationStep{
+				{Duration: time.Second, Rate: Bandwidth(100*maxDatagramSize) * BytesPerSecond},
+				{Duration: time.Second}, // not limited
+			},
+		}, nil)
+		rttStats.UpdateRTT(10*time.Millisecond, 0, time.Now())
+		Expect(sender.rateLimiter).ToNot(BeNil())
+		Expect(sender.PacingRate()).To(Equal(Bandwidth(100*maxDatagramSize) * BytesPerSecond))
+		for sender.HasPacingBudget() {
+			sender.OnPacketSent(clock.Now(), bytesInFlight, packetNumber, maxDatagramSize, true)
+			packetNumber++
+			bytesInFlight += maxDatagramSize
+		}
+		Expect(sender.TimeUntilSend(bytesInFlight)).To(BeTemporally("~", clock.Now().Add(10*time.Millisecond), time.Microsecond))
+		// the rate is not limited during the second step
+		clock.Advance(time.Second)
+		Expect(sender.HasPacingBudget()).To(BeTrue())
+		Expect(sender.rateLimiter).To(BeNil())
+		// the profile is repeated
+		clock.Advance(time.Second)
+		Expect(sender.PacingBudget()).To(BeNumerically("<", protocol.MaxByteCount))
+		Expect(sender.rateLimiter).ToNot(BeNil())
+	})
+
+	It("wakes up the sender when the next step of the bandwidth profile starts", func() {
+		clock.Advance(time.Hour)
+		sender = newCubicSender(&clock, rttStats, utils.ChooseHystart, utils.ChooseNewReno, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, MaxCongestionWindow, &Config{
+			DisablePacing: true,
+			EmulationProfile: []EmulationStep{
+				{Duration: 5 * time.Millisecond, Rate: BytesPerSecond},
+				{Duration: time.Second},
+			},
+		}, nil)
+		for sender.HasPacingBudget() {
+			sender.OnPacketSent(clock.Now(), bytesInFlight, packetNumber, maxDatagramSize, true)
+			packetNumber++
+			bytesInFlight += maxDatagramSize
+		}
+		Expect(sender.TimeUntilSend(bytesInFlight)).To(Equal(clock.Now().Add(5 * time.Millisecond)))
+	})
+
+	It("limits the emulated bandwidth to the maximum send rate", func() {
+		sender = newCubicSender(&clock, rttStats, utils.ChooseHystart, utils.ChooseNewReno, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, MaxCongestionWindow, &Config{
+			DisablePacing:    true,
+			MaxSendRate:      Bandwidth(100*maxDatagramSize) * BytesPerSecond,
+			EmulationProfile: []EmulationStep{{Duration: time.Second, Rate: Bandwidth(200*maxDatagramSize) * BytesPerSecond}},
+		}, nil)
+		Expect(sender.rateLimiter.Rate()).To(Equal(Bandwidth(100*maxDatagramSize) * BytesPerSecond))
+		sender.SetMaxSendRate(Bandwidth(400*maxDatagramSize) * BytesPerSecond)
+		Expect(sender.rateLimiter.Rate()).To(Equal(Bandwidth(200*maxDatagramSize) * BytesPerSecond))
+		sender.SetMaxSendRate(0)
+		Expect(sender.rateLimiter.Rate()).To(Equal(Bandwidth(200*maxDatagramSize) * BytesPerSecond))
+	})
+
+	It("reports the pacing rate", func() {
+		// the bandwidth estimate is unknown before the first RTT sample
+		Expect(sender.PacingRate()).To(BeZero())
+		rttStats.UpdateRTT(10*time.Millisecond, 0, time.Now())
+		rate := sender.PacingRate()
+		Expect(rate).To(BeNumerically("~", sender.BandwidthEstimate()*5/4, 8*BytesPerSecond))
+		// the rate limiter imposes a lower rate
+		sender.SetMaxSendRate(rate / 2)
+		Expect(sender.PacingRate()).To(Equal(rate / 2))
+		sender.SetMaxSendRate(2 * rate)
+		Expect(sender.PacingRate()).To(Equal(rate))
+	})
+
+	It("reports the pacing budget", func() {
+		sender = newCubicSender(&clock, rttStats, utils.ChooseHystart, utils.ChooseNewReno, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, MaxCongestionWindow, &Config{DisablePacing: true}, nil)
+		Expect(sender.PacingBudget()).To(Equal(protocol.MaxByteCount))
+		rttStats.UpdateRTT(10*time.Millisecond, 0, time.Now())
+		clock.Advance(time.Hour)
+		sender.SetMaxSendRate(Bandwidth(100*maxDatagramSize) * BytesPerSecond)
+		Expect(sender.PacingBudget()).To(Equal(maxBurstSizePackets * maxDatagramSize))
+		sender.OnPacketSent(clock.Now(), bytesInFlight, packetNumber, maxDatagramSize, true)
+		Expect(sender.PacingBudget()).To(Equal((maxBurstSizePackets - 1) * maxDatagramSize))
+	})
+
+	It("limits the bytes in flight while probing for a new min RTT", func() {
+		rttStats.SetMinRTTExpiry(10 * time.Second)
+		now := time.Now()
+		rttStats.UpdateRTT(10*time.Millisecond, 0, now)
+		Expect(sender.CanSend(minCongestionWindowPackets * maxDatagramSize)).To(BeTrue())
+		rttStats.UpdateRTT(20*time.Millisecond, 0, now.Add(11*time.Second))
+		Expect(rttStats.ProbingMinRTT()).To(BeTrue())
+		Expect(sender.CanSend(minCongestionWindowPackets*maxDatagramSize - 1)).To(BeTrue())
+		Expect(sender.CanSend(minCongestionWindowPackets * maxDatagramSize)).To(BeFalse())
+	})
+
+	It("doesn't limit the bytes in flight while probing for a new min RTT, if the dip is disabled", func() {
+		sender = newCubicSender(&clock, rttStats, utils.ChooseHystart, utils.ChooseNewReno, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, MaxCongestionWindow, &Config{DisableMinRTTProbeDip: true}, nil)
+		rttStats.SetMinRTTExpiry(10 * time.Second)
+		now := time.Now()
+		rttStats.UpdateRTT(10*time.Millisecond, 0, now)
+		rttStats.UpdateRTT(20*time.Millisecond, 0, now.Add(11*time.Second))
+		Expect(rttStats.ProbingMinRTT()).To(BeTrue())
+		Expect(sender.CanSend(minCongestionWindowPackets * maxDatagramSize)).To(BeTrue())
+		Expect(sender.CanSend(sender.GetCongestionWindow() - 1)).To(BeTrue())
+	})
+
+	It("stores the delivery rate reported by the receiver", func() {
+		_, t := sender.ReceiverDeliveryRate()
+		Expect(t).To(BeZero())
+		cwnd := sender.GetCongestionWindow()
+		sender.OnDeliveryRateReport(Bandwidth(1e6)*BytesPerSecond, 25*time.Millisecond, clock.Now())
+		rate, t := sender.ReceiverDeliveryRate()
+		Expect(rate).To(Equal(Bandwidth(1e6) * BytesPerSecond))
+		Expect(t).To(Equal(clock.Now()))
+		Expect(sender.GetCongestionWindow()).To(Equal(cwnd))
+	})
+
 	It("application limited slow start", func() {
 		// Send exactly 10 packets and ensure the CWND ends at 14 packets.
 		const numberOfAcks = 5
@@ -306,6 +481,67 @@ var _ = Describe("Cubic Sender", func() {
 		Expect(sender.slowStartThreshold).To(Equal(5 * maxDatagramSize))
 	})
 
+	It("doesn't increase the congestion window while application-limited", func() {
+		SendAvailableSendWindow()
+		AckNPackets(2)
+		cwnd := sender.GetCongestionWindow()
+		Expect(cwnd).To(BeNumerically(">", defaultWindowTCP))
+		sender.SetApplicationLimited(true, bytesInFlight)
+		AckNPackets(2)
+		Expect(sender.GetCongestionWindow()).To(Equal(cwnd))
+		// the window grows again once the application has data to send
+		sender.SetApplicationLimited(false, bytesInFlight)
+		SendAvailableSendWindow()
+		AckNPackets(2)
+		Expect(sender.GetCongestionWindow()).To(BeNumerically(">", cwnd))
+	})
+
+	It("collapses the congestion window on persistent congestion", func() {
+		SendAvailableSendWindow()
+		AckNPackets(1)
+		LoseNPackets(1)
+		Expect(sender.InRecovery()).To(BeTrue())
+		ssthresh := sender.slowStartThreshold
+
+		// Expect the window to decrease to the minimum,
+		// while the slow start threshold set when the packet was lost is kept.
+		sender.OnPersistentCongestion()
+		Expect(sender.GetCongestionWindow()).To(Equal(2 * maxDatagramSize))
+		Expect(sender.slowStartThreshold).To(Equal(ssthresh))
+		Expect(sender.InRecovery()).To(BeFalse())
+		Expect(sender.InSlowStart()).To(BeTrue())
+	})
+
+	It("reports congestion events", func() {
+		type event struct {
+			event Event
+			cwnd  protocol.ByteCount
+		}
+		var events []event
+		sender = newCubicSender(&clock, rttStats, utils.ChooseHystart, utils.ChooseNewReno, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, MaxCongestionWindow, &Config{
+			OnCongestionEvent: func(e Event, cwnd protocol.ByteCount) { events = append(events, event{e, cwnd}) },
+		}, nil)
+		SendAvailableSendWindow()
+		AckNPackets(1)
+		LoseNPackets(1)
+		Expect(events).To(Equal([]event{{EventLoss, sender.GetCongestionWindow()}}))
+		sender.OnPersistentCongestion()
+		Expect(events).To(HaveLen(2))
+		Expect(events[1]).To(Equal(event{EventPersistentCongestion, 2 * maxDatagramSize}))
+	})
+
+	It("tracks the largest congestion window used", func() {
+		SendAvailableSendWindow()
+		AckNPackets(2)
+		SendAvailableSendWindow()
+		maxCwnd := sender.GetCongestionWindow()
+		Expect(sender.MaxCongestionWindowUsed()).To(Equal(maxCwnd))
+		LoseNPackets(1)
+		Expect(sender.GetCongestionWindow()).To(BeNumerically("<", maxCwnd))
+		SendAvailableSendWindow()
+		Expect(sender.MaxCongestionWindowUsed()).To(Equal(maxCwnd))
+	})
+
 	It("RTO congestion window no retransmission", func() {
 		Expect(sender.GetCongestionWindow()).To(Equal(defaultWindowTCP))
 
@@ -318,7 +554,7 @@ var _ = Describe("Cubic Sender", func() {
 	It("tcp cubic reset epoch on quiescence", func() {
 		const maxCongestionWindow = 50
 		const maxCongestionWindowBytes = maxCongestionWindow * maxDatagramSize
-		sender = newCubicSender(&clock, rttStats, false, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, maxCongestionWindowBytes, nil)
+		sender = newCubicSender(&clock, rttStats, utils.ChooseHystart, utils.ChooseCubic, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, maxCongestionWindowBytes, nil, nil)
 
 		numSent := SendAvailableSendWindow()
 
@@ -459,7 +695,7 @@ var _ = Describe("Cubic Sender", func() {
 
 	It("slow starts up to the maximum congestion window", func() {
 		const initialMaxCongestionWindow = protocol.MaxCongestionWindowPackets * initialMaxDatagramSize
-		sender = newCubicSender(&clock, rttStats, true, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, initialMaxCongestionWindow, nil)
+		sender = newCubicSender(&clock, rttStats, utils.ChooseHystart, utils.ChooseNewReno, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, initialMaxCongestionWindow, nil, nil)
 
 		for i := 1; i < protocol.MaxCongestionWindowPackets; i++ {
 			sender.MaybeExitSlowStart()
@@ -468,13 +704,25 @@ var _ = Describe("Cubic Sender", func() {
 		Expect(sender.GetCongestionWindow()).To(Equal(initialMaxCongestionWindow))
 	})
 
-	It("doesn't allow reductions of the maximum packet size", func() {
-		Expect(func() { sender.SetMaxDatagramSize(initialMaxDatagramSize - 1) }).To(Panic())
+	It("scales the congestion window when the maximum packet size decreases", func() {
+		SendAvailableSendWindow()
+		AckNPackets(2)
+		cwnd := sender.GetCongestionWindow()
+		Expect(cwnd).To(BeNumerically(">", sender.minCongestionWindow()))
+		sender.SetMaxDatagramSize(initialMaxDatagramSize / 2)
+		Expect(sender.GetCongestionWindow()).To(Equal(cwnd / 2))
+	})
+
+	It("doesn't reduce the congestion window below the minimum when the maximum packet size decreases", func() {
+		sender.OnRetransmissionTimeout(true)
+		Expect(sender.GetCongestionWindow()).To(Equal(sender.minCongestionWindow()))
+		sender.SetMaxDatagramSize(1000)
+		Expect(sender.GetCongestionWindow()).To(Equal(minCongestionWindowPackets * protocol.ByteCount(1000)))
 	})
 
 	It("slow starts up to maximum congestion window, if larger packets are sent", func() {
 		const initialMaxCongestionWindow = protocol.MaxCongestionWindowPackets * initialMaxDatagramSize
-		sender = newCubicSender(&clock, rttStats, true, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, initialMaxCongestionWindow, nil)
+		sender = newCubicSender(&clock, rttStats, utils.ChooseHystart, utils.ChooseNewReno, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, initialMaxCongestionWindow, nil, nil)
 		const packetSize = initialMaxDatagramSize + 100
 		sender.SetMaxDatagramSize(packetSize)
 		for i := 1; i < protocol.MaxCongestionWindowPackets; i++ {
@@ -489,7 +737,7 @@ var _ = Describe("Cubic Sender", func() {
 
 	It("limit cwnd increase in congestion avoidance", func() {
 		// Enable Cubic.
-		sender = newCubicSender(&clock, rttStats, false, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, MaxCongestionWindow, nil)
+		sender = newCubicSender(&clock, rttStats, utils.ChooseHystart, utils.ChooseCubic, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, MaxCongestionWindow, nil, nil)
 		numSent := SendAvailableSendWindow()
 
 		// Make sure we fall out of slow start.
@@ -523,4 +771,375 @@ var _ = Describe("Cubic Sender", func() {
 		AckNPackets(2)
 		Expect(sender.GetCongestionWindow()).To(Equal(savedCwnd + maxDatagramSize))
 	})
+
+	Context("Paced Chirping", func() {
+		BeforeEach(func() {
+			sender = newCubicSender(&clock, rttStats, utils.ChoosePacedChirping, utils.ChooseNewReno, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, MaxCongestionWindow, nil, nil)
+			rttStats.UpdateRTT(60*time.Millisecond, 0, clock.Now())
+			sender.MaybeExitSlowStart()
+		})
+
+		// sendChirp sends a chirp, and acknowledges the packets with an increasing delay after packet 7.
+		sendChirp := func() {
+			first := packetNumber
+			sentTimes := make([]time.Time, chirpPackets)
+			for i := 0; i < chirpPackets; i++ {
+				if t := sender.TimeUntilSend(bytesInFlight); t.After(clock.Now()) {
+					clock = mockClock(t)
+				}
+				sentTimes[i] = clock.Now()
+				sender.OnPacketSent(clock.Now(), bytesInFlight, packetNumber, maxDatagramSize, true)
+				packetNumber++
+			}
+			for i := 0; i < chirpPackets; i++ {
+				delay := 60 * time.Millisecond
+				if i > 7 {
+					delay += time.Duration(i-7) * time.Millisecond
+				}
+				sender.OnPacketAcked(first+protocol.PacketNumber(i), maxDatagramSize, sender.GetCongestionWindow(), sentTimes[i].Add(delay))
+			}
+			sender.MaybeExitSlowStart()
+		}
+
+		It("paces the packets of a chirp", func() {
+			// the first chirp is sent at the rate of the initial congestion window
+			Expect(sender.pacedChirping.targetGap).To(Equal(6 * time.Millisecond))
+			sender.OnPacketSent(clock.Now(), 0, 1, maxDatagramSize, true)
+			Expect(sender.HasPacingBudget()).To(BeFalse())
+			Expect(sender.TimeUntilSend(0)).To(Equal(clock.Now().Add(12 * time.Millisecond)))
+			clock.Advance(12 * time.Millisecond)
+			Expect(sender.HasPacingBudget()).To(BeTrue())
+		})
+
+		It("sets the congestion window to the estimated bandwidth-delay product", func() {
+			sendChirp()
+			Expect(sender.InSlowStart()).To(BeTrue())
+			sendChirp()
+			Expect(sender.InSlowStart()).To(BeFalse())
+			gap := sender.pacedChirping.estimatedGap
+			Expect(sender.GetCongestionWindow()).To(Equal(protocol.ByteCount(uint64(60*time.Millisecond) * uint64(maxDatagramSize) / uint64(gap))))
+		})
+
+		It("only uses Paced Chirping for the initial slow start", func() {
+			sender.OnPacketSent(clock.Now(), 0, 1, maxDatagramSize, true)
+			sender.OnPacketLost(1, maxDatagramSize, maxDatagramSize)
+			Expect(sender.chosenStartAlgo).To(Equal(utils.ChooseSlowStart))
+		})
+	})
+
+	It("exits slow start when SEARCH detects that the delivery rate stopped growing", func() {
+		sender = newCubicSender(&clock, rttStats, utils.ChooseSearch, utils.ChooseNewReno, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, MaxCongestionWindow, nil, nil)
+		// Packets are delivered at a constant rate, as if the path capacity was reached.
+		rttStats.UpdateRTT(60*time.Millisecond, 0, clock.Now())
+		for i := 0; i < 200 && sender.InSlowStart(); i++ {
+			sender.OnPacketSent(clock.Now(), 0, packetNumber, maxDatagramSize, true)
+			sender.MaybeExitSlowStart()
+			sender.OnPacketAcked(packetNumber, maxDatagramSize, sender.GetCongestionWindow(), clock.Now())
+			packetNumber++
+			clock.Advance(5 * time.Millisecond)
+		}
+		Expect(sender.InSlowStart()).To(BeFalse())
+		Expect(sender.search.ShouldExitSlowStart()).To(BeTrue())
+	})
+
+	It("uses the configured initial congestion window", func() {
+		sender = NewCubicSender(&clock, rttStats, protocol.InitialPacketSizeIPv4, utils.ChooseHystart, utils.ChooseNewReno, nil, nil)
+		Expect(sender.GetCongestionWindow()).To(Equal(initialCongestionWindow * protocol.ByteCount(protocol.InitialPacketSizeIPv4)))
+		sender = NewCubicSender(&clock, rttStats, protocol.InitialPacketSizeIPv4, utils.ChooseHystart, utils.ChooseNewReno, &Config{InitialCongestionWindow: 10}, nil)
+		Expect(sender.GetCongestionWindow()).To(Equal(10 * protocol.ByteCount(protocol.InitialPacketSizeIPv4)))
+	})
+	It("slow starts up to the configured maximum congestion window", func() {
+		sender = NewCubicSender(&clock, rttStats, protocol.InitialPacketSizeIPv4, utils.ChooseSlowStart, utils.ChooseNewReno, &Config{MaxCongestionWindow: 100}, nil)
+		for i := 1; i < 1000; i++ {
+			sender.OnPacketAcked(protocol.PacketNumber(i), 1350, sender.GetCongestionWindow(), clock.Now())
+		}
+		Expect(sender.GetCongestionWindow()).To(Equal(100 * protocol.ByteCount(protocol.InitialPacketSizeIPv4)))
+	})
+
+	It("uses the configured minimum congestion window", func() {
+		sender = NewCubicSender(&clock, rttStats, protocol.InitialPacketSizeIPv4, utils.ChooseHystart, utils.ChooseNewReno, &Config{MinCongestionWindow: 8}, nil)
+		sender.OnRetransmissionTimeout(true)
+		Expect(sender.GetCongestionWindow()).To(Equal(8 * protocol.ByteCount(protocol.InitialPacketSizeIPv4)))
+	})
+
+	It("keeps the initial congestion window within the configured limits", func() {
+		sender = NewCubicSender(&clock, rttStats, protocol.InitialPacketSizeIPv4, utils.ChooseHystart, utils.ChooseNewReno, &Config{MaxCongestionWindow: 10}, nil)
+		Expect(sender.GetCongestionWindow()).To(Equal(10 * protocol.ByteCount(protocol.InitialPacketSizeIPv4)))
+		sender = NewCubicSender(&clock, rttStats, protocol.InitialPacketSizeIPv4, utils.ChooseHystart, utils.ChooseNewReno, &Config{MinCongestionWindow: 50}, nil)
+		Expect(sender.GetCongestionWindow()).To(Equal(50 * protocol.ByteCount(protocol.InitialPacketSizeIPv4)))
+	})
+	It("uses the configured Reno backoff factor", func() {
+		sender = newCubicSender(&clock, rttStats, utils.ChooseHystart, utils.ChooseNewReno, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, MaxCongestionWindow, &Config{RenoBeta: 0.5}, nil)
+		const numberOfAcks = 10
+		for i := 0; i < numberOfAcks; i++ {
+			SendAvailableSendWindow()
+			AckNPackets(2)
+		}
+		SendAvailableSendWindow()
+		expectedSendWindow := defaultWindowTCP + (maxDatagramSize * 2 * numberOfAcks)
+		Expect(sender.GetCongestionWindow()).To(Equal(expectedSendWindow))
+		LoseNPackets(1)
+		Expect(sender.GetCongestionWindow()).To(Equal(expectedSendWindow / 2))
+	})
+
+	Context("Congestion Window Validation", func() {
+		BeforeEach(func() {
+			sender = newCubicSender(
+				&clock,
+				rttStats,
+				utils.ChooseHystart,
+				utils.ChooseNewReno,
+				protocol.InitialPacketSizeIPv4,
+				initialCongestionWindowPackets*maxDatagramSize,
+				MaxCongestionWindow,
+				&Config{CongestionWindowValidation: true, NonValidatedPeriod: time.Second},
+				nil,
+			)
+		})
+
+		// SendRounds sends and acknowledges the whole congestion window, once per RTT.
+		SendRounds := func(n int) {
+			for i := 0; i < n; i++ {
+				num := SendAvailableSendWindow()
+				clock.Advance(60 * time.Millisecond)
+				AckNPackets(num)
+			}
+		}
+
+		It("doesn't decay the congestion window while it is used", func() {
+			SendRounds(4)
+			LoseNPackets(1)
+			cwnd := sender.GetCongestionWindow()
+			SendRounds(50)
+			Expect(sender.GetCongestionWindow()).To(BeNumerically(">", cwnd))
+		})
+
+		It("halves the congestion window for every non-validated period", func() {
+			SendRounds(4)
+			cwnd := sender.GetCongestionWindow()
+			Expect(cwnd).To(Equal(16 * defaultWindowTCP))
+			clock.Advance(3500 * time.Millisecond)
+			Expect(SendAvailableSendWindow()).To(Equal(int(cwnd / 8 / maxDatagramSize)))
+			Expect(sender.GetCongestionWindow()).To(Equal(cwnd / 8))
+		})
+
+		It("doesn't decay the congestion window below the initial congestion window", func() {
+			SendRounds(4)
+			clock.Advance(time.Minute)
+			SendAvailableSendWindow()
+			Expect(sender.GetCongestionWindow()).To(Equal(defaultWindowTCP))
+		})
+
+		It("slow starts back to the old congestion window", func() {
+			SendRounds(4)
+			LoseNPackets(1)
+			cwnd := sender.GetCongestionWindow()
+			Expect(sender.InSlowStart()).To(BeFalse())
+			clock.Advance(2500 * time.Millisecond)
+			SendAvailableSendWindow()
+			Expect(sender.GetCongestionWindow()).To(Equal(cwnd / 4))
+			Expect(sender.InSlowStart()).To(BeTrue())
+			Expect(sender.slowStartThreshold).To(Equal(cwnd))
+		})
+
+		It("doesn't decay the congestion window if it is not enabled", func() {
+			sender = newCubicSender(&clock, rttStats, utils.ChooseHystart, utils.ChooseNewReno, protocol.InitialPacketSizeIPv4, initialCongestionWindowPackets*maxDatagramSize, MaxCongestionWindow, nil, nil)
+			SendRounds(4)
+			cwnd := sender.GetCongestionWindow()
+			clock.Advance(time.Minute)
+			SendAvailableSendWindow()
+			Expect(sender.GetCongestionWindow()).To(Equal(cwnd))
+		})
+	})
+
+	Context("Loss Differentiation", func() {
+		var (
+			classifier *mockLossClassifier
+			tracer     *mocklogging.MockConnectionTracer
+		)
+
+		BeforeEach(func() {
+			classifier = &mockLossClassifier{}
+			tracer = mocklogging.NewMockConnectionTracer(gomock.NewController(GinkgoT()))
+			tracer.EXPECT().UpdatedCongestionState(gomock.Any()).AnyTimes()
+			sender = newCubicSender(
+				&clock,
+				rttStats,
+				utils.ChooseHystart,
+				utils.ChooseNewReno,
+				protocol.InitialPacketSizeIPv4,
+				initialCongestionWindowPackets*maxDatagramSize,
+				MaxCongestionWindow,
+				&Config{LossDifferentiation: true, LossClassifier: classifier},
+				tracer,
+			)
+		})
+
+		It("passes acknowledged packets to the classifier", func() {
+			SendAvailableSendWindow()
+			AckNPackets(3)
+			Expect(classifier.acked).To(Equal(3))
+		})
+
+		It("doesn't reduce the congestion window for random losses", func() {
+			classifier.random = true
+			SendAvailableSendWindow()
+			AckNPackets(2)
+			cwnd := sender.GetCongestionWindow()
+			tracer.EXPECT().ClassifiedPacketLoss(protocol.PacketNumber(3), true)
+			LoseNPackets(1)
+			Expect(sender.GetCongestionWindow()).To(Equal(cwnd))
+			Expect(sender.InSlowStart()).To(BeTrue())
+			Expect(sender.InRecovery()).To(BeFalse())
+		})
+
+		It("reduces the congestion window for congestion losses", func() {
+			SendAvailableSendWindow()
+			AckNPackets(2)
+			cwnd := sender.GetCongestionWindow()
+			tracer.EXPECT().ClassifiedPacketLoss(protocol.PacketNumber(3), false)
+			LoseNPackets(1)
+			Expect(sender.GetCongestionWindow()).To(BeNumerically("<", cwnd))
+			Expect(sender.InRecovery()).To(BeTrue())
+			// losses in the same loss event are not classified
+			LoseNPackets(1)
+		})
+
+		It("uses the delay-based classifier by default", func() {
+			sender = newCubicSender(
+				&clock,
+				rttStats,
+				utils.ChooseHystart,
+				utils.ChooseNewReno,
+				protocol.InitialPacketSizeIPv4,
+				initialCongestionWindowPackets*maxDatagramSize,
+				MaxCongestionWindow,
+				&Config{LossDifferentiation: true},
+				tracer,
+			)
+			Expect(sender.lossClassifier).To(BeAssignableToTypeOf(&delayLossClassifier{}))
+		})
+	})
+
+	Context("tracing pacing delays", func() {
+		var tracer *mocklogging.MockConnectionTracer
+
+		BeforeEach(func() {
+			tracer = mocklogging.NewMockConnectionTracer(gomock.NewController(GinkgoT()))
+			tracer.EXPECT().UpdatedCongestionState(gomock.Any()).AnyTimes()
+			sender = newCubicSender(
+				&clock,
+				rttStats,
+				utils.ChooseHystart,
+				utils.ChooseNewReno,
+				protocol.InitialPacketSizeIPv4,
+				initialCongestionWindowPackets*maxDatagramSize,
+				MaxCongestionWindow,
+				&Config{DisablePacing: true, MaxSendRate: Bandwidth(100*maxDatagramSize) * BytesPerSecond},
+				tracer,
+			)
+			rttStats.UpdateRTT(10*time.Millisecond, 0, time.Now())
+			clock.Advance(time.Hour)
+		})
+
+		sendBurst := func() {
+			for sender.HasPacingBudget() {
+				sender.OnPacketSent(clock.Now(), bytesInFlight, packetNumber, maxDatagramSize, true)
+				packetNumber++
+				bytesInFlight += maxDatagramSize
+			}
+		}
+
+		It("traces when the pacer delays sending", func() {
+			var budget protocol.ByteCount
+			var nextSendTime time.Time
+			tracer.EXPECT().PacerDelayedSend(gomock.Any(), gomock.Any()).Do(func(b protocol.ByteCount, t time.Time) {
+				budget = b
+				nextSendTime = t
+			})
+			sendBurst()
+			Expect(budget).To(BeNumerically("<", maxDatagramSize))
+			Expect(budget).To(Equal(sender.PacingBudget()))
+			Expect(nextSendTime).To(Equal(sender.TimeUntilSend(bytesInFlight)))
+			Expect(nextSendTime).To(BeTemporally("~", clock.Now().Add(10*time.Millisecond), time.Microsecond))
+		})
+
+		It("traces every next send time once", func() {
+			tracer.EXPECT().PacerDelayedSend(gomock.Any(), gomock.Any())
+			sendBurst()
+			Expect(sender.HasPacingBudget()).To(BeFalse())
+			Expect(sender.HasPacingBudget()).To(BeFalse())
+			clock.Advance(10 * time.Millisecond)
+			tracer.EXPECT().PacerDelayedSend(gomock.Any(), gomock.Any())
+			sendBurst()
+		})
+	})
+
+	Context("automatic algorithm selection", func() {
+		type selection struct {
+			startAlgo      utils.StartAlgo
+			congestionAlgo utils.CongestionAlgo
+		}
+
+		var (
+			tracer   *mocklogging.MockConnectionTracer
+			selected chan selection
+		)
+
+		BeforeEach(func() {
+			selected = make(chan selection, 1)
+			tracer = mocklogging.NewMockConnectionTracer(gomock.NewController(GinkgoT()))
+			tracer.EXPECT().UpdatedCongestionState(gomock.Any()).AnyTimes()
+			sender = newCubicSender(
+				&clock,
+				rttStats,
+				utils.ChooseAutoStart,
+				utils.ChooseAutoCongestion,
+				protocol.InitialPacketSizeIPv4,
+				initialCongestionWindowPackets*maxDatagramSize,
+				MaxCongestionWindow,
+				&Config{OnAlgorithmsSelected: func(s utils.StartAlgo, c utils.CongestionAlgo) { selected <- selection{s, c} }},
+				tracer,
+			)
+		})
+
+		It("uses standard slow start until the algorithms are selected", func() {
+			Expect(sender.chosenStartAlgo).To(Equal(utils.ChooseSlowStart))
+			Expect(sender.chosenCongestionAlgo).To(Equal(utils.ChooseNewReno))
+			Expect(sender.autoSelector).ToNot(BeNil())
+		})
+
+		It("selects the algorithms one RTT after the first ACK", func() {
+			rttStats.UpdateRTT(400*time.Millisecond, 0, clock.Now())
+			SendAvailableSendWindow()
+			for i := 0; i < 5; i++ {
+				ackedPacketNumber++
+				sender.OnPacketAcked(ackedPacketNumber, maxDatagramSize, bytesInFlight, clock.Now())
+				bytesInFlight -= maxDatagramSize
+				clock.Advance(time.Millisecond)
+			}
+			Expect(selected).ToNot(Receive())
+			clock.Advance(400 * time.Millisecond)
+			tracer.EXPECT().Debug("algorithm_selection", gomock.Any()).Do(func(_, msg string) {
+				Expect(msg).To(ContainSubstring("start: search, congestion: cubic"))
+			})
+			ackedPacketNumber++
+			sender.OnPacketAcked(ackedPacketNumber, maxDatagramSize, bytesInFlight, clock.Now())
+			Expect(selected).To(Receive(Equal(selection{utils.ChooseSearch, utils.ChooseCubic})))
+			Expect(sender.chosenStartAlgo).To(Equal(utils.ChooseSearch))
+			Expect(sender.chosenCongestionAlgo).To(Equal(utils.ChooseCubic))
+			Expect(sender.autoSelector).To(BeNil())
+		})
+
+		It("selects the algorithms when a packet is lost during the probe round", func() {
+			rttStats.UpdateRTT(20*time.Millisecond, 0, clock.Now())
+			SendAvailableSendWindow()
+			tracer.EXPECT().Debug("algorithm_selection", gomock.Any())
+			LoseNPackets(1)
+			Expect(selected).To(Receive(Equal(selection{utils.ChooseHystartpp, utils.ChooseNewReno})))
+			Expect(sender.GetCongestionWindow()).To(BeNumerically("<", defaultWindowTCP))
+			// HyStart++ is only used for the initial slow start
+			Expect(sender.chosenStartAlgo).To(Equal(utils.ChooseSlowStart))
+		})
+	})
 })
@@ -236,4 +236,43 @@ var _ = Describe("Cubic", func() {
 		expectedCwnd = 553632 * maxDatagramSize / 1460
 		Expect(currentCwnd).To(Equal(expectedCwnd))
 	})
+	It("uses the configured backoff factor", func() {
+		cubic = newCubic(&clock, &Config{CubicBeta: 0.5})
+		currentCwnd := 100 * maxDatagramSize
+		Expect(cubic.CongestionWindowAfterPacketLoss(currentCwnd)).To(Equal(50 * maxDatagramSize))
+		Expect(cubic.lastMaxCongestionWindow).To(Equal(currentCwnd))
+		// the additional backoff factor is derived from the backoff factor
+		currentCwnd = 50 * maxDatagramSize
+		Expect(cubic.CongestionWindowAfterPacketLoss(currentCwnd)).To(Equal(25 * maxDatagramSize))
+		Expect(cubic.lastMaxCongestionWindow).To(Equal(protocol.ByteCount(0.75 * float32(currentCwnd))))
+	})
+
+	It("uses the configured scaling constant", func() {
+		const rttMin = 100 * time.Millisecond
+		cubic = newCubic(&clock, &Config{CubicC: 0.8, DisableCubicTCPFriendliness: true})
+		currentCwnd := 1000 * maxDatagramSize
+		initialCwnd := currentCwnd
+		clock.Advance(time.Millisecond)
+		initialTime := clock.Now()
+		currentCwnd = cubic.CongestionWindowAfterAck(maxDatagramSize, currentCwnd, rttMin, initialTime)
+		for i := 0; i < 10; i++ {
+			clock.Advance(100 * time.Millisecond)
+			currentCwnd = cubic.CongestionWindowAfterAck(maxDatagramSize, currentCwnd, rttMin, clock.Now())
+		}
+		// The window grows twice as fast as with the default value of C.
+		offset := protocol.ByteCount((clock.Now().Sub(initialTime)+rttMin)/time.Microsecond) << 10 / 1000000
+		expected := initialCwnd + 819*offset*offset*offset*maxDatagramSize>>40
+		Expect(cubic.lastTargetCongestionWindow).To(Equal(expected))
+	})
+
+	It("uses the TCP-friendly region, unless disabled", func() {
+		const rttMin = 100 * time.Millisecond
+		currentCwnd := 10 * maxDatagramSize
+		clock.Advance(time.Millisecond)
+		// In the beginning of an epoch, the Reno window grows faster than the Cubic window.
+		cubic = newCubic(&clock, nil)
+		Expect(cubic.CongestionWindowAfterAck(maxDatagramSize, currentCwnd, rttMin, clock.Now())).To(BeNumerically(">", currentCwnd))
+		cubic = newCubic(&clock, &Config{DisableCubicTCPFriendliness: true})
+		Expect(cubic.CongestionWindowAfterAck(maxDatagramSize, currentCwnd, rttMin, clock.Now())).To(Equal(currentCwnd))
+	})
 })
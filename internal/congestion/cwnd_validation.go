@@ -0,0 +1,99 @@
+package congestion
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+// The minimum duration of the pipeACK sampling period, see section 4.2 of RFC 7661.
+const minPipeACKSamplingPeriod = time.Second
+
+// cwndValidation implements Congestion Window Validation (RFC 7661).
+// The sender measures the amount of data acknowledged per RTT (pipeACK).
+// As long as pipeACK is smaller than half the congestion window, the congestion window is not validated,
+// because the sender is application-limited or idle.
+// For every non-validated period that passes, the congestion window is halved,
+// but not below the initial congestion window.
+// The slow start threshold is raised, so that the sender quickly regains the old congestion window
+// by slow starting, once it has enough data to send.
+type cwndValidation struct {
+	rttStats *utils.RTTStats
+	// A value of 0 means that the PTO is used as the non-validated period.
+	nonValidatedPeriod time.Duration
+
+	sampleStart time.Time
+	sampleAcked protocol.ByteCount
+
+	pipeACK     protocol.ByteCount
+	pipeACKTime time.Time
+
+	// the start of the current non-validated period
+	periodStart time.Time
+}
+
+func newCwndValidation(rttStats *utils.RTTStats, nonValidatedPeriod time.Duration) *cwndValidation {
+	return &cwndValidation{
+		rttStats:           rttStats,
+		nonValidatedPeriod: nonValidatedPeriod,
+	}
+}
+
+func (v *cwndValidation) period() time.Duration {
+	if v.nonValidatedPeriod > 0 {
+		return v.nonValidatedPeriod
+	}
+	return v.rttStats.PTO(false)
+}
+
+func (v *cwndValidation) samplingPeriod() time.Duration {
+	return utils.MaxDuration(3*v.rttStats.SmoothedRTT(), minPipeACKSamplingPeriod)
+}
+
+// OnPacketAcked is called when a packet was acknowledged.
+// It measures the amount of data acknowledged per RTT.
+func (v *cwndValidation) OnPacketAcked(ackedBytes protocol.ByteCount, now time.Time) {
+	if v.sampleStart.IsZero() {
+		v.sampleStart = now
+	}
+	v.sampleAcked += ackedBytes
+	if now.Sub(v.sampleStart) < v.rttStats.SmoothedRTT() {
+		return
+	}
+	// pipeACK is the maximum of the samples taken during the sampling period
+	if v.sampleAcked >= v.pipeACK || now.Sub(v.pipeACKTime) > v.samplingPeriod() {
+		v.pipeACK = v.sampleAcked
+		v.pipeACKTime = now
+	}
+	v.sampleStart = now
+	v.sampleAcked = 0
+}
+
+func (v *cwndValidation) isValidated(now time.Time, cwnd protocol.ByteCount) bool {
+	if now.Sub(v.pipeACKTime) > v.samplingPeriod() {
+		// No data was acknowledged during the sampling period.
+		return false
+	}
+	return v.pipeACK >= cwnd/2
+}
+
+// Update decays the congestion window for every non-validated period that passed.
+// It returns the new congestion window and slow start threshold.
+func (v *cwndValidation) Update(now time.Time, cwnd, ssthresh, minCwnd protocol.ByteCount) (protocol.ByteCount, protocol.ByteCount) {
+	if v.periodStart.IsZero() || v.isValidated(now, cwnd) {
+		v.periodStart = now
+		return cwnd, ssthresh
+	}
+	period := v.period()
+	for now.Sub(v.periodStart) >= period {
+		if cwnd <= minCwnd {
+			v.periodStart = now
+			break
+		}
+		ssthresh = utils.MaxByteCount(ssthresh, 3*cwnd/4)
+		cwnd = utils.MaxByteCount(cwnd/2, minCwnd)
+		v.periodStart = v.periodStart.Add(period)
+	}
+	return cwnd, ssthresh
+}
@@ -0,0 +1,42 @@
+package congestion
+
+import "time"
+
+// An EmulationStep is a step of an emulated bandwidth profile.
+type EmulationStep struct {
+	Duration time.Duration
+	Rate     Bandwidth // if 0, the rate is not limited
+}
+
+// An emulationProfile is a bandwidth profile, which is repeated after its last step.
+type emulationProfile struct {
+	steps []EmulationStep
+	total time.Duration
+	start time.Time
+}
+
+func newEmulationProfile(steps []EmulationStep, start time.Time) *emulationProfile {
+	var total time.Duration
+	for _, step := range steps {
+		total += step.Duration
+	}
+	return &emulationProfile{steps: steps, total: total, start: start}
+}
+
+// At returns the rate at time t, and the time when the next step starts.
+func (p *emulationProfile) At(t time.Time) (Bandwidth, time.Time) {
+	elapsed := t.Sub(p.start)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	stepStart := t.Add(-(elapsed % p.total))
+	for _, step := range p.steps {
+		stepEnd := stepStart.Add(step.Duration)
+		if t.Before(stepEnd) {
+			return step.Rate, stepEnd
+		}
+		stepStart = stepEnd
+	}
+	// unreachable, since the steps cover the whole profile
+	return p.steps[len(p.steps)-1].Rate, stepStart
+}
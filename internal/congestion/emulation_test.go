@@ -0,0 +1,50 @@
+package congestion
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Emulation Profile", func() {
+	var (
+		start   time.Time
+		profile *emulationProfile
+	)
+
+	BeforeEach(func() {
+		start = time.Now()
+		profile = newEmulationProfile([]EmulationStep{
+			{Duration: 100 * time.Millisecond, Rate: 10 * BytesPerSecond},
+			{Duration: 200 * time.Millisecond, Rate: 20 * BytesPerSecond},
+		}, start)
+	})
+
+	It("returns the rate of the current step", func() {
+		rate, next := profile.At(start)
+		Expect(rate).To(Equal(10 * BytesPerSecond))
+		Expect(next).To(Equal(start.Add(100 * time.Millisecond)))
+		rate, next = profile.At(start.Add(99 * time.Millisecond))
+		Expect(rate).To(Equal(10 * BytesPerSecond))
+		Expect(next).To(Equal(start.Add(100 * time.Millisecond)))
+		rate, next = profile.At(start.Add(100 * time.Millisecond))
+		Expect(rate).To(Equal(20 * BytesPerSecond))
+		Expect(next).To(Equal(start.Add(300 * time.Millisecond)))
+	})
+
+	It("repeats the profile", func() {
+		rate, next := profile.At(start.Add(350 * time.Millisecond))
+		Expect(rate).To(Equal(10 * BytesPerSecond))
+		Expect(next).To(Equal(start.Add(400 * time.Millisecond)))
+		rate, next = profile.At(start.Add(1350 * time.Millisecond))
+		Expect(rate).To(Equal(20 * BytesPerSecond))
+		Expect(next).To(Equal(start.Add(1500 * time.Millisecond)))
+	})
+
+	It("uses the first step before the start of the profile", func() {
+		rate, next := profile.At(start.Add(-time.Second))
+		Expect(rate).To(Equal(10 * BytesPerSecond))
+		Expect(next).To(Equal(start.Add(-900 * time.Millisecond)))
+	})
+})
@@ -18,8 +18,8 @@ const hybridStartMinSamples = uint32(8)
 const hybridStartDelayFactorExp = 3 // 2^3 = 8
 // The original paper specifies 2 and 8ms, but those have changed over time.
 const (
-	hybridStartDelayMinThresholdUs = int64(4000)
-	hybridStartDelayMaxThresholdUs = int64(16000)
+	hybridStartDelayMinThreshold = 4 * time.Millisecond
+	hybridStartDelayMaxThreshold = 16 * time.Millisecond
 )
 
 // HybridSlowStart implements the TCP hybrid slow start algorithm
@@ -30,6 +30,9 @@ type HybridSlowStart struct {
 	currentMinRTT        time.Duration
 	rttSampleCount       uint32
 	hystartFound         bool
+
+	// may be nil, in which case the default parameters are used
+	config *Config
 }
 
 // StartReceiveRound is called for the start of each receive round (burst) in the slow start phase.
@@ -64,19 +67,20 @@ func (s *HybridSlowStart) ShouldExitSlowStart(latestRTT time.Duration, minRTT ti
 	// Note: we only look at the first few(8) packets in each burst, since we
 	// only want to compare the lowest RTT of the burst relative to previous
 	// bursts.
+	minSamples := s.config.hybridStartRTTSamples(hybridStartMinSamples)
 	s.rttSampleCount++
-	if s.rttSampleCount <= hybridStartMinSamples {
+	if s.rttSampleCount <= minSamples {
 		if s.currentMinRTT == 0 || s.currentMinRTT > latestRTT {
 			s.currentMinRTT = latestRTT
 		}
 	}
 	// We only need to check this once per round.
-	if s.rttSampleCount == hybridStartMinSamples {
+	if s.rttSampleCount == minSamples {
 		// Divide minRTT by 8 to get a rtt increase threshold for exiting.
-		minRTTincreaseThresholdUs := int64(minRTT / time.Microsecond >> hybridStartDelayFactorExp)
-		// Ensure the rtt threshold is never less than 2ms or more than 16ms.
-		minRTTincreaseThresholdUs = utils.MinInt64(minRTTincreaseThresholdUs, hybridStartDelayMaxThresholdUs)
-		minRTTincreaseThreshold := time.Duration(utils.MaxInt64(minRTTincreaseThresholdUs, hybridStartDelayMinThresholdUs)) * time.Microsecond
+		minRTTincreaseThreshold := minRTT >> hybridStartDelayFactorExp
+		// Ensure the rtt threshold is never less than 4ms or more than 16ms.
+		minRTTincreaseThreshold = utils.MinDuration(minRTTincreaseThreshold, s.config.hybridStartMaxRTTThreshold(hybridStartDelayMaxThreshold))
+		minRTTincreaseThreshold = utils.MaxDuration(minRTTincreaseThreshold, s.config.hybridStartMinRTTThreshold(hybridStartDelayMinThreshold))
 
 		if s.currentMinRTT > (minRTT + minRTTincreaseThreshold) {
 			s.hystartFound = true
@@ -84,7 +88,7 @@ func (s *HybridSlowStart) ShouldExitSlowStart(latestRTT time.Duration, minRTT ti
 	}
 	// Exit from slow start if the cwnd is greater than 16 and
 	// increasing delay is found.
-	return congestionWindow >= hybridStartLowWindow && s.hystartFound
+	return congestionWindow >= s.config.hybridStartLowWindow() && s.hystartFound
 }
 
 // OnPacketSent is called when a packet was sent
@@ -2,42 +2,47 @@ package congestion
 
 import (
 	"time"
-	"math"
 
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 )
 
-// Note(pwestin): the magic clamping numbers come from the original code in
-// tcp_cubic.c.
-const hybridStartppLowWindow = protocol.ByteCount(16)
-
-// Number of delay samples for detecting the increase of delay.
-//N_RTT_SAMPLE
-const hybridStartppNRttSample = uint32(8)
-
-// cwnd increase limit, recommended value in RFC3465
-const hybridStartppL = 2
-
-// Limited Slow Start from RFC 3742, advised value 0.25 < 0.5
-const hybridStartppLSS_DIVISOR = 0.25  
-
-// The original paper specifies 2 and 8ms, but those have changed over time.
-//MIN et MAX RTT_THRESH
+// The constants of HyStart++, see section 4.3 of RFC 9406.
 const (
-	hybridStartppDelayMinThresholdUs = 4000
-	hybridStartppDelayMaxThresholdUs = 16000
+	// Clamping of the RTT increase threshold.
+	hybridStartppMinRTTThresh = 4 * time.Millisecond
+	hybridStartppMaxRTTThresh = 16 * time.Millisecond
+	// The RTT increase threshold is lastRoundMinRTT / hybridStartppMinRTTDivisor.
+	hybridStartppMinRTTDivisor = 8
+	// Number of RTT samples per round needed before checking for an RTT increase.
+	hybridStartppNRTTSample = uint32(8)
+	// The congestion window grows 4x slower during Conservative Slow Start.
+	hybridStartppCSSGrowthDivisor = 4
+	// Number of rounds spent in Conservative Slow Start before entering congestion avoidance.
+	hybridStartppCSSRounds = uint32(5)
+	// Limit of the congestion window increase per ACK, in packets.
+	hybridStartppL = 8
 )
 
-// HybridSlowStartpp implements the TCP hybrid slow start algorithm
+// HybridSlowStartpp implements HyStart++ (RFC 9406).
+// It is only used for the initial slow start of a connection.
 type HybridSlowStartpp struct {
 	endPacketNumber      protocol.PacketNumber
 	lastSentPacketNumber protocol.PacketNumber
 	started              bool
-	currentRoundMinRTT   time.Duration
-	lastRoundMinRTT		 time.Duration
-	rttSampleCount       uint32
-	inLSS				 bool
+
+	// A value of 0 means that no RTT sample was taken in the round.
+	currentRoundMinRTT time.Duration
+	lastRoundMinRTT    time.Duration
+	rttSampleCount     uint32
+
+	// Conservative Slow Start (CSS)
+	inCSS             bool
+	cssBaselineMinRTT time.Duration
+	cssRounds         uint32
+
+	// may be nil, in which case the default parameters are used
+	config *Config
 }
 
 // StartReceiveRound is called for the start of each receive round (burst) in the slow start phase.
@@ -46,16 +51,15 @@ func (s *HybridSlowStartpp) StartReceiveRound(lastSent protocol.PacketNumber) {
 	s.lastRoundMinRTT = s.currentRoundMinRTT
 	s.currentRoundMinRTT = 0
 	s.rttSampleCount = 0
-	s.inLSS = false
 	s.started = true
+	if s.inCSS {
+		s.cssRounds++
+	}
 }
 
-func (s *HybridSlowStartpp) IsInLSS() bool {
-	return s.inLSS
-}
-
-func (s *HybridSlowStartpp) QuitLSS() {
-	s.inLSS = false
+// InCSS says if the sender is in Conservative Slow Start.
+func (s *HybridSlowStartpp) InCSS() bool {
+	return s.inCSS
 }
 
 // IsEndOfRound returns true if this ack is the last packet number of our current slow start round.
@@ -63,38 +67,51 @@ func (s *HybridSlowStartpp) IsEndOfRound(ack protocol.PacketNumber) bool {
 	return s.endPacketNumber < ack
 }
 
-func (s *HybridSlowStartpp) UpdateCwndHystartppSlowStart(ackedBytes protocol.ByteCount, cwnd protocol.ByteCount, maxDatagramSize protocol.ByteCount) protocol.ByteCount {
-	// maxDatagramSize : SMSS, ackedBytes:N
-	return cwnd + protocol.ByteCount(math.Min(float64(ackedBytes), hybridStartppL*float64(maxDatagramSize)))
-}
-func (s *HybridSlowStartpp) UpdateCwndHystartppLowSlowStart(ackedBytes protocol.ByteCount, cwnd protocol.ByteCount, maxDatagramSize protocol.ByteCount, ssthresh protocol.ByteCount, predictedCAcwnd protocol.ByteCount) protocol.ByteCount {
-	// maxDatagramSize : SMSS, ackedBytes:N
-	K := float64(cwnd) / (hybridStartppLSS_DIVISOR * float64(ssthresh))
-	return protocol.ByteCount(math.Max(float64(cwnd) + (math.Min(float64(ackedBytes), hybridStartppL*float64(maxDatagramSize)) / K), float64(predictedCAcwnd)))
+// CongestionWindowAfterAck returns the congestion window after ackedBytes were acknowledged
+// during slow start or Conservative Slow Start.
+func (s *HybridSlowStartpp) CongestionWindowAfterAck(ackedBytes, congestionWindow, maxDatagramSize protocol.ByteCount) protocol.ByteCount {
+	increase := utils.MinByteCount(ackedBytes, hybridStartppL*maxDatagramSize)
+	if s.inCSS {
+		increase /= s.config.hybridStartCSSGrowthDivisor()
+	}
+	return congestionWindow + increase
 }
+
 // ShouldExitSlowStart should be called on every new ack frame, since a new
 // RTT measurement can be made then.
-// rtt: the RTT for this ack packet.
-// minRTT: is the lowest delay (RTT) we have seen during the session.
-// congestionWindow: the congestion window in packets.
-
-func (s *HybridSlowStartpp) ShouldExitSlowStart(latestRTT time.Duration, minRTT time.Duration, congestionWindow protocol.ByteCount) bool {
+// It returns true when Conservative Slow Start is complete, and the sender should enter congestion avoidance.
+func (s *HybridSlowStartpp) ShouldExitSlowStart(latestRTT time.Duration) bool {
 	if !s.started {
-		// Time to start the hybrid slow start.
 		s.StartReceiveRound(s.lastSentPacketNumber)
 	}
-	//keep track of minimum observed RTT, 
-	if s.currentRoundMinRTT == 0 || s.currentRoundMinRTT > latestRTT {
+	if s.currentRoundMinRTT == 0 || latestRTT < s.currentRoundMinRTT {
 		s.currentRoundMinRTT = latestRTT
 	}
 	s.rttSampleCount++
-	if (congestionWindow >= hybridStartppLowWindow  && s.rttSampleCount >= hybridStartppNRttSample) {
-		rttThresh := utils.MaxDuration(hybridStartppDelayMinThresholdUs, utils.MinDuration(s.lastRoundMinRTT >> 3, hybridStartppDelayMaxThresholdUs))//we divide minRTT by 8
-		if (s.currentRoundMinRTT >= (s.lastRoundMinRTT + rttThresh)){
-			s.inLSS = true
-			//ssthresh = cwnd
+	nRTTSample := s.config.hybridStartRTTSamples(hybridStartppNRTTSample)
+
+	if s.inCSS {
+		if s.cssRounds >= hybridStartppCSSRounds {
 			return true
 		}
+		// The RTT increase was spurious. Resume slow start.
+		if s.rttSampleCount >= nRTTSample && s.currentRoundMinRTT < s.cssBaselineMinRTT {
+			s.inCSS = false
+			s.cssBaselineMinRTT = 0
+			s.cssRounds = 0
+		}
+		return false
+	}
+	if s.rttSampleCount >= nRTTSample && s.lastRoundMinRTT != 0 {
+		rttThresh := utils.MaxDuration(
+			s.config.hybridStartMinRTTThreshold(hybridStartppMinRTTThresh),
+			utils.MinDuration(s.lastRoundMinRTT/hybridStartppMinRTTDivisor, s.config.hybridStartMaxRTTThreshold(hybridStartppMaxRTTThresh)),
+		)
+		if s.currentRoundMinRTT >= s.lastRoundMinRTT+rttThresh {
+			s.inCSS = true
+			s.cssBaselineMinRTT = s.currentRoundMinRTT
+			s.cssRounds = 0
+		}
 	}
 	return false
 }
@@ -115,6 +132,5 @@ func (s *HybridSlowStartpp) OnPacketAcked(ackedPacketNumber protocol.PacketNumbe
 
 // Restart the slow start phase
 func (s *HybridSlowStartpp) Restart() {
-	s.started = false
+	*s = HybridSlowStartpp{lastSentPacketNumber: s.lastSentPacketNumber, config: s.config}
 }
-
@@ -0,0 +1,157 @@
+package congestion
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HyStart++", func() {
+	var (
+		slowStart HybridSlowStartpp
+		lastSent  protocol.PacketNumber
+	)
+
+	BeforeEach(func() {
+		slowStart = HybridSlowStartpp{}
+		lastSent = 0
+	})
+
+	// runRound sends a round of packets, and acknowledges them all with the same RTT.
+	// Since a round ends when a packet sent after the end of the round is acknowledged,
+	// it sends one more packet than the number of RTT samples needed per round.
+	// It returns true if HyStart++ said that slow start should be exited.
+	runRound := func(rtt time.Duration) bool {
+		first := lastSent + 1
+		for i := uint32(0); i <= hybridStartppNRTTSample; i++ {
+			lastSent++
+			slowStart.OnPacketSent(lastSent)
+		}
+		var exit bool
+		for pn := first; pn <= lastSent; pn++ {
+			if slowStart.ShouldExitSlowStart(rtt) {
+				exit = true
+			}
+			slowStart.OnPacketAcked(pn)
+		}
+		return exit
+	}
+
+	It("limits the increase of the congestion window per ACK", func() {
+		const mds = protocol.ByteCount(1000)
+		Expect(slowStart.CongestionWindowAfterAck(2*mds, 10*mds, mds)).To(Equal(12 * mds))
+		Expect(slowStart.CongestionWindowAfterAck(20*mds, 10*mds, mds)).To(Equal((10 + hybridStartppL) * mds))
+	})
+
+	It("doesn't enter CSS if the RTT stays constant", func() {
+		for i := 0; i < 10; i++ {
+			Expect(runRound(100 * time.Millisecond)).To(BeFalse())
+			Expect(slowStart.InCSS()).To(BeFalse())
+		}
+	})
+
+	It("doesn't check for an RTT increase before N_RTT_SAMPLE samples were taken", func() {
+		Expect(runRound(100 * time.Millisecond)).To(BeFalse())
+		// end the round
+		Expect(slowStart.ShouldExitSlowStart(100 * time.Millisecond)).To(BeFalse())
+		slowStart.OnPacketAcked(lastSent + 1)
+		slowStart.OnPacketSent(lastSent + 100)
+		for i := uint32(1); i < hybridStartppNRTTSample; i++ {
+			Expect(slowStart.ShouldExitSlowStart(200 * time.Millisecond)).To(BeFalse())
+			Expect(slowStart.InCSS()).To(BeFalse())
+		}
+		Expect(slowStart.ShouldExitSlowStart(200 * time.Millisecond)).To(BeFalse())
+		Expect(slowStart.InCSS()).To(BeTrue())
+	})
+
+	It("enters CSS when the RTT increases by more than lastRoundMinRTT / MIN_RTT_DIVISOR", func() {
+		// the threshold is 80ms / 8 = 10ms
+		runRound(80 * time.Millisecond)
+		runRound(89 * time.Millisecond)
+		Expect(slowStart.InCSS()).To(BeFalse())
+		// the threshold is 88ms / 8 = 11ms
+		runRound(88 * time.Millisecond)
+		runRound(99 * time.Millisecond)
+		Expect(slowStart.InCSS()).To(BeTrue())
+	})
+
+	It("clamps the RTT threshold to MIN_RTT_THRESH", func() {
+		// 8ms / 8 = 1ms, clamped to 4ms
+		runRound(8 * time.Millisecond)
+		runRound(11 * time.Millisecond)
+		Expect(slowStart.InCSS()).To(BeFalse())
+		runRound(15 * time.Millisecond)
+		Expect(slowStart.InCSS()).To(BeTrue())
+	})
+
+	It("clamps the RTT threshold to MAX_RTT_THRESH", func() {
+		// 400ms / 8 = 50ms, clamped to 16ms
+		runRound(400 * time.Millisecond)
+		runRound(415 * time.Millisecond)
+		Expect(slowStart.InCSS()).To(BeFalse())
+		runRound(431 * time.Millisecond)
+		Expect(slowStart.InCSS()).To(BeTrue())
+	})
+
+	It("grows the congestion window more slowly in CSS", func() {
+		const mds = protocol.ByteCount(1000)
+		runRound(100 * time.Millisecond)
+		runRound(200 * time.Millisecond)
+		Expect(slowStart.InCSS()).To(BeTrue())
+		Expect(slowStart.CongestionWindowAfterAck(2*mds, 10*mds, mds)).To(Equal(10*mds + 2*mds/hybridStartppCSSGrowthDivisor))
+	})
+
+	It("resumes slow start if the RTT drops below the CSS baseline", func() {
+		runRound(100 * time.Millisecond)
+		runRound(200 * time.Millisecond)
+		Expect(slowStart.InCSS()).To(BeTrue())
+		Expect(runRound(150 * time.Millisecond)).To(BeFalse())
+		Expect(slowStart.InCSS()).To(BeFalse())
+	})
+
+	It("exits slow start after CSS_ROUNDS rounds", func() {
+		runRound(100 * time.Millisecond)
+		runRound(200 * time.Millisecond)
+		Expect(slowStart.InCSS()).To(BeTrue())
+		for i := uint32(1); i < hybridStartppCSSRounds; i++ {
+			Expect(runRound(200 * time.Millisecond)).To(BeFalse())
+			Expect(slowStart.InCSS()).To(BeTrue())
+		}
+		Expect(runRound(200 * time.Millisecond)).To(BeTrue())
+	})
+
+	It("uses the configured parameters", func() {
+		const mds = protocol.ByteCount(1000)
+		slowStart.config = &Config{
+			HybridStartMinRTTThreshold:  30 * time.Millisecond,
+			HybridStartMaxRTTThreshold:  50 * time.Millisecond,
+			HybridStartCSSGrowthDivisor: 2,
+		}
+		runRound(100 * time.Millisecond)
+		runRound(129 * time.Millisecond)
+		Expect(slowStart.InCSS()).To(BeFalse())
+		runRound(159 * time.Millisecond)
+		Expect(slowStart.InCSS()).To(BeTrue())
+		Expect(slowStart.CongestionWindowAfterAck(2*mds, 10*mds, mds)).To(Equal(11 * mds))
+	})
+
+	It("uses the configured number of RTT samples", func() {
+		slowStart.config = &Config{HybridStartRTTSamples: 2 * hybridStartppNRTTSample}
+		runRound(100 * time.Millisecond)
+		runRound(200 * time.Millisecond)
+		// not enough RTT samples were taken in this round
+		Expect(slowStart.InCSS()).To(BeFalse())
+	})
+
+	It("resets the state when restarting", func() {
+		runRound(100 * time.Millisecond)
+		runRound(200 * time.Millisecond)
+		Expect(slowStart.InCSS()).To(BeTrue())
+		slowStart.Restart()
+		Expect(slowStart.InCSS()).To(BeFalse())
+		Expect(runRound(300 * time.Millisecond)).To(BeFalse())
+		Expect(slowStart.InCSS()).To(BeFalse())
+	})
+})
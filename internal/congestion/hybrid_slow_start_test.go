@@ -69,4 +69,22 @@ var _ = Describe("Hybrid slow start", func() {
 		// RTT provided.
 		Expect(slowStart.ShouldExitSlowStart(rtt+10*time.Millisecond, rtt, 100)).To(BeTrue())
 	})
+
+	It("uses the configured parameters", func() {
+		slowStart.config = &Config{
+			HybridStartRTTSamples:      2,
+			HybridStartMinRTTThreshold: 20 * time.Millisecond,
+			HybridStartLowWindow:       50,
+		}
+		rtt := 60 * time.Millisecond
+		slowStart.StartReceiveRound(1)
+		// an RTT increase of 15ms is below the configured threshold
+		Expect(slowStart.ShouldExitSlowStart(rtt+15*time.Millisecond, rtt, 100)).To(BeFalse())
+		Expect(slowStart.ShouldExitSlowStart(rtt+15*time.Millisecond, rtt, 100)).To(BeFalse())
+		slowStart.StartReceiveRound(2)
+		Expect(slowStart.ShouldExitSlowStart(rtt+25*time.Millisecond, rtt, 40)).To(BeFalse())
+		// the increase is detected after 2 samples, but the congestion window is below the low window
+		Expect(slowStart.ShouldExitSlowStart(rtt+25*time.Millisecond, rtt, 40)).To(BeFalse())
+		Expect(slowStart.ShouldExitSlowStart(rtt+25*time.Millisecond, rtt, 50)).To(BeTrue())
+	})
 })
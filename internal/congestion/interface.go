@@ -16,6 +16,20 @@ type SendAlgorithm interface {
 	OnPacketAcked(number protocol.PacketNumber, ackedBytes protocol.ByteCount, priorInFlight protocol.ByteCount, eventTime time.Time)
 	OnPacketLost(number protocol.PacketNumber, lostBytes protocol.ByteCount, priorInFlight protocol.ByteCount)
 	OnRetransmissionTimeout(packetsRetransmitted bool)
+	// OnPersistentCongestion is called when persistent congestion is established (see section 7.6 of RFC 9002),
+	// after OnPacketLost was called for the lost packets.
+	OnPersistentCongestion()
+	// OnConnectionMigration resets the congestion controller to its initial state.
+	// It is called when the path might have changed, and the current estimates can't be trusted any more.
+	OnConnectionMigration()
+	// SetApplicationLimited is called when the application marks the connection as (no longer) application-limited.
+	SetApplicationLimited(limited bool, bytesInFlight protocol.ByteCount)
+	// SetMaxSendRate limits the send rate, independent of the congestion window. 0 removes the limit.
+	SetMaxSendRate(Bandwidth)
+	// OnDeliveryRateReport is called when the peer reports the rate at which it received data during the last interval.
+	OnDeliveryRateReport(rate Bandwidth, interval time.Duration, eventTime time.Time)
+	// SetMaxDatagramSize is called when the maximum datagram size changes.
+	// It increases when Path MTU Discovery finds a larger MTU, and decreases when the MTU shrinks.
 	SetMaxDatagramSize(protocol.ByteCount)
 }
 
@@ -25,4 +39,24 @@ type SendAlgorithmWithDebugInfos interface {
 	InSlowStart() bool
 	InRecovery() bool
 	GetCongestionWindow() protocol.ByteCount
+	// MaxCongestionWindowUsed returns the largest congestion window that was in use when an ack-eliciting packet was sent.
+	MaxCongestionWindowUsed() protocol.ByteCount
+	GetSlowStartThreshold() protocol.ByteCount
+	// PacingRate returns the rate at which packets are paced. It returns 0 if packets are not paced.
+	PacingRate() Bandwidth
+	// PacingBudget returns the number of bytes that can be sent right now without violating the pacing rate.
+	PacingBudget() protocol.ByteCount
 }
+
+// An Event is a reaction of the congestion controller to the network.
+type Event uint8
+
+const (
+	// EventLoss means that the congestion window was reduced in response to a packet loss.
+	EventLoss Event = iota
+	// EventPersistentCongestion means that the congestion window was reduced to the minimum congestion window,
+	// because persistent congestion was established.
+	EventPersistentCongestion
+	// EventSlowStartExit means that the slow start algorithm (e.g. HyStart) exited slow start before a loss occurred.
+	EventSlowStartExit
+)
@@ -0,0 +1,85 @@
+package congestion
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+const (
+	// The number of RTT samples the delay-based loss classifier uses.
+	lossClassifierSamples = 8
+	// A loss is only classified as random if the queueing delay is below this fraction of the largest queueing delay
+	// observed on the connection.
+	lossClassifierQueueingThreshold = 0.25
+)
+
+// A LossClassifier decides if a packet loss was caused by congestion,
+// or if it is a random loss, e.g. caused by interference on a wireless link.
+// Senders don't reduce the congestion window for random losses.
+type LossClassifier interface {
+	// OnPacketAcked is called for every acknowledged packet.
+	OnPacketAcked(eventTime time.Time)
+	// IsRandomLoss is called when a packet is declared lost.
+	IsRandomLoss() bool
+}
+
+// delayLossClassifier classifies losses based on the trend of the RTT.
+// Congestion losses happen when the bottleneck queue is full,
+// so they are preceded by a growing queueing delay.
+// A loss is classified as random if the RTT is close to the min RTT (relative to the largest RTT seen so far),
+// and the RTT didn't increase over the last samples.
+// As long as there aren't enough RTT samples, all losses are classified as congestion losses.
+type delayLossClassifier struct {
+	rttStats *utils.RTTStats
+
+	// a ring buffer of the latest RTT samples
+	samples    [lossClassifierSamples]time.Duration
+	numSamples int
+	// all packets acknowledged by an ACK frame have the same event time, and yield the same RTT sample
+	lastSampleTime time.Time
+	maxRTT         time.Duration
+}
+
+var _ LossClassifier = &delayLossClassifier{}
+
+func newDelayLossClassifier(rttStats *utils.RTTStats) *delayLossClassifier {
+	return &delayLossClassifier{rttStats: rttStats}
+}
+
+func (c *delayLossClassifier) OnPacketAcked(eventTime time.Time) {
+	if eventTime.Equal(c.lastSampleTime) {
+		return
+	}
+	rtt := c.rttStats.LatestRTT()
+	if rtt == 0 {
+		return
+	}
+	c.lastSampleTime = eventTime
+	c.samples[c.numSamples%lossClassifierSamples] = rtt
+	c.numSamples++
+	c.maxRTT = utils.MaxDuration(c.maxRTT, rtt)
+}
+
+func (c *delayLossClassifier) IsRandomLoss() bool {
+	if c.numSamples < lossClassifierSamples {
+		return false
+	}
+	// sum up the older and the newer half of the samples
+	var older, newer time.Duration
+	for i := 0; i < lossClassifierSamples; i++ {
+		rtt := c.samples[(c.numSamples+i)%lossClassifierSamples]
+		if i < lossClassifierSamples/2 {
+			older += rtt
+		} else {
+			newer += rtt
+		}
+	}
+	// The queue is building up.
+	if (newer-older)/(lossClassifierSamples/2) > c.rttStats.MeanDeviation()/2 {
+		return false
+	}
+	minRTT := c.rttStats.MinRTT()
+	queueingDelay := (older+newer)/lossClassifierSamples - minRTT
+	return float64(queueingDelay) <= lossClassifierQueueingThreshold*float64(c.maxRTT-minRTT)
+}
@@ -0,0 +1,76 @@
+package congestion
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Delay-based Loss Classifier", func() {
+	var (
+		classifier *delayLossClassifier
+		rttStats   *utils.RTTStats
+		now        time.Time
+	)
+
+	BeforeEach(func() {
+		rttStats = utils.NewRTTStats()
+		classifier = newDelayLossClassifier(rttStats)
+		now = time.Now()
+	})
+
+	sample := func(rtt time.Duration) {
+		now = now.Add(10 * time.Millisecond)
+		rttStats.UpdateRTT(rtt, 0, now)
+		classifier.OnPacketAcked(now)
+	}
+
+	// fill fills the classifier with samples around the min RTT, and observes a large RTT once.
+	fill := func() {
+		sample(100 * time.Millisecond)
+		sample(300 * time.Millisecond)
+		for i := 0; i < lossClassifierSamples; i++ {
+			sample(100 * time.Millisecond)
+		}
+	}
+
+	It("classifies losses as congestion losses until it has enough samples", func() {
+		for i := 0; i < lossClassifierSamples-1; i++ {
+			sample(100 * time.Millisecond)
+			Expect(classifier.IsRandomLoss()).To(BeFalse())
+		}
+		sample(100 * time.Millisecond)
+		Expect(classifier.IsRandomLoss()).To(BeTrue())
+	})
+
+	It("only takes one sample per ACK", func() {
+		for i := 0; i < lossClassifierSamples-1; i++ {
+			sample(100 * time.Millisecond)
+		}
+		classifier.OnPacketAcked(now)
+		Expect(classifier.IsRandomLoss()).To(BeFalse())
+	})
+
+	It("classifies losses at a low RTT as random losses", func() {
+		fill()
+		Expect(classifier.IsRandomLoss()).To(BeTrue())
+	})
+
+	It("classifies losses at a high RTT as congestion losses", func() {
+		fill()
+		for i := 0; i < lossClassifierSamples; i++ {
+			sample(200 * time.Millisecond)
+		}
+		Expect(classifier.IsRandomLoss()).To(BeFalse())
+	})
+
+	It("classifies losses while the RTT is increasing as congestion losses", func() {
+		fill()
+		for i := 0; i < lossClassifierSamples/2; i++ {
+			sample(140 * time.Millisecond)
+		}
+		Expect(classifier.IsRandomLoss()).To(BeFalse())
+	})
+})
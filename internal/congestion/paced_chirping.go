@@ -0,0 +1,193 @@
+package congestion
+
+import (
+	"math"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+)
+
+// The constants of Paced Chirping, see "Paced Chirping: Rapid flow start with very low queuing delay"
+// by Misund and Briscoe.
+const (
+	// Number of packets in a chirp.
+	chirpPackets = 16
+	// The gap between the packets of a chirp decreases geometrically,
+	// from chirpGapSpread times the target gap to the target gap divided by chirpGapSpread.
+	chirpGapSpread = 2
+	// Number of consecutive packets with an increasing delay that mark a queueing delay excursion.
+	chirpExcursionLength = 4
+	// Number of consecutive chirps with an excursion needed before exiting slow start.
+	chirpRequiredExcursions = 2
+	// Gaps below this value can't be realized. If the target gap gets smaller, chirping is stopped.
+	chirpMinGap = time.Microsecond
+)
+
+type chirp struct {
+	// gaps[i] is the gap between packet i-1 and packet i
+	gaps      [chirpPackets]time.Duration
+	sentTimes [chirpPackets]time.Time
+	// A value of 0 means that no delay sample was taken for the packet.
+	delays [chirpPackets]time.Duration
+
+	numSent int
+	// A chirp is analyzed when all its packets were acknowledged.
+	// Lost packets prevent the analysis, but a loss ends slow start anyway.
+	numOutstanding int
+}
+
+func newChirp(targetGap time.Duration) *chirp {
+	c := &chirp{}
+	ratio := math.Pow(1/float64(chirpGapSpread*chirpGapSpread), 1/float64(chirpPackets-2))
+	gap := float64(chirpGapSpread * targetGap)
+	for i := 1; i < chirpPackets; i++ {
+		c.gaps[i] = time.Duration(gap)
+		gap *= ratio
+	}
+	return c
+}
+
+// excursionGap returns the gap at which a queueing delay excursion started.
+// Sending packets at this gap was faster than the capacity of the path.
+func (c *chirp) excursionGap() (time.Duration, bool) {
+	for k := 0; k+chirpExcursionLength < chirpPackets; k++ {
+		isExcursion := true
+		for i := k; i < k+chirpExcursionLength; i++ {
+			if c.delays[i] == 0 || c.delays[i+1] <= c.delays[i] {
+				isExcursion = false
+				break
+			}
+		}
+		if isExcursion {
+			return c.gaps[k+1], true
+		}
+	}
+	return 0, false
+}
+
+type chirpPacket struct {
+	chirp *chirp
+	index int
+}
+
+// PacedChirping implements Paced Chirping.
+// Instead of sending bursts of packets, the sender sends chirps:
+// groups of packets with decreasing gaps between them.
+// The gap at which the queueing delay starts to increase is used to estimate the capacity of the path.
+// It is only used for the initial slow start of a connection.
+type PacedChirping struct {
+	started bool
+	// A value of 0 means that chirping is not active.
+	targetGap time.Duration
+
+	current      *chirp
+	packets      map[protocol.PacketNumber]chirpPacket
+	nextSendTime time.Time
+
+	excursions int
+	// average of the excursion gaps of the last chirps
+	estimatedGap time.Duration
+}
+
+// Start starts chirping.
+// The initial gap should be derived from the congestion window and the RTT.
+// Chirping is only started once.
+func (p *PacedChirping) Start(initialGap time.Duration) {
+	if p.started {
+		return
+	}
+	p.started = true
+	if initialGap >= chirpMinGap {
+		p.targetGap = initialGap
+	}
+}
+
+// Active says if the sender is chirping.
+func (p *PacedChirping) Active() bool {
+	return p.targetGap > 0
+}
+
+// TimeUntilSend returns when the next packet of a chirp should be sent.
+func (p *PacedChirping) TimeUntilSend() time.Time {
+	return p.nextSendTime
+}
+
+// OnPacketSent is called when a packet was sent
+func (p *PacedChirping) OnPacketSent(sentTime time.Time, packetNumber protocol.PacketNumber) {
+	if !p.Active() {
+		return
+	}
+	if p.current == nil {
+		p.current = newChirp(p.targetGap)
+	}
+	if p.packets == nil {
+		p.packets = make(map[protocol.PacketNumber]chirpPacket)
+	}
+	c := p.current
+	c.sentTimes[c.numSent] = sentTime
+	p.packets[packetNumber] = chirpPacket{chirp: c, index: c.numSent}
+	c.numSent++
+	c.numOutstanding++
+	if c.numSent == chirpPackets {
+		p.current = nil
+		p.nextSendTime = sentTime.Add(p.targetGap)
+		return
+	}
+	p.nextSendTime = sentTime.Add(c.gaps[c.numSent])
+}
+
+// OnPacketAcked is called when a packet was acknowledged
+func (p *PacedChirping) OnPacketAcked(packetNumber protocol.PacketNumber, ackTime time.Time) {
+	cp, ok := p.packets[packetNumber]
+	if !ok {
+		return
+	}
+	delete(p.packets, packetNumber)
+	cp.chirp.delays[cp.index] = ackTime.Sub(cp.chirp.sentTimes[cp.index])
+	p.onPacketDone(cp.chirp)
+}
+
+func (p *PacedChirping) onPacketDone(c *chirp) {
+	c.numOutstanding--
+	if c.numOutstanding > 0 || c.numSent < chirpPackets || !p.Active() {
+		return
+	}
+	gap, ok := c.excursionGap()
+	if !ok {
+		// The path can handle packets at the smallest gap of this chirp.
+		p.excursions = 0
+		p.setTargetGap(c.gaps[chirpPackets-1])
+		return
+	}
+	p.excursions++
+	if p.excursions == 1 {
+		p.estimatedGap = gap
+	} else {
+		p.estimatedGap = (p.estimatedGap + gap) / 2
+	}
+	p.setTargetGap(gap)
+}
+
+func (p *PacedChirping) setTargetGap(gap time.Duration) {
+	if gap < chirpMinGap {
+		// Stop chirping. The sender continues with slow start.
+		p.targetGap = 0
+		p.nextSendTime = time.Time{}
+		return
+	}
+	p.targetGap = gap
+}
+
+// CapacityEstimate returns the estimated gap between packets that the path can sustain.
+// It returns false until enough chirps were analyzed.
+func (p *PacedChirping) CapacityEstimate() (time.Duration, bool) {
+	if !p.Active() || p.excursions < chirpRequiredExcursions {
+		return 0, false
+	}
+	return p.estimatedGap, true
+}
+
+// Restart the slow start phase
+func (p *PacedChirping) Restart() {
+	*p = PacedChirping{}
+}
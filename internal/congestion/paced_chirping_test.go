@@ -0,0 +1,128 @@
+package congestion
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Paced Chirping", func() {
+	var (
+		chirping PacedChirping
+		now      time.Time
+		lastSent protocol.PacketNumber
+	)
+
+	BeforeEach(func() {
+		chirping = PacedChirping{}
+		now = time.Now()
+		lastSent = 0
+	})
+
+	// sendChirp sends a chirp, and acknowledges every packet after delay(i).
+	sendChirp := func(delay func(i int) time.Duration) {
+		first := lastSent + 1
+		sentTimes := make([]time.Time, chirpPackets)
+		for i := 0; i < chirpPackets; i++ {
+			if t := chirping.TimeUntilSend(); t.After(now) {
+				now = t
+			}
+			lastSent++
+			sentTimes[i] = now
+			chirping.OnPacketSent(now, lastSent)
+		}
+		for i := 0; i < chirpPackets; i++ {
+			chirping.OnPacketAcked(first+protocol.PacketNumber(i), sentTimes[i].Add(delay(i)))
+		}
+	}
+
+	constantDelay := func(int) time.Duration { return 50 * time.Millisecond }
+	// the queueing delay starts increasing after packet 7
+	excursionDelay := func(i int) time.Duration {
+		if i <= 7 {
+			return 50 * time.Millisecond
+		}
+		return 50*time.Millisecond + time.Duration(i-7)*time.Millisecond
+	}
+
+	It("decreases the gaps between the packets of a chirp", func() {
+		c := newChirp(time.Millisecond)
+		Expect(c.gaps[1]).To(Equal(2 * time.Millisecond))
+		for i := 2; i < chirpPackets; i++ {
+			Expect(c.gaps[i]).To(BeNumerically("<", c.gaps[i-1]))
+		}
+		Expect(c.gaps[chirpPackets-1]).To(BeNumerically("~", 500*time.Microsecond, time.Microsecond))
+	})
+
+	It("schedules the packets of a chirp", func() {
+		chirping.Start(time.Millisecond)
+		Expect(chirping.Active()).To(BeTrue())
+		chirping.OnPacketSent(now, 1)
+		Expect(chirping.TimeUntilSend()).To(Equal(now.Add(2 * time.Millisecond)))
+		now = now.Add(2 * time.Millisecond)
+		chirping.OnPacketSent(now, 2)
+		Expect(chirping.TimeUntilSend()).To(BeTemporally("<", now.Add(2*time.Millisecond)))
+		Expect(chirping.TimeUntilSend()).To(BeTemporally(">", now.Add(time.Millisecond)))
+	})
+
+	It("is only started once", func() {
+		chirping.Start(time.Millisecond)
+		chirping.Start(time.Second)
+		Expect(chirping.targetGap).To(Equal(time.Millisecond))
+	})
+
+	It("speeds up if there's no queueing delay excursion", func() {
+		chirping.Start(time.Millisecond)
+		sendChirp(constantDelay)
+		Expect(chirping.targetGap).To(BeNumerically("~", 500*time.Microsecond, time.Microsecond))
+		_, ok := chirping.CapacityEstimate()
+		Expect(ok).To(BeFalse())
+	})
+
+	It("estimates the capacity from queueing delay excursions", func() {
+		chirping.Start(time.Millisecond)
+		sendChirp(excursionDelay)
+		c := newChirp(time.Millisecond)
+		Expect(chirping.targetGap).To(Equal(c.gaps[8]))
+		_, ok := chirping.CapacityEstimate()
+		Expect(ok).To(BeFalse())
+		sendChirp(excursionDelay)
+		gap, ok := chirping.CapacityEstimate()
+		Expect(ok).To(BeTrue())
+		Expect(gap).To(Equal((c.gaps[8] + newChirp(c.gaps[8]).gaps[8]) / 2))
+	})
+
+	It("requires consecutive excursions", func() {
+		chirping.Start(time.Millisecond)
+		sendChirp(excursionDelay)
+		sendChirp(constantDelay)
+		sendChirp(excursionDelay)
+		_, ok := chirping.CapacityEstimate()
+		Expect(ok).To(BeFalse())
+	})
+
+	It("stops chirping when the gap gets too small", func() {
+		chirping.Start(3 * chirpMinGap)
+		sendChirp(constantDelay)
+		Expect(chirping.Active()).To(BeTrue())
+		sendChirp(constantDelay)
+		Expect(chirping.Active()).To(BeFalse())
+		Expect(chirping.TimeUntilSend()).To(BeZero())
+	})
+
+	It("doesn't start with a too small gap", func() {
+		chirping.Start(chirpMinGap / 2)
+		Expect(chirping.Active()).To(BeFalse())
+	})
+
+	It("resets the state when restarting", func() {
+		chirping.Start(time.Millisecond)
+		sendChirp(excursionDelay)
+		chirping.Restart()
+		Expect(chirping.Active()).To(BeFalse())
+		chirping.Start(2 * time.Millisecond)
+		Expect(chirping.targetGap).To(Equal(2 * time.Millisecond))
+	})
+})
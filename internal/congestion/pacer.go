@@ -8,19 +8,38 @@ import (
 	"github.com/lucas-clemente/quic-go/internal/utils"
 )
 
-const maxBurstSizePackets = 10
+const (
+	maxBurstSizePackets = 10
+	pacingGain          = 1.25
+)
+
+// A Pacer paces the packets sent by a congestion controller, such that they are not sent in bursts.
+type Pacer interface {
+	// SentPacket is called for every packet sent.
+	SentPacket(sendTime time.Time, size protocol.ByteCount)
+	// Budget returns the number of bytes that can be sent at the given time.
+	Budget(now time.Time) protocol.ByteCount
+	// TimeUntilSend returns the time when the next full-size packet can be sent.
+	TimeUntilSend() time.Time
+	SetMaxDatagramSize(protocol.ByteCount)
+}
 
 // The pacer implements a token bucket pacing algorithm.
 type pacer struct {
 	budgetAtLastSent     protocol.ByteCount
 	maxDatagramSize      protocol.ByteCount
+	maxBurstPackets      protocol.ByteCount
+	granularity          time.Duration
 	lastSentTime         time.Time
 	getAdjustedBandwidth func() uint64 // in bytes/s
 }
 
-func newPacer(getBandwidth func() Bandwidth) *pacer {
+func newPacer(getBandwidth func() Bandwidth, config *Config) *pacer {
+	gain := config.pacingGain()
 	p := &pacer{
 		maxDatagramSize: initialMaxDatagramSize,
+		maxBurstPackets: config.pacerMaxBurstPackets(),
+		granularity:     config.pacingGranularity(),
 		getAdjustedBandwidth: func() uint64 {
 			// Bandwidth is in bits/s. We need the value in bytes/s.
 			bw := uint64(getBandwidth() / BytesPerSecond)
@@ -28,13 +47,45 @@ func newPacer(getBandwidth func() Bandwidth) *pacer {
 			// RTT variations then won't result in under-utilization of the congestion window.
 			// Ultimately, this will  result in sending packets as acknowledgments are received rather than when timers fire,
 			// provided the congestion window is fully utilized and acknowledgments arrive at regular intervals.
-			return bw * 5 / 4
+			return uint64(float64(bw) * gain)
 		},
 	}
 	p.budgetAtLastSent = p.maxBurstSize()
 	return p
 }
 
+var _ Pacer = &pacer{}
+
+// NewPacer creates a pacer that paces packets at a rate slightly higher than the bandwidth returned by getBandwidth.
+// It uses the default pacing parameters.
+func NewPacer(getBandwidth func() Bandwidth) Pacer {
+	return newPacer(getBandwidth, nil)
+}
+
+// newRateLimiter creates a pacer that limits the send rate to a fixed rate,
+// independent of the bandwidth estimate of the congestion controller.
+func newRateLimiter(rate Bandwidth, maxBurstPackets protocol.ByteCount, granularity time.Duration) *pacer {
+	p := &pacer{
+		maxDatagramSize: initialMaxDatagramSize,
+		maxBurstPackets: maxBurstPackets,
+		granularity:     granularity,
+	}
+	p.SetRate(rate)
+	p.budgetAtLastSent = p.maxBurstSize()
+	return p
+}
+
+// SetRate sets the fixed rate of a rate limiter.
+func (p *pacer) SetRate(rate Bandwidth) {
+	bw := utils.MaxUint64(uint64(rate/BytesPerSecond), 1)
+	p.getAdjustedBandwidth = func() uint64 { return bw }
+}
+
+// Rate returns the rate at which packets are paced.
+func (p *pacer) Rate() Bandwidth {
+	return Bandwidth(p.getAdjustedBandwidth()) * BytesPerSecond
+}
+
 func (p *pacer) SentPacket(sendTime time.Time, size protocol.ByteCount) {
 	budget := p.Budget(sendTime)
 	if size > budget {
@@ -49,29 +100,39 @@ func (p *pacer) Budget(now time.Time) protocol.ByteCount {
 	if p.lastSentTime.IsZero() {
 		return p.maxBurstSize()
 	}
+	// With kernel pacing, packets are sent with a transmit time in the future.
+	if now.Before(p.lastSentTime) {
+		return p.budgetAtLastSent
+	}
 	budget := p.budgetAtLastSent + (protocol.ByteCount(p.getAdjustedBandwidth())*protocol.ByteCount(now.Sub(p.lastSentTime).Nanoseconds()))/1e9
 	return utils.MinByteCount(p.maxBurstSize(), budget)
 }
 
+// maxBurstSize is the budget that can be accumulated.
+// It allows sending all the packets that became due while waiting for the pacing timer,
+// such that packets are sent in bursts of (at least) one pacing granularity.
 func (p *pacer) maxBurstSize() protocol.ByteCount {
 	return utils.MaxByteCount(
-		protocol.ByteCount(uint64((protocol.MinPacingDelay+protocol.TimerGranularity).Nanoseconds())*p.getAdjustedBandwidth())/1e9,
-		maxBurstSizePackets*p.maxDatagramSize,
+		protocol.ByteCount(uint64((p.granularity+protocol.TimerGranularity).Nanoseconds())*p.getAdjustedBandwidth())/1e9,
+		p.maxBurstPackets*p.maxDatagramSize,
 	)
 }
 
 // TimeUntilSend returns when the next packet should be sent.
 // It returns the zero value of time.Time if a packet can be sent immediately.
+// Packets that are due within the pacing granularity are coalesced into a single timer wake-up.
 func (p *pacer) TimeUntilSend() time.Time {
 	if p.budgetAtLastSent >= p.maxDatagramSize {
 		return time.Time{}
 	}
 	return p.lastSentTime.Add(utils.MaxDuration(
-		protocol.MinPacingDelay,
+		p.granularity,
 		time.Duration(math.Ceil(float64(p.maxDatagramSize-p.budgetAtLastSent)*1e9/float64(p.getAdjustedBandwidth())))*time.Nanosecond,
 	))
 }
 
 func (p *pacer) SetMaxDatagramSize(s protocol.ByteCount) {
 	p.maxDatagramSize = s
+	// The maximum burst size depends on the datagram size.
+	p.budgetAtLastSent = utils.MinByteCount(p.budgetAtLastSent, p.maxBurstSize())
 }
@@ -19,7 +19,7 @@ var _ = Describe("Pacer", func() {
 		bandwidth = uint64(packetsPerSecond * initialMaxDatagramSize) // 50 full-size packets per second
 		// The pacer will multiply the bandwidth with 1.25 to achieve a slightly higher pacing speed.
 		// For the tests, cancel out this factor, so we can do the math using the exact bandwidth.
-		p = newPacer(func() Bandwidth { return Bandwidth(bandwidth) * BytesPerSecond * 4 / 5 })
+		p = newPacer(func() Bandwidth { return Bandwidth(bandwidth) * BytesPerSecond * 4 / 5 }, nil)
 	})
 
 	It("allows a burst at the beginning", func() {
@@ -54,6 +54,44 @@ var _ = Describe("Pacer", func() {
 		}
 	}
 
+	It("creates a pacer with the default parameters", func() {
+		pacer := NewPacer(func() Bandwidth { return Bandwidth(bandwidth) * BytesPerSecond })
+		Expect(pacer.Budget(time.Now())).To(BeEquivalentTo(maxBurstSizePackets * initialMaxDatagramSize))
+	})
+
+	It("uses the configured maximum burst size", func() {
+		p = newPacer(func() Bandwidth { return Bandwidth(bandwidth) * BytesPerSecond * 4 / 5 }, &Config{PacerMaxBurst: 2})
+		Expect(p.Budget(time.Now())).To(BeEquivalentTo(2 * initialMaxDatagramSize))
+	})
+
+	It("uses the configured pacing gain", func() {
+		p = newPacer(func() Bandwidth { return Bandwidth(bandwidth) * BytesPerSecond / 2 }, &Config{PacingGain: 2})
+		t := time.Now()
+		sendBurst(t)
+		Expect(p.TimeUntilSend().Sub(t)).To(BeNumerically("~", time.Second/packetsPerSecond, time.Nanosecond))
+	})
+
+	It("coalesces packets that are due within the pacing granularity", func() {
+		bandwidth = uint64(1e6 * initialMaxDatagramSize)
+		p = newPacer(func() Bandwidth { return Bandwidth(bandwidth) * BytesPerSecond * 4 / 5 }, &Config{PacingGranularity: 5 * time.Millisecond})
+		t := time.Now()
+		sendBurst(t)
+		Expect(p.TimeUntilSend()).To(Equal(t.Add(5 * time.Millisecond)))
+		// all packets that became due in the meantime can be sent at once
+		Expect(p.Budget(t.Add(5 * time.Millisecond))).To(Equal(protocol.ByteCount(5*time.Millisecond) * initialMaxDatagramSize * 1e6 / 1e9))
+	})
+
+	It("limits the send rate to a fixed rate", func() {
+		p = newRateLimiter(Bandwidth(bandwidth)*BytesPerSecond, maxBurstSizePackets, protocol.MinPacingDelay)
+		t := time.Now()
+		Expect(p.Budget(t)).To(BeEquivalentTo(maxBurstSizePackets * initialMaxDatagramSize))
+		sendBurst(t)
+		Expect(p.TimeUntilSend().Sub(t)).To(BeNumerically("~", time.Second/packetsPerSecond, time.Nanosecond))
+		// double the rate
+		p.SetRate(2 * Bandwidth(bandwidth) * BytesPerSecond)
+		Expect(p.TimeUntilSend().Sub(t)).To(BeNumerically("~", time.Second/(2*packetsPerSecond), time.Nanosecond))
+	})
+
 	It("paces packets after a burst", func() {
 		t := time.Now()
 		sendBurst(t)
@@ -67,6 +105,15 @@ var _ = Describe("Pacer", func() {
 		}
 	})
 
+	It("doesn't accumulate budget before a packet sent in the future", func() {
+		t := time.Now()
+		sendBurst(t)
+		t2 := p.TimeUntilSend()
+		p.SentPacket(t2, initialMaxDatagramSize)
+		Expect(p.Budget(t)).To(BeZero())
+		Expect(p.TimeUntilSend().Sub(t2)).To(BeNumerically("~", time.Second/packetsPerSecond, time.Nanosecond))
+	})
+
 	It("accounts for non-full-size packets", func() {
 		t := time.Now()
 		sendBurst(t)
@@ -114,6 +161,14 @@ var _ = Describe("Pacer", func() {
 		Expect(p.Budget(t.Add(time.Hour))).To(BeEquivalentTo(maxBurstSizePackets * packetSize))
 	})
 
+	It("reduces the budget when the maximum datagram size decreases", func() {
+		t := time.Now()
+		p.SentPacket(t, 100)
+		p.SetMaxDatagramSize(1000)
+		// With kernel pacing, the budget of the last packet sent is used.
+		Expect(p.Budget(t.Add(-time.Millisecond))).To(BeEquivalentTo(maxBurstSizePackets * 1000))
+	})
+
 	It("changes the bandwidth", func() {
 		t := time.Now()
 		sendBurst(t)
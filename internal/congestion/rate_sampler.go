@@ -0,0 +1,175 @@
+package congestion
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+// A RateSample is a delivery rate sample, taken when a packet is acknowledged.
+type RateSample struct {
+	// DeliveryRate is the estimated delivery rate, Delivered / Interval.
+	DeliveryRate Bandwidth
+	// Delivered is the number of bytes delivered during the Interval.
+	Delivered protocol.ByteCount
+	// Lost is the number of bytes declared lost during the Interval.
+	Lost protocol.ByteCount
+	// Interval is the duration of the sampling interval,
+	// the maximum of the send interval and the ACK interval of the acknowledged packet.
+	Interval time.Duration
+	// RTT is the RTT of the acknowledged packet.
+	RTT time.Duration
+	// BytesInFlight is the number of bytes in flight when the acknowledged packet was sent, including the packet.
+	BytesInFlight protocol.ByteCount
+	// IsAppLimited says if the acknowledged packet was sent while the sender was application-limited.
+	// Samples that are application-limited underestimate the available bandwidth,
+	// and should only be used if they are larger than the current estimate.
+	IsAppLimited bool
+}
+
+// the state of the sampler when the packet was sent
+type rateSamplerPacket struct {
+	sentTime      time.Time
+	bytes         protocol.ByteCount
+	bytesInFlight protocol.ByteCount
+
+	delivered     protocol.ByteCount
+	deliveredTime time.Time
+	firstSentTime time.Time
+	lost          protocol.ByteCount
+	isAppLimited  bool
+}
+
+// rateSampler estimates the delivery rate, following draft-cheng-iccrg-delivery-rate-estimation.
+// Every packet records how much data was delivered when it was sent.
+// When the packet is acknowledged, the data delivered in the meantime divided by the elapsed time yields a rate sample.
+//
+// Senders pass all sent, acknowledged and lost packets to the sampler,
+// and call OnAppLimited when they run out of data to send.
+// SetApplicationLimited is used when the application explicitly marks the connection as application-limited.
+// Packet numbers are not unique across packet number spaces.
+// If an Initial or Handshake packet is still in flight when a 1-RTT packet with the same packet number is sent,
+// the sample for that packet number is wrong. This only affects samples taken during the handshake.
+// Packets that are neither acknowledged nor lost (because their packet number space was dropped)
+// are removed once the packet number is reused.
+type rateSampler struct {
+	rttStats *utils.RTTStats
+
+	packets map[protocol.PacketNumber]*rateSamplerPacket
+
+	// the total number of bytes delivered and lost
+	delivered protocol.ByteCount
+	lost      protocol.ByteCount
+	// the time the last packet was acknowledged
+	deliveredTime time.Time
+	// the send time of the most recently sent packet that was acknowledged
+	firstSentTime time.Time
+	// If the sender is application-limited, the value of delivered at which the application-limited phase ends.
+	// 0 means that the sender is not application-limited.
+	appLimitedUntil protocol.ByteCount
+	// set while the application marks the connection as application-limited
+	appLimited bool
+}
+
+func newRateSampler(rttStats *utils.RTTStats) *rateSampler {
+	return &rateSampler{
+		rttStats: rttStats,
+		packets:  make(map[protocol.PacketNumber]*rateSamplerPacket),
+	}
+}
+
+// OnPacketSent records the state of the sampler for a packet.
+// priorInFlight is the number of bytes in flight before the packet was sent.
+func (s *rateSampler) OnPacketSent(sentTime time.Time, priorInFlight protocol.ByteCount, pn protocol.PacketNumber, bytes protocol.ByteCount) {
+	if priorInFlight == 0 {
+		// Start a new sampling interval when the sender is idle.
+		// Otherwise, the time spent idle would be included in the send interval.
+		s.firstSentTime = sentTime
+		s.deliveredTime = sentTime
+	}
+	s.packets[pn] = &rateSamplerPacket{
+		sentTime:      sentTime,
+		bytes:         bytes,
+		bytesInFlight: priorInFlight + bytes,
+		delivered:     s.delivered,
+		deliveredTime: s.deliveredTime,
+		firstSentTime: s.firstSentTime,
+		lost:          s.lost,
+		isAppLimited:  s.IsAppLimited(),
+	}
+}
+
+// OnPacketAcked is called when a packet is acknowledged.
+// It returns a rate sample, if a valid sample can be taken.
+// Samples with an interval shorter than the min RTT are not valid,
+// since this happens when ACKs are compressed.
+func (s *rateSampler) OnPacketAcked(pn protocol.PacketNumber, eventTime time.Time) (RateSample, bool) {
+	p, ok := s.packets[pn]
+	if !ok {
+		return RateSample{}, false
+	}
+	delete(s.packets, pn)
+
+	s.delivered += p.bytes
+	s.deliveredTime = eventTime
+	if p.sentTime.After(s.firstSentTime) {
+		s.firstSentTime = p.sentTime
+	}
+	if s.appLimitedUntil > 0 && s.delivered > s.appLimitedUntil {
+		s.appLimitedUntil = 0
+	}
+
+	if p.deliveredTime.IsZero() {
+		return RateSample{}, false
+	}
+	sample := RateSample{
+		Delivered:     s.delivered - p.delivered,
+		Lost:          s.lost - p.lost,
+		Interval:      utils.MaxDuration(p.sentTime.Sub(p.firstSentTime), eventTime.Sub(p.deliveredTime)),
+		RTT:           eventTime.Sub(p.sentTime),
+		BytesInFlight: p.bytesInFlight,
+		IsAppLimited:  p.isAppLimited,
+	}
+	if sample.Interval <= 0 || sample.Interval < s.rttStats.MinRTT() {
+		return sample, false
+	}
+	sample.DeliveryRate = BandwidthFromDelta(sample.Delivered, sample.Interval)
+	return sample, true
+}
+
+// OnPacketLost is called when a packet is declared lost.
+func (s *rateSampler) OnPacketLost(pn protocol.PacketNumber, bytes protocol.ByteCount) {
+	delete(s.packets, pn)
+	s.lost += bytes
+}
+
+// OnAppLimited is called when the sender doesn't have any data to send, although the congestion window would allow it.
+// The application-limited phase ends once a packet sent after all data currently in flight is acknowledged.
+func (s *rateSampler) OnAppLimited(bytesInFlight protocol.ByteCount) {
+	s.appLimitedUntil = utils.MaxByteCount(s.delivered+bytesInFlight, 1)
+}
+
+// SetApplicationLimited is called when the application marks the connection as (no longer) application-limited.
+// All packets sent while the connection is marked are application-limited.
+// When the mark is removed, the application-limited phase ends as if OnAppLimited was called at that moment.
+func (s *rateSampler) SetApplicationLimited(limited bool, bytesInFlight protocol.ByteCount) {
+	if limited {
+		s.appLimited = true
+		return
+	}
+	if s.appLimited {
+		s.appLimited = false
+		s.OnAppLimited(bytesInFlight)
+	}
+}
+
+// IsAppLimited says if the sender is in an application-limited phase.
+func (s *rateSampler) IsAppLimited() bool {
+	return s.appLimited || s.appLimitedUntil > 0
+}
+
+// Delivered returns the total number of bytes delivered.
+func (s *rateSampler) Delivered() protocol.ByteCount {
+	return s.delivered
+}
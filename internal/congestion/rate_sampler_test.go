@@ -0,0 +1,182 @@
+package congestion
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Delivery Rate Sampler", func() {
+	const rtt = 100 * time.Millisecond
+
+	var (
+		sampler  *rateSampler
+		rttStats *utils.RTTStats
+		now      time.Time
+		inFlight protocol.ByteCount
+	)
+
+	BeforeEach(func() {
+		rttStats = utils.NewRTTStats()
+		sampler = newRateSampler(rttStats)
+		now = time.Now()
+		inFlight = 0
+	})
+
+	send := func(pn protocol.PacketNumber) {
+		sampler.OnPacketSent(now, inFlight, pn, maxDatagramSize)
+		inFlight += maxDatagramSize
+	}
+
+	ack := func(pn protocol.PacketNumber) (RateSample, bool) {
+		inFlight -= maxDatagramSize
+		return sampler.OnPacketAcked(pn, now)
+	}
+
+	It("measures the delivery rate", func() {
+		// send 10 packets, one every 10ms
+		for pn := protocol.PacketNumber(1); pn <= 10; pn++ {
+			send(pn)
+			now = now.Add(10 * time.Millisecond)
+		}
+		now = now.Add(rtt - 100*time.Millisecond)
+		// acknowledge the packets at the same rate, and keep sending
+		for pn := protocol.PacketNumber(1); pn <= 10; pn++ {
+			sample, ok := ack(pn)
+			if pn == 1 {
+				// the sample for the first packet only covers a single packet
+				Expect(ok).To(BeTrue())
+				Expect(sample.Delivered).To(Equal(maxDatagramSize))
+				Expect(sample.Interval).To(Equal(rtt))
+			}
+			send(pn + 10)
+			now = now.Add(10 * time.Millisecond)
+		}
+		rttStats.UpdateRTT(rtt, 0, now)
+		for pn := protocol.PacketNumber(11); pn <= 20; pn++ {
+			sample, ok := ack(pn)
+			Expect(ok).To(BeTrue())
+			Expect(sample.Delivered).To(Equal(10 * maxDatagramSize))
+			Expect(sample.Interval).To(Equal(rtt))
+			Expect(sample.RTT).To(Equal(rtt))
+			Expect(sample.DeliveryRate).To(Equal(BandwidthFromDelta(10*maxDatagramSize, rtt)))
+			Expect(sample.IsAppLimited).To(BeFalse())
+			now = now.Add(10 * time.Millisecond)
+		}
+		Expect(sampler.Delivered()).To(Equal(20 * maxDatagramSize))
+	})
+
+	It("uses the send interval, if it's longer than the ACK interval", func() {
+		send(1)
+		now = now.Add(50 * time.Millisecond)
+		send(2)
+		now = now.Add(rtt)
+		_, ok := ack(1)
+		Expect(ok).To(BeTrue())
+		// ACK compression: packet 2 is acknowledged at the same time as packet 1
+		sample, ok := ack(2)
+		Expect(ok).To(BeTrue())
+		Expect(sample.Delivered).To(Equal(2 * maxDatagramSize))
+		Expect(sample.Interval).To(Equal(rtt + 50*time.Millisecond))
+	})
+
+	It("rejects samples with an interval shorter than the min RTT", func() {
+		rttStats.UpdateRTT(rtt, 0, now)
+		send(1)
+		now = now.Add(rtt / 2)
+		sample, ok := ack(1)
+		Expect(ok).To(BeFalse())
+		Expect(sample.Interval).To(Equal(rtt / 2))
+	})
+
+	It("starts a new interval after an idle period", func() {
+		send(1)
+		now = now.Add(rtt)
+		_, ok := ack(1)
+		Expect(ok).To(BeTrue())
+		Expect(inFlight).To(BeZero())
+		// the time spent idle is not included in the interval
+		now = now.Add(time.Hour)
+		send(2)
+		now = now.Add(rtt)
+		sample, ok := ack(2)
+		Expect(ok).To(BeTrue())
+		Expect(sample.Interval).To(Equal(rtt))
+		Expect(sample.Delivered).To(Equal(maxDatagramSize))
+	})
+
+	It("counts lost bytes", func() {
+		send(1)
+		send(2)
+		send(3)
+		now = now.Add(rtt)
+		sampler.OnPacketLost(1, maxDatagramSize)
+		inFlight -= maxDatagramSize
+		sample, ok := ack(2)
+		Expect(ok).To(BeTrue())
+		Expect(sample.Lost).To(Equal(maxDatagramSize))
+		Expect(sample.BytesInFlight).To(Equal(2 * maxDatagramSize))
+		// the lost packet can't be acknowledged any more
+		_, ok = sampler.OnPacketAcked(1, now)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("marks samples as application-limited", func() {
+		send(1)
+		send(2)
+		sampler.OnAppLimited(inFlight)
+		Expect(sampler.IsAppLimited()).To(BeTrue())
+		send(3)
+		now = now.Add(rtt)
+		// packets sent before the sender became application-limited
+		sample, ok := ack(1)
+		Expect(ok).To(BeTrue())
+		Expect(sample.IsAppLimited).To(BeFalse())
+		_, ok = ack(2)
+		Expect(ok).To(BeTrue())
+		Expect(sampler.IsAppLimited()).To(BeTrue())
+		// the application-limited phase ends once a packet sent after the data in flight was delivered
+		sample, ok = ack(3)
+		Expect(ok).To(BeTrue())
+		Expect(sample.IsAppLimited).To(BeTrue())
+		Expect(sampler.IsAppLimited()).To(BeFalse())
+	})
+
+	It("marks samples as application-limited while the application marks the connection", func() {
+		send(1)
+		sampler.SetApplicationLimited(true, inFlight)
+		Expect(sampler.IsAppLimited()).To(BeTrue())
+		send(2)
+		now = now.Add(rtt)
+		_, ok := ack(1)
+		Expect(ok).To(BeTrue())
+		// the mark doesn't expire when data is delivered
+		Expect(sampler.IsAppLimited()).To(BeTrue())
+		sampler.SetApplicationLimited(false, inFlight)
+		// packet 2 was sent while application-limited, and is still in flight
+		Expect(sampler.IsAppLimited()).To(BeTrue())
+		send(3)
+		now = now.Add(rtt)
+		sample, ok := ack(2)
+		Expect(ok).To(BeTrue())
+		Expect(sample.IsAppLimited).To(BeTrue())
+		sample, ok = ack(3)
+		Expect(ok).To(BeTrue())
+		Expect(sample.IsAppLimited).To(BeTrue())
+		Expect(sampler.IsAppLimited()).To(BeFalse())
+		send(4)
+		now = now.Add(rtt)
+		sample, ok = ack(4)
+		Expect(ok).To(BeTrue())
+		Expect(sample.IsAppLimited).To(BeFalse())
+	})
+
+	It("ignores unknown packets", func() {
+		_, ok := sampler.OnPacketAcked(42, now)
+		Expect(ok).To(BeFalse())
+		Expect(sampler.Delivered()).To(BeZero())
+	})
+})
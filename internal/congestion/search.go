@@ -0,0 +1,103 @@
+package congestion
+
+import (
+	"math"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+)
+
+// The constants of SEARCH, see draft-chung-ccwg-search.
+const (
+	// The size of the window over which the delivered bytes are compared, in multiples of the initial RTT.
+	searchWindowSizeFactor = 3.5
+	// Number of bins in a window (W).
+	searchBins = 10
+	// Number of bins kept in addition to the window, to compare windows that are one RTT apart.
+	searchExtraBins = 15
+	searchNumBins   = searchBins + searchExtraBins
+	// Slow start is exited when the delivered bytes fall short of the expected delivery by this fraction.
+	searchThresh = 0.35
+)
+
+// Search implements the SEARCH slow start exit algorithm.
+// In slow start, the number of bytes delivered in a window should double every RTT.
+// SEARCH compares the bytes delivered in the current window to twice the bytes delivered
+// in the window one initial RTT earlier, and exits slow start when the difference gets too large.
+// Unlike HyStart, it doesn't rely on RTT increases, which makes it suitable for high-latency paths.
+type Search struct {
+	initialRTT time.Duration
+	// A value of 0 means that SEARCH was not initialized yet.
+	binDuration time.Duration
+	binEnd      time.Time
+	currIdx     int
+	// the total delivered bytes at the end of each bin
+	bins      [searchNumBins]protocol.ByteCount
+	delivered protocol.ByteCount
+
+	exit bool
+}
+
+func (s *Search) init(now time.Time, rtt time.Duration) {
+	s.initialRTT = rtt
+	s.binDuration = time.Duration(float64(rtt) * searchWindowSizeFactor / searchBins)
+	s.resetBins(now)
+}
+
+func (s *Search) resetBins(now time.Time) {
+	s.binEnd = now.Add(s.binDuration)
+	s.currIdx = 0
+	s.bins = [searchNumBins]protocol.ByteCount{}
+	s.delivered = 0
+}
+
+// OnPacketAcked is called when a packet was acknowledged.
+// The first RTT sample is used as the initial RTT.
+func (s *Search) OnPacketAcked(ackedBytes protocol.ByteCount, now time.Time, rtt time.Duration) {
+	if s.binDuration == 0 {
+		if rtt == 0 {
+			return
+		}
+		s.init(now, rtt)
+		return
+	}
+	s.delivered += ackedBytes
+	if !now.After(s.binEnd) {
+		return
+	}
+	passedBins := int(now.Sub(s.binEnd)/s.binDuration) + 1
+	if passedBins > searchBins {
+		// No packets were acknowledged for longer than a window.
+		s.resetBins(now)
+		return
+	}
+	for i := 1; i <= passedBins; i++ {
+		s.bins[(s.currIdx+i)%searchNumBins] = s.delivered
+	}
+	s.currIdx += passedBins
+	s.binEnd = s.binEnd.Add(time.Duration(passedBins) * s.binDuration)
+
+	prevIdx := s.currIdx - int(math.Round(float64(s.initialRTT)/float64(s.binDuration)))
+	if prevIdx < searchBins {
+		return
+	}
+	currDelivered := s.bins[s.currIdx%searchNumBins] - s.bins[(s.currIdx-searchBins)%searchNumBins]
+	prevDelivered := s.bins[prevIdx%searchNumBins] - s.bins[(prevIdx-searchBins)%searchNumBins]
+	if prevDelivered == 0 {
+		return
+	}
+	expected := 2 * float64(prevDelivered)
+	if (expected-float64(currDelivered))/expected >= searchThresh {
+		s.exit = true
+	}
+}
+
+// ShouldExitSlowStart says if the delivered bytes fell short of the expected delivery.
+func (s *Search) ShouldExitSlowStart() bool {
+	return s.exit
+}
+
+// Restart the slow start phase
+func (s *Search) Restart() {
+	*s = Search{}
+}
@@ -0,0 +1,83 @@
+package congestion
+
+import (
+	"math"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SEARCH", func() {
+	const rtt = 100 * time.Millisecond
+
+	var (
+		search Search
+		now    time.Time
+	)
+
+	BeforeEach(func() {
+		search = Search{}
+		now = time.Now()
+		search.OnPacketAcked(1000, now, rtt)
+	})
+
+	// deliver acknowledges packets every millisecond for the given duration.
+	// It returns true as soon as SEARCH says that slow start should be exited.
+	deliver := func(d time.Duration, bytesPerMs func(elapsed time.Duration) protocol.ByteCount) bool {
+		start := now
+		for now.Sub(start) < d {
+			now = now.Add(time.Millisecond)
+			search.OnPacketAcked(bytesPerMs(now.Sub(start)), now, rtt)
+			if search.ShouldExitSlowStart() {
+				return true
+			}
+		}
+		return false
+	}
+
+	doubling := func(elapsed time.Duration) protocol.ByteCount {
+		return protocol.ByteCount(1000 * math.Pow(2, float64(elapsed)/float64(rtt)))
+	}
+
+	It("uses the first RTT sample as the initial RTT", func() {
+		s := Search{}
+		s.OnPacketAcked(1000, now, 0)
+		Expect(s.binDuration).To(BeZero())
+		s.OnPacketAcked(1000, now, 200*time.Millisecond)
+		Expect(s.binDuration).To(Equal(70 * time.Millisecond))
+		s.OnPacketAcked(1000, now, 100*time.Millisecond)
+		Expect(s.initialRTT).To(Equal(200 * time.Millisecond))
+	})
+
+	It("doesn't exit slow start while the delivery rate doubles every RTT", func() {
+		Expect(deliver(1500*time.Millisecond, doubling)).To(BeFalse())
+	})
+
+	It("exits slow start when the delivery rate stops growing", func() {
+		Expect(deliver(600*time.Millisecond, doubling)).To(BeFalse())
+		rate := doubling(600 * time.Millisecond)
+		Expect(deliver(time.Second, func(time.Duration) protocol.ByteCount { return rate })).To(BeTrue())
+	})
+
+	It("doesn't compare windows before enough bins were filled", func() {
+		Expect(deliver(12*search.binDuration, func(time.Duration) protocol.ByteCount { return 1000 })).To(BeFalse())
+		Expect(deliver(2*search.binDuration, func(time.Duration) protocol.ByteCount { return 1000 })).To(BeTrue())
+	})
+
+	It("resets the bins when no packets are acknowledged for longer than a window", func() {
+		Expect(deliver(600*time.Millisecond, doubling)).To(BeFalse())
+		now = now.Add(time.Second)
+		search.OnPacketAcked(1000, now, rtt)
+		Expect(search.currIdx).To(BeZero())
+		Expect(search.ShouldExitSlowStart()).To(BeFalse())
+	})
+
+	It("resets the state when restarting", func() {
+		Expect(deliver(time.Second, func(time.Duration) protocol.ByteCount { return 1000 })).To(BeTrue())
+		search.Restart()
+		Expect(search.ShouldExitSlowStart()).To(BeFalse())
+		Expect(search.binDuration).To(BeZero())
+	})
+})
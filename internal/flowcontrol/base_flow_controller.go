@@ -6,6 +6,7 @@ import (
 
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/logging"
 )
 
 type baseFlowController struct {
@@ -13,6 +14,7 @@ type baseFlowController struct {
 	bytesSent     protocol.ByteCount
 	sendWindow    protocol.ByteCount
 	lastBlockedAt protocol.ByteCount
+	blockedSince  time.Time // only set if the connection is traced
 
 	// for receiving data
 	//nolint:structcheck // The mutex is used both by the stream and the connection flow controller
@@ -27,7 +29,9 @@ type baseFlowController struct {
 	epochStartOffset protocol.ByteCount
 	rttStats         *utils.RTTStats
 
-	logger utils.Logger
+	streamID protocol.StreamID        // InvalidStreamID for the connection flow controller
+	tracer   logging.ConnectionTracer // may be nil
+	logger   utils.Logger
 }
 
 // IsNewlyBlocked says if it is newly blocked by flow control.
@@ -38,6 +42,10 @@ func (c *baseFlowController) IsNewlyBlocked() (bool, protocol.ByteCount) {
 		return false, 0
 	}
 	c.lastBlockedAt = c.sendWindow
+	if c.tracer != nil {
+		c.blockedSince = time.Now()
+		c.tracer.BlockedByFlowControl(c.streamID, c.sendWindow)
+	}
 	return true, c.sendWindow
 }
 
@@ -47,8 +55,17 @@ func (c *baseFlowController) AddBytesSent(n protocol.ByteCount) {
 
 // UpdateSendWindow is be called after receiving a MAX_{STREAM_}DATA frame.
 func (c *baseFlowController) UpdateSendWindow(offset protocol.ByteCount) {
-	if offset > c.sendWindow {
-		c.sendWindow = offset
+	if offset <= c.sendWindow {
+		return
+	}
+	c.sendWindow = offset
+	if c.tracer != nil {
+		var blockedFor time.Duration
+		if !c.blockedSince.IsZero() {
+			blockedFor = time.Since(c.blockedSince)
+			c.blockedSince = time.Time{}
+		}
+		c.tracer.ReceivedFlowControlUpdate(c.streamID, offset, blockedFor)
 	}
 }
 
@@ -85,6 +102,9 @@ func (c *baseFlowController) getWindowUpdate() protocol.ByteCount {
 
 	c.maybeAdjustWindowSize()
 	c.receiveWindow = c.bytesRead + c.receiveWindowSize
+	if c.tracer != nil {
+		c.tracer.SentFlowControlUpdate(c.streamID, c.receiveWindow, c.receiveWindowSize)
+	}
 	return c.receiveWindow
 }
 
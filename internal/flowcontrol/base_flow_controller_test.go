@@ -5,8 +5,10 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/golang/mock/gomock"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 
+	mocklogging "github.com/lucas-clemente/quic-go/internal/mocks/logging"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -86,6 +88,27 @@ var _ = Describe("Base Flow controller", func() {
 			newlyBlocked, _ = controller.IsNewlyBlocked()
 			Expect(newlyBlocked).To(BeTrue())
 		})
+
+		It("traces when it's blocked, and for how long", func() {
+			tracer := mocklogging.NewMockConnectionTracer(mockCtrl)
+			controller.tracer = tracer
+			controller.streamID = 5
+			tracer.EXPECT().ReceivedFlowControlUpdate(protocol.StreamID(5), protocol.ByteCount(100), time.Duration(0))
+			controller.UpdateSendWindow(100)
+			controller.AddBytesSent(100)
+			tracer.EXPECT().BlockedByFlowControl(protocol.StreamID(5), protocol.ByteCount(100))
+			blocked, _ := controller.IsNewlyBlocked()
+			Expect(blocked).To(BeTrue())
+			time.Sleep(scaleDuration(10 * time.Millisecond))
+			// doesn't trace updates that don't increase the limit
+			controller.UpdateSendWindow(50)
+			tracer.EXPECT().ReceivedFlowControlUpdate(protocol.StreamID(5), protocol.ByteCount(150), gomock.Any()).Do(func(_ protocol.StreamID, _ protocol.ByteCount, blockedFor time.Duration) {
+				Expect(blockedFor).To(BeNumerically(">=", 10*time.Millisecond))
+			})
+			controller.UpdateSendWindow(150)
+			tracer.EXPECT().ReceivedFlowControlUpdate(protocol.StreamID(5), protocol.ByteCount(200), time.Duration(0))
+			controller.UpdateSendWindow(200)
+		})
 	})
 
 	Context("receive flow control", func() {
@@ -116,6 +139,15 @@ var _ = Describe("Base Flow controller", func() {
 			Expect(controller.receiveWindow).To(Equal(readPosition + receiveWindowSize))
 		})
 
+		It("traces window updates", func() {
+			tracer := mocklogging.NewMockConnectionTracer(mockCtrl)
+			controller.tracer = tracer
+			controller.streamID = protocol.InvalidStreamID
+			controller.bytesRead = receiveWindow
+			tracer.EXPECT().SentFlowControlUpdate(protocol.InvalidStreamID, receiveWindow+receiveWindowSize, receiveWindowSize)
+			Expect(controller.getWindowUpdate()).To(Equal(receiveWindow + receiveWindowSize))
+		})
+
 		It("doesn't trigger a window update when not necessary", func() {
 			bytesConsumed := float64(receiveWindowSize)*protocol.WindowUpdateThreshold - 1 // consumed 1 byte less than the threshold
 			bytesRemaining := receiveWindowSize - protocol.ByteCount(bytesConsumed)
@@ -8,6 +8,7 @@ import (
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/qerr"
 	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/logging"
 )
 
 type connectionFlowController struct {
@@ -25,6 +26,7 @@ func NewConnectionFlowController(
 	maxReceiveWindow protocol.ByteCount,
 	queueWindowUpdate func(),
 	rttStats *utils.RTTStats,
+	tracer logging.ConnectionTracer,
 	logger utils.Logger,
 ) ConnectionFlowController {
 	return &connectionFlowController{
@@ -33,6 +35,8 @@ func NewConnectionFlowController(
 			receiveWindow:        receiveWindow,
 			receiveWindowSize:    receiveWindow,
 			maxReceiveWindowSize: maxReceiveWindow,
+			streamID:             protocol.InvalidStreamID,
+			tracer:               tracer,
 			logger:               logger,
 		},
 		queueWindowUpdate: queueWindowUpdate,
@@ -58,6 +62,13 @@ func (c *connectionFlowController) IncrementHighestReceived(increment protocol.B
 	return nil
 }
 
+func (c *connectionFlowController) BytesReceived() protocol.ByteCount {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.highestReceived
+}
+
 func (c *connectionFlowController) AddBytesRead(n protocol.ByteCount) {
 	c.mutex.Lock()
 	c.baseFlowController.addBytesRead(n)
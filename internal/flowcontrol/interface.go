@@ -29,6 +29,9 @@ type StreamFlowController interface {
 // The ConnectionFlowController is the flow controller for the connection.
 type ConnectionFlowController interface {
 	flowController
+	// BytesReceived returns the number of bytes received on all streams.
+	// For every stream, it counts the bytes up to the highest offset received.
+	BytesReceived() protocol.ByteCount
 	Reset() error
 }
 
@@ -6,13 +6,12 @@ import (
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/qerr"
 	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/logging"
 )
 
 type streamFlowController struct {
 	baseFlowController
 
-	streamID protocol.StreamID
-
 	queueWindowUpdate func()
 
 	connection connectionFlowControllerI
@@ -31,10 +30,10 @@ func NewStreamFlowController(
 	initialSendWindow protocol.ByteCount,
 	queueWindowUpdate func(protocol.StreamID),
 	rttStats *utils.RTTStats,
+	tracer logging.ConnectionTracer,
 	logger utils.Logger,
 ) StreamFlowController {
 	return &streamFlowController{
-		streamID:          streamID,
 		connection:        cfc.(connectionFlowControllerI),
 		queueWindowUpdate: func() { queueWindowUpdate(streamID) },
 		baseFlowController: baseFlowController{
@@ -43,6 +42,8 @@ func NewStreamFlowController(
 			receiveWindowSize:    receiveWindow,
 			maxReceiveWindowSize: maxReceiveWindow,
 			sendWindow:           initialSendWindow,
+			streamID:             streamID,
+			tracer:               tracer,
 			logger:               logger,
 		},
 	}
@@ -0,0 +1,88 @@
+package handshake
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+// TLS extensions, see section 4.2 of RFC 8446.
+const (
+	extensionServerName    uint16 = 0
+	extensionPreSharedKey  uint16 = 41
+	serverNameTypeHostName uint8  = 0
+)
+
+// The length of the key name at the beginning of a session ticket issued by crypto/tls.
+const ticketKeyNameLen = 16
+
+var errInvalidClientHello = errors.New("invalid ClientHello")
+
+// clientHelloExtension returns the data of the extension of type extType of a ClientHello.
+func clientHelloExtension(data []byte, extType uint16) (ext cryptobyte.String, found bool, err error) {
+	s := cryptobyte.String(data)
+	var msgType uint8
+	var body, sessionID, cipherSuites, compressionMethods, extensions cryptobyte.String
+	if !s.ReadUint8(&msgType) || messageType(msgType) != typeClientHello ||
+		!s.ReadUint24LengthPrefixed(&body) ||
+		!body.Skip(2+32) || // legacy_version and random
+		!body.ReadUint8LengthPrefixed(&sessionID) ||
+		!body.ReadUint16LengthPrefixed(&cipherSuites) ||
+		!body.ReadUint8LengthPrefixed(&compressionMethods) ||
+		!body.ReadUint16LengthPrefixed(&extensions) {
+		return nil, false, errInvalidClientHello
+	}
+	for !extensions.Empty() {
+		var typ uint16
+		var extData cryptobyte.String
+		if !extensions.ReadUint16(&typ) || !extensions.ReadUint16LengthPrefixed(&extData) {
+			return nil, false, errInvalidClientHello
+		}
+		if typ == extType {
+			return extData, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// ParseServerName parses a ClientHello, and returns the server name sent in the server_name extension (RFC 6066).
+// If the client didn't send a server name, it returns an empty string.
+func ParseServerName(clientHello []byte) (string, error) {
+	ext, found, err := clientHelloExtension(clientHello, extensionServerName)
+	if err != nil || !found {
+		return "", err
+	}
+	var serverNames cryptobyte.String
+	if !ext.ReadUint16LengthPrefixed(&serverNames) {
+		return "", errInvalidClientHello
+	}
+	for !serverNames.Empty() {
+		var nameType uint8
+		var name cryptobyte.String
+		if !serverNames.ReadUint8(&nameType) || !serverNames.ReadUint16LengthPrefixed(&name) {
+			return "", errInvalidClientHello
+		}
+		if nameType == serverNameTypeHostName {
+			return string(name), nil
+		}
+	}
+	return "", nil
+}
+
+// parseSessionTicketKeyName parses a ClientHello, and returns the name of the key that was
+// used to encrypt the first session ticket offered in the pre_shared_key extension.
+func parseSessionTicketKeyName(data []byte) ([ticketKeyNameLen]byte, bool) {
+	var name [ticketKeyNameLen]byte
+	ext, found, err := clientHelloExtension(data, extensionPreSharedKey)
+	if err != nil || !found {
+		return name, false
+	}
+	var identities, identity cryptobyte.String
+	if !ext.ReadUint16LengthPrefixed(&identities) ||
+		!identities.ReadUint16LengthPrefixed(&identity) ||
+		len(identity) < ticketKeyNameLen {
+		return name, false
+	}
+	copy(name[:], identity)
+	return name, true
+}
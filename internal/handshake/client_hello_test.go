@@ -0,0 +1,104 @@
+package handshake
+
+import (
+	"golang.org/x/crypto/cryptobyte"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ClientHello parsing", func() {
+	getClientHello := func(serverName string, identities ...[]byte) []byte {
+		b := cryptobyte.NewBuilder(nil)
+		b.AddUint8(uint8(typeClientHello))
+		b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddUint16(0x0303)
+			b.AddBytes(make([]byte, 32))
+			b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {})
+			b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) { b.AddUint16(0x1301) })
+			b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) { b.AddUint8(0) })
+			b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+				// supported_versions
+				b.AddUint16(43)
+				b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+					b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) { b.AddUint16(0x0304) })
+				})
+				if serverName != "" {
+					b.AddUint16(extensionServerName)
+					b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+						b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+							b.AddUint8(serverNameTypeHostName)
+							b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes([]byte(serverName)) })
+						})
+					})
+				}
+				if len(identities) == 0 {
+					return
+				}
+				b.AddUint16(extensionPreSharedKey)
+				b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+					b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+						for _, identity := range identities {
+							b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(identity) })
+							b.AddUint32(1337) // obfuscated_ticket_age
+						}
+					})
+					b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+						b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(make([]byte, 32)) })
+					})
+				})
+			})
+		})
+		return b.BytesOrPanic()
+	}
+
+	It("parses the key name of the session ticket", func() {
+		ticket := append([]byte("0123456789abcdef"), []byte("encrypted session state")...)
+		name, ok := parseSessionTicketKeyName(getClientHello("", ticket, []byte("another ticket foobar")))
+		Expect(ok).To(BeTrue())
+		Expect(name[:]).To(Equal([]byte("0123456789abcdef")))
+	})
+
+	It("doesn't return a key name if no session ticket is offered", func() {
+		_, ok := parseSessionTicketKeyName(getClientHello(""))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("doesn't return a key name if the session ticket is too short", func() {
+		_, ok := parseSessionTicketKeyName(getClientHello("", []byte("foobar")))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("errors on invalid ClientHellos", func() {
+		data := getClientHello("example.com", append([]byte("0123456789abcdef"), []byte("encrypted session state")...))
+		for i := range data {
+			_, ok := parseSessionTicketKeyName(data[:i])
+			Expect(ok).To(BeFalse())
+		}
+		_, ok := parseSessionTicketKeyName(append([]byte{uint8(typeServerHello)}, data[1:]...))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("parses the server name", func() {
+		serverName, err := ParseServerName(getClientHello("example.com", []byte("0123456789abcdef foobar")))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(serverName).To(Equal("example.com"))
+		name, ok := parseSessionTicketKeyName(getClientHello("example.com", []byte("0123456789abcdef foobar")))
+		Expect(ok).To(BeTrue())
+		Expect(name[:]).To(Equal([]byte("0123456789abcdef")))
+	})
+
+	It("returns an empty server name if the client didn't send one", func() {
+		serverName, err := ParseServerName(getClientHello(""))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(serverName).To(BeEmpty())
+	})
+
+	It("errors when parsing the server name of an invalid ClientHello", func() {
+		data := getClientHello("example.com")
+		for i := range data {
+			_, err := ParseServerName(data[:i])
+			Expect(err).To(HaveOccurred())
+		}
+	})
+})
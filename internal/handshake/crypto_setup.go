@@ -122,6 +122,10 @@ type cryptoSetup struct {
 
 	perspective protocol.Perspective
 
+	// the key name of the session ticket offered by the client, only set for the server
+	ticketKeyName    [ticketKeyNameLen]byte
+	hasTicketKeyName bool
+
 	mutex sync.Mutex // protects all members below
 
 	handshakeCompleteTime time.Time
@@ -347,6 +351,9 @@ func (h *cryptoSetup) HandleMessage(data []byte, encLevel protocol.EncryptionLev
 		h.onError(alertUnexpectedMessage, err.Error())
 		return false
 	}
+	if msgType == typeClientHello && h.perspective == protocol.PerspectiveServer {
+		h.ticketKeyName, h.hasTicketKeyName = parseSessionTicketKeyName(data)
+	}
 	h.messageChan <- data
 	if encLevel == protocol.Encryption1RTT {
 		h.handlePostHandshakeMessage()
@@ -452,6 +459,12 @@ func (h *cryptoSetup) handleDataFromSessionStateImpl(data []byte) (*wire.Transpo
 }
 
 // only valid for the server
+// SessionTicketKeyName returns the name of the key that encrypted the session ticket offered by the client.
+// It is only available for the server, and doesn't say if the session ticket was accepted.
+func (h *cryptoSetup) SessionTicketKeyName() ([16]byte, bool) {
+	return h.ticketKeyName, h.hasTicketKeyName
+}
+
 func (h *cryptoSetup) GetSessionTicket() ([]byte, error) {
 	var appData []byte
 	// Save transport parameters to the session ticket if we're allowing 0-RTT.
@@ -470,14 +483,23 @@ func (h *cryptoSetup) accept0RTT(sessionTicketData []byte) bool {
 	var t sessionTicket
 	if err := t.Unmarshal(sessionTicketData); err != nil {
 		h.logger.Debugf("Unmarshalling transport parameters from session ticket failed: %s", err.Error())
+		if h.tracer != nil {
+			h.tracer.Rejected0RTT(logging.ZeroRTTRejectedInvalidTicket)
+		}
 		return false
 	}
 	valid := h.ourParams.ValidFor0RTT(t.Parameters)
 	if valid {
 		h.logger.Debugf("Accepting 0-RTT. Restoring RTT from session ticket: %s", t.RTT)
 		h.rttStats.SetInitialRTT(t.RTT)
+		if h.tracer != nil {
+			h.tracer.Accepted0RTT()
+		}
 	} else {
 		h.logger.Debugf("Transport parameters changed. Rejecting 0-RTT.")
+		if h.tracer != nil {
+			h.tracer.Rejected0RTT(logging.ZeroRTTRejectedTransportParameters)
+		}
 	}
 	return valid
 }
@@ -492,6 +514,9 @@ func (h *cryptoSetup) rejected0RTT() {
 	h.mutex.Unlock()
 
 	if had0RTTKeys {
+		if h.tracer != nil {
+			h.tracer.Rejected0RTT(logging.ZeroRTTRejectedByServer)
+		}
 		h.runner.DropKeys(protocol.Encryption0RTT)
 	}
 }
@@ -618,9 +643,11 @@ func (h *cryptoSetup) SetWriteKey(encLevel qtls.EncryptionLevel, suite *qtls.Cip
 		h.has1RTTSealer = true
 		h.logger.Debugf("Installed 1-RTT Write keys (using %s)", tls.CipherSuiteName(suite.ID))
 		if h.zeroRTTSealer != nil {
+			// The 0-RTT keys would have been dropped if the server had rejected 0-RTT.
 			h.zeroRTTSealer = nil
 			h.logger.Debugf("Dropping 0-RTT keys.")
 			if h.tracer != nil {
+				h.tracer.Accepted0RTT()
 				h.tracer.DroppedEncryptionLevel(protocol.Encryption0RTT)
 			}
 		}
@@ -4,18 +4,21 @@ import (
 	"bytes"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha512"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"math/big"
 	"time"
 
+	mocklogging "github.com/lucas-clemente/quic-go/internal/mocks/logging"
 	mocktls "github.com/lucas-clemente/quic-go/internal/mocks/tls"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/qerr"
 	"github.com/lucas-clemente/quic-go/internal/testdata"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/lucas-clemente/quic-go/internal/wire"
+	"github.com/lucas-clemente/quic-go/logging"
 
 	"github.com/golang/mock/gomock"
 
@@ -60,6 +63,8 @@ func (s *stream) Write(b []byte) (int, error) {
 
 var _ = Describe("Crypto Setup TLS", func() {
 	var clientConf, serverConf *tls.Config
+	// the tracers used by handshakeWithTLSConf
+	var clientTracer, serverTracer logging.ConnectionTracer
 
 	// unparam incorrectly complains that the first argument is never used.
 	//nolint:unparam
@@ -71,6 +76,8 @@ var _ = Describe("Crypto Setup TLS", func() {
 	}
 
 	BeforeEach(func() {
+		clientTracer = nil
+		serverTracer = nil
 		serverConf = testdata.GetTLSConfig()
 		serverConf.NextProtos = []string{"crypto-setup"}
 		clientConf = &tls.Config{
@@ -334,7 +341,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 				clientConf,
 				enable0RTT,
 				clientRTTStats,
-				nil,
+				clientTracer,
 				utils.DefaultLogger.WithPrefix("client"),
 				protocol.VersionTLS,
 			)
@@ -361,7 +368,7 @@ var _ = Describe("Crypto Setup TLS", func() {
 				serverConf,
 				enable0RTT,
 				serverRTTStats,
-				nil,
+				serverTracer,
 				utils.DefaultLogger.WithPrefix("server"),
 				protocol.VersionTLS,
 			)
@@ -678,6 +685,48 @@ var _ = Describe("Crypto Setup TLS", func() {
 				Expect(clientHelloWrittenChan).To(Receive(BeNil()))
 			})
 
+			It("reports the name of the key that encrypted the session ticket", func() {
+				csc := mocktls.NewMockClientSessionCache(mockCtrl)
+				var state *tls.ClientSessionState
+				receivedSessionTicket := make(chan struct{})
+				csc.EXPECT().Get(gomock.Any())
+				csc.EXPECT().Put(gomock.Any(), gomock.Any()).Do(func(_ string, css *tls.ClientSessionState) {
+					state = css
+					close(receivedSessionTicket)
+				})
+				clientConf.ClientSessionCache = csc
+				serverConf = serverConf.Clone()
+				key := [32]byte{1, 2, 3, 4}
+				serverConf.SessionTicketKey = key
+				_, _, clientErr, server, serverErr := handshakeWithTLSConf(
+					clientConf, serverConf,
+					&utils.RTTStats{}, &utils.RTTStats{},
+					&wire.TransportParameters{}, &wire.TransportParameters{},
+					false,
+				)
+				Expect(clientErr).ToNot(HaveOccurred())
+				Expect(serverErr).ToNot(HaveOccurred())
+				Eventually(receivedSessionTicket).Should(BeClosed())
+				_, ok := server.SessionTicketKeyName()
+				Expect(ok).To(BeFalse())
+
+				csc.EXPECT().Get(gomock.Any()).Return(state, true)
+				csc.EXPECT().Put(gomock.Any(), gomock.Any()).MaxTimes(1)
+				_, _, clientErr, server, serverErr = handshakeWithTLSConf(
+					clientConf, serverConf,
+					&utils.RTTStats{}, &utils.RTTStats{},
+					&wire.TransportParameters{}, &wire.TransportParameters{},
+					false,
+				)
+				Expect(clientErr).ToNot(HaveOccurred())
+				Expect(serverErr).ToNot(HaveOccurred())
+				Expect(server.ConnectionState().DidResume).To(BeTrue())
+				name, ok := server.SessionTicketKeyName()
+				Expect(ok).To(BeTrue())
+				hash := sha512.Sum512(key[:])
+				Expect(name[:]).To(Equal(hash[:16]))
+			})
+
 			It("doesn't use session resumption if the server disabled it", func() {
 				csc := mocktls.NewMockClientSessionCache(mockCtrl)
 				var state *tls.ClientSessionState
@@ -747,6 +796,15 @@ var _ = Describe("Crypto Setup TLS", func() {
 				csc.EXPECT().Put(gomock.Any(), nil)
 				csc.EXPECT().Put(gomock.Any(), gomock.Any()).MaxTimes(1)
 
+				cTracer := mocklogging.NewMockConnectionTracer(mockCtrl)
+				cTracer.EXPECT().UpdatedKeyFromTLS(gomock.Any(), gomock.Any()).AnyTimes()
+				cTracer.EXPECT().DroppedEncryptionLevel(protocol.Encryption0RTT)
+				cTracer.EXPECT().Accepted0RTT()
+				sTracer := mocklogging.NewMockConnectionTracer(mockCtrl)
+				sTracer.EXPECT().UpdatedKeyFromTLS(gomock.Any(), gomock.Any()).AnyTimes()
+				sTracer.EXPECT().Accepted0RTT()
+				clientTracer = cTracer
+				serverTracer = sTracer
 				clientRTTStats := &utils.RTTStats{}
 				serverRTTStats := &utils.RTTStats{}
 				clientHelloWrittenChan, client, clientErr, server, serverErr = handshakeWithTLSConf(
@@ -800,6 +858,15 @@ var _ = Describe("Crypto Setup TLS", func() {
 				csc.EXPECT().Put(gomock.Any(), nil)
 				csc.EXPECT().Put(gomock.Any(), gomock.Any()).MaxTimes(1)
 
+				cTracer := mocklogging.NewMockConnectionTracer(mockCtrl)
+				cTracer.EXPECT().UpdatedKeyFromTLS(gomock.Any(), gomock.Any()).AnyTimes()
+				cTracer.EXPECT().DroppedEncryptionLevel(gomock.Any()).AnyTimes()
+				cTracer.EXPECT().Rejected0RTT(logging.ZeroRTTRejectedByServer)
+				sTracer := mocklogging.NewMockConnectionTracer(mockCtrl)
+				sTracer.EXPECT().UpdatedKeyFromTLS(gomock.Any(), gomock.Any()).AnyTimes()
+				sTracer.EXPECT().Rejected0RTT(logging.ZeroRTTRejectedTransportParameters)
+				clientTracer = cTracer
+				serverTracer = sTracer
 				clientRTTStats := &utils.RTTStats{}
 				clientHelloWrittenChan, client, clientErr, server, serverErr = handshakeWithTLSConf(
 					clientConf, serverConf,
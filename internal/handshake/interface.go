@@ -77,6 +77,7 @@ type CryptoSetup interface {
 	io.Closer
 	ChangeConnectionID(protocol.ConnectionID)
 	GetSessionTicket() ([]byte, error)
+	SessionTicketKeyName() ([16]byte, bool)
 
 	HandleMessage([]byte, protocol.EncryptionLevel) bool
 	SetLargest1RTTAcked(protocol.PacketNumber) error
@@ -23,6 +23,8 @@ type Token struct {
 	// only set for retry tokens
 	OriginalDestConnectionID protocol.ConnectionID
 	RetrySrcConnectionID     protocol.ConnectionID
+	// only set for tokens sent in a NEW_TOKEN frame, if the server identifies returning clients
+	ClientID []byte
 }
 
 // token is the struct that is used for ASN1 serialization and deserialization
@@ -32,6 +34,7 @@ type token struct {
 	Timestamp                int64
 	OriginalDestConnectionID []byte
 	RetrySrcConnectionID     []byte
+	ClientID                 []byte `asn1:"optional,omitempty"`
 }
 
 // A TokenGenerator generates tokens
@@ -69,11 +72,13 @@ func (g *TokenGenerator) NewRetryToken(
 	return g.tokenProtector.NewToken(data)
 }
 
-// NewToken generates a new token to be sent in a NEW_TOKEN frame
-func (g *TokenGenerator) NewToken(raddr net.Addr) ([]byte, error) {
+// NewToken generates a new token to be sent in a NEW_TOKEN frame.
+// The clientID identifies the client when it returns with this token. It may be nil.
+func (g *TokenGenerator) NewToken(raddr net.Addr, clientID []byte) ([]byte, error) {
 	data, err := asn1.Marshal(token{
 		RemoteAddr: encodeRemoteAddr(raddr),
 		Timestamp:  time.Now().UnixNano(),
+		ClientID:   clientID,
 	})
 	if err != nil {
 		return nil, err
@@ -108,6 +113,8 @@ func (g *TokenGenerator) DecodeToken(encrypted []byte) (*Token, error) {
 	if t.IsRetryToken {
 		token.OriginalDestConnectionID = protocol.ConnectionID(t.OriginalDestConnectionID)
 		token.RetrySrcConnectionID = protocol.ConnectionID(t.RetrySrcConnectionID)
+	} else if len(t.ClientID) > 0 {
+		token.ClientID = t.ClientID
 	}
 	return token, nil
 }
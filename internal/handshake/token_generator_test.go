@@ -63,6 +63,22 @@ var _ = Describe("Token Generator", func() {
 		Expect(token.RetrySrcConnectionID).To(Equal(protocol.ConnectionID{0xde, 0xad, 0xc0, 0xde}))
 	})
 
+	It("saves the client ID", func() {
+		tokenEnc, err := tokenGen.NewToken(&net.UDPAddr{IP: net.IPv4(192, 168, 0, 1)}, []byte{0xca, 0xfe})
+		Expect(err).ToNot(HaveOccurred())
+		token, err := tokenGen.DecodeToken(tokenEnc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(token.IsRetryToken).To(BeFalse())
+		Expect(token.RemoteAddr).To(Equal("192.168.0.1"))
+		Expect(token.ClientID).To(Equal([]byte{0xca, 0xfe}))
+		// the client ID is optional
+		tokenEnc, err = tokenGen.NewToken(&net.UDPAddr{IP: net.IPv4(192, 168, 0, 1)}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		token, err = tokenGen.DecodeToken(tokenEnc)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(token.ClientID).To(BeNil())
+	})
+
 	It("rejects invalid tokens", func() {
 		_, err := tokenGen.DecodeToken([]byte("invalid token"))
 		Expect(err).To(HaveOccurred())
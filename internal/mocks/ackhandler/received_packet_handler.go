@@ -36,6 +36,18 @@ func (m *MockReceivedPacketHandler) EXPECT() *MockReceivedPacketHandlerMockRecor
 	return m.recorder
 }
 
+// Compact mocks base method.
+func (m *MockReceivedPacketHandler) Compact() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Compact")
+}
+
+// Compact indicates an expected call of Compact.
+func (mr *MockReceivedPacketHandlerMockRecorder) Compact() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Compact", reflect.TypeOf((*MockReceivedPacketHandler)(nil).Compact))
+}
+
 // DropPackets mocks base method.
 func (m *MockReceivedPacketHandler) DropPackets(arg0 protocol.EncryptionLevel) {
 	m.ctrl.T.Helper()
@@ -37,6 +37,18 @@ func (m *MockSentPacketHandler) EXPECT() *MockSentPacketHandlerMockRecorder {
 	return m.recorder
 }
 
+// Compact mocks base method.
+func (m *MockSentPacketHandler) Compact() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Compact")
+}
+
+// Compact indicates an expected call of Compact.
+func (mr *MockSentPacketHandlerMockRecorder) Compact() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Compact", reflect.TypeOf((*MockSentPacketHandler)(nil).Compact))
+}
+
 // DropPackets mocks base method.
 func (m *MockSentPacketHandler) DropPackets(arg0 protocol.EncryptionLevel) {
 	m.ctrl.T.Helper()
@@ -49,6 +61,20 @@ func (mr *MockSentPacketHandlerMockRecorder) DropPackets(arg0 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DropPackets", reflect.TypeOf((*MockSentPacketHandler)(nil).DropPackets), arg0)
 }
 
+// GetCongestionWindow mocks base method.
+func (m *MockSentPacketHandler) GetCongestionWindow() protocol.ByteCount {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCongestionWindow")
+	ret0, _ := ret[0].(protocol.ByteCount)
+	return ret0
+}
+
+// GetCongestionWindow indicates an expected call of GetCongestionWindow.
+func (mr *MockSentPacketHandlerMockRecorder) GetCongestionWindow() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCongestionWindow", reflect.TypeOf((*MockSentPacketHandler)(nil).GetCongestionWindow))
+}
+
 // GetLossDetectionTimeout mocks base method.
 func (m *MockSentPacketHandler) GetLossDetectionTimeout() time.Time {
 	m.ctrl.T.Helper()
@@ -91,6 +117,18 @@ func (mr *MockSentPacketHandlerMockRecorder) OnLossDetectionTimeout() *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnLossDetectionTimeout", reflect.TypeOf((*MockSentPacketHandler)(nil).OnLossDetectionTimeout))
 }
 
+// OnSuspend mocks base method.
+func (m *MockSentPacketHandler) OnSuspend(arg0 time.Duration, arg1 bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnSuspend", arg0, arg1)
+}
+
+// OnSuspend indicates an expected call of OnSuspend.
+func (mr *MockSentPacketHandlerMockRecorder) OnSuspend(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnSuspend", reflect.TypeOf((*MockSentPacketHandler)(nil).OnSuspend), arg0, arg1)
+}
+
 // PeekPacketNumber mocks base method.
 func (m *MockSentPacketHandler) PeekPacketNumber(arg0 protocol.EncryptionLevel) (protocol.PacketNumber, protocol.PacketNumberLen) {
 	m.ctrl.T.Helper()
@@ -161,6 +199,18 @@ func (mr *MockSentPacketHandlerMockRecorder) ReceivedBytes(arg0 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReceivedBytes", reflect.TypeOf((*MockSentPacketHandler)(nil).ReceivedBytes), arg0)
 }
 
+// ReceivedDeliveryRate mocks base method.
+func (m *MockSentPacketHandler) ReceivedDeliveryRate(arg0 uint64, arg1 time.Duration, arg2 time.Time) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ReceivedDeliveryRate", arg0, arg1, arg2)
+}
+
+// ReceivedDeliveryRate indicates an expected call of ReceivedDeliveryRate.
+func (mr *MockSentPacketHandlerMockRecorder) ReceivedDeliveryRate(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReceivedDeliveryRate", reflect.TypeOf((*MockSentPacketHandler)(nil).ReceivedDeliveryRate), arg0, arg1, arg2)
+}
+
 // ResetForRetry mocks base method.
 func (m *MockSentPacketHandler) ResetForRetry() error {
 	m.ctrl.T.Helper()
@@ -201,6 +251,18 @@ func (mr *MockSentPacketHandlerMockRecorder) SentPacket(arg0 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SentPacket", reflect.TypeOf((*MockSentPacketHandler)(nil).SentPacket), arg0)
 }
 
+// SetApplicationLimited mocks base method.
+func (m *MockSentPacketHandler) SetApplicationLimited(arg0 bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetApplicationLimited", arg0)
+}
+
+// SetApplicationLimited indicates an expected call of SetApplicationLimited.
+func (mr *MockSentPacketHandlerMockRecorder) SetApplicationLimited(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetApplicationLimited", reflect.TypeOf((*MockSentPacketHandler)(nil).SetApplicationLimited), arg0)
+}
+
 // SetHandshakeConfirmed mocks base method.
 func (m *MockSentPacketHandler) SetHandshakeConfirmed() {
 	m.ctrl.T.Helper()
@@ -225,6 +287,32 @@ func (mr *MockSentPacketHandlerMockRecorder) SetMaxDatagramSize(arg0 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMaxDatagramSize", reflect.TypeOf((*MockSentPacketHandler)(nil).SetMaxDatagramSize), arg0)
 }
 
+// SetMaxSendRate mocks base method.
+func (m *MockSentPacketHandler) SetMaxSendRate(arg0 uint64) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetMaxSendRate", arg0)
+}
+
+// SetMaxSendRate indicates an expected call of SetMaxSendRate.
+func (mr *MockSentPacketHandlerMockRecorder) SetMaxSendRate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMaxSendRate", reflect.TypeOf((*MockSentPacketHandler)(nil).SetMaxSendRate), arg0)
+}
+
+// Stats mocks base method.
+func (m *MockSentPacketHandler) Stats() ackhandler.Stats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stats")
+	ret0, _ := ret[0].(ackhandler.Stats)
+	return ret0
+}
+
+// Stats indicates an expected call of Stats.
+func (mr *MockSentPacketHandlerMockRecorder) Stats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stats", reflect.TypeOf((*MockSentPacketHandler)(nil).Stats))
+}
+
 // TimeUntilSend mocks base method.
 func (m *MockSentPacketHandler) TimeUntilSend() time.Time {
 	m.ctrl.T.Helper()
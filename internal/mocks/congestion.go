@@ -9,6 +9,7 @@ import (
 	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	congestion "github.com/lucas-clemente/quic-go/internal/congestion"
 	protocol "github.com/lucas-clemente/quic-go/internal/protocol"
 )
 
@@ -63,6 +64,20 @@ func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) GetCongestionWindow() *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCongestionWindow", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).GetCongestionWindow))
 }
 
+// GetSlowStartThreshold mocks base method.
+func (m *MockSendAlgorithmWithDebugInfos) GetSlowStartThreshold() protocol.ByteCount {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSlowStartThreshold")
+	ret0, _ := ret[0].(protocol.ByteCount)
+	return ret0
+}
+
+// GetSlowStartThreshold indicates an expected call of GetSlowStartThreshold.
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) GetSlowStartThreshold() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSlowStartThreshold", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).GetSlowStartThreshold))
+}
+
 // HasPacingBudget mocks base method.
 func (m *MockSendAlgorithmWithDebugInfos) HasPacingBudget() bool {
 	m.ctrl.T.Helper()
@@ -105,6 +120,20 @@ func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) InSlowStart() *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InSlowStart", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).InSlowStart))
 }
 
+// MaxCongestionWindowUsed mocks base method.
+func (m *MockSendAlgorithmWithDebugInfos) MaxCongestionWindowUsed() protocol.ByteCount {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MaxCongestionWindowUsed")
+	ret0, _ := ret[0].(protocol.ByteCount)
+	return ret0
+}
+
+// MaxCongestionWindowUsed indicates an expected call of MaxCongestionWindowUsed.
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) MaxCongestionWindowUsed() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxCongestionWindowUsed", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).MaxCongestionWindowUsed))
+}
+
 // MaybeExitSlowStart mocks base method.
 func (m *MockSendAlgorithmWithDebugInfos) MaybeExitSlowStart() {
 	m.ctrl.T.Helper()
@@ -117,6 +146,30 @@ func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) MaybeExitSlowStart() *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaybeExitSlowStart", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).MaybeExitSlowStart))
 }
 
+// OnConnectionMigration mocks base method.
+func (m *MockSendAlgorithmWithDebugInfos) OnConnectionMigration() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnConnectionMigration")
+}
+
+// OnConnectionMigration indicates an expected call of OnConnectionMigration.
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) OnConnectionMigration() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnConnectionMigration", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).OnConnectionMigration))
+}
+
+// OnDeliveryRateReport mocks base method.
+func (m *MockSendAlgorithmWithDebugInfos) OnDeliveryRateReport(arg0 congestion.Bandwidth, arg1 time.Duration, arg2 time.Time) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnDeliveryRateReport", arg0, arg1, arg2)
+}
+
+// OnDeliveryRateReport indicates an expected call of OnDeliveryRateReport.
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) OnDeliveryRateReport(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnDeliveryRateReport", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).OnDeliveryRateReport), arg0, arg1, arg2)
+}
+
 // OnPacketAcked mocks base method.
 func (m *MockSendAlgorithmWithDebugInfos) OnPacketAcked(arg0 protocol.PacketNumber, arg1, arg2 protocol.ByteCount, arg3 time.Time) {
 	m.ctrl.T.Helper()
@@ -153,6 +206,18 @@ func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) OnPacketSent(arg0, arg1,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnPacketSent", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).OnPacketSent), arg0, arg1, arg2, arg3, arg4)
 }
 
+// OnPersistentCongestion mocks base method.
+func (m *MockSendAlgorithmWithDebugInfos) OnPersistentCongestion() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "OnPersistentCongestion")
+}
+
+// OnPersistentCongestion indicates an expected call of OnPersistentCongestion.
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) OnPersistentCongestion() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnPersistentCongestion", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).OnPersistentCongestion))
+}
+
 // OnRetransmissionTimeout mocks base method.
 func (m *MockSendAlgorithmWithDebugInfos) OnRetransmissionTimeout(arg0 bool) {
 	m.ctrl.T.Helper()
@@ -165,6 +230,46 @@ func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) OnRetransmissionTimeout(a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnRetransmissionTimeout", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).OnRetransmissionTimeout), arg0)
 }
 
+// PacingBudget mocks base method.
+func (m *MockSendAlgorithmWithDebugInfos) PacingBudget() protocol.ByteCount {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PacingBudget")
+	ret0, _ := ret[0].(protocol.ByteCount)
+	return ret0
+}
+
+// PacingBudget indicates an expected call of PacingBudget.
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) PacingBudget() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PacingBudget", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).PacingBudget))
+}
+
+// PacingRate mocks base method.
+func (m *MockSendAlgorithmWithDebugInfos) PacingRate() congestion.Bandwidth {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PacingRate")
+	ret0, _ := ret[0].(congestion.Bandwidth)
+	return ret0
+}
+
+// PacingRate indicates an expected call of PacingRate.
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) PacingRate() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PacingRate", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).PacingRate))
+}
+
+// SetApplicationLimited mocks base method.
+func (m *MockSendAlgorithmWithDebugInfos) SetApplicationLimited(arg0 bool, arg1 protocol.ByteCount) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetApplicationLimited", arg0, arg1)
+}
+
+// SetApplicationLimited indicates an expected call of SetApplicationLimited.
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) SetApplicationLimited(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetApplicationLimited", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).SetApplicationLimited), arg0, arg1)
+}
+
 // SetMaxDatagramSize mocks base method.
 func (m *MockSendAlgorithmWithDebugInfos) SetMaxDatagramSize(arg0 protocol.ByteCount) {
 	m.ctrl.T.Helper()
@@ -177,6 +282,18 @@ func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) SetMaxDatagramSize(arg0 i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMaxDatagramSize", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).SetMaxDatagramSize), arg0)
 }
 
+// SetMaxSendRate mocks base method.
+func (m *MockSendAlgorithmWithDebugInfos) SetMaxSendRate(arg0 congestion.Bandwidth) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetMaxSendRate", arg0)
+}
+
+// SetMaxSendRate indicates an expected call of SetMaxSendRate.
+func (mr *MockSendAlgorithmWithDebugInfosMockRecorder) SetMaxSendRate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMaxSendRate", reflect.TypeOf((*MockSendAlgorithmWithDebugInfos)(nil).SetMaxSendRate), arg0)
+}
+
 // TimeUntilSend mocks base method.
 func (m *MockSendAlgorithmWithDebugInfos) TimeUntilSend(arg0 protocol.ByteCount) time.Time {
 	m.ctrl.T.Helper()
@@ -58,6 +58,20 @@ func (mr *MockConnectionFlowControllerMockRecorder) AddBytesSent(arg0 interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBytesSent", reflect.TypeOf((*MockConnectionFlowController)(nil).AddBytesSent), arg0)
 }
 
+// BytesReceived mocks base method.
+func (m *MockConnectionFlowController) BytesReceived() protocol.ByteCount {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BytesReceived")
+	ret0, _ := ret[0].(protocol.ByteCount)
+	return ret0
+}
+
+// BytesReceived indicates an expected call of BytesReceived.
+func (mr *MockConnectionFlowControllerMockRecorder) BytesReceived() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BytesReceived", reflect.TypeOf((*MockConnectionFlowController)(nil).BytesReceived))
+}
+
 // GetWindowUpdate mocks base method.
 func (m *MockConnectionFlowController) GetWindowUpdate() protocol.ByteCount {
 	m.ctrl.T.Helper()
@@ -237,6 +237,21 @@ func (mr *MockCryptoSetupMockRecorder) RunHandshake() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunHandshake", reflect.TypeOf((*MockCryptoSetup)(nil).RunHandshake))
 }
 
+// SessionTicketKeyName mocks base method.
+func (m *MockCryptoSetup) SessionTicketKeyName() ([16]byte, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SessionTicketKeyName")
+	ret0, _ := ret[0].([16]byte)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// SessionTicketKeyName indicates an expected call of SessionTicketKeyName.
+func (mr *MockCryptoSetupMockRecorder) SessionTicketKeyName() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SessionTicketKeyName", reflect.TypeOf((*MockCryptoSetup)(nil).SessionTicketKeyName))
+}
+
 // SetHandshakeConfirmed mocks base method.
 func (m *MockCryptoSetup) SetHandshakeConfirmed() {
 	m.ctrl.T.Helper()
@@ -253,3 +253,51 @@ func (mr *MockEarlySessionMockRecorder) SendMessage(arg0 interface{}) *gomock.Ca
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMessage", reflect.TypeOf((*MockEarlySession)(nil).SendMessage), arg0)
 }
+
+// SetApplicationLimited mocks base method.
+func (m *MockEarlySession) SetApplicationLimited(arg0 bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetApplicationLimited", arg0)
+}
+
+// SetApplicationLimited indicates an expected call of SetApplicationLimited.
+func (mr *MockEarlySessionMockRecorder) SetApplicationLimited(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetApplicationLimited", reflect.TypeOf((*MockEarlySession)(nil).SetApplicationLimited), arg0)
+}
+
+// SetLabels mocks base method.
+func (m *MockEarlySession) SetLabels(arg0 map[string]string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetLabels", arg0)
+}
+
+// SetLabels indicates an expected call of SetLabels.
+func (mr *MockEarlySessionMockRecorder) SetLabels(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLabels", reflect.TypeOf((*MockEarlySession)(nil).SetLabels), arg0)
+}
+
+// SetMaxReceiveRate mocks base method.
+func (m *MockEarlySession) SetMaxReceiveRate(arg0 uint64) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetMaxReceiveRate", arg0)
+}
+
+// SetMaxReceiveRate indicates an expected call of SetMaxReceiveRate.
+func (mr *MockEarlySessionMockRecorder) SetMaxReceiveRate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMaxReceiveRate", reflect.TypeOf((*MockEarlySession)(nil).SetMaxReceiveRate), arg0)
+}
+
+// SetMaxSendRate mocks base method.
+func (m *MockEarlySession) SetMaxSendRate(arg0 uint64) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetMaxSendRate", arg0)
+}
+
+// SetMaxSendRate indicates an expected call of SetMaxSendRate.
+func (mr *MockEarlySessionMockRecorder) SetMaxSendRate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMaxSendRate", reflect.TypeOf((*MockEarlySession)(nil).SetMaxSendRate), arg0)
+}
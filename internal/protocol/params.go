@@ -108,10 +108,21 @@ const DefaultHandshakeIdleTimeout = 5 * time.Second
 // DefaultHandshakeTimeout is the default timeout for a connection until the crypto handshake succeeds.
 const DefaultHandshakeTimeout = 10 * time.Second
 
+// DefaultSuspendThreshold is the default duration for which the system has to be suspended until a session notices it.
+const DefaultSuspendThreshold = 5 * time.Second
+
 // MaxKeepAliveInterval is the maximum time until we send a packet to keep a connection alive.
 // It should be shorter than the time that NATs clear their mapping.
 const MaxKeepAliveInterval = 20 * time.Second
 
+// NATKeepAliveInterval is the keep-alive interval used for peer-to-peer connections.
+// Many NATs clear UDP bindings after 30 seconds of inactivity.
+const NATKeepAliveInterval = 10 * time.Second
+
+// PeerRoleNegotiationTimeout is the time that we wait for the preferred connection to a peer,
+// after a different connection was established.
+const PeerRoleNegotiationTimeout = 2 * time.Second
+
 // RetiredConnectionIDDeleteTimeout is the time we keep closed sessions around in order to retransmit the CONNECTION_CLOSE.
 // after this time all information about the old connection will be deleted
 const RetiredConnectionIDDeleteTimeout = 5 * time.Second
@@ -122,6 +133,14 @@ const RetiredConnectionIDDeleteTimeout = 5 * time.Second
 // 2. it reduces the head-of-line blocking, when a packet is lost
 const MinStreamFrameSize ByteCount = 128
 
+// BlackholeDetectionPTOs is the number of PTOs that expire without receiving any packet from the server,
+// before the client declares a UDP blackhole.
+const BlackholeDetectionPTOs = 3
+
+// MTUBlackholeDetectionTimeouts is the number of loss detection timeouts without receiving an acknowledgement,
+// after which the datagram size discovered by Path MTU Discovery is considered to be black-holed.
+const MTUBlackholeDetectionTimeouts = 3
+
 // MaxPostHandshakeCryptoFrameSize is the maximum size of CRYPTO frames
 // we send after the handshake completes.
 const MaxPostHandshakeCryptoFrameSize = 1000
@@ -146,11 +165,15 @@ const DatagramRcvQueueLen = 128
 // If at any point we keep track of more ranges, old ranges are discarded.
 const MaxNumAckRanges = 32
 
-// MinPacingDelay is the minimum duration that is used for packet pacing
+// MinPacingDelay is the default minimum duration that is used for packet pacing (see Config.PacingGranularity).
 // If the packet packing frequency is higher, multiple packets might be sent at once.
 // Example: For a packet pacing delay of 200μs, we would send 5 packets at once, wait for 1ms, and so forth.
 const MinPacingDelay = time.Millisecond
 
+// KernelPacingHorizon is how far ahead of their pacing deadline packets are handed to the kernel,
+// if pacing is offloaded to the kernel.
+const KernelPacingHorizon = 5 * time.Millisecond
+
 // DefaultConnectionIDLength is the connection ID length that is used for multiplexed connections
 // if no other value is configured.
 const DefaultConnectionIDLength = 4
@@ -175,6 +198,10 @@ const TimerGranularity = time.Millisecond
 // MaxAckDelay is the maximum time by which we delay sending ACKs.
 const MaxAckDelay = 25 * time.Millisecond
 
+// MaxAckThinningPackets is the maximum number of ack-eliciting packets received before sending an ACK,
+// when the ACK frequency is reduced by an ACK thinning strategy.
+const MaxAckThinningPackets = 64
+
 // MaxAckDelayInclGranularity is the max_ack_delay including the timer granularity.
 // This is the value that should be advertised to the peer.
 const MaxAckDelayInclGranularity = MaxAckDelay + TimerGranularity
@@ -193,3 +220,34 @@ const Max0RTTQueues = 32
 // To avoid blocking, this value has to be smaller than MaxSessionUnprocessedPackets.
 // To avoid packets being dropped as undecryptable by the session, this value has to be smaller than MaxUndecryptablePackets.
 const Max0RTTQueueLen = 31
+
+// MaxPendingClientHellos is the maximum number of connections for which the server reassembles a ClientHello
+// that spans multiple Initial packets, in order to route the connection based on the server name.
+const MaxPendingClientHellos = 64
+
+// MaxClientHelloPackets is the maximum number of Initial packets that are buffered for a connection while reassembling the ClientHello.
+const MaxClientHelloPackets = 4
+
+// ClientHelloReassemblyTimeout is the time after which a ClientHello that is being reassembled is dropped.
+const ClientHelloReassemblyTimeout = time.Second
+
+// MaxCongestionStateAge is the maximum age of a saved congestion state that is used for Careful Resume.
+const MaxCongestionStateAge = time.Hour
+
+// ClientIDLen is the length of the client ID the server puts into tokens, to recognize returning clients.
+const ClientIDLen = 8
+
+// StateCompactionInterval is the interval at which a session compacts its state.
+const StateCompactionInterval = time.Minute
+
+// MinDeliveryRateReportInterval is the minimum interval at which the delivery rate is reported to the peer.
+const MinDeliveryRateReportInterval = time.Millisecond
+
+// MaxDeliveryRateReportInterval is the maximum interval at which the peer can request delivery rate reports.
+const MaxDeliveryRateReportInterval = time.Minute
+
+// MinRTTProbeDuration is the minimum duration of a probe for a new min RTT, after the min RTT expired.
+const MinRTTProbeDuration = 200 * time.Millisecond
+
+// MinMapCompactionSize is the minimum size a map must have reached before it is reallocated during compaction.
+const MinMapCompactionSize = 64
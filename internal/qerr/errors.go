@@ -8,8 +8,10 @@ import (
 )
 
 var (
-	ErrHandshakeTimeout = &HandshakeTimeoutError{}
-	ErrIdleTimeout      = &IdleTimeoutError{}
+	ErrHandshakeTimeout   = &HandshakeTimeoutError{}
+	ErrIdleTimeout        = &IdleTimeoutError{}
+	ErrFirstFlightTimeout = &FirstFlightTimeoutError{}
+	ErrFallbackAdvised    = &FallbackAdvisedError{}
 )
 
 type TransportError struct {
@@ -91,6 +93,26 @@ func (e *HandshakeTimeoutError) Temporary() bool      { return false }
 func (e *HandshakeTimeoutError) Error() string        { return "timeout: handshake did not complete in time" }
 func (e *HandshakeTimeoutError) Is(target error) bool { return target == net.ErrClosed }
 
+type FirstFlightTimeoutError struct{}
+
+var _ error = &FirstFlightTimeoutError{}
+
+func (e *FirstFlightTimeoutError) Timeout() bool        { return true }
+func (e *FirstFlightTimeoutError) Temporary() bool      { return false }
+func (e *FirstFlightTimeoutError) Error() string        { return "timeout: no response from the server" }
+func (e *FirstFlightTimeoutError) Is(target error) bool { return target == net.ErrClosed }
+
+// A FallbackAdvisedError occurs when the client didn't receive any packet from the server,
+// although its Initial was retransmitted multiple times. This happens when UDP is blocked on the path.
+type FallbackAdvisedError struct{}
+
+var _ error = &FallbackAdvisedError{}
+
+func (e *FallbackAdvisedError) Timeout() bool        { return true }
+func (e *FallbackAdvisedError) Temporary() bool      { return false }
+func (e *FallbackAdvisedError) Error() string        { return "timeout: UDP blackhole detected" }
+func (e *FallbackAdvisedError) Is(target error) bool { return target == net.ErrClosed }
+
 // A VersionNegotiationError occurs when the client and the server can't agree on a QUIC version.
 type VersionNegotiationError struct {
 	Ours   []protocol.VersionNumber
@@ -77,6 +77,28 @@ var _ = Describe("QUIC Errors", func() {
 			Expect(err.Error()).To(Equal("timeout: handshake did not complete in time"))
 		})
 
+		It("first flight timeouts", func() {
+			//nolint:gosimple // we need to assign to an interface here
+			var err error
+			err = &FirstFlightTimeoutError{}
+			nerr, ok := err.(net.Error)
+			Expect(ok).To(BeTrue())
+			Expect(nerr.Timeout()).To(BeTrue())
+			Expect(nerr.Temporary()).To(BeFalse())
+			Expect(err.Error()).To(Equal("timeout: no response from the server"))
+		})
+
+		It("fallback advised errors", func() {
+			//nolint:gosimple // we need to assign to an interface here
+			var err error
+			err = &FallbackAdvisedError{}
+			nerr, ok := err.(net.Error)
+			Expect(ok).To(BeTrue())
+			Expect(nerr.Timeout()).To(BeTrue())
+			Expect(nerr.Temporary()).To(BeFalse())
+			Expect(err.Error()).To(Equal("timeout: UDP blackhole detected"))
+		})
+
 		It("idle timeouts", func() {
 			//nolint:gosimple // we need to assign to an interface here
 			var err error
@@ -121,6 +143,8 @@ var _ = Describe("QUIC Errors", func() {
 		Expect(errors.Is(&ApplicationError{}, net.ErrClosed)).To(BeTrue())
 		Expect(errors.Is(&IdleTimeoutError{}, net.ErrClosed)).To(BeTrue())
 		Expect(errors.Is(&HandshakeTimeoutError{}, net.ErrClosed)).To(BeTrue())
+		Expect(errors.Is(&FirstFlightTimeoutError{}, net.ErrClosed)).To(BeTrue())
+		Expect(errors.Is(&FallbackAdvisedError{}, net.ErrClosed)).To(BeTrue())
 		Expect(errors.Is(&StatelessResetError{}, net.ErrClosed)).To(BeTrue())
 		Expect(errors.Is(&VersionNegotiationError{}, net.ErrClosed)).To(BeTrue())
 	})
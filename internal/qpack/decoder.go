@@ -0,0 +1,478 @@
+package qpack
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// A decodingError is something the spec defines as a decoding error.
+type decodingError struct {
+	err error
+}
+
+func (de decodingError) Error() string {
+	return fmt.Sprintf("decoding error: %v", de.err)
+}
+
+// An invalidIndexError is returned when an encoder references a table
+// entry before the static table or after the end of the dynamic table.
+type invalidIndexError int
+
+func (e invalidIndexError) Error() string {
+	return fmt.Sprintf("invalid indexed representation index %d", int(e))
+}
+
+var (
+	errTooManyBlocked     = decodingError{errors.New("too many blocked streams")}
+	errTruncatedHeaders   = decodingError{errors.New("truncated headers")}
+	errStringTooLong      = errors.New("string literal too long")
+	errEntryTooLarge      = errors.New("entry exceeds the dynamic table capacity")
+	errCapacityTooLarge   = errors.New("dynamic table capacity exceeds the maximum")
+	errBlockedNoStream    = decodingError{errors.New("field section requires a stream to wait for the dynamic table")}
+	errInvalidInsertCount = decodingError{errors.New("invalid Required Insert Count")}
+)
+
+// errNeedMore is an internal sentinel error value that means the
+// buffer is truncated and we need to read more data before we can
+// continue parsing.
+var errNeedMore = errors.New("need more data")
+
+// A Decoder decodes field sections.
+// A single Decoder is used for all streams of a connection, since they share the dynamic table.
+type Decoder struct {
+	mutex sync.Mutex
+
+	emitFunc func(f HeaderField)
+
+	maxTableCapacity  uint64
+	maxBlockedStreams uint64
+	blockedStreams    uint64
+	table             dynamicTable
+	// The number of inserts that the encoder knows we received,
+	// either from Section Acknowledgements, or from Insert Count Increments.
+	acknowledged uint64
+	inserted     chan struct{} // closed (and replaced) when entries are inserted
+
+	decoderStream       io.Writer
+	pendingInstructions []byte // instructions that weren't written to the decoder stream yet
+	writingInstructions bool   // set while a goroutine writes the pending instructions
+}
+
+// NewDecoder returns a Decoder that only supports the static table.
+// The emitFunc, if set, is called for each field decoded by DecodeFull,
+// in the same goroutine, before DecodeFull returns.
+func NewDecoder(emitFunc func(f HeaderField)) *Decoder {
+	return &Decoder{
+		emitFunc: emitFunc,
+		inserted: make(chan struct{}),
+	}
+}
+
+// NewDynamicDecoder returns a Decoder that allows the peer's encoder to use a dynamic table
+// of up to maxTableCapacity bytes, and to block up to maxBlockedStreams streams.
+// These values are announced to the peer in the SETTINGS frame.
+// The instructions sent on the peer's encoder stream are passed to HandleEncoderStream.
+func NewDynamicDecoder(maxTableCapacity, maxBlockedStreams uint64) *Decoder {
+	d := NewDecoder(nil)
+	d.maxTableCapacity = maxTableCapacity
+	d.maxBlockedStreams = maxBlockedStreams
+	return d
+}
+
+// SetDecoderStream sets the stream that acknowledgements for the peer's encoder are written to.
+// It must be set before the peer's encoder inserts entries into the dynamic table.
+func (d *Decoder) SetDecoderStream(w io.Writer) {
+	d.mutex.Lock()
+	d.decoderStream = w
+	d.mutex.Unlock()
+}
+
+// DecodeFull decodes an entire field section.
+// Field sections that depend on dynamic table entries that weren't received yet are rejected.
+func (d *Decoder) DecodeFull(p []byte) ([]HeaderField, error) {
+	hfs, err := d.decode(context.Background(), 0, false, p)
+	if err != nil {
+		return nil, err
+	}
+	if d.emitFunc != nil {
+		for _, hf := range hfs {
+			d.emitFunc(hf)
+		}
+	}
+	return hfs, nil
+}
+
+// DecodeStream decodes an entire field section received on a stream.
+// If the field section depends on dynamic table entries that weren't received yet,
+// it blocks until they are received, or until the context is canceled.
+// Decoding a field section that uses the dynamic table is acknowledged to the peer's encoder.
+func (d *Decoder) DecodeStream(ctx context.Context, streamID uint64, p []byte) ([]HeaderField, error) {
+	hfs, err := d.decode(ctx, streamID, true, p)
+	d.flushInstructions()
+	return hfs, err
+}
+
+func (d *Decoder) decode(ctx context.Context, streamID uint64, onStream bool, p []byte) ([]HeaderField, error) {
+	if len(p) == 0 {
+		return []HeaderField{}, nil
+	}
+	encodedInsertCount, buf, err := readVarInt(8, p)
+	if err != nil || len(buf) == 0 {
+		return nil, errTruncatedHeaders
+	}
+	negativeBase := buf[0]&0x80 > 0
+	deltaBase, buf, err := readVarInt(7, buf)
+	if err != nil {
+		return nil, errTruncatedHeaders
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	requiredInsertCount, err := d.requiredInsertCount(encodedInsertCount)
+	if err != nil {
+		return nil, err
+	}
+	var base uint64
+	if negativeBase {
+		if deltaBase >= requiredInsertCount {
+			return nil, decodingError{errors.New("invalid Base")}
+		}
+		base = requiredInsertCount - deltaBase - 1
+	} else {
+		base = requiredInsertCount + deltaBase
+	}
+	if requiredInsertCount > d.table.insertCount() {
+		if !onStream {
+			return nil, errBlockedNoStream
+		}
+		if err := d.waitForInserts(ctx, requiredInsertCount); err != nil {
+			return nil, err
+		}
+	}
+
+	hfs, err := d.parseFieldLines(buf, requiredInsertCount, base)
+	if err != nil {
+		return nil, err
+	}
+	if requiredInsertCount > 0 && onStream {
+		if requiredInsertCount > d.acknowledged {
+			d.acknowledged = requiredInsertCount
+		}
+		d.queueInstruction(appendInstruction(nil, sectionAckInstruction, 7, streamID))
+	}
+	return hfs, nil
+}
+
+// CancelStream tells the peer's encoder that the field sections on a stream won't be decoded,
+// because the stream was reset, or reading from it was abandoned.
+func (d *Decoder) CancelStream(streamID uint64) {
+	if d.maxTableCapacity == 0 {
+		return
+	}
+	d.mutex.Lock()
+	d.queueInstruction(appendInstruction(nil, streamCancellationInstruction, 6, streamID))
+	d.mutex.Unlock()
+	d.flushInstructions()
+}
+
+// requiredInsertCount decodes the Required Insert Count, see section 4.5.1.1 of RFC 9204.
+func (d *Decoder) requiredInsertCount(encoded uint64) (uint64, error) {
+	if encoded == 0 {
+		return 0, nil
+	}
+	maxEntries := d.maxTableCapacity / entryOverhead
+	fullRange := 2 * maxEntries
+	if encoded > fullRange {
+		return 0, errInvalidInsertCount
+	}
+	maxValue := d.table.insertCount() + maxEntries
+	maxWrapped := (maxValue / fullRange) * fullRange
+	ric := maxWrapped + encoded - 1
+	if ric > maxValue {
+		if ric <= fullRange {
+			return 0, errInvalidInsertCount
+		}
+		ric -= fullRange
+	}
+	if ric == 0 {
+		return 0, errInvalidInsertCount
+	}
+	return ric, nil
+}
+
+// waitForInserts waits until the required number of entries were inserted.
+// It must be called with the mutex held.
+func (d *Decoder) waitForInserts(ctx context.Context, requiredInsertCount uint64) error {
+	if d.blockedStreams >= d.maxBlockedStreams {
+		return errTooManyBlocked
+	}
+	d.blockedStreams++
+	defer func() { d.blockedStreams-- }()
+
+	for d.table.insertCount() < requiredInsertCount {
+		inserted := d.inserted
+		d.mutex.Unlock()
+		select {
+		case <-inserted:
+			d.mutex.Lock()
+		case <-ctx.Done():
+			d.mutex.Lock()
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (d *Decoder) parseFieldLines(buf []byte, requiredInsertCount, base uint64) ([]HeaderField, error) {
+	var hfs []HeaderField
+	for len(buf) > 0 {
+		var hf HeaderField
+		var err error
+		b := buf[0]
+		switch {
+		case b&0x80 > 0: // 1Txxxxxx: indexed field line
+			var index uint64
+			index, buf, err = readVarInt(6, buf)
+			if err != nil {
+				break
+			}
+			if b&0x40 > 0 {
+				hf, err = staticEntry(index)
+			} else {
+				hf, err = d.relativeEntry(index, requiredInsertCount, base)
+			}
+		case b&0xc0 == 0x40: // 01NTxxxx: literal field line with name reference
+			var index uint64
+			index, buf, err = readVarInt(4, buf)
+			if err != nil {
+				break
+			}
+			if b&0x10 > 0 {
+				hf, err = staticEntry(index)
+			} else {
+				hf, err = d.relativeEntry(index, requiredInsertCount, base)
+			}
+			if err != nil {
+				break
+			}
+			hf.Value, buf, err = readValue(buf)
+		case b&0xe0 == 0x20: // 001NHxxx: literal field line with literal name
+			hf.Name, buf, err = readString(buf, 3)
+			if err != nil {
+				break
+			}
+			hf.Value, buf, err = readValue(buf)
+		case b&0xf0 == 0x10: // 0001xxxx: indexed field line with post-base index
+			var index uint64
+			index, buf, err = readVarInt(4, buf)
+			if err != nil {
+				break
+			}
+			hf, err = d.dynamicEntry(base+index, requiredInsertCount)
+		default: // 0000Nxxx: literal field line with post-base name reference
+			var index uint64
+			index, buf, err = readVarInt(3, buf)
+			if err != nil {
+				break
+			}
+			hf, err = d.dynamicEntry(base+index, requiredInsertCount)
+			if err != nil {
+				break
+			}
+			hf.Value, buf, err = readValue(buf)
+		}
+		if err == errNeedMore {
+			return nil, errTruncatedHeaders
+		}
+		if err != nil {
+			return nil, err
+		}
+		hfs = append(hfs, hf)
+	}
+	return hfs, nil
+}
+
+func readValue(buf []byte) (string, []byte, error) {
+	if len(buf) == 0 {
+		return "", nil, errNeedMore
+	}
+	return readString(buf, 7)
+}
+
+func staticEntry(index uint64) (HeaderField, error) {
+	if index >= uint64(len(staticTableEntries)) {
+		return HeaderField{}, decodingError{invalidIndexError(index)}
+	}
+	return staticTableEntries[index], nil
+}
+
+func (d *Decoder) relativeEntry(index, requiredInsertCount, base uint64) (HeaderField, error) {
+	if index >= base {
+		return HeaderField{}, decodingError{invalidIndexError(index)}
+	}
+	return d.dynamicEntry(base-1-index, requiredInsertCount)
+}
+
+func (d *Decoder) dynamicEntry(absIndex, requiredInsertCount uint64) (HeaderField, error) {
+	if absIndex >= requiredInsertCount {
+		return HeaderField{}, decodingError{invalidIndexError(absIndex)}
+	}
+	hf, ok := d.table.get(absIndex)
+	if !ok {
+		return HeaderField{}, decodingError{invalidIndexError(absIndex)}
+	}
+	return hf, nil
+}
+
+// HandleEncoderStream processes the instructions received on the peer's encoder stream.
+// It returns when reading from the stream fails, or when an invalid instruction is received.
+// Any error is a connection error.
+func (d *Decoder) HandleEncoderStream(r io.Reader) error {
+	br := bufio.NewReader(r)
+	for {
+		if err := d.handleEncoderInstruction(br); err != nil {
+			return err
+		}
+		// Acknowledge all inserts received so far, before blocking on the stream.
+		if br.Buffered() == 0 {
+			d.mutex.Lock()
+			if n := d.table.insertCount() - d.acknowledged; n > 0 {
+				d.acknowledged += n
+				d.queueInstruction(appendInstruction(nil, insertCountIncrementInstruction, 6, n))
+			}
+			d.mutex.Unlock()
+			d.flushInstructions()
+		}
+	}
+}
+
+func (d *Decoder) handleEncoderInstruction(r instructionReader) error {
+	b, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	switch {
+	case b&0x80 > 0: // 1Txxxxxx: insert with name reference
+		index, err := readInt(r, b, 6)
+		if err != nil {
+			return err
+		}
+		var hf HeaderField
+		if b&0x40 > 0 {
+			hf, err = staticEntry(index)
+		} else {
+			hf, err = d.insertedEntry(index)
+		}
+		if err != nil {
+			return err
+		}
+		if hf.Value, err = d.readEncoderString(r, 7); err != nil {
+			return err
+		}
+		return d.insert(hf)
+	case b&0xc0 == 0x40: // 01Hxxxxx: insert with literal name
+		var hf HeaderField
+		if hf.Name, err = readStringFrom(r, b, 5, d.maxTableCapacity); err != nil {
+			return err
+		}
+		if hf.Value, err = d.readEncoderString(r, 7); err != nil {
+			return err
+		}
+		return d.insert(hf)
+	case b&0xe0 == 0x20: // 001xxxxx: set dynamic table capacity
+		capacity, err := readInt(r, b, 5)
+		if err != nil {
+			return err
+		}
+		if capacity > d.maxTableCapacity {
+			return errCapacityTooLarge
+		}
+		d.mutex.Lock()
+		d.table.setCapacity(capacity)
+		d.mutex.Unlock()
+		return nil
+	default: // 000xxxxx: duplicate
+		index, err := readInt(r, b, 5)
+		if err != nil {
+			return err
+		}
+		hf, err := d.insertedEntry(index)
+		if err != nil {
+			return err
+		}
+		return d.insert(hf)
+	}
+}
+
+func (d *Decoder) readEncoderString(r instructionReader, n byte) (string, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	return readStringFrom(r, b, n, d.maxTableCapacity)
+}
+
+// insertedEntry returns an entry referenced relative to the number of inserts, as used on the encoder stream.
+func (d *Decoder) insertedEntry(index uint64) (HeaderField, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	insertCount := d.table.insertCount()
+	if index >= insertCount {
+		return HeaderField{}, invalidIndexError(index)
+	}
+	hf, ok := d.table.get(insertCount - 1 - index)
+	if !ok {
+		return HeaderField{}, invalidIndexError(index)
+	}
+	return hf, nil
+}
+
+func (d *Decoder) insert(hf HeaderField) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if entrySize(hf) > d.table.capacity {
+		return errEntryTooLarge
+	}
+	d.table.insert(hf)
+	close(d.inserted)
+	d.inserted = make(chan struct{})
+	return nil
+}
+
+// queueInstruction queues an instruction for the decoder stream.
+// It must be called with the mutex held, and followed by a call to flushInstructions after releasing it.
+func (d *Decoder) queueInstruction(b []byte) {
+	if d.decoderStream == nil {
+		return
+	}
+	d.pendingInstructions = append(d.pendingInstructions, b...)
+}
+
+// flushInstructions writes the queued instructions to the decoder stream.
+// The mutex is not held while writing, so that a decoder stream that is blocked by flow control doesn't block decoding.
+// Only one goroutine writes at a time, so the instructions are written in the order they were queued.
+func (d *Decoder) flushInstructions() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.writingInstructions {
+		return
+	}
+	d.writingInstructions = true
+	for len(d.pendingInstructions) > 0 {
+		b := d.pendingInstructions
+		d.pendingInstructions = nil
+		w := d.decoderStream
+		d.mutex.Unlock()
+		w.Write(b)
+		d.mutex.Lock()
+	}
+	d.writingInstructions = false
+}
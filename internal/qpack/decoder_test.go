@@ -0,0 +1,187 @@
+package qpack
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Decoder", func() {
+	encodeStatically := func(fields ...HeaderField) []byte {
+		buf := &bytes.Buffer{}
+		enc := NewEncoder(buf)
+		for _, hf := range fields {
+			Expect(enc.WriteField(hf)).To(Succeed())
+		}
+		return buf.Bytes()
+	}
+
+	It("decodes field sections that only use the static table", func() {
+		var emitted []HeaderField
+		decoder := NewDecoder(func(hf HeaderField) { emitted = append(emitted, hf) })
+		fields := []HeaderField{
+			{Name: ":method", Value: "GET"},
+			{Name: ":path", Value: "/foo"},
+			{Name: "foo", Value: "bar"},
+		}
+		hfs, err := decoder.DecodeFull(encodeStatically(fields...))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(hfs).To(Equal(fields))
+		Expect(emitted).To(Equal(fields))
+	})
+
+	It("errors on truncated field sections", func() {
+		data := encodeStatically(HeaderField{Name: "foo", Value: "bar"})
+		_, err := NewDecoder(nil).DecodeFull(data[:len(data)-1])
+		Expect(err).To(MatchError(errTruncatedHeaders))
+	})
+
+	It("rejects references to the dynamic table, if it's not enabled", func() {
+		_, err := NewDecoder(nil).DecodeFull([]byte{0x2, 0x0, 0x80})
+		Expect(err).To(MatchError(errInvalidInsertCount))
+	})
+
+	Context("using the dynamic table", func() {
+		var (
+			decoder       *Decoder
+			decoderStream *bytes.Buffer
+		)
+
+		BeforeEach(func() {
+			decoder = NewDynamicDecoder(200, 1)
+			decoderStream = &bytes.Buffer{}
+			decoder.SetDecoderStream(decoderStream)
+		})
+
+		insertFooBar := func() []byte {
+			b := appendInstruction(nil, setCapacityInstruction, 5, 200)
+			b = appendStringLiteral(b, insertWithLiteralNameInstruction, 5, "foo")
+			return appendStringLiteral(b, 0, 7, "bar")
+		}
+
+		It("processes encoder instructions and acknowledges them", func() {
+			Expect(decoder.HandleEncoderStream(bytes.NewReader(insertFooBar()))).To(MatchError(io.EOF))
+			Expect(decoder.table.entries).To(Equal([]HeaderField{{Name: "foo", Value: "bar"}}))
+			Expect(decoderStream.Bytes()).To(Equal([]byte{insertCountIncrementInstruction | 1}))
+		})
+
+		It("decodes references to the dynamic table, and acknowledges the field section", func() {
+			Expect(decoder.HandleEncoderStream(bytes.NewReader(insertFooBar()))).To(MatchError(io.EOF))
+			decoderStream.Reset()
+			// Required Insert Count 1 (encoded as 2), Base 1, relative index 0
+			hfs, err := decoder.DecodeStream(context.Background(), 4, []byte{0x2, 0x0, 0x80})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hfs).To(Equal([]HeaderField{{Name: "foo", Value: "bar"}}))
+			Expect(decoderStream.Bytes()).To(Equal([]byte{sectionAckInstruction | 4}))
+		})
+
+		It("decodes post-base references", func() {
+			Expect(decoder.HandleEncoderStream(bytes.NewReader(insertFooBar()))).To(MatchError(io.EOF))
+			// Required Insert Count 1 (encoded as 2), Base 0 (sign bit set, delta base 0), post-base index 0
+			hfs, err := decoder.DecodeStream(context.Background(), 4, []byte{0x2, 0x80, 0x10})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hfs).To(Equal([]HeaderField{{Name: "foo", Value: "bar"}}))
+		})
+
+		It("blocks until the entries are received", func() {
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+				hfs, err := decoder.DecodeStream(context.Background(), 4, []byte{0x2, 0x0, 0x80})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(hfs).To(Equal([]HeaderField{{Name: "foo", Value: "bar"}}))
+			}()
+			Consistently(done).ShouldNot(BeClosed())
+			Expect(decoder.HandleEncoderStream(bytes.NewReader(insertFooBar()))).To(MatchError(io.EOF))
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("stops waiting when the context is canceled", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			_, err := decoder.DecodeStream(ctx, 4, []byte{0x2, 0x0, 0x80})
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+		})
+
+		It("limits the number of blocked streams", func() {
+			go decoder.DecodeStream(context.Background(), 4, []byte{0x2, 0x0, 0x80})
+			Eventually(func() uint64 {
+				decoder.mutex.Lock()
+				defer decoder.mutex.Unlock()
+				return decoder.blockedStreams
+			}).Should(BeEquivalentTo(1))
+			_, err := decoder.DecodeStream(context.Background(), 8, []byte{0x2, 0x0, 0x80})
+			Expect(err).To(MatchError(errTooManyBlocked))
+		})
+
+		It("rejects blocked field sections that are not received on a stream", func() {
+			_, err := decoder.DecodeFull([]byte{0x2, 0x0, 0x80})
+			Expect(err).To(MatchError(errBlockedNoStream))
+		})
+
+		It("rejects a capacity larger than the maximum", func() {
+			b := appendInstruction(nil, setCapacityInstruction, 5, 201)
+			Expect(decoder.HandleEncoderStream(bytes.NewReader(b))).To(MatchError(errCapacityTooLarge))
+		})
+
+		It("rejects entries larger than the capacity", func() {
+			b := appendInstruction(nil, setCapacityInstruction, 5, 40)
+			b = appendStringLiteral(b, insertWithLiteralNameInstruction, 5, "foo")
+			b = appendStringLiteral(b, 0, 7, "foobar")
+			Expect(decoder.HandleEncoderStream(bytes.NewReader(b))).To(MatchError(errEntryTooLarge))
+		})
+
+		It("rejects references to entries that don't exist", func() {
+			b := appendInstruction(nil, setCapacityInstruction, 5, 200)
+			b = appendInstruction(b, insertWithNameRefInstruction, 6, 0)
+			b = appendStringLiteral(b, 0, 7, "bar")
+			Expect(decoder.HandleEncoderStream(bytes.NewReader(b))).To(MatchError(invalidIndexError(0)))
+		})
+
+		It("sends stream cancellations", func() {
+			decoder.CancelStream(8)
+			Expect(decoderStream.Bytes()).To(Equal([]byte{streamCancellationInstruction | 8}))
+		})
+
+		It("doesn't block decoding while writing to the decoder stream", func() {
+			Expect(decoder.HandleEncoderStream(bytes.NewReader(insertFooBar()))).To(MatchError(io.EOF))
+			decoderStream.Reset()
+			w := &blockingWriter{unblock: make(chan struct{}), writing: make(chan struct{}, 10)}
+			decoder.SetDecoderStream(w)
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+				decoder.CancelStream(8)
+			}()
+			Eventually(w.writing).Should(Receive())
+			// the decoder stream is blocked, but field sections are still decoded
+			hfs, err := decoder.DecodeStream(context.Background(), 4, []byte{0x2, 0x0, 0x80})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(hfs).To(Equal([]HeaderField{{Name: "foo", Value: "bar"}}))
+			Consistently(done).ShouldNot(BeClosed())
+			close(w.unblock)
+			Eventually(done).Should(BeClosed())
+			// the instructions are written in order
+			Expect(w.buf.Bytes()).To(Equal([]byte{streamCancellationInstruction | 8, sectionAckInstruction | 4}))
+		})
+	})
+})
+
+// blockingWriter blocks every Write until unblock is closed.
+type blockingWriter struct {
+	buf     bytes.Buffer
+	writing chan struct{}
+	unblock chan struct{}
+}
+
+func (w *blockingWriter) Write(b []byte) (int, error) {
+	w.writing <- struct{}{}
+	<-w.unblock
+	return w.buf.Write(b)
+}
@@ -0,0 +1,357 @@
+package qpack
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+)
+
+// fields that are never inserted into the dynamic table:
+// their values are either sensitive, or change too often to be worth inserting
+var notIndexed = map[string]struct{}{
+	"authorization":       {},
+	"proxy-authorization": {},
+	"content-length":      {},
+	"date":                {},
+}
+
+// A fieldSection is a field section that was not acknowledged by the decoder yet.
+type fieldSection struct {
+	requiredInsertCount uint64
+	references          []uint64 // absolute indices of the entries referenced
+}
+
+// A DynamicEncoder encodes field sections for all streams of a connection,
+// using the static and the dynamic table.
+// Until the dynamic table is enabled (see SetPeerSettings), only the static table is used.
+type DynamicEncoder struct {
+	mutex sync.Mutex
+
+	maxTableCapacity  uint64 // the maximum capacity we're willing to use
+	maxEntries        uint64 // derived from the decoder's maximum table capacity
+	maxBlockedStreams uint64
+	table             dynamicTable
+
+	knownReceivedCount uint64
+	references         map[uint64]int            // number of unacknowledged field sections referencing an entry, by absolute index
+	sections           map[uint64][]fieldSection // unacknowledged field sections, by stream ID
+
+	encoderStream io.Writer // nil as long as the dynamic table is not enabled
+}
+
+// NewDynamicEncoder returns an encoder that uses a dynamic table of up to maxTableCapacity bytes.
+// If maxTableCapacity is 0, only the static table is used.
+func NewDynamicEncoder(maxTableCapacity uint64) *DynamicEncoder {
+	return &DynamicEncoder{
+		maxTableCapacity: maxTableCapacity,
+		references:       make(map[uint64]int),
+		sections:         make(map[uint64][]fieldSection),
+	}
+}
+
+// SetPeerSettings enables the dynamic table, using the SETTINGS_QPACK_MAX_TABLE_CAPACITY and
+// SETTINGS_QPACK_BLOCKED_STREAMS values sent by the peer.
+// The capacity of the dynamic table is the smaller of the peer's and our maximum.
+// Encoder instructions are written to encoderStream.
+// The instructions sent on the peer's decoder stream are passed to HandleDecoderStream.
+func (e *DynamicEncoder) SetPeerSettings(maxTableCapacity, maxBlockedStreams uint64, encoderStream io.Writer) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	capacity := e.maxTableCapacity
+	if maxTableCapacity < capacity {
+		capacity = maxTableCapacity
+	}
+	if capacity == 0 || e.encoderStream != nil {
+		return
+	}
+	e.encoderStream = encoderStream
+	e.maxEntries = maxTableCapacity / entryOverhead
+	e.maxBlockedStreams = maxBlockedStreams
+	e.table.setCapacity(capacity)
+	e.encoderStream.Write(appendInstruction(nil, setCapacityInstruction, 5, capacity))
+}
+
+// Enabled says if the dynamic table was enabled by SetPeerSettings.
+func (e *DynamicEncoder) Enabled() bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.encoderStream != nil
+}
+
+// the representations of a field line, see section 4.5 of RFC 9204
+const (
+	indexedStatic = iota
+	indexedDynamic
+	literalStaticName
+	literalDynamicName
+	literal
+)
+
+type fieldLine struct {
+	representation int
+	index          uint64 // the static index, or the absolute index of the dynamic table entry
+	hf             HeaderField
+}
+
+// Encode encodes a field section sent on a stream.
+func (e *DynamicEncoder) Encode(streamID uint64, fields []HeaderField) []byte {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.encoderStream == nil {
+		return encodeStatic(fields)
+	}
+
+	// Entries that are not acknowledged yet may only be referenced if the stream may block.
+	canBlock := e.isBlocking(streamID) || e.numBlockedStreams() < e.maxBlockedStreams
+	usable := func(absIndex uint64) bool { return absIndex < e.knownReceivedCount || canBlock }
+
+	lines := make([]fieldLine, 0, len(fields))
+	var references []uint64
+	for _, hf := range fields {
+		staticIndex, exact, nameFound := lookupStatic(hf)
+		if exact {
+			lines = append(lines, fieldLine{representation: indexedStatic, index: staticIndex})
+			continue
+		}
+		absIndex, found := e.find(hf, false)
+		if found && usable(absIndex) {
+			lines = append(lines, fieldLine{representation: indexedDynamic, index: absIndex})
+			references = e.reference(references, absIndex)
+			continue
+		}
+		// If the stream may not block, the entry can only be used by later field sections,
+		// once the decoder acknowledged it.
+		if !found && e.shouldInsert(hf, references) {
+			e.insert(hf, staticIndex, nameFound)
+			if canBlock {
+				absIndex := e.table.insertCount() - 1
+				lines = append(lines, fieldLine{representation: indexedDynamic, index: absIndex})
+				references = e.reference(references, absIndex)
+				continue
+			}
+		}
+		if nameFound {
+			lines = append(lines, fieldLine{representation: literalStaticName, index: staticIndex, hf: hf})
+			continue
+		}
+		if absIndex, ok := e.find(hf, true); ok && usable(absIndex) {
+			lines = append(lines, fieldLine{representation: literalDynamicName, index: absIndex, hf: hf})
+			references = e.reference(references, absIndex)
+			continue
+		}
+		lines = append(lines, fieldLine{representation: literal, hf: hf})
+	}
+
+	var requiredInsertCount uint64
+	for _, absIndex := range references {
+		if absIndex+1 > requiredInsertCount {
+			requiredInsertCount = absIndex + 1
+		}
+	}
+	base := e.table.insertCount()
+	var encodedInsertCount uint64
+	if requiredInsertCount > 0 {
+		encodedInsertCount = requiredInsertCount%(2*e.maxEntries) + 1
+		e.sections[streamID] = append(e.sections[streamID], fieldSection{
+			requiredInsertCount: requiredInsertCount,
+			references:          references,
+		})
+	}
+
+	b := appendVarInt(nil, 8, encodedInsertCount)
+	b = appendVarInt(b, 7, base-requiredInsertCount) // the sign bit is 0, since base >= requiredInsertCount
+	for _, l := range lines {
+		switch l.representation {
+		case indexedStatic: // 11xxxxxx
+			b = appendInstruction(b, 0xc0, 6, l.index)
+		case indexedDynamic: // 10xxxxxx
+			b = appendInstruction(b, 0x80, 6, base-1-l.index)
+		case literalStaticName: // 0101xxxx
+			b = appendInstruction(b, 0x50, 4, l.index)
+			b = appendStringLiteral(b, 0, 7, l.hf.Value)
+		case literalDynamicName: // 0100xxxx
+			b = appendInstruction(b, 0x40, 4, base-1-l.index)
+			b = appendStringLiteral(b, 0, 7, l.hf.Value)
+		case literal: // 0010xxxx
+			b = appendStringLiteral(b, 0x20, 3, l.hf.Name)
+			b = appendStringLiteral(b, 0, 7, l.hf.Value)
+		}
+	}
+	return b
+}
+
+func encodeStatic(fields []HeaderField) []byte {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	for _, hf := range fields {
+		enc.WriteField(hf)
+	}
+	return buf.Bytes()
+}
+
+func lookupStatic(hf HeaderField) (index uint64, exact, nameFound bool) {
+	idxAndVals, ok := encoderMap[hf.Name]
+	if !ok {
+		return 0, false, false
+	}
+	if idxAndVals.values == nil {
+		return uint64(idxAndVals.idx), len(hf.Value) == 0, true
+	}
+	if valIdx, ok := idxAndVals.values[hf.Value]; ok {
+		return uint64(valIdx), true, true
+	}
+	return uint64(idxAndVals.idx), false, true
+}
+
+// find finds the newest entry matching the field (or only its name), and returns its absolute index.
+func (e *DynamicEncoder) find(hf HeaderField, nameOnly bool) (uint64, bool) {
+	for i := len(e.table.entries) - 1; i >= 0; i-- {
+		entry := e.table.entries[i]
+		if entry.Name == hf.Name && (nameOnly || entry.Value == hf.Value) {
+			return e.table.evicted + uint64(i), true
+		}
+	}
+	return 0, false
+}
+
+func (e *DynamicEncoder) reference(references []uint64, absIndex uint64) []uint64 {
+	for _, r := range references {
+		if r == absIndex {
+			return references
+		}
+	}
+	e.references[absIndex]++
+	return append(references, absIndex)
+}
+
+// shouldInsert says if a field should be inserted into the dynamic table.
+// Entries with unacknowledged references can't be evicted to make room for it.
+func (e *DynamicEncoder) shouldInsert(hf HeaderField, references []uint64) bool {
+	if _, ok := notIndexed[hf.Name]; ok {
+		return false
+	}
+	evictable := e.table.insertCount()
+	for absIndex := range e.references {
+		if absIndex < evictable {
+			evictable = absIndex
+		}
+	}
+	for _, absIndex := range references {
+		if absIndex < evictable {
+			evictable = absIndex
+		}
+	}
+	return e.table.fits(entrySize(hf), evictable)
+}
+
+// insert inserts an entry into the dynamic table, and sends the instruction on the encoder stream.
+func (e *DynamicEncoder) insert(hf HeaderField, staticIndex uint64, nameFound bool) {
+	var b []byte
+	if nameFound {
+		b = appendInstruction(nil, insertWithNameRefInstruction|0x40, 6, staticIndex)
+	} else if absIndex, ok := e.find(hf, true); ok {
+		b = appendInstruction(nil, insertWithNameRefInstruction, 6, e.table.insertCount()-1-absIndex)
+	} else {
+		b = appendStringLiteral(nil, insertWithLiteralNameInstruction, 5, hf.Name)
+	}
+	b = appendStringLiteral(b, 0, 7, hf.Value)
+	e.table.insert(hf)
+	e.encoderStream.Write(b)
+}
+
+// isBlocking says if a stream has a field section that might block the decoder.
+func (e *DynamicEncoder) isBlocking(streamID uint64) bool {
+	for _, s := range e.sections[streamID] {
+		if s.requiredInsertCount > e.knownReceivedCount {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *DynamicEncoder) numBlockedStreams() uint64 {
+	var n uint64
+	for streamID := range e.sections {
+		if e.isBlocking(streamID) {
+			n++
+		}
+	}
+	return n
+}
+
+// HandleDecoderStream processes the instructions received on the peer's decoder stream.
+// It returns when reading from the stream fails, or when an invalid instruction is received.
+// Any error is a connection error.
+func (e *DynamicEncoder) HandleDecoderStream(r io.Reader) error {
+	br := bufio.NewReader(r)
+	for {
+		if err := e.handleDecoderInstruction(br); err != nil {
+			return err
+		}
+	}
+}
+
+func (e *DynamicEncoder) handleDecoderInstruction(r instructionReader) error {
+	b, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	switch {
+	case b&0x80 > 0: // 1xxxxxxx: section acknowledgement
+		streamID, err := readInt(r, b, 7)
+		if err != nil {
+			return err
+		}
+		e.mutex.Lock()
+		defer e.mutex.Unlock()
+		sections := e.sections[streamID]
+		if len(sections) == 0 {
+			return errors.New("acknowledgement for a stream without unacknowledged field sections")
+		}
+		if sections[0].requiredInsertCount > e.knownReceivedCount {
+			e.knownReceivedCount = sections[0].requiredInsertCount
+		}
+		e.release(sections[0])
+		if len(sections) == 1 {
+			delete(e.sections, streamID)
+		} else {
+			e.sections[streamID] = sections[1:]
+		}
+	case b&0x40 > 0: // 01xxxxxx: stream cancellation
+		streamID, err := readInt(r, b, 6)
+		if err != nil {
+			return err
+		}
+		e.mutex.Lock()
+		defer e.mutex.Unlock()
+		for _, s := range e.sections[streamID] {
+			e.release(s)
+		}
+		delete(e.sections, streamID)
+	default: // 00xxxxxx: insert count increment
+		increment, err := readInt(r, b, 6)
+		if err != nil {
+			return err
+		}
+		e.mutex.Lock()
+		defer e.mutex.Unlock()
+		if increment == 0 || e.knownReceivedCount+increment > e.table.insertCount() {
+			return errors.New("invalid Insert Count Increment")
+		}
+		e.knownReceivedCount += increment
+	}
+	return nil
+}
+
+func (e *DynamicEncoder) release(s fieldSection) {
+	for _, absIndex := range s.references {
+		e.references[absIndex]--
+		if e.references[absIndex] == 0 {
+			delete(e.references, absIndex)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package qpack
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Dynamic Encoder", func() {
+	var (
+		encoder       *DynamicEncoder
+		decoder       *Decoder
+		encoderStream *bytes.Buffer
+		decoderStream *bytes.Buffer
+	)
+
+	fields := []HeaderField{
+		{Name: ":method", Value: "GET"},
+		{Name: ":path", Value: "/api/v1/items"},
+		{Name: "user-agent", Value: "my-api-client/1.0"},
+		{Name: "x-api-key", Value: "0123456789abcdef0123456789abcdef"},
+	}
+
+	BeforeEach(func() {
+		encoder = NewDynamicEncoder(4096)
+		decoder = NewDynamicDecoder(1024, 10)
+		encoderStream = &bytes.Buffer{}
+		decoderStream = &bytes.Buffer{}
+		decoder.SetDecoderStream(decoderStream)
+	})
+
+	// transfer passes the instructions on the encoder and decoder streams to the peer
+	transfer := func() {
+		ExpectWithOffset(1, decoder.HandleEncoderStream(encoderStream)).To(MatchError(io.EOF))
+		ExpectWithOffset(1, encoder.HandleDecoderStream(decoderStream)).To(MatchError(io.EOF))
+	}
+
+	decode := func(streamID uint64, data []byte) []HeaderField {
+		hfs, err := decoder.DecodeStream(context.Background(), streamID, data)
+		ExpectWithOffset(1, err).ToNot(HaveOccurred())
+		return hfs
+	}
+
+	It("only uses the static table before the dynamic table is enabled", func() {
+		data := encoder.Encode(0, fields)
+		Expect(data).To(Equal(encodeStatic(fields)))
+		Expect(encoderStream.Len()).To(BeZero())
+	})
+
+	It("doesn't enable the dynamic table if the peer doesn't allow it", func() {
+		encoder.SetPeerSettings(0, 10, encoderStream)
+		Expect(encoder.Encode(0, fields)).To(Equal(encodeStatic(fields)))
+		Expect(encoderStream.Len()).To(BeZero())
+	})
+
+	It("uses the smaller capacity", func() {
+		encoder.SetPeerSettings(1024, 10, encoderStream)
+		Expect(encoder.table.capacity).To(BeEquivalentTo(1024))
+	})
+
+	It("inserts fields into the dynamic table, and references them", func() {
+		encoder.SetPeerSettings(1024, 10, encoderStream)
+		data := encoder.Encode(0, fields)
+		transfer()
+		Expect(decode(0, data)).To(Equal(fields))
+		Expect(decoder.table.insertCount()).To(BeEquivalentTo(3))
+
+		// the second request only references the dynamic table
+		data2 := encoder.Encode(4, fields)
+		Expect(len(data2)).To(BeNumerically("<", len(encodeStatic(fields))/4))
+		Expect(decode(4, data2)).To(Equal(fields))
+		transfer()
+		Expect(encoder.sections).To(BeEmpty())
+		Expect(encoder.references).To(BeEmpty())
+		Expect(encoder.knownReceivedCount).To(BeEquivalentTo(3))
+	})
+
+	It("doesn't insert fields that are not indexed", func() {
+		encoder.SetPeerSettings(1024, 10, encoderStream)
+		hfs := []HeaderField{{Name: "authorization", Value: "secret"}, {Name: "content-length", Value: "1337"}}
+		data := encoder.Encode(0, hfs)
+		Expect(encoder.table.insertCount()).To(BeZero())
+		transfer()
+		Expect(decode(0, data)).To(Equal(hfs))
+	})
+
+	It("doesn't block streams if the decoder doesn't allow it", func() {
+		encoder.SetPeerSettings(1024, 0, encoderStream)
+		decoder = NewDynamicDecoder(1024, 0)
+		decoder.SetDecoderStream(decoderStream)
+		data := encoder.Encode(0, fields)
+		// Entries are inserted, but not referenced until they are acknowledged.
+		Expect(encoder.table.insertCount()).To(BeEquivalentTo(3))
+		Expect(data[0]).To(BeZero()) // Required Insert Count
+		Expect(decode(0, data)).To(Equal(fields))
+		transfer()
+		Expect(encoder.knownReceivedCount).To(BeEquivalentTo(3))
+		data = encoder.Encode(4, fields)
+		Expect(data[0]).ToNot(BeZero())
+		Expect(decode(4, data)).To(Equal(fields))
+	})
+
+	It("limits the number of blocked streams", func() {
+		encoder.SetPeerSettings(1024, 1, encoderStream)
+		data1 := encoder.Encode(0, fields[:2])
+		Expect(data1[0]).ToNot(BeZero())
+		// The first stream is blocking. This stream may not block.
+		data2 := encoder.Encode(4, fields)
+		Expect(data2[0]).To(BeZero())
+		// Another field section on the first stream may block.
+		data3 := encoder.Encode(0, fields)
+		Expect(data3[0]).ToNot(BeZero())
+		transfer()
+		Expect(decode(0, data1)).To(Equal(fields[:2]))
+		Expect(decode(4, data2)).To(Equal(fields))
+		Expect(decode(0, data3)).To(Equal(fields))
+	})
+
+	It("doesn't evict entries that have unacknowledged references", func() {
+		// space for 2 entries
+		encoder.SetPeerSettings(120, 10, encoderStream)
+		decoder = NewDynamicDecoder(120, 10)
+		decoder.SetDecoderStream(decoderStream)
+		hf1 := HeaderField{Name: "foo", Value: "foobar"}
+		hf2 := HeaderField{Name: "bar", Value: "foobar"}
+		hf3 := HeaderField{Name: "baz", Value: "foobar"}
+		data1 := encoder.Encode(0, []HeaderField{hf1})
+		data2 := encoder.Encode(4, []HeaderField{hf2})
+		// hf1 can't be evicted, since it's referenced by an unacknowledged field section
+		data3 := encoder.Encode(8, []HeaderField{hf3})
+		Expect(encoder.table.insertCount()).To(BeEquivalentTo(2))
+		transfer()
+		Expect(decode(0, data1)).To(Equal([]HeaderField{hf1}))
+		Expect(decode(4, data2)).To(Equal([]HeaderField{hf2}))
+		Expect(decode(8, data3)).To(Equal([]HeaderField{hf3}))
+		transfer()
+		// now hf1 can be evicted
+		data4 := encoder.Encode(12, []HeaderField{hf3})
+		Expect(encoder.table.entries).To(Equal([]HeaderField{hf2, hf3}))
+		transfer()
+		Expect(decode(12, data4)).To(Equal([]HeaderField{hf3}))
+	})
+
+	It("releases references when a stream is canceled", func() {
+		encoder.SetPeerSettings(1024, 10, encoderStream)
+		encoder.Encode(0, fields)
+		Expect(encoder.references).ToNot(BeEmpty())
+		decoder.CancelStream(0)
+		transfer()
+		Expect(encoder.sections).To(BeEmpty())
+		Expect(encoder.references).To(BeEmpty())
+	})
+
+	It("rejects invalid instructions on the decoder stream", func() {
+		encoder.SetPeerSettings(1024, 10, encoderStream)
+		err := encoder.HandleDecoderStream(bytes.NewReader(appendInstruction(nil, sectionAckInstruction, 7, 4)))
+		Expect(err).To(MatchError("acknowledgement for a stream without unacknowledged field sections"))
+		err = encoder.HandleDecoderStream(bytes.NewReader(appendInstruction(nil, insertCountIncrementInstruction, 6, 1)))
+		Expect(err).To(MatchError("invalid Insert Count Increment"))
+	})
+})
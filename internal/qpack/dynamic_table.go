@@ -0,0 +1,73 @@
+package qpack
+
+// entryOverhead is the overhead of a dynamic table entry, see section 3.2.1 of RFC 9204.
+const entryOverhead = 32
+
+func entrySize(hf HeaderField) uint64 {
+	return uint64(len(hf.Name)+len(hf.Value)) + entryOverhead
+}
+
+// The dynamicTable is used by both the encoder and the decoder.
+// Entries are identified by their absolute index, see section 3.2.4 of RFC 9204.
+type dynamicTable struct {
+	entries  []HeaderField // the oldest entry first
+	size     uint64
+	capacity uint64
+	evicted  uint64 // the number of entries evicted so far, i.e. the absolute index of entries[0]
+}
+
+// insertCount is the total number of entries inserted into the table.
+func (t *dynamicTable) insertCount() uint64 {
+	return t.evicted + uint64(len(t.entries))
+}
+
+func (t *dynamicTable) get(absIndex uint64) (HeaderField, bool) {
+	if absIndex < t.evicted || absIndex >= t.insertCount() {
+		return HeaderField{}, false
+	}
+	return t.entries[absIndex-t.evicted], true
+}
+
+// fits says if an entry of the given size fits into the table,
+// after evicting all entries with an absolute index smaller than evictable.
+func (t *dynamicTable) fits(size, evictable uint64) bool {
+	if size > t.capacity {
+		return false
+	}
+	free := t.capacity - t.size
+	absIndex := t.evicted
+	for _, e := range t.entries {
+		if free >= size {
+			return true
+		}
+		if absIndex >= evictable {
+			return false
+		}
+		free += entrySize(e)
+		absIndex++
+	}
+	return free >= size
+}
+
+// evictFor evicts entries until an entry of the given size fits into the table.
+func (t *dynamicTable) evictFor(size uint64) {
+	for len(t.entries) > 0 && t.size+size > t.capacity {
+		t.size -= entrySize(t.entries[0])
+		t.entries[0] = HeaderField{}
+		t.entries = t.entries[1:]
+		t.evicted++
+	}
+}
+
+// insert inserts an entry. The caller must make sure that the entry fits.
+func (t *dynamicTable) insert(hf HeaderField) {
+	size := entrySize(hf)
+	t.evictFor(size)
+	t.entries = append(t.entries, hf)
+	t.size += size
+}
+
+func (t *dynamicTable) setCapacity(c uint64) {
+	t.capacity = c
+	t.evictFor(0)
+}
@@ -0,0 +1,19 @@
+// Package qpack implements QPACK header compression for HTTP/3, as specified in RFC 9204.
+// It is a fork of github.com/marten-seemann/qpack v0.2.1 (Copyright 2019 Marten Seemann, MIT License),
+// extended with support for the dynamic table.
+package qpack
+
+// A HeaderField is a name-value pair. Both the name and value are
+// treated as opaque sequences of octets.
+type HeaderField struct {
+	Name  string
+	Value string
+}
+
+// IsPseudo reports whether the header field is an HTTP3 pseudo header.
+// That is, it reports whether it starts with a colon.
+// It is not otherwise guaranteed to be a valid pseudo header field,
+// though.
+func (hf HeaderField) IsPseudo() bool {
+	return len(hf.Name) != 0 && hf.Name[0] == ':'
+}
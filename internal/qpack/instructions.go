@@ -0,0 +1,114 @@
+package qpack
+
+import (
+	"io"
+
+	"golang.org/x/net/http2/hpack"
+)
+
+// instructions on the encoder stream, see section 4.3 of RFC 9204
+const (
+	setCapacityInstruction           = 0x20 // 001xxxxx
+	insertWithNameRefInstruction     = 0x80 // 1Txxxxxx
+	insertWithLiteralNameInstruction = 0x40 // 01Hxxxxx
+	// duplicate (000xxxxx) is never sent by our encoder
+)
+
+// instructions on the decoder stream, see section 4.4 of RFC 9204
+const (
+	sectionAckInstruction           = 0x80 // 1xxxxxxx
+	streamCancellationInstruction   = 0x40 // 01xxxxxx
+	insertCountIncrementInstruction = 0x00 // 00xxxxxx
+)
+
+// appendInstruction appends an integer with an n-bit prefix, and sets the pattern bits of the first byte.
+func appendInstruction(dst []byte, pattern byte, n byte, i uint64) []byte {
+	offset := len(dst)
+	dst = appendVarInt(dst, n, i)
+	dst[offset] |= pattern
+	return dst
+}
+
+// appendStringLiteral appends a string literal with an n-bit length prefix.
+// The Huffman flag is the bit preceding the prefix. Huffman encoding is used if it's shorter.
+func appendStringLiteral(dst []byte, pattern byte, n byte, s string) []byte {
+	if l := hpack.HuffmanEncodeLength(s); l < uint64(len(s)) {
+		dst = appendInstruction(dst, pattern|1<<n, n, l)
+		return hpack.AppendHuffmanString(dst, s)
+	}
+	dst = appendInstruction(dst, pattern, n, uint64(len(s)))
+	return append(dst, s...)
+}
+
+// readString reads a string literal with an n-bit length prefix.
+// The Huffman flag is the bit preceding the prefix.
+func readString(buf []byte, n byte) (string, []byte, error) {
+	usesHuffman := buf[0]&(1<<n) > 0
+	l, buf, err := readVarInt(n, buf)
+	if err != nil {
+		return "", nil, err
+	}
+	if uint64(len(buf)) < l {
+		return "", nil, errNeedMore
+	}
+	var val string
+	if usesHuffman {
+		val, err = hpack.HuffmanDecodeToString(buf[:l])
+		if err != nil {
+			return "", nil, err
+		}
+	} else {
+		val = string(buf[:l])
+	}
+	return val, buf[l:], nil
+}
+
+// An instructionReader reads instructions from the encoder or decoder stream.
+type instructionReader interface {
+	io.Reader
+	io.ByteReader
+	Buffered() int
+}
+
+// readInt reads an integer with an n-bit prefix. The first byte was already read.
+func readInt(r io.ByteReader, first byte, n byte) (uint64, error) {
+	i := uint64(first) & (1<<n - 1)
+	if i < 1<<n-1 {
+		return i, nil
+	}
+	var m uint64
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		i += uint64(b&0x7f) << m
+		if b&0x80 == 0 {
+			return i, nil
+		}
+		m += 7
+		if m >= 63 {
+			return 0, errVarintOverflow
+		}
+	}
+}
+
+// readStringFrom reads a string literal with an n-bit length prefix from a stream.
+// The first byte was already read. Strings longer than maxLen are rejected.
+func readStringFrom(r instructionReader, first byte, n byte, maxLen uint64) (string, error) {
+	l, err := readInt(r, first, n)
+	if err != nil {
+		return "", err
+	}
+	if l > maxLen {
+		return "", errStringTooLong
+	}
+	b := make([]byte, l)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+	if first&(1<<n) > 0 {
+		return hpack.HuffmanDecodeToString(b)
+	}
+	return string(b), nil
+}
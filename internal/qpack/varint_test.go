@@ -0,0 +1,62 @@
+package qpack
+
+import (
+	"math"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Varints", func() {
+	It("encodes and decodes", func() {
+		for n := byte(1); n <= 8; n++ {
+			for _, i := range []uint64{0, 1, 1<<n - 2, 1<<n - 1, 1 << n, 1337, 1 << 40, math.MaxUint64 - 1, math.MaxUint64} {
+				b := appendVarInt(nil, n, i)
+				val, rest, err := readVarInt(n, append(b, 0x42))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(val).To(Equal(i))
+				Expect(rest).To(Equal([]byte{0x42}))
+			}
+		}
+	})
+
+	It("ignores the bits before the prefix", func() {
+		val, _, err := readVarInt(4, []byte{0xf0 | 5})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(val).To(BeEquivalentTo(5))
+	})
+
+	It("errors if there's not enough data", func() {
+		b := appendVarInt(nil, 5, 1337)
+		for i := 0; i < len(b); i++ {
+			_, rest, err := readVarInt(5, b[:i])
+			Expect(err).To(MatchError(errNeedMore))
+			Expect(rest).To(Equal(b[:i]))
+		}
+	})
+
+	It("errors on integers that don't fit into a uint64", func() {
+		b := appendVarInt(nil, 8, math.MaxUint64)
+		b[len(b)-1]++
+		_, rest, err := readVarInt(8, b)
+		Expect(err).To(MatchError(errVarintOverflow))
+		Expect(rest).To(Equal(b))
+	})
+
+	It("errors on integers with too many continuation bytes", func() {
+		b := []byte{0xff}
+		for i := 0; i < 10; i++ {
+			b = append(b, 0x80)
+		}
+		b = append(b, 0)
+		_, _, err := readVarInt(8, b)
+		Expect(err).To(MatchError(errVarintOverflow))
+	})
+
+	It("rejects invalid prefix lengths", func() {
+		_, _, err := readVarInt(0, []byte{0})
+		Expect(err).To(MatchError("invalid prefix length: 0"))
+		_, _, err = readVarInt(9, []byte{0})
+		Expect(err).To(MatchError("invalid prefix length: 9"))
+	})
+})
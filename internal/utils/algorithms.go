@@ -7,11 +7,17 @@ const (
 	ChooseSlowStart StartAlgo = iota + 1
 	ChooseHystart
 	ChooseHystartpp
+	ChoosePacedChirping
+	ChooseSearch
+	// ChooseAutoStart selects the start algorithm based on the path characteristics measured during the first round trip
+	ChooseAutoStart
 )
 type CongestionAlgo int
 const (
 	ChooseNewReno CongestionAlgo = iota + 1
 	ChooseCubic
+	// ChooseAutoCongestion selects the congestion control algorithm based on the path characteristics measured during the first round trip
+	ChooseAutoCongestion
 )
 
 //converts option string to start algo
@@ -24,6 +30,12 @@ func String2Start(nomAlgo string) StartAlgo {
 		return ChooseHystart
 	case "hystartpp", "hystart++", "hpp", "h++":
 		return ChooseHystartpp
+	case "pacedchirping", "chirping", "pc":
+		return ChoosePacedChirping
+	case "search":
+		return ChooseSearch
+	case "auto":
+		return ChooseAutoStart
 	default:
 		return ChooseHystart
 	}
@@ -37,7 +49,41 @@ func String2Congestion(nomAlgo string) CongestionAlgo {
 		return ChooseCubic
 	case "newreno", "reno", "nr":
 		return ChooseNewReno
+	case "auto":
+		return ChooseAutoCongestion
 	default:
 		return ChooseNewReno
 	}
-}
\ No newline at end of file
+}
+
+func (a StartAlgo) String() string {
+	switch a {
+	case ChooseSlowStart:
+		return "slowstart"
+	case ChooseHystart:
+		return "hystart"
+	case ChooseHystartpp:
+		return "hystart++"
+	case ChoosePacedChirping:
+		return "pacedchirping"
+	case ChooseSearch:
+		return "search"
+	case ChooseAutoStart:
+		return "auto"
+	default:
+		return "unknown start algorithm"
+	}
+}
+
+func (a CongestionAlgo) String() string {
+	switch a {
+	case ChooseNewReno:
+		return "newreno"
+	case ChooseCubic:
+		return "cubic"
+	case ChooseAutoCongestion:
+		return "auto"
+	default:
+		return "unknown congestion algorithm"
+	}
+}
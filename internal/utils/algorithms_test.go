@@ -0,0 +1,29 @@
+package utils
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Algorithms", func() {
+	It("parses start algorithms", func() {
+		Expect(String2Start("HyStart++")).To(Equal(ChooseHystartpp))
+		Expect(String2Start("auto")).To(Equal(ChooseAutoStart))
+		Expect(String2Start("foobar")).To(Equal(ChooseHystart))
+	})
+
+	It("parses congestion control algorithms", func() {
+		Expect(String2Congestion("cubic")).To(Equal(ChooseCubic))
+		Expect(String2Congestion("auto")).To(Equal(ChooseAutoCongestion))
+		Expect(String2Congestion("foobar")).To(Equal(ChooseNewReno))
+	})
+
+	It("has a string representation", func() {
+		for _, a := range []StartAlgo{ChooseSlowStart, ChooseHystart, ChooseHystartpp, ChoosePacedChirping, ChooseSearch, ChooseAutoStart} {
+			Expect(String2Start(a.String())).To(Equal(a))
+		}
+		for _, a := range []CongestionAlgo{ChooseNewReno, ChooseCubic, ChooseAutoCongestion} {
+			Expect(String2Congestion(a.String())).To(Equal(a))
+		}
+	})
+})
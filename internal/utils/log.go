@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -29,6 +30,9 @@ type Logger interface {
 	SetLogLevel(LogLevel)
 	SetLogTimeFormat(format string)
 	WithPrefix(prefix string) Logger
+	// WithFields returns a logger that attaches the fields to every message.
+	// The default logger only prints the formatted messages, the fields are only used by a LogSink.
+	WithFields(fields ...Field) Logger
 	Debug() bool
 
 	Errorf(format string, args ...interface{})
@@ -36,6 +40,21 @@ type Logger interface {
 	Debugf(format string, args ...interface{})
 }
 
+// A Field is a key-value pair attached to a log message, e.g. the connection ID or the packet number.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// A LogSink receives log messages with structured fields.
+// It allows backing the logger by a structured logging library.
+type LogSink interface {
+	// Enabled says if messages of the given level are logged.
+	// It is used to avoid formatting messages that would be discarded.
+	Enabled(LogLevel) bool
+	Log(level LogLevel, msg string, fields []Field)
+}
+
 // DefaultLogger is used by quic-go for logging.
 var DefaultLogger Logger
 
@@ -104,11 +123,208 @@ func (l *defaultLogger) WithPrefix(prefix string) Logger {
 	}
 }
 
+func (l *defaultLogger) WithFields(...Field) Logger {
+	return l
+}
+
+func (l *defaultLogger) withLogLevel(level LogLevel) Logger {
+	return &defaultLogger{
+		logLevel:   level,
+		timeFormat: l.timeFormat,
+		prefix:     l.prefix,
+	}
+}
+
 // Debug returns true if the log level is LogLevelDebug
 func (l *defaultLogger) Debug() bool {
 	return l.logLevel == LogLevelDebug
 }
 
+type sinkLogger struct {
+	sink     LogSink
+	logLevel LogLevel
+	prefix   string
+	fields   []Field
+}
+
+var _ Logger = &sinkLogger{}
+
+// NewSinkLogger creates a logger that passes all messages to the sink.
+// The prefix is passed as the "component" field.
+func NewSinkLogger(sink LogSink) Logger {
+	return &sinkLogger{sink: sink, logLevel: LogLevelDebug}
+}
+
+func (l *sinkLogger) SetLogLevel(level LogLevel) {
+	l.logLevel = level
+}
+
+// SetLogTimeFormat does nothing. Timestamps are added by the sink.
+func (l *sinkLogger) SetLogTimeFormat(string) {}
+
+func (l *sinkLogger) enabled(level LogLevel) bool {
+	return l.logLevel >= level && l.sink.Enabled(level)
+}
+
+func (l *sinkLogger) Debugf(format string, args ...interface{}) {
+	if l.enabled(LogLevelDebug) {
+		l.logMessage(LogLevelDebug, format, args...)
+	}
+}
+
+func (l *sinkLogger) Infof(format string, args ...interface{}) {
+	if l.enabled(LogLevelInfo) {
+		l.logMessage(LogLevelInfo, format, args...)
+	}
+}
+
+func (l *sinkLogger) Errorf(format string, args ...interface{}) {
+	if l.enabled(LogLevelError) {
+		l.logMessage(LogLevelError, format, args...)
+	}
+}
+
+func (l *sinkLogger) logMessage(level LogLevel, format string, args ...interface{}) {
+	fields := l.fields
+	if len(l.prefix) > 0 {
+		fields = make([]Field, 0, len(l.fields)+1)
+		fields = append(fields, Field{Key: "component", Value: l.prefix})
+		fields = append(fields, l.fields...)
+	}
+	l.sink.Log(level, fmt.Sprintf(format, args...), fields)
+}
+
+func (l *sinkLogger) WithPrefix(prefix string) Logger {
+	if len(l.prefix) > 0 {
+		prefix = l.prefix + " " + prefix
+	}
+	return &sinkLogger{
+		sink:     l.sink,
+		logLevel: l.logLevel,
+		prefix:   prefix,
+		fields:   l.fields,
+	}
+}
+
+func (l *sinkLogger) WithFields(fields ...Field) Logger {
+	f := make([]Field, 0, len(l.fields)+len(fields))
+	f = append(f, l.fields...)
+	f = append(f, fields...)
+	return &sinkLogger{
+		sink:     l.sink,
+		logLevel: l.logLevel,
+		prefix:   l.prefix,
+		fields:   f,
+	}
+}
+
+func (l *sinkLogger) Debug() bool {
+	return l.enabled(LogLevelDebug)
+}
+
+func (l *sinkLogger) withLogLevel(level LogLevel) Logger {
+	return &sinkLogger{
+		sink:     l.sink,
+		logLevel: level,
+		prefix:   l.prefix,
+		fields:   l.fields,
+	}
+}
+
+// A ConnectionLogger is a logger whose log level can be changed at runtime, independent of the logger it was created from.
+// It is safe to change the log level while other goroutines are logging.
+// All loggers derived from it (using WithPrefix and WithFields) share the log level.
+type ConnectionLogger struct {
+	base       Logger
+	unfiltered Logger // logs at LogLevelDebug, used when the log level is overridden
+	level      *int32 // the log level set by SetLogLevel, or -1 to use the log level of the base logger
+}
+
+var _ Logger = &ConnectionLogger{}
+
+// NewConnectionLogger creates a new ConnectionLogger.
+// Until SetLogLevel is called, it logs at the log level of the base logger.
+func NewConnectionLogger(base Logger) *ConnectionLogger {
+	unfiltered := base
+	if l, ok := base.(interface{ withLogLevel(LogLevel) Logger }); ok {
+		unfiltered = l.withLogLevel(LogLevelDebug)
+	}
+	level := int32(-1)
+	return &ConnectionLogger{
+		base:       base,
+		unfiltered: unfiltered,
+		level:      &level,
+	}
+}
+
+// SetLogLevel overrides the log level.
+func (l *ConnectionLogger) SetLogLevel(level LogLevel) {
+	atomic.StoreInt32(l.level, int32(level))
+}
+
+// ResetLogLevel makes the logger use the log level of the base logger again.
+func (l *ConnectionLogger) ResetLogLevel() {
+	atomic.StoreInt32(l.level, -1)
+}
+
+func (l *ConnectionLogger) SetLogTimeFormat(format string) {
+	l.base.SetLogTimeFormat(format)
+	l.unfiltered.SetLogTimeFormat(format)
+}
+
+// logger returns the logger used for messages of the given level, or nil if the message is not logged.
+func (l *ConnectionLogger) logger(level LogLevel) Logger {
+	override := atomic.LoadInt32(l.level)
+	if override < 0 {
+		return l.base
+	}
+	if LogLevel(override) >= level {
+		return l.unfiltered
+	}
+	return nil
+}
+
+func (l *ConnectionLogger) Debugf(format string, args ...interface{}) {
+	if logger := l.logger(LogLevelDebug); logger != nil {
+		logger.Debugf(format, args...)
+	}
+}
+
+func (l *ConnectionLogger) Infof(format string, args ...interface{}) {
+	if logger := l.logger(LogLevelInfo); logger != nil {
+		logger.Infof(format, args...)
+	}
+}
+
+func (l *ConnectionLogger) Errorf(format string, args ...interface{}) {
+	if logger := l.logger(LogLevelError); logger != nil {
+		logger.Errorf(format, args...)
+	}
+}
+
+func (l *ConnectionLogger) Debug() bool {
+	if logger := l.logger(LogLevelDebug); logger != nil {
+		return logger.Debug()
+	}
+	return false
+}
+
+func (l *ConnectionLogger) WithPrefix(prefix string) Logger {
+	return &ConnectionLogger{
+		base:       l.base.WithPrefix(prefix),
+		unfiltered: l.unfiltered.WithPrefix(prefix),
+		level:      l.level,
+	}
+}
+
+func (l *ConnectionLogger) WithFields(fields ...Field) Logger {
+	return &ConnectionLogger{
+		base:       l.base.WithFields(fields...),
+		unfiltered: l.unfiltered.WithFields(fields...),
+		level:      l.level,
+	}
+}
+
 func init() {
 	DefaultLogger = &defaultLogger{}
 	DefaultLogger.SetLogLevel(readLoggingEnv())
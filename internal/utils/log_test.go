@@ -10,6 +10,23 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+type loggedMessage struct {
+	level  LogLevel
+	msg    string
+	fields []Field
+}
+
+type recordingSink struct {
+	level    LogLevel
+	messages []loggedMessage
+}
+
+func (s *recordingSink) Enabled(level LogLevel) bool { return level <= s.level }
+
+func (s *recordingSink) Log(level LogLevel, msg string, fields []Field) {
+	s.messages = append(s.messages, loggedMessage{level: level, msg: msg, fields: fields})
+}
+
 var _ = Describe("Log", func() {
 	var b *bytes.Buffer
 
@@ -108,6 +125,92 @@ var _ = Describe("Log", func() {
 		Expect(b.String()).To(ContainSubstring("debug"))
 	})
 
+	It("ignores fields", func() {
+		DefaultLogger.SetLogLevel(LogLevelDebug)
+		DefaultLogger.SetLogTimeFormat("")
+		DefaultLogger.WithFields(Field{Key: "foo", Value: "bar"}).Debugf("debug")
+		Expect(b.String()).To(Equal("debug\n"))
+	})
+
+	Context("logging to a sink", func() {
+		var sink *recordingSink
+
+		BeforeEach(func() {
+			sink = &recordingSink{level: LogLevelInfo}
+		})
+
+		It("passes messages to the sink", func() {
+			logger := NewSinkLogger(sink)
+			logger.Infof("foo %d", 42)
+			logger.Debugf("debug")
+			Expect(sink.messages).To(Equal([]loggedMessage{{level: LogLevelInfo, msg: "foo 42"}}))
+			Expect(logger.Debug()).To(BeFalse())
+			sink.level = LogLevelDebug
+			Expect(logger.Debug()).To(BeTrue())
+			logger.SetLogLevel(LogLevelError)
+			Expect(logger.Debug()).To(BeFalse())
+			logger.Infof("info")
+			Expect(sink.messages).To(HaveLen(1))
+		})
+
+		It("passes fields and prefixes", func() {
+			logger := NewSinkLogger(sink).WithPrefix("server").WithFields(Field{Key: "conn_id", Value: "deadbeef"})
+			logger.WithFields(Field{Key: "packet_number", Value: 1337}).Infof("packet")
+			logger.WithPrefix("h3").Errorf("error")
+			Expect(sink.messages).To(Equal([]loggedMessage{
+				{
+					level:  LogLevelInfo,
+					msg:    "packet",
+					fields: []Field{{Key: "component", Value: "server"}, {Key: "conn_id", Value: "deadbeef"}, {Key: "packet_number", Value: 1337}},
+				},
+				{
+					level:  LogLevelError,
+					msg:    "error",
+					fields: []Field{{Key: "component", Value: "server h3"}, {Key: "conn_id", Value: "deadbeef"}},
+				},
+			}))
+		})
+	})
+
+	Context("connection loggers", func() {
+		BeforeEach(func() {
+			DefaultLogger.SetLogLevel(LogLevelInfo)
+			DefaultLogger.SetLogTimeFormat("")
+		})
+
+		It("uses the log level of the base logger", func() {
+			logger := NewConnectionLogger(DefaultLogger.WithPrefix("conn"))
+			Expect(logger.Debug()).To(BeFalse())
+			logger.Debugf("debug")
+			logger.Infof("info")
+			Expect(b.String()).To(Equal("conn info\n"))
+		})
+
+		It("overrides the log level", func() {
+			logger := NewConnectionLogger(DefaultLogger.WithPrefix("conn"))
+			derived := logger.WithPrefix("sub")
+			logger.SetLogLevel(LogLevelDebug)
+			Expect(logger.Debug()).To(BeTrue())
+			Expect(derived.Debug()).To(BeTrue())
+			derived.Debugf("debug")
+			Expect(b.String()).To(Equal("conn sub debug\n"))
+			b.Reset()
+			logger.SetLogLevel(LogLevelNothing)
+			derived.Errorf("error")
+			Expect(b.String()).To(BeEmpty())
+			logger.ResetLogLevel()
+			derived.Errorf("error")
+			derived.Debugf("debug")
+			Expect(b.String()).To(Equal("conn sub error\n"))
+		})
+
+		It("doesn't change the log level of the base logger", func() {
+			logger := NewConnectionLogger(DefaultLogger)
+			logger.SetLogLevel(LogLevelDebug)
+			Expect(DefaultLogger.Debug()).To(BeFalse())
+		})
+	})
+
 	Context("reading from env", func() {
 		BeforeEach(func() {
 			Expect(DefaultLogger.(*defaultLogger).logLevel).To(Equal(LogLevelNothing))
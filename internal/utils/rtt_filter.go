@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// An RTTFilter selects how RTT samples are filtered before they are used by delay-based slow start algorithms.
+type RTTFilter uint8
+
+const (
+	// RTTFilterNone uses the latest RTT sample.
+	RTTFilterNone RTTFilter = iota
+	// RTTFilterMedian uses the median of the most recent RTT samples.
+	RTTFilterMedian
+	// RTTFilterKalman uses a one-dimensional Kalman filter.
+	// Innovations larger than 3 standard deviations are clipped, such that a single spike only has a small effect.
+	RTTFilterKalman
+)
+
+const (
+	// the number of samples used by the median filter, if not configured
+	defaultRTTFilterWindow = 5
+	// The standard deviations of the process noise (the change of the path RTT between two samples)
+	// and of the measurement noise of the Kalman filter, relative to the current estimate.
+	rttKalmanProcessNoise     = 0.05
+	rttKalmanMeasurementNoise = 0.25
+	// innovations are clipped to this number of standard deviations
+	rttKalmanMaxInnovation = 3
+)
+
+type rttFilter struct {
+	filter RTTFilter
+
+	// median filter: the most recent samples, in a ring buffer
+	window  int
+	samples []time.Duration
+	next    int
+
+	// Kalman filter: the estimate and its variance, in nanoseconds
+	estimate float64
+	variance float64
+
+	filtered time.Duration
+}
+
+func (f *rttFilter) reset() {
+	f.samples = f.samples[:0]
+	f.next = 0
+	f.estimate = 0
+	f.variance = 0
+	f.filtered = 0
+}
+
+func (f *rttFilter) update(sample time.Duration) {
+	switch f.filter {
+	case RTTFilterMedian:
+		f.updateMedian(sample)
+	case RTTFilterKalman:
+		f.updateKalman(sample)
+	}
+}
+
+func (f *rttFilter) updateMedian(sample time.Duration) {
+	if len(f.samples) < f.window {
+		f.samples = append(f.samples, sample)
+	} else {
+		f.samples[f.next] = sample
+		f.next = (f.next + 1) % f.window
+	}
+	sorted := make([]time.Duration, len(f.samples))
+	copy(sorted, f.samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	f.filtered = sorted[len(sorted)/2]
+}
+
+func (f *rttFilter) updateKalman(sample time.Duration) {
+	z := float64(sample)
+	if f.estimate == 0 {
+		f.estimate = z
+		f.variance = (z / 2) * (z / 2)
+		f.filtered = sample
+		return
+	}
+	processNoise := rttKalmanProcessNoise * f.estimate
+	measurementNoise := rttKalmanMeasurementNoise * f.estimate
+	// predict
+	f.variance += processNoise * processNoise
+	// update
+	innovationVariance := f.variance + measurementNoise*measurementNoise
+	maxInnovation := rttKalmanMaxInnovation * math.Sqrt(innovationVariance)
+	innovation := math.Max(-maxInnovation, math.Min(maxInnovation, z-f.estimate))
+	gain := f.variance / innovationVariance
+	f.estimate += gain * innovation
+	f.variance *= 1 - gain
+	f.filtered = time.Duration(f.estimate)
+}
@@ -25,6 +25,20 @@ type RTTStats struct {
 	meanDeviation time.Duration
 
 	maxAckDelay time.Duration
+
+	lastSampleTime time.Time
+
+	filter rttFilter
+
+	// min RTT aging: the min RTT expires minRTTExpiry after it was measured.
+	// The sender then probes for a new min RTT until probeMinRTTEnd.
+	minRTTExpiry   time.Duration
+	probeDuration  time.Duration
+	minRTTTime     time.Time
+	probeMinRTT    time.Duration // the min RTT measured during the current probe
+	probeMinRTTEnd time.Time
+
+	onUpdate func(smoothed, latest, min time.Duration)
 }
 
 // NewRTTStats makes a properly initialized RTTStats object
@@ -50,6 +64,45 @@ func (r *RTTStats) MeanDeviation() time.Duration { return r.meanDeviation }
 // MaxAckDelay gets the max_ack_delay advertised by the peer
 func (r *RTTStats) MaxAckDelay() time.Duration { return r.maxAckDelay }
 
+// LastSampleTime returns the time when the most recent RTT sample was taken.
+// It returns the zero value if no valid updates have occurred.
+func (r *RTTStats) LastSampleTime() time.Time { return r.lastSampleTime }
+
+// SetMinRTTExpiry sets the time after which the min RTT expires. 0 means that it never expires.
+// When the min RTT expires, the sender briefly probes for a new min RTT (see ProbingMinRTT),
+// such that the min RTT follows increases of the path RTT, e.g. after a route change.
+func (r *RTTStats) SetMinRTTExpiry(expiry time.Duration) { r.minRTTExpiry = expiry }
+
+// SetRTTFilter sets the filter applied to the RTT samples returned by FilteredRTT.
+// For the median filter, window is the number of samples. If it is 0, 5 samples are used.
+func (r *RTTStats) SetRTTFilter(filter RTTFilter, window int) {
+	if window <= 0 {
+		window = defaultRTTFilterWindow
+	}
+	r.filter = rttFilter{filter: filter, window: window}
+}
+
+// FilteredRTT returns the most recent RTT sample, filtered by the filter set by SetRTTFilter.
+// Without a filter, it is the LatestRTT.
+// May return Zero if no valid updates have occurred.
+func (r *RTTStats) FilteredRTT() time.Duration {
+	if r.filter.filter == RTTFilterNone {
+		return r.latestRTT
+	}
+	return r.filter.filtered
+}
+
+// SetUpdateCallback sets a callback that is called for every RTT sample, after the statistics were updated.
+func (r *RTTStats) SetUpdateCallback(cb func(smoothed, latest, min time.Duration)) { r.onUpdate = cb }
+
+// SetMinRTTProbeDuration sets the duration of the probe for a new min RTT.
+// The probe lasts at least one smoothed RTT. 0 means that the default duration of 200ms is used.
+func (r *RTTStats) SetMinRTTProbeDuration(d time.Duration) { r.probeDuration = d }
+
+// ProbingMinRTT says if the min RTT expired, and the sender is probing for a new min RTT.
+// While probing, the sender should reduce the bytes in flight, to drain the queue at the bottleneck.
+func (r *RTTStats) ProbingMinRTT() bool { return !r.probeMinRTTEnd.IsZero() }
+
 // PTO gets the probe timeout duration.
 func (r *RTTStats) PTO(includeMaxAckDelay bool) time.Duration {
 	if r.SmoothedRTT() == 0 {
@@ -67,6 +120,7 @@ func (r *RTTStats) UpdateRTT(sendDelta, ackDelay time.Duration, now time.Time) {
 	if sendDelta == InfDuration || sendDelta <= 0 {
 		return
 	}
+	r.lastSampleTime = now
 
 	// Update r.minRTT first. r.minRTT does not use an rttSample corrected for
 	// ackDelay but the raw observed sendDelta, since poor clock granularity at
@@ -74,6 +128,10 @@ func (r *RTTStats) UpdateRTT(sendDelta, ackDelay time.Duration, now time.Time) {
 	// r.minRTT.
 	if r.minRTT == 0 || r.minRTT > sendDelta {
 		r.minRTT = sendDelta
+		r.minRTTTime = now
+	}
+	if r.minRTTExpiry > 0 {
+		r.maybeExpireMinRTT(sendDelta, now)
 	}
 
 	// Correct for ackDelay if information received from the peer results in a
@@ -84,6 +142,7 @@ func (r *RTTStats) UpdateRTT(sendDelta, ackDelay time.Duration, now time.Time) {
 		sample -= ackDelay
 	}
 	r.latestRTT = sample
+	r.filter.update(sample)
 	// First time call.
 	if !r.hasMeasurement {
 		r.hasMeasurement = true
@@ -93,6 +152,31 @@ func (r *RTTStats) UpdateRTT(sendDelta, ackDelay time.Duration, now time.Time) {
 		r.meanDeviation = time.Duration(oneMinusBeta*float32(r.meanDeviation/time.Microsecond)+rttBeta*float32(AbsDuration(r.smoothedRTT-sample)/time.Microsecond)) * time.Microsecond
 		r.smoothedRTT = time.Duration((float32(r.smoothedRTT/time.Microsecond)*oneMinusAlpha)+(float32(sample/time.Microsecond)*rttAlpha)) * time.Microsecond
 	}
+	if r.onUpdate != nil {
+		r.onUpdate(r.smoothedRTT, r.latestRTT, r.minRTT)
+	}
+}
+
+func (r *RTTStats) maybeExpireMinRTT(sendDelta time.Duration, now time.Time) {
+	if r.ProbingMinRTT() {
+		r.probeMinRTT = MinDuration(r.probeMinRTT, sendDelta)
+		if now.Before(r.probeMinRTTEnd) {
+			return
+		}
+		// The probe is done. The min RTT measured during the probe replaces the expired min RTT.
+		r.minRTT = r.probeMinRTT
+		r.minRTTTime = now
+		r.probeMinRTTEnd = time.Time{}
+		return
+	}
+	if now.Sub(r.minRTTTime) > r.minRTTExpiry {
+		r.probeMinRTT = sendDelta
+		probeDuration := protocol.MinRTTProbeDuration
+		if r.probeDuration > 0 {
+			probeDuration = r.probeDuration
+		}
+		r.probeMinRTTEnd = now.Add(MaxDuration(probeDuration, r.smoothedRTT))
+	}
 }
 
 // SetMaxAckDelay sets the max_ack_delay
@@ -116,6 +200,8 @@ func (r *RTTStats) OnConnectionMigration() {
 	r.minRTT = 0
 	r.smoothedRTT = 0
 	r.meanDeviation = 0
+	r.probeMinRTTEnd = time.Time{}
+	r.filter.reset()
 }
 
 // ExpireSmoothedMetrics causes the smoothed_rtt to be increased to the latest_rtt if the latest_rtt
@@ -52,6 +52,61 @@ var _ = Describe("RTT stats", func() {
 		Expect(rttStats.MinRTT()).To(Equal((7 * time.Millisecond)))
 	})
 
+	It("doesn't expire the min RTT by default", func() {
+		now := time.Now()
+		rttStats.UpdateRTT(10*time.Millisecond, 0, now)
+		rttStats.UpdateRTT(50*time.Millisecond, 0, now.Add(time.Hour))
+		Expect(rttStats.ProbingMinRTT()).To(BeFalse())
+		Expect(rttStats.MinRTT()).To(Equal(10 * time.Millisecond))
+	})
+
+	It("expires the min RTT and probes for a new min RTT", func() {
+		rttStats.SetMinRTTExpiry(10 * time.Second)
+		now := time.Now()
+		rttStats.UpdateRTT(10*time.Millisecond, 0, now)
+		rttStats.UpdateRTT(50*time.Millisecond, 0, now.Add(10*time.Second))
+		Expect(rttStats.ProbingMinRTT()).To(BeFalse())
+		// the min RTT expires
+		now = now.Add(10*time.Second + time.Millisecond)
+		rttStats.UpdateRTT(50*time.Millisecond, 0, now)
+		Expect(rttStats.ProbingMinRTT()).To(BeTrue())
+		Expect(rttStats.MinRTT()).To(Equal(10 * time.Millisecond))
+		rttStats.UpdateRTT(40*time.Millisecond, 0, now.Add(protocol.MinRTTProbeDuration/2))
+		rttStats.UpdateRTT(45*time.Millisecond, 0, now.Add(protocol.MinRTTProbeDuration))
+		Expect(rttStats.ProbingMinRTT()).To(BeFalse())
+		Expect(rttStats.MinRTT()).To(Equal(40 * time.Millisecond))
+		// a lower RTT is taken into account immediately
+		rttStats.UpdateRTT(30*time.Millisecond, 0, now.Add(time.Second))
+		Expect(rttStats.MinRTT()).To(Equal(30 * time.Millisecond))
+	})
+
+	It("uses the configured probe duration", func() {
+		rttStats.SetMinRTTExpiry(10 * time.Second)
+		rttStats.SetMinRTTProbeDuration(time.Second)
+		now := time.Now()
+		rttStats.UpdateRTT(10*time.Millisecond, 0, now)
+		now = now.Add(11 * time.Second)
+		rttStats.UpdateRTT(10*time.Millisecond, 0, now)
+		Expect(rttStats.ProbingMinRTT()).To(BeTrue())
+		rttStats.UpdateRTT(10*time.Millisecond, 0, now.Add(protocol.MinRTTProbeDuration))
+		Expect(rttStats.ProbingMinRTT()).To(BeTrue())
+		rttStats.UpdateRTT(10*time.Millisecond, 0, now.Add(time.Second))
+		Expect(rttStats.ProbingMinRTT()).To(BeFalse())
+	})
+
+	It("probes for at least one RTT", func() {
+		rttStats.SetMinRTTExpiry(10 * time.Second)
+		now := time.Now()
+		rttStats.UpdateRTT(time.Second, 0, now)
+		now = now.Add(11 * time.Second)
+		rttStats.UpdateRTT(time.Second, 0, now)
+		Expect(rttStats.ProbingMinRTT()).To(BeTrue())
+		rttStats.UpdateRTT(time.Second, 0, now.Add(protocol.MinRTTProbeDuration))
+		Expect(rttStats.ProbingMinRTT()).To(BeTrue())
+		rttStats.UpdateRTT(time.Second, 0, now.Add(time.Second))
+		Expect(rttStats.ProbingMinRTT()).To(BeFalse())
+	})
+
 	It("MaxAckDelay", func() {
 		rttStats.SetMaxAckDelay(42 * time.Minute)
 		Expect(rttStats.MaxAckDelay()).To(Equal(42 * time.Minute))
@@ -126,6 +181,83 @@ var _ = Describe("RTT stats", func() {
 		}
 	})
 
+	It("stores the time of the last sample", func() {
+		Expect(rttStats.LastSampleTime()).To(BeZero())
+		now := time.Now()
+		rttStats.UpdateRTT(10*time.Millisecond, 0, now)
+		Expect(rttStats.LastSampleTime()).To(Equal(now))
+		// invalid samples are ignored
+		rttStats.UpdateRTT(0, 0, now.Add(time.Second))
+		Expect(rttStats.LastSampleTime()).To(Equal(now))
+	})
+
+	Context("filtering", func() {
+		It("doesn't filter the RTT by default", func() {
+			rttStats.UpdateRTT(10*time.Millisecond, 0, time.Time{})
+			rttStats.UpdateRTT(100*time.Millisecond, 0, time.Time{})
+			Expect(rttStats.FilteredRTT()).To(Equal(100 * time.Millisecond))
+		})
+
+		It("uses the median of the most recent samples", func() {
+			rttStats.SetRTTFilter(RTTFilterMedian, 3)
+			rttStats.UpdateRTT(10*time.Millisecond, 0, time.Time{})
+			Expect(rttStats.FilteredRTT()).To(Equal(10 * time.Millisecond))
+			// a single spike doesn't change the median
+			rttStats.UpdateRTT(100*time.Millisecond, 0, time.Time{})
+			rttStats.UpdateRTT(12*time.Millisecond, 0, time.Time{})
+			Expect(rttStats.FilteredRTT()).To(Equal(12 * time.Millisecond))
+			rttStats.UpdateRTT(11*time.Millisecond, 0, time.Time{})
+			Expect(rttStats.FilteredRTT()).To(Equal(12 * time.Millisecond))
+			// the spike has left the window
+			rttStats.UpdateRTT(13*time.Millisecond, 0, time.Time{})
+			Expect(rttStats.FilteredRTT()).To(Equal(12 * time.Millisecond))
+			rttStats.UpdateRTT(9*time.Millisecond, 0, time.Time{})
+			Expect(rttStats.FilteredRTT()).To(Equal(11 * time.Millisecond))
+		})
+
+		It("uses 5 samples for the median by default", func() {
+			rttStats.SetRTTFilter(RTTFilterMedian, 0)
+			for _, rtt := range []time.Duration{50, 10, 20, 40, 30} {
+				rttStats.UpdateRTT(rtt*time.Millisecond, 0, time.Time{})
+			}
+			Expect(rttStats.FilteredRTT()).To(Equal(30 * time.Millisecond))
+			rttStats.UpdateRTT(60*time.Millisecond, 0, time.Time{}) // replaces the 50ms sample
+			Expect(rttStats.FilteredRTT()).To(Equal(30 * time.Millisecond))
+		})
+
+		It("limits the effect of a spike with the Kalman filter", func() {
+			rttStats.SetRTTFilter(RTTFilterKalman, 0)
+			for i := 0; i < 20; i++ {
+				rttStats.UpdateRTT(10*time.Millisecond, 0, time.Time{})
+			}
+			Expect(rttStats.FilteredRTT()).To(BeNumerically("~", 10*time.Millisecond, 10*time.Microsecond))
+			// HyStart would exit slow start at an RTT of 14ms
+			rttStats.UpdateRTT(100*time.Millisecond, 0, time.Time{})
+			Expect(rttStats.FilteredRTT()).To(BeNumerically("<", 12*time.Millisecond))
+		})
+
+		It("follows a sustained RTT increase with the Kalman filter", func() {
+			rttStats.SetRTTFilter(RTTFilterKalman, 0)
+			for i := 0; i < 20; i++ {
+				rttStats.UpdateRTT(10*time.Millisecond, 0, time.Time{})
+			}
+			for i := 0; i < 50; i++ {
+				rttStats.UpdateRTT(20*time.Millisecond, 0, time.Time{})
+			}
+			Expect(rttStats.FilteredRTT()).To(BeNumerically("~", 20*time.Millisecond, time.Millisecond))
+		})
+
+		It("resets the filter on connection migration", func() {
+			rttStats.SetRTTFilter(RTTFilterMedian, 3)
+			rttStats.UpdateRTT(100*time.Millisecond, 0, time.Time{})
+			rttStats.UpdateRTT(100*time.Millisecond, 0, time.Time{})
+			rttStats.OnConnectionMigration()
+			Expect(rttStats.FilteredRTT()).To(BeZero())
+			rttStats.UpdateRTT(10*time.Millisecond, 0, time.Time{})
+			Expect(rttStats.FilteredRTT()).To(Equal(10 * time.Millisecond))
+		})
+	})
+
 	It("ResetAfterConnectionMigrations", func() {
 		rttStats.UpdateRTT(200*time.Millisecond, 0, time.Time{})
 		Expect(rttStats.LatestRTT()).To(Equal((200 * time.Millisecond)))
@@ -143,6 +275,16 @@ var _ = Describe("RTT stats", func() {
 		Expect(rttStats.MinRTT()).To(Equal(time.Duration(0)))
 	})
 
+	It("calls the update callback", func() {
+		var smoothed, latest, min time.Duration
+		rttStats.SetUpdateCallback(func(s, l, m time.Duration) { smoothed, latest, min = s, l, m })
+		rttStats.UpdateRTT(200*time.Millisecond, 0, time.Time{})
+		rttStats.UpdateRTT(100*time.Millisecond, 0, time.Time{})
+		Expect(smoothed).To(Equal(rttStats.SmoothedRTT()))
+		Expect(latest).To(Equal(100 * time.Millisecond))
+		Expect(min).To(Equal(100 * time.Millisecond))
+	})
+
 	It("restores the RTT", func() {
 		rttStats.SetInitialRTT(10 * time.Second)
 		Expect(rttStats.LatestRTT()).To(Equal(10 * time.Second))
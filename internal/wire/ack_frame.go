@@ -163,16 +163,29 @@ func (f *AckFrame) Length(version protocol.VersionNumber) protocol.ByteCount {
 	return length
 }
 
+// Truncate removes the lowest ACK ranges, such that the frame is smaller than maxSize.
+// It returns the number of ranges that were removed.
+func (f *AckFrame) Truncate(maxSize protocol.ByteCount) int {
+	numRanges := f.numEncodableAckRangesWithin(maxSize)
+	omitted := len(f.AckRanges) - numRanges
+	f.AckRanges = f.AckRanges[:numRanges]
+	return omitted
+}
+
 // gets the number of ACK ranges that can be encoded
 // such that the resulting frame is smaller than the maximum ACK frame size
 func (f *AckFrame) numEncodableAckRanges() int {
+	return f.numEncodableAckRangesWithin(protocol.MaxAckFrameSize)
+}
+
+func (f *AckFrame) numEncodableAckRangesWithin(maxSize protocol.ByteCount) int {
 	length := 1 + quicvarint.Len(uint64(f.LargestAcked())) + quicvarint.Len(encodeAckDelay(f.DelayTime))
 	length += 2 // assume that the number of ranges will consume 2 bytes
 	for i := 1; i < len(f.AckRanges); i++ {
 		gap, len := f.encodeAckRange(i)
 		rangeLen := quicvarint.Len(gap) + quicvarint.Len(len)
-		if length+rangeLen > protocol.MaxAckFrameSize {
-			// Writing range i would exceed the MaxAckFrameSize.
+		if length+rangeLen > maxSize {
+			// Writing range i would exceed the maximum size.
 			// So encode one range less than that.
 			return i - 1
 		}
@@ -330,6 +330,30 @@ var _ = Describe("ACK Frame (for IETF QUIC)", func() {
 			Expect(b.Len()).To(BeZero())
 			Expect(len(frame.AckRanges)).To(BeNumerically("<", numRanges)) // make sure we dropped some ranges
 		})
+
+		It("truncates the ACK frame to a smaller size", func() {
+			const numRanges = 100
+			ackRanges := make([]AckRange, numRanges)
+			for i := protocol.PacketNumber(1); i <= numRanges; i++ {
+				ackRanges[numRanges-i] = AckRange{Smallest: 2 * i, Largest: 2 * i}
+			}
+			f := &AckFrame{AckRanges: ackRanges}
+			omitted := f.Truncate(100)
+			Expect(omitted).To(BeNumerically(">", 0))
+			Expect(f.AckRanges).To(HaveLen(numRanges - omitted))
+			Expect(f.AckRanges[0]).To(Equal(ackRanges[0]))
+			Expect(f.Length(versionIETFFrames)).To(BeNumerically("<=", 100))
+			Expect(f.Length(versionIETFFrames)).To(BeNumerically(">", 95))
+			buf := &bytes.Buffer{}
+			Expect(f.Write(buf, versionIETFFrames)).To(Succeed())
+			Expect(f.Length(versionIETFFrames)).To(BeEquivalentTo(buf.Len()))
+		})
+
+		It("doesn't truncate an ACK frame that is small enough", func() {
+			f := &AckFrame{AckRanges: []AckRange{{Smallest: 10, Largest: 12}, {Smallest: 2, Largest: 4}}}
+			Expect(f.Truncate(protocol.MaxAckFrameSize)).To(BeZero())
+			Expect(f.AckRanges).To(HaveLen(2))
+		})
 	})
 
 	Context("ACK range validator", func() {
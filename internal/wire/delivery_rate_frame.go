@@ -0,0 +1,62 @@
+package wire
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/quicvarint"
+)
+
+// The frame type of the (experimental) DELIVERY_RATE frame.
+// It is not registered with IANA, and only understood by peers running this fork.
+const deliveryRateFrameType = 0xd7e1a0
+
+// A DeliveryRateFrame is a DELIVERY_RATE frame.
+// The receiver uses it to report the goodput it measured over the last interval.
+type DeliveryRateFrame struct {
+	SequenceNumber uint64
+	Rate           uint64 // in bytes/s
+	Interval       time.Duration
+}
+
+func parseDeliveryRateFrame(r *bytes.Reader, _ protocol.VersionNumber) (*DeliveryRateFrame, error) {
+	frameType, err := quicvarint.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	if frameType != deliveryRateFrameType {
+		return nil, errors.New("not a DELIVERY_RATE frame")
+	}
+	seq, err := quicvarint.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	rate, err := quicvarint.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	interval, err := quicvarint.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	return &DeliveryRateFrame{
+		SequenceNumber: seq,
+		Rate:           rate,
+		Interval:       time.Duration(interval) * time.Microsecond,
+	}, nil
+}
+
+func (f *DeliveryRateFrame) Write(b *bytes.Buffer, _ protocol.VersionNumber) error {
+	quicvarint.Write(b, deliveryRateFrameType)
+	quicvarint.Write(b, f.SequenceNumber)
+	quicvarint.Write(b, f.Rate)
+	quicvarint.Write(b, uint64(f.Interval/time.Microsecond))
+	return nil
+}
+
+// Length of a written frame
+func (f *DeliveryRateFrame) Length(_ protocol.VersionNumber) protocol.ByteCount {
+	return quicvarint.Len(deliveryRateFrameType) + quicvarint.Len(f.SequenceNumber) + quicvarint.Len(f.Rate) + quicvarint.Len(uint64(f.Interval/time.Microsecond))
+}
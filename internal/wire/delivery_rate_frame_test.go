@@ -0,0 +1,58 @@
+package wire
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/quicvarint"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DELIVERY_RATE frame", func() {
+	Context("parsing", func() {
+		It("accepts a sample frame", func() {
+			data := encodeVarInt(0xd7e1a0)
+			data = append(data, encodeVarInt(0x1337)...)     // sequence number
+			data = append(data, encodeVarInt(0xdecafbad)...) // rate
+			data = append(data, encodeVarInt(25000)...)      // interval, in µs
+			b := bytes.NewReader(data)
+			f, err := parseDeliveryRateFrame(b, protocol.VersionWhatever)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.SequenceNumber).To(BeEquivalentTo(0x1337))
+			Expect(f.Rate).To(BeEquivalentTo(0xdecafbad))
+			Expect(f.Interval).To(Equal(25 * time.Millisecond))
+			Expect(b.Len()).To(BeZero())
+		})
+
+		It("errors on EOFs", func() {
+			data := encodeVarInt(0xd7e1a0)
+			data = append(data, encodeVarInt(0x1337)...)
+			data = append(data, encodeVarInt(0xdecafbad)...)
+			data = append(data, encodeVarInt(25000)...)
+			_, err := parseDeliveryRateFrame(bytes.NewReader(data), protocol.VersionWhatever)
+			Expect(err).NotTo(HaveOccurred())
+			for i := range data {
+				_, err := parseDeliveryRateFrame(bytes.NewReader(data[0:i]), protocol.VersionWhatever)
+				Expect(err).To(HaveOccurred())
+			}
+		})
+	})
+
+	Context("writing", func() {
+		It("writes a sample frame", func() {
+			f := &DeliveryRateFrame{SequenceNumber: 0x1337, Rate: 0xdecafbad, Interval: 25 * time.Millisecond}
+			b := &bytes.Buffer{}
+			Expect(f.Write(b, protocol.VersionWhatever)).To(Succeed())
+			expected := encodeVarInt(0xd7e1a0)
+			expected = append(expected, encodeVarInt(0x1337)...)
+			expected = append(expected, encodeVarInt(0xdecafbad)...)
+			expected = append(expected, encodeVarInt(25000)...)
+			Expect(b.Bytes()).To(Equal(expected))
+			Expect(f.Length(protocol.VersionWhatever)).To(BeEquivalentTo(b.Len()))
+			Expect(f.Length(protocol.VersionWhatever)).To(Equal(quicvarint.Len(0xd7e1a0) + 2 + 8 + 4))
+		})
+	})
+})
@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
 
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/qerr"
+	"github.com/lucas-clemente/quic-go/quicvarint"
 )
 
 type frameParser struct {
@@ -36,10 +38,20 @@ func (p *frameParser) ParseNext(r *bytes.Reader, encLevel protocol.EncryptionLev
 		}
 		r.UnreadByte()
 
-		f, err := p.parseFrame(r, typeByte, encLevel)
+		// frame types of extensions are encoded in more than one byte
+		frameType := uint64(typeByte)
+		if typeByte&0xc0 != 0 {
+			startLen := r.Len()
+			if t, err := quicvarint.Read(r); err == nil {
+				frameType = t
+			}
+			r.Seek(int64(r.Len()-startLen), io.SeekCurrent)
+		}
+
+		f, err := p.parseFrame(r, typeByte, frameType, encLevel)
 		if err != nil {
 			return nil, &qerr.TransportError{
-				FrameType:    uint64(typeByte),
+				FrameType:    frameType,
 				ErrorCode:    qerr.FrameEncodingError,
 				ErrorMessage: err.Error(),
 			}
@@ -49,7 +61,7 @@ func (p *frameParser) ParseNext(r *bytes.Reader, encLevel protocol.EncryptionLev
 	return nil, nil
 }
 
-func (p *frameParser) parseFrame(r *bytes.Reader, typeByte byte, encLevel protocol.EncryptionLevel) (Frame, error) {
+func (p *frameParser) parseFrame(r *bytes.Reader, typeByte byte, frameType uint64, encLevel protocol.EncryptionLevel) (Frame, error) {
 	var frame Frame
 	var err error
 	if typeByte&0xf8 == 0x8 {
@@ -103,6 +115,10 @@ func (p *frameParser) parseFrame(r *bytes.Reader, typeByte byte, encLevel protoc
 			}
 			fallthrough
 		default:
+			if typeByte&0xc0 != 0 {
+				frame, err = p.parseExtensionFrame(r, frameType)
+				break
+			}
 			err = errors.New("unknown frame type")
 		}
 	}
@@ -115,6 +131,17 @@ func (p *frameParser) parseFrame(r *bytes.Reader, typeByte byte, encLevel protoc
 	return frame, nil
 }
 
+func (p *frameParser) parseExtensionFrame(r *bytes.Reader, frameType uint64) (Frame, error) {
+	switch frameType {
+	case observedAddressIPv4FrameType, observedAddressIPv6FrameType:
+		return parseObservedAddressFrame(r, p.version)
+	case deliveryRateFrameType:
+		return parseDeliveryRateFrame(r, p.version)
+	default:
+		return nil, errors.New("unknown frame type")
+	}
+}
+
 func (p *frameParser) isAllowedAtEncLevel(f Frame, encLevel protocol.EncryptionLevel) bool {
 	switch encLevel {
 	case protocol.EncryptionInitial, protocol.EncryptionHandshake:
@@ -126,7 +153,7 @@ func (p *frameParser) isAllowedAtEncLevel(f Frame, encLevel protocol.EncryptionL
 		}
 	case protocol.Encryption0RTT:
 		switch f.(type) {
-		case *CryptoFrame, *AckFrame, *ConnectionCloseFrame, *NewTokenFrame, *PathResponseFrame, *RetireConnectionIDFrame:
+		case *CryptoFrame, *AckFrame, *ConnectionCloseFrame, *NewTokenFrame, *PathResponseFrame, *RetireConnectionIDFrame, *ObservedAddressFrame, *DeliveryRateFrame:
 			return false
 		default:
 			return true
@@ -2,10 +2,12 @@ package wire
 
 import (
 	"bytes"
+	"net"
 	"time"
 
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/qerr"
+	"github.com/lucas-clemente/quic-go/quicvarint"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -302,6 +304,56 @@ var _ = Describe("Frame parsing", func() {
 		}))
 	})
 
+	It("unpacks OBSERVED_ADDRESS frames", func() {
+		f := &ObservedAddressFrame{
+			SequenceNumber: 42,
+			IP:             net.IPv4(192, 168, 0, 1).To4(),
+			Port:           1337,
+		}
+		buf := &bytes.Buffer{}
+		Expect(f.Write(buf, versionIETFFrames)).To(Succeed())
+		frame, err := parser.ParseNext(bytes.NewReader(buf.Bytes()), protocol.Encryption1RTT)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(frame).To(Equal(f))
+	})
+
+	It("uses the frame type of the extension when parsing fails", func() {
+		f := &ObservedAddressFrame{
+			SequenceNumber: 42,
+			IP:             net.IPv4(192, 168, 0, 1).To4(),
+			Port:           1337,
+		}
+		buf := &bytes.Buffer{}
+		Expect(f.Write(buf, versionIETFFrames)).To(Succeed())
+		_, err := parser.ParseNext(bytes.NewReader(buf.Bytes()[:buf.Len()-1]), protocol.Encryption1RTT)
+		Expect(err).To(HaveOccurred())
+		Expect(err.(*qerr.TransportError).FrameType).To(BeEquivalentTo(observedAddressIPv4FrameType))
+	})
+
+	It("unpacks DELIVERY_RATE frames", func() {
+		f := &DeliveryRateFrame{
+			SequenceNumber: 42,
+			Rate:           1e6,
+			Interval:       10 * time.Millisecond,
+		}
+		buf := &bytes.Buffer{}
+		Expect(f.Write(buf, versionIETFFrames)).To(Succeed())
+		frame, err := parser.ParseNext(bytes.NewReader(buf.Bytes()), protocol.Encryption1RTT)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(frame).To(Equal(f))
+	})
+
+	It("errors on unknown frame types of extensions", func() {
+		b := &bytes.Buffer{}
+		quicvarint.Write(b, 0x9f81a5)
+		_, err := parser.ParseNext(bytes.NewReader(b.Bytes()), protocol.Encryption1RTT)
+		Expect(err).To(MatchError(&qerr.TransportError{
+			ErrorCode:    qerr.FrameEncodingError,
+			FrameType:    0x9f81a5,
+			ErrorMessage: "unknown frame type",
+		}))
+	})
+
 	It("errors on invalid type", func() {
 		_, err := parser.ParseNext(bytes.NewReader([]byte{0x42}), protocol.Encryption1RTT)
 		Expect(err).To(MatchError(&qerr.TransportError{
@@ -345,6 +397,8 @@ var _ = Describe("Frame parsing", func() {
 			&ConnectionCloseFrame{},
 			&HandshakeDoneFrame{},
 			&DatagramFrame{},
+			&ObservedAddressFrame{IP: net.IPv4(127, 0, 0, 1)},
+			&DeliveryRateFrame{},
 		}
 
 		var framesSerialized [][]byte
@@ -386,11 +440,11 @@ var _ = Describe("Frame parsing", func() {
 			}
 		})
 
-		It("rejects all frames but ACK, CRYPTO, CONNECTION_CLOSE, NEW_TOKEN, PATH_RESPONSE and RETIRE_CONNECTION_ID in 0-RTT packets", func() {
+		It("rejects all frames but ACK, CRYPTO, CONNECTION_CLOSE, NEW_TOKEN, PATH_RESPONSE, RETIRE_CONNECTION_ID, OBSERVED_ADDRESS and DELIVERY_RATE in 0-RTT packets", func() {
 			for i, b := range framesSerialized {
 				_, err := parser.ParseNext(bytes.NewReader(b), protocol.Encryption0RTT)
 				switch frames[i].(type) {
-				case *AckFrame, *ConnectionCloseFrame, *CryptoFrame, *NewTokenFrame, *PathResponseFrame, *RetireConnectionIDFrame:
+				case *AckFrame, *ConnectionCloseFrame, *CryptoFrame, *NewTokenFrame, *PathResponseFrame, *RetireConnectionIDFrame, *ObservedAddressFrame, *DeliveryRateFrame:
 					Expect(err).To(BeAssignableToTypeOf(&qerr.TransportError{}))
 					Expect(err.(*qerr.TransportError).ErrorCode).To(Equal(qerr.FrameEncodingError))
 					Expect(err.(*qerr.TransportError).ErrorMessage).To(ContainSubstring("not allowed at encryption level 0-RTT"))
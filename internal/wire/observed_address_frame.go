@@ -0,0 +1,86 @@
+package wire
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/quicvarint"
+)
+
+// The frame types of the OBSERVED_ADDRESS frame, see draft-ietf-quic-address-discovery.
+const (
+	observedAddressIPv4FrameType = 0x9f81a6
+	observedAddressIPv6FrameType = 0x9f81a7
+)
+
+// An ObservedAddressFrame is an OBSERVED_ADDRESS frame
+type ObservedAddressFrame struct {
+	SequenceNumber uint64
+	IP             net.IP
+	Port           uint16
+}
+
+func parseObservedAddressFrame(r *bytes.Reader, _ protocol.VersionNumber) (*ObservedAddressFrame, error) {
+	frameType, err := quicvarint.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	var ipLen int
+	switch frameType {
+	case observedAddressIPv4FrameType:
+		ipLen = net.IPv4len
+	case observedAddressIPv6FrameType:
+		ipLen = net.IPv6len
+	default:
+		return nil, errors.New("not an OBSERVED_ADDRESS frame")
+	}
+	seq, err := quicvarint.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	ip := make([]byte, ipLen)
+	if _, err := io.ReadFull(r, ip); err != nil {
+		return nil, err
+	}
+	port, err := utils.BigEndian.ReadUint16(r)
+	if err != nil {
+		return nil, err
+	}
+	return &ObservedAddressFrame{
+		SequenceNumber: seq,
+		IP:             net.IP(ip),
+		Port:           port,
+	}, nil
+}
+
+func (f *ObservedAddressFrame) Write(b *bytes.Buffer, _ protocol.VersionNumber) error {
+	if ip := f.IP.To4(); ip != nil {
+		quicvarint.Write(b, observedAddressIPv4FrameType)
+		quicvarint.Write(b, f.SequenceNumber)
+		b.Write(ip)
+	} else {
+		quicvarint.Write(b, observedAddressIPv6FrameType)
+		quicvarint.Write(b, f.SequenceNumber)
+		b.Write(f.IP.To16())
+	}
+	utils.BigEndian.WriteUint16(b, f.Port)
+	return nil
+}
+
+// Length of a written frame
+func (f *ObservedAddressFrame) Length(_ protocol.VersionNumber) protocol.ByteCount {
+	ipLen := protocol.ByteCount(net.IPv6len)
+	if f.IP.To4() != nil {
+		ipLen = net.IPv4len
+	}
+	return quicvarint.Len(observedAddressIPv4FrameType) + quicvarint.Len(f.SequenceNumber) + ipLen + 2
+}
+
+// Addr returns the observed address
+func (f *ObservedAddressFrame) Addr() *net.UDPAddr {
+	return &net.UDPAddr{IP: f.IP, Port: int(f.Port)}
+}
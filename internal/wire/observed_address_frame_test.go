@@ -0,0 +1,86 @@
+package wire
+
+import (
+	"bytes"
+	"net"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/quicvarint"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OBSERVED_ADDRESS frame", func() {
+	Context("parsing", func() {
+		It("accepts a frame with an IPv4 address", func() {
+			data := encodeVarInt(0x9f81a6)
+			data = append(data, encodeVarInt(0x1337)...)
+			data = append(data, []byte{192, 168, 0, 1}...)
+			data = append(data, []byte{0x12, 0x34}...)
+			b := bytes.NewReader(data)
+			f, err := parseObservedAddressFrame(b, protocol.VersionWhatever)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.SequenceNumber).To(BeEquivalentTo(0x1337))
+			Expect(f.IP.Equal(net.IPv4(192, 168, 0, 1))).To(BeTrue())
+			Expect(f.Port).To(BeEquivalentTo(0x1234))
+			Expect(f.Addr()).To(Equal(&net.UDPAddr{IP: f.IP, Port: 0x1234}))
+			Expect(b.Len()).To(BeZero())
+		})
+
+		It("accepts a frame with an IPv6 address", func() {
+			ip := net.ParseIP("2001:db8::1")
+			data := encodeVarInt(0x9f81a7)
+			data = append(data, encodeVarInt(7)...)
+			data = append(data, ip...)
+			data = append(data, []byte{0x1, 0xbb}...)
+			b := bytes.NewReader(data)
+			f, err := parseObservedAddressFrame(b, protocol.VersionWhatever)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(f.SequenceNumber).To(BeEquivalentTo(7))
+			Expect(f.IP.Equal(ip)).To(BeTrue())
+			Expect(f.Port).To(BeEquivalentTo(443))
+			Expect(b.Len()).To(BeZero())
+		})
+
+		It("errors on EOFs", func() {
+			data := encodeVarInt(0x9f81a6)
+			data = append(data, encodeVarInt(0x1337)...)
+			data = append(data, []byte{192, 168, 0, 1}...)
+			data = append(data, []byte{0x12, 0x34}...)
+			_, err := parseObservedAddressFrame(bytes.NewReader(data), protocol.VersionWhatever)
+			Expect(err).NotTo(HaveOccurred())
+			for i := range data {
+				_, err := parseObservedAddressFrame(bytes.NewReader(data[0:i]), protocol.VersionWhatever)
+				Expect(err).To(HaveOccurred())
+			}
+		})
+	})
+
+	Context("writing", func() {
+		It("writes a frame with an IPv4 address", func() {
+			f := &ObservedAddressFrame{SequenceNumber: 0x1337, IP: net.IPv4(192, 168, 0, 1), Port: 0x1234}
+			b := &bytes.Buffer{}
+			Expect(f.Write(b, protocol.VersionWhatever)).To(Succeed())
+			expected := encodeVarInt(0x9f81a6)
+			expected = append(expected, encodeVarInt(0x1337)...)
+			expected = append(expected, []byte{192, 168, 0, 1}...)
+			expected = append(expected, []byte{0x12, 0x34}...)
+			Expect(b.Bytes()).To(Equal(expected))
+			Expect(f.Length(protocol.VersionWhatever)).To(BeEquivalentTo(b.Len()))
+		})
+
+		It("writes a frame with an IPv6 address", func() {
+			ip := net.ParseIP("2001:db8::1")
+			f := &ObservedAddressFrame{SequenceNumber: 1, IP: ip, Port: 443}
+			b := &bytes.Buffer{}
+			Expect(f.Write(b, protocol.VersionWhatever)).To(Succeed())
+			expected := encodeVarInt(0x9f81a7)
+			expected = append(expected, encodeVarInt(1)...)
+			expected = append(expected, ip...)
+			expected = append(expected, []byte{0x1, 0xbb}...)
+			Expect(b.Bytes()).To(Equal(expected))
+			Expect(f.Length(protocol.VersionWhatever)).To(Equal(quicvarint.Len(0x9f81a7) + 1 + 16 + 2))
+		})
+	})
+})
@@ -100,6 +100,8 @@ var _ = Describe("Transport Parameters", func() {
 			MaxAckDelay:                     42 * time.Millisecond,
 			ActiveConnectionIDLimit:         getRandomValue(),
 			MaxDatagramFrameSize:            protocol.ByteCount(getRandomValue()),
+			AddressDiscoveryMode:            AddressDiscoveryReceive,
+			DeliveryRateReportInterval:      25 * time.Millisecond,
 		}
 		data := params.Marshal(protocol.PerspectiveServer)
 
@@ -121,6 +123,8 @@ var _ = Describe("Transport Parameters", func() {
 		Expect(p.MaxAckDelay).To(Equal(42 * time.Millisecond))
 		Expect(p.ActiveConnectionIDLimit).To(Equal(params.ActiveConnectionIDLimit))
 		Expect(p.MaxDatagramFrameSize).To(Equal(params.MaxDatagramFrameSize))
+		Expect(p.AddressDiscoveryMode).To(Equal(AddressDiscoveryReceive))
+		Expect(p.DeliveryRateReportInterval).To(Equal(25 * time.Millisecond))
 	})
 
 	It("doesn't marshal a retry_source_connection_id, if no Retry was performed", func() {
@@ -296,6 +300,48 @@ var _ = Describe("Transport Parameters", func() {
 		}))
 	})
 
+	It("doesn't send the address_discovery, if address discovery is disabled", func() {
+		data := (&TransportParameters{
+			InitialSourceConnectionID: protocol.ConnectionID{0xde, 0xca, 0xfb, 0xad},
+		}).Marshal(protocol.PerspectiveClient)
+		enabledData := (&TransportParameters{
+			InitialSourceConnectionID: protocol.ConnectionID{0xde, 0xca, 0xfb, 0xad},
+			AddressDiscoveryMode:      AddressDiscoveryProvideAndReceive,
+		}).Marshal(protocol.PerspectiveClient)
+		p := &TransportParameters{}
+		Expect(p.Unmarshal(data, protocol.PerspectiveClient)).To(Succeed())
+		Expect(p.AddressDiscoveryMode).To(Equal(AddressDiscoveryDisabled))
+		Expect(p.Unmarshal(enabledData, protocol.PerspectiveClient)).To(Succeed())
+		Expect(p.AddressDiscoveryMode).To(Equal(AddressDiscoveryProvideAndReceive))
+		Expect(p.AddressDiscoveryMode.Provides()).To(BeTrue())
+		Expect(p.AddressDiscoveryMode.Receives()).To(BeTrue())
+	})
+
+	It("errors if address_discovery has an invalid value", func() {
+		b := &bytes.Buffer{}
+		quicvarint.Write(b, uint64(addressDiscoveryParameterID))
+		quicvarint.Write(b, 1)
+		quicvarint.Write(b, 3)
+		addInitialSourceConnectionID(b)
+		Expect((&TransportParameters{}).Unmarshal(b.Bytes(), protocol.PerspectiveClient)).To(MatchError(&qerr.TransportError{
+			ErrorCode:    qerr.TransportParameterError,
+			ErrorMessage: "invalid value for address_discovery: 3 (maximum 2)",
+		}))
+	})
+
+	It("errors if delivery_rate_report_interval is too large", func() {
+		b := &bytes.Buffer{}
+		val := uint64(protocol.MaxDeliveryRateReportInterval/time.Microsecond) + 1
+		quicvarint.Write(b, uint64(deliveryRateReportIntervalParameterID))
+		quicvarint.Write(b, uint64(quicvarint.Len(val)))
+		quicvarint.Write(b, val)
+		addInitialSourceConnectionID(b)
+		Expect((&TransportParameters{}).Unmarshal(b.Bytes(), protocol.PerspectiveClient)).To(MatchError(&qerr.TransportError{
+			ErrorCode:    qerr.TransportParameterError,
+			ErrorMessage: "invalid value for delivery_rate_report_interval: 60000001µs (maximum 60000000µs)",
+		}))
+	})
+
 	It("errors if initial_max_streams_uni is too large", func() {
 		b := &bytes.Buffer{}
 		quicvarint.Write(b, uint64(initialMaxStreamsUniParameterID))
@@ -44,8 +44,36 @@ const (
 	retrySourceConnectionIDParameterID         transportParameterID = 0x10
 	// https://datatracker.ietf.org/doc/draft-ietf-quic-datagram/
 	maxDatagramFrameSizeParameterID transportParameterID = 0x20
+	// https://datatracker.ietf.org/doc/draft-ietf-quic-address-discovery/
+	addressDiscoveryParameterID transportParameterID = 0x9f81a176
+	// experimental, see DeliveryRateFrame
+	deliveryRateReportIntervalParameterID transportParameterID = 0xd7e1a1
 )
 
+// AddressDiscoveryMode is the value of the address_discovery transport parameter
+type AddressDiscoveryMode uint8
+
+const (
+	// AddressDiscoveryDisabled means that the transport parameter was not sent
+	AddressDiscoveryDisabled AddressDiscoveryMode = iota
+	// AddressDiscoveryProvide means that the endpoint reports observed addresses, but doesn't want to receive them
+	AddressDiscoveryProvide
+	// AddressDiscoveryReceive means that the endpoint wants to receive observed addresses, but doesn't report them
+	AddressDiscoveryReceive
+	// AddressDiscoveryProvideAndReceive means that the endpoint both reports and receives observed addresses
+	AddressDiscoveryProvideAndReceive
+)
+
+// Provides says if the endpoint reports observed addresses
+func (m AddressDiscoveryMode) Provides() bool {
+	return m == AddressDiscoveryProvide || m == AddressDiscoveryProvideAndReceive
+}
+
+// Receives says if the endpoint wants to receive observed addresses
+func (m AddressDiscoveryMode) Receives() bool {
+	return m == AddressDiscoveryReceive || m == AddressDiscoveryProvideAndReceive
+}
+
 // PreferredAddress is the value encoding in the preferred_address transport parameter
 type PreferredAddress struct {
 	IPv4                net.IP
@@ -85,6 +113,12 @@ type TransportParameters struct {
 	ActiveConnectionIDLimit uint64
 
 	MaxDatagramFrameSize protocol.ByteCount
+
+	AddressDiscoveryMode AddressDiscoveryMode
+
+	// DeliveryRateReportInterval is the interval at which the endpoint wants to receive DELIVERY_RATE frames.
+	// 0 means that the endpoint doesn't support the extension.
+	DeliveryRateReportInterval time.Duration
 }
 
 // Unmarshal the transport parameters
@@ -137,6 +171,8 @@ func (p *TransportParameters) unmarshal(r *bytes.Reader, sentBy protocol.Perspec
 			maxAckDelayParameterID,
 			activeConnectionIDLimitParameterID,
 			maxDatagramFrameSizeParameterID,
+			addressDiscoveryParameterID,
+			deliveryRateReportIntervalParameterID,
 			ackDelayExponentParameterID:
 			if err := p.readNumericTransportParameter(r, paramID, int(paramLen)); err != nil {
 				return err
@@ -305,6 +341,17 @@ func (p *TransportParameters) readNumericTransportParameter(
 		p.ActiveConnectionIDLimit = val
 	case maxDatagramFrameSizeParameterID:
 		p.MaxDatagramFrameSize = protocol.ByteCount(val)
+	case addressDiscoveryParameterID:
+		if val > 2 {
+			return fmt.Errorf("invalid value for address_discovery: %d (maximum 2)", val)
+		}
+		// the values 0, 1 and 2 correspond to AddressDiscoveryProvide, AddressDiscoveryReceive and AddressDiscoveryProvideAndReceive
+		p.AddressDiscoveryMode = AddressDiscoveryMode(val + 1)
+	case deliveryRateReportIntervalParameterID:
+		if val > uint64(protocol.MaxDeliveryRateReportInterval/time.Microsecond) {
+			return fmt.Errorf("invalid value for delivery_rate_report_interval: %dµs (maximum %dµs)", val, protocol.MaxDeliveryRateReportInterval/time.Microsecond)
+		}
+		p.DeliveryRateReportInterval = time.Duration(val) * time.Microsecond
 	default:
 		return fmt.Errorf("TransportParameter BUG: transport parameter %d not found", paramID)
 	}
@@ -394,6 +441,12 @@ func (p *TransportParameters) Marshal(pers protocol.Perspective) []byte {
 	if p.MaxDatagramFrameSize != protocol.InvalidByteCount {
 		p.marshalVarintParam(b, maxDatagramFrameSizeParameterID, uint64(p.MaxDatagramFrameSize))
 	}
+	if p.AddressDiscoveryMode != AddressDiscoveryDisabled {
+		p.marshalVarintParam(b, addressDiscoveryParameterID, uint64(p.AddressDiscoveryMode-1))
+	}
+	if p.DeliveryRateReportInterval > 0 {
+		p.marshalVarintParam(b, deliveryRateReportIntervalParameterID, uint64(p.DeliveryRateReportInterval/time.Microsecond))
+	}
 	return b.Bytes()
 }
 
@@ -471,6 +524,14 @@ func (p *TransportParameters) String() string {
 		logString += ", MaxDatagramFrameSize: %d"
 		logParams = append(logParams, p.MaxDatagramFrameSize)
 	}
+	if p.AddressDiscoveryMode != AddressDiscoveryDisabled {
+		logString += ", AddressDiscoveryMode: %d"
+		logParams = append(logParams, p.AddressDiscoveryMode)
+	}
+	if p.DeliveryRateReportInterval > 0 {
+		logString += ", DeliveryRateReportInterval: %s"
+		logParams = append(logParams, p.DeliveryRateReportInterval)
+	}
 	logString += "}"
 	return fmt.Sprintf(logString, logParams...)
 }
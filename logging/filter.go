@@ -0,0 +1,388 @@
+package logging
+
+import (
+	"context"
+	"hash/fnv"
+	"net"
+	"time"
+)
+
+// An EventCategory is a category of events, as defined by qlog.
+// Categories can be combined, e.g. EventCategoryTransport | EventCategoryRecovery.
+type EventCategory uint8
+
+const (
+	// EventCategoryTransport are the events related to the connection lifecycle,
+	// packets and transport parameters, as well as Debug events.
+	EventCategoryTransport EventCategory = 1 << iota
+	// EventCategorySecurity are the events related to the keys.
+	EventCategorySecurity
+	// EventCategoryRecovery are the events related to loss detection and congestion control.
+	EventCategoryRecovery
+
+	// EventCategoryAll are all event categories.
+	EventCategoryAll = EventCategoryTransport | EventCategorySecurity | EventCategoryRecovery
+)
+
+// A FilterConfig configures which events are passed to a tracer.
+type FilterConfig struct {
+	// Categories are the event categories that are passed to the tracer.
+	// If zero, events of all categories are passed.
+	Categories EventCategory
+	// SampleRate is the rate at which connections are traced: 1 in SampleRate connections is traced.
+	// Connections are sampled based on their original destination connection ID,
+	// such that a connection is either traced on both endpoints or on none of them.
+	// If 0 or 1, all connections are traced.
+	SampleRate uint32
+}
+
+type tracerFilter struct {
+	tracer     Tracer
+	categories EventCategory
+	sampleRate uint32
+}
+
+var _ Tracer = &tracerFilter{}
+
+// NewFilteredTracer creates a new tracer that only passes a subset of the events to the tracer.
+func NewFilteredTracer(tracer Tracer, conf FilterConfig) Tracer {
+	categories := conf.Categories
+	if categories == 0 {
+		categories = EventCategoryAll
+	}
+	if categories == EventCategoryAll && conf.SampleRate <= 1 {
+		return tracer
+	}
+	return &tracerFilter{
+		tracer:     tracer,
+		categories: categories,
+		sampleRate: conf.SampleRate,
+	}
+}
+
+func (f *tracerFilter) TracerForConnection(ctx context.Context, p Perspective, odcid ConnectionID) ConnectionTracer {
+	if f.sampleRate > 1 {
+		h := fnv.New32a()
+		h.Write(odcid)
+		if h.Sum32()%f.sampleRate != 0 {
+			return nil
+		}
+	}
+	tracer := f.tracer.TracerForConnection(ctx, p, odcid)
+	if tracer == nil {
+		return nil
+	}
+	return NewFilteredConnectionTracer(tracer, f.categories)
+}
+
+func (f *tracerFilter) SentPacket(remote net.Addr, hdr *Header, size ByteCount, frames []Frame) {
+	if f.categories&EventCategoryTransport != 0 {
+		f.tracer.SentPacket(remote, hdr, size, frames)
+	}
+}
+
+func (f *tracerFilter) DroppedPacket(remote net.Addr, typ PacketType, size ByteCount, reason PacketDropReason) {
+	if f.categories&EventCategoryTransport != 0 {
+		f.tracer.DroppedPacket(remote, typ, size, reason)
+	}
+}
+
+type connTracerFilter struct {
+	tracer     ConnectionTracer
+	categories EventCategory
+}
+
+var _ ConnectionTracer = &connTracerFilter{}
+
+// NewFilteredConnectionTracer creates a new connection tracer that only passes the events of the given categories to the tracer.
+// Close is always passed to the tracer.
+func NewFilteredConnectionTracer(tracer ConnectionTracer, categories EventCategory) ConnectionTracer {
+	if categories&EventCategoryAll == EventCategoryAll {
+		return tracer
+	}
+	return &connTracerFilter{tracer: tracer, categories: categories}
+}
+
+func (f *connTracerFilter) transport() bool { return f.categories&EventCategoryTransport != 0 }
+func (f *connTracerFilter) security() bool  { return f.categories&EventCategorySecurity != 0 }
+func (f *connTracerFilter) recovery() bool  { return f.categories&EventCategoryRecovery != 0 }
+
+func (f *connTracerFilter) StartedConnection(local, remote net.Addr, srcConnID, destConnID ConnectionID) {
+	if f.transport() {
+		f.tracer.StartedConnection(local, remote, srcConnID, destConnID)
+	}
+}
+
+func (f *connTracerFilter) NegotiatedVersion(chosen VersionNumber, clientVersions, serverVersions []VersionNumber) {
+	if f.transport() {
+		f.tracer.NegotiatedVersion(chosen, clientVersions, serverVersions)
+	}
+}
+
+func (f *connTracerFilter) ClosedConnection(e error) {
+	if f.transport() {
+		f.tracer.ClosedConnection(e)
+	}
+}
+
+func (f *connTracerFilter) SentTransportParameters(tp *TransportParameters) {
+	if f.transport() {
+		f.tracer.SentTransportParameters(tp)
+	}
+}
+
+func (f *connTracerFilter) ReceivedTransportParameters(tp *TransportParameters) {
+	if f.transport() {
+		f.tracer.ReceivedTransportParameters(tp)
+	}
+}
+
+func (f *connTracerFilter) RestoredTransportParameters(tp *TransportParameters) {
+	if f.transport() {
+		f.tracer.RestoredTransportParameters(tp)
+	}
+}
+
+func (f *connTracerFilter) SentPacket(hdr *ExtendedHeader, size ByteCount, ack *AckFrame, frames []Frame) {
+	if f.transport() {
+		f.tracer.SentPacket(hdr, size, ack, frames)
+	}
+}
+
+func (f *connTracerFilter) ComposedPacket(encLevel EncryptionLevel, pn PacketNumber, composition *PacketComposition) {
+	if f.transport() {
+		f.tracer.ComposedPacket(encLevel, pn, composition)
+	}
+}
+
+func (f *connTracerFilter) ReceivedVersionNegotiationPacket(hdr *Header, versions []VersionNumber) {
+	if f.transport() {
+		f.tracer.ReceivedVersionNegotiationPacket(hdr, versions)
+	}
+}
+
+func (f *connTracerFilter) ReceivedRetry(hdr *Header) {
+	if f.transport() {
+		f.tracer.ReceivedRetry(hdr)
+	}
+}
+
+func (f *connTracerFilter) ReceivedPacket(hdr *ExtendedHeader, size ByteCount, frames []Frame) {
+	if f.transport() {
+		f.tracer.ReceivedPacket(hdr, size, frames)
+	}
+}
+
+func (f *connTracerFilter) SentDatagram(data []byte) {
+	if f.transport() {
+		f.tracer.SentDatagram(data)
+	}
+}
+
+func (f *connTracerFilter) ReceivedDatagram(data []byte) {
+	if f.transport() {
+		f.tracer.ReceivedDatagram(data)
+	}
+}
+
+func (f *connTracerFilter) SentPlaintextPacket(encLevel EncryptionLevel, data []byte) {
+	if f.transport() {
+		f.tracer.SentPlaintextPacket(encLevel, data)
+	}
+}
+
+func (f *connTracerFilter) ReceivedPlaintextPacket(encLevel EncryptionLevel, data []byte) {
+	if f.transport() {
+		f.tracer.ReceivedPlaintextPacket(encLevel, data)
+	}
+}
+
+func (f *connTracerFilter) SentDatagramFrame(length ByteCount) {
+	if f.transport() {
+		f.tracer.SentDatagramFrame(length)
+	}
+}
+
+func (f *connTracerFilter) ReceivedDatagramFrame(length ByteCount) {
+	if f.transport() {
+		f.tracer.ReceivedDatagramFrame(length)
+	}
+}
+
+func (f *connTracerFilter) DroppedDatagramFrame(length ByteCount, reason DatagramDropReason) {
+	if f.transport() {
+		f.tracer.DroppedDatagramFrame(length, reason)
+	}
+}
+
+func (f *connTracerFilter) SentFlowControlUpdate(id StreamID, limit, windowSize ByteCount) {
+	if f.transport() {
+		f.tracer.SentFlowControlUpdate(id, limit, windowSize)
+	}
+}
+
+func (f *connTracerFilter) ReceivedFlowControlUpdate(id StreamID, limit ByteCount, blockedFor time.Duration) {
+	if f.transport() {
+		f.tracer.ReceivedFlowControlUpdate(id, limit, blockedFor)
+	}
+}
+
+func (f *connTracerFilter) BlockedByFlowControl(id StreamID, limit ByteCount) {
+	if f.transport() {
+		f.tracer.BlockedByFlowControl(id, limit)
+	}
+}
+
+func (f *connTracerFilter) BufferedPacket(typ PacketType) {
+	if f.transport() {
+		f.tracer.BufferedPacket(typ)
+	}
+}
+
+func (f *connTracerFilter) DroppedPacket(typ PacketType, size ByteCount, reason PacketDropReason) {
+	if f.transport() {
+		f.tracer.DroppedPacket(typ, size, reason)
+	}
+}
+
+func (f *connTracerFilter) UpdatedMetrics(rttStats *RTTStats, cwnd, bytesInFlight ByteCount, packetsInFlight int) {
+	if f.recovery() {
+		f.tracer.UpdatedMetrics(rttStats, cwnd, bytesInFlight, packetsInFlight)
+	}
+}
+
+func (f *connTracerFilter) UpdatedCongestionMetrics(cwnd, ssthresh, bytesInFlight ByteCount, pacingRate uint64) {
+	if f.recovery() {
+		f.tracer.UpdatedCongestionMetrics(cwnd, ssthresh, bytesInFlight, pacingRate)
+	}
+}
+
+func (f *connTracerFilter) AcknowledgedPacket(encLevel EncryptionLevel, pn PacketNumber) {
+	if f.recovery() {
+		f.tracer.AcknowledgedPacket(encLevel, pn)
+	}
+}
+
+func (f *connTracerFilter) LostPacket(encLevel EncryptionLevel, pn PacketNumber, reason PacketLossReason) {
+	if f.recovery() {
+		f.tracer.LostPacket(encLevel, pn, reason)
+	}
+}
+
+func (f *connTracerFilter) DetectedPersistentCongestion(duration time.Duration) {
+	if f.recovery() {
+		f.tracer.DetectedPersistentCongestion(duration)
+	}
+}
+
+func (f *connTracerFilter) DetectedSuspend(duration time.Duration) {
+	if f.recovery() {
+		f.tracer.DetectedSuspend(duration)
+	}
+}
+
+func (f *connTracerFilter) ClassifiedPacketLoss(pn PacketNumber, random bool) {
+	if f.recovery() {
+		f.tracer.ClassifiedPacketLoss(pn, random)
+	}
+}
+
+func (f *connTracerFilter) PacerDelayedSend(budget ByteCount, nextSendTime time.Time) {
+	if f.recovery() {
+		f.tracer.PacerDelayedSend(budget, nextSendTime)
+	}
+}
+
+func (f *connTracerFilter) LimitedAckRanges(encLevel EncryptionLevel, evicted, omitted int) {
+	if f.recovery() {
+		f.tracer.LimitedAckRanges(encLevel, evicted, omitted)
+	}
+}
+
+func (f *connTracerFilter) SkippedPacketNumber(pn PacketNumber) {
+	if f.recovery() {
+		f.tracer.SkippedPacketNumber(pn)
+	}
+}
+
+func (f *connTracerFilter) UpdatedCongestionState(state CongestionState) {
+	if f.recovery() {
+		f.tracer.UpdatedCongestionState(state)
+	}
+}
+
+func (f *connTracerFilter) UpdatedMaxDatagramSize(size ByteCount) {
+	if f.transport() {
+		f.tracer.UpdatedMaxDatagramSize(size)
+	}
+}
+
+func (f *connTracerFilter) UpdatedPTOCount(value uint32) {
+	if f.recovery() {
+		f.tracer.UpdatedPTOCount(value)
+	}
+}
+
+func (f *connTracerFilter) UpdatedKeyFromTLS(encLevel EncryptionLevel, perspective Perspective) {
+	if f.security() {
+		f.tracer.UpdatedKeyFromTLS(encLevel, perspective)
+	}
+}
+
+func (f *connTracerFilter) UpdatedKey(generation KeyPhase, remote bool) {
+	if f.security() {
+		f.tracer.UpdatedKey(generation, remote)
+	}
+}
+
+func (f *connTracerFilter) DroppedEncryptionLevel(encLevel EncryptionLevel) {
+	if f.security() {
+		f.tracer.DroppedEncryptionLevel(encLevel)
+	}
+}
+
+func (f *connTracerFilter) DroppedKey(generation KeyPhase) {
+	if f.security() {
+		f.tracer.DroppedKey(generation)
+	}
+}
+
+func (f *connTracerFilter) Accepted0RTT() {
+	if f.security() {
+		f.tracer.Accepted0RTT()
+	}
+}
+
+func (f *connTracerFilter) Rejected0RTT(reason ZeroRTTRejectReason) {
+	if f.security() {
+		f.tracer.Rejected0RTT(reason)
+	}
+}
+
+func (f *connTracerFilter) SetLossTimer(typ TimerType, encLevel EncryptionLevel, exp time.Time) {
+	if f.recovery() {
+		f.tracer.SetLossTimer(typ, encLevel, exp)
+	}
+}
+
+func (f *connTracerFilter) LossTimerExpired(typ TimerType, encLevel EncryptionLevel) {
+	if f.recovery() {
+		f.tracer.LossTimerExpired(typ, encLevel)
+	}
+}
+
+func (f *connTracerFilter) LossTimerCanceled() {
+	if f.recovery() {
+		f.tracer.LossTimerCanceled()
+	}
+}
+
+func (f *connTracerFilter) Close() {
+	f.tracer.Close()
+}
+
+func (f *connTracerFilter) Debug(name, msg string) {
+	if f.transport() {
+		f.tracer.Debug(name, msg)
+	}
+}
@@ -0,0 +1,157 @@
+package logging
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Filtering", func() {
+	Context("Tracer", func() {
+		var tr *MockTracer
+
+		BeforeEach(func() {
+			tr = NewMockTracer(mockCtrl)
+		})
+
+		It("returns the raw tracer if no events are filtered", func() {
+			Expect(NewFilteredTracer(tr, FilterConfig{})).To(BeIdenticalTo(tr))
+			Expect(NewFilteredTracer(tr, FilterConfig{Categories: EventCategoryAll, SampleRate: 1})).To(BeIdenticalTo(tr))
+		})
+
+		It("filters the connection tracer", func() {
+			ctx := context.Background()
+			ctr := NewMockConnectionTracer(mockCtrl)
+			tr.EXPECT().TracerForConnection(ctx, PerspectiveClient, ConnectionID{1, 2, 3}).Return(ctr)
+			tracer := NewFilteredTracer(tr, FilterConfig{Categories: EventCategoryRecovery})
+			connTracer := tracer.TracerForConnection(ctx, PerspectiveClient, ConnectionID{1, 2, 3})
+			ctr.EXPECT().LossTimerCanceled()
+			connTracer.LossTimerCanceled()
+			connTracer.ClosedConnection(nil)
+		})
+
+		It("returns nil if the tracer returns a nil connection tracer", func() {
+			ctx := context.Background()
+			tr.EXPECT().TracerForConnection(ctx, PerspectiveServer, ConnectionID{1, 2, 3})
+			tracer := NewFilteredTracer(tr, FilterConfig{Categories: EventCategoryRecovery})
+			Expect(tracer.TracerForConnection(ctx, PerspectiveServer, ConnectionID{1, 2, 3})).To(BeNil())
+		})
+
+		It("filters the SentPacket and DroppedPacket events", func() {
+			remote := &net.UDPAddr{IP: net.IPv4(4, 3, 2, 1)}
+			tracer := NewFilteredTracer(tr, FilterConfig{Categories: EventCategoryRecovery})
+			tracer.SentPacket(remote, &Header{}, 1024, nil)
+			tracer.DroppedPacket(remote, PacketTypeInitial, 1024, PacketDropDuplicate)
+			tracer = NewFilteredTracer(tr, FilterConfig{Categories: EventCategoryTransport})
+			tr.EXPECT().SentPacket(remote, &Header{}, ByteCount(1024), nil)
+			tr.EXPECT().DroppedPacket(remote, PacketTypeInitial, ByteCount(1024), PacketDropDuplicate)
+			tracer.SentPacket(remote, &Header{}, 1024, nil)
+			tracer.DroppedPacket(remote, PacketTypeInitial, 1024, PacketDropDuplicate)
+		})
+
+		It("samples connections", func() {
+			const num = 2000
+			var sampled int
+			tr.EXPECT().TracerForConnection(gomock.Any(), PerspectiveClient, gomock.Any()).Return(NewMockConnectionTracer(mockCtrl)).AnyTimes()
+			tracer := NewFilteredTracer(tr, FilterConfig{SampleRate: 10})
+			for i := 0; i < num; i++ {
+				odcid := make(ConnectionID, 8)
+				rand.Read(odcid)
+				if tracer.TracerForConnection(context.Background(), PerspectiveClient, odcid) != nil {
+					sampled++
+				}
+			}
+			Expect(sampled).To(BeNumerically("~", num/10, num/20))
+		})
+
+		It("samples connections consistently on both endpoints", func() {
+			tr.EXPECT().TracerForConnection(gomock.Any(), gomock.Any(), gomock.Any()).Return(NewMockConnectionTracer(mockCtrl)).AnyTimes()
+			tracer := NewFilteredTracer(tr, FilterConfig{SampleRate: 3})
+			for i := 0; i < 100; i++ {
+				odcid := make(ConnectionID, 8)
+				rand.Read(odcid)
+				client := tracer.TracerForConnection(context.Background(), PerspectiveClient, odcid)
+				server := tracer.TracerForConnection(context.Background(), PerspectiveServer, odcid)
+				Expect(client == nil).To(Equal(server == nil))
+			}
+		})
+	})
+
+	Context("Connection Tracer", func() {
+		var ctr *MockConnectionTracer
+
+		BeforeEach(func() {
+			ctr = NewMockConnectionTracer(mockCtrl)
+		})
+
+		It("returns the raw connection tracer if no events are filtered", func() {
+			Expect(NewFilteredConnectionTracer(ctr, EventCategoryAll)).To(BeIdenticalTo(ctr))
+		})
+
+		It("only passes transport events", func() {
+			tracer := NewFilteredConnectionTracer(ctr, EventCategoryTransport)
+			ctr.EXPECT().ReceivedPacket(&ExtendedHeader{}, ByteCount(1337), nil)
+			ctr.EXPECT().SentDatagram([]byte("foo"))
+			ctr.EXPECT().DroppedDatagramFrame(ByteCount(100), DatagramDropTooLarge)
+			ctr.EXPECT().BlockedByFlowControl(InvalidStreamID, ByteCount(1000))
+			ctr.EXPECT().Debug("foo", "bar")
+			tracer.ReceivedPacket(&ExtendedHeader{}, 1337, nil)
+			tracer.SentDatagram([]byte("foo"))
+			tracer.DroppedDatagramFrame(100, DatagramDropTooLarge)
+			tracer.BlockedByFlowControl(InvalidStreamID, 1000)
+			tracer.Debug("foo", "bar")
+			tracer.UpdatedCongestionMetrics(1000, 2000, 3000, 4000)
+			tracer.LostPacket(EncryptionHandshake, 42, PacketLossTimeThreshold)
+			tracer.UpdatedKey(1, true)
+		})
+
+		It("only passes security events", func() {
+			tracer := NewFilteredConnectionTracer(ctr, EventCategorySecurity)
+			ctr.EXPECT().UpdatedKeyFromTLS(EncryptionHandshake, PerspectiveClient)
+			ctr.EXPECT().DroppedEncryptionLevel(EncryptionHandshake)
+			ctr.EXPECT().Rejected0RTT(ZeroRTTRejectedByServer)
+			tracer.UpdatedKeyFromTLS(EncryptionHandshake, PerspectiveClient)
+			tracer.DroppedEncryptionLevel(EncryptionHandshake)
+			tracer.Rejected0RTT(ZeroRTTRejectedByServer)
+			tracer.SentPacket(&ExtendedHeader{}, 1337, nil, nil)
+			tracer.ReceivedPlaintextPacket(Encryption1RTT, []byte("foo"))
+			tracer.SetLossTimer(TimerTypePTO, EncryptionHandshake, time.Now())
+		})
+
+		It("only passes recovery events", func() {
+			tracer := NewFilteredConnectionTracer(ctr, EventCategoryRecovery)
+			rttStats := &RTTStats{}
+			ctr.EXPECT().UpdatedMetrics(rttStats, ByteCount(1000), ByteCount(2000), 3)
+			ctr.EXPECT().UpdatedCongestionState(CongestionStateRecovery)
+			ctr.EXPECT().PacerDelayedSend(ByteCount(500), time.Time{})
+			ctr.EXPECT().DetectedSuspend(time.Minute)
+			tracer.UpdatedMetrics(rttStats, 1000, 2000, 3)
+			tracer.UpdatedCongestionState(CongestionStateRecovery)
+			tracer.PacerDelayedSend(500, time.Time{})
+			tracer.DetectedSuspend(time.Minute)
+			tracer.StartedConnection(nil, nil, ConnectionID{1}, ConnectionID{2})
+			tracer.DroppedKey(1)
+		})
+
+		It("passes events of multiple categories", func() {
+			tracer := NewFilteredConnectionTracer(ctr, EventCategoryTransport|EventCategoryRecovery)
+			ctr.EXPECT().BufferedPacket(PacketTypeHandshake)
+			ctr.EXPECT().UpdatedPTOCount(uint32(3))
+			tracer.BufferedPacket(PacketTypeHandshake)
+			tracer.UpdatedPTOCount(3)
+			tracer.UpdatedKey(1, false)
+		})
+
+		It("always passes the Close event", func() {
+			tracer := NewFilteredConnectionTracer(ctr, EventCategorySecurity)
+			ctr.EXPECT().Close()
+			tracer.Close()
+		})
+	})
+})
@@ -16,6 +16,8 @@ type (
 	ConnectionCloseFrame = wire.ConnectionCloseFrame
 	// A DataBlockedFrame is a DATA_BLOCKED frame.
 	DataBlockedFrame = wire.DataBlockedFrame
+	// A DeliveryRateFrame is a DELIVERY_RATE frame.
+	DeliveryRateFrame = wire.DeliveryRateFrame
 	// A HandshakeDoneFrame is a HANDSHAKE_DONE frame.
 	HandshakeDoneFrame = wire.HandshakeDoneFrame
 	// A MaxDataFrame is a MAX_DATA frame.
@@ -28,6 +30,8 @@ type (
 	NewConnectionIDFrame = wire.NewConnectionIDFrame
 	// A NewTokenFrame is a NEW_TOKEN frame.
 	NewTokenFrame = wire.NewTokenFrame
+	// An ObservedAddressFrame is an OBSERVED_ADDRESS frame.
+	ObservedAddressFrame = wire.ObservedAddressFrame
 	// A PathChallengeFrame is a PATH_CHALLENGE frame.
 	PathChallengeFrame = wire.PathChallengeFrame
 	// A PathResponseFrame is a PATH_RESPONSE frame.
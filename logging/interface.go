@@ -90,6 +90,9 @@ const (
 	StreamTypeBidi = protocol.StreamTypeBidi
 )
 
+// InvalidStreamID is used as the stream ID for connection-level flow control events.
+const InvalidStreamID StreamID = protocol.InvalidStreamID
+
 // A Tracer traces events.
 type Tracer interface {
 	// TracerForConnection requests a new tracer for a connection.
@@ -111,20 +114,83 @@ type ConnectionTracer interface {
 	ReceivedTransportParameters(*TransportParameters)
 	RestoredTransportParameters(parameters *TransportParameters) // for 0-RTT
 	SentPacket(hdr *ExtendedHeader, size ByteCount, ack *AckFrame, frames []Frame)
+	// ComposedPacket is called after SentPacket, and describes how the packet was composed.
+	ComposedPacket(EncryptionLevel, PacketNumber, *PacketComposition)
 	ReceivedVersionNegotiationPacket(*Header, []VersionNumber)
 	ReceivedRetry(*Header)
 	ReceivedPacket(hdr *ExtendedHeader, size ByteCount, frames []Frame)
+	// SentDatagram is called for every UDP datagram sent on the connection, with the data as it is sent on the wire.
+	// A datagram may contain multiple coalesced packets.
+	// The data must not be retained after the call returns.
+	SentDatagram(data []byte)
+	// ReceivedDatagram is called for every UDP datagram received for the connection, before it is decrypted.
+	// The data must not be retained after the call returns.
+	ReceivedDatagram(data []byte)
+	// SentPlaintextPacket is called for every packet before it is encrypted.
+	// The data is the packet header (without header protection), followed by the plaintext payload.
+	// The data must not be retained after the call returns.
+	SentPlaintextPacket(EncryptionLevel, []byte)
+	// ReceivedPlaintextPacket is called for every packet after it was decrypted.
+	// The data is the packet header (without header protection), followed by the plaintext payload.
+	// The data must not be retained after the call returns.
+	ReceivedPlaintextPacket(EncryptionLevel, []byte)
+	// SentDatagramFrame is called when a DATAGRAM frame is packed into a packet.
+	// The length is the length of the payload of the frame.
+	SentDatagramFrame(length ByteCount)
+	// ReceivedDatagramFrame is called when a DATAGRAM frame is received and queued for the application.
+	ReceivedDatagramFrame(length ByteCount)
+	// DroppedDatagramFrame is called when a DATAGRAM frame is dropped instead of being sent or delivered to the application.
+	DroppedDatagramFrame(length ByteCount, reason DatagramDropReason)
+	// SentFlowControlUpdate is called when a MAX_DATA (if the stream ID is InvalidStreamID) or a MAX_STREAM_DATA frame is queued.
+	// The windowSize is the size of the receive window, as determined by the flow control auto-tuning.
+	SentFlowControlUpdate(id StreamID, limit, windowSize ByteCount)
+	// ReceivedFlowControlUpdate is called when the peer increases the flow control limit of the connection (if the stream ID is InvalidStreamID)
+	// or of a stream, either in its transport parameters or in a MAX_DATA / MAX_STREAM_DATA frame.
+	// If sending was blocked by the previous limit, blockedFor is the time that sending was blocked.
+	ReceivedFlowControlUpdate(id StreamID, limit ByteCount, blockedFor time.Duration)
+	// BlockedByFlowControl is called when sending is blocked by the flow control limit of the connection (if the stream ID is InvalidStreamID)
+	// or of a stream, i.e. when a DATA_BLOCKED or a STREAM_DATA_BLOCKED frame is queued.
+	BlockedByFlowControl(id StreamID, limit ByteCount)
 	BufferedPacket(PacketType)
 	DroppedPacket(PacketType, ByteCount, PacketDropReason)
 	UpdatedMetrics(rttStats *RTTStats, cwnd, bytesInFlight ByteCount, packetsInFlight int)
+	// UpdatedCongestionMetrics is called when the congestion window, the slow start threshold,
+	// the bytes in flight or the pacing rate (in bits/s) changed.
+	UpdatedCongestionMetrics(cwnd, ssthresh, bytesInFlight ByteCount, pacingRate uint64)
 	AcknowledgedPacket(EncryptionLevel, PacketNumber)
 	LostPacket(EncryptionLevel, PacketNumber, PacketLossReason)
+	// DetectedPersistentCongestion is called when persistent congestion is established (see section 7.6 of RFC 9002).
+	// The duration is the time between the send times of the first and the last lost packet.
+	DetectedPersistentCongestion(duration time.Duration)
+	// DetectedSuspend is called when the system was suspended (e.g. when the lid of a laptop was closed) for the given duration.
+	DetectedSuspend(duration time.Duration)
+	// ClassifiedPacketLoss is called when the congestion controller classifies a lost packet.
+	// Random losses don't reduce the congestion window.
+	ClassifiedPacketLoss(pn PacketNumber, random bool)
+	// PacerDelayedSend is called when the pacer doesn't allow sending a packet at this moment.
+	// The budget is the number of bytes that the pacer allows to be sent, nextSendTime is when the next packet can be sent.
+	PacerDelayedSend(budget ByteCount, nextSendTime time.Time)
+	// LimitedAckRanges is called when an ACK frame is sent that doesn't report all packets received in the packet number space.
+	// Evicted is the number of ACK ranges discarded from the history since the last ACK frame,
+	// omitted is the number of ranges that didn't fit into the ACK frame.
+	LimitedAckRanges(encLevel EncryptionLevel, evicted, omitted int)
+	SkippedPacketNumber(PacketNumber)
 	UpdatedCongestionState(CongestionState)
 	UpdatedPTOCount(value uint32)
+	// UpdatedMaxDatagramSize is called when the maximum datagram size changes,
+	// either because Path MTU Discovery found a larger MTU, or because the MTU decreased.
+	UpdatedMaxDatagramSize(size ByteCount)
 	UpdatedKeyFromTLS(EncryptionLevel, Perspective)
 	UpdatedKey(generation KeyPhase, remote bool)
 	DroppedEncryptionLevel(EncryptionLevel)
 	DroppedKey(generation KeyPhase)
+	// Accepted0RTT is called when 0-RTT is accepted.
+	// On the client, it is called when the handshake completes, if the client used 0-RTT.
+	// On the server, it is called when the server decides to accept the client's 0-RTT data.
+	Accepted0RTT()
+	// Rejected0RTT is called when 0-RTT is rejected.
+	// On the client, it is called if the client used 0-RTT, on the server if the client offered to use 0-RTT.
+	Rejected0RTT(ZeroRTTRejectReason)
 	SetLossTimer(TimerType, EncryptionLevel, time.Time)
 	LossTimerExpired(TimerType, EncryptionLevel)
 	LossTimerCanceled()
@@ -0,0 +1,33 @@
+package logging
+
+import "github.com/lucas-clemente/quic-go/internal/utils"
+
+type (
+	// The LogLevel is the level of a log message.
+	LogLevel = utils.LogLevel
+	// A LogField is a key-value pair attached to a log message, e.g. the connection ID ("conn_id"),
+	// the packet number ("packet_number") or the type of event ("event").
+	LogField = utils.Field
+	// A LogSink receives the log messages of quic-go, together with their fields.
+	// It can be used to back quic-go's logging by a structured logging library (e.g. zap or logr),
+	// instead of printing free-text messages using the log package.
+	LogSink = utils.LogSink
+)
+
+const (
+	// LogLevelNothing disables logging
+	LogLevelNothing LogLevel = utils.LogLevelNothing
+	// LogLevelError is used for errors
+	LogLevelError LogLevel = utils.LogLevelError
+	// LogLevelInfo is used for informational messages
+	LogLevelInfo LogLevel = utils.LogLevelInfo
+	// LogLevelDebug is used for debug messages (e.g. the contents of every packet)
+	LogLevelDebug LogLevel = utils.LogLevelDebug
+)
+
+// SetLogSink makes quic-go pass all log messages to the sink.
+// The QUIC_GO_LOG_LEVEL environment variable is ignored, the sink decides which messages are logged.
+// It must be called before any listener or connection is created.
+func SetLogSink(sink LogSink) {
+	utils.DefaultLogger = utils.NewSinkLogger(sink)
+}
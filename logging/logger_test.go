@@ -0,0 +1,33 @@
+package logging
+
+import (
+	"github.com/lucas-clemente/quic-go/internal/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type testLogSink struct {
+	messages []string
+	fields   [][]LogField
+}
+
+func (s *testLogSink) Enabled(LogLevel) bool { return true }
+
+func (s *testLogSink) Log(_ LogLevel, msg string, fields []LogField) {
+	s.messages = append(s.messages, msg)
+	s.fields = append(s.fields, fields)
+}
+
+var _ = Describe("Log sink", func() {
+	It("passes log messages to the sink", func() {
+		defaultLogger := utils.DefaultLogger
+		defer func() { utils.DefaultLogger = defaultLogger }()
+
+		sink := &testLogSink{}
+		SetLogSink(sink)
+		utils.DefaultLogger.WithFields(LogField{Key: "conn_id", Value: "c0ffee"}).Infof("foo %s", "bar")
+		Expect(sink.messages).To(Equal([]string{"foo bar"}))
+		Expect(sink.fields).To(Equal([][]LogField{{{Key: "conn_id", Value: "c0ffee"}}}))
+	})
+})
@@ -38,6 +38,18 @@ func (m *MockConnectionTracer) EXPECT() *MockConnectionTracerMockRecorder {
 	return m.recorder
 }
 
+// Accepted0RTT mocks base method.
+func (m *MockConnectionTracer) Accepted0RTT() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Accepted0RTT")
+}
+
+// Accepted0RTT indicates an expected call of Accepted0RTT.
+func (mr *MockConnectionTracerMockRecorder) Accepted0RTT() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Accepted0RTT", reflect.TypeOf((*MockConnectionTracer)(nil).Accepted0RTT))
+}
+
 // AcknowledgedPacket mocks base method.
 func (m *MockConnectionTracer) AcknowledgedPacket(arg0 protocol.EncryptionLevel, arg1 protocol.PacketNumber) {
 	m.ctrl.T.Helper()
@@ -50,6 +62,18 @@ func (mr *MockConnectionTracerMockRecorder) AcknowledgedPacket(arg0, arg1 interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcknowledgedPacket", reflect.TypeOf((*MockConnectionTracer)(nil).AcknowledgedPacket), arg0, arg1)
 }
 
+// BlockedByFlowControl mocks base method.
+func (m *MockConnectionTracer) BlockedByFlowControl(arg0 protocol.StreamID, arg1 protocol.ByteCount) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "BlockedByFlowControl", arg0, arg1)
+}
+
+// BlockedByFlowControl indicates an expected call of BlockedByFlowControl.
+func (mr *MockConnectionTracerMockRecorder) BlockedByFlowControl(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockedByFlowControl", reflect.TypeOf((*MockConnectionTracer)(nil).BlockedByFlowControl), arg0, arg1)
+}
+
 // BufferedPacket mocks base method.
 func (m *MockConnectionTracer) BufferedPacket(arg0 PacketType) {
 	m.ctrl.T.Helper()
@@ -62,6 +86,18 @@ func (mr *MockConnectionTracerMockRecorder) BufferedPacket(arg0 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BufferedPacket", reflect.TypeOf((*MockConnectionTracer)(nil).BufferedPacket), arg0)
 }
 
+// ClassifiedPacketLoss mocks base method.
+func (m *MockConnectionTracer) ClassifiedPacketLoss(arg0 protocol.PacketNumber, arg1 bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ClassifiedPacketLoss", arg0, arg1)
+}
+
+// ClassifiedPacketLoss indicates an expected call of ClassifiedPacketLoss.
+func (mr *MockConnectionTracerMockRecorder) ClassifiedPacketLoss(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClassifiedPacketLoss", reflect.TypeOf((*MockConnectionTracer)(nil).ClassifiedPacketLoss), arg0, arg1)
+}
+
 // Close mocks base method.
 func (m *MockConnectionTracer) Close() {
 	m.ctrl.T.Helper()
@@ -86,6 +122,18 @@ func (mr *MockConnectionTracerMockRecorder) ClosedConnection(arg0 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClosedConnection", reflect.TypeOf((*MockConnectionTracer)(nil).ClosedConnection), arg0)
 }
 
+// ComposedPacket mocks base method.
+func (m *MockConnectionTracer) ComposedPacket(arg0 protocol.EncryptionLevel, arg1 protocol.PacketNumber, arg2 *PacketComposition) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ComposedPacket", arg0, arg1, arg2)
+}
+
+// ComposedPacket indicates an expected call of ComposedPacket.
+func (mr *MockConnectionTracerMockRecorder) ComposedPacket(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ComposedPacket", reflect.TypeOf((*MockConnectionTracer)(nil).ComposedPacket), arg0, arg1, arg2)
+}
+
 // Debug mocks base method.
 func (m *MockConnectionTracer) Debug(arg0, arg1 string) {
 	m.ctrl.T.Helper()
@@ -98,6 +146,42 @@ func (mr *MockConnectionTracerMockRecorder) Debug(arg0, arg1 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Debug", reflect.TypeOf((*MockConnectionTracer)(nil).Debug), arg0, arg1)
 }
 
+// DetectedPersistentCongestion mocks base method.
+func (m *MockConnectionTracer) DetectedPersistentCongestion(arg0 time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DetectedPersistentCongestion", arg0)
+}
+
+// DetectedPersistentCongestion indicates an expected call of DetectedPersistentCongestion.
+func (mr *MockConnectionTracerMockRecorder) DetectedPersistentCongestion(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetectedPersistentCongestion", reflect.TypeOf((*MockConnectionTracer)(nil).DetectedPersistentCongestion), arg0)
+}
+
+// DetectedSuspend mocks base method.
+func (m *MockConnectionTracer) DetectedSuspend(arg0 time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DetectedSuspend", arg0)
+}
+
+// DetectedSuspend indicates an expected call of DetectedSuspend.
+func (mr *MockConnectionTracerMockRecorder) DetectedSuspend(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetectedSuspend", reflect.TypeOf((*MockConnectionTracer)(nil).DetectedSuspend), arg0)
+}
+
+// DroppedDatagramFrame mocks base method.
+func (m *MockConnectionTracer) DroppedDatagramFrame(arg0 protocol.ByteCount, arg1 DatagramDropReason) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DroppedDatagramFrame", arg0, arg1)
+}
+
+// DroppedDatagramFrame indicates an expected call of DroppedDatagramFrame.
+func (mr *MockConnectionTracerMockRecorder) DroppedDatagramFrame(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DroppedDatagramFrame", reflect.TypeOf((*MockConnectionTracer)(nil).DroppedDatagramFrame), arg0, arg1)
+}
+
 // DroppedEncryptionLevel mocks base method.
 func (m *MockConnectionTracer) DroppedEncryptionLevel(arg0 protocol.EncryptionLevel) {
 	m.ctrl.T.Helper()
@@ -134,6 +218,18 @@ func (mr *MockConnectionTracerMockRecorder) DroppedPacket(arg0, arg1, arg2 inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DroppedPacket", reflect.TypeOf((*MockConnectionTracer)(nil).DroppedPacket), arg0, arg1, arg2)
 }
 
+// LimitedAckRanges mocks base method.
+func (m *MockConnectionTracer) LimitedAckRanges(arg0 protocol.EncryptionLevel, arg1, arg2 int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "LimitedAckRanges", arg0, arg1, arg2)
+}
+
+// LimitedAckRanges indicates an expected call of LimitedAckRanges.
+func (mr *MockConnectionTracerMockRecorder) LimitedAckRanges(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LimitedAckRanges", reflect.TypeOf((*MockConnectionTracer)(nil).LimitedAckRanges), arg0, arg1, arg2)
+}
+
 // LossTimerCanceled mocks base method.
 func (m *MockConnectionTracer) LossTimerCanceled() {
 	m.ctrl.T.Helper()
@@ -182,6 +278,54 @@ func (mr *MockConnectionTracerMockRecorder) NegotiatedVersion(arg0, arg1, arg2 i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NegotiatedVersion", reflect.TypeOf((*MockConnectionTracer)(nil).NegotiatedVersion), arg0, arg1, arg2)
 }
 
+// PacerDelayedSend mocks base method.
+func (m *MockConnectionTracer) PacerDelayedSend(arg0 protocol.ByteCount, arg1 time.Time) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "PacerDelayedSend", arg0, arg1)
+}
+
+// PacerDelayedSend indicates an expected call of PacerDelayedSend.
+func (mr *MockConnectionTracerMockRecorder) PacerDelayedSend(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PacerDelayedSend", reflect.TypeOf((*MockConnectionTracer)(nil).PacerDelayedSend), arg0, arg1)
+}
+
+// ReceivedDatagram mocks base method.
+func (m *MockConnectionTracer) ReceivedDatagram(arg0 []byte) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ReceivedDatagram", arg0)
+}
+
+// ReceivedDatagram indicates an expected call of ReceivedDatagram.
+func (mr *MockConnectionTracerMockRecorder) ReceivedDatagram(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReceivedDatagram", reflect.TypeOf((*MockConnectionTracer)(nil).ReceivedDatagram), arg0)
+}
+
+// ReceivedDatagramFrame mocks base method.
+func (m *MockConnectionTracer) ReceivedDatagramFrame(arg0 protocol.ByteCount) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ReceivedDatagramFrame", arg0)
+}
+
+// ReceivedDatagramFrame indicates an expected call of ReceivedDatagramFrame.
+func (mr *MockConnectionTracerMockRecorder) ReceivedDatagramFrame(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReceivedDatagramFrame", reflect.TypeOf((*MockConnectionTracer)(nil).ReceivedDatagramFrame), arg0)
+}
+
+// ReceivedFlowControlUpdate mocks base method.
+func (m *MockConnectionTracer) ReceivedFlowControlUpdate(arg0 protocol.StreamID, arg1 protocol.ByteCount, arg2 time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ReceivedFlowControlUpdate", arg0, arg1, arg2)
+}
+
+// ReceivedFlowControlUpdate indicates an expected call of ReceivedFlowControlUpdate.
+func (mr *MockConnectionTracerMockRecorder) ReceivedFlowControlUpdate(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReceivedFlowControlUpdate", reflect.TypeOf((*MockConnectionTracer)(nil).ReceivedFlowControlUpdate), arg0, arg1, arg2)
+}
+
 // ReceivedPacket mocks base method.
 func (m *MockConnectionTracer) ReceivedPacket(arg0 *wire.ExtendedHeader, arg1 protocol.ByteCount, arg2 []Frame) {
 	m.ctrl.T.Helper()
@@ -194,6 +338,18 @@ func (mr *MockConnectionTracerMockRecorder) ReceivedPacket(arg0, arg1, arg2 inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReceivedPacket", reflect.TypeOf((*MockConnectionTracer)(nil).ReceivedPacket), arg0, arg1, arg2)
 }
 
+// ReceivedPlaintextPacket mocks base method.
+func (m *MockConnectionTracer) ReceivedPlaintextPacket(arg0 EncryptionLevel, arg1 []byte) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ReceivedPlaintextPacket", arg0, arg1)
+}
+
+// ReceivedPlaintextPacket indicates an expected call of ReceivedPlaintextPacket.
+func (mr *MockConnectionTracerMockRecorder) ReceivedPlaintextPacket(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReceivedPlaintextPacket", reflect.TypeOf((*MockConnectionTracer)(nil).ReceivedPlaintextPacket), arg0, arg1)
+}
+
 // ReceivedRetry mocks base method.
 func (m *MockConnectionTracer) ReceivedRetry(arg0 *wire.Header) {
 	m.ctrl.T.Helper()
@@ -230,6 +386,18 @@ func (mr *MockConnectionTracerMockRecorder) ReceivedVersionNegotiationPacket(arg
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReceivedVersionNegotiationPacket", reflect.TypeOf((*MockConnectionTracer)(nil).ReceivedVersionNegotiationPacket), arg0, arg1)
 }
 
+// Rejected0RTT mocks base method.
+func (m *MockConnectionTracer) Rejected0RTT(arg0 ZeroRTTRejectReason) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Rejected0RTT", arg0)
+}
+
+// Rejected0RTT indicates an expected call of Rejected0RTT.
+func (mr *MockConnectionTracerMockRecorder) Rejected0RTT(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rejected0RTT", reflect.TypeOf((*MockConnectionTracer)(nil).Rejected0RTT), arg0)
+}
+
 // RestoredTransportParameters mocks base method.
 func (m *MockConnectionTracer) RestoredTransportParameters(arg0 *wire.TransportParameters) {
 	m.ctrl.T.Helper()
@@ -242,6 +410,42 @@ func (mr *MockConnectionTracerMockRecorder) RestoredTransportParameters(arg0 int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoredTransportParameters", reflect.TypeOf((*MockConnectionTracer)(nil).RestoredTransportParameters), arg0)
 }
 
+// SentDatagram mocks base method.
+func (m *MockConnectionTracer) SentDatagram(arg0 []byte) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SentDatagram", arg0)
+}
+
+// SentDatagram indicates an expected call of SentDatagram.
+func (mr *MockConnectionTracerMockRecorder) SentDatagram(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SentDatagram", reflect.TypeOf((*MockConnectionTracer)(nil).SentDatagram), arg0)
+}
+
+// SentDatagramFrame mocks base method.
+func (m *MockConnectionTracer) SentDatagramFrame(arg0 protocol.ByteCount) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SentDatagramFrame", arg0)
+}
+
+// SentDatagramFrame indicates an expected call of SentDatagramFrame.
+func (mr *MockConnectionTracerMockRecorder) SentDatagramFrame(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SentDatagramFrame", reflect.TypeOf((*MockConnectionTracer)(nil).SentDatagramFrame), arg0)
+}
+
+// SentFlowControlUpdate mocks base method.
+func (m *MockConnectionTracer) SentFlowControlUpdate(arg0 protocol.StreamID, arg1, arg2 protocol.ByteCount) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SentFlowControlUpdate", arg0, arg1, arg2)
+}
+
+// SentFlowControlUpdate indicates an expected call of SentFlowControlUpdate.
+func (mr *MockConnectionTracerMockRecorder) SentFlowControlUpdate(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SentFlowControlUpdate", reflect.TypeOf((*MockConnectionTracer)(nil).SentFlowControlUpdate), arg0, arg1, arg2)
+}
+
 // SentPacket mocks base method.
 func (m *MockConnectionTracer) SentPacket(arg0 *wire.ExtendedHeader, arg1 protocol.ByteCount, arg2 *wire.AckFrame, arg3 []Frame) {
 	m.ctrl.T.Helper()
@@ -254,6 +458,18 @@ func (mr *MockConnectionTracerMockRecorder) SentPacket(arg0, arg1, arg2, arg3 in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SentPacket", reflect.TypeOf((*MockConnectionTracer)(nil).SentPacket), arg0, arg1, arg2, arg3)
 }
 
+// SentPlaintextPacket mocks base method.
+func (m *MockConnectionTracer) SentPlaintextPacket(arg0 EncryptionLevel, arg1 []byte) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SentPlaintextPacket", arg0, arg1)
+}
+
+// SentPlaintextPacket indicates an expected call of SentPlaintextPacket.
+func (mr *MockConnectionTracerMockRecorder) SentPlaintextPacket(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SentPlaintextPacket", reflect.TypeOf((*MockConnectionTracer)(nil).SentPlaintextPacket), arg0, arg1)
+}
+
 // SentTransportParameters mocks base method.
 func (m *MockConnectionTracer) SentTransportParameters(arg0 *wire.TransportParameters) {
 	m.ctrl.T.Helper()
@@ -278,6 +494,18 @@ func (mr *MockConnectionTracerMockRecorder) SetLossTimer(arg0, arg1, arg2 interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLossTimer", reflect.TypeOf((*MockConnectionTracer)(nil).SetLossTimer), arg0, arg1, arg2)
 }
 
+// SkippedPacketNumber mocks base method.
+func (m *MockConnectionTracer) SkippedPacketNumber(arg0 protocol.PacketNumber) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SkippedPacketNumber", arg0)
+}
+
+// SkippedPacketNumber indicates an expected call of SkippedPacketNumber.
+func (mr *MockConnectionTracerMockRecorder) SkippedPacketNumber(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SkippedPacketNumber", reflect.TypeOf((*MockConnectionTracer)(nil).SkippedPacketNumber), arg0)
+}
+
 // StartedConnection mocks base method.
 func (m *MockConnectionTracer) StartedConnection(arg0, arg1 net.Addr, arg2, arg3 protocol.ConnectionID) {
 	m.ctrl.T.Helper()
@@ -290,6 +518,18 @@ func (mr *MockConnectionTracerMockRecorder) StartedConnection(arg0, arg1, arg2,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartedConnection", reflect.TypeOf((*MockConnectionTracer)(nil).StartedConnection), arg0, arg1, arg2, arg3)
 }
 
+// UpdatedCongestionMetrics mocks base method.
+func (m *MockConnectionTracer) UpdatedCongestionMetrics(arg0, arg1, arg2 protocol.ByteCount, arg3 uint64) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UpdatedCongestionMetrics", arg0, arg1, arg2, arg3)
+}
+
+// UpdatedCongestionMetrics indicates an expected call of UpdatedCongestionMetrics.
+func (mr *MockConnectionTracerMockRecorder) UpdatedCongestionMetrics(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatedCongestionMetrics", reflect.TypeOf((*MockConnectionTracer)(nil).UpdatedCongestionMetrics), arg0, arg1, arg2, arg3)
+}
+
 // UpdatedCongestionState mocks base method.
 func (m *MockConnectionTracer) UpdatedCongestionState(arg0 CongestionState) {
 	m.ctrl.T.Helper()
@@ -326,6 +566,18 @@ func (mr *MockConnectionTracerMockRecorder) UpdatedKeyFromTLS(arg0, arg1 interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatedKeyFromTLS", reflect.TypeOf((*MockConnectionTracer)(nil).UpdatedKeyFromTLS), arg0, arg1)
 }
 
+// UpdatedMaxDatagramSize mocks base method.
+func (m *MockConnectionTracer) UpdatedMaxDatagramSize(arg0 protocol.ByteCount) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UpdatedMaxDatagramSize", arg0)
+}
+
+// UpdatedMaxDatagramSize indicates an expected call of UpdatedMaxDatagramSize.
+func (mr *MockConnectionTracerMockRecorder) UpdatedMaxDatagramSize(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatedMaxDatagramSize", reflect.TypeOf((*MockConnectionTracer)(nil).UpdatedMaxDatagramSize), arg0)
+}
+
 // UpdatedMetrics mocks base method.
 func (m *MockConnectionTracer) UpdatedMetrics(arg0 *utils.RTTStats, arg1, arg2 protocol.ByteCount, arg3 int) {
 	m.ctrl.T.Helper()
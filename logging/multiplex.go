@@ -104,6 +104,12 @@ func (m *connTracerMultiplexer) SentPacket(hdr *ExtendedHeader, size ByteCount,
 	}
 }
 
+func (m *connTracerMultiplexer) ComposedPacket(encLevel EncryptionLevel, pn PacketNumber, composition *PacketComposition) {
+	for _, t := range m.tracers {
+		t.ComposedPacket(encLevel, pn, composition)
+	}
+}
+
 func (m *connTracerMultiplexer) ReceivedVersionNegotiationPacket(hdr *Header, versions []VersionNumber) {
 	for _, t := range m.tracers {
 		t.ReceivedVersionNegotiationPacket(hdr, versions)
@@ -122,6 +128,66 @@ func (m *connTracerMultiplexer) ReceivedPacket(hdr *ExtendedHeader, size ByteCou
 	}
 }
 
+func (m *connTracerMultiplexer) SentDatagram(data []byte) {
+	for _, t := range m.tracers {
+		t.SentDatagram(data)
+	}
+}
+
+func (m *connTracerMultiplexer) ReceivedDatagram(data []byte) {
+	for _, t := range m.tracers {
+		t.ReceivedDatagram(data)
+	}
+}
+
+func (m *connTracerMultiplexer) SentPlaintextPacket(encLevel EncryptionLevel, data []byte) {
+	for _, t := range m.tracers {
+		t.SentPlaintextPacket(encLevel, data)
+	}
+}
+
+func (m *connTracerMultiplexer) ReceivedPlaintextPacket(encLevel EncryptionLevel, data []byte) {
+	for _, t := range m.tracers {
+		t.ReceivedPlaintextPacket(encLevel, data)
+	}
+}
+
+func (m *connTracerMultiplexer) SentDatagramFrame(length ByteCount) {
+	for _, t := range m.tracers {
+		t.SentDatagramFrame(length)
+	}
+}
+
+func (m *connTracerMultiplexer) ReceivedDatagramFrame(length ByteCount) {
+	for _, t := range m.tracers {
+		t.ReceivedDatagramFrame(length)
+	}
+}
+
+func (m *connTracerMultiplexer) DroppedDatagramFrame(length ByteCount, reason DatagramDropReason) {
+	for _, t := range m.tracers {
+		t.DroppedDatagramFrame(length, reason)
+	}
+}
+
+func (m *connTracerMultiplexer) SentFlowControlUpdate(id StreamID, limit, windowSize ByteCount) {
+	for _, t := range m.tracers {
+		t.SentFlowControlUpdate(id, limit, windowSize)
+	}
+}
+
+func (m *connTracerMultiplexer) ReceivedFlowControlUpdate(id StreamID, limit ByteCount, blockedFor time.Duration) {
+	for _, t := range m.tracers {
+		t.ReceivedFlowControlUpdate(id, limit, blockedFor)
+	}
+}
+
+func (m *connTracerMultiplexer) BlockedByFlowControl(id StreamID, limit ByteCount) {
+	for _, t := range m.tracers {
+		t.BlockedByFlowControl(id, limit)
+	}
+}
+
 func (m *connTracerMultiplexer) BufferedPacket(typ PacketType) {
 	for _, t := range m.tracers {
 		t.BufferedPacket(typ)
@@ -146,6 +212,12 @@ func (m *connTracerMultiplexer) UpdatedMetrics(rttStats *RTTStats, cwnd, bytesIn
 	}
 }
 
+func (m *connTracerMultiplexer) UpdatedCongestionMetrics(cwnd, ssthresh, bytesInFlight ByteCount, pacingRate uint64) {
+	for _, t := range m.tracers {
+		t.UpdatedCongestionMetrics(cwnd, ssthresh, bytesInFlight, pacingRate)
+	}
+}
+
 func (m *connTracerMultiplexer) AcknowledgedPacket(encLevel EncryptionLevel, pn PacketNumber) {
 	for _, t := range m.tracers {
 		t.AcknowledgedPacket(encLevel, pn)
@@ -158,6 +230,48 @@ func (m *connTracerMultiplexer) LostPacket(encLevel EncryptionLevel, pn PacketNu
 	}
 }
 
+func (m *connTracerMultiplexer) DetectedPersistentCongestion(duration time.Duration) {
+	for _, t := range m.tracers {
+		t.DetectedPersistentCongestion(duration)
+	}
+}
+
+func (m *connTracerMultiplexer) DetectedSuspend(duration time.Duration) {
+	for _, t := range m.tracers {
+		t.DetectedSuspend(duration)
+	}
+}
+
+func (m *connTracerMultiplexer) ClassifiedPacketLoss(pn PacketNumber, random bool) {
+	for _, t := range m.tracers {
+		t.ClassifiedPacketLoss(pn, random)
+	}
+}
+
+func (m *connTracerMultiplexer) PacerDelayedSend(budget ByteCount, nextSendTime time.Time) {
+	for _, t := range m.tracers {
+		t.PacerDelayedSend(budget, nextSendTime)
+	}
+}
+
+func (m *connTracerMultiplexer) LimitedAckRanges(encLevel EncryptionLevel, evicted, omitted int) {
+	for _, t := range m.tracers {
+		t.LimitedAckRanges(encLevel, evicted, omitted)
+	}
+}
+
+func (m *connTracerMultiplexer) SkippedPacketNumber(pn PacketNumber) {
+	for _, t := range m.tracers {
+		t.SkippedPacketNumber(pn)
+	}
+}
+
+func (m *connTracerMultiplexer) UpdatedMaxDatagramSize(size ByteCount) {
+	for _, t := range m.tracers {
+		t.UpdatedMaxDatagramSize(size)
+	}
+}
+
 func (m *connTracerMultiplexer) UpdatedPTOCount(value uint32) {
 	for _, t := range m.tracers {
 		t.UpdatedPTOCount(value)
@@ -188,6 +302,18 @@ func (m *connTracerMultiplexer) DroppedKey(generation KeyPhase) {
 	}
 }
 
+func (m *connTracerMultiplexer) Accepted0RTT() {
+	for _, t := range m.tracers {
+		t.Accepted0RTT()
+	}
+}
+
+func (m *connTracerMultiplexer) Rejected0RTT(reason ZeroRTTRejectReason) {
+	for _, t := range m.tracers {
+		t.Rejected0RTT(reason)
+	}
+}
+
 func (m *connTracerMultiplexer) SetLossTimer(typ TimerType, encLevel EncryptionLevel, exp time.Time) {
 	for _, t := range m.tracers {
 		t.SetLossTimer(typ, encLevel, exp)
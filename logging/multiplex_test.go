@@ -148,6 +148,13 @@ var _ = Describe("Tracing", func() {
 			tracer.SentPacket(hdr, 1337, ack, []Frame{ping})
 		})
 
+		It("traces the ComposedPacket event", func() {
+			composition := &PacketComposition{Considered: FrameSourceAck | FrameSourceData, MaxSize: 1200, PacingBudget: 2400}
+			tr1.EXPECT().ComposedPacket(Encryption1RTT, PacketNumber(42), composition)
+			tr2.EXPECT().ComposedPacket(Encryption1RTT, PacketNumber(42), composition)
+			tracer.ComposedPacket(Encryption1RTT, 42, composition)
+		})
+
 		It("traces the ReceivedVersionNegotiationPacket event", func() {
 			hdr := &Header{DestConnectionID: ConnectionID{1, 2, 3}}
 			tr1.EXPECT().ReceivedVersionNegotiationPacket(hdr, []VersionNumber{1337})
@@ -196,6 +203,12 @@ var _ = Describe("Tracing", func() {
 			tracer.UpdatedMetrics(rttStats, 1337, 42, 13)
 		})
 
+		It("traces the UpdatedCongestionMetrics event", func() {
+			tr1.EXPECT().UpdatedCongestionMetrics(ByteCount(1337), ByteCount(2000), ByteCount(42), uint64(1e6))
+			tr2.EXPECT().UpdatedCongestionMetrics(ByteCount(1337), ByteCount(2000), ByteCount(42), uint64(1e6))
+			tracer.UpdatedCongestionMetrics(1337, 2000, 42, 1e6)
+		})
+
 		It("traces the AcknowledgedPacket event", func() {
 			tr1.EXPECT().AcknowledgedPacket(EncryptionHandshake, PacketNumber(42))
 			tr2.EXPECT().AcknowledgedPacket(EncryptionHandshake, PacketNumber(42))
@@ -208,6 +221,91 @@ var _ = Describe("Tracing", func() {
 			tracer.LostPacket(EncryptionHandshake, 42, PacketLossReorderingThreshold)
 		})
 
+		It("traces the DetectedPersistentCongestion event", func() {
+			tr1.EXPECT().DetectedPersistentCongestion(time.Second)
+			tr2.EXPECT().DetectedPersistentCongestion(time.Second)
+			tracer.DetectedPersistentCongestion(time.Second)
+		})
+
+		It("traces the DetectedSuspend event", func() {
+			tr1.EXPECT().DetectedSuspend(time.Minute)
+			tr2.EXPECT().DetectedSuspend(time.Minute)
+			tracer.DetectedSuspend(time.Minute)
+		})
+
+		It("traces the ClassifiedPacketLoss event", func() {
+			tr1.EXPECT().ClassifiedPacketLoss(PacketNumber(42), true)
+			tr2.EXPECT().ClassifiedPacketLoss(PacketNumber(42), true)
+			tracer.ClassifiedPacketLoss(42, true)
+		})
+
+		It("traces the datagram events", func() {
+			tr1.EXPECT().SentDatagram([]byte("foo"))
+			tr2.EXPECT().SentDatagram([]byte("foo"))
+			tracer.SentDatagram([]byte("foo"))
+			tr1.EXPECT().ReceivedDatagram([]byte("bar"))
+			tr2.EXPECT().ReceivedDatagram([]byte("bar"))
+			tracer.ReceivedDatagram([]byte("bar"))
+		})
+
+		It("traces the plaintext packet events", func() {
+			tr1.EXPECT().SentPlaintextPacket(Encryption1RTT, []byte("foo"))
+			tr2.EXPECT().SentPlaintextPacket(Encryption1RTT, []byte("foo"))
+			tracer.SentPlaintextPacket(Encryption1RTT, []byte("foo"))
+			tr1.EXPECT().ReceivedPlaintextPacket(EncryptionHandshake, []byte("bar"))
+			tr2.EXPECT().ReceivedPlaintextPacket(EncryptionHandshake, []byte("bar"))
+			tracer.ReceivedPlaintextPacket(EncryptionHandshake, []byte("bar"))
+		})
+
+		It("traces the DATAGRAM frame events", func() {
+			tr1.EXPECT().SentDatagramFrame(ByteCount(100))
+			tr2.EXPECT().SentDatagramFrame(ByteCount(100))
+			tracer.SentDatagramFrame(100)
+			tr1.EXPECT().ReceivedDatagramFrame(ByteCount(200))
+			tr2.EXPECT().ReceivedDatagramFrame(ByteCount(200))
+			tracer.ReceivedDatagramFrame(200)
+			tr1.EXPECT().DroppedDatagramFrame(ByteCount(300), DatagramDropReceiveQueueFull)
+			tr2.EXPECT().DroppedDatagramFrame(ByteCount(300), DatagramDropReceiveQueueFull)
+			tracer.DroppedDatagramFrame(300, DatagramDropReceiveQueueFull)
+		})
+
+		It("traces flow control events", func() {
+			tr1.EXPECT().SentFlowControlUpdate(InvalidStreamID, ByteCount(1000), ByteCount(500))
+			tr2.EXPECT().SentFlowControlUpdate(InvalidStreamID, ByteCount(1000), ByteCount(500))
+			tracer.SentFlowControlUpdate(InvalidStreamID, 1000, 500)
+			tr1.EXPECT().ReceivedFlowControlUpdate(StreamID(4), ByteCount(2000), time.Second)
+			tr2.EXPECT().ReceivedFlowControlUpdate(StreamID(4), ByteCount(2000), time.Second)
+			tracer.ReceivedFlowControlUpdate(4, 2000, time.Second)
+			tr1.EXPECT().BlockedByFlowControl(StreamID(4), ByteCount(3000))
+			tr2.EXPECT().BlockedByFlowControl(StreamID(4), ByteCount(3000))
+			tracer.BlockedByFlowControl(4, 3000)
+		})
+
+		It("traces the PacerDelayedSend event", func() {
+			now := time.Now()
+			tr1.EXPECT().PacerDelayedSend(ByteCount(500), now)
+			tr2.EXPECT().PacerDelayedSend(ByteCount(500), now)
+			tracer.PacerDelayedSend(500, now)
+		})
+
+		It("traces the LimitedAckRanges event", func() {
+			tr1.EXPECT().LimitedAckRanges(EncryptionHandshake, 3, 5)
+			tr2.EXPECT().LimitedAckRanges(EncryptionHandshake, 3, 5)
+			tracer.LimitedAckRanges(EncryptionHandshake, 3, 5)
+		})
+
+		It("traces the SkippedPacketNumber event", func() {
+			tr1.EXPECT().SkippedPacketNumber(PacketNumber(42))
+			tr2.EXPECT().SkippedPacketNumber(PacketNumber(42))
+			tracer.SkippedPacketNumber(PacketNumber(42))
+		})
+
+		It("traces the UpdatedMaxDatagramSize event", func() {
+			tr1.EXPECT().UpdatedMaxDatagramSize(ByteCount(1400))
+			tr2.EXPECT().UpdatedMaxDatagramSize(ByteCount(1400))
+			tracer.UpdatedMaxDatagramSize(1400)
+		})
+
 		It("traces the UpdatedPTOCount event", func() {
 			tr1.EXPECT().UpdatedPTOCount(uint32(88))
 			tr2.EXPECT().UpdatedPTOCount(uint32(88))
@@ -238,6 +336,15 @@ var _ = Describe("Tracing", func() {
 			tracer.DroppedKey(123)
 		})
 
+		It("traces the 0-RTT events", func() {
+			tr1.EXPECT().Accepted0RTT()
+			tr2.EXPECT().Accepted0RTT()
+			tracer.Accepted0RTT()
+			tr1.EXPECT().Rejected0RTT(ZeroRTTRejectedTransportParameters)
+			tr2.EXPECT().Rejected0RTT(ZeroRTTRejectedTransportParameters)
+			tracer.Rejected0RTT(ZeroRTTRejectedTransportParameters)
+		})
+
 		It("traces the SetLossTimer event", func() {
 			now := time.Now()
 			tr1.EXPECT().SetLossTimer(TimerTypePTO, EncryptionHandshake, now)
@@ -58,6 +58,30 @@ const (
 	PacketDropDuplicate
 )
 
+// DatagramDropReason is the reason why a DATAGRAM frame was dropped
+type DatagramDropReason uint8
+
+const (
+	// DatagramDropReceiveQueueFull is used when a received DATAGRAM frame is dropped because the application doesn't read them fast enough
+	DatagramDropReceiveQueueFull DatagramDropReason = iota
+	// DatagramDropTooLarge is used when a DATAGRAM frame is dropped because it doesn't fit into a packet
+	DatagramDropTooLarge
+	// DatagramDropConnectionClosed is used when a queued DATAGRAM frame is dropped because the connection was closed
+	DatagramDropConnectionClosed
+)
+
+// ZeroRTTRejectReason is the reason why 0-RTT was rejected
+type ZeroRTTRejectReason uint8
+
+const (
+	// ZeroRTTRejectedByServer is used by the client when the server rejected 0-RTT
+	ZeroRTTRejectedByServer ZeroRTTRejectReason = iota
+	// ZeroRTTRejectedInvalidTicket is used by the server when the transport parameters couldn't be restored from the session ticket
+	ZeroRTTRejectedInvalidTicket
+	// ZeroRTTRejectedTransportParameters is used by the server when its transport parameters changed in a way that prevents 0-RTT
+	ZeroRTTRejectedTransportParameters
+)
+
 // TimerType is the type of the loss detection timer
 type TimerType uint8
 
@@ -85,7 +109,7 @@ type CongestionState uint8
 const (
 	// CongestionStateSlowStart is the slow start phase of Reno / Cubic
 	CongestionStateSlowStart CongestionState = iota
-	// CongestionStateCongestionAvoidance is the low slow start phase of Reno / Cubic (only hystart++)
+	// CongestionStateLowSlowStart is the Conservative Slow Start phase of HyStart++ (RFC 9406)
 	CongestionStateLowSlowStart
 	// CongestionStateCongestionAvoidance is the slow start phase of Reno / Cubic
 	CongestionStateCongestionAvoidance
@@ -94,3 +118,35 @@ const (
 	// CongestionStateApplicationLimited means that the congestion controller is application limited
 	CongestionStateApplicationLimited
 )
+
+// FrameSources is a set of frame sources that the packet packer considers when composing a packet.
+type FrameSources uint8
+
+const (
+	// FrameSourceAck means that an ACK frame was queued
+	FrameSourceAck FrameSources = 1 << iota
+	// FrameSourceCrypto means that CRYPTO data was queued
+	FrameSourceCrypto
+	// FrameSourceRetransmission means that frames of lost packets were queued for retransmission
+	FrameSourceRetransmission
+	// FrameSourceData means that control frames or stream data were queued
+	FrameSourceData
+	// FrameSourceDatagram means that a DATAGRAM frame was queued
+	FrameSourceDatagram
+)
+
+// A PacketComposition describes how a packet was composed.
+// The budgets are the number of bytes that could be sent when the packet was composed.
+// They are MaxByteCount if the respective limit doesn't apply.
+type PacketComposition struct {
+	// the frame sources that had frames queued
+	Considered FrameSources
+	// the maximum size of the packet, i.e. the space left in the datagram
+	MaxSize ByteCount
+	// the number of padding bytes added to the packet
+	Padding ByteCount
+
+	PacingBudget           ByteCount
+	CongestionWindowBudget ByteCount
+	AmplificationBudget    ByteCount
+}
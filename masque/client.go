@@ -0,0 +1,89 @@
+package masque
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/lucas-clemente/quic-go/http3"
+)
+
+// Dialer establishes proxied UDP connections through a proxy.
+// Connections through the same proxy share a single QUIC connection.
+type Dialer struct {
+	// RoundTripper is used to send the CONNECT requests.
+	// If nil, a RoundTripper with default values is used.
+	// Datagrams are enabled.
+	RoundTripper *http3.RoundTripper
+
+	initOnce sync.Once
+}
+
+func (d *Dialer) init() {
+	d.initOnce.Do(func() {
+		if d.RoundTripper == nil {
+			d.RoundTripper = &http3.RoundTripper{}
+		}
+		d.RoundTripper.EnableDatagrams = true
+	})
+}
+
+// Dial establishes a proxied UDP connection to target (host:port).
+// template is the URI template of the proxy, for example
+// "https://proxy.example.com/.well-known/masque/udp/{target_host}/{target_port}/".
+// The context is used for sending the CONNECT request and receiving the response.
+// The Conn is independent from the context once it was established.
+// If the proxy responds with a status code other than 2xx, the response is returned together with an error.
+func (d *Dialer) Dial(ctx context.Context, template, target string) (*http.Response, *Conn, error) {
+	d.init()
+
+	host, port, err := net.SplitHostPort(target)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := compileTemplate(template); err != nil {
+		return nil, nil, err
+	}
+	u, err := url.Parse(expandTemplate(template, host, port))
+	if err != nil {
+		return nil, nil, err
+	}
+	req := (&http.Request{
+		Method: http.MethodConnect,
+		Header: http.Header{capsuleProtocolHeader: []string{capsuleProtocolHeaderValue}},
+		Proto:  protocolName,
+		Host:   u.Host,
+		URL:    u,
+	}).WithContext(ctx)
+	rsp, err := d.RoundTripper.RoundTrip(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rsp.StatusCode < 200 || rsp.StatusCode >= 300 {
+		rsp.Body.Close()
+		return rsp, nil, fmt.Errorf("masque: received status %d", rsp.StatusCode)
+	}
+	streamer, ok := rsp.Body.(http3.DataStreamer)
+	if !ok {
+		rsp.Body.Close()
+		return rsp, nil, errors.New("masque: response body doesn't implement http3.DataStreamer")
+	}
+	hijacker, ok := rsp.Body.(http3.Hijacker)
+	if !ok {
+		rsp.Body.Close()
+		return rsp, nil, errors.New("masque: response body doesn't implement http3.Hijacker")
+	}
+	str := streamer.DataStream()
+	return rsp, establishConn(hijacker.Session(), str, rsp.Body, target, true), nil
+}
+
+// Close closes the QUIC connections used by the Dialer.
+// All proxied connections are closed.
+func (d *Dialer) Close() error {
+	d.init()
+	return d.RoundTripper.Close()
+}
@@ -0,0 +1,287 @@
+package masque
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/http3"
+	"github.com/lucas-clemente/quic-go/quicvarint"
+)
+
+// maxQueuedDatagrams is the maximum number of datagrams that are queued for a Conn before they are received.
+const maxQueuedDatagrams = 32
+
+var (
+	errClosed       = errors.New("masque: connection closed")
+	errClosedByPeer = errors.New("masque: connection closed by peer")
+)
+
+type contextDatagram struct {
+	id   ContextID
+	data []byte
+}
+
+// A Conn proxies UDP payloads between a client and a target, through a proxy.
+// It is established by a CONNECT request with the connect-udp protocol.
+// Datagrams are sent as HTTP Datagrams, on the QUIC connection that the request was sent on.
+// If the peer doesn't support QUIC datagrams, they are sent in DATAGRAM capsules on the request stream.
+type Conn struct {
+	str   quic.Stream
+	qsess quic.Session
+
+	target      string
+	isClient    bool
+	datagramHdr []byte
+
+	unregisterDatagrams func()
+
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+
+	writeMutex sync.Mutex // for capsules written to the request stream
+
+	mutex         sync.Mutex
+	closeErr      error
+	nextContextID ContextID
+	contextIDs    map[ContextID]struct{} // the registered context IDs, other than 0
+
+	datagrams        chan []byte
+	contextDatagrams chan contextDatagram
+}
+
+// establishConn establishes a Conn, after the CONNECT request was accepted.
+// r reads the payload of the DATA frames on the request stream.
+func establishConn(qsess quic.Session, str quic.Stream, r io.Reader, target string, isClient bool) *Conn {
+	conn := newConn(str, qsess, target, isClient)
+	conn.unregisterDatagrams = http3.RegisterDatagramHandler(qsess, str.StreamID(), conn.handleDatagram)
+	go conn.run(r)
+	return conn
+}
+
+func newConn(str quic.Stream, qsess quic.Session, target string, isClient bool) *Conn {
+	datagramHdr := &bytes.Buffer{}
+	// The quarter stream ID, see RFC 9297
+	quicvarint.Write(datagramHdr, uint64(str.StreamID())/4)
+	ctx, cancel := context.WithCancel(qsess.Context())
+	nextContextID := ContextID(1)
+	if isClient {
+		nextContextID = 2
+	}
+	return &Conn{
+		str:              str,
+		qsess:            qsess,
+		target:           target,
+		isClient:         isClient,
+		datagramHdr:      datagramHdr.Bytes(),
+		ctx:              ctx,
+		ctxCancel:        cancel,
+		nextContextID:    nextContextID,
+		contextIDs:       make(map[ContextID]struct{}),
+		datagrams:        make(chan []byte, maxQueuedDatagrams),
+		contextDatagrams: make(chan contextDatagram, maxQueuedDatagrams),
+	}
+}
+
+// run reads the capsules from the request stream, until the stream is closed.
+func (c *Conn) run(r io.Reader) {
+	readCapsules(r, c.handleDatagram)
+	if c.closeWithError(errClosedByPeer) {
+		c.str.Close()
+	}
+}
+
+// handleDatagram handles an HTTP Datagram, after the quarter stream ID was removed.
+// Datagrams with an unknown context ID are dropped, see RFC 9298, section 4.
+func (c *Conn) handleDatagram(data []byte) {
+	r := bytes.NewReader(data)
+	id, err := quicvarint.Read(r)
+	if err != nil {
+		return
+	}
+	payload := data[len(data)-r.Len():]
+	if id == 0 {
+		select {
+		case c.datagrams <- payload:
+		default:
+			// drop the datagram if the application doesn't receive datagrams fast enough
+		}
+		return
+	}
+	c.mutex.Lock()
+	_, ok := c.contextIDs[ContextID(id)]
+	c.mutex.Unlock()
+	if !ok {
+		return
+	}
+	select {
+	case c.contextDatagrams <- contextDatagram{id: ContextID(id), data: payload}:
+	default:
+	}
+}
+
+// Target returns the target (host:port) of the proxied connection.
+func (c *Conn) Target() string {
+	return c.target
+}
+
+// SendDatagram sends a UDP payload.
+func (c *Conn) SendDatagram(b []byte) error {
+	return c.SendContextDatagram(0, b)
+}
+
+// ReceiveDatagram receives a UDP payload.
+// It blocks until a datagram is received, the Conn is closed or the context is canceled.
+func (c *Conn) ReceiveDatagram(ctx context.Context) ([]byte, error) {
+	select {
+	case data := <-c.datagrams:
+		return data, nil
+	case <-c.ctx.Done():
+		return nil, c.getCloseErr()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// AllocateContextID allocates a new context ID.
+// Clients allocate even context IDs, proxies allocate odd context IDs.
+// Datagrams with this context ID are received by ReceiveContextDatagram.
+// Communicating the context ID to the peer is the responsibility of the extension that uses it.
+func (c *Conn) AllocateContextID() (ContextID, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	id := c.nextContextID
+	if id > quicvarint.Max {
+		return 0, errors.New("masque: too many context IDs")
+	}
+	c.nextContextID += 2
+	c.contextIDs[id] = struct{}{}
+	return id, nil
+}
+
+// RegisterContextID registers a context ID that was allocated by the peer.
+// Datagrams with this context ID are received by ReceiveContextDatagram.
+func (c *Conn) RegisterContextID(id ContextID) error {
+	if id == 0 || id > quicvarint.Max {
+		return fmt.Errorf("masque: invalid context ID: %d", id)
+	}
+	// client-allocated context IDs are even
+	if (id%2 == 0) == c.isClient {
+		return fmt.Errorf("masque: context ID %d was not allocated by the peer", id)
+	}
+	c.mutex.Lock()
+	c.contextIDs[id] = struct{}{}
+	c.mutex.Unlock()
+	return nil
+}
+
+// SendContextDatagram sends a datagram with the given context ID.
+// The context ID must be 0, or it must have been allocated or registered.
+func (c *Conn) SendContextDatagram(id ContextID, b []byte) error {
+	if err := c.getCloseErr(); err != nil {
+		return err
+	}
+	if id != 0 {
+		c.mutex.Lock()
+		_, ok := c.contextIDs[id]
+		c.mutex.Unlock()
+		if !ok {
+			return fmt.Errorf("masque: unknown context ID: %d", id)
+		}
+	}
+	if !c.qsess.ConnectionState().SupportsDatagrams {
+		payload := make([]byte, 0, int(quicvarint.Len(uint64(id)))+len(b))
+		payload = append(payload, contextIDBytes(id)...)
+		payload = append(payload, b...)
+		c.writeMutex.Lock()
+		defer c.writeMutex.Unlock()
+		return writeDatagramCapsule(c.str, payload)
+	}
+	data := make([]byte, 0, len(c.datagramHdr)+int(quicvarint.Len(uint64(id)))+len(b))
+	data = append(data, c.datagramHdr...)
+	data = append(data, contextIDBytes(id)...)
+	data = append(data, b...)
+	return c.qsess.SendMessage(data)
+}
+
+// ReceiveContextDatagram receives a datagram with a context ID other than 0.
+// Only datagrams with context IDs that were allocated or registered are received.
+// It blocks until a datagram is received, the Conn is closed or the context is canceled.
+func (c *Conn) ReceiveContextDatagram(ctx context.Context) (ContextID, []byte, error) {
+	select {
+	case d := <-c.contextDatagrams:
+		return d.id, d.data, nil
+	case <-c.ctx.Done():
+		return 0, nil, c.getCloseErr()
+	case <-ctx.Done():
+		return 0, nil, ctx.Err()
+	}
+}
+
+func contextIDBytes(id ContextID) []byte {
+	b := &bytes.Buffer{}
+	quicvarint.Write(b, uint64(id))
+	return b.Bytes()
+}
+
+// Context returns a context that is canceled when the Conn is closed.
+func (c *Conn) Context() context.Context {
+	return c.ctx
+}
+
+// LocalAddr returns the local address of the QUIC connection.
+func (c *Conn) LocalAddr() net.Addr {
+	return c.qsess.LocalAddr()
+}
+
+// RemoteAddr returns the remote address of the QUIC connection.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.qsess.RemoteAddr()
+}
+
+// Close closes the Conn, by closing the request stream.
+// The QUIC connection is not closed, since it might be used by other requests.
+func (c *Conn) Close() error {
+	if !c.closeWithError(errClosed) {
+		return nil
+	}
+	c.str.CancelRead(errorCodeNoError)
+	return c.str.Close()
+}
+
+// closeWithError closes the Conn.
+// It returns false if the Conn was already closed.
+func (c *Conn) closeWithError(e error) bool {
+	c.mutex.Lock()
+	if c.closeErr != nil {
+		c.mutex.Unlock()
+		return false
+	}
+	c.closeErr = e
+	c.mutex.Unlock()
+
+	c.ctxCancel()
+	c.unregisterDatagrams()
+	return true
+}
+
+func (c *Conn) getCloseErr() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.closeErr != nil {
+		return c.closeErr
+	}
+	// The QUIC connection might have been closed.
+	select {
+	case <-c.ctx.Done():
+		return errors.New("masque: QUIC connection closed")
+	default:
+	}
+	return nil
+}
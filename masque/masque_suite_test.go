@@ -0,0 +1,13 @@
+package masque
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestMasque(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "MASQUE Suite")
+}
@@ -0,0 +1,194 @@
+package masque
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/http3"
+	"github.com/lucas-clemente/quic-go/internal/testdata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MASQUE", func() {
+	var (
+		server   *Server
+		dialer   *Dialer
+		conn     *net.UDPConn
+		echo     *net.UDPConn
+		connChan chan *Conn
+		template string
+		upgrade  bool // if set, the handler calls Upgrade instead of Proxy
+	)
+
+	BeforeEach(func() {
+		var err error
+		echo, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).ToNot(HaveOccurred())
+		go func() {
+			b := make([]byte, 1500)
+			for {
+				n, addr, err := echo.ReadFrom(b)
+				if err != nil {
+					return
+				}
+				echo.WriteTo(b[:n], addr)
+			}
+		}()
+
+		connChan = make(chan *Conn, 1)
+		upgrade = false
+		server = &Server{
+			H3: http3.Server{Server: &http.Server{TLSConfig: testdata.GetTLSConfig()}},
+			CheckTarget: func(_ *http.Request, target string) bool {
+				return target != "127.0.0.1:1"
+			},
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/.well-known/masque/udp/", func(w http.ResponseWriter, r *http.Request) {
+			if !upgrade {
+				server.Proxy(w, r)
+				return
+			}
+			c, err := server.Upgrade(w, r)
+			if err != nil {
+				return
+			}
+			connChan <- c
+		})
+		server.H3.Handler = mux
+		conn, err = net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).ToNot(HaveOccurred())
+		go server.Serve(conn)
+		template = fmt.Sprintf("https://localhost:%d%s", conn.LocalAddr().(*net.UDPAddr).Port, DefaultTemplate)
+
+		dialer = &Dialer{RoundTripper: &http3.RoundTripper{
+			TLSClientConfig: &tls.Config{RootCAs: testdata.GetRootCA()},
+			QuicConfig:      &quic.Config{Versions: []quic.VersionNumber{quic.VersionDraft29}},
+		}}
+	})
+
+	AfterEach(func() {
+		Expect(dialer.Close()).To(Succeed())
+		Expect(server.Close()).To(Succeed())
+		conn.Close()
+		echo.Close()
+	})
+
+	dial := func(template string) (*Conn, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, c, err := dialer.Dial(ctx, template, echo.LocalAddr().String())
+		return c, err
+	}
+
+	It("proxies UDP payloads", func() {
+		c, err := dial(template)
+		Expect(err).ToNot(HaveOccurred())
+		defer c.Close()
+		Expect(c.Target()).To(Equal(echo.LocalAddr().String()))
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		// The first datagram might be dropped, before the proxy dialed the target.
+		Eventually(func() []byte {
+			Expect(c.SendDatagram([]byte("ping"))).To(Succeed())
+			ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+			defer cancel()
+			data, _ := c.ReceiveDatagram(ctx)
+			return data
+		}).Should(Equal([]byte("ping")))
+	})
+
+	It("rejects targets that are not allowed", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		rsp, c, err := dialer.Dial(ctx, template, "127.0.0.1:1")
+		Expect(err).To(MatchError("masque: received status 403"))
+		Expect(rsp.StatusCode).To(Equal(http.StatusForbidden))
+		Expect(c).To(BeNil())
+	})
+
+	It("rejects all targets if CheckTarget is not set", func() {
+		req := httptest.NewRequest(http.MethodConnect, expandTemplate(DefaultTemplate, "127.0.0.1", "1234"), nil)
+		req.Proto = protocolName
+		req.Header.Set(capsuleProtocolHeader, capsuleProtocolHeaderValue)
+		w := httptest.NewRecorder()
+		_, err := (&Server{}).checkRequest(w, req)
+		Expect(err).To(MatchError("masque: target not allowed: 127.0.0.1:1234"))
+		Expect(w.Code).To(Equal(http.StatusForbidden))
+	})
+
+	It("rejects requests that don't match the template", func() {
+		port := conn.LocalAddr().(*net.UDPAddr).Port
+		_, err := dial(fmt.Sprintf("https://localhost:%d/.well-known/masque/udp/{target_host}/{target_port}/foo", port))
+		Expect(err).To(MatchError("masque: received status 400"))
+	})
+
+	Context("using context IDs", func() {
+		BeforeEach(func() {
+			upgrade = true
+		})
+
+		establish := func() (*Conn, *Conn) {
+			clientConn, err := dial(template)
+			Expect(err).ToNot(HaveOccurred())
+			var serverConn *Conn
+			Eventually(connChan).Should(Receive(&serverConn))
+			return clientConn, serverConn
+		}
+
+		It("allocates context IDs", func() {
+			clientConn, serverConn := establish()
+			id1, err := clientConn.AllocateContextID()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(id1).To(BeEquivalentTo(2))
+			id2, err := clientConn.AllocateContextID()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(id2).To(BeEquivalentTo(4))
+			id, err := serverConn.AllocateContextID()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(id).To(BeEquivalentTo(1))
+			Expect(serverConn.RegisterContextID(3)).To(MatchError("masque: context ID 3 was not allocated by the peer"))
+			Expect(clientConn.RegisterContextID(6)).To(MatchError("masque: context ID 6 was not allocated by the peer"))
+			Expect(clientConn.RegisterContextID(0)).To(MatchError("masque: invalid context ID: 0"))
+			Expect(clientConn.SendContextDatagram(42, []byte("foo"))).To(MatchError("masque: unknown context ID: 42"))
+		})
+
+		It("sends datagrams with context IDs", func() {
+			clientConn, serverConn := establish()
+			id, err := clientConn.AllocateContextID()
+			Expect(err).ToNot(HaveOccurred())
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			// datagrams with unknown context IDs are dropped
+			Expect(clientConn.SendContextDatagram(id, []byte("dropped"))).To(Succeed())
+			Expect(clientConn.SendDatagram([]byte("udp"))).To(Succeed())
+			data, err := serverConn.ReceiveDatagram(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(data).To(Equal([]byte("udp")))
+
+			Expect(serverConn.RegisterContextID(id)).To(Succeed())
+			Expect(clientConn.SendContextDatagram(id, []byte("foobar"))).To(Succeed())
+			rid, data, err := serverConn.ReceiveContextDatagram(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rid).To(Equal(id))
+			Expect(data).To(Equal([]byte("foobar")))
+		})
+
+		It("closes the Conn", func() {
+			clientConn, serverConn := establish()
+			Expect(clientConn.Close()).To(Succeed())
+			Eventually(serverConn.Context().Done()).Should(BeClosed())
+			_, err := serverConn.ReceiveDatagram(context.Background())
+			Expect(err).To(MatchError("masque: connection closed by peer"))
+			Expect(clientConn.SendDatagram([]byte("foo"))).To(MatchError("masque: connection closed"))
+		})
+	})
+})
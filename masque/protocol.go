@@ -0,0 +1,135 @@
+package masque
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lucas-clemente/quic-go/http3"
+	"github.com/lucas-clemente/quic-go/quicvarint"
+)
+
+// This package implements RFC 9298 (Proxying UDP in HTTP), using HTTP Datagrams (RFC 9297).
+const (
+	protocolName = "connect-udp"
+
+	capsuleProtocolHeader      = "Capsule-Protocol"
+	capsuleProtocolHeaderValue = "?1"
+
+	// the DATAGRAM capsule, see RFC 9297, section 3.5
+	capsuleTypeDatagram = 0x00
+
+	// H3_NO_ERROR
+	errorCodeNoError = 0x100
+
+	// maxUDPPayloadSize is the maximum size of a UDP payload
+	maxUDPPayloadSize = 1<<16 - 1
+	// maxDatagramCapsuleLen is the maximum length of a DATAGRAM capsule: a context ID and a UDP payload
+	maxDatagramCapsuleLen = 8 + maxUDPPayloadSize
+)
+
+// DefaultTemplate is the URI template path used if no other template is configured.
+// It is the default template defined in RFC 9298, section 3.
+const DefaultTemplate = "/.well-known/masque/udp/{target_host}/{target_port}/"
+
+const (
+	templateVarHost = "{target_host}"
+	templateVarPort = "{target_port}"
+)
+
+// A ContextID identifies the format of the payload of a datagram, see RFC 9298, section 4.
+// Context ID 0 is used for UDP payloads. Other context IDs are allocated by extensions:
+// clients allocate even context IDs, proxies allocate odd context IDs.
+type ContextID uint64
+
+// expandTemplate fills in the target host and port of a URI template.
+func expandTemplate(template, host, port string) string {
+	return strings.NewReplacer(
+		templateVarHost, escapeTemplateVar(host),
+		templateVarPort, escapeTemplateVar(port),
+	).Replace(template)
+}
+
+// escapeTemplateVar percent-encodes all characters of a variable that are not unreserved,
+// as required for the simple string expansion of RFC 6570.
+// Most importantly, this encodes the colons of IPv6 addresses.
+func escapeTemplateVar(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// compileTemplate compiles a URI template path to a regular expression that matches request URIs.
+// The template must contain both the target_host and the target_port variable.
+func compileTemplate(template string) (*regexp.Regexp, error) {
+	if !strings.Contains(template, templateVarHost) || !strings.Contains(template, templateVarPort) {
+		return nil, fmt.Errorf("masque: template must contain %s and %s: %s", templateVarHost, templateVarPort, template)
+	}
+	expr := regexp.QuoteMeta(template)
+	expr = strings.Replace(expr, regexp.QuoteMeta(templateVarHost), `(?P<host>[^/?&]+)`, 1)
+	expr = strings.Replace(expr, regexp.QuoteMeta(templateVarPort), `(?P<port>[0-9]+)`, 1)
+	return regexp.Compile("^" + expr + "$")
+}
+
+// matchTemplate returns the target (host:port) of a request URI.
+func matchTemplate(re *regexp.Regexp, requestURI string) (string, error) {
+	m := re.FindStringSubmatch(requestURI)
+	if m == nil {
+		return "", fmt.Errorf("masque: request URI doesn't match the template: %s", requestURI)
+	}
+	host, err := url.PathUnescape(m[re.SubexpIndex("host")])
+	if err != nil {
+		return "", err
+	}
+	port, err := strconv.ParseUint(m[re.SubexpIndex("port")], 10, 16)
+	if err != nil || port == 0 {
+		return "", fmt.Errorf("masque: invalid target port: %s", m[re.SubexpIndex("port")])
+	}
+	return net.JoinHostPort(host, strconv.FormatUint(port, 10)), nil
+}
+
+// writeDatagramCapsule writes a DATAGRAM capsule.
+// Capsules are sent in HTTP/3 DATA frames on the request stream.
+func writeDatagramCapsule(w io.Writer, datagram []byte) error {
+	return http3.WriteCapsule(w, capsuleTypeDatagram, datagram)
+}
+
+// readCapsules reads the capsules from r (the payload of the DATA frames on the request stream),
+// until r returns an error.
+// The payloads of DATAGRAM capsules are passed to handleDatagram.
+// All other capsules are ignored.
+func readCapsules(r io.Reader, handleDatagram func([]byte)) error {
+	qr := quicvarint.NewReader(r)
+	for {
+		typ, cr, err := http3.ParseCapsule(qr)
+		if err != nil {
+			return err
+		}
+		if typ != capsuleTypeDatagram {
+			if _, err := io.Copy(ioutil.Discard, cr); err != nil {
+				return err
+			}
+			continue
+		}
+		b, err := ioutil.ReadAll(io.LimitReader(cr, maxDatagramCapsuleLen+1))
+		if err != nil {
+			return err
+		}
+		if len(b) > maxDatagramCapsuleLen {
+			return fmt.Errorf("masque: DATAGRAM capsule too large")
+		}
+		handleDatagram(b)
+	}
+}
@@ -0,0 +1,84 @@
+package masque
+
+import (
+	"bytes"
+	"io"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Protocol", func() {
+	Context("URI templates", func() {
+		It("expands the default template", func() {
+			Expect(expandTemplate("https://proxy.example.com"+DefaultTemplate, "192.0.2.6", "443")).To(Equal("https://proxy.example.com/.well-known/masque/udp/192.0.2.6/443/"))
+		})
+
+		It("percent-encodes IPv6 addresses", func() {
+			Expect(expandTemplate(DefaultTemplate, "2001:db8::42", "443")).To(Equal("/.well-known/masque/udp/2001%3Adb8%3A%3A42/443/"))
+		})
+
+		It("matches request URIs", func() {
+			re, err := compileTemplate(DefaultTemplate)
+			Expect(err).ToNot(HaveOccurred())
+			target, err := matchTemplate(re, "/.well-known/masque/udp/192.0.2.6/443/")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target).To(Equal("192.0.2.6:443"))
+			target, err = matchTemplate(re, expandTemplate(DefaultTemplate, "2001:db8::42", "1337"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target).To(Equal("[2001:db8::42]:1337"))
+		})
+
+		It("matches templates that use the query", func() {
+			re, err := compileTemplate("/masque?h={target_host}&p={target_port}")
+			Expect(err).ToNot(HaveOccurred())
+			target, err := matchTemplate(re, "/masque?h=example.com&p=53")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target).To(Equal("example.com:53"))
+		})
+
+		It("rejects request URIs that don't match", func() {
+			re, err := compileTemplate(DefaultTemplate)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = matchTemplate(re, "/.well-known/masque/udp/192.0.2.6/")
+			Expect(err).To(MatchError("masque: request URI doesn't match the template: /.well-known/masque/udp/192.0.2.6/"))
+			_, err = matchTemplate(re, "/.well-known/masque/udp/192.0.2.6/0/")
+			Expect(err).To(MatchError("masque: invalid target port: 0"))
+			_, err = matchTemplate(re, "/.well-known/masque/udp/192.0.2.6/65536/")
+			Expect(err).To(MatchError("masque: invalid target port: 65536"))
+		})
+
+		It("rejects templates without variables", func() {
+			_, err := compileTemplate("/masque/{target_host}")
+			Expect(err).To(MatchError("masque: template must contain {target_host} and {target_port}: /masque/{target_host}"))
+		})
+	})
+
+	Context("capsules", func() {
+		It("writes and reads DATAGRAM capsules", func() {
+			buf := &bytes.Buffer{}
+			Expect(writeDatagramCapsule(buf, []byte("foo"))).To(Succeed())
+			Expect(writeDatagramCapsule(buf, []byte("bar"))).To(Succeed())
+			// the DATA frame header
+			Expect(buf.Next(2)).To(Equal([]byte{0x0, 5}))
+			capsule := buf.Next(5)
+			buf.Next(2)
+			capsules := append(capsule, buf.Bytes()...)
+			var datagrams []string
+			Expect(readCapsules(bytes.NewReader(capsules), func(b []byte) { datagrams = append(datagrams, string(b)) })).To(MatchError(io.EOF))
+			Expect(datagrams).To(Equal([]string{"foo", "bar"}))
+		})
+
+		It("skips unknown capsules", func() {
+			capsules := []byte{0x21, 3, 'f', 'o', 'o', capsuleTypeDatagram, 3, 'b', 'a', 'r'}
+			var datagrams []string
+			Expect(readCapsules(bytes.NewReader(capsules), func(b []byte) { datagrams = append(datagrams, string(b)) })).To(MatchError(io.EOF))
+			Expect(datagrams).To(Equal([]string{"bar"}))
+		})
+
+		It("errors on truncated capsules", func() {
+			capsules := []byte{capsuleTypeDatagram, 10, 'f', 'o', 'o'}
+			Expect(readCapsules(bytes.NewReader(capsules), func([]byte) {})).To(MatchError(io.ErrUnexpectedEOF))
+		})
+	})
+})
@@ -0,0 +1,190 @@
+package masque
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"sync"
+
+	"github.com/lucas-clemente/quic-go/http3"
+)
+
+// Server is a UDP proxy.
+// Proxied connections are established by HTTP/3 handlers, by calling Proxy or Upgrade.
+// Datagrams are received using http3.RegisterDatagramHandler,
+// so the HTTP/3 server can serve other users of HTTP Datagrams (like WebTransport) at the same time.
+type Server struct {
+	// H3 is the HTTP/3 server that handles the CONNECT requests.
+	// Datagrams are enabled.
+	H3 http3.Server
+
+	// Template is the URI template path that requests are matched against.
+	// It must contain the target_host and target_port variables.
+	// If empty, DefaultTemplate is used.
+	Template string
+
+	// CheckTarget is used to decide if a request may be proxied to a target (host:port).
+	// If nil, all requests are rejected, so that the server can't be used as an open proxy.
+	CheckTarget func(r *http.Request, target string) bool
+
+	initOnce    sync.Once
+	template    *regexp.Regexp
+	templateErr error
+}
+
+func (s *Server) init() {
+	s.initOnce.Do(func() {
+		s.H3.EnableDatagrams = true
+		template := s.Template
+		if template == "" {
+			template = DefaultTemplate
+		}
+		s.template, s.templateErr = compileTemplate(template)
+	})
+}
+
+// ListenAndServe listens on the UDP address s.H3.Addr and serves HTTP/3 requests.
+func (s *Server) ListenAndServe() error {
+	s.init()
+	if s.templateErr != nil {
+		return s.templateErr
+	}
+	return s.H3.ListenAndServe()
+}
+
+// ListenAndServeTLS listens on the UDP address s.H3.Addr and serves HTTP/3 requests.
+func (s *Server) ListenAndServeTLS(certFile, keyFile string) error {
+	s.init()
+	if s.templateErr != nil {
+		return s.templateErr
+	}
+	return s.H3.ListenAndServeTLS(certFile, keyFile)
+}
+
+// Serve serves HTTP/3 requests on an existing UDP connection.
+func (s *Server) Serve(conn net.PacketConn) error {
+	s.init()
+	if s.templateErr != nil {
+		return s.templateErr
+	}
+	return s.H3.Serve(conn)
+}
+
+// Close closes the server.
+// All QUIC connections, and therefore all proxied connections, are closed.
+func (s *Server) Close() error {
+	return s.H3.Close()
+}
+
+// Upgrade accepts a connect-udp request, and returns the Conn.
+// The application is responsible for forwarding the datagrams to and from Conn.Target.
+// It must be called from an HTTP/3 handler of the Server.
+// If the request is not a valid connect-udp request, Upgrade responds with an HTTP error and returns an error.
+// After a successful upgrade, the handler may return, the Conn stays open until it is closed.
+func (s *Server) Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	target, err := s.checkRequest(w, r)
+	if err != nil {
+		return nil, err
+	}
+	return s.accept(w, r, target)
+}
+
+// Proxy accepts a connect-udp request, and proxies datagrams between the client and the target.
+// It must be called from an HTTP/3 handler of the Server.
+// If the request is not a valid connect-udp request, Proxy responds with an HTTP error and returns an error.
+// If the target can't be reached, it responds with 502 (Bad Gateway).
+// Proxy blocks until the proxied connection is closed.
+func (s *Server) Proxy(w http.ResponseWriter, r *http.Request) error {
+	target, err := s.checkRequest(w, r)
+	if err != nil {
+		return err
+	}
+	udpConn, err := net.Dial("udp", target)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return err
+	}
+	conn, err := s.accept(w, r, target)
+	if err != nil {
+		udpConn.Close()
+		return err
+	}
+
+	go func() {
+		defer udpConn.Close()
+		for {
+			data, err := conn.ReceiveDatagram(conn.Context())
+			if err != nil {
+				return
+			}
+			udpConn.Write(data)
+		}
+	}()
+
+	b := make([]byte, maxUDPPayloadSize)
+	for {
+		n, err := udpConn.Read(b)
+		if err != nil {
+			// The UDP socket is closed when the Conn is closed.
+			if conn.Context().Err() != nil {
+				return nil
+			}
+			conn.Close()
+			return err
+		}
+		// Datagrams that are too large for the QUIC connection are dropped.
+		if err := conn.SendDatagram(b[:n]); err != nil && conn.Context().Err() != nil {
+			return nil
+		}
+	}
+}
+
+// checkRequest checks that the request is a valid connect-udp request, and returns the target.
+// If it is not, it responds with an HTTP error.
+func (s *Server) checkRequest(w http.ResponseWriter, r *http.Request) (string, error) {
+	s.init()
+	if s.templateErr != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return "", s.templateErr
+	}
+	if r.Method != http.MethodConnect {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return "", fmt.Errorf("masque: expected CONNECT request, got %s", r.Method)
+	}
+	if r.Proto != protocolName {
+		w.WriteHeader(http.StatusBadRequest)
+		return "", fmt.Errorf("masque: unexpected protocol: %s", r.Proto)
+	}
+	if v := r.Header.Get(capsuleProtocolHeader); v != capsuleProtocolHeaderValue {
+		w.WriteHeader(http.StatusBadRequest)
+		return "", fmt.Errorf("masque: missing or invalid %s header: %q", capsuleProtocolHeader, v)
+	}
+	target, err := matchTemplate(s.template, r.URL.RequestURI())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return "", err
+	}
+	if s.CheckTarget == nil || !s.CheckTarget(r, target) {
+		w.WriteHeader(http.StatusForbidden)
+		return "", fmt.Errorf("masque: target not allowed: %s", target)
+	}
+	return target, nil
+}
+
+func (s *Server) accept(w http.ResponseWriter, r *http.Request, target string) (*Conn, error) {
+	streamer, ok := w.(http3.DataStreamer)
+	if !ok {
+		return nil, errors.New("masque: response writer doesn't implement http3.DataStreamer")
+	}
+	hijacker, ok := w.(http3.Hijacker)
+	if !ok {
+		return nil, errors.New("masque: response writer doesn't implement http3.Hijacker")
+	}
+
+	w.Header().Set(capsuleProtocolHeader, capsuleProtocolHeaderValue)
+	w.WriteHeader(http.StatusOK)
+	str := streamer.DataStream()
+	return establishConn(hijacker.Session(), str, r.Body, target, false), nil
+}
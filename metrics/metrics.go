@@ -0,0 +1,242 @@
+// Package metrics aggregates counters over all QUIC connections and publishes them using expvar.
+//
+// The counters are published as the expvar map "quic" when this package is imported,
+// and are served by the expvar HTTP handler (at /debug/vars). They are incremented by the tracer returned by NewTracer,
+// which can be combined with other tracers using logging.NewMultiplexedTracer.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/logging"
+)
+
+var (
+	// number of connections started, by perspective ("client" or "server")
+	connectionsOpened = new(expvar.Map).Init()
+	// number of connections closed, by perspective
+	connectionsClosed = new(expvar.Map).Init()
+	// number of connections closed before the handshake completed, by reason
+	handshakeFailures = new(expvar.Map).Init()
+	// number of packets declared lost, whose frames were queued for retransmission
+	retransmissions = new(expvar.Int)
+	// number of Version Negotiation packets, by direction ("sent" or "received")
+	versionNegotiation = new(expvar.Map).Init()
+	// number of packets dropped, by reason
+	droppedPackets = new(expvar.Map).Init()
+)
+
+func init() {
+	vars := new(expvar.Map).Init()
+	vars.Set("connections_opened", connectionsOpened)
+	vars.Set("connections_closed", connectionsClosed)
+	vars.Set("handshake_failures", handshakeFailures)
+	vars.Set("retransmissions", retransmissions)
+	vars.Set("version_negotiation", versionNegotiation)
+	vars.Set("dropped_packets", droppedPackets)
+	expvar.Publish("quic", vars)
+}
+
+type tracer struct{}
+
+var _ logging.Tracer = &tracer{}
+
+// NewTracer creates a new tracer that adds the events of all connections to the global counters.
+// Multiple tracers can be used at the same time, they all update the same counters.
+func NewTracer() logging.Tracer {
+	return &tracer{}
+}
+
+func (t *tracer) TracerForConnection(_ context.Context, p logging.Perspective, _ logging.ConnectionID) logging.ConnectionTracer {
+	return &connectionTracer{perspective: perspectiveString(p)}
+}
+
+func (t *tracer) SentPacket(_ net.Addr, hdr *logging.Header, _ logging.ByteCount, _ []logging.Frame) {
+	if logging.PacketTypeFromHeader(hdr) == logging.PacketTypeVersionNegotiation {
+		versionNegotiation.Add("sent", 1)
+	}
+}
+
+func (t *tracer) DroppedPacket(_ net.Addr, _ logging.PacketType, _ logging.ByteCount, reason logging.PacketDropReason) {
+	droppedPackets.Add(dropReasonString(reason), 1)
+}
+
+type connectionTracer struct {
+	perspective string
+
+	mutex             sync.Mutex
+	handshakeComplete bool
+	closeErr          error
+}
+
+var _ logging.ConnectionTracer = &connectionTracer{}
+
+func (t *connectionTracer) StartedConnection(net.Addr, net.Addr, logging.ConnectionID, logging.ConnectionID) {
+	connectionsOpened.Add(t.perspective, 1)
+}
+
+func (t *connectionTracer) NegotiatedVersion(logging.VersionNumber, []logging.VersionNumber, []logging.VersionNumber) {
+}
+
+func (t *connectionTracer) ClosedConnection(err error) {
+	t.mutex.Lock()
+	t.closeErr = err
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) SentTransportParameters(*logging.TransportParameters)     {}
+func (t *connectionTracer) ReceivedTransportParameters(*logging.TransportParameters) {}
+func (t *connectionTracer) RestoredTransportParameters(*logging.TransportParameters) {}
+func (t *connectionTracer) SentPacket(*logging.ExtendedHeader, logging.ByteCount, *logging.AckFrame, []logging.Frame) {
+}
+
+func (t *connectionTracer) ComposedPacket(logging.EncryptionLevel, logging.PacketNumber, *logging.PacketComposition) {
+}
+
+func (t *connectionTracer) ReceivedVersionNegotiationPacket(*logging.Header, []logging.VersionNumber) {
+	versionNegotiation.Add("received", 1)
+}
+
+func (t *connectionTracer) ReceivedRetry(*logging.Header) {}
+func (t *connectionTracer) ReceivedPacket(*logging.ExtendedHeader, logging.ByteCount, []logging.Frame) {
+}
+func (t *connectionTracer) SentDatagram([]byte)                                                {}
+func (t *connectionTracer) ReceivedDatagram([]byte)                                            {}
+func (t *connectionTracer) SentPlaintextPacket(logging.EncryptionLevel, []byte)                {}
+func (t *connectionTracer) ReceivedPlaintextPacket(logging.EncryptionLevel, []byte)            {}
+func (t *connectionTracer) SentDatagramFrame(logging.ByteCount)                                {}
+func (t *connectionTracer) ReceivedDatagramFrame(logging.ByteCount)                            {}
+func (t *connectionTracer) DroppedDatagramFrame(logging.ByteCount, logging.DatagramDropReason) {}
+func (t *connectionTracer) SentFlowControlUpdate(logging.StreamID, logging.ByteCount, logging.ByteCount) {
+}
+func (t *connectionTracer) ReceivedFlowControlUpdate(logging.StreamID, logging.ByteCount, time.Duration) {
+}
+func (t *connectionTracer) BlockedByFlowControl(logging.StreamID, logging.ByteCount) {}
+func (t *connectionTracer) BufferedPacket(logging.PacketType)                        {}
+
+func (t *connectionTracer) DroppedPacket(_ logging.PacketType, _ logging.ByteCount, reason logging.PacketDropReason) {
+	droppedPackets.Add(dropReasonString(reason), 1)
+}
+
+func (t *connectionTracer) UpdatedMetrics(*logging.RTTStats, logging.ByteCount, logging.ByteCount, int) {
+}
+func (t *connectionTracer) UpdatedCongestionMetrics(_, _, _ logging.ByteCount, _ uint64)     {}
+func (t *connectionTracer) AcknowledgedPacket(logging.EncryptionLevel, logging.PacketNumber) {}
+
+func (t *connectionTracer) LostPacket(logging.EncryptionLevel, logging.PacketNumber, logging.PacketLossReason) {
+	retransmissions.Add(1)
+}
+
+func (t *connectionTracer) DetectedPersistentCongestion(time.Duration)                     {}
+func (t *connectionTracer) DetectedSuspend(time.Duration)                                  {}
+func (t *connectionTracer) ClassifiedPacketLoss(logging.PacketNumber, bool)                {}
+func (t *connectionTracer) PacerDelayedSend(logging.ByteCount, time.Time)                  {}
+func (t *connectionTracer) LimitedAckRanges(logging.EncryptionLevel, int, int)             {}
+func (t *connectionTracer) SkippedPacketNumber(logging.PacketNumber)                       {}
+func (t *connectionTracer) UpdatedCongestionState(logging.CongestionState)                 {}
+func (t *connectionTracer) UpdatedPTOCount(uint32)                                         {}
+func (t *connectionTracer) UpdatedMaxDatagramSize(logging.ByteCount)                       {}
+func (t *connectionTracer) UpdatedKeyFromTLS(logging.EncryptionLevel, logging.Perspective) {}
+func (t *connectionTracer) UpdatedKey(logging.KeyPhase, bool)                              {}
+
+func (t *connectionTracer) DroppedEncryptionLevel(encLevel logging.EncryptionLevel) {
+	// The Handshake keys are dropped when the handshake is confirmed.
+	if encLevel != logging.EncryptionHandshake {
+		return
+	}
+	t.mutex.Lock()
+	t.handshakeComplete = true
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) DroppedKey(logging.KeyPhase)                                        {}
+func (t *connectionTracer) Accepted0RTT()                                                      {}
+func (t *connectionTracer) Rejected0RTT(logging.ZeroRTTRejectReason)                           {}
+func (t *connectionTracer) SetLossTimer(logging.TimerType, logging.EncryptionLevel, time.Time) {}
+func (t *connectionTracer) LossTimerExpired(logging.TimerType, logging.EncryptionLevel)        {}
+func (t *connectionTracer) LossTimerCanceled()                                                 {}
+
+func (t *connectionTracer) Close() {
+	connectionsClosed.Add(t.perspective, 1)
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if !t.handshakeComplete {
+		handshakeFailures.Add(handshakeFailureReason(t.closeErr), 1)
+	}
+}
+
+func (t *connectionTracer) Debug(string, string) {}
+
+func perspectiveString(p logging.Perspective) string {
+	if p == logging.PerspectiveClient {
+		return "client"
+	}
+	return "server"
+}
+
+func handshakeFailureReason(err error) string {
+	var (
+		statelessResetErr     *quic.StatelessResetError
+		handshakeTimeoutErr   *quic.HandshakeTimeoutError
+		firstFlightTimeoutErr *quic.FirstFlightTimeoutError
+		fallbackAdvisedErr    *quic.FallbackAdvisedError
+		idleTimeoutErr        *quic.IdleTimeoutError
+		applicationErr        *quic.ApplicationError
+		transportErr          *quic.TransportError
+		versionNegotiationErr *quic.VersionNegotiationError
+	)
+	switch {
+	case errors.As(err, &statelessResetErr):
+		return "stateless_reset"
+	case errors.As(err, &handshakeTimeoutErr), errors.As(err, &firstFlightTimeoutErr), errors.As(err, &fallbackAdvisedErr):
+		return "handshake_timeout"
+	case errors.As(err, &idleTimeoutErr):
+		return "idle_timeout"
+	case errors.As(err, &applicationErr):
+		return "application_error"
+	case errors.As(err, &transportErr):
+		if transportErr.ErrorCode.IsCryptoError() {
+			return "crypto_error"
+		}
+		return "transport_error"
+	case errors.As(err, &versionNegotiationErr):
+		return "version_negotiation"
+	default:
+		return "unknown"
+	}
+}
+
+func dropReasonString(r logging.PacketDropReason) string {
+	switch r {
+	case logging.PacketDropKeyUnavailable:
+		return "key_unavailable"
+	case logging.PacketDropUnknownConnectionID:
+		return "unknown_connection_id"
+	case logging.PacketDropHeaderParseError:
+		return "header_parse_error"
+	case logging.PacketDropPayloadDecryptError:
+		return "payload_decrypt_error"
+	case logging.PacketDropProtocolViolation:
+		return "protocol_violation"
+	case logging.PacketDropDOSPrevention:
+		return "dos_prevention"
+	case logging.PacketDropUnsupportedVersion:
+		return "unsupported_version"
+	case logging.PacketDropUnexpectedPacket:
+		return "unexpected_packet"
+	case logging.PacketDropUnexpectedSourceConnectionID:
+		return "unexpected_source_connection_id"
+	case logging.PacketDropUnexpectedVersion:
+		return "unexpected_version"
+	case logging.PacketDropDuplicate:
+		return "duplicate"
+	default:
+		return "unknown"
+	}
+}
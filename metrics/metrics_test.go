@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"net"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Metrics", func() {
+	// getCounter returns the current value of a counter published in the "quic" map.
+	// For counters that are maps, key selects the counter in the map.
+	getCounter := func(name, key string) int64 {
+		var vars map[string]json.RawMessage
+		ExpectWithOffset(1, json.Unmarshal([]byte(expvar.Get("quic").String()), &vars)).To(Succeed())
+		if key == "" {
+			var v int64
+			ExpectWithOffset(1, json.Unmarshal(vars[name], &v)).To(Succeed())
+			return v
+		}
+		var m map[string]int64
+		ExpectWithOffset(1, json.Unmarshal(vars[name], &m)).To(Succeed())
+		return m[key]
+	}
+
+	newConnectionTracer := func(p logging.Perspective) logging.ConnectionTracer {
+		return NewTracer().TracerForConnection(context.Background(), p, logging.ConnectionID{1, 2, 3, 4})
+	}
+
+	It("publishes the counters", func() {
+		var vars map[string]interface{}
+		Expect(json.Unmarshal([]byte(expvar.Get("quic").String()), &vars)).To(Succeed())
+		Expect(vars).To(HaveKey("connections_opened"))
+		Expect(vars).To(HaveKey("connections_closed"))
+		Expect(vars).To(HaveKey("handshake_failures"))
+		Expect(vars).To(HaveKey("retransmissions"))
+		Expect(vars).To(HaveKey("version_negotiation"))
+		Expect(vars).To(HaveKey("dropped_packets"))
+	})
+
+	It("counts opened and closed connections", func() {
+		clientOpened := getCounter("connections_opened", "client")
+		serverOpened := getCounter("connections_opened", "server")
+		serverClosed := getCounter("connections_closed", "server")
+		tr := newConnectionTracer(logging.PerspectiveServer)
+		tr.StartedConnection(&net.UDPAddr{}, &net.UDPAddr{}, nil, nil)
+		Expect(getCounter("connections_opened", "server")).To(Equal(serverOpened + 1))
+		Expect(getCounter("connections_opened", "client")).To(Equal(clientOpened))
+		tr.DroppedEncryptionLevel(logging.EncryptionHandshake)
+		tr.ClosedConnection(&quic.IdleTimeoutError{})
+		tr.Close()
+		Expect(getCounter("connections_closed", "server")).To(Equal(serverClosed + 1))
+	})
+
+	It("counts handshake failures by reason", func() {
+		timeouts := getCounter("handshake_failures", "handshake_timeout")
+		cryptoErrors := getCounter("handshake_failures", "crypto_error")
+		idleTimeouts := getCounter("handshake_failures", "idle_timeout")
+
+		tr := newConnectionTracer(logging.PerspectiveClient)
+		tr.ClosedConnection(&quic.HandshakeTimeoutError{})
+		tr.Close()
+		tr = newConnectionTracer(logging.PerspectiveServer)
+		tr.DroppedEncryptionLevel(logging.EncryptionInitial)
+		tr.ClosedConnection(&quic.TransportError{ErrorCode: 0x12a})
+		tr.Close()
+		// the handshake completed, this is not a handshake failure
+		tr = newConnectionTracer(logging.PerspectiveClient)
+		tr.DroppedEncryptionLevel(logging.EncryptionHandshake)
+		tr.ClosedConnection(&quic.IdleTimeoutError{})
+		tr.Close()
+
+		Expect(getCounter("handshake_failures", "handshake_timeout")).To(Equal(timeouts + 1))
+		Expect(getCounter("handshake_failures", "crypto_error")).To(Equal(cryptoErrors + 1))
+		Expect(getCounter("handshake_failures", "idle_timeout")).To(Equal(idleTimeouts))
+	})
+
+	It("counts retransmissions", func() {
+		retransmissions := getCounter("retransmissions", "")
+		tr := newConnectionTracer(logging.PerspectiveClient)
+		tr.LostPacket(logging.Encryption1RTT, 42, logging.PacketLossReorderingThreshold)
+		tr.LostPacket(logging.Encryption1RTT, 43, logging.PacketLossTimeThreshold)
+		Expect(getCounter("retransmissions", "")).To(Equal(retransmissions + 2))
+	})
+
+	It("counts Version Negotiation packets", func() {
+		sent := getCounter("version_negotiation", "sent")
+		received := getCounter("version_negotiation", "received")
+		NewTracer().SentPacket(&net.UDPAddr{}, &logging.Header{IsLongHeader: true}, 100, nil)
+		NewTracer().SentPacket(&net.UDPAddr{}, &logging.Header{IsLongHeader: true, Version: 1}, 100, nil)
+		newConnectionTracer(logging.PerspectiveClient).ReceivedVersionNegotiationPacket(&logging.Header{}, nil)
+		Expect(getCounter("version_negotiation", "sent")).To(Equal(sent + 1))
+		Expect(getCounter("version_negotiation", "received")).To(Equal(received + 1))
+	})
+
+	It("counts dropped packets", func() {
+		dropped := getCounter("dropped_packets", "duplicate")
+		NewTracer().DroppedPacket(&net.UDPAddr{}, logging.PacketTypeInitial, 100, logging.PacketDropDuplicate)
+		newConnectionTracer(logging.PerspectiveClient).DroppedPacket(logging.PacketType1RTT, 100, logging.PacketDropDuplicate)
+		Expect(getCounter("dropped_packets", "duplicate")).To(Equal(dropped + 2))
+	})
+})
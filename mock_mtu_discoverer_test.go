@@ -65,6 +65,20 @@ func (mr *MockMtuDiscovererMockRecorder) NextProbeTime() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NextProbeTime", reflect.TypeOf((*MockMtuDiscoverer)(nil).NextProbeTime))
 }
 
+// Reset mocks base method.
+func (m *MockMtuDiscoverer) Reset() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockMtuDiscovererMockRecorder) Reset() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockMtuDiscoverer)(nil).Reset))
+}
+
 // ShouldSendProbe mocks base method.
 func (m *MockMtuDiscoverer) ShouldSendProbe(now time.Time) bool {
 	m.ctrl.T.Helper()
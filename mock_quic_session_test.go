@@ -267,6 +267,54 @@ func (mr *MockQuicSessionMockRecorder) SendMessage(arg0 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendMessage", reflect.TypeOf((*MockQuicSession)(nil).SendMessage), arg0)
 }
 
+// SetApplicationLimited mocks base method.
+func (m *MockQuicSession) SetApplicationLimited(arg0 bool) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetApplicationLimited", arg0)
+}
+
+// SetApplicationLimited indicates an expected call of SetApplicationLimited.
+func (mr *MockQuicSessionMockRecorder) SetApplicationLimited(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetApplicationLimited", reflect.TypeOf((*MockQuicSession)(nil).SetApplicationLimited), arg0)
+}
+
+// SetLabels mocks base method.
+func (m *MockQuicSession) SetLabels(arg0 map[string]string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetLabels", arg0)
+}
+
+// SetLabels indicates an expected call of SetLabels.
+func (mr *MockQuicSessionMockRecorder) SetLabels(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLabels", reflect.TypeOf((*MockQuicSession)(nil).SetLabels), arg0)
+}
+
+// SetMaxReceiveRate mocks base method.
+func (m *MockQuicSession) SetMaxReceiveRate(arg0 uint64) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetMaxReceiveRate", arg0)
+}
+
+// SetMaxReceiveRate indicates an expected call of SetMaxReceiveRate.
+func (mr *MockQuicSessionMockRecorder) SetMaxReceiveRate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMaxReceiveRate", reflect.TypeOf((*MockQuicSession)(nil).SetMaxReceiveRate), arg0)
+}
+
+// SetMaxSendRate mocks base method.
+func (m *MockQuicSession) SetMaxSendRate(arg0 uint64) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetMaxSendRate", arg0)
+}
+
+// SetMaxSendRate indicates an expected call of SetMaxSendRate.
+func (mr *MockQuicSessionMockRecorder) SetMaxSendRate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMaxSendRate", reflect.TypeOf((*MockQuicSession)(nil).SetMaxSendRate), arg0)
+}
+
 // destroy mocks base method.
 func (m *MockQuicSession) destroy(arg0 error) {
 	m.ctrl.T.Helper()
@@ -7,6 +7,7 @@ package quic
 import (
 	net "net"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -48,6 +49,20 @@ func (mr *MockSendConnMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockSendConn)(nil).Close))
 }
 
+// EnableTxTime mocks base method.
+func (m *MockSendConn) EnableTxTime() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTxTime")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// EnableTxTime indicates an expected call of EnableTxTime.
+func (mr *MockSendConnMockRecorder) EnableTxTime() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTxTime", reflect.TypeOf((*MockSendConn)(nil).EnableTxTime))
+}
+
 // LocalAddr mocks base method.
 func (m *MockSendConn) LocalAddr() net.Addr {
 	m.ctrl.T.Helper()
@@ -89,3 +104,17 @@ func (mr *MockSendConnMockRecorder) Write(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Write", reflect.TypeOf((*MockSendConn)(nil).Write), arg0)
 }
+
+// WriteAt mocks base method.
+func (m *MockSendConn) WriteAt(arg0 []byte, arg1 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteAt", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteAt indicates an expected call of WriteAt.
+func (mr *MockSendConnMockRecorder) WriteAt(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteAt", reflect.TypeOf((*MockSendConn)(nil).WriteAt), arg0, arg1)
+}
@@ -6,6 +6,7 @@ package quic
 
 import (
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -85,6 +86,18 @@ func (mr *MockSenderMockRecorder) Send(p interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockSender)(nil).Send), p)
 }
 
+// SendAt mocks base method.
+func (m *MockSender) SendAt(p *packetBuffer, txTime time.Time) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SendAt", p, txTime)
+}
+
+// SendAt indicates an expected call of SendAt.
+func (mr *MockSenderMockRecorder) SendAt(p, txTime interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendAt", reflect.TypeOf((*MockSender)(nil).SendAt), p, txTime)
+}
+
 // WouldBlock mocks base method.
 func (m *MockSender) WouldBlock() bool {
 	m.ctrl.T.Helper()
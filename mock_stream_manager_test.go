@@ -78,6 +78,18 @@ func (mr *MockStreamManagerMockRecorder) CloseWithError(arg0 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseWithError", reflect.TypeOf((*MockStreamManager)(nil).CloseWithError), arg0)
 }
 
+// Compact mocks base method.
+func (m *MockStreamManager) Compact() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Compact")
+}
+
+// Compact indicates an expected call of Compact.
+func (mr *MockStreamManagerMockRecorder) Compact() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Compact", reflect.TypeOf((*MockStreamManager)(nil).Compact))
+}
+
 // DeleteStream mocks base method.
 func (m *MockStreamManager) DeleteStream(arg0 protocol.StreamID) error {
 	m.ctrl.T.Helper()
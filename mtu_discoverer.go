@@ -13,6 +13,9 @@ type mtuDiscoverer interface {
 	ShouldSendProbe(now time.Time) bool
 	NextProbeTime() time.Time
 	GetPing() (ping ackhandler.Frame, datagramSize protocol.ByteCount)
+	// Reset falls back to the initial datagram size, e.g. when packets of the current size are black-holed.
+	// It returns false if the initial size is already used.
+	Reset() bool
 }
 
 const (
@@ -26,21 +29,23 @@ const (
 type mtuFinder struct {
 	lastProbeTime time.Time
 	probeInFlight bool
-	mtuIncreased  func(protocol.ByteCount)
+	mtuChanged    func(protocol.ByteCount)
 
 	rttStats *utils.RTTStats
+	start    protocol.ByteCount
 	current  protocol.ByteCount
 	max      protocol.ByteCount // the maximum value, as advertised by the peer (or our maximum size buffer)
 }
 
 var _ mtuDiscoverer = &mtuFinder{}
 
-func newMTUDiscoverer(rttStats *utils.RTTStats, start, max protocol.ByteCount, mtuIncreased func(protocol.ByteCount)) mtuDiscoverer {
+func newMTUDiscoverer(rttStats *utils.RTTStats, start, max protocol.ByteCount, mtuChanged func(protocol.ByteCount)) mtuDiscoverer {
 	return &mtuFinder{
+		start:         start,
 		current:       start,
 		rttStats:      rttStats,
 		lastProbeTime: time.Now(), // to make sure the first probe packet is not sent immediately
-		mtuIncreased:  mtuIncreased,
+		mtuChanged:    mtuChanged,
 		max:           max,
 	}
 }
@@ -73,12 +78,28 @@ func (f *mtuFinder) GetPing() (ackhandler.Frame, protocol.ByteCount) {
 		Frame: &wire.PingFrame{},
 		OnLost: func(wire.Frame) {
 			f.probeInFlight = false
-			f.max = size
+			f.max = utils.MinByteCount(f.max, size)
 		},
 		OnAcked: func(wire.Frame) {
 			f.probeInFlight = false
+			// ignore probes that were sent before the MTU discoverer was reset
+			if size <= f.current || size >= f.max {
+				return
+			}
 			f.current = size
-			f.mtuIncreased(size)
+			f.mtuChanged(size)
 		},
 	}, size
 }
+
+func (f *mtuFinder) Reset() bool {
+	if f.current <= f.start {
+		return false
+	}
+	// Packets of the current size didn't make it through, so only search for sizes below that.
+	f.max = f.current
+	f.current = f.start
+	f.lastProbeTime = time.Now()
+	f.mtuChanged(f.start)
+	return true
+}
@@ -68,6 +68,29 @@ var _ = Describe("MTU Discoverer", func() {
 		Expect(size).To(Equal(protocol.ByteCount(1750)))
 	})
 
+	It("falls back to the initial size when reset", func() {
+		Expect(d.Reset()).To(BeFalse())
+		ping, size := d.GetPing()
+		Expect(size).To(Equal(protocol.ByteCount(1500)))
+		ping.OnAcked(ping.Frame)
+		Expect(discoveredMTU).To(Equal(protocol.ByteCount(1500)))
+		Expect(d.Reset()).To(BeTrue())
+		Expect(discoveredMTU).To(Equal(startMTU))
+		// only searches for sizes smaller than the size that was black-holed
+		_, size = d.GetPing()
+		Expect(size).To(Equal(protocol.ByteCount(1250)))
+	})
+
+	It("ignores probes sent before it was reset", func() {
+		ping, _ := d.GetPing()
+		ping.OnAcked(ping.Frame)
+		ping, size := d.GetPing()
+		Expect(size).To(Equal(protocol.ByteCount(1750)))
+		Expect(d.Reset()).To(BeTrue())
+		ping.OnAcked(ping.Frame)
+		Expect(discoveredMTU).To(Equal(startMTU))
+	})
+
 	It("stops discovery after getting close enough to the MTU", func() {
 		var sizes []protocol.ByteCount
 		t := now.Add(5 * rtt)
@@ -0,0 +1,123 @@
+package quic
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+type streamConn struct {
+	Stream
+	sess Session
+}
+
+var _ net.Conn = &streamConn{}
+
+// NewStreamConn returns a net.Conn that reads from and writes to a bidirectional stream.
+// The addresses of the net.Conn are the addresses of the session.
+// Closing the net.Conn closes both directions of the stream, but not the session.
+func NewStreamConn(sess Session, str Stream) net.Conn {
+	return &streamConn{Stream: str, sess: sess}
+}
+
+// DialStreamConn opens a new bidirectional stream, and returns it as a net.Conn.
+// It blocks until a new stream can be opened.
+func DialStreamConn(ctx context.Context, sess Session) (net.Conn, error) {
+	str, err := sess.OpenStreamSync(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewStreamConn(sess, str), nil
+}
+
+func (c *streamConn) LocalAddr() net.Addr  { return c.sess.LocalAddr() }
+func (c *streamConn) RemoteAddr() net.Addr { return c.sess.RemoteAddr() }
+
+func (c *streamConn) Close() error {
+	c.Stream.CancelRead(0)
+	return c.Stream.Close()
+}
+
+type streamListener struct {
+	ln Listener
+
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+
+	conns chan net.Conn
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	closeErr  error
+}
+
+var _ net.Listener = &streamListener{}
+
+// NewStreamListener returns a net.Listener that accepts the bidirectional streams
+// opened by the peers of all sessions accepted by a Listener.
+// Every stream is returned as a net.Conn.
+// Closing the net.Listener closes the Listener, and therefore all of its sessions.
+func NewStreamListener(ln Listener) net.Listener {
+	l := &streamListener{
+		ln:     ln,
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+	l.ctx, l.ctxCancel = context.WithCancel(context.Background())
+	go l.acceptSessions()
+	return l
+}
+
+func (l *streamListener) acceptSessions() {
+	for {
+		sess, err := l.ln.Accept(l.ctx)
+		if err != nil {
+			l.closeWithError(err)
+			return
+		}
+		go l.acceptStreams(sess)
+	}
+}
+
+func (l *streamListener) acceptStreams(sess Session) {
+	for {
+		str, err := sess.AcceptStream(l.ctx)
+		if err != nil {
+			return
+		}
+		select {
+		case l.conns <- NewStreamConn(sess, str):
+		case <-l.closed:
+			str.CancelRead(0)
+			str.CancelWrite(0)
+			return
+		}
+	}
+}
+
+func (l *streamListener) closeWithError(e error) {
+	l.closeOnce.Do(func() {
+		l.closeErr = e
+		close(l.closed)
+	})
+}
+
+// Accept returns the next stream.
+func (l *streamListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, l.closeErr
+	}
+}
+
+func (l *streamListener) Close() error {
+	l.closeWithError(net.ErrClosed)
+	l.ctxCancel()
+	return l.ln.Close()
+}
+
+func (l *streamListener) Addr() net.Addr {
+	return l.ln.Addr()
+}
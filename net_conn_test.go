@@ -0,0 +1,155 @@
+package quic
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type sessionListener struct {
+	sessions chan Session
+	closed   chan struct{}
+}
+
+var _ Listener = &sessionListener{}
+
+func (l *sessionListener) Accept(ctx context.Context) (Session, error) {
+	select {
+	case sess := <-l.sessions:
+		return sess, nil
+	case <-l.closed:
+		return nil, errors.New("listener closed")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (l *sessionListener) Addr() net.Addr { return &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 443} }
+
+func (l *sessionListener) Close() error {
+	close(l.closed)
+	return nil
+}
+
+var _ = Describe("net.Conn and net.Listener adapters", func() {
+	var (
+		sess       *MockQuicSession
+		localAddr  = &net.UDPAddr{IP: net.IPv4(192, 168, 0, 1), Port: 1337}
+		remoteAddr = &net.UDPAddr{IP: net.IPv4(192, 168, 0, 2), Port: 4242}
+	)
+
+	BeforeEach(func() {
+		sess = NewMockQuicSession(mockCtrl)
+		sess.EXPECT().LocalAddr().Return(localAddr).AnyTimes()
+		sess.EXPECT().RemoteAddr().Return(remoteAddr).AnyTimes()
+	})
+
+	Context("net.Conn", func() {
+		It("uses the addresses of the session", func() {
+			conn := NewStreamConn(sess, NewMockStreamI(mockCtrl))
+			Expect(conn.LocalAddr()).To(Equal(localAddr))
+			Expect(conn.RemoteAddr()).To(Equal(remoteAddr))
+		})
+
+		It("reads and writes", func() {
+			str := NewMockStreamI(mockCtrl)
+			conn := NewStreamConn(sess, str)
+			str.EXPECT().Write([]byte("foobar")).Return(6, nil)
+			n, err := conn.Write([]byte("foobar"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(n).To(Equal(6))
+			str.EXPECT().Read(gomock.Any()).DoAndReturn(func(b []byte) (int, error) { return copy(b, "raboof"), nil })
+			b := make([]byte, 6)
+			n, err = conn.Read(b)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(b[:n]).To(Equal([]byte("raboof")))
+		})
+
+		It("closes both directions of the stream", func() {
+			str := NewMockStreamI(mockCtrl)
+			conn := NewStreamConn(sess, str)
+			gomock.InOrder(
+				str.EXPECT().CancelRead(StreamErrorCode(0)),
+				str.EXPECT().Close(),
+			)
+			Expect(conn.Close()).To(Succeed())
+		})
+
+		It("opens a stream", func() {
+			str := NewMockStreamI(mockCtrl)
+			sess.EXPECT().OpenStreamSync(context.Background()).Return(str, nil)
+			conn, err := DialStreamConn(context.Background(), sess)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(conn.(*streamConn).Stream).To(Equal(str))
+		})
+
+		It("returns the error when opening a stream fails", func() {
+			testErr := errors.New("test error")
+			sess.EXPECT().OpenStreamSync(context.Background()).Return(nil, testErr)
+			_, err := DialStreamConn(context.Background(), sess)
+			Expect(err).To(MatchError(testErr))
+		})
+	})
+
+	Context("net.Listener", func() {
+		var (
+			quicLn *sessionListener
+			ln     net.Listener
+		)
+
+		BeforeEach(func() {
+			quicLn = &sessionListener{sessions: make(chan Session, 1), closed: make(chan struct{})}
+			ln = NewStreamListener(quicLn)
+		})
+
+		It("uses the address of the listener", func() {
+			Expect(ln.Addr()).To(Equal(quicLn.Addr()))
+			sess.EXPECT().AcceptStream(gomock.Any()).Return(nil, errors.New("closed")).AnyTimes()
+			Expect(ln.Close()).To(Succeed())
+		})
+
+		It("accepts streams of accepted sessions", func() {
+			str1 := NewMockStreamI(mockCtrl)
+			str2 := NewMockStreamI(mockCtrl)
+			acceptCalled := make(chan struct{})
+			sessDone := make(chan struct{})
+			gomock.InOrder(
+				sess.EXPECT().AcceptStream(gomock.Any()).Return(str1, nil),
+				sess.EXPECT().AcceptStream(gomock.Any()).Return(str2, nil),
+				sess.EXPECT().AcceptStream(gomock.Any()).DoAndReturn(func(context.Context) (Stream, error) {
+					close(acceptCalled)
+					<-sessDone
+					return nil, errors.New("session closed")
+				}),
+			)
+			quicLn.sessions <- sess
+			conn1, err := ln.Accept()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(conn1.(*streamConn).Stream).To(Equal(str1))
+			Expect(conn1.RemoteAddr()).To(Equal(remoteAddr))
+			conn2, err := ln.Accept()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(conn2.(*streamConn).Stream).To(Equal(str2))
+			Eventually(acceptCalled).Should(BeClosed())
+			close(sessDone)
+			Expect(ln.Close()).To(Succeed())
+		})
+
+		It("returns net.ErrClosed after being closed", func() {
+			Expect(ln.Close()).To(Succeed())
+			_, err := ln.Accept()
+			Expect(err).To(MatchError(net.ErrClosed))
+			Expect(quicLn.closed).To(BeClosed())
+		})
+
+		It("returns the error of the listener", func() {
+			quicLn.Close()
+			_, err := ln.Accept()
+			Expect(err).To(MatchError("listener closed"))
+		})
+	})
+})
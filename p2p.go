@@ -0,0 +1,208 @@
+package quic
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+// peerConnection is a connection to a peer, established by DialPeer.
+type peerConnection struct {
+	sess Session
+	// true if this peer dialed the connection
+	isClient  bool
+	peerNonce uint64
+	err       error
+}
+
+// DialPeer establishes a connection to a peer that might be behind a NAT (experimental).
+// Both peers have to call DialPeer at roughly the same time.
+// Every peer listens on conn using ln, and dials the other peer using the same conn (simultaneous open).
+// The packets sent by each peer create the NAT bindings needed for the packets of the other peer.
+//
+// If connections in both directions are established, the peers negotiate which connection to keep:
+// Every peer draws a random tie-breaker and sends it on the first stream of the connection.
+// The connection dialed by the peer with the larger tie-breaker is kept, the other one is closed.
+// If only one connection can be established, that connection is used.
+// As a consequence, the peer might be either the client or the server of the returned session,
+// and the first stream of the session is used by DialPeer.
+//
+// Sessions accepted from other addresses are closed, so ln shouldn't be used for anything else while DialPeer runs.
+// The tls.Config is used for dialing. The ServerName (if set) is used for SNI.
+// Keep-alives are enabled. If the KeepAlivePeriod isn't set, it is set to 10 seconds, to keep the NAT bindings alive.
+// Note that this only applies to the dialed connection. The Listener should use a similar Config.
+func DialPeer(
+	ctx context.Context,
+	ln Listener,
+	conn net.PacketConn,
+	peerAddr net.Addr,
+	tlsConf *tls.Config,
+	config *Config,
+	startAlgo utils.StartAlgo,
+	congestionAlgo utils.CongestionAlgo,
+) (Session, error) {
+	if tlsConf == nil {
+		return nil, errors.New("quic: tls.Config not set")
+	}
+	if config == nil {
+		config = &Config{}
+	} else {
+		config = config.Clone()
+	}
+	config.KeepAlive = true
+	if config.KeepAlivePeriod == 0 {
+		config.KeepAlivePeriod = protocol.NATKeepAliveInterval
+	}
+	nonce, err := generatePeerNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	dialCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	conns := make(chan peerConnection, 2)
+	go func() {
+		sess, err := DialContext(dialCtx, conn, peerAddr, tlsConf.ServerName, tlsConf, config, startAlgo, congestionAlgo)
+		if err != nil {
+			conns <- peerConnection{err: err}
+			return
+		}
+		conns <- exchangePeerNonces(dialCtx, sess, true, nonce)
+	}()
+	go func() {
+		sess, err := acceptPeer(dialCtx, ln, peerAddr)
+		if err != nil {
+			conns <- peerConnection{err: err}
+			return
+		}
+		conns <- exchangePeerNonces(dialCtx, sess, false, nonce)
+	}()
+
+	var fallback *peerConnection
+	var fallbackTimer <-chan time.Time
+	var lastErr error
+	var numDone int
+	// closeRemaining closes the connections that are established after DialPeer returned.
+	closeRemaining := func() {
+		go func() {
+			for ; numDone < 2; numDone++ {
+				if c := <-conns; c.sess != nil {
+					c.sess.CloseWithError(0, "duplicate connection")
+				}
+			}
+		}()
+	}
+	for numDone < 2 {
+		select {
+		case <-ctx.Done():
+			if fallback != nil {
+				fallback.sess.CloseWithError(0, "")
+			}
+			closeRemaining()
+			return nil, ctx.Err()
+		case <-fallbackTimer:
+			closeRemaining()
+			return fallback.sess, nil
+		case c := <-conns:
+			numDone++
+			if c.err != nil {
+				lastErr = c.err
+				continue
+			}
+			// Keep the connection dialed by the peer with the larger tie-breaker.
+			if c.isClient == (nonce > c.peerNonce) {
+				if fallback != nil {
+					fallback.sess.CloseWithError(0, "duplicate connection")
+				}
+				closeRemaining()
+				return c.sess, nil
+			}
+			fallback = &c
+			fallbackTimer = time.After(protocol.PeerRoleNegotiationTimeout)
+		}
+	}
+	if fallback != nil {
+		return fallback.sess, nil
+	}
+	return nil, lastErr
+}
+
+func generatePeerNonce() (uint64, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+// acceptPeer accepts sessions until a session from the peer is accepted.
+func acceptPeer(ctx context.Context, ln Listener, peerAddr net.Addr) (Session, error) {
+	for {
+		sess, err := ln.Accept(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if sess.RemoteAddr().String() == peerAddr.String() {
+			return sess, nil
+		}
+		sess.CloseWithError(0, "unexpected peer")
+	}
+}
+
+// exchangePeerNonces exchanges the tie-breakers on the first stream of a session.
+// The client sends its tie-breaker first.
+// If the exchange fails, the session is closed.
+func exchangePeerNonces(ctx context.Context, sess Session, isClient bool, nonce uint64) peerConnection {
+	peerNonce, err := exchangePeerNoncesImpl(ctx, sess, isClient, nonce)
+	if err != nil {
+		sess.CloseWithError(0, "")
+		return peerConnection{err: err}
+	}
+	return peerConnection{sess: sess, isClient: isClient, peerNonce: peerNonce}
+}
+
+func exchangePeerNoncesImpl(ctx context.Context, sess Session, isClient bool, nonce uint64) (uint64, error) {
+	var str Stream
+	var err error
+	if isClient {
+		str, err = sess.OpenStreamSync(ctx)
+	} else {
+		str, err = sess.AcceptStream(ctx)
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer str.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		str.SetDeadline(deadline)
+	}
+	local := make([]byte, 8)
+	binary.BigEndian.PutUint64(local, nonce)
+	remote := make([]byte, 8)
+	if isClient {
+		if _, err := str.Write(local); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := io.ReadFull(str, remote); err != nil {
+		return 0, err
+	}
+	if !isClient {
+		if _, err := str.Write(local); err != nil {
+			return 0, err
+		}
+	}
+	peerNonce := binary.BigEndian.Uint64(remote)
+	if peerNonce == nonce {
+		return 0, errors.New("peer used the same tie-breaker")
+	}
+	return peerNonce, nil
+}
@@ -0,0 +1,117 @@
+package quic
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/testdata"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Peer-to-peer connections", func() {
+	type peer struct {
+		conn *net.UDPConn
+		ln   Listener
+	}
+
+	var peer1, peer2 peer
+
+	newPeer := func() peer {
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).ToNot(HaveOccurred())
+		tlsConf := testdata.GetTLSConfig()
+		tlsConf.NextProtos = []string{"p2p"}
+		ln, err := Listen(conn, tlsConf, nil)
+		Expect(err).ToNot(HaveOccurred())
+		return peer{conn: conn, ln: ln}
+	}
+
+	clientTLSConf := func() *tls.Config {
+		return &tls.Config{
+			ServerName: "localhost",
+			RootCAs:    testdata.GetRootCA(),
+			NextProtos: []string{"p2p"},
+		}
+	}
+
+	BeforeEach(func() {
+		peer1 = newPeer()
+		peer2 = newPeer()
+	})
+
+	AfterEach(func() {
+		Expect(peer1.ln.Close()).To(Succeed())
+		Expect(peer2.ln.Close()).To(Succeed())
+		Expect(peer1.conn.Close()).To(Succeed())
+		Expect(peer2.conn.Close()).To(Succeed())
+	})
+
+	dialPeer := func(ctx context.Context, p peer, peerAddr net.Addr) <-chan Session {
+		sessChan := make(chan Session, 1)
+		go func() {
+			defer GinkgoRecover()
+			sess, err := DialPeer(ctx, p.ln, p.conn, peerAddr, clientTLSConf(), nil, utils.ChooseHystart, utils.ChooseNewReno)
+			Expect(err).ToNot(HaveOccurred())
+			sessChan <- sess
+		}()
+		return sessChan
+	}
+
+	It("establishes a single connection using simultaneous open", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		sessChan1 := dialPeer(ctx, peer1, peer2.conn.LocalAddr())
+		sessChan2 := dialPeer(ctx, peer2, peer1.conn.LocalAddr())
+		var sess1, sess2 Session
+		Eventually(sessChan1, 5*time.Second).Should(Receive(&sess1))
+		Eventually(sessChan2, 5*time.Second).Should(Receive(&sess2))
+		// exactly one of the peers is the client
+		Expect(sess1.(*session).perspective).ToNot(Equal(sess2.(*session).perspective))
+
+		str, err := sess1.OpenStream()
+		Expect(err).ToNot(HaveOccurred())
+		_, err = str.Write([]byte("foobar"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(str.Close()).To(Succeed())
+		str2, err := sess2.AcceptStream(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		data, err := io.ReadAll(str2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal([]byte("foobar")))
+		sess1.CloseWithError(0, "")
+		sess2.CloseWithError(0, "")
+	})
+
+	It("enables keep-alives for NAT bindings", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		sessChan1 := dialPeer(ctx, peer1, peer2.conn.LocalAddr())
+		sessChan2 := dialPeer(ctx, peer2, peer1.conn.LocalAddr())
+		var sess1, sess2 Session
+		Eventually(sessChan1, 5*time.Second).Should(Receive(&sess1))
+		Eventually(sessChan2, 5*time.Second).Should(Receive(&sess2))
+		client := sess1.(*session)
+		if client.perspective != protocol.PerspectiveClient {
+			client = sess2.(*session)
+		}
+		Expect(client.config.KeepAlive).To(BeTrue())
+		Expect(client.config.KeepAlivePeriod).To(Equal(protocol.NATKeepAliveInterval))
+		sess1.CloseWithError(0, "")
+		sess2.CloseWithError(0, "")
+	})
+
+	It("returns an error when the context is canceled", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		// the peer doesn't exist
+		_, err := DialPeer(ctx, peer1.ln, peer1.conn, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 9}, clientTLSConf(), nil, utils.ChooseHystart, utils.ChooseNewReno)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+	})
+})
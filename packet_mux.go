@@ -0,0 +1,194 @@
+package quic
+
+import (
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+// A PacketMux shares a net.PacketConn between QUIC and other protocols,
+// for example an ICE agent that uses the same port for STUN and DTLS.
+// Packets are demultiplexed by their first byte, see RFC 7983 (as updated by RFC 9443):
+// All packets that have the QUIC fixed bit set are delivered to QUICConn, all other packets
+// (STUN, ZRTP, DTLS and RTP/RTCP) are delivered to OtherConn.
+// Note that TURN channel data (first byte 64 to 79) can't be distinguished from QUIC short header packets,
+// and is therefore delivered to QUICConn.
+type PacketMux struct {
+	conn net.PacketConn
+
+	quicConn  *muxedConn
+	otherConn *muxedConn
+
+	logger utils.Logger
+}
+
+// NewPacketMux starts reading packets from conn.
+// conn must not be read from by anybody else.
+// Packets are written to conn directly.
+func NewPacketMux(conn net.PacketConn) *PacketMux {
+	m := &PacketMux{
+		conn:   conn,
+		logger: utils.DefaultLogger.WithPrefix("packet mux"),
+	}
+	m.quicConn = newMuxedConn(conn)
+	m.otherConn = newMuxedConn(conn)
+	go m.run()
+	return m
+}
+
+// QUICConn returns the net.PacketConn that receives the QUIC packets.
+// It can be passed to Listen and Dial.
+func (m *PacketMux) QUICConn() net.PacketConn {
+	return m.quicConn
+}
+
+// OtherConn returns the net.PacketConn that receives all packets that are not QUIC packets.
+func (m *PacketMux) OtherConn() net.PacketConn {
+	return m.otherConn
+}
+
+// Close closes the underlying net.PacketConn.
+func (m *PacketMux) Close() error {
+	return m.conn.Close()
+}
+
+func (m *PacketMux) run() {
+	for {
+		b := make([]byte, protocol.MaxPacketBufferSize)
+		n, addr, err := m.conn.ReadFrom(b)
+		if err != nil {
+			m.quicConn.closeWithError(err)
+			m.otherConn.closeWithError(err)
+			return
+		}
+		if n == 0 {
+			continue
+		}
+		c := m.otherConn
+		if isQUICPacket(b[0]) {
+			c = m.quicConn
+		}
+		if !c.deliver(b[:n], addr) {
+			m.logger.Debugf("Dropping packet from %s (%d bytes). Receive queue full.", addr, n)
+		}
+	}
+}
+
+// isQUICPacket says if the first byte of a packet corresponds to a QUIC packet, see section 3 of RFC 9443.
+func isQUICPacket(b byte) bool {
+	return b&0x40 > 0
+}
+
+type muxedPacket struct {
+	data []byte
+	addr net.Addr
+}
+
+// A muxedConn is a net.PacketConn that receives one class of packets of a PacketMux.
+// Closing it doesn't close the underlying net.PacketConn.
+// Writes and write deadlines apply to the underlying net.PacketConn.
+type muxedConn struct {
+	net.PacketConn
+
+	queue chan muxedPacket
+
+	mutex           sync.Mutex
+	readDeadline    time.Time
+	deadlineChanged chan struct{}
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	closeErr  error
+}
+
+var _ net.PacketConn = &muxedConn{}
+
+func newMuxedConn(conn net.PacketConn) *muxedConn {
+	return &muxedConn{
+		PacketConn:      conn,
+		queue:           make(chan muxedPacket, protocol.MaxServerUnprocessedPackets),
+		deadlineChanged: make(chan struct{}, 1),
+		closed:          make(chan struct{}),
+	}
+}
+
+func (c *muxedConn) deliver(b []byte, addr net.Addr) bool {
+	select {
+	case c.queue <- muxedPacket{data: b, addr: addr}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *muxedConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	for {
+		c.mutex.Lock()
+		deadline := c.readDeadline
+		c.mutex.Unlock()
+		if !deadline.IsZero() && !deadline.After(time.Now()) {
+			return 0, nil, os.ErrDeadlineExceeded
+		}
+		p, ok, err := c.readPacket(deadline)
+		if err != nil {
+			return 0, nil, err
+		}
+		if ok {
+			return copy(b, p.data), p.addr, nil
+		}
+		// the read deadline was changed
+	}
+}
+
+func (c *muxedConn) readPacket(deadline time.Time) (muxedPacket, bool, error) {
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case p := <-c.queue:
+		return p, true, nil
+	case <-c.closed:
+		return muxedPacket{}, false, c.closeErr
+	case <-timeout:
+		return muxedPacket{}, false, os.ErrDeadlineExceeded
+	case <-c.deadlineChanged:
+		return muxedPacket{}, false, nil
+	}
+}
+
+func (c *muxedConn) SetDeadline(t time.Time) error {
+	if err := c.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.SetWriteDeadline(t)
+}
+
+func (c *muxedConn) SetReadDeadline(t time.Time) error {
+	c.mutex.Lock()
+	c.readDeadline = t
+	c.mutex.Unlock()
+	select {
+	case c.deadlineChanged <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (c *muxedConn) closeWithError(e error) {
+	c.closeOnce.Do(func() {
+		c.closeErr = e
+		close(c.closed)
+	})
+}
+
+func (c *muxedConn) Close() error {
+	c.closeWithError(net.ErrClosed)
+	return nil
+}
@@ -0,0 +1,103 @@
+package quic
+
+import (
+	"net"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Packet Mux", func() {
+	var (
+		mux  *PacketMux
+		peer *net.UDPConn
+	)
+
+	BeforeEach(func() {
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).ToNot(HaveOccurred())
+		mux = NewPacketMux(conn)
+		peer, err = net.DialUDP("udp", nil, conn.LocalAddr().(*net.UDPAddr))
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		mux.Close()
+		Expect(peer.Close()).To(Succeed())
+	})
+
+	read := func(conn net.PacketConn) []byte {
+		b := make([]byte, 100)
+		Expect(conn.SetReadDeadline(time.Now().Add(time.Second))).To(Succeed())
+		n, addr, err := conn.ReadFrom(b)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(addr.String()).To(Equal(peer.LocalAddr().String()))
+		return b[:n]
+	}
+
+	It("classifies packets by their first byte", func() {
+		Expect(isQUICPacket(0x00)).To(BeFalse()) // STUN
+		Expect(isQUICPacket(0x14)).To(BeFalse()) // DTLS
+		Expect(isQUICPacket(0x80)).To(BeFalse()) // RTP
+		Expect(isQUICPacket(0x40)).To(BeTrue())  // short header
+		Expect(isQUICPacket(0xc0)).To(BeTrue())  // long header
+	})
+
+	It("demultiplexes packets", func() {
+		_, err := peer.Write([]byte{0x00, 0x01, 's', 't', 'u', 'n'})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = peer.Write([]byte{0xc3, 'q', 'u', 'i', 'c'})
+		Expect(err).ToNot(HaveOccurred())
+		_, err = peer.Write([]byte{0x16, 'd', 't', 'l', 's'})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(read(mux.QUICConn())).To(Equal([]byte{0xc3, 'q', 'u', 'i', 'c'}))
+		Expect(read(mux.OtherConn())).To(Equal([]byte{0x00, 0x01, 's', 't', 'u', 'n'}))
+		Expect(read(mux.OtherConn())).To(Equal([]byte{0x16, 'd', 't', 'l', 's'}))
+	})
+
+	It("writes to the underlying conn", func() {
+		_, err := mux.OtherConn().WriteTo([]byte("foobar"), peer.LocalAddr())
+		Expect(err).ToNot(HaveOccurred())
+		b := make([]byte, 100)
+		Expect(peer.SetReadDeadline(time.Now().Add(time.Second))).To(Succeed())
+		n, err := peer.Read(b)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(b[:n]).To(Equal([]byte("foobar")))
+	})
+
+	It("times out reads", func() {
+		Expect(mux.QUICConn().SetReadDeadline(time.Now().Add(20 * time.Millisecond))).To(Succeed())
+		_, _, err := mux.QUICConn().ReadFrom(make([]byte, 100))
+		Expect(err).To(MatchError(os.ErrDeadlineExceeded))
+		Expect(err.(net.Error).Timeout()).To(BeTrue())
+	})
+
+	It("unblocks reads when the deadline is changed", func() {
+		done := make(chan error)
+		go func() {
+			_, _, err := mux.QUICConn().ReadFrom(make([]byte, 100))
+			done <- err
+		}()
+		Consistently(done).ShouldNot(Receive())
+		Expect(mux.QUICConn().SetReadDeadline(time.Now())).To(Succeed())
+		Eventually(done).Should(Receive(MatchError(os.ErrDeadlineExceeded)))
+	})
+
+	It("closes the muxed conns independently", func() {
+		Expect(mux.QUICConn().Close()).To(Succeed())
+		_, _, err := mux.QUICConn().ReadFrom(make([]byte, 100))
+		Expect(err).To(MatchError(net.ErrClosed))
+		_, err = peer.Write([]byte{0x00, 0x01})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(read(mux.OtherConn())).To(Equal([]byte{0x00, 0x01}))
+	})
+
+	It("returns the error of the underlying conn", func() {
+		Expect(mux.Close()).To(Succeed())
+		_, _, err := mux.OtherConn().ReadFrom(make([]byte, 100))
+		Expect(err).To(HaveOccurred())
+		Expect(mux.QUICConn().(*muxedConn).closed).To(BeClosed())
+	})
+})
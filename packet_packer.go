@@ -13,6 +13,7 @@ import (
 	"github.com/lucas-clemente/quic-go/internal/qerr"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/lucas-clemente/quic-go/internal/wire"
+	"github.com/lucas-clemente/quic-go/logging"
 )
 
 type packer interface {
@@ -38,6 +39,8 @@ type payload struct {
 	frames []ackhandler.Frame
 	ack    *wire.AckFrame
 	length protocol.ByteCount
+
+	considered logging.FrameSources // the frame sources that had frames queued, only used for tracing
 }
 
 type packedPacket struct {
@@ -53,6 +56,8 @@ type packetContents struct {
 	length protocol.ByteCount
 
 	isMTUProbePacket bool
+
+	composition logging.PacketComposition
 }
 
 type coalescedPacket struct {
@@ -105,9 +110,11 @@ func (p *packetContents) ToAckHandlerPacket(now time.Time, q *retransmissionQueu
 		LargestAcked:         largestAcked,
 		Frames:               p.frames,
 		Length:               p.length,
+		PaddingLength:        p.composition.Padding,
 		EncryptionLevel:      encLevel,
 		SendTime:             now,
 		IsPathMTUProbePacket: p.isMTUProbePacket,
+		Composition:          &p.composition,
 	}
 }
 
@@ -168,6 +175,10 @@ type packetPacker struct {
 
 	maxPacketSize          protocol.ByteCount
 	numNonAckElicitingAcks int
+
+	paddingProfile []protocol.ByteCount // see Config.PaddingProfile
+
+	tracer logging.ConnectionTracer // may be nil
 }
 
 var _ packer = &packetPacker{}
@@ -184,9 +195,15 @@ func newPacketPacker(
 	framer frameSource,
 	acks ackFrameSource,
 	datagramQueue *datagramQueue,
+	paddingProfile []int,
+	tracer logging.ConnectionTracer,
 	perspective protocol.Perspective,
 	version protocol.VersionNumber,
 ) *packetPacker {
+	var profile []protocol.ByteCount
+	for _, size := range paddingProfile {
+		profile = append(profile, protocol.ByteCount(size))
+	}
 	return &packetPacker{
 		cryptoSetup:         cryptoSetup,
 		getDestConnID:       getDestConnID,
@@ -201,6 +218,8 @@ func newPacketPacker(
 		acks:                acks,
 		pnManager:           packetNumberManager,
 		maxPacketSize:       getMaxPacketSize(remoteAddr),
+		paddingProfile:      profile,
+		tracer:              tracer,
 	}
 }
 
@@ -288,6 +307,11 @@ func (p *packetPacker) packConnectionClose(
 		size += p.packetLength(hdr, payload) + protocol.ByteCount(sealer.Overhead())
 		numPackets++
 	}
+	var initialPadding protocol.ByteCount
+	if sealers[0] != nil {
+		initialPadding = p.initialPaddingLen(payloads[0].frames, size)
+	}
+	profilePadding := p.profilePaddingLen(size + initialPadding)
 	contents := make([]*packetContents, 0, numPackets)
 	buffer := getPacketBuffer()
 	for i, encLevel := range encLevels {
@@ -296,7 +320,10 @@ func (p *packetPacker) packConnectionClose(
 		}
 		var paddingLen protocol.ByteCount
 		if encLevel == protocol.EncryptionInitial {
-			paddingLen = p.initialPaddingLen(payloads[i].frames, size)
+			paddingLen = initialPadding
+		}
+		if len(contents) == int(numPackets)-1 { // the profile padding is added to the last packet in the datagram
+			paddingLen += profilePadding
 		}
 		c, err := p.appendPacket(buffer, hdrs[i], payloads[i], paddingLen, encLevel, sealers[i], false)
 		if err != nil {
@@ -341,8 +368,9 @@ func (p *packetPacker) MaybePackAckPacket(handshakeConfirmed bool) (*packedPacke
 		encLevel = protocol.Encryption1RTT
 	}
 	payload := &payload{
-		ack:    ack,
-		length: ack.Length(p.version),
+		ack:        ack,
+		length:     ack.Length(p.version),
+		considered: logging.FrameSourceAck,
 	}
 
 	sealer, hdr, err := p.getSealerAndHeader(encLevel)
@@ -364,6 +392,22 @@ func (p *packetPacker) initialPaddingLen(frames []ackhandler.Frame, size protoco
 	return p.maxPacketSize - size
 }
 
+// profilePaddingLen calculates the number of bytes that need to be added to a datagram according to the padding profile.
+// size is the size of the datagram, including any padding that was already applied.
+func (p *packetPacker) profilePaddingLen(size protocol.ByteCount) protocol.ByteCount {
+	if len(p.paddingProfile) == 0 || size >= p.maxPacketSize {
+		return 0
+	}
+	paddedSize := p.maxPacketSize
+	for _, s := range p.paddingProfile {
+		if s >= size {
+			paddedSize = utils.MinByteCount(s, p.maxPacketSize)
+			break
+		}
+	}
+	return paddedSize - size
+}
+
 // PackCoalescedPacket packs a new packet.
 // It packs an Initial / Handshake if there is data to send in these packet number spaces.
 // It should only be called before the handshake is confirmed.
@@ -431,28 +475,42 @@ func (p *packetPacker) PackCoalescedPacket() (*coalescedPacket, error) {
 		return nil, nil
 	}
 
+	var initialPadding, handshakePadding, appDataPadding protocol.ByteCount
+	if initialPayload != nil {
+		initialPadding = p.initialPaddingLen(initialPayload.frames, size)
+	}
+	// the profile padding is added to the last packet in the datagram
+	profilePadding := p.profilePaddingLen(size + initialPadding)
+	switch {
+	case appDataPayload != nil:
+		appDataPadding = profilePadding
+	case handshakePayload != nil:
+		handshakePadding = profilePadding
+	default:
+		initialPadding += profilePadding
+	}
+
 	buffer := getPacketBuffer()
 	packet := &coalescedPacket{
 		buffer:  buffer,
 		packets: make([]*packetContents, 0, numPackets),
 	}
 	if initialPayload != nil {
-		padding := p.initialPaddingLen(initialPayload.frames, size)
-		cont, err := p.appendPacket(buffer, initialHdr, initialPayload, padding, protocol.EncryptionInitial, initialSealer, false)
+		cont, err := p.appendPacket(buffer, initialHdr, initialPayload, initialPadding, protocol.EncryptionInitial, initialSealer, false)
 		if err != nil {
 			return nil, err
 		}
 		packet.packets = append(packet.packets, cont)
 	}
 	if handshakePayload != nil {
-		cont, err := p.appendPacket(buffer, handshakeHdr, handshakePayload, 0, protocol.EncryptionHandshake, handshakeSealer, false)
+		cont, err := p.appendPacket(buffer, handshakeHdr, handshakePayload, handshakePadding, protocol.EncryptionHandshake, handshakeSealer, false)
 		if err != nil {
 			return nil, err
 		}
 		packet.packets = append(packet.packets, cont)
 	}
 	if appDataPayload != nil {
-		cont, err := p.appendPacket(buffer, appDataHdr, appDataPayload, 0, appDataEncLevel, appDataSealer, false)
+		cont, err := p.appendPacket(buffer, appDataHdr, appDataPayload, appDataPadding, appDataEncLevel, appDataSealer, false)
 		if err != nil {
 			return nil, err
 		}
@@ -473,7 +531,8 @@ func (p *packetPacker) PackPacket() (*packedPacket, error) {
 	if hdr.IsLongHeader {
 		encLevel = protocol.Encryption0RTT
 	}
-	cont, err := p.appendPacket(buffer, hdr, payload, 0, encLevel, sealer, false)
+	padding := p.profilePaddingLen(p.packetLength(hdr, payload) + protocol.ByteCount(sealer.Overhead()))
+	cont, err := p.appendPacket(buffer, hdr, payload, padding, encLevel, sealer, false)
 	if err != nil {
 		return nil, err
 	}
@@ -510,8 +569,15 @@ func (p *packetPacker) maybeGetCryptoPacket(maxPacketSize, currentSize protocol.
 	if ack != nil {
 		payload.ack = ack
 		payload.length = ack.Length(p.version)
+		payload.considered |= logging.FrameSourceAck
 		maxPacketSize -= payload.length
 	}
+	if hasData {
+		payload.considered |= logging.FrameSourceCrypto
+	}
+	if hasRetransmission {
+		payload.considered |= logging.FrameSourceRetransmission
+	}
 	hdr := p.getLongHeader(encLevel)
 	maxPacketSize -= hdr.GetLength(p.version)
 	if hasRetransmission {
@@ -596,13 +662,23 @@ func (p *packetPacker) composeNextPacket(maxFrameSize protocol.ByteCount, ackAll
 
 	var hasDatagram bool
 	if p.datagramQueue != nil {
-		if datagram := p.datagramQueue.Get(); datagram != nil {
+		datagram := p.datagramQueue.Get()
+		if datagram != nil && datagram.Length(p.version) > maxFrameSize {
+			// The DATAGRAM frame is the first frame in the packet. If it doesn't fit now, it never will.
+			if p.tracer != nil {
+				p.tracer.DroppedDatagramFrame(protocol.ByteCount(len(datagram.Data)), logging.DatagramDropTooLarge)
+			}
+		} else if datagram != nil {
+			if p.tracer != nil {
+				p.tracer.SentDatagramFrame(protocol.ByteCount(len(datagram.Data)))
+			}
 			payload.frames = append(payload.frames, ackhandler.Frame{
 				Frame: datagram,
 				// set it to a no-op. Then we won't set the default callback, which would retransmit the frame.
 				OnLost: func(wire.Frame) {},
 			})
 			payload.length += datagram.Length(p.version)
+			payload.considered |= logging.FrameSourceDatagram
 			hasDatagram = true
 		}
 	}
@@ -616,8 +692,15 @@ func (p *packetPacker) composeNextPacket(maxFrameSize protocol.ByteCount, ackAll
 		if ack != nil {
 			payload.ack = ack
 			payload.length += ack.Length(p.version)
+			payload.considered |= logging.FrameSourceAck
 		}
 	}
+	if hasData {
+		payload.considered |= logging.FrameSourceData
+	}
+	if hasRetransmission {
+		payload.considered |= logging.FrameSourceRetransmission
+	}
 
 	if ack == nil && !hasData && !hasRetransmission {
 		return payload
@@ -688,6 +771,7 @@ func (p *packetPacker) MaybePackProbePacket(encLevel protocol.EncryptionLevel) (
 	if encLevel == protocol.EncryptionInitial {
 		padding = p.initialPaddingLen(payload.frames, size)
 	}
+	padding += p.profilePaddingLen(size + padding)
 	buffer := getPacketBuffer()
 	cont, err := p.appendPacket(buffer, hdr, payload, padding, encLevel, sealer, false)
 	if err != nil {
@@ -799,10 +883,12 @@ func (p *packetPacker) writeSinglePacket(
 	sealer sealer,
 ) (*packedPacket, error) {
 	buffer := getPacketBuffer()
+	size := p.packetLength(hdr, payload) + protocol.ByteCount(sealer.Overhead())
 	var paddingLen protocol.ByteCount
 	if encLevel == protocol.EncryptionInitial {
-		paddingLen = p.initialPaddingLen(payload.frames, hdr.GetLength(p.version)+payload.length+protocol.ByteCount(sealer.Overhead()))
+		paddingLen = p.initialPaddingLen(payload.frames, size)
 	}
+	paddingLen += p.profilePaddingLen(size + paddingLen)
 	contents, err := p.appendPacket(buffer, hdr, payload, paddingLen, encLevel, sealer, false)
 	if err != nil {
 		return nil, err
@@ -857,6 +943,9 @@ func (p *packetPacker) appendPacket(buffer *packetBuffer, header *wire.ExtendedH
 	raw := buffer.Data
 	// encrypt the packet
 	raw = raw[:buf.Len()]
+	if p.tracer != nil {
+		p.tracer.SentPlaintextPacket(encLevel, raw[hdrOffset:])
+	}
 	_ = sealer.Seal(raw[payloadOffset:payloadOffset], raw[payloadOffset:], header.PacketNumber, raw[hdrOffset:payloadOffset])
 	raw = raw[0 : buf.Len()+sealer.Overhead()]
 	// apply header protection
@@ -868,11 +957,21 @@ func (p *packetPacker) appendPacket(buffer *packetBuffer, header *wire.ExtendedH
 	if num != header.PacketNumber {
 		return nil, errors.New("packetPacker BUG: Peeked and Popped packet numbers do not match")
 	}
+	length := buffer.Len() - hdrOffset
+	maxSize := p.maxPacketSize - protocol.ByteCount(hdrOffset)
+	if isMTUProbePacket {
+		maxSize = length
+	}
 	return &packetContents{
 		header: header,
 		ack:    payload.ack,
 		frames: payload.frames,
-		length: buffer.Len() - hdrOffset,
+		length: length,
+		composition: logging.PacketComposition{
+			Considered: payload.considered,
+			MaxSize:    maxSize,
+			Padding:    paddingLen,
+		},
 	}, nil
 }
 
@@ -11,10 +11,12 @@ import (
 	"github.com/lucas-clemente/quic-go/internal/handshake"
 	"github.com/lucas-clemente/quic-go/internal/mocks"
 	mockackhandler "github.com/lucas-clemente/quic-go/internal/mocks/ackhandler"
+	mocklogging "github.com/lucas-clemente/quic-go/internal/mocks/logging"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/qerr"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/lucas-clemente/quic-go/internal/wire"
+	"github.com/lucas-clemente/quic-go/logging"
 
 	"github.com/golang/mock/gomock"
 
@@ -91,7 +93,7 @@ var _ = Describe("Packet packer", func() {
 		ackFramer = NewMockAckFrameSource(mockCtrl)
 		sealingManager = NewMockSealingManager(mockCtrl)
 		pnManager = mockackhandler.NewMockSentPacketHandler(mockCtrl)
-		datagramQueue = newDatagramQueue(func() {}, utils.DefaultLogger)
+		datagramQueue = newDatagramQueue(func() {}, nil, utils.DefaultLogger)
 
 		packer = newPacketPacker(
 			protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8},
@@ -105,6 +107,8 @@ var _ = Describe("Packet packer", func() {
 			framer,
 			ackFramer,
 			datagramQueue,
+			nil,
+			nil,
 			protocol.PerspectiveServer,
 			version,
 		)
@@ -551,6 +555,10 @@ var _ = Describe("Packet packer", func() {
 				Expect(err).ToNot(HaveOccurred())
 				Expect(p.frames).To(Equal(frames))
 				Expect(p.buffer.Len()).ToNot(BeZero())
+				Expect(p.composition).To(Equal(logging.PacketComposition{
+					Considered: logging.FrameSourceData,
+					MaxSize:    packer.maxPacketSize,
+				}))
 			})
 
 			It("packs DATAGRAM frames", func() {
@@ -580,6 +588,33 @@ var _ = Describe("Packet packer", func() {
 				Eventually(done).Should(BeClosed())
 			})
 
+			It("drops DATAGRAM frames that don't fit into a packet", func() {
+				tracer := mocklogging.NewMockConnectionTracer(mockCtrl)
+				packer.tracer = tracer
+				pnManager.EXPECT().PeekPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
+				sealingManager.EXPECT().Get1RTTSealer().Return(getSealer(), nil)
+				f := &wire.DatagramFrame{
+					DataLenPresent: true,
+					Data:           make([]byte, packer.maxPacketSize),
+				}
+				done := make(chan struct{})
+				go func() {
+					defer GinkgoRecover()
+					defer close(done)
+					datagramQueue.AddAndWait(f)
+				}()
+				// make sure the DATAGRAM has actually been queued
+				time.Sleep(scaleDuration(20 * time.Millisecond))
+
+				tracer.EXPECT().DroppedDatagramFrame(packer.maxPacketSize, logging.DatagramDropTooLarge)
+				framer.EXPECT().HasData()
+				ackFramer.EXPECT().GetAckFrame(protocol.Encryption1RTT, true)
+				p, err := packer.PackPacket()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(p).To(BeNil())
+				Eventually(done).Should(BeClosed())
+			})
+
 			It("accounts for the space consumed by control frames", func() {
 				pnManager.EXPECT().PeekPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
 				sealingManager.EXPECT().Get1RTTSealer().Return(getSealer(), nil)
@@ -894,6 +929,56 @@ var _ = Describe("Packet packer", func() {
 					Expect(err).ToNot(HaveOccurred())
 				})
 			})
+
+			Context("padding profiles", func() {
+				packStreamFrame := func(dataLen int) *packedPacket {
+					pnManager.EXPECT().PeekPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
+					pnManager.EXPECT().PopPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42))
+					sealingManager.EXPECT().Get1RTTSealer().Return(getSealer(), nil)
+					framer.EXPECT().HasData().Return(true)
+					ackFramer.EXPECT().GetAckFrame(protocol.Encryption1RTT, false)
+					expectAppendControlFrames()
+					expectAppendStreamFrames(ackhandler.Frame{Frame: &wire.StreamFrame{StreamID: 5, Data: make([]byte, dataLen)}})
+					p, err := packer.PackPacket()
+					Expect(err).ToNot(HaveOccurred())
+					Expect(p).ToNot(BeNil())
+					return p
+				}
+
+				It("pads packets to the smallest size that fits", func() {
+					packer.paddingProfile = []protocol.ByteCount{100, 500, 1000}
+					p := packStreamFrame(10)
+					Expect(p.buffer.Len()).To(BeEquivalentTo(100))
+					Expect(p.composition.Padding).To(BeNumerically(">", 0))
+					Expect(p.ToAckHandlerPacket(time.Now(), nil).PaddingLength).To(Equal(p.composition.Padding))
+					Expect(p.length).To(BeEquivalentTo(100))
+					p = packStreamFrame(200)
+					Expect(p.buffer.Len()).To(BeEquivalentTo(500))
+				})
+
+				It("pads packets larger than the largest size to the maximum packet size", func() {
+					packer.paddingProfile = []protocol.ByteCount{100}
+					p := packStreamFrame(200)
+					Expect(p.buffer.Len()).To(BeEquivalentTo(maxPacketSize))
+				})
+
+				It("doesn't pad beyond the maximum packet size", func() {
+					packer.paddingProfile = []protocol.ByteCount{maxPacketSize + 100}
+					p := packStreamFrame(10)
+					Expect(p.buffer.Len()).To(BeEquivalentTo(maxPacketSize))
+				})
+
+				It("pads ACK-only packets", func() {
+					packer.paddingProfile = []protocol.ByteCount{300}
+					pnManager.EXPECT().PeekPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42), protocol.PacketNumberLen2)
+					pnManager.EXPECT().PopPacketNumber(protocol.Encryption1RTT).Return(protocol.PacketNumber(0x42))
+					sealingManager.EXPECT().Get1RTTSealer().Return(getSealer(), nil)
+					ackFramer.EXPECT().GetAckFrame(protocol.Encryption1RTT, true).Return(&wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 1, Largest: 10}}})
+					p, err := packer.MaybePackAckPacket(true)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(p.buffer.Len()).To(BeEquivalentTo(300))
+				})
+			})
 		})
 
 		Context("packing crypto packets", func() {
@@ -937,6 +1022,9 @@ var _ = Describe("Packet packer", func() {
 				Expect(p.packets[0].EncryptionLevel()).To(Equal(protocol.EncryptionInitial))
 				Expect(p.packets[0].frames).To(HaveLen(1))
 				Expect(p.packets[0].frames[0].Frame.(*wire.CryptoFrame).Data).To(Equal([]byte("initial")))
+				Expect(p.packets[0].composition.Considered).To(Equal(logging.FrameSourceCrypto))
+				Expect(p.packets[0].composition.MaxSize).To(Equal(packer.maxPacketSize))
+				Expect(p.packets[0].composition.Padding).To(BeNumerically(">", 1000))
 				hdrs := parsePacket(p.buffer.Data)
 				Expect(hdrs).To(HaveLen(1))
 				Expect(hdrs[0].Type).To(Equal(protocol.PacketTypeInitial))
@@ -995,6 +1083,8 @@ var _ = Describe("Packet packer", func() {
 				Expect(p.packets[1].EncryptionLevel()).To(Equal(protocol.EncryptionHandshake))
 				Expect(p.packets[1].frames).To(HaveLen(1))
 				Expect(p.packets[1].frames[0].Frame.(*wire.CryptoFrame).Data).To(Equal([]byte("handshake")))
+				// the Handshake packet can use the space left in the datagram
+				Expect(p.packets[1].composition.MaxSize).To(Equal(packer.maxPacketSize - p.packets[0].length))
 				hdrs := parsePacket(p.buffer.Data)
 				Expect(hdrs).To(HaveLen(2))
 				Expect(hdrs[0].Type).To(Equal(protocol.PacketTypeInitial))
@@ -1512,6 +1602,7 @@ var _ = Describe("Converting to AckHandler packets", func() {
 		Expect(p.Frames).To(Equal(packet.frames))
 		Expect(p.LargestAcked).To(Equal(protocol.PacketNumber(100)))
 		Expect(p.SendTime).To(Equal(t))
+		Expect(p.Composition).To(Equal(&packet.composition))
 	})
 
 	It("sets the LargestAcked to invalid, if the packet doesn't have an ACK frame", func() {
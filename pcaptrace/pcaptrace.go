@@ -0,0 +1,287 @@
+// Package pcaptrace writes the datagrams of QUIC connections to a pcapng file,
+// such that they can be analyzed using Wireshark, and correlated with the qlog of the connection.
+package pcaptrace
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/logging"
+)
+
+const (
+	// LinkTypeUser0 is the default link type of the datagrams (LINKTYPE_USER0).
+	// To dissect the datagrams, configure Wireshark to decode this link type as QUIC (DLT_USER preferences).
+	LinkTypeUser0 uint16 = 147
+	// LinkTypeUser1 is the default link type of the plaintext packets (LINKTYPE_USER1).
+	LinkTypeUser1 uint16 = 148
+)
+
+// pcapng block types
+const (
+	blockTypeSectionHeader        uint32 = 0x0a0d0d0a
+	blockTypeInterfaceDescription uint32 = 1
+	blockTypeEnhancedPacket       uint32 = 6
+)
+
+// pcapng option codes
+const (
+	optionEndOfOpt    uint16 = 0
+	optionComment     uint16 = 1
+	optionIfName      uint16 = 2
+	optionIfTsresol   uint16 = 9
+	optionEpbFlags    uint16 = 2
+	epbFlagsInbound   uint32 = 1
+	epbFlagsOutbound  uint32 = 2
+	byteOrderMagic    uint32 = 0x1a2b3c4d
+	tsresolNanosecond byte   = 9
+)
+
+// The interface IDs, in the order the Interface Description Blocks are written.
+const (
+	interfaceDatagrams uint32 = iota
+	interfacePlaintext
+)
+
+// Config configures the pcap tracer.
+type Config struct {
+	// RecordPlaintext records every packet before it is encrypted and after it is decrypted,
+	// in addition to the encrypted datagrams.
+	// Note that this writes the plaintext of the connection (including the TLS handshake messages) to the file.
+	RecordPlaintext bool
+	// LinkType is the link type of the interface that the datagrams are written to.
+	// If not set, LinkTypeUser0 is used.
+	LinkType uint16
+	// PlaintextLinkType is the link type of the interface that the plaintext packets are written to.
+	// If not set, LinkTypeUser1 is used.
+	PlaintextLinkType uint16
+}
+
+type tracer struct {
+	recordPlaintext bool
+
+	mutex sync.Mutex
+	w     io.Writer
+	err   error
+}
+
+var _ logging.Tracer = &tracer{}
+
+// NewTracer creates a new tracer that writes the datagrams of all connections to w, as a pcapng file.
+// Datagrams are recorded as they are sent on the wire, i.e. after encryption.
+// Every packet carries a comment with the original destination connection ID (which also names the qlog of the connection),
+// the vantage point and, for plaintext packets, the encryption level.
+// The caller is responsible for closing w after all connections were closed.
+func NewTracer(w io.Writer, config Config) (logging.Tracer, error) {
+	linkType := config.LinkType
+	if linkType == 0 {
+		linkType = LinkTypeUser0
+	}
+	plaintextLinkType := config.PlaintextLinkType
+	if plaintextLinkType == 0 {
+		plaintextLinkType = LinkTypeUser1
+	}
+	t := &tracer{w: w, recordPlaintext: config.RecordPlaintext}
+	if err := t.writeBlock(blockTypeSectionHeader, sectionHeaderBody(), nil); err != nil {
+		return nil, err
+	}
+	if err := t.writeBlock(blockTypeInterfaceDescription, interfaceDescriptionBody(linkType), interfaceOptions("quic-datagrams")); err != nil {
+		return nil, err
+	}
+	if config.RecordPlaintext {
+		if err := t.writeBlock(blockTypeInterfaceDescription, interfaceDescriptionBody(plaintextLinkType), interfaceOptions("quic-plaintext")); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func (t *tracer) TracerForConnection(_ context.Context, p logging.Perspective, odcid logging.ConnectionID) logging.ConnectionTracer {
+	return &connectionTracer{
+		tracer:  t,
+		comment: fmt.Sprintf("odcid=%x vantage_point=%s", odcid.Bytes(), strings.ToLower(p.String())),
+	}
+}
+
+func (t *tracer) SentPacket(net.Addr, *logging.Header, logging.ByteCount, []logging.Frame) {}
+func (t *tracer) DroppedPacket(net.Addr, logging.PacketType, logging.ByteCount, logging.PacketDropReason) {
+}
+
+// writePacket writes an Enhanced Packet Block.
+// Once writing failed, nothing is written anymore.
+func (t *tracer) writePacket(iface uint32, now time.Time, data []byte, flags uint32, comment string) {
+	ts := uint64(now.UnixNano())
+	body := make([]byte, 20, 20+len(data)+3)
+	binary.LittleEndian.PutUint32(body[0:4], iface)
+	binary.LittleEndian.PutUint32(body[4:8], uint32(ts>>32))
+	binary.LittleEndian.PutUint32(body[8:12], uint32(ts))
+	binary.LittleEndian.PutUint32(body[12:16], uint32(len(data)))
+	binary.LittleEndian.PutUint32(body[16:20], uint32(len(data)))
+	body = append(body, pad(data)...)
+
+	flagsVal := make([]byte, 4)
+	binary.LittleEndian.PutUint32(flagsVal, flags)
+	options := appendOption(nil, optionEpbFlags, flagsVal)
+	options = appendOption(options, optionComment, []byte(comment))
+
+	if err := t.writeBlock(blockTypeEnhancedPacket, body, options); err != nil {
+		log.Printf("writing the pcap failed: %s", err)
+	}
+}
+
+func (t *tracer) writeBlock(blockType uint32, body, options []byte) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.err != nil {
+		return nil
+	}
+	if options != nil {
+		options = appendOption(options, optionEndOfOpt, nil)
+	}
+	totalLen := 12 + len(body) + len(options)
+	b := make([]byte, totalLen)
+	binary.LittleEndian.PutUint32(b[0:4], blockType)
+	binary.LittleEndian.PutUint32(b[4:8], uint32(totalLen))
+	copy(b[8:], body)
+	copy(b[8+len(body):], options)
+	binary.LittleEndian.PutUint32(b[totalLen-4:], uint32(totalLen))
+	if _, err := t.w.Write(b); err != nil {
+		t.err = err
+		return err
+	}
+	return nil
+}
+
+func sectionHeaderBody() []byte {
+	b := make([]byte, 16)
+	binary.LittleEndian.PutUint32(b[0:4], byteOrderMagic)
+	binary.LittleEndian.PutUint16(b[4:6], 1) // major version
+	binary.LittleEndian.PutUint16(b[6:8], 0) // minor version
+	// the section length is not specified
+	binary.LittleEndian.PutUint64(b[8:16], 0xffffffffffffffff)
+	return b
+}
+
+func interfaceDescriptionBody(linkType uint16) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint16(b[0:2], linkType)
+	// b[2:4] is reserved, and a snap length of 0 means no limit
+	return b
+}
+
+func interfaceOptions(name string) []byte {
+	options := appendOption(nil, optionIfName, []byte(name))
+	return appendOption(options, optionIfTsresol, []byte{tsresolNanosecond})
+}
+
+func appendOption(b []byte, code uint16, value []byte) []byte {
+	var hdr [4]byte
+	binary.LittleEndian.PutUint16(hdr[0:2], code)
+	binary.LittleEndian.PutUint16(hdr[2:4], uint16(len(value)))
+	b = append(b, hdr[:]...)
+	return append(b, pad(value)...)
+}
+
+// pad pads b to a multiple of 4 bytes.
+func pad(b []byte) []byte {
+	if len(b)%4 == 0 {
+		return b
+	}
+	return append(append([]byte{}, b...), make([]byte, 4-len(b)%4)...)
+}
+
+type connectionTracer struct {
+	tracer  *tracer
+	comment string
+}
+
+var _ logging.ConnectionTracer = &connectionTracer{}
+
+func (t *connectionTracer) SentDatagram(data []byte) {
+	t.tracer.writePacket(interfaceDatagrams, time.Now(), data, epbFlagsOutbound, t.comment)
+}
+
+func (t *connectionTracer) ReceivedDatagram(data []byte) {
+	t.tracer.writePacket(interfaceDatagrams, time.Now(), data, epbFlagsInbound, t.comment)
+}
+
+func (t *connectionTracer) SentPlaintextPacket(encLevel logging.EncryptionLevel, data []byte) {
+	if t.tracer.recordPlaintext {
+		t.tracer.writePacket(interfacePlaintext, time.Now(), data, epbFlagsOutbound, t.plaintextComment(encLevel))
+	}
+}
+
+func (t *connectionTracer) ReceivedPlaintextPacket(encLevel logging.EncryptionLevel, data []byte) {
+	if t.tracer.recordPlaintext {
+		t.tracer.writePacket(interfacePlaintext, time.Now(), data, epbFlagsInbound, t.plaintextComment(encLevel))
+	}
+}
+
+func (t *connectionTracer) plaintextComment(encLevel logging.EncryptionLevel) string {
+	return fmt.Sprintf("%s encryption_level=%s", t.comment, encLevel)
+}
+
+func (t *connectionTracer) StartedConnection(net.Addr, net.Addr, logging.ConnectionID, logging.ConnectionID) {
+}
+func (t *connectionTracer) NegotiatedVersion(logging.VersionNumber, []logging.VersionNumber, []logging.VersionNumber) {
+}
+func (t *connectionTracer) ClosedConnection(error)                                   {}
+func (t *connectionTracer) SentTransportParameters(*logging.TransportParameters)     {}
+func (t *connectionTracer) ReceivedTransportParameters(*logging.TransportParameters) {}
+func (t *connectionTracer) RestoredTransportParameters(*logging.TransportParameters) {}
+func (t *connectionTracer) SentPacket(*logging.ExtendedHeader, logging.ByteCount, *logging.AckFrame, []logging.Frame) {
+}
+func (t *connectionTracer) ComposedPacket(logging.EncryptionLevel, logging.PacketNumber, *logging.PacketComposition) {
+}
+func (t *connectionTracer) ReceivedVersionNegotiationPacket(*logging.Header, []logging.VersionNumber) {
+}
+func (t *connectionTracer) ReceivedRetry(*logging.Header) {}
+func (t *connectionTracer) ReceivedPacket(*logging.ExtendedHeader, logging.ByteCount, []logging.Frame) {
+}
+func (t *connectionTracer) BufferedPacket(logging.PacketType)       {}
+func (t *connectionTracer) SentDatagramFrame(logging.ByteCount)     {}
+func (t *connectionTracer) ReceivedDatagramFrame(logging.ByteCount) {}
+func (t *connectionTracer) DroppedDatagramFrame(logging.ByteCount, logging.DatagramDropReason) {
+}
+func (t *connectionTracer) SentFlowControlUpdate(logging.StreamID, logging.ByteCount, logging.ByteCount) {
+}
+func (t *connectionTracer) ReceivedFlowControlUpdate(logging.StreamID, logging.ByteCount, time.Duration) {
+}
+func (t *connectionTracer) BlockedByFlowControl(logging.StreamID, logging.ByteCount) {}
+func (t *connectionTracer) DroppedPacket(logging.PacketType, logging.ByteCount, logging.PacketDropReason) {
+}
+func (t *connectionTracer) UpdatedMetrics(*logging.RTTStats, logging.ByteCount, logging.ByteCount, int) {
+}
+func (t *connectionTracer) UpdatedCongestionMetrics(logging.ByteCount, logging.ByteCount, logging.ByteCount, uint64) {
+}
+func (t *connectionTracer) AcknowledgedPacket(logging.EncryptionLevel, logging.PacketNumber) {}
+func (t *connectionTracer) LostPacket(logging.EncryptionLevel, logging.PacketNumber, logging.PacketLossReason) {
+}
+func (t *connectionTracer) DetectedPersistentCongestion(time.Duration)                         {}
+func (t *connectionTracer) DetectedSuspend(time.Duration)                                      {}
+func (t *connectionTracer) ClassifiedPacketLoss(logging.PacketNumber, bool)                    {}
+func (t *connectionTracer) PacerDelayedSend(logging.ByteCount, time.Time)                      {}
+func (t *connectionTracer) LimitedAckRanges(logging.EncryptionLevel, int, int)                 {}
+func (t *connectionTracer) SkippedPacketNumber(logging.PacketNumber)                           {}
+func (t *connectionTracer) UpdatedCongestionState(logging.CongestionState)                     {}
+func (t *connectionTracer) UpdatedPTOCount(uint32)                                             {}
+func (t *connectionTracer) UpdatedMaxDatagramSize(logging.ByteCount)                           {}
+func (t *connectionTracer) UpdatedKeyFromTLS(logging.EncryptionLevel, logging.Perspective)     {}
+func (t *connectionTracer) UpdatedKey(logging.KeyPhase, bool)                                  {}
+func (t *connectionTracer) DroppedEncryptionLevel(logging.EncryptionLevel)                     {}
+func (t *connectionTracer) DroppedKey(logging.KeyPhase)                                        {}
+func (t *connectionTracer) Accepted0RTT()                                                      {}
+func (t *connectionTracer) Rejected0RTT(logging.ZeroRTTRejectReason)                           {}
+func (t *connectionTracer) SetLossTimer(logging.TimerType, logging.EncryptionLevel, time.Time) {}
+func (t *connectionTracer) LossTimerExpired(logging.TimerType, logging.EncryptionLevel)        {}
+func (t *connectionTracer) LossTimerCanceled()                                                 {}
+func (t *connectionTracer) Close()                                                             {}
+func (t *connectionTracer) Debug(string, string)                                               {}
@@ -0,0 +1,13 @@
+package pcaptrace
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestPCAPTrace(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "pcaptrace Suite")
+}
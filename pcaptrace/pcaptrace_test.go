@@ -0,0 +1,163 @@
+package pcaptrace
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+
+	"github.com/lucas-clemente/quic-go/logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type block struct {
+	Type uint32
+	Body []byte // including the options
+}
+
+func parseBlocks(data []byte) []block {
+	var blocks []block
+	for len(data) > 0 {
+		Expect(len(data)).To(BeNumerically(">=", 12))
+		l := binary.LittleEndian.Uint32(data[4:8])
+		Expect(l % 4).To(BeZero())
+		Expect(binary.LittleEndian.Uint32(data[l-4 : l])).To(Equal(l))
+		blocks = append(blocks, block{
+			Type: binary.LittleEndian.Uint32(data[0:4]),
+			Body: data[8 : l-4],
+		})
+		data = data[l:]
+	}
+	return blocks
+}
+
+func parseOptions(data []byte) map[uint16][]byte {
+	options := make(map[uint16][]byte)
+	for {
+		code := binary.LittleEndian.Uint16(data[0:2])
+		l := binary.LittleEndian.Uint16(data[2:4])
+		if code == optionEndOfOpt {
+			return options
+		}
+		options[code] = data[4 : 4+l]
+		data = data[4+int(l)+(4-int(l)%4)%4:]
+	}
+}
+
+type enhancedPacket struct {
+	Interface uint32
+	Data      []byte
+	Flags     uint32
+	Comment   string
+}
+
+func parseEnhancedPacket(b block) enhancedPacket {
+	Expect(b.Type).To(Equal(blockTypeEnhancedPacket))
+	caplen := binary.LittleEndian.Uint32(b.Body[12:16])
+	Expect(binary.LittleEndian.Uint32(b.Body[16:20])).To(Equal(caplen))
+	options := parseOptions(b.Body[20+int(caplen)+(4-int(caplen)%4)%4:])
+	return enhancedPacket{
+		Interface: binary.LittleEndian.Uint32(b.Body[0:4]),
+		Data:      b.Body[20 : 20+caplen],
+		Flags:     binary.LittleEndian.Uint32(options[optionEpbFlags]),
+		Comment:   string(options[optionComment]),
+	}
+}
+
+type errorWriter struct{ n int }
+
+func (w *errorWriter) Write(b []byte) (int, error) {
+	if w.n == 0 {
+		return 0, errors.New("write failed")
+	}
+	w.n--
+	return len(b), nil
+}
+
+var _ = Describe("pcap tracer", func() {
+	var buf *bytes.Buffer
+
+	BeforeEach(func() {
+		buf = &bytes.Buffer{}
+	})
+
+	It("writes the section header and the interface description", func() {
+		_, err := NewTracer(buf, Config{})
+		Expect(err).ToNot(HaveOccurred())
+		blocks := parseBlocks(buf.Bytes())
+		Expect(blocks).To(HaveLen(2))
+		Expect(blocks[0].Type).To(Equal(blockTypeSectionHeader))
+		Expect(binary.LittleEndian.Uint32(blocks[0].Body[0:4])).To(Equal(uint32(0x1a2b3c4d)))
+		Expect(blocks[1].Type).To(Equal(blockTypeInterfaceDescription))
+		Expect(binary.LittleEndian.Uint16(blocks[1].Body[0:2])).To(Equal(LinkTypeUser0))
+		options := parseOptions(blocks[1].Body[8:])
+		Expect(options[optionIfName]).To(Equal([]byte("quic-datagrams")))
+		Expect(options[optionIfTsresol]).To(Equal([]byte{9}))
+	})
+
+	It("uses the configured link types", func() {
+		_, err := NewTracer(buf, Config{RecordPlaintext: true, LinkType: 1337, PlaintextLinkType: 42})
+		Expect(err).ToNot(HaveOccurred())
+		blocks := parseBlocks(buf.Bytes())
+		Expect(blocks).To(HaveLen(3))
+		Expect(binary.LittleEndian.Uint16(blocks[1].Body[0:2])).To(Equal(uint16(1337)))
+		Expect(binary.LittleEndian.Uint16(blocks[2].Body[0:2])).To(Equal(uint16(42)))
+		Expect(parseOptions(blocks[2].Body[8:])[optionIfName]).To(Equal([]byte("quic-plaintext")))
+	})
+
+	It("records the datagrams", func() {
+		t, err := NewTracer(buf, Config{})
+		Expect(err).ToNot(HaveOccurred())
+		tracer := t.TracerForConnection(context.Background(), logging.PerspectiveServer, logging.ConnectionID{0xde, 0xad, 0xbe, 0xef})
+		tracer.SentDatagram([]byte("foobar"))
+		tracer.ReceivedDatagram([]byte("raboof!!"))
+		tracer.SentPlaintextPacket(logging.Encryption1RTT, []byte("plaintext"))
+		blocks := parseBlocks(buf.Bytes())
+		Expect(blocks).To(HaveLen(4))
+		sent := parseEnhancedPacket(blocks[2])
+		Expect(sent.Interface).To(BeZero())
+		Expect(sent.Data).To(Equal([]byte("foobar")))
+		Expect(sent.Flags).To(Equal(epbFlagsOutbound))
+		Expect(sent.Comment).To(Equal("odcid=deadbeef vantage_point=server"))
+		received := parseEnhancedPacket(blocks[3])
+		Expect(received.Data).To(Equal([]byte("raboof!!")))
+		Expect(received.Flags).To(Equal(epbFlagsInbound))
+	})
+
+	It("records the plaintext packets", func() {
+		t, err := NewTracer(buf, Config{RecordPlaintext: true})
+		Expect(err).ToNot(HaveOccurred())
+		tracer := t.TracerForConnection(context.Background(), logging.PerspectiveClient, logging.ConnectionID{0xca, 0xfe})
+		tracer.SentPlaintextPacket(logging.EncryptionHandshake, []byte("foo"))
+		tracer.ReceivedPlaintextPacket(logging.Encryption1RTT, []byte("bar"))
+		blocks := parseBlocks(buf.Bytes())
+		Expect(blocks).To(HaveLen(5))
+		sent := parseEnhancedPacket(blocks[3])
+		Expect(sent.Interface).To(Equal(uint32(1)))
+		Expect(sent.Data).To(Equal([]byte("foo")))
+		Expect(sent.Flags).To(Equal(epbFlagsOutbound))
+		Expect(sent.Comment).To(Equal("odcid=cafe vantage_point=client encryption_level=Handshake"))
+		received := parseEnhancedPacket(blocks[4])
+		Expect(received.Data).To(Equal([]byte("bar")))
+		Expect(received.Flags).To(Equal(epbFlagsInbound))
+		Expect(received.Comment).To(Equal("odcid=cafe vantage_point=client encryption_level=1-RTT"))
+	})
+
+	It("returns the error when writing the header fails", func() {
+		_, err := NewTracer(&errorWriter{n: 1}, Config{})
+		Expect(err).To(MatchError("write failed"))
+	})
+
+	It("stops writing after an error", func() {
+		w := &errorWriter{n: 2}
+		t, err := NewTracer(w, Config{})
+		Expect(err).ToNot(HaveOccurred())
+		tracer := t.TracerForConnection(context.Background(), logging.PerspectiveClient, logging.ConnectionID{1})
+		tracer.SentDatagram([]byte("foo"))
+		w.n = 10
+		tracer.SentDatagram([]byte("bar"))
+		Expect(w.n).To(Equal(10))
+	})
+})
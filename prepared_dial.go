@@ -0,0 +1,226 @@
+package quic
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/lucas-clemente/quic-go/internal/handshake"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+)
+
+var errPreparedDialUsed = errors.New("quic: prepared dial already used")
+
+// A PreparedDial is a client connection that was set up before the server is dialed.
+// PrepareDial performs all the work that doesn't depend on the network:
+// it generates the connection IDs, derives the Initial keys and serializes the ClientHello.
+// Dialing then only needs to send the first packet.
+//
+// A PreparedDial can only be used for a single connection.
+// If it won't be used, it must be closed.
+// If the server sends a Retry or a Version Negotiation packet, the handshake is restarted as usual,
+// so the precomputed first flight is only used for the first connection attempt.
+type PreparedDial struct {
+	mutex sync.Mutex
+	used  bool // set once the PreparedDial was dialed or closed
+
+	client    *client
+	handshake *preparedHandshake
+}
+
+// preparedHandshake is the state of a handshake that was started by PrepareDial.
+// The ClientHello was already written to the Initial stream.
+type preparedHandshake struct {
+	initialStream      cryptoStream
+	handshakeStream    cryptoStream
+	params             *wire.TransportParameters
+	cryptoSetup        handshake.CryptoSetup
+	clientHelloWritten <-chan *wire.TransportParameters
+	rttStats           *utils.RTTStats
+	// The session sets the callbacks when it is created.
+	runner *handshakeRunner
+}
+
+// PrepareDial prepares a new QUIC connection to a server.
+// The host is used for SNI. Since the ClientHello is serialized before the remote address is known,
+// either the host or tls.Config.ServerName must be set.
+// The tls.Config must define an application protocol (using NextProtos).
+// If a Tracer is configured, the connection tracer is created right away.
+// The context passed to it is not derived from the context passed to DialContext.
+func PrepareDial(host string, tlsConf *tls.Config, config *Config) (*PreparedDial, error) {
+	return prepareDial(host, tlsConf, config, false)
+}
+
+// PrepareDialEarly prepares a new 0-RTT QUIC connection to a server.
+// See PrepareDial for details.
+func PrepareDialEarly(host string, tlsConf *tls.Config, config *Config) (*PreparedDial, error) {
+	return prepareDial(host, tlsConf, config, true)
+}
+
+func prepareDial(host string, tlsConf *tls.Config, config *Config, use0RTT bool) (*PreparedDial, error) {
+	if tlsConf == nil {
+		return nil, errors.New("quic: tls.Config not set")
+	}
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+	config = populateClientConfig(config, false)
+	c, err := newClient(nil, nil, config, tlsConf, host, use0RTT, false, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if c.tlsConf.ServerName == "" {
+		return nil, errors.New("quic: PrepareDial requires a server name")
+	}
+	c.tracingID = nextSessionTracingID()
+	if c.config.Tracer != nil {
+		c.tracer = c.config.Tracer.TracerForConnection(
+			context.WithValue(context.Background(), SessionTracingKey, c.tracingID),
+			protocol.PerspectiveClient,
+			c.destConnID,
+		)
+	}
+
+	hs := &preparedHandshake{
+		initialStream:   newCryptoStream(),
+		handshakeStream: newCryptoStream(),
+		params:          clientTransportParameters(c.config, c.srcConnID),
+		rttStats:        &utils.RTTStats{},
+	}
+	errChan := make(chan error, 1)
+	hs.runner = &handshakeRunner{
+		onReceivedParams:    func(*wire.TransportParameters) {},
+		onError:             func(err error) { errChan <- err },
+		dropKeys:            func(protocol.EncryptionLevel) {},
+		onHandshakeComplete: func() {},
+	}
+	cs, clientHelloWritten := handshake.NewCryptoSetupClient(
+		hs.initialStream,
+		hs.handshakeStream,
+		c.destConnID,
+		nil,
+		nil,
+		hs.params,
+		hs.runner,
+		c.tlsConf,
+		use0RTT,
+		hs.rttStats,
+		c.tracer,
+		c.logger,
+		c.version,
+	)
+	go cs.RunHandshake()
+	select {
+	case zeroRTTParams := <-clientHelloWritten:
+		// put the value back, such that the session can restore the 0-RTT transport parameters
+		ch := make(chan *wire.TransportParameters, 1)
+		ch <- zeroRTTParams
+		hs.clientHelloWritten = ch
+	case err := <-errChan:
+		cs.Close()
+		if c.tracer != nil {
+			c.tracer.Close()
+		}
+		return nil, err
+	}
+	hs.cryptoSetup = cs
+	return &PreparedDial{client: c, handshake: hs}, nil
+}
+
+// DialContext establishes the prepared QUIC connection to a server using a net.PacketConn.
+// See DialContext for details.
+func (d *PreparedDial) DialContext(
+	ctx context.Context,
+	pconn net.PacketConn,
+	remoteAddr net.Addr,
+	startAlgo utils.StartAlgo,
+	congestionAlgo utils.CongestionAlgo,
+) (Session, error) {
+	if d.client.use0RTT {
+		return nil, errors.New("quic: use DialEarlyContext for a dial prepared with PrepareDialEarly")
+	}
+	return d.dial(ctx, pconn, remoteAddr, startAlgo, congestionAlgo)
+}
+
+// DialEarlyContext establishes the prepared 0-RTT QUIC connection to a server using a net.PacketConn.
+// The PreparedDial must have been created by PrepareDialEarly.
+// See DialEarlyContext for details.
+func (d *PreparedDial) DialEarlyContext(
+	ctx context.Context,
+	pconn net.PacketConn,
+	remoteAddr net.Addr,
+	startAlgo utils.StartAlgo,
+	congestionAlgo utils.CongestionAlgo,
+) (EarlySession, error) {
+	if !d.client.use0RTT {
+		return nil, errors.New("quic: use DialContext for a dial prepared with PrepareDial")
+	}
+	return d.dial(ctx, pconn, remoteAddr, startAlgo, congestionAlgo)
+}
+
+func (d *PreparedDial) dial(
+	ctx context.Context,
+	pconn net.PacketConn,
+	remoteAddr net.Addr,
+	startAlgo utils.StartAlgo,
+	congestionAlgo utils.CongestionAlgo,
+) (quicSession, error) {
+	if !d.use() {
+		return nil, errPreparedDialUsed
+	}
+	if err := ctx.Err(); err != nil {
+		d.discard()
+		return nil, err
+	}
+	c := d.client
+	packetHandlers, err := getMultiplexer().AddConn(pconn, c.config.ConnectionIDLength, c.config.StatelessResetKey, c.config.Tracer)
+	if err != nil {
+		d.discard()
+		return nil, err
+	}
+	c.conn = newSendPconn(pconn, remoteAddr)
+	c.packetHandlers = packetHandlers
+	c.startAlgo = startAlgo
+	c.congestionAlgo = congestionAlgo
+	c.prepared = d.handshake
+	if c.tracer != nil {
+		c.tracer.StartedConnection(c.conn.LocalAddr(), c.conn.RemoteAddr(), c.srcConnID, c.destConnID)
+	}
+	if err := c.dial(ctx); err != nil {
+		return nil, err
+	}
+	return c.session, nil
+}
+
+// Close discards a PreparedDial that won't be dialed.
+// It is a no-op if the PreparedDial was already dialed.
+func (d *PreparedDial) Close() error {
+	if d.use() {
+		d.discard()
+	}
+	return nil
+}
+
+// use marks the PreparedDial as used.
+// It returns false if it was already used.
+func (d *PreparedDial) use() bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.used {
+		return false
+	}
+	d.used = true
+	return true
+}
+
+func (d *PreparedDial) discard() {
+	d.handshake.cryptoSetup.Close()
+	if d.client.tracer != nil {
+		d.client.tracer.Close()
+	}
+}
@@ -0,0 +1,213 @@
+package quic
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Prepared Dial", func() {
+	var (
+		mockCtrl                      *gomock.Controller
+		tlsConf                       *tls.Config
+		packetConn                    *MockPacketConn
+		addr                          net.Addr
+		mockMultiplexer               *MockMultiplexer
+		origMultiplexer               multiplexer
+		originalClientSessConstructor func(
+			conn sendConn,
+			runner sessionRunner,
+			destConnID protocol.ConnectionID,
+			srcConnID protocol.ConnectionID,
+			conf *Config,
+			tlsConf *tls.Config,
+			initialPacketNumber protocol.PacketNumber,
+			enable0RTT bool,
+			hasNegotiatedVersion bool,
+			prepared *preparedHandshake,
+			tracer logging.ConnectionTracer,
+			tracingID uint64,
+			logger utils.Logger,
+			startAlgo utils.StartAlgo,
+			congestionAlgo utils.CongestionAlgo,
+			v protocol.VersionNumber,
+		) quicSession
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		tlsConf = &tls.Config{NextProtos: []string{"proto1"}}
+		addr = &net.UDPAddr{IP: net.IPv4(192, 168, 100, 200), Port: 1337}
+		packetConn = NewMockPacketConn(mockCtrl)
+		packetConn.EXPECT().LocalAddr().Return(&net.UDPAddr{}).AnyTimes()
+		originalClientSessConstructor = newClientSession
+		getMultiplexer() // make the sync.Once execute
+		mockMultiplexer = NewMockMultiplexer(mockCtrl)
+		// packet handler maps created by other tests might still be closing
+		mockMultiplexer.EXPECT().RemoveConn(gomock.Any()).AnyTimes()
+		origMultiplexer = connMuxer
+		connMuxer = mockMultiplexer
+	})
+
+	AfterEach(func() {
+		connMuxer = origMultiplexer
+		newClientSession = originalClientSessConstructor
+		mockCtrl.Finish()
+	})
+
+	It("errors if no tls.Config is given", func() {
+		_, err := PrepareDial("localhost", nil, nil)
+		Expect(err).To(MatchError("quic: tls.Config not set"))
+	})
+
+	It("errors if the server name is not known", func() {
+		_, err := PrepareDial("", tlsConf, nil)
+		Expect(err).To(MatchError("quic: PrepareDial requires a server name"))
+	})
+
+	It("serializes the ClientHello", func() {
+		d, err := PrepareDial("localhost:1337", tlsConf, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tlsConf.ServerName).To(Equal("localhost"))
+		Expect(d.handshake.initialStream.HasData()).To(BeTrue())
+		Expect(d.handshake.handshakeStream.HasData()).To(BeFalse())
+		Expect(d.handshake.params.InitialSourceConnectionID).To(Equal(d.client.srcConnID))
+		Expect(d.Close()).To(Succeed())
+	})
+
+	It("can't be dialed after it was closed", func() {
+		d, err := PrepareDial("localhost", tlsConf, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(d.Close()).To(Succeed())
+		_, err = d.DialContext(context.Background(), packetConn, addr, utils.ChooseHystart, utils.ChooseNewReno)
+		Expect(err).To(MatchError(errPreparedDialUsed))
+	})
+
+	It("requires the 0-RTT mode to match", func() {
+		d, err := PrepareDial("localhost", tlsConf, nil)
+		Expect(err).ToNot(HaveOccurred())
+		defer d.Close()
+		_, err = d.DialEarlyContext(context.Background(), packetConn, addr, utils.ChooseHystart, utils.ChooseNewReno)
+		Expect(err).To(MatchError("quic: use DialContext for a dial prepared with PrepareDial"))
+		de, err := PrepareDialEarly("localhost", tlsConf, nil)
+		Expect(err).ToNot(HaveOccurred())
+		defer de.Close()
+		_, err = de.DialContext(context.Background(), packetConn, addr, utils.ChooseHystart, utils.ChooseNewReno)
+		Expect(err).To(MatchError("quic: use DialEarlyContext for a dial prepared with PrepareDialEarly"))
+	})
+
+	It("doesn't dial if the context is already canceled", func() {
+		d, err := PrepareDial("localhost", tlsConf, nil)
+		Expect(err).ToNot(HaveOccurred())
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = d.DialContext(ctx, packetConn, addr, utils.ChooseHystart, utils.ChooseNewReno)
+		Expect(err).To(MatchError(context.Canceled))
+		_, err = d.DialContext(context.Background(), packetConn, addr, utils.ChooseHystart, utils.ChooseNewReno)
+		Expect(err).To(MatchError(errPreparedDialUsed))
+	})
+
+	It("discards the prepared handshake if adding the connection fails", func() {
+		testErr := errors.New("listen error")
+		mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, testErr)
+		d, err := PrepareDial("localhost", tlsConf, nil)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = d.DialContext(context.Background(), packetConn, addr, utils.ChooseHystart, utils.ChooseNewReno)
+		Expect(err).To(MatchError(testErr))
+		_, err = d.DialContext(context.Background(), packetConn, addr, utils.ChooseHystart, utils.ChooseNewReno)
+		Expect(err).To(MatchError(errPreparedDialUsed))
+	})
+
+	It("passes the prepared handshake to the session", func() {
+		d, err := PrepareDial("localhost", tlsConf, nil)
+		Expect(err).ToNot(HaveOccurred())
+		manager := NewMockPacketHandlerManager(mockCtrl)
+		manager.EXPECT().Add(d.client.srcConnID, gomock.Any())
+		mockMultiplexer.EXPECT().AddConn(packetConn, gomock.Any(), gomock.Any(), gomock.Any()).Return(manager, nil)
+		preparedChan := make(chan *preparedHandshake, 1)
+		newClientSession = func(
+			conn sendConn,
+			_ sessionRunner,
+			destConnID protocol.ConnectionID,
+			srcConnID protocol.ConnectionID,
+			_ *Config,
+			_ *tls.Config,
+			_ protocol.PacketNumber,
+			_ bool,
+			_ bool,
+			prepared *preparedHandshake,
+			_ logging.ConnectionTracer,
+			_ uint64,
+			_ utils.Logger,
+			startAlgo utils.StartAlgo,
+			congestionAlgo utils.CongestionAlgo,
+			_ protocol.VersionNumber,
+		) quicSession {
+			Expect(conn.RemoteAddr()).To(Equal(addr))
+			Expect(destConnID).To(Equal(d.client.destConnID))
+			Expect(srcConnID).To(Equal(d.client.srcConnID))
+			Expect(startAlgo).To(Equal(utils.ChooseHystart))
+			Expect(congestionAlgo).To(Equal(utils.ChooseNewReno))
+			preparedChan <- prepared
+			sess := NewMockQuicSession(mockCtrl)
+			sess.EXPECT().run().Do(func() { select {} }).MaxTimes(1)
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			sess.EXPECT().HandshakeComplete().Return(ctx)
+			return sess
+		}
+		_, err = d.DialContext(context.Background(), packetConn, addr, utils.ChooseHystart, utils.ChooseNewReno)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(preparedChan).To(Receive(Equal(d.handshake)))
+		Expect(d.client.prepared).To(BeNil())
+		// the PreparedDial can only be used once
+		_, err = d.DialContext(context.Background(), packetConn, addr, utils.ChooseHystart, utils.ChooseNewReno)
+		Expect(err).To(MatchError(errPreparedDialUsed))
+		d.handshake.cryptoSetup.Close()
+	})
+
+	It("creates a session that uses the prepared handshake", func() {
+		d, err := PrepareDial("localhost", tlsConf, nil)
+		Expect(err).ToNot(HaveOccurred())
+		defer d.handshake.cryptoSetup.Close()
+		mconn := NewMockSendConn(mockCtrl)
+		mconn.EXPECT().RemoteAddr().Return(addr).AnyTimes()
+		mconn.EXPECT().LocalAddr().Return(&net.UDPAddr{}).AnyTimes()
+		sess := originalClientSessConstructor(
+			mconn,
+			NewMockSessionRunner(mockCtrl),
+			d.client.destConnID,
+			d.client.srcConnID,
+			d.client.config,
+			d.client.tlsConf,
+			0,
+			false,
+			false,
+			d.handshake,
+			nil,
+			1234,
+			utils.DefaultLogger,
+			utils.ChooseHystart,
+			utils.ChooseNewReno,
+			protocol.VersionTLS,
+		).(*session)
+		Expect(sess.handshakeStarted).To(BeTrue())
+		Expect(sess.cryptoStreamHandler).To(Equal(d.handshake.cryptoSetup))
+		Expect(sess.rttStats).To(BeIdenticalTo(d.handshake.rttStats))
+		Expect(sess.clientHelloWritten).To(Receive(BeNil()))
+		// the session's callbacks were installed on the handshake runner
+		Expect(d.handshake.runner.onHandshakeComplete).ToNot(BeNil())
+		d.handshake.runner.OnHandshakeComplete()
+		Expect(sess.handshakeCompleteChan).To(BeClosed())
+	})
+})
@@ -45,6 +45,26 @@ func (v versions) MarshalJSONArray(enc *gojay.Encoder) {
 	}
 }
 
+type frameSources logging.FrameSources
+
+func (s frameSources) IsNil() bool { return false }
+func (s frameSources) MarshalJSONArray(enc *gojay.Encoder) {
+	for _, src := range []struct {
+		source logging.FrameSources
+		name   string
+	}{
+		{logging.FrameSourceAck, "ack"},
+		{logging.FrameSourceCrypto, "crypto"},
+		{logging.FrameSourceRetransmission, "retransmission"},
+		{logging.FrameSourceData, "data"},
+		{logging.FrameSourceDatagram, "datagram"},
+	} {
+		if logging.FrameSources(s)&src.source != 0 {
+			enc.AddString(src.name)
+		}
+	}
+}
+
 type rawInfo struct {
 	Length        logging.ByteCount // full packet length, including header and AEAD authentication tag
 	PayloadLength logging.ByteCount // length of the packet payload, excluding AEAD tag
@@ -115,6 +135,8 @@ func (e eventConnectionClosed) MarshalJSONObject(enc *gojay.Encoder) {
 	var (
 		statelessResetErr     *quic.StatelessResetError
 		handshakeTimeoutErr   *quic.HandshakeTimeoutError
+		firstFlightTimeoutErr *quic.FirstFlightTimeoutError
+		fallbackAdvisedErr    *quic.FallbackAdvisedError
 		idleTimeoutErr        *quic.IdleTimeoutError
 		applicationErr        *quic.ApplicationError
 		transportErr          *quic.TransportError
@@ -128,6 +150,15 @@ func (e eventConnectionClosed) MarshalJSONObject(enc *gojay.Encoder) {
 	case errors.As(e.e, &handshakeTimeoutErr):
 		enc.StringKey("owner", ownerLocal.String())
 		enc.StringKey("trigger", "handshake_timeout")
+	case errors.As(e.e, &firstFlightTimeoutErr):
+		enc.StringKey("owner", ownerLocal.String())
+		enc.StringKey("trigger", "handshake_timeout")
+		enc.StringKey("reason", firstFlightTimeoutErr.Error())
+	case errors.As(e.e, &fallbackAdvisedErr):
+		enc.StringKey("owner", ownerLocal.String())
+		enc.StringKey("trigger", "handshake_timeout")
+		enc.StringKey("reason", fallbackAdvisedErr.Error())
+		enc.BoolKey("fallback_advised", true)
 	case errors.As(e.e, &idleTimeoutErr):
 		enc.StringKey("owner", ownerLocal.String())
 		enc.StringKey("trigger", "idle_timeout")
@@ -176,6 +207,35 @@ func (e eventPacketSent) MarshalJSONObject(enc *gojay.Encoder) {
 	enc.StringKeyOmitEmpty("trigger", e.Trigger)
 }
 
+type eventPacketComposed struct {
+	PacketType   logging.PacketType
+	PacketNumber protocol.PacketNumber
+	Composition  logging.PacketComposition
+}
+
+func (e eventPacketComposed) Category() category { return categoryTransport }
+func (e eventPacketComposed) Name() string       { return "packet_composed" }
+func (e eventPacketComposed) IsNil() bool        { return false }
+
+func (e eventPacketComposed) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.ObjectKey("header", packetHeaderWithTypeAndPacketNumber{
+		PacketType:   e.PacketType,
+		PacketNumber: e.PacketNumber,
+	})
+	enc.ArrayKey("considered", frameSources(e.Composition.Considered))
+	enc.Int64Key("max_size", int64(e.Composition.MaxSize))
+	enc.Int64KeyOmitEmpty("padding", int64(e.Composition.Padding))
+	if e.Composition.PacingBudget != protocol.MaxByteCount {
+		enc.Int64Key("pacing_budget", int64(e.Composition.PacingBudget))
+	}
+	if e.Composition.CongestionWindowBudget != protocol.MaxByteCount {
+		enc.Int64Key("congestion_window_budget", int64(e.Composition.CongestionWindowBudget))
+	}
+	if e.Composition.AmplificationBudget != protocol.MaxByteCount {
+		enc.Int64Key("amplification_budget", int64(e.Composition.AmplificationBudget))
+	}
+}
+
 type eventPacketReceived struct {
 	Header        packetHeader
 	Length        logging.ByteCount
@@ -255,15 +315,97 @@ func (e eventPacketDropped) MarshalJSONObject(enc *gojay.Encoder) {
 	enc.StringKey("trigger", e.Trigger.String())
 }
 
+type eventDatagramFrameSent struct {
+	Length protocol.ByteCount
+}
+
+func (e eventDatagramFrameSent) Category() category { return categoryTransport }
+func (e eventDatagramFrameSent) Name() string       { return "datagram_frame_sent" }
+func (e eventDatagramFrameSent) IsNil() bool        { return false }
+
+func (e eventDatagramFrameSent) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.Uint64Key("length", uint64(e.Length))
+}
+
+type eventDatagramFrameReceived struct {
+	Length protocol.ByteCount
+}
+
+func (e eventDatagramFrameReceived) Category() category { return categoryTransport }
+func (e eventDatagramFrameReceived) Name() string       { return "datagram_frame_received" }
+func (e eventDatagramFrameReceived) IsNil() bool        { return false }
+
+func (e eventDatagramFrameReceived) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.Uint64Key("length", uint64(e.Length))
+}
+
+type eventDatagramFrameDropped struct {
+	Length  protocol.ByteCount
+	Trigger datagramDropReason
+}
+
+func (e eventDatagramFrameDropped) Category() category { return categoryTransport }
+func (e eventDatagramFrameDropped) Name() string       { return "datagram_frame_dropped" }
+func (e eventDatagramFrameDropped) IsNil() bool        { return false }
+
+func (e eventDatagramFrameDropped) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.Uint64Key("length", uint64(e.Length))
+	enc.StringKey("trigger", e.Trigger.String())
+}
+
+type eventFlowControlUpdated struct {
+	Owner      owner
+	StreamID   protocol.StreamID // InvalidStreamID for the connection
+	Limit      protocol.ByteCount
+	WindowSize protocol.ByteCount // only set for updates we sent
+	BlockedFor time.Duration      // only set for updates we received
+}
+
+func (e eventFlowControlUpdated) Category() category { return categoryTransport }
+func (e eventFlowControlUpdated) Name() string       { return "flow_control_updated" }
+func (e eventFlowControlUpdated) IsNil() bool        { return false }
+
+func (e eventFlowControlUpdated) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.StringKey("owner", e.Owner.String())
+	if e.StreamID != protocol.InvalidStreamID {
+		enc.Int64Key("stream_id", int64(e.StreamID))
+	}
+	enc.Uint64Key("limit", uint64(e.Limit))
+	if e.WindowSize > 0 {
+		enc.Uint64Key("window_size", uint64(e.WindowSize))
+	}
+	if e.BlockedFor > 0 {
+		enc.FloatKey("blocked_for", milliseconds(e.BlockedFor))
+	}
+}
+
+type eventFlowControlBlocked struct {
+	StreamID protocol.StreamID // InvalidStreamID for the connection
+	Limit    protocol.ByteCount
+}
+
+func (e eventFlowControlBlocked) Category() category { return categoryTransport }
+func (e eventFlowControlBlocked) Name() string       { return "flow_control_blocked" }
+func (e eventFlowControlBlocked) IsNil() bool        { return false }
+
+func (e eventFlowControlBlocked) MarshalJSONObject(enc *gojay.Encoder) {
+	if e.StreamID != protocol.InvalidStreamID {
+		enc.Int64Key("stream_id", int64(e.StreamID))
+	}
+	enc.Uint64Key("limit", uint64(e.Limit))
+}
+
 type metrics struct {
 	MinRTT      time.Duration
 	SmoothedRTT time.Duration
 	LatestRTT   time.Duration
 	RTTVariance time.Duration
 
-	CongestionWindow protocol.ByteCount
-	BytesInFlight    protocol.ByteCount
-	PacketsInFlight  int
+	CongestionWindow   protocol.ByteCount
+	SlowStartThreshold protocol.ByteCount
+	BytesInFlight      protocol.ByteCount
+	PacketsInFlight    int
+	PacingRate         uint64 // in bits/s
 }
 
 type eventMetricsUpdated struct {
@@ -292,12 +434,19 @@ func (e eventMetricsUpdated) MarshalJSONObject(enc *gojay.Encoder) {
 	if e.Last == nil || e.Last.CongestionWindow != e.Current.CongestionWindow {
 		enc.Uint64Key("congestion_window", uint64(e.Current.CongestionWindow))
 	}
+	// The slow start threshold is infinite until the first congestion event.
+	if (e.Last == nil || e.Last.SlowStartThreshold != e.Current.SlowStartThreshold) && e.Current.SlowStartThreshold != protocol.MaxByteCount {
+		enc.Uint64KeyOmitEmpty("ssthresh", uint64(e.Current.SlowStartThreshold))
+	}
 	if e.Last == nil || e.Last.BytesInFlight != e.Current.BytesInFlight {
 		enc.Uint64Key("bytes_in_flight", uint64(e.Current.BytesInFlight))
 	}
 	if e.Last == nil || e.Last.PacketsInFlight != e.Current.PacketsInFlight {
 		enc.Uint64KeyOmitEmpty("packets_in_flight", uint64(e.Current.PacketsInFlight))
 	}
+	if e.Last == nil || e.Last.PacingRate != e.Current.PacingRate {
+		enc.Uint64KeyOmitEmpty("pacing_rate", e.Current.PacingRate)
+	}
 }
 
 type eventUpdatedPTO struct {
@@ -312,6 +461,18 @@ func (e eventUpdatedPTO) MarshalJSONObject(enc *gojay.Encoder) {
 	enc.Uint32Key("pto_count", e.Value)
 }
 
+type eventMTUUpdated struct {
+	Value protocol.ByteCount
+}
+
+func (e eventMTUUpdated) Category() category { return categoryConnectivity }
+func (e eventMTUUpdated) Name() string       { return "mtu_updated" }
+func (e eventMTUUpdated) IsNil() bool        { return false }
+
+func (e eventMTUUpdated) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.Uint64Key("new", uint64(e.Value))
+}
+
 type eventPacketLost struct {
 	PacketType   logging.PacketType
 	PacketNumber protocol.PacketNumber
@@ -330,6 +491,90 @@ func (e eventPacketLost) MarshalJSONObject(enc *gojay.Encoder) {
 	enc.StringKey("trigger", e.Trigger.String())
 }
 
+type eventPersistentCongestion struct {
+	Duration time.Duration
+}
+
+func (e eventPersistentCongestion) Category() category { return categoryRecovery }
+func (e eventPersistentCongestion) Name() string       { return "persistent_congestion" }
+func (e eventPersistentCongestion) IsNil() bool        { return false }
+
+func (e eventPersistentCongestion) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.FloatKey("duration", milliseconds(e.Duration))
+}
+
+type eventSuspendDetected struct {
+	Duration time.Duration
+}
+
+func (e eventSuspendDetected) Category() category { return categoryRecovery }
+func (e eventSuspendDetected) Name() string       { return "suspend_detected" }
+func (e eventSuspendDetected) IsNil() bool        { return false }
+
+func (e eventSuspendDetected) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.FloatKey("duration", milliseconds(e.Duration))
+}
+
+type eventPacketLossClassified struct {
+	PacketNumber protocol.PacketNumber
+	Random       bool
+}
+
+func (e eventPacketLossClassified) Category() category { return categoryRecovery }
+func (e eventPacketLossClassified) Name() string       { return "packet_loss_classified" }
+func (e eventPacketLossClassified) IsNil() bool        { return false }
+
+func (e eventPacketLossClassified) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.Int64Key("packet_number", int64(e.PacketNumber))
+	if e.Random {
+		enc.StringKey("classification", "random")
+	} else {
+		enc.StringKey("classification", "congestion")
+	}
+}
+
+type eventPacerDelayedSend struct {
+	Budget protocol.ByteCount
+	Delay  time.Duration // until the next packet can be sent
+}
+
+func (e eventPacerDelayedSend) Category() category { return categoryRecovery }
+func (e eventPacerDelayedSend) Name() string       { return "pacer_delayed_send" }
+func (e eventPacerDelayedSend) IsNil() bool        { return false }
+
+func (e eventPacerDelayedSend) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.Int64Key("budget", int64(e.Budget))
+	enc.FloatKey("delay", milliseconds(e.Delay))
+}
+
+type eventAckRangesLimited struct {
+	EncLevel protocol.EncryptionLevel
+	Evicted  int
+	Omitted  int
+}
+
+func (e eventAckRangesLimited) Category() category { return categoryRecovery }
+func (e eventAckRangesLimited) Name() string       { return "ack_ranges_limited" }
+func (e eventAckRangesLimited) IsNil() bool        { return false }
+
+func (e eventAckRangesLimited) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.StringKey("packet_number_space", encLevelToPacketNumberSpace(e.EncLevel))
+	enc.IntKey("evicted", e.Evicted)
+	enc.IntKey("omitted", e.Omitted)
+}
+
+type eventPacketNumberSkipped struct {
+	PacketNumber protocol.PacketNumber
+}
+
+func (e eventPacketNumberSkipped) Category() category { return categoryRecovery }
+func (e eventPacketNumberSkipped) Name() string       { return "packet_number_skipped" }
+func (e eventPacketNumberSkipped) IsNil() bool        { return false }
+
+func (e eventPacketNumberSkipped) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.Int64Key("packet_number", int64(e.PacketNumber))
+}
+
 type eventKeyUpdated struct {
 	Trigger    keyUpdateTrigger
 	KeyType    keyType
@@ -368,6 +613,26 @@ func (e eventKeyRetired) MarshalJSONObject(enc *gojay.Encoder) {
 	}
 }
 
+type eventZeroRTTAccepted struct{}
+
+func (e eventZeroRTTAccepted) Category() category { return categorySecurity }
+func (e eventZeroRTTAccepted) Name() string       { return "zero_rtt_accepted" }
+func (e eventZeroRTTAccepted) IsNil() bool        { return false }
+
+func (e eventZeroRTTAccepted) MarshalJSONObject(*gojay.Encoder) {}
+
+type eventZeroRTTRejected struct {
+	Trigger zeroRTTRejectReason
+}
+
+func (e eventZeroRTTRejected) Category() category { return categorySecurity }
+func (e eventZeroRTTRejected) Name() string       { return "zero_rtt_rejected" }
+func (e eventZeroRTTRejected) IsNil() bool        { return false }
+
+func (e eventZeroRTTRejected) MarshalJSONObject(enc *gojay.Encoder) {
+	enc.StringKey("trigger", e.Trigger.String())
+}
+
 type eventTransportParameters struct {
 	Restore bool
 	Owner   owner
@@ -0,0 +1,57 @@
+package qlog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/logging"
+)
+
+// DefaultFileNameTemplate is the file name template used by NewFileTracer.
+const DefaultFileNameTemplate = "{odcid}_{role}.qlog"
+
+// NewFileTracer creates a qlog tracer that writes the qlog of every connection to a file in the directory given by dirTemplate.
+// The files are named according to DefaultFileNameTemplate.
+// See NewFileSink for the placeholders that can be used in the template.
+func NewFileTracer(dirTemplate string) logging.Tracer {
+	return NewTracer(NewFileSink(dirTemplate, DefaultFileNameTemplate))
+}
+
+// NewFileSink writes the qlog of every connection to a file.
+// The following placeholders in the directory and the file name template are replaced:
+//
+//	{role}: the vantage point, "client" or "server"
+//	{odcid}: the original destination connection ID, hex encoded
+//	{date}: the date the connection was started at (UTC), formatted as 2006-01-02
+//	{timestamp}: the time the connection was started at (UTC), formatted as 20060102T150405.000000Z
+//
+// The directory is created if it doesn't exist yet.
+func NewFileSink(dirTemplate, fileNameTemplate string) Sink {
+	return func(p logging.Perspective, connectionID []byte) io.WriteCloser {
+		now := time.Now().UTC()
+		r := strings.NewReplacer(
+			"{role}", strings.ToLower(p.String()),
+			"{odcid}", fmt.Sprintf("%x", connectionID),
+			"{date}", now.Format("2006-01-02"),
+			"{timestamp}", now.Format("20060102T150405.000000Z"),
+		)
+		dir := r.Replace(dirTemplate)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			log.Printf("creating qlog directory failed: %s\n", err)
+			return nil
+		}
+		f, err := os.Create(filepath.Join(dir, r.Replace(fileNameTemplate)))
+		if err != nil {
+			log.Printf("creating qlog file failed: %s\n", err)
+			return nil
+		}
+		return utils.NewBufferedWriteCloser(bufio.NewWriter(f), f)
+	}
+}
@@ -0,0 +1,61 @@
+package qlog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("File sink", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "qlog")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("uses the default file name", func() {
+		w := NewFileSink(dir, DefaultFileNameTemplate)(logging.PerspectiveClient, []byte{0xde, 0xad, 0xbe, 0xef})
+		Expect(w).ToNot(BeNil())
+		_, err := w.Write([]byte("foobar"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(w.Close()).To(Succeed())
+		data, err := ioutil.ReadFile(filepath.Join(dir, "deadbeef_client.qlog"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(data)).To(Equal("foobar"))
+	})
+
+	It("replaces the placeholders, and creates the directory", func() {
+		w := NewFileSink(filepath.Join(dir, "{date}", "{role}"), "{timestamp}_{odcid}.qlog")(logging.PerspectiveServer, []byte{0xca, 0xfe})
+		Expect(w).ToNot(BeNil())
+		Expect(w.Close()).To(Succeed())
+		date := time.Now().UTC().Format("2006-01-02")
+		files, err := ioutil.ReadDir(filepath.Join(dir, date, "server"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(files).To(HaveLen(1))
+		Expect(files[0].Name()).To(MatchRegexp(`^\d{8}T\d{6}\.\d{6}Z_cafe\.qlog$`))
+	})
+
+	It("returns nil if the directory can't be created", func() {
+		b := &bytes.Buffer{}
+		log.SetOutput(b)
+		defer log.SetOutput(os.Stdout)
+		file := filepath.Join(dir, "file")
+		Expect(ioutil.WriteFile(file, []byte("foo"), 0o644)).To(Succeed())
+		Expect(NewFileSink(filepath.Join(file, "{role}"), DefaultFileNameTemplate)(logging.PerspectiveServer, []byte{0xde, 0xad})).To(BeNil())
+		Expect(b.String()).To(ContainSubstring("creating qlog directory failed"))
+	})
+})
@@ -59,6 +59,10 @@ func (f frame) MarshalJSONObject(enc *gojay.Encoder) {
 		marshalHandshakeDoneFrame(enc, frame)
 	case *logging.DatagramFrame:
 		marshalDatagramFrame(enc, frame)
+	case *logging.ObservedAddressFrame:
+		marshalObservedAddressFrame(enc, frame)
+	case *logging.DeliveryRateFrame:
+		marshalDeliveryRateFrame(enc, frame)
 	default:
 		panic("unknown frame type")
 	}
@@ -225,3 +229,17 @@ func marshalDatagramFrame(enc *gojay.Encoder, f *logging.DatagramFrame) {
 	enc.StringKey("frame_type", "datagram")
 	enc.Int64Key("length", int64(f.Length))
 }
+
+func marshalObservedAddressFrame(enc *gojay.Encoder, f *logging.ObservedAddressFrame) {
+	enc.StringKey("frame_type", "observed_address")
+	enc.Uint64Key("sequence_number", f.SequenceNumber)
+	enc.StringKey("ip", f.IP.String())
+	enc.Uint64Key("port", uint64(f.Port))
+}
+
+func marshalDeliveryRateFrame(enc *gojay.Encoder, f *logging.DeliveryRateFrame) {
+	enc.StringKey("frame_type", "delivery_rate")
+	enc.Uint64Key("sequence_number", f.SequenceNumber)
+	enc.Uint64Key("rate", f.Rate)
+	enc.FloatKey("interval", milliseconds(f.Interval))
+}
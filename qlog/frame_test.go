@@ -3,6 +3,7 @@ package qlog
 import (
 	"bytes"
 	"encoding/json"
+	"net"
 	"time"
 
 	"github.com/lucas-clemente/quic-go/internal/protocol"
@@ -374,4 +375,34 @@ var _ = Describe("Frames", func() {
 			},
 		)
 	})
+	It("marshals OBSERVED_ADDRESS frames", func() {
+		check(
+			&logging.ObservedAddressFrame{
+				SequenceNumber: 42,
+				IP:             net.IPv4(192, 168, 0, 1),
+				Port:           1337,
+			},
+			map[string]interface{}{
+				"frame_type":      "observed_address",
+				"sequence_number": 42,
+				"ip":              "192.168.0.1",
+				"port":            1337,
+			},
+		)
+	})
+	It("marshals DELIVERY_RATE frames", func() {
+		check(
+			&logging.DeliveryRateFrame{
+				SequenceNumber: 42,
+				Rate:           1e6,
+				Interval:       25 * time.Millisecond,
+			},
+			map[string]interface{}{
+				"frame_type":      "delivery_rate",
+				"sequence_number": 42,
+				"rate":            1000000,
+				"interval":        25,
+			},
+		)
+	})
 })
@@ -0,0 +1,252 @@
+package qlog
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/lucas-clemente/quic-go/logging"
+)
+
+// liveSubscriberBufferSize is the number of lines buffered for a subscriber.
+// Subscribers that fall behind by more than this are disconnected, such that they never block the connection.
+const liveSubscriberBufferSize = 1000
+
+// A LiveStream streams the qlogs of running connections to HTTP clients, e.g. a qvis-style live dashboard.
+// It is an http.Handler:
+// A GET request without query parameters returns the list of running connections as a JSON array.
+// A GET request with the query parameter "odcid" (hex-encoded) streams the qlog of that connection as NDJSON,
+// starting with the header of the trace, followed by all events recorded after the request was received.
+// The response ends when the connection is closed.
+type LiveStream struct {
+	mutex       sync.Mutex
+	connections map[string]*liveConnection
+}
+
+var _ http.Handler = &LiveStream{}
+
+// LiveConnection describes a connection that can be streamed.
+type LiveConnection struct {
+	VantagePoint string `json:"vantage_point"`
+	ODCID        string `json:"odcid"`
+}
+
+// NewLiveStream creates a new LiveStream.
+// To record the qlogs of connections, use its Sink with a tracer.
+func NewLiveStream() *LiveStream {
+	return &LiveStream{connections: make(map[string]*liveConnection)}
+}
+
+// Sink returns a Sink that makes the qlog of every connection available to the LiveStream.
+// The qlog is also written to the writer returned by next, if next is not nil.
+func (s *LiveStream) Sink(next Sink) Sink {
+	return func(p logging.Perspective, connectionID []byte) io.WriteCloser {
+		c := &liveConnection{
+			perspective: p,
+			odcid:       logging.ConnectionID(append([]byte{}, connectionID...)),
+			subscribers: make(map[chan []byte]struct{}),
+		}
+		if next != nil {
+			c.next = next(p, connectionID)
+		}
+		key := string(connectionID)
+		c.remove = func() {
+			s.mutex.Lock()
+			if s.connections[key] == c {
+				delete(s.connections, key)
+			}
+			s.mutex.Unlock()
+		}
+		s.mutex.Lock()
+		s.connections[key] = c
+		s.mutex.Unlock()
+		return c
+	}
+}
+
+// Connections returns the running connections, sorted by their original destination connection ID.
+func (s *LiveStream) Connections() []LiveConnection {
+	s.mutex.Lock()
+	conns := make([]*liveConnection, 0, len(s.connections))
+	for _, c := range s.connections {
+		conns = append(conns, c)
+	}
+	s.mutex.Unlock()
+
+	sort.Slice(conns, func(i, j int) bool { return bytes.Compare(conns[i].odcid, conns[j].odcid) < 0 })
+	list := make([]LiveConnection, 0, len(conns))
+	for _, c := range conns {
+		list = append(list, LiveConnection{
+			VantagePoint: strings.ToLower(c.perspective.String()),
+			ODCID:        hex.EncodeToString(c.odcid),
+		})
+	}
+	return list
+}
+
+func (s *LiveStream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	odcidStr := r.URL.Query().Get("odcid")
+	if odcidStr == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.Connections())
+		return
+	}
+	odcid, err := hex.DecodeString(odcidStr)
+	if err != nil {
+		http.Error(w, "invalid odcid", http.StatusBadRequest)
+		return
+	}
+	s.mutex.Lock()
+	c, ok := s.connections[string(odcid)]
+	s.mutex.Unlock()
+	if !ok {
+		http.Error(w, "unknown connection", http.StatusNotFound)
+		return
+	}
+	header, lines := c.subscribe()
+	if lines == nil { // the connection was closed in the meantime
+		http.Error(w, "unknown connection", http.StatusNotFound)
+		return
+	}
+	defer c.unsubscribe(lines)
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	write := func(b []byte) bool {
+		if _, err := w.Write(b); err != nil {
+			return false
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return true
+	}
+	if header != nil && !write(header) {
+		return
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			if !write(line) {
+				return
+			}
+		}
+	}
+}
+
+type liveConnection struct {
+	perspective logging.Perspective
+	odcid       logging.ConnectionID
+	next        io.WriteCloser // may be nil
+	remove      func()
+
+	mutex       sync.Mutex
+	header      []byte // the first line
+	current     []byte // the line that is currently being written
+	closed      bool
+	subscribers map[chan []byte]struct{}
+}
+
+var (
+	_ io.WriteCloser = &liveConnection{}
+	_ errorRecorder  = &liveConnection{}
+)
+
+func (c *liveConnection) Write(b []byte) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	data := b
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i == -1 {
+			c.current = append(c.current, data...)
+			break
+		}
+		c.current = append(c.current, data[:i+1]...)
+		data = data[i+1:]
+		c.addLine(c.current)
+		c.current = nil
+	}
+	if c.next != nil {
+		return c.next.Write(b)
+	}
+	return len(b), nil
+}
+
+func (c *liveConnection) addLine(line []byte) {
+	if c.header == nil {
+		c.header = line
+		return
+	}
+	for sub := range c.subscribers {
+		select {
+		case sub <- line:
+		default:
+			// The subscriber is too slow. Disconnect it.
+			delete(c.subscribers, sub)
+			close(sub)
+		}
+	}
+}
+
+// subscribe returns the header and a channel that the lines of the trace are sent on.
+// The channel is closed when the connection is closed.
+// It returns a nil channel if the connection was already closed.
+func (c *liveConnection) subscribe() ([]byte, chan []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.closed {
+		return nil, nil
+	}
+	sub := make(chan []byte, liveSubscriberBufferSize)
+	c.subscribers[sub] = struct{}{}
+	return c.header, sub
+}
+
+func (c *liveConnection) unsubscribe(sub chan []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := c.subscribers[sub]; ok {
+		delete(c.subscribers, sub)
+		close(sub)
+	}
+}
+
+func (c *liveConnection) closedWithError(e error) {
+	if r, ok := c.next.(errorRecorder); ok {
+		r.closedWithError(e)
+	}
+}
+
+func (c *liveConnection) Close() error {
+	c.remove()
+	c.mutex.Lock()
+	c.closed = true
+	for sub := range c.subscribers {
+		close(sub)
+	}
+	c.subscribers = nil
+	c.mutex.Unlock()
+
+	if c.next != nil {
+		return c.next.Close()
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package qlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Live Stream", func() {
+	var (
+		stream *LiveStream
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		stream = NewLiveStream()
+		server = httptest.NewServer(stream)
+	})
+
+	AfterEach(func() { server.Close() })
+
+	It("lists the running connections", func() {
+		sink := stream.Sink(nil)
+		w1 := sink(logging.PerspectiveServer, []byte{0xde, 0xad})
+		w2 := sink(logging.PerspectiveClient, []byte{0xbe, 0xef})
+		rsp, err := http.Get(server.URL)
+		Expect(err).ToNot(HaveOccurred())
+		defer rsp.Body.Close()
+		Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+		var conns []LiveConnection
+		Expect(json.NewDecoder(rsp.Body).Decode(&conns)).To(Succeed())
+		Expect(conns).To(Equal([]LiveConnection{
+			{VantagePoint: "client", ODCID: "beef"},
+			{VantagePoint: "server", ODCID: "dead"},
+		}))
+		Expect(w1.Close()).To(Succeed())
+		Expect(w2.Close()).To(Succeed())
+		Expect(stream.Connections()).To(BeEmpty())
+	})
+
+	It("streams the qlog of a connection", func() {
+		w := stream.Sink(nil)(logging.PerspectiveServer, []byte{0xde, 0xad})
+		w.Write([]byte("header\nevent 1\n"))
+		rsp, err := http.Get(server.URL + "?odcid=dead")
+		Expect(err).ToNot(HaveOccurred())
+		defer rsp.Body.Close()
+		Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+		Expect(rsp.Header.Get("Content-Type")).To(Equal("application/x-ndjson"))
+		r := bufio.NewReader(rsp.Body)
+		line, err := r.ReadString('\n')
+		Expect(err).ToNot(HaveOccurred())
+		Expect(line).To(Equal("header\n"))
+		// events are streamed while the connection is running
+		w.Write([]byte("event"))
+		w.Write([]byte(" 2\n"))
+		line, err = r.ReadString('\n')
+		Expect(err).ToNot(HaveOccurred())
+		Expect(line).To(Equal("event 2\n"))
+		// the response ends when the connection is closed
+		Expect(w.Close()).To(Succeed())
+		_, err = r.ReadString('\n')
+		Expect(err).To(Equal(io.EOF))
+	})
+
+	It("rejects unknown connections", func() {
+		rsp, err := http.Get(server.URL + "?odcid=dead")
+		Expect(err).ToNot(HaveOccurred())
+		rsp.Body.Close()
+		Expect(rsp.StatusCode).To(Equal(http.StatusNotFound))
+		rsp, err = http.Get(server.URL + "?odcid=foobar")
+		Expect(err).ToNot(HaveOccurred())
+		rsp.Body.Close()
+		Expect(rsp.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+
+	It("disconnects subscribers that are too slow", func() {
+		w := stream.Sink(nil)(logging.PerspectiveServer, []byte{0xde, 0xad})
+		w.Write([]byte("header\n"))
+		c := w.(*liveConnection)
+		_, sub := c.subscribe()
+		for i := 0; i < liveSubscriberBufferSize; i++ {
+			w.Write([]byte("event\n"))
+		}
+		Expect(sub).To(HaveLen(liveSubscriberBufferSize))
+		w.Write([]byte("event\n"))
+		Expect(sub).To(HaveLen(liveSubscriberBufferSize))
+		Expect(c.subscribers).To(BeEmpty())
+		for range sub { // drain the channel, it's closed
+		}
+		Expect(w.Close()).To(Succeed())
+	})
+
+	It("writes the qlog to the next sink", func() {
+		buf := &bytes.Buffer{}
+		w := stream.Sink(func(logging.Perspective, []byte) io.WriteCloser {
+			return NewRingBufferSink(0, func(logging.Perspective, []byte) io.WriteCloser {
+				return nopWriteCloser(buf)
+			})(logging.PerspectiveServer, nil)
+		})(logging.PerspectiveServer, []byte{0xde, 0xad})
+		w.Write([]byte("header\nevent\n"))
+		w.(errorRecorder).closedWithError(&quic.IdleTimeoutError{})
+		Expect(w.Close()).To(Succeed())
+		Expect(buf.String()).To(Equal("header\nevent\n"))
+	})
+})
@@ -11,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/lucas-clemente/quic-go"
 	"github.com/lucas-clemente/quic-go/internal/protocol"
 	"github.com/lucas-clemente/quic-go/internal/utils"
 	"github.com/lucas-clemente/quic-go/internal/wire"
@@ -50,21 +51,43 @@ func init() {
 const eventChanSize = 50
 
 type tracer struct {
-	getLogWriter func(p logging.Perspective, connectionID []byte) io.WriteCloser
+	getLogWriter Sink
+	scope        *StreamScope
 }
 
 var _ logging.Tracer = &tracer{}
 
 // NewTracer creates a new qlog tracer.
-func NewTracer(getLogWriter func(p logging.Perspective, connectionID []byte) io.WriteCloser) logging.Tracer {
+// The qlog of every connection is written to the writer returned by getLogWriter.
+// Traces use the NDJSON serialization of qlog draft-02: every event is written on its own line as soon as it is recorded,
+// so traces can be streamed and ingested (e.g. by qvis) before the connection is closed.
+// Files named after the connection can be created using NewFileSink (or NewFileTracer).
+// Besides writing qlogs to files, they can be kept in memory (see NewRingBufferSink), or uploaded (see NewHTTPSink).
+// Long traces can be split into rotated and compressed files (see NewRotatingFileSink).
+// Running connections can be watched by a live dashboard (see LiveStream),
+// and recently closed connections can be served to qvis (see RecentConnections).
+func NewTracer(getLogWriter Sink) logging.Tracer {
 	return &tracer{getLogWriter: getLogWriter}
 }
 
-func (t *tracer) TracerForConnection(_ context.Context, p logging.Perspective, odcid protocol.ConnectionID) logging.ConnectionTracer {
-	if w := t.getLogWriter(p, odcid.Bytes()); w != nil {
-		return NewConnectionTracer(w, p, odcid)
+// NewScopedTracer creates a new qlog tracer that only records stream-level frames for the streams selected by the scope.
+func NewScopedTracer(getLogWriter Sink, scope *StreamScope) logging.Tracer {
+	return &tracer{getLogWriter: getLogWriter, scope: scope}
+}
+
+func (t *tracer) TracerForConnection(ctx context.Context, p logging.Perspective, odcid protocol.ConnectionID) logging.ConnectionTracer {
+	w := t.getLogWriter(p, odcid.Bytes())
+	if w == nil {
+		return nil
 	}
-	return nil
+	ct := newConnectionTracer(w, p, odcid)
+	if t.scope != nil {
+		tracingID, _ := ctx.Value(quic.SessionTracingKey).(uint64)
+		t.scope.addConnection(tracingID)
+		ct.tracesStream = func(id logging.StreamID) bool { return t.scope.traces(tracingID, id) }
+		ct.onClose = func() { t.scope.removeConnection(tracingID) }
+	}
+	return ct
 }
 
 func (t *tracer) SentPacket(net.Addr, *logging.Header, protocol.ByteCount, []logging.Frame) {}
@@ -84,12 +107,19 @@ type connectionTracer struct {
 	runStopped chan struct{}
 
 	lastMetrics *metrics
+
+	tracesStream func(logging.StreamID) bool // if set, only stream-level frames of the streams it returns true for are recorded
+	onClose      func()
 }
 
 var _ logging.ConnectionTracer = &connectionTracer{}
 
 // NewConnectionTracer creates a new tracer to record a qlog for a connection.
 func NewConnectionTracer(w io.WriteCloser, p protocol.Perspective, odcid protocol.ConnectionID) logging.ConnectionTracer {
+	return newConnectionTracer(w, p, odcid)
+}
+
+func newConnectionTracer(w io.WriteCloser, p protocol.Perspective, odcid protocol.ConnectionID) *connectionTracer {
 	t := &connectionTracer{
 		w:             w,
 		perspective:   p,
@@ -141,6 +171,9 @@ func (t *connectionTracer) run() {
 }
 
 func (t *connectionTracer) Close() {
+	if t.onClose != nil {
+		t.onClose()
+	}
 	if err := t.export(); err != nil {
 		log.Printf("exporting qlog failed: %s\n", err)
 	}
@@ -207,6 +240,9 @@ func (t *connectionTracer) NegotiatedVersion(chosen logging.VersionNumber, clien
 }
 
 func (t *connectionTracer) ClosedConnection(e error) {
+	if r, ok := t.w.(errorRecorder); ok {
+		r.closedWithError(e)
+	}
 	t.mutex.Lock()
 	t.recordEvent(time.Now(), &eventConnectionClosed{e: e})
 	t.mutex.Unlock()
@@ -286,7 +322,9 @@ func (t *connectionTracer) SentPacket(hdr *wire.ExtendedHeader, packetSize loggi
 		fs = append(fs, frame{Frame: ack})
 	}
 	for _, f := range frames {
-		fs = append(fs, frame{Frame: f})
+		if t.recordsFrame(f) {
+			fs = append(fs, frame{Frame: f})
+		}
 	}
 	header := *transformExtendedHeader(hdr)
 	t.mutex.Lock()
@@ -299,10 +337,22 @@ func (t *connectionTracer) SentPacket(hdr *wire.ExtendedHeader, packetSize loggi
 	t.mutex.Unlock()
 }
 
+func (t *connectionTracer) ComposedPacket(encLevel protocol.EncryptionLevel, pn protocol.PacketNumber, composition *logging.PacketComposition) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventPacketComposed{
+		PacketType:   getPacketTypeFromEncryptionLevel(encLevel),
+		PacketNumber: pn,
+		Composition:  *composition,
+	})
+	t.mutex.Unlock()
+}
+
 func (t *connectionTracer) ReceivedPacket(hdr *wire.ExtendedHeader, packetSize logging.ByteCount, frames []logging.Frame) {
-	fs := make([]frame, len(frames))
-	for i, f := range frames {
-		fs[i] = frame{Frame: f}
+	fs := make([]frame, 0, len(frames))
+	for _, f := range frames {
+		if t.recordsFrame(f) {
+			fs = append(fs, frame{Frame: f})
+		}
 	}
 	header := *transformExtendedHeader(hdr)
 	t.mutex.Lock()
@@ -315,6 +365,15 @@ func (t *connectionTracer) ReceivedPacket(hdr *wire.ExtendedHeader, packetSize l
 	t.mutex.Unlock()
 }
 
+// recordsFrame says if a frame is recorded in the packet_sent and packet_received events.
+func (t *connectionTracer) recordsFrame(f logging.Frame) bool {
+	if t.tracesStream == nil {
+		return true
+	}
+	id, ok := streamIDOfFrame(f)
+	return !ok || t.tracesStream(id)
+}
+
 func (t *connectionTracer) ReceivedRetry(hdr *wire.Header) {
 	t.mutex.Lock()
 	t.recordEvent(time.Now(), &eventRetryReceived{
@@ -336,6 +395,60 @@ func (t *connectionTracer) ReceivedVersionNegotiationPacket(hdr *wire.Header, ve
 	t.mutex.Unlock()
 }
 
+func (t *connectionTracer) SentDatagram([]byte)                                     {}
+func (t *connectionTracer) ReceivedDatagram([]byte)                                 {}
+func (t *connectionTracer) SentPlaintextPacket(logging.EncryptionLevel, []byte)     {}
+func (t *connectionTracer) ReceivedPlaintextPacket(logging.EncryptionLevel, []byte) {}
+
+func (t *connectionTracer) SentDatagramFrame(length protocol.ByteCount) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventDatagramFrameSent{Length: length})
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) ReceivedDatagramFrame(length protocol.ByteCount) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventDatagramFrameReceived{Length: length})
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) DroppedDatagramFrame(length protocol.ByteCount, reason logging.DatagramDropReason) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventDatagramFrameDropped{
+		Length:  length,
+		Trigger: datagramDropReason(reason),
+	})
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) SentFlowControlUpdate(id protocol.StreamID, limit, windowSize protocol.ByteCount) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventFlowControlUpdated{
+		Owner:      ownerLocal,
+		StreamID:   id,
+		Limit:      limit,
+		WindowSize: windowSize,
+	})
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) ReceivedFlowControlUpdate(id protocol.StreamID, limit protocol.ByteCount, blockedFor time.Duration) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventFlowControlUpdated{
+		Owner:      ownerRemote,
+		StreamID:   id,
+		Limit:      limit,
+		BlockedFor: blockedFor,
+	})
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) BlockedByFlowControl(id protocol.StreamID, limit protocol.ByteCount) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventFlowControlBlocked{StreamID: id, Limit: limit})
+	t.mutex.Unlock()
+}
+
 func (t *connectionTracer) BufferedPacket(pt logging.PacketType) {
 	t.mutex.Lock()
 	t.recordEvent(time.Now(), &eventPacketBuffered{PacketType: pt})
@@ -363,6 +476,10 @@ func (t *connectionTracer) UpdatedMetrics(rttStats *utils.RTTStats, cwnd, bytesI
 		PacketsInFlight:  packetsInFlight,
 	}
 	t.mutex.Lock()
+	if t.lastMetrics != nil {
+		m.SlowStartThreshold = t.lastMetrics.SlowStartThreshold
+		m.PacingRate = t.lastMetrics.PacingRate
+	}
 	t.recordEvent(time.Now(), &eventMetricsUpdated{
 		Last:    t.lastMetrics,
 		Current: m,
@@ -371,6 +488,28 @@ func (t *connectionTracer) UpdatedMetrics(rttStats *utils.RTTStats, cwnd, bytesI
 	t.mutex.Unlock()
 }
 
+func (t *connectionTracer) UpdatedCongestionMetrics(cwnd, ssthresh, bytesInFlight protocol.ByteCount, pacingRate uint64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	m := &metrics{}
+	if t.lastMetrics != nil {
+		*m = *t.lastMetrics
+	}
+	m.CongestionWindow = cwnd
+	m.SlowStartThreshold = ssthresh
+	m.BytesInFlight = bytesInFlight
+	m.PacingRate = pacingRate
+	if t.lastMetrics != nil && *m == *t.lastMetrics {
+		return
+	}
+	t.recordEvent(time.Now(), &eventMetricsUpdated{
+		Last:    t.lastMetrics,
+		Current: m,
+	})
+	t.lastMetrics = m
+}
+
 func (t *connectionTracer) AcknowledgedPacket(protocol.EncryptionLevel, protocol.PacketNumber) {}
 
 func (t *connectionTracer) LostPacket(encLevel protocol.EncryptionLevel, pn protocol.PacketNumber, lossReason logging.PacketLossReason) {
@@ -383,12 +522,59 @@ func (t *connectionTracer) LostPacket(encLevel protocol.EncryptionLevel, pn prot
 	t.mutex.Unlock()
 }
 
+func (t *connectionTracer) DetectedPersistentCongestion(duration time.Duration) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventPersistentCongestion{Duration: duration})
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) DetectedSuspend(duration time.Duration) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventSuspendDetected{Duration: duration})
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) ClassifiedPacketLoss(pn protocol.PacketNumber, random bool) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventPacketLossClassified{PacketNumber: pn, Random: random})
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) PacerDelayedSend(budget protocol.ByteCount, nextSendTime time.Time) {
+	now := time.Now()
+	var delay time.Duration
+	if nextSendTime.After(now) {
+		delay = nextSendTime.Sub(now)
+	}
+	t.mutex.Lock()
+	t.recordEvent(now, &eventPacerDelayedSend{Budget: budget, Delay: delay})
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) LimitedAckRanges(encLevel protocol.EncryptionLevel, evicted, omitted int) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventAckRangesLimited{EncLevel: encLevel, Evicted: evicted, Omitted: omitted})
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) SkippedPacketNumber(pn protocol.PacketNumber) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventPacketNumberSkipped{PacketNumber: pn})
+	t.mutex.Unlock()
+}
+
 func (t *connectionTracer) UpdatedCongestionState(state logging.CongestionState) {
 	t.mutex.Lock()
 	t.recordEvent(time.Now(), &eventCongestionStateUpdated{state: congestionState(state)})
 	t.mutex.Unlock()
 }
 
+func (t *connectionTracer) UpdatedMaxDatagramSize(size protocol.ByteCount) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventMTUUpdated{Value: size})
+	t.mutex.Unlock()
+}
+
 func (t *connectionTracer) UpdatedPTOCount(value uint32) {
 	t.mutex.Lock()
 	t.recordEvent(time.Now(), &eventUpdatedPTO{Value: value})
@@ -450,6 +636,18 @@ func (t *connectionTracer) DroppedKey(generation protocol.KeyPhase) {
 	t.mutex.Unlock()
 }
 
+func (t *connectionTracer) Accepted0RTT() {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventZeroRTTAccepted{})
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) Rejected0RTT(reason logging.ZeroRTTRejectReason) {
+	t.mutex.Lock()
+	t.recordEvent(time.Now(), &eventZeroRTTRejected{Trigger: zeroRTTRejectReason(reason)})
+	t.mutex.Unlock()
+}
+
 func (t *connectionTracer) SetLossTimer(tt logging.TimerType, encLevel protocol.EncryptionLevel, timeout time.Time) {
 	t.mutex.Lock()
 	now := time.Now()
@@ -1,6 +1,7 @@
 package qlog
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -76,6 +77,26 @@ var _ = Describe("Tracing", func() {
 		Expect(b.String()).To(ContainSubstring("writer full"))
 	})
 
+	It("streams events before the trace is closed", func() {
+		r, w := io.Pipe()
+		t := NewConnectionTracer(w, protocol.PerspectiveServer, protocol.ConnectionID{0xde, 0xad, 0xbe, 0xef})
+		reader := bufio.NewReader(r)
+		line, err := reader.ReadBytes('\n')
+		Expect(err).ToNot(HaveOccurred())
+		m := make(map[string]interface{})
+		Expect(json.Unmarshal(line, &m)).To(Succeed())
+		Expect(m).To(HaveKeyWithValue("qlog_format", "NDJSON"))
+		t.UpdatedPTOCount(1)
+		line, err = reader.ReadBytes('\n')
+		Expect(err).ToNot(HaveOccurred())
+		ev := make(map[string]interface{})
+		Expect(json.Unmarshal(line, &ev)).To(Succeed())
+		Expect(ev).To(HaveKeyWithValue("name", "recovery:metrics_updated"))
+		t.Close()
+		_, err = reader.ReadBytes('\n')
+		Expect(err).To(MatchError(io.EOF))
+	})
+
 	Context("connection tracer", func() {
 		var (
 			tracer logging.ConnectionTracer
@@ -217,6 +238,29 @@ var _ = Describe("Tracing", func() {
 				Expect(ev).To(HaveKeyWithValue("trigger", "handshake_timeout"))
 			})
 
+			It("records first flight timeouts", func() {
+				tracer.ClosedConnection(&quic.FirstFlightTimeoutError{})
+				entry := exportAndParseSingle()
+				Expect(entry.Name).To(Equal("transport:connection_closed"))
+				ev := entry.Event
+				Expect(ev).To(HaveLen(3))
+				Expect(ev).To(HaveKeyWithValue("owner", "local"))
+				Expect(ev).To(HaveKeyWithValue("trigger", "handshake_timeout"))
+				Expect(ev).To(HaveKeyWithValue("reason", "timeout: no response from the server"))
+			})
+
+			It("records detected UDP blackholes", func() {
+				tracer.ClosedConnection(&quic.FallbackAdvisedError{})
+				entry := exportAndParseSingle()
+				Expect(entry.Name).To(Equal("transport:connection_closed"))
+				ev := entry.Event
+				Expect(ev).To(HaveLen(4))
+				Expect(ev).To(HaveKeyWithValue("owner", "local"))
+				Expect(ev).To(HaveKeyWithValue("trigger", "handshake_timeout"))
+				Expect(ev).To(HaveKeyWithValue("reason", "timeout: UDP blackhole detected"))
+				Expect(ev).To(HaveKeyWithValue("fallback_advised", true))
+			})
+
 			It("records a received stateless reset packet", func() {
 				tracer.ClosedConnection(&quic.StatelessResetError{
 					Token: protocol.StatelessResetToken{0x00, 0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff},
@@ -517,6 +561,31 @@ var _ = Describe("Tracing", func() {
 				Expect(ev["frames"].([]interface{})).To(HaveLen(2))
 			})
 
+			It("records the composition of packets", func() {
+				tracer.ComposedPacket(protocol.Encryption1RTT, 42, &logging.PacketComposition{
+					Considered:             logging.FrameSourceAck | logging.FrameSourceData,
+					MaxSize:                1200,
+					Padding:                3,
+					PacingBudget:           2400,
+					CongestionWindowBudget: 0,
+					AmplificationBudget:    protocol.MaxByteCount,
+				})
+				entry := exportAndParseSingle()
+				Expect(entry.Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
+				Expect(entry.Name).To(Equal("transport:packet_composed"))
+				ev := entry.Event
+				Expect(ev).To(HaveKey("header"))
+				hdr := ev["header"].(map[string]interface{})
+				Expect(hdr).To(HaveKeyWithValue("packet_type", "1RTT"))
+				Expect(hdr).To(HaveKeyWithValue("packet_number", float64(42)))
+				Expect(ev).To(HaveKeyWithValue("considered", []interface{}{"ack", "data"}))
+				Expect(ev).To(HaveKeyWithValue("max_size", float64(1200)))
+				Expect(ev).To(HaveKeyWithValue("padding", float64(3)))
+				Expect(ev).To(HaveKeyWithValue("pacing_budget", float64(2400)))
+				Expect(ev).To(HaveKeyWithValue("congestion_window_budget", float64(0)))
+				Expect(ev).ToNot(HaveKey("amplification_budget"))
+			})
+
 			It("records a received Retry packet", func() {
 				tracer.ReceivedRetry(
 					&logging.Header{
@@ -600,6 +669,59 @@ var _ = Describe("Tracing", func() {
 				Expect(ev).To(HaveKeyWithValue("trigger", "payload_decrypt_error"))
 			})
 
+			It("records sent and received DATAGRAM frames", func() {
+				tracer.SentDatagramFrame(100)
+				tracer.ReceivedDatagramFrame(200)
+				entries := exportAndParse()
+				Expect(entries).To(HaveLen(2))
+				Expect(entries[0].Name).To(Equal("transport:datagram_frame_sent"))
+				Expect(entries[0].Event).To(HaveKeyWithValue("length", float64(100)))
+				Expect(entries[1].Name).To(Equal("transport:datagram_frame_received"))
+				Expect(entries[1].Event).To(HaveKeyWithValue("length", float64(200)))
+			})
+
+			It("records dropped DATAGRAM frames", func() {
+				tracer.DroppedDatagramFrame(1337, logging.DatagramDropReceiveQueueFull)
+				entry := exportAndParseSingle()
+				Expect(entry.Name).To(Equal("transport:datagram_frame_dropped"))
+				Expect(entry.Event).To(HaveKeyWithValue("length", float64(1337)))
+				Expect(entry.Event).To(HaveKeyWithValue("trigger", "receive_queue_full"))
+			})
+
+			It("records flow control updates", func() {
+				tracer.SentFlowControlUpdate(protocol.InvalidStreamID, 1000, 500)
+				tracer.ReceivedFlowControlUpdate(4, 2000, 1500*time.Millisecond)
+				tracer.ReceivedFlowControlUpdate(8, 3000, 0)
+				entries := exportAndParse()
+				Expect(entries).To(HaveLen(3))
+				for _, entry := range entries {
+					Expect(entry.Name).To(Equal("transport:flow_control_updated"))
+				}
+				Expect(entries[0].Event).To(HaveKeyWithValue("owner", "local"))
+				Expect(entries[0].Event).ToNot(HaveKey("stream_id"))
+				Expect(entries[0].Event).To(HaveKeyWithValue("limit", float64(1000)))
+				Expect(entries[0].Event).To(HaveKeyWithValue("window_size", float64(500)))
+				Expect(entries[0].Event).ToNot(HaveKey("blocked_for"))
+				Expect(entries[1].Event).To(HaveKeyWithValue("owner", "remote"))
+				Expect(entries[1].Event).To(HaveKeyWithValue("stream_id", float64(4)))
+				Expect(entries[1].Event).To(HaveKeyWithValue("limit", float64(2000)))
+				Expect(entries[1].Event).To(HaveKeyWithValue("blocked_for", float64(1500)))
+				Expect(entries[1].Event).ToNot(HaveKey("window_size"))
+				Expect(entries[2].Event).ToNot(HaveKey("blocked_for"))
+			})
+
+			It("records when sending is blocked by flow control", func() {
+				tracer.BlockedByFlowControl(protocol.InvalidStreamID, 1000)
+				tracer.BlockedByFlowControl(4, 2000)
+				entries := exportAndParse()
+				Expect(entries).To(HaveLen(2))
+				Expect(entries[0].Name).To(Equal("transport:flow_control_blocked"))
+				Expect(entries[0].Event).ToNot(HaveKey("stream_id"))
+				Expect(entries[0].Event).To(HaveKeyWithValue("limit", float64(1000)))
+				Expect(entries[1].Event).To(HaveKeyWithValue("stream_id", float64(4)))
+				Expect(entries[1].Event).To(HaveKeyWithValue("limit", float64(2000)))
+			})
+
 			It("records metrics updates", func() {
 				now := time.Now()
 				rttStats := utils.NewRTTStats()
@@ -675,6 +797,34 @@ var _ = Describe("Tracing", func() {
 				Expect(ev).To(HaveKeyWithValue("smoothed_rtt", float64(15)))
 			})
 
+			It("records congestion metrics updates", func() {
+				rttStats := utils.NewRTTStats()
+				rttStats.UpdateRTT(15*time.Millisecond, 0, time.Now())
+				tracer.UpdatedMetrics(rttStats, 4321, 1234, 42)
+				tracer.UpdatedCongestionMetrics(4321, protocol.MaxByteCount, 2000, 1e6)
+				tracer.UpdatedCongestionMetrics(4321, protocol.MaxByteCount, 2000, 1e6) // nothing changed
+				tracer.UpdatedCongestionMetrics(3000, 3000, 2000, 1e6)
+				tracer.UpdatedMetrics(rttStats, 3000, 1000, 41)
+				entries := exportAndParse()
+				Expect(entries).To(HaveLen(4))
+				for _, e := range entries {
+					Expect(e.Name).To(Equal("recovery:metrics_updated"))
+				}
+				Expect(entries[1].Event).To(Equal(map[string]interface{}{
+					"bytes_in_flight": float64(2000),
+					"pacing_rate":     float64(1e6),
+				}))
+				Expect(entries[2].Event).To(Equal(map[string]interface{}{
+					"congestion_window": float64(3000),
+					"ssthresh":          float64(3000),
+				}))
+				// the slow start threshold and pacing rate are retained
+				Expect(entries[3].Event).To(Equal(map[string]interface{}{
+					"bytes_in_flight":   float64(1000),
+					"packets_in_flight": float64(41),
+				}))
+			})
+
 			It("records lost packets", func() {
 				tracer.LostPacket(protocol.EncryptionHandshake, 42, logging.PacketLossReorderingThreshold)
 				entry := exportAndParseSingle()
@@ -689,6 +839,61 @@ var _ = Describe("Tracing", func() {
 				Expect(ev).To(HaveKeyWithValue("trigger", "reordering_threshold"))
 			})
 
+			It("records persistent congestion", func() {
+				tracer.DetectedPersistentCongestion(1500 * time.Millisecond)
+				entry := exportAndParseSingle()
+				Expect(entry.Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
+				Expect(entry.Name).To(Equal("recovery:persistent_congestion"))
+				Expect(entry.Event).To(HaveKeyWithValue("duration", float64(1500)))
+			})
+
+			It("records when the system was suspended", func() {
+				tracer.DetectedSuspend(90 * time.Second)
+				entry := exportAndParseSingle()
+				Expect(entry.Name).To(Equal("recovery:suspend_detected"))
+				Expect(entry.Event).To(HaveKeyWithValue("duration", float64(90000)))
+			})
+
+			It("records the classification of lost packets", func() {
+				tracer.ClassifiedPacketLoss(42, true)
+				tracer.ClassifiedPacketLoss(43, false)
+				entries := exportAndParse()
+				Expect(entries).To(HaveLen(2))
+				Expect(entries[0].Name).To(Equal("recovery:packet_loss_classified"))
+				Expect(entries[0].Event).To(HaveKeyWithValue("packet_number", float64(42)))
+				Expect(entries[0].Event).To(HaveKeyWithValue("classification", "random"))
+				Expect(entries[1].Event).To(HaveKeyWithValue("packet_number", float64(43)))
+				Expect(entries[1].Event).To(HaveKeyWithValue("classification", "congestion"))
+			})
+
+			It("records when the pacer delays sending", func() {
+				tracer.PacerDelayedSend(500, time.Now().Add(5*time.Millisecond))
+				entry := exportAndParseSingle()
+				Expect(entry.Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
+				Expect(entry.Name).To(Equal("recovery:pacer_delayed_send"))
+				Expect(entry.Event).To(HaveKeyWithValue("budget", float64(500)))
+				Expect(entry.Event).To(HaveKey("delay"))
+				Expect(entry.Event["delay"]).To(BeNumerically("~", 5, 1))
+			})
+
+			It("records limited ACK ranges", func() {
+				tracer.LimitedAckRanges(protocol.Encryption1RTT, 3, 5)
+				entry := exportAndParseSingle()
+				Expect(entry.Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
+				Expect(entry.Name).To(Equal("recovery:ack_ranges_limited"))
+				Expect(entry.Event).To(HaveKeyWithValue("packet_number_space", "application_data"))
+				Expect(entry.Event).To(HaveKeyWithValue("evicted", float64(3)))
+				Expect(entry.Event).To(HaveKeyWithValue("omitted", float64(5)))
+			})
+
+			It("records skipped packet numbers", func() {
+				tracer.SkippedPacketNumber(42)
+				entry := exportAndParseSingle()
+				Expect(entry.Time).To(BeTemporally("~", time.Now(), scaleDuration(10*time.Millisecond)))
+				Expect(entry.Name).To(Equal("recovery:packet_number_skipped"))
+				Expect(entry.Event).To(HaveKeyWithValue("packet_number", float64(42)))
+			})
+
 			It("records congestion state updates", func() {
 				tracer.UpdatedCongestionState(logging.CongestionStateCongestionAvoidance)
 				entry := exportAndParseSingle()
@@ -706,6 +911,13 @@ var _ = Describe("Tracing", func() {
 				Expect(entry.Event).To(HaveKeyWithValue("pto_count", float64(42)))
 			})
 
+			It("records MTU changes", func() {
+				tracer.UpdatedMaxDatagramSize(1400)
+				entry := exportAndParseSingle()
+				Expect(entry.Name).To(Equal("connectivity:mtu_updated"))
+				Expect(entry.Event).To(HaveKeyWithValue("new", float64(1400)))
+			})
+
 			It("records TLS key updates", func() {
 				tracer.UpdatedKeyFromTLS(protocol.EncryptionHandshake, protocol.PerspectiveClient)
 				entry := exportAndParseSingle()
@@ -797,6 +1009,20 @@ var _ = Describe("Tracing", func() {
 				Expect(keyTypes).To(ContainElement("client_1rtt_secret"))
 			})
 
+			It("records when 0-RTT is accepted", func() {
+				tracer.Accepted0RTT()
+				entry := exportAndParseSingle()
+				Expect(entry.Name).To(Equal("security:zero_rtt_accepted"))
+				Expect(entry.Event).To(BeEmpty())
+			})
+
+			It("records when 0-RTT is rejected", func() {
+				tracer.Rejected0RTT(logging.ZeroRTTRejectedTransportParameters)
+				entry := exportAndParseSingle()
+				Expect(entry.Name).To(Equal("security:zero_rtt_rejected"))
+				Expect(entry.Event).To(HaveKeyWithValue("trigger", "transport_parameters_changed"))
+			})
+
 			It("records when the timer is set", func() {
 				timeout := time.Now().Add(137 * time.Millisecond)
 				tracer.SetLossTimer(logging.TimerTypePTO, protocol.EncryptionHandshake, timeout)
@@ -0,0 +1,194 @@
+package qlog
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/logging"
+)
+
+// RecentConnections keeps the qlogs of recently closed connections in memory and serves them to qvis.
+// It is an http.Handler, meant to be served on a local address:
+// A GET request without query parameters returns a list of the retained traces, in the format that qvis loads
+// using its "list" URL parameter (e.g. https://qvis.quictools.info/#/files?list=http://localhost:8080/).
+// A GET request with the query parameter "odcid" (hex-encoded) returns the qlog of that connection.
+// Responses allow cross-origin requests, such that they can be fetched by qvis running on a different host.
+type RecentConnections struct {
+	numConnections int
+	maxEvents      int
+
+	mutex       sync.Mutex
+	connections []*recentConnection // ordered by the time the connection was closed, the oldest first
+}
+
+var _ http.Handler = &RecentConnections{}
+
+type recentConnection struct {
+	perspective logging.Perspective
+	odcid       logging.ConnectionID
+	closeTime   time.Time
+	trace       []byte
+}
+
+// qvisList is the format of the list of files that qvis loads.
+type qvisList struct {
+	Description string     `json:"description"`
+	Paths       []qvisPath `json:"paths"`
+}
+
+type qvisPath struct {
+	Capture     string `json:"capture"`
+	Description string `json:"description,omitempty"`
+}
+
+// NewRecentConnections creates a new RecentConnections, that keeps the qlogs of the numConnections most recently closed connections.
+// Of every connection, the header of the trace and the most recent maxEvents events are kept.
+// If maxEvents is 0, all events are kept.
+func NewRecentConnections(numConnections, maxEvents int) *RecentConnections {
+	return &RecentConnections{
+		numConnections: numConnections,
+		maxEvents:      maxEvents,
+	}
+}
+
+// Sink returns a Sink that retains the qlog of every connection when it is closed.
+// The qlog is also written to the writer returned by next, if next is not nil.
+func (r *RecentConnections) Sink(next Sink) Sink {
+	return func(p logging.Perspective, connectionID []byte) io.WriteCloser {
+		w := &recentConnectionWriter{
+			ringBufferWriter: &ringBufferWriter{maxLines: r.maxEvents},
+			conns:            r,
+			perspective:      p,
+			odcid:            logging.ConnectionID(append([]byte{}, connectionID...)),
+		}
+		if next != nil {
+			w.next = next(p, connectionID)
+		}
+		return w
+	}
+}
+
+func (r *RecentConnections) add(c *recentConnection) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.connections = append(r.connections, c)
+	if len(r.connections) > r.numConnections {
+		r.connections[0] = nil
+		r.connections = r.connections[1:]
+	}
+}
+
+func (r *RecentConnections) get(odcid logging.ConnectionID) *recentConnection {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// If the same ODCID was used multiple times, serve the most recent connection.
+	for i := len(r.connections) - 1; i >= 0; i-- {
+		if string(r.connections[i].odcid) == string(odcid) {
+			return r.connections[i]
+		}
+	}
+	return nil
+}
+
+// list returns the qvis list of the retained traces, the most recently closed connection first.
+func (r *RecentConnections) list(base *url.URL) qvisList {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	paths := make([]qvisPath, 0, len(r.connections))
+	for i := len(r.connections) - 1; i >= 0; i-- {
+		c := r.connections[i]
+		u := *base
+		u.RawQuery = url.Values{"odcid": []string{hex.EncodeToString(c.odcid)}}.Encode()
+		paths = append(paths, qvisPath{
+			Capture:     u.String(),
+			Description: fmt.Sprintf("%s %s, closed at %s", strings.ToLower(c.perspective.String()), hex.EncodeToString(c.odcid), c.closeTime.Format(time.RFC3339)),
+		})
+	}
+	return qvisList{
+		Description: "quic-go: recently closed connections",
+		Paths:       paths,
+	}
+}
+
+func (r *RecentConnections) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	odcidStr := req.URL.Query().Get("odcid")
+	if odcidStr == "" {
+		// qvis needs absolute URLs, since it resolves relative URLs relative to its own origin.
+		base := &url.URL{Scheme: "http", Host: req.Host, Path: req.URL.Path}
+		if req.TLS != nil {
+			base.Scheme = "https"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(r.list(base))
+		return
+	}
+	odcid, err := hex.DecodeString(odcidStr)
+	if err != nil {
+		http.Error(w, "invalid odcid", http.StatusBadRequest)
+		return
+	}
+	c := r.get(odcid)
+	if c == nil {
+		http.Error(w, "unknown connection", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	// qvis uses the file extension to detect the NDJSON serialization
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s_%s.sqlog"`, hex.EncodeToString(c.odcid), strings.ToLower(c.perspective.String())))
+	w.Write(c.trace)
+}
+
+type recentConnectionWriter struct {
+	*ringBufferWriter
+	conns       *RecentConnections
+	perspective logging.Perspective
+	odcid       logging.ConnectionID
+	next        io.WriteCloser // may be nil
+}
+
+var (
+	_ io.WriteCloser = &recentConnectionWriter{}
+	_ errorRecorder  = &recentConnectionWriter{}
+)
+
+func (w *recentConnectionWriter) Write(b []byte) (int, error) {
+	n, err := w.ringBufferWriter.Write(b)
+	if w.next != nil {
+		return w.next.Write(b)
+	}
+	return n, err
+}
+
+func (w *recentConnectionWriter) closedWithError(e error) {
+	if r, ok := w.next.(errorRecorder); ok {
+		r.closedWithError(e)
+	}
+}
+
+func (w *recentConnectionWriter) Close() error {
+	w.conns.add(&recentConnection{
+		perspective: w.perspective,
+		odcid:       w.odcid,
+		closeTime:   time.Now(),
+		trace:       w.snapshot(),
+	})
+	if w.next != nil {
+		return w.next.Close()
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package qlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Recent Connections", func() {
+	var (
+		conns  *RecentConnections
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		conns = NewRecentConnections(2, 2)
+		server = httptest.NewServer(conns)
+	})
+
+	AfterEach(func() { server.Close() })
+
+	getList := func() qvisList {
+		rsp, err := http.Get(server.URL + "/")
+		Expect(err).ToNot(HaveOccurred())
+		defer rsp.Body.Close()
+		Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+		Expect(rsp.Header.Get("Access-Control-Allow-Origin")).To(Equal("*"))
+		var list qvisList
+		Expect(json.NewDecoder(rsp.Body).Decode(&list)).To(Succeed())
+		return list
+	}
+
+	It("lists the closed connections, in the qvis list format", func() {
+		w := conns.Sink(nil)(logging.PerspectiveServer, []byte{0xde, 0xad})
+		w.Write([]byte("header\n"))
+		Expect(getList().Paths).To(BeEmpty())
+		Expect(w.Close()).To(Succeed())
+		list := getList()
+		Expect(list.Paths).To(HaveLen(1))
+		Expect(list.Paths[0].Capture).To(Equal(server.URL + "/?odcid=dead"))
+		Expect(list.Paths[0].Description).To(HavePrefix("server dead, closed at "))
+	})
+
+	It("serves the qlog of a closed connection", func() {
+		w := conns.Sink(nil)(logging.PerspectiveClient, []byte{0xde, 0xad})
+		w.Write([]byte("header\nevent 1\nevent 2\nevent 3\n"))
+		Expect(w.Close()).To(Succeed())
+		rsp, err := http.Get(getList().Paths[0].Capture)
+		Expect(err).ToNot(HaveOccurred())
+		defer rsp.Body.Close()
+		Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+		Expect(rsp.Header.Get("Content-Type")).To(Equal("application/x-ndjson"))
+		Expect(rsp.Header.Get("Content-Disposition")).To(ContainSubstring(`filename="dead_client.sqlog"`))
+		body, err := ioutil.ReadAll(rsp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		// only the most recent 2 events are kept
+		Expect(string(body)).To(Equal("header\nevent 2\nevent 3\n"))
+	})
+
+	It("only keeps the most recently closed connections", func() {
+		for _, id := range [][]byte{{1}, {2}, {3}} {
+			w := conns.Sink(nil)(logging.PerspectiveServer, id)
+			w.Write([]byte("header\n"))
+			Expect(w.Close()).To(Succeed())
+		}
+		list := getList()
+		Expect(list.Paths).To(HaveLen(2))
+		Expect(list.Paths[0].Capture).To(HaveSuffix("odcid=03"))
+		Expect(list.Paths[1].Capture).To(HaveSuffix("odcid=02"))
+		rsp, err := http.Get(server.URL + "?odcid=01")
+		Expect(err).ToNot(HaveOccurred())
+		rsp.Body.Close()
+		Expect(rsp.StatusCode).To(Equal(http.StatusNotFound))
+	})
+
+	It("rejects invalid connection IDs", func() {
+		rsp, err := http.Get(server.URL + "?odcid=foobar")
+		Expect(err).ToNot(HaveOccurred())
+		rsp.Body.Close()
+		Expect(rsp.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+
+	It("writes the qlog to the next sink", func() {
+		buf := &bytes.Buffer{}
+		w := conns.Sink(func(logging.Perspective, []byte) io.WriteCloser {
+			return NewRingBufferSink(0, func(logging.Perspective, []byte) io.WriteCloser {
+				return nopWriteCloser(buf)
+			})(logging.PerspectiveServer, nil)
+		})(logging.PerspectiveServer, []byte{0xde, 0xad})
+		w.Write([]byte("header\nevent\n"))
+		w.(errorRecorder).closedWithError(&quic.IdleTimeoutError{})
+		Expect(w.Close()).To(Succeed())
+		Expect(buf.String()).To(Equal("header\nevent\n"))
+		Expect(getList().Paths).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,118 @@
+package qlog
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/lucas-clemente/quic-go/logging"
+)
+
+// A ConnectionSnapshot contains the most recent events of a connection.
+type ConnectionSnapshot struct {
+	Perspective logging.Perspective
+	ODCID       logging.ConnectionID
+	// Trace is a qlog, consisting of the header of the trace and the most recent events.
+	Trace []byte
+}
+
+// A RingBufferTracer is a qlog tracer that keeps the most recent events of every connection in memory.
+// Nothing is written unless the history is requested using Snapshot or Dump.
+// This allows dumping the transport history when a problem is detected, instead of always writing a qlog.
+// The history of a connection is discarded when the connection is closed.
+// To keep the history of connections that were closed with an error, use a NewRingBufferSink.
+type RingBufferTracer struct {
+	*tracer
+
+	numEvents int
+
+	mutex       sync.Mutex
+	connections map[string]*ringBufferConnection
+}
+
+var _ logging.Tracer = &RingBufferTracer{}
+
+type ringBufferConnection struct {
+	perspective logging.Perspective
+	odcid       logging.ConnectionID
+	*ringBufferWriter
+	remove func()
+}
+
+var (
+	_ io.WriteCloser = &ringBufferConnection{}
+	_ errorRecorder  = &ringBufferConnection{}
+)
+
+func (c *ringBufferConnection) Close() error {
+	c.remove()
+	return nil
+}
+
+// NewRingBufferTracer creates a new RingBufferTracer, that keeps the most recent numEvents events of every connection.
+// The header of the trace is always retained.
+func NewRingBufferTracer(numEvents int) *RingBufferTracer {
+	t := &RingBufferTracer{
+		numEvents:   numEvents,
+		connections: make(map[string]*ringBufferConnection),
+	}
+	t.tracer = &tracer{getLogWriter: t.newConnection}
+	return t
+}
+
+func (t *RingBufferTracer) newConnection(p logging.Perspective, connectionID []byte) io.WriteCloser {
+	key := string(connectionID)
+	c := &ringBufferConnection{
+		perspective:      p,
+		odcid:            logging.ConnectionID(append([]byte{}, connectionID...)),
+		ringBufferWriter: &ringBufferWriter{maxLines: t.numEvents},
+	}
+	c.remove = func() {
+		t.mutex.Lock()
+		if t.connections[key] == c {
+			delete(t.connections, key)
+		}
+		t.mutex.Unlock()
+	}
+	t.mutex.Lock()
+	t.connections[key] = c
+	t.mutex.Unlock()
+	return c
+}
+
+// Snapshot returns the most recent events of all open connections, sorted by their original destination connection ID.
+// Events are encoded asynchronously, so events that were recorded just before might be missing.
+func (t *RingBufferTracer) Snapshot() []ConnectionSnapshot {
+	t.mutex.Lock()
+	conns := make([]*ringBufferConnection, 0, len(t.connections))
+	for _, c := range t.connections {
+		conns = append(conns, c)
+	}
+	t.mutex.Unlock()
+
+	sort.Slice(conns, func(i, j int) bool { return bytes.Compare(conns[i].odcid, conns[j].odcid) < 0 })
+	snapshots := make([]ConnectionSnapshot, 0, len(conns))
+	for _, c := range conns {
+		snapshots = append(snapshots, ConnectionSnapshot{
+			Perspective: c.perspective,
+			ODCID:       c.odcid,
+			Trace:       c.snapshot(),
+		})
+	}
+	return snapshots
+}
+
+// Dump writes the most recent events of a connection to w, as a qlog.
+// It returns an error if there's no open connection with this original destination connection ID.
+func (t *RingBufferTracer) Dump(w io.Writer, odcid logging.ConnectionID) error {
+	t.mutex.Lock()
+	c, ok := t.connections[string(odcid)]
+	t.mutex.Unlock()
+	if !ok {
+		return errors.New("qlog: unknown connection")
+	}
+	_, err := w.Write(c.snapshot())
+	return err
+}
@@ -0,0 +1,90 @@
+package qlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/lucas-clemente/quic-go/logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Ring buffer tracer", func() {
+	var tracer *RingBufferTracer
+
+	BeforeEach(func() {
+		tracer = NewRingBufferTracer(3)
+	})
+
+	// eventNames returns the names of the events in a trace
+	eventNames := func(trace []byte) []string {
+		lines := strings.Split(strings.TrimSpace(string(trace)), "\n")
+		m := make(map[string]interface{})
+		ExpectWithOffset(1, json.Unmarshal([]byte(lines[0]), &m)).To(Succeed())
+		ExpectWithOffset(1, m).To(HaveKey("trace"))
+		var names []string
+		for _, line := range lines[1:] {
+			ev := make(map[string]interface{})
+			ExpectWithOffset(1, json.Unmarshal([]byte(line), &ev)).To(Succeed())
+			names = append(names, ev["name"].(string))
+		}
+		return names
+	}
+
+	It("keeps the most recent events of every connection", func() {
+		t1 := tracer.TracerForConnection(context.Background(), logging.PerspectiveServer, logging.ConnectionID{2, 2})
+		t2 := tracer.TracerForConnection(context.Background(), logging.PerspectiveClient, logging.ConnectionID{1, 1})
+		t1.UpdatedPTOCount(1)
+		t1.LossTimerExpired(logging.TimerTypePTO, logging.Encryption1RTT)
+		t1.LossTimerCanceled()
+		t1.UpdatedKeyFromTLS(logging.EncryptionHandshake, logging.PerspectiveClient)
+		t2.UpdatedPTOCount(1)
+		Eventually(func() int {
+			snapshots := tracer.Snapshot()
+			if len(snapshots) != 2 {
+				return 0
+			}
+			return bytes.Count(snapshots[0].Trace, []byte{'\n'}) + bytes.Count(snapshots[1].Trace, []byte{'\n'})
+		}).Should(Equal(4 + 2))
+		snapshots := tracer.Snapshot()
+		Expect(snapshots[0].ODCID).To(Equal(logging.ConnectionID{1, 1}))
+		Expect(snapshots[0].Perspective).To(Equal(logging.PerspectiveClient))
+		Expect(eventNames(snapshots[0].Trace)).To(Equal([]string{"recovery:metrics_updated"}))
+		Expect(snapshots[1].ODCID).To(Equal(logging.ConnectionID{2, 2}))
+		Expect(snapshots[1].Perspective).To(Equal(logging.PerspectiveServer))
+		Expect(eventNames(snapshots[1].Trace)).To(Equal([]string{
+			"recovery:loss_timer_updated",
+			"recovery:loss_timer_updated",
+			"security:key_updated",
+		}))
+		t1.Close()
+		t2.Close()
+	})
+
+	It("dumps the trace of a connection", func() {
+		t := tracer.TracerForConnection(context.Background(), logging.PerspectiveServer, logging.ConnectionID{0xde, 0xad})
+		t.UpdatedPTOCount(1)
+		buf := &bytes.Buffer{}
+		Eventually(func() []string {
+			buf.Reset()
+			Expect(tracer.Dump(buf, logging.ConnectionID{0xde, 0xad})).To(Succeed())
+			if buf.Len() == 0 {
+				return nil
+			}
+			return eventNames(buf.Bytes())
+		}).Should(Equal([]string{"recovery:metrics_updated"}))
+		Expect(tracer.Dump(&bytes.Buffer{}, logging.ConnectionID{0xbe, 0xef})).To(MatchError("qlog: unknown connection"))
+		t.Close()
+	})
+
+	It("discards the history when the connection is closed", func() {
+		t := tracer.TracerForConnection(context.Background(), logging.PerspectiveServer, logging.ConnectionID{0xde, 0xad})
+		Expect(tracer.Snapshot()).To(HaveLen(1))
+		t.Close()
+		Expect(tracer.Snapshot()).To(BeEmpty())
+		Expect(tracer.Dump(&bytes.Buffer{}, logging.ConnectionID{0xde, 0xad})).To(MatchError("qlog: unknown connection"))
+	})
+})
@@ -0,0 +1,202 @@
+package qlog
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/logging"
+)
+
+// RotationConfig configures the rotation of qlog files.
+type RotationConfig struct {
+	// MaxSize is the size (in bytes) after which a new segment is started.
+	// If not set, segments are not rotated by size.
+	MaxSize int64
+	// MaxAge is the time after which a new segment is started.
+	// If not set, segments are not rotated by time.
+	MaxAge time.Duration
+	// Compress gzips every segment once it is complete.
+	Compress bool
+}
+
+// NewRotatingFileSink writes the qlog of every connection to a series of files in dir.
+// The segments are named <odcid>_<vantage point>.<n>.qlog (with a .gz suffix when they are compressed).
+// A new segment is started when the current segment exceeds the size or the age configured in config.
+// Segments are only rotated between events, and every segment starts with the header of the trace,
+// such that every segment can be processed as a qlog of its own.
+func NewRotatingFileSink(dir string, config RotationConfig) Sink {
+	return func(p logging.Perspective, connectionID []byte) io.WriteCloser {
+		prefix := filepath.Join(dir, fmt.Sprintf("%x_%s", connectionID, strings.ToLower(p.String())))
+		w := &rotatingFileWriter{
+			config: config,
+			path:   func(segment int) string { return fmt.Sprintf("%s.%d.qlog", prefix, segment) },
+		}
+		if err := w.openSegment(); err != nil {
+			log.Printf("creating qlog file failed: %s\n", err)
+			return nil
+		}
+		return w
+	}
+}
+
+type rotatingFileWriter struct {
+	config RotationConfig
+	path   func(segment int) string
+
+	header         []byte // the first line
+	headerComplete bool
+
+	segment      int
+	file         *os.File // nil after a segment was completed, until the next line is written
+	buf          *bufio.Writer
+	segmentSize  int64
+	segmentStart time.Time
+
+	compressions sync.WaitGroup
+	mutex        sync.Mutex
+	compressErr  error
+}
+
+var _ io.WriteCloser = &rotatingFileWriter{}
+
+func (w *rotatingFileWriter) Write(b []byte) (int, error) {
+	var n int
+	for len(b) > 0 {
+		line := b
+		i := bytes.IndexByte(b, '\n')
+		if i != -1 {
+			line = b[:i+1]
+		}
+		if w.file == nil {
+			w.segment++
+			if err := w.openSegment(); err != nil {
+				return n, err
+			}
+		}
+		if _, err := w.buf.Write(line); err != nil {
+			return n, err
+		}
+		w.segmentSize += int64(len(line))
+		if !w.headerComplete {
+			w.header = append(w.header, line...)
+			w.headerComplete = i != -1
+		}
+		n += len(line)
+		b = b[len(line):]
+		if i != -1 && w.rotationDue() {
+			if err := w.completeSegment(); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+func (w *rotatingFileWriter) openSegment() error {
+	f, err := os.Create(w.path(w.segment))
+	if err != nil {
+		return err
+	}
+	w.file = f
+	w.buf = bufio.NewWriter(f)
+	w.segmentStart = time.Now()
+	w.segmentSize = 0
+	if w.headerComplete {
+		if _, err := w.buf.Write(w.header); err != nil {
+			return err
+		}
+		w.segmentSize = int64(len(w.header))
+	}
+	return nil
+}
+
+// rotationDue says if the current segment is complete.
+// A segment contains at least one event.
+func (w *rotatingFileWriter) rotationDue() bool {
+	if !w.headerComplete || w.segmentSize <= int64(len(w.header)) {
+		return false
+	}
+	return (w.config.MaxSize > 0 && w.segmentSize >= w.config.MaxSize) ||
+		(w.config.MaxAge > 0 && time.Since(w.segmentStart) >= w.config.MaxAge)
+}
+
+func (w *rotatingFileWriter) completeSegment() error {
+	if err := w.buf.Flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+	if w.config.Compress {
+		path := w.path(w.segment)
+		w.compressions.Add(1)
+		go func() {
+			defer w.compressions.Done()
+			if err := compressFile(path); err != nil {
+				w.mutex.Lock()
+				if w.compressErr == nil {
+					w.compressErr = err
+				}
+				w.mutex.Unlock()
+			}
+		}()
+	}
+	return nil
+}
+
+// Close completes the current segment, and waits until all segments are compressed.
+func (w *rotatingFileWriter) Close() error {
+	var err error
+	if w.file != nil {
+		err = w.completeSegment()
+	}
+	w.compressions.Wait()
+	if err != nil {
+		return err
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.compressErr
+}
+
+// compressFile gzips a file, and removes the uncompressed file.
+func compressFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	err = writeGzipFile(path+".gz", in)
+	in.Close()
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func writeGzipFile(path string, r io.Reader) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, r); err != nil {
+		out.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
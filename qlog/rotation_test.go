@@ -0,0 +1,108 @@
+package qlog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rotating file sink", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "qlog")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	readFile := func(name string) string {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		Expect(err).ToNot(HaveOccurred())
+		return string(data)
+	}
+
+	listFiles := func() []string {
+		files, err := ioutil.ReadDir(dir)
+		Expect(err).ToNot(HaveOccurred())
+		var names []string
+		for _, f := range files {
+			names = append(names, f.Name())
+		}
+		return names
+	}
+
+	It("writes a single file if the trace is not rotated", func() {
+		w := NewRotatingFileSink(dir, RotationConfig{})(logging.PerspectiveServer, []byte{0xde, 0xad})
+		Expect(w).ToNot(BeNil())
+		_, err := w.Write([]byte("header\nline 1\n"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(w.Close()).To(Succeed())
+		Expect(listFiles()).To(Equal([]string{"dead_server.0.qlog"}))
+		Expect(readFile("dead_server.0.qlog")).To(Equal("header\nline 1\n"))
+	})
+
+	It("rotates by size, and starts every segment with the header", func() {
+		w := NewRotatingFileSink(dir, RotationConfig{MaxSize: 20})(logging.PerspectiveClient, []byte{0xde, 0xad})
+		w.Write([]byte("head"))
+		w.Write([]byte("er\nline 1\n"))
+		w.Write([]byte("line 2\nline 3\nli"))
+		w.Write([]byte("ne 4\n"))
+		Expect(w.Close()).To(Succeed())
+		Expect(listFiles()).To(Equal([]string{"dead_client.0.qlog", "dead_client.1.qlog"}))
+		Expect(readFile("dead_client.0.qlog")).To(Equal("header\nline 1\nline 2\n"))
+		Expect(readFile("dead_client.1.qlog")).To(Equal("header\nline 3\nline 4\n"))
+	})
+
+	It("rotates by time", func() {
+		w := NewRotatingFileSink(dir, RotationConfig{MaxAge: scaleDuration(20 * time.Millisecond)})(logging.PerspectiveClient, []byte{0xde, 0xad})
+		w.Write([]byte("header\nline 1\n"))
+		time.Sleep(scaleDuration(25 * time.Millisecond))
+		w.Write([]byte("line 2\n"))
+		w.Write([]byte("line 3\n"))
+		Expect(w.Close()).To(Succeed())
+		Expect(listFiles()).To(Equal([]string{"dead_client.0.qlog", "dead_client.1.qlog"}))
+		Expect(readFile("dead_client.0.qlog")).To(Equal("header\nline 1\nline 2\n"))
+		Expect(readFile("dead_client.1.qlog")).To(Equal("header\nline 3\n"))
+	})
+
+	It("compresses completed segments", func() {
+		w := NewRotatingFileSink(dir, RotationConfig{MaxSize: 10, Compress: true})(logging.PerspectiveServer, []byte{0xde, 0xad})
+		w.Write([]byte("header\nline 1\nline 2\n"))
+		Expect(w.Close()).To(Succeed())
+		Expect(listFiles()).To(Equal([]string{"dead_server.0.qlog.gz", "dead_server.1.qlog.gz"}))
+		for name, expected := range map[string]string{
+			"dead_server.0.qlog.gz": "header\nline 1\n",
+			"dead_server.1.qlog.gz": "header\nline 2\n",
+		} {
+			f, err := os.Open(filepath.Join(dir, name))
+			Expect(err).ToNot(HaveOccurred())
+			r, err := gzip.NewReader(f)
+			Expect(err).ToNot(HaveOccurred())
+			data, err := ioutil.ReadAll(r)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal(expected))
+			f.Close()
+		}
+	})
+
+	It("returns nil if the file can't be created", func() {
+		b := &bytes.Buffer{}
+		log.SetOutput(b)
+		defer log.SetOutput(os.Stdout)
+		Expect(NewRotatingFileSink(filepath.Join(dir, "foo", "bar"), RotationConfig{})(logging.PerspectiveServer, []byte{0xde, 0xad})).To(BeNil())
+		Expect(b.String()).To(ContainSubstring("creating qlog file failed"))
+	})
+})
@@ -0,0 +1,107 @@
+package qlog
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/logging"
+)
+
+// A StreamScope selects the streams for which stream-level frames are recorded.
+// Stream-level frames are STREAM, RESET_STREAM, STOP_SENDING, MAX_STREAM_DATA and STREAM_DATA_BLOCKED frames.
+// All other events (e.g. packets, ACKs, congestion and recovery events) are recorded for the whole connection.
+// On busy multiplexed connections, this keeps the size of the qlog manageable.
+type StreamScope struct {
+	streamIDs map[logging.StreamID]struct{}
+	paths     map[string]struct{}
+
+	mutex    sync.Mutex
+	selected map[uint64]map[logging.StreamID]struct{} // indexed by the session tracing ID
+}
+
+// NewStreamScope creates a new StreamScope.
+// The streams with the given stream IDs are traced on every connection.
+// Requests for one of the given HTTP request paths are traced when selected using SelectRequest.
+func NewStreamScope(streamIDs []logging.StreamID, paths []string) *StreamScope {
+	s := &StreamScope{
+		streamIDs: make(map[logging.StreamID]struct{}, len(streamIDs)),
+		paths:     make(map[string]struct{}, len(paths)),
+		selected:  make(map[uint64]map[logging.StreamID]struct{}),
+	}
+	for _, id := range streamIDs {
+		s.streamIDs[id] = struct{}{}
+	}
+	for _, p := range paths {
+		s.paths[p] = struct{}{}
+	}
+	return s
+}
+
+// SelectStream enables tracing of a stream.
+// The context is the context of the session (see quic.Session.Context).
+// Frames that were sent or received on the stream before it was selected are not recorded.
+func (s *StreamScope) SelectStream(sessCtx context.Context, id logging.StreamID) {
+	tracingID, ok := sessCtx.Value(quic.SessionTracingKey).(uint64)
+	if !ok {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	streams, ok := s.selected[tracingID]
+	if !ok {
+		return
+	}
+	streams[id] = struct{}{}
+}
+
+// SelectRequest enables tracing of the stream of an HTTP request, if the request path is one of the paths of the StreamScope.
+// It can be used as the TraceRequest callback of an http3.Server.
+func (s *StreamScope) SelectRequest(sessCtx context.Context, id logging.StreamID, r *http.Request) {
+	if _, ok := s.paths[r.URL.Path]; ok {
+		s.SelectStream(sessCtx, id)
+	}
+}
+
+func (s *StreamScope) traces(tracingID uint64, id logging.StreamID) bool {
+	if _, ok := s.streamIDs[id]; ok {
+		return true
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, ok := s.selected[tracingID][id]
+	return ok
+}
+
+func (s *StreamScope) addConnection(tracingID uint64) {
+	s.mutex.Lock()
+	s.selected[tracingID] = make(map[logging.StreamID]struct{})
+	s.mutex.Unlock()
+}
+
+func (s *StreamScope) removeConnection(tracingID uint64) {
+	s.mutex.Lock()
+	delete(s.selected, tracingID)
+	s.mutex.Unlock()
+}
+
+// streamIDOfFrame returns the stream ID of a stream-level frame.
+func streamIDOfFrame(f logging.Frame) (logging.StreamID, bool) {
+	switch f := f.(type) {
+	case *logging.StreamFrame:
+		return f.StreamID, true
+	case *logging.ResetStreamFrame:
+		return f.StreamID, true
+	case *logging.StopSendingFrame:
+		return f.StreamID, true
+	case *logging.MaxStreamDataFrame:
+		return f.StreamID, true
+	case *logging.StreamDataBlockedFrame:
+		return f.StreamID, true
+	default:
+		return 0, false
+	}
+}
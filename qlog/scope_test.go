@@ -0,0 +1,124 @@
+package qlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+	"github.com/lucas-clemente/quic-go/logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stream Scope", func() {
+	var (
+		scope  *StreamScope
+		buf    *bytes.Buffer
+		tracer logging.ConnectionTracer
+	)
+
+	sessCtx := context.WithValue(context.Background(), quic.SessionTracingKey, uint64(1337))
+
+	BeforeEach(func() {
+		scope = NewStreamScope([]logging.StreamID{4}, []string{"/traced"})
+		buf = &bytes.Buffer{}
+		t := NewScopedTracer(func(logging.Perspective, []byte) io.WriteCloser { return nopWriteCloser(buf) }, scope)
+		tracer = t.TracerForConnection(sessCtx, logging.PerspectiveServer, logging.ConnectionID{0xde, 0xad, 0xbe, 0xef})
+	})
+
+	// sendPacket sends a packet containing a STREAM frame for every stream, as well as a PING frame.
+	sendPacket := func(streamIDs ...logging.StreamID) {
+		frames := []logging.Frame{&logging.PingFrame{}}
+		for _, id := range streamIDs {
+			frames = append(frames, &logging.StreamFrame{StreamID: id, Length: 100})
+		}
+		tracer.SentPacket(
+			&wire.ExtendedHeader{Header: wire.Header{DestConnectionID: protocol.ConnectionID{1, 2, 3, 4}}, PacketNumber: 1},
+			1234,
+			nil,
+			frames,
+		)
+	}
+
+	// exportFrames returns the frames of every packet_sent event
+	exportFrames := func() [][]map[string]interface{} {
+		tracer.Close()
+		lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte{'\n'})
+		var packets [][]map[string]interface{}
+		for _, line := range lines[1:] {
+			var ev struct {
+				Name string `json:"name"`
+				Data struct {
+					Frames []map[string]interface{} `json:"frames"`
+				} `json:"data"`
+			}
+			Expect(json.Unmarshal(line, &ev)).To(Succeed())
+			if ev.Name == "transport:packet_sent" {
+				packets = append(packets, ev.Data.Frames)
+			}
+		}
+		return packets
+	}
+
+	streamIDs := func(frames []map[string]interface{}) []float64 {
+		var ids []float64
+		for _, f := range frames {
+			if f["frame_type"] == "stream" {
+				ids = append(ids, f["stream_id"].(float64))
+			}
+		}
+		return ids
+	}
+
+	It("only records stream frames for the configured streams", func() {
+		sendPacket(0, 4, 8)
+		packets := exportFrames()
+		Expect(packets).To(HaveLen(1))
+		Expect(packets[0]).To(HaveLen(2))
+		Expect(packets[0][0]).To(HaveKeyWithValue("frame_type", "ping"))
+		Expect(streamIDs(packets[0])).To(Equal([]float64{4}))
+	})
+
+	It("records packets that only contain frames of other streams", func() {
+		sendPacket(8)
+		packets := exportFrames()
+		Expect(packets).To(HaveLen(1))
+		Expect(packets[0]).To(HaveLen(1))
+	})
+
+	It("records stream frames for selected streams", func() {
+		sendPacket(8)
+		scope.SelectStream(sessCtx, 8)
+		sendPacket(8)
+		scope.SelectStream(context.WithValue(context.Background(), quic.SessionTracingKey, uint64(42)), 12)
+		sendPacket(12)
+		packets := exportFrames()
+		Expect(packets).To(HaveLen(3))
+		Expect(streamIDs(packets[0])).To(BeEmpty())
+		Expect(streamIDs(packets[1])).To(Equal([]float64{8}))
+		Expect(streamIDs(packets[2])).To(BeEmpty())
+	})
+
+	It("selects requests by their path", func() {
+		scope.SelectRequest(sessCtx, 8, httptest.NewRequest(http.MethodGet, "https://example.com/traced", nil))
+		scope.SelectRequest(sessCtx, 12, httptest.NewRequest(http.MethodGet, "https://example.com/other", nil))
+		sendPacket(8, 12)
+		packets := exportFrames()
+		Expect(packets).To(HaveLen(1))
+		Expect(streamIDs(packets[0])).To(Equal([]float64{8}))
+	})
+
+	It("forgets the selected streams when the connection is closed", func() {
+		scope.SelectStream(sessCtx, 8)
+		Expect(scope.selected).To(HaveKey(uint64(1337)))
+		tracer.Close()
+		Expect(scope.selected).ToNot(HaveKey(uint64(1337)))
+	})
+})
@@ -0,0 +1,269 @@
+package qlog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/logging"
+)
+
+// A Sink returns the writer that the qlog of a connection is written to.
+// It may return nil, in which case no qlog is recorded for this connection.
+type Sink func(p logging.Perspective, connectionID []byte) io.WriteCloser
+
+// errorRecorder is implemented by writers that need to know if the connection was closed with an error.
+type errorRecorder interface {
+	closedWithError(error)
+}
+
+// isCleanClose says if a connection was closed without an error,
+// i.e. with an application error code 0, or with a NO_ERROR transport error.
+func isCleanClose(e error) bool {
+	var applicationErr *quic.ApplicationError
+	if errors.As(e, &applicationErr) {
+		return applicationErr.ErrorCode == 0
+	}
+	var transportErr *quic.TransportError
+	if errors.As(e, &transportErr) {
+		return transportErr.ErrorCode == quic.NoError
+	}
+	return false
+}
+
+// NewRingBufferSink keeps the most recent events of every connection in memory, using roughly size bytes per connection.
+// The header of the trace is always retained.
+// When a connection is closed with an error, the retained events are written to the writer returned by dump.
+// Nothing is written for connections that are closed without an error.
+// This allows recording qlogs in environments where writing a qlog for every connection is too expensive,
+// without losing the traces of the connections that failed.
+func NewRingBufferSink(size int, dump Sink) Sink {
+	return func(p logging.Perspective, connectionID []byte) io.WriteCloser {
+		return &ringBufferWriter{
+			size: size,
+			dump: func() io.WriteCloser { return dump(p, connectionID) },
+		}
+	}
+}
+
+type ringBufferWriter struct {
+	size     int // the maximum number of bytes retained (not counting the header), 0 means no limit
+	maxLines int // the maximum number of lines retained (not counting the header), 0 means no limit
+	dump     func() io.WriteCloser
+
+	mutex    sync.Mutex
+	header   []byte   // the first line
+	lines    [][]byte // the most recent complete lines
+	numBytes int      // the number of bytes in lines
+	current  []byte   // the line that is currently being written
+	closeErr error    // the error that the connection was closed with
+}
+
+var (
+	_ io.WriteCloser = &ringBufferWriter{}
+	_ errorRecorder  = &ringBufferWriter{}
+)
+
+func (w *ringBufferWriter) Write(b []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	n := len(b)
+	for len(b) > 0 {
+		i := bytes.IndexByte(b, '\n')
+		if i == -1 {
+			w.current = append(w.current, b...)
+			break
+		}
+		w.current = append(w.current, b[:i+1]...)
+		b = b[i+1:]
+		w.addLine(w.current)
+		w.current = nil
+	}
+	return n, nil
+}
+
+func (w *ringBufferWriter) addLine(line []byte) {
+	if w.header == nil {
+		w.header = line
+		return
+	}
+	w.lines = append(w.lines, line)
+	w.numBytes += len(line)
+	// always retain the most recent line
+	for len(w.lines) > 1 && ((w.size > 0 && w.numBytes > w.size) || (w.maxLines > 0 && len(w.lines) > w.maxLines)) {
+		w.numBytes -= len(w.lines[0])
+		w.lines[0] = nil
+		w.lines = w.lines[1:]
+	}
+}
+
+// snapshot returns the header and the retained complete lines.
+func (w *ringBufferWriter) snapshot() []byte {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	b := make([]byte, 0, len(w.header)+w.numBytes)
+	b = append(b, w.header...)
+	for _, line := range w.lines {
+		b = append(b, line...)
+	}
+	return b
+}
+
+func (w *ringBufferWriter) closedWithError(e error) {
+	w.mutex.Lock()
+	w.closeErr = e
+	w.mutex.Unlock()
+}
+
+func (w *ringBufferWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closeErr == nil || isCleanClose(w.closeErr) {
+		return nil
+	}
+	out := w.dump()
+	if out == nil {
+		return nil
+	}
+	for _, line := range append(append([][]byte{w.header}, w.lines...), w.current) {
+		if _, err := out.Write(line); err != nil {
+			out.Close()
+			return err
+		}
+	}
+	return out.Close()
+}
+
+// NewHTTPSink streams the qlog of every connection to a collector, using one POST request per connection.
+// The vantage point and the original destination connection ID are sent as the query parameters
+// "vantage_point" and "odcid".
+// To upload the qlogs over QUIC, use a client with an http3.RoundTripper.
+// Events are buffered in memory while they are uploaded, such that a slow collector doesn't block the connection.
+func NewHTTPSink(client *http.Client, collectorURL string) (Sink, error) {
+	u, err := url.Parse(collectorURL)
+	if err != nil {
+		return nil, err
+	}
+	return func(p logging.Perspective, connectionID []byte) io.WriteCloser {
+		reqURL := *u
+		query := reqURL.Query()
+		query.Set("vantage_point", strings.ToLower(p.String()))
+		query.Set("odcid", fmt.Sprintf("%x", connectionID))
+		reqURL.RawQuery = query.Encode()
+		return newHTTPUploadWriter(client, reqURL.String())
+	}, nil
+}
+
+// The httpUploadWriter is a pipe with an unlimited buffer.
+// The buffered data is read by the HTTP client as the request body.
+type httpUploadWriter struct {
+	mutex  sync.Mutex
+	cond   sync.Cond
+	buf    bytes.Buffer
+	closed bool  // set when Close is called
+	err    error // set when the upload failed
+
+	done chan struct{}
+}
+
+var _ io.WriteCloser = &httpUploadWriter{}
+
+func newHTTPUploadWriter(client *http.Client, url string) *httpUploadWriter {
+	w := &httpUploadWriter{done: make(chan struct{})}
+	w.cond.L = &w.mutex
+	go w.upload(client, url)
+	return w
+}
+
+func (w *httpUploadWriter) upload(client *http.Client, url string) {
+	defer close(w.done)
+	err := w.doUpload(client, url)
+	w.mutex.Lock()
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	w.cond.Broadcast()
+	w.mutex.Unlock()
+}
+
+func (w *httpUploadWriter) doUpload(client *http.Client, url string) error {
+	req, err := http.NewRequest(http.MethodPost, url, (*httpUploadBody)(w))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rsp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode < 200 || rsp.StatusCode >= 300 {
+		return fmt.Errorf("uploading qlog failed: %s", rsp.Status)
+	}
+	return nil
+}
+
+func (w *httpUploadWriter) Write(b []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.err != nil {
+		return 0, w.err
+	}
+	w.buf.Write(b)
+	w.cond.Signal()
+	return len(b), nil
+}
+
+// Close waits until the upload has completed.
+func (w *httpUploadWriter) Close() error {
+	w.mutex.Lock()
+	w.closed = true
+	w.cond.Broadcast()
+	w.mutex.Unlock()
+
+	<-w.done
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.err
+}
+
+// httpUploadBody is the request body of the upload.
+type httpUploadBody httpUploadWriter
+
+func (b *httpUploadBody) Read(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for b.buf.Len() == 0 && !b.closed && b.err == nil {
+		b.cond.Wait()
+	}
+	if b.buf.Len() > 0 {
+		return b.buf.Read(p)
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return 0, io.EOF
+}
+
+// Close is called by the HTTP client when it stops reading the request body.
+func (b *httpUploadBody) Close() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.err == nil && !b.closed {
+		b.err = io.ErrClosedPipe
+	}
+	b.cond.Broadcast()
+	return nil
+}
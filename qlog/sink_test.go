@@ -0,0 +1,170 @@
+package qlog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/internal/qerr"
+	"github.com/lucas-clemente/quic-go/logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sinks", func() {
+	Context("ring buffer", func() {
+		var (
+			dumped *bytes.Buffer
+			sink   Sink
+		)
+
+		BeforeEach(func() {
+			dumped = nil
+			sink = NewRingBufferSink(20, func(logging.Perspective, []byte) io.WriteCloser {
+				dumped = &bytes.Buffer{}
+				return nopWriteCloser(dumped)
+			})
+		})
+
+		It("retains the header and the most recent lines", func() {
+			w := sink(logging.PerspectiveClient, []byte{0xde, 0xad})
+			_, err := w.Write([]byte("header\nfoo"))
+			Expect(err).ToNot(HaveOccurred())
+			w.Write([]byte("bar\n"))
+			w.Write([]byte("line 1\nline 2\n"))
+			w.Write([]byte("line 3\n"))
+			w.Write([]byte("partial"))
+			w.(errorRecorder).closedWithError(&quic.IdleTimeoutError{})
+			Expect(w.Close()).To(Succeed())
+			Expect(dumped.String()).To(Equal("header\nline 2\nline 3\npartial"))
+		})
+
+		It("always retains the most recent line", func() {
+			w := sink(logging.PerspectiveClient, []byte{0xde, 0xad})
+			w.Write([]byte("header\n"))
+			w.Write([]byte("a line that is longer than the buffer\n"))
+			w.(errorRecorder).closedWithError(&quic.IdleTimeoutError{})
+			Expect(w.Close()).To(Succeed())
+			Expect(dumped.String()).To(Equal("header\na line that is longer than the buffer\n"))
+		})
+
+		It("doesn't dump the trace if the connection was closed without an error", func() {
+			for _, e := range []error{
+				nil,
+				&quic.ApplicationError{ErrorCode: 0},
+				&quic.TransportError{ErrorCode: quic.NoError},
+			} {
+				w := sink(logging.PerspectiveClient, []byte{0xde, 0xad})
+				w.Write([]byte("header\nline\n"))
+				if e != nil {
+					w.(errorRecorder).closedWithError(e)
+				}
+				Expect(w.Close()).To(Succeed())
+				Expect(dumped).To(BeNil())
+			}
+		})
+
+		It("dumps the trace if the connection was closed with an error", func() {
+			for _, e := range []error{
+				&quic.ApplicationError{ErrorCode: 42},
+				&quic.TransportError{ErrorCode: quic.ProtocolViolation},
+				&quic.HandshakeTimeoutError{},
+			} {
+				dumped = nil
+				w := sink(logging.PerspectiveClient, []byte{0xde, 0xad})
+				w.Write([]byte("header\nline\n"))
+				w.(errorRecorder).closedWithError(e)
+				Expect(w.Close()).To(Succeed())
+				Expect(dumped.String()).To(Equal("header\nline\n"))
+			}
+		})
+
+		It("is notified by the connection tracer", func() {
+			t := NewTracer(NewRingBufferSink(1<<10, func(logging.Perspective, []byte) io.WriteCloser {
+				dumped = &bytes.Buffer{}
+				return nopWriteCloser(dumped)
+			}))
+			tracer := t.TracerForConnection(context.Background(), logging.PerspectiveServer, logging.ConnectionID{0xde, 0xad, 0xbe, 0xef})
+			tracer.ClosedConnection(&qerr.TransportError{ErrorCode: qerr.InternalError})
+			tracer.Close()
+			Expect(dumped).ToNot(BeNil())
+			lines := bytes.Split(bytes.TrimSpace(dumped.Bytes()), []byte{'\n'})
+			Expect(lines).To(HaveLen(2))
+			Expect(string(lines[1])).To(ContainSubstring("connection_closed"))
+		})
+	})
+
+	Context("HTTP upload", func() {
+		type upload struct {
+			query string
+			body  []byte
+		}
+
+		var (
+			server  *httptest.Server
+			uploads chan upload
+		)
+
+		BeforeEach(func() {
+			uploads = make(chan upload, 10)
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				Expect(r.Method).To(Equal(http.MethodPost))
+				body, err := ioutil.ReadAll(r.Body)
+				Expect(err).ToNot(HaveOccurred())
+				if r.URL.Query().Get("fail") != "" {
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+				uploads <- upload{query: r.URL.RawQuery, body: body}
+			}))
+		})
+
+		AfterEach(func() { server.Close() })
+
+		It("uploads the qlog", func() {
+			sink, err := NewHTTPSink(server.Client(), server.URL+"/qlog")
+			Expect(err).ToNot(HaveOccurred())
+			w := sink(logging.PerspectiveServer, []byte{0xde, 0xad, 0xbe, 0xef})
+			_, err = w.Write([]byte("header\n"))
+			Expect(err).ToNot(HaveOccurred())
+			// the data is streamed to the collector before the writer is closed
+			time.Sleep(10 * time.Millisecond)
+			_, err = w.Write([]byte("event\n"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(w.Close()).To(Succeed())
+			var u upload
+			Eventually(uploads).Should(Receive(&u))
+			Expect(u.query).To(Equal("odcid=deadbeef&vantage_point=server"))
+			Expect(u.body).To(Equal([]byte("header\nevent\n")))
+		})
+
+		It("returns the error if the upload fails", func() {
+			sink, err := NewHTTPSink(server.Client(), server.URL+"/qlog?fail=1")
+			Expect(err).ToNot(HaveOccurred())
+			w := sink(logging.PerspectiveClient, []byte{0xde, 0xad, 0xbe, 0xef})
+			w.Write([]byte("header\n"))
+			Expect(w.Close()).To(MatchError("uploading qlog failed: 500 Internal Server Error"))
+			Eventually(uploads).Should(Receive())
+		})
+
+		It("returns the error if the collector is not reachable", func() {
+			sink, err := NewHTTPSink(server.Client(), "http://localhost:0/qlog")
+			Expect(err).ToNot(HaveOccurred())
+			w := sink(logging.PerspectiveClient, []byte{0xde, 0xad, 0xbe, 0xef})
+			Expect(w.Close()).ToNot(Succeed())
+			_, err = w.Write([]byte("header\n"))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("rejects invalid URLs", func() {
+			_, err := NewHTTPSink(http.DefaultClient, "http://[::1")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
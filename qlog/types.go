@@ -289,6 +289,36 @@ func (r packetDropReason) String() string {
 	}
 }
 
+type datagramDropReason logging.DatagramDropReason
+
+func (r datagramDropReason) String() string {
+	switch logging.DatagramDropReason(r) {
+	case logging.DatagramDropReceiveQueueFull:
+		return "receive_queue_full"
+	case logging.DatagramDropTooLarge:
+		return "too_large"
+	case logging.DatagramDropConnectionClosed:
+		return "connection_closed"
+	default:
+		return "unknown datagram drop reason"
+	}
+}
+
+type zeroRTTRejectReason logging.ZeroRTTRejectReason
+
+func (r zeroRTTRejectReason) String() string {
+	switch logging.ZeroRTTRejectReason(r) {
+	case logging.ZeroRTTRejectedByServer:
+		return "rejected_by_server"
+	case logging.ZeroRTTRejectedInvalidTicket:
+		return "invalid_session_ticket"
+	case logging.ZeroRTTRejectedTransportParameters:
+		return "transport_parameters_changed"
+	default:
+		return "unknown 0-RTT reject reason"
+	}
+}
+
 type timerType logging.TimerType
 
 func (t timerType) String() string {
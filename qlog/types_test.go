@@ -40,6 +40,18 @@ var _ = Describe("Types", func() {
 		Expect(packetType(logging.PacketTypeNotDetermined).String()).To(BeEmpty())
 	})
 
+	It("has a string representation for the 0-RTT reject reason", func() {
+		Expect(zeroRTTRejectReason(logging.ZeroRTTRejectedByServer).String()).To(Equal("rejected_by_server"))
+		Expect(zeroRTTRejectReason(logging.ZeroRTTRejectedInvalidTicket).String()).To(Equal("invalid_session_ticket"))
+		Expect(zeroRTTRejectReason(logging.ZeroRTTRejectedTransportParameters).String()).To(Equal("transport_parameters_changed"))
+	})
+
+	It("has a string representation for the datagram drop reason", func() {
+		Expect(datagramDropReason(logging.DatagramDropReceiveQueueFull).String()).To(Equal("receive_queue_full"))
+		Expect(datagramDropReason(logging.DatagramDropTooLarge).String()).To(Equal("too_large"))
+		Expect(datagramDropReason(logging.DatagramDropConnectionClosed).String()).To(Equal("connection_closed"))
+	})
+
 	It("has a string representation for the packet drop reason", func() {
 		Expect(packetDropReason(logging.PacketDropKeyUnavailable).String()).To(Equal("key_unavailable"))
 		Expect(packetDropReason(logging.PacketDropUnknownConnectionID).String()).To(Equal("unknown_connection_id"))
@@ -0,0 +1,75 @@
+package quic
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+)
+
+// The read rate limiter allows bursts of the data it admits during this time.
+const readRateLimiterBurstDuration = 20 * time.Millisecond
+
+// The readRateLimiter limits the rate at which the application reads data from the receive streams of a session.
+// It is a token bucket shared by all receive streams.
+// Since the flow control windows are only increased when data is read,
+// this emulates a receiver that consumes data slowly, without having to change the application.
+type readRateLimiter struct {
+	rate uint64 // in bytes/s, 0 if the rate is not limited. Accessed atomically.
+
+	mutex      sync.Mutex
+	tokens     float64
+	lastUpdate time.Time
+}
+
+func newReadRateLimiter(bytesPerSecond uint64) *readRateLimiter {
+	return &readRateLimiter{rate: bytesPerSecond}
+}
+
+// SetRate sets the rate (in bytes/s). 0 removes the limit.
+func (l *readRateLimiter) SetRate(bytesPerSecond uint64) {
+	atomic.StoreUint64(&l.rate, bytesPerSecond)
+}
+
+// Take takes up to n bytes from the bucket, and returns the number of bytes that may be read.
+// If no bytes may be read, it returns the time when enough tokens will have accumulated.
+func (l *readRateLimiter) Take(now time.Time, n int) (int, time.Time) {
+	rate := atomic.LoadUint64(&l.rate)
+	if rate == 0 || n == 0 {
+		return n, time.Time{}
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	maxTokens := float64(rate) * readRateLimiterBurstDuration.Seconds()
+	if maxTokens < float64(protocol.MaxPacketBufferSize) {
+		maxTokens = float64(protocol.MaxPacketBufferSize)
+	}
+	if l.lastUpdate.IsZero() {
+		l.tokens = maxTokens
+	} else if now.After(l.lastUpdate) {
+		l.tokens += now.Sub(l.lastUpdate).Seconds() * float64(rate)
+		if l.tokens > maxTokens {
+			l.tokens = maxTokens
+		}
+	}
+	l.lastUpdate = now
+
+	// Wait until a full packet (or the requested amount of data, if smaller) can be read,
+	// instead of waking up the reader for every single byte.
+	minTokens := n
+	if minTokens > int(protocol.MaxPacketBufferSize) {
+		minTokens = int(protocol.MaxPacketBufferSize)
+	}
+	if l.tokens < float64(minTokens) {
+		missing := float64(minTokens) - l.tokens
+		return 0, now.Add(time.Duration(missing / float64(rate) * float64(time.Second)))
+	}
+	if float64(n) > l.tokens {
+		n = int(l.tokens)
+	}
+	l.tokens -= float64(n)
+	return n, time.Time{}
+}
@@ -0,0 +1,76 @@
+package quic
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Read Rate Limiter", func() {
+	const maxPacket = int(protocol.MaxPacketBufferSize)
+
+	It("doesn't limit the rate if no rate is set", func() {
+		l := newReadRateLimiter(0)
+		n, next := l.Take(time.Now(), 1<<30)
+		Expect(n).To(Equal(1 << 30))
+		Expect(next).To(BeZero())
+	})
+
+	It("allows a burst of one packet at low rates", func() {
+		l := newReadRateLimiter(1000)
+		now := time.Now()
+		n, next := l.Take(now, 1<<20)
+		Expect(n).To(Equal(maxPacket))
+		Expect(next).To(BeZero())
+		n, next = l.Take(now, 1<<20)
+		Expect(n).To(BeZero())
+		// it takes 1.452s to accumulate the tokens for another packet
+		Expect(next).To(BeTemporally("~", now.Add(1452*time.Millisecond), time.Millisecond))
+	})
+
+	It("allows a burst of 20ms at high rates", func() {
+		l := newReadRateLimiter(1 << 30)
+		n, _ := l.Take(time.Now(), 1<<30)
+		Expect(n).To(BeNumerically("~", (1<<30)/50, 1))
+	})
+
+	It("accumulates tokens over time", func() {
+		l := newReadRateLimiter(100000) // 100 kB/s
+		now := time.Now()
+		n, _ := l.Take(now, 1<<20)
+		Expect(n).To(Equal(2000)) // 20ms
+		now = now.Add(10 * time.Millisecond)
+		n, next := l.Take(now, 1000)
+		Expect(n).To(Equal(1000))
+		Expect(next).To(BeZero())
+		// the tokens are capped at the burst size
+		now = now.Add(time.Second)
+		n, _ = l.Take(now, 1<<20)
+		Expect(n).To(Equal(2000))
+	})
+
+	It("waits until the requested amount of data can be read, if it's smaller than a packet", func() {
+		l := newReadRateLimiter(1000)
+		now := time.Now()
+		l.Take(now, maxPacket)
+		_, next := l.Take(now, 100)
+		Expect(next).To(BeTemporally("~", now.Add(100*time.Millisecond), time.Millisecond))
+		n, _ := l.Take(next, 100)
+		Expect(n).To(Equal(100))
+	})
+
+	It("changes the rate", func() {
+		l := newReadRateLimiter(1000)
+		now := time.Now()
+		l.Take(now, 1<<20)
+		n, _ := l.Take(now, 1<<20)
+		Expect(n).To(BeZero())
+		l.SetRate(0)
+		n, next := l.Take(now, 1<<20)
+		Expect(n).To(Equal(1 << 20))
+		Expect(next).To(BeZero())
+	})
+})
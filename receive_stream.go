@@ -22,6 +22,12 @@ type receiveStreamI interface {
 	getWindowUpdate() protocol.ByteCount
 }
 
+// A streamFrameQueue reassembles the data received in STREAM frames.
+type streamFrameQueue interface {
+	Push(data []byte, offset protocol.ByteCount, doneCb func()) error
+	Pop() (protocol.ByteCount, []byte, func())
+}
+
 type receiveStream struct {
 	mutex sync.Mutex
 
@@ -29,7 +35,7 @@ type receiveStream struct {
 
 	sender streamSender
 
-	frameQueue  *frameSorter
+	frameQueue  streamFrameQueue
 	finalOffset protocol.ByteCount
 
 	currentFrame       []byte
@@ -50,6 +56,7 @@ type receiveStream struct {
 	deadline time.Time
 
 	flowController flowcontrol.StreamFlowController
+	readLimiter    *readRateLimiter // may be nil
 	version        protocol.VersionNumber
 }
 
@@ -62,15 +69,24 @@ func newReceiveStream(
 	streamID protocol.StreamID,
 	sender streamSender,
 	flowController flowcontrol.StreamFlowController,
+	receiveBufferSize protocol.ByteCount,
+	readLimiter *readRateLimiter,
 	version protocol.VersionNumber,
 ) *receiveStream {
+	var frameQueue streamFrameQueue
+	if receiveBufferSize > 0 {
+		frameQueue = newStreamReceiveBuffer(receiveBufferSize)
+	} else {
+		frameQueue = newFrameSorter()
+	}
 	return &receiveStream{
 		streamID:       streamID,
 		sender:         sender,
 		flowController: flowController,
-		frameQueue:     newFrameSorter(),
+		frameQueue:     frameQueue,
 		readChan:       make(chan struct{}, 1),
 		finalOffset:    protocol.MaxByteCount,
+		readLimiter:    readLimiter,
 		version:        version,
 	}
 }
@@ -166,9 +182,22 @@ func (s *receiveStream) readImpl(p []byte) (bool /*stream completed */, int, err
 			return false, bytesRead, fmt.Errorf("BUG: readPosInFrame (%d) > frame.DataLen (%d) in stream.Read", s.readPosInFrame, len(s.currentFrame))
 		}
 
+		toRead := p[bytesRead:]
+		if s.readLimiter != nil {
+			allowed, next := s.readLimiter.Take(time.Now(), utils.Min(len(toRead), len(s.currentFrame)-s.readPosInFrame))
+			if allowed == 0 && !next.IsZero() {
+				if bytesRead > 0 {
+					return false, bytesRead, nil
+				}
+				s.waitForReadLimiter(next, deadlineTimer)
+				continue
+			}
+			toRead = toRead[:allowed]
+		}
+
 		s.mutex.Unlock()
 
-		m := copy(p[bytesRead:], s.currentFrame[s.readPosInFrame:])
+		m := copy(toRead, s.currentFrame[s.readPosInFrame:])
 		s.readPosInFrame += m
 		bytesRead += m
 
@@ -186,6 +215,27 @@ func (s *receiveStream) readImpl(p []byte) (bool /*stream completed */, int, err
 	return false, bytesRead, nil
 }
 
+// waitForReadLimiter waits until the read rate limiter allows reading more data.
+// It returns early when the state of the stream changes, or when the deadline expires.
+// It must be called with the mutex held.
+func (s *receiveStream) waitForReadLimiter(next time.Time, deadlineTimer *utils.Timer) {
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
+	var deadlineChan <-chan time.Time
+	if deadlineTimer != nil {
+		deadlineChan = deadlineTimer.Chan()
+	}
+
+	s.mutex.Unlock()
+	select {
+	case <-timer.C:
+	case <-s.readChan:
+	case <-deadlineChan:
+		deadlineTimer.SetRead()
+	}
+	s.mutex.Lock()
+}
+
 func (s *receiveStream) dequeueNextFrame() {
 	var offset protocol.ByteCount
 	// We're done with the last frame. Release the buffer.
@@ -209,7 +259,9 @@ func (s *receiveStream) CancelRead(errorCode StreamErrorCode) {
 }
 
 func (s *receiveStream) cancelReadImpl(errorCode qerr.StreamErrorCode) bool /* completed */ {
-	if s.finRead || s.canceledRead || s.resetRemotely {
+	// After closeForShutdown, the stream ID might already be reused (after 0-RTT rejection).
+	// No STOP_SENDING frame must be sent for it.
+	if s.finRead || s.canceledRead || s.resetRemotely || s.closedForShutdown {
 		return false
 	}
 	s.canceledRead = true
@@ -29,7 +29,7 @@ var _ = Describe("Receive Stream", func() {
 	BeforeEach(func() {
 		mockSender = NewMockStreamSender(mockCtrl)
 		mockFC = mocks.NewMockStreamFlowController(mockCtrl)
-		str = newReceiveStream(streamID, mockSender, mockFC, protocol.VersionWhatever)
+		str = newReceiveStream(streamID, mockSender, mockFC, 0, nil, protocol.VersionWhatever)
 
 		timeout := scaleDuration(250 * time.Millisecond)
 		strWithTimeout = gbytes.TimeoutReader(str, timeout)
@@ -216,6 +216,91 @@ var _ = Describe("Receive Stream", func() {
 			Expect(b).To(Equal([]byte("foobar")))
 		})
 
+		It("reads from a contiguous receive buffer", func() {
+			str = newReceiveStream(streamID, mockSender, mockFC, 8, nil, protocol.VersionWhatever)
+			mockFC.EXPECT().UpdateHighestReceived(protocol.ByteCount(6), false)
+			mockFC.EXPECT().UpdateHighestReceived(protocol.ByteCount(3), false)
+			mockFC.EXPECT().UpdateHighestReceived(protocol.ByteCount(10), true)
+			mockFC.EXPECT().AddBytesRead(protocol.ByteCount(6))
+			mockFC.EXPECT().AddBytesRead(protocol.ByteCount(2))
+			mockFC.EXPECT().AddBytesRead(protocol.ByteCount(2))
+			Expect(str.handleStreamFrame(&wire.StreamFrame{Offset: 3, Data: []byte("bar")})).To(Succeed())
+			Expect(str.handleStreamFrame(&wire.StreamFrame{Data: []byte("foo")})).To(Succeed())
+			b := make([]byte, 10)
+			n, err := str.Read(b)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(b[:n]).To(Equal([]byte("foobar")))
+			// the data wraps around at the end of the buffer
+			Expect(str.handleStreamFrame(&wire.StreamFrame{Offset: 6, Data: []byte("baz!"), Fin: true})).To(Succeed())
+			mockSender.EXPECT().onStreamCompleted(streamID)
+			n, err = str.Read(b)
+			Expect(err).To(MatchError(io.EOF))
+			Expect(b[:n]).To(Equal([]byte("baz!")))
+		})
+
+		Context("read rate limiting", func() {
+			It("limits the amount of data read", func() {
+				limiter := newReadRateLimiter(10000) // 10 kB/s
+				str = newReceiveStream(streamID, mockSender, mockFC, 0, limiter, protocol.VersionWhatever)
+				data := make([]byte, 3*protocol.MaxPacketBufferSize)
+				mockFC.EXPECT().UpdateHighestReceived(protocol.ByteCount(len(data)), false)
+				mockFC.EXPECT().AddBytesRead(gomock.Any()).AnyTimes()
+				Expect(str.handleStreamFrame(&wire.StreamFrame{Data: data})).To(Succeed())
+				b := make([]byte, len(data))
+				// the first read consumes the initial burst
+				n, err := str.Read(b)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(n).To(BeEquivalentTo(protocol.MaxPacketBufferSize))
+				// the next read blocks until another packet can be read
+				start := time.Now()
+				n, err = str.Read(b)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(n).To(BeNumerically(">=", protocol.MaxPacketBufferSize))
+				Expect(time.Since(start)).To(BeNumerically("~", 145*time.Millisecond, scaleDuration(50*time.Millisecond)))
+			})
+
+			It("respects the deadline while waiting for the read rate limiter", func() {
+				limiter := newReadRateLimiter(1000) // 1 kB/s
+				str = newReceiveStream(streamID, mockSender, mockFC, 0, limiter, protocol.VersionWhatever)
+				data := make([]byte, 2*protocol.MaxPacketBufferSize)
+				mockFC.EXPECT().UpdateHighestReceived(protocol.ByteCount(len(data)), false)
+				mockFC.EXPECT().AddBytesRead(gomock.Any()).AnyTimes()
+				Expect(str.handleStreamFrame(&wire.StreamFrame{Data: data})).To(Succeed())
+				b := make([]byte, len(data))
+				_, err := str.Read(b)
+				Expect(err).ToNot(HaveOccurred())
+				deadline := time.Now().Add(scaleDuration(50 * time.Millisecond))
+				str.SetReadDeadline(deadline)
+				n, err := str.Read(b)
+				Expect(err).To(MatchError(errDeadline))
+				Expect(n).To(BeZero())
+				Expect(time.Now()).To(BeTemporally("~", deadline, scaleDuration(20*time.Millisecond)))
+			})
+
+			It("unblocks when reading is canceled while waiting for the read rate limiter", func() {
+				limiter := newReadRateLimiter(1000) // 1 kB/s
+				str = newReceiveStream(streamID, mockSender, mockFC, 0, limiter, protocol.VersionWhatever)
+				data := make([]byte, 2*protocol.MaxPacketBufferSize)
+				mockFC.EXPECT().UpdateHighestReceived(protocol.ByteCount(len(data)), false)
+				mockFC.EXPECT().AddBytesRead(gomock.Any()).AnyTimes()
+				Expect(str.handleStreamFrame(&wire.StreamFrame{Data: data})).To(Succeed())
+				b := make([]byte, len(data))
+				_, err := str.Read(b)
+				Expect(err).ToNot(HaveOccurred())
+				done := make(chan struct{})
+				go func() {
+					defer GinkgoRecover()
+					_, err := str.Read(b)
+					Expect(err).To(MatchError("Read on stream 1337 canceled with error code 1234"))
+					close(done)
+				}()
+				Consistently(done).ShouldNot(BeClosed())
+				mockSender.EXPECT().queueControlFrame(gomock.Any())
+				str.CancelRead(1234)
+				Eventually(done).Should(BeClosed())
+			})
+		})
+
 		Context("deadlines", func() {
 			It("the deadline error has the right net.Error properties", func() {
 				Expect(errDeadline.Temporary()).To(BeTrue())
@@ -443,6 +528,14 @@ var _ = Describe("Receive Stream", func() {
 				Expect(n).To(BeZero())
 				Expect(err).To(MatchError(testErr))
 			})
+
+			It("doesn't queue a STOP_SENDING frame when canceled afterwards", func() {
+				str.closeForShutdown(testErr)
+				str.CancelRead(1234) // no call to queueControlFrame EXPECTed
+				n, err := strWithTimeout.Read(make([]byte, 1))
+				Expect(n).To(BeZero())
+				Expect(err).To(MatchError(testErr))
+			})
 		})
 	})
 
@@ -0,0 +1,91 @@
+package quic
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+)
+
+// ClientHelloInfo contains information about a new connection, taken from the ClientHello.
+type ClientHelloInfo struct {
+	// ServerName is the server name sent by the client (SNI).
+	// It is empty if the client didn't send a server name.
+	ServerName string
+	RemoteAddr net.Addr
+}
+
+// A Route is the configuration of a connection.
+type Route struct {
+	// TLSConfig is used for the TLS handshake.
+	// If nil, the tls.Config of the listener is used.
+	TLSConfig *tls.Config
+	// Config is used for the connection.
+	// If nil, the Config of the listener is used.
+	// Options that apply to the listener (see Config.GetRoute) are always taken from the Config of the listener.
+	Config *Config
+}
+
+// A ServerNameRouter selects the Route of a connection based on the server name sent by the client.
+// Its GetRoute method can be used as Config.GetRoute, e.g. to terminate connections for multiple tenants on a single listener.
+type ServerNameRouter struct {
+	mutex  sync.RWMutex
+	routes map[string]*Route
+}
+
+// NewServerNameRouter creates a new ServerNameRouter.
+func NewServerNameRouter() *ServerNameRouter {
+	return &ServerNameRouter{routes: make(map[string]*Route)}
+}
+
+// Add adds a route for a server name.
+// A server name starting with "*." is a wildcard, which matches all server names with one additional label,
+// e.g. "*.example.com" matches "foo.example.com", but neither "example.com" nor "foo.bar.example.com".
+// Routes for server names without a wildcard take precedence.
+func (r *ServerNameRouter) Add(serverName string, route *Route) error {
+	if serverName == "" || route == nil {
+		return errors.New("quic: invalid route")
+	}
+	if route.Config != nil {
+		if err := validateConfig(route.Config); err != nil {
+			return err
+		}
+	}
+	r.mutex.Lock()
+	r.routes[strings.ToLower(serverName)] = route
+	r.mutex.Unlock()
+	return nil
+}
+
+// Remove removes the route for a server name.
+func (r *ServerNameRouter) Remove(serverName string) {
+	r.mutex.Lock()
+	delete(r.routes, strings.ToLower(serverName))
+	r.mutex.Unlock()
+}
+
+// Lookup returns the route for a server name.
+// It returns nil if there's no matching route.
+func (r *ServerNameRouter) Lookup(serverName string) *Route {
+	if serverName == "" {
+		return nil
+	}
+	serverName = strings.ToLower(serverName)
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if route, ok := r.routes[serverName]; ok {
+		return route
+	}
+	if i := strings.IndexByte(serverName, '.'); i > 0 {
+		return r.routes["*"+serverName[i:]]
+	}
+	return nil
+}
+
+// GetRoute returns the route for the server name of a new connection.
+// If there's no matching route, it returns nil, and the connection uses the configuration of the listener.
+func (r *ServerNameRouter) GetRoute(info *ClientHelloInfo) *Route {
+	return r.Lookup(info.ServerName)
+}
@@ -0,0 +1,246 @@
+package quic
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/handshake"
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/internal/wire"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Server Name Router", func() {
+	var router *ServerNameRouter
+
+	BeforeEach(func() {
+		router = NewServerNameRouter()
+	})
+
+	It("finds routes", func() {
+		route := &Route{}
+		Expect(router.Add("example.com", route)).To(Succeed())
+		Expect(router.Lookup("example.com")).To(BeIdenticalTo(route))
+		Expect(router.Lookup("foo.example.com")).To(BeNil())
+		Expect(router.Lookup("")).To(BeNil())
+	})
+
+	It("ignores the case of server names", func() {
+		route := &Route{}
+		Expect(router.Add("Example.com", route)).To(Succeed())
+		Expect(router.Lookup("EXAMPLE.COM")).To(BeIdenticalTo(route))
+	})
+
+	It("finds routes for wildcards", func() {
+		wildcard := &Route{}
+		exact := &Route{}
+		Expect(router.Add("*.example.com", wildcard)).To(Succeed())
+		Expect(router.Add("bar.example.com", exact)).To(Succeed())
+		Expect(router.Lookup("foo.example.com")).To(BeIdenticalTo(wildcard))
+		Expect(router.Lookup("bar.example.com")).To(BeIdenticalTo(exact))
+		Expect(router.Lookup("example.com")).To(BeNil())
+		Expect(router.Lookup("foo.bar.example.com")).To(BeNil())
+	})
+
+	It("removes routes", func() {
+		Expect(router.Add("example.com", &Route{})).To(Succeed())
+		router.Remove("EXAMPLE.com")
+		Expect(router.Lookup("example.com")).To(BeNil())
+	})
+
+	It("uses the server name of the ClientHello", func() {
+		route := &Route{}
+		Expect(router.Add("example.com", route)).To(Succeed())
+		Expect(router.GetRoute(&ClientHelloInfo{ServerName: "example.com"})).To(BeIdenticalTo(route))
+		Expect(router.GetRoute(&ClientHelloInfo{})).To(BeNil())
+	})
+
+	It("rejects invalid routes", func() {
+		Expect(router.Add("", &Route{})).To(MatchError("quic: invalid route"))
+		Expect(router.Add("example.com", nil)).To(MatchError("quic: invalid route"))
+		Expect(router.Add("example.com", &Route{Config: &Config{MaxIncomingStreams: 1 << 61}})).To(HaveOccurred())
+	})
+
+	Context("routing connections", func() {
+		var (
+			serv    *baseServer
+			infos   []*ClientHelloInfo
+			route   *Route
+			tlsConf *tls.Config
+		)
+
+		// getClientHello returns a ClientHello generated by crypto/tls.
+		getClientHello := func(serverName string) []byte {
+			c, s := net.Pipe()
+			defer s.Close()
+			go func() {
+				tls.Client(c, &tls.Config{ServerName: serverName, MinVersion: tls.VersionTLS13}).Handshake()
+				c.Close()
+			}()
+			recordHdr := make([]byte, 5)
+			_, err := io.ReadFull(s, recordHdr)
+			Expect(err).ToNot(HaveOccurred())
+			clientHello := make([]byte, binary.BigEndian.Uint16(recordHdr[3:]))
+			_, err = io.ReadFull(s, clientHello)
+			Expect(err).ToNot(HaveOccurred())
+			return clientHello
+		}
+
+		getInitial := func(connID protocol.ConnectionID, frame *wire.CryptoFrame) *receivedPacket {
+			hdr := &wire.ExtendedHeader{
+				Header: wire.Header{
+					IsLongHeader:     true,
+					Type:             protocol.PacketTypeInitial,
+					SrcConnectionID:  protocol.ConnectionID{5, 4, 3, 2, 1},
+					DestConnectionID: connID,
+					Version:          protocol.VersionTLS,
+				},
+				PacketNumber:    0x42,
+				PacketNumberLen: protocol.PacketNumberLen4,
+			}
+			payload := &bytes.Buffer{}
+			Expect(frame.Write(payload, protocol.VersionTLS)).To(Succeed())
+			hdr.Length = protocol.ByteCount(payload.Len()) + 4 + 16
+			buffer := getPacketBuffer()
+			buf := bytes.NewBuffer(buffer.Data)
+			Expect(hdr.Write(buf, protocol.VersionTLS)).To(Succeed())
+			n := buf.Len()
+			buf.Write(payload.Bytes())
+			data := buffer.Data[:buf.Len()]
+			sealer, _ := handshake.NewInitialAEAD(connID, protocol.PerspectiveClient, protocol.VersionTLS)
+			_ = sealer.Seal(data[n:n], data[n:], 0x42, data[:n])
+			data = data[:len(data)+16]
+			sealer.EncryptHeader(data[n:n+16], &data[0], data[n-4:n])
+			return &receivedPacket{
+				remoteAddr: &net.UDPAddr{IP: net.IPv4(1, 2, 3, 4), Port: 42},
+				rcvTime:    time.Now(),
+				data:       data,
+				buffer:     buffer,
+			}
+		}
+
+		parseHeader := func(p *receivedPacket) *wire.Header {
+			hdr, _, _, err := wire.ParsePacket(p.data, 0)
+			Expect(err).ToNot(HaveOccurred())
+			return hdr
+		}
+
+		BeforeEach(func() {
+			infos = nil
+			route = &Route{}
+			tlsConf = &tls.Config{}
+			serv = &baseServer{
+				tlsConf: tlsConf,
+				config: populateServerConfig(&Config{
+					GetRoute: func(info *ClientHelloInfo) *Route {
+						infos = append(infos, info)
+						return route
+					},
+				}),
+				logger: utils.DefaultLogger,
+			}
+		})
+
+		It("routes a ClientHello contained in a single packet", func() {
+			connID := protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8}
+			p := getInitial(connID, &wire.CryptoFrame{Data: getClientHello("foo.example.com")})
+			data := append([]byte{}, p.data...)
+			r, queued, ok := serv.routeConnection(p, parseHeader(p))
+			Expect(ok).To(BeTrue())
+			Expect(r).To(BeIdenticalTo(route))
+			Expect(queued).To(BeEmpty())
+			Expect(infos).To(HaveLen(1))
+			Expect(infos[0].ServerName).To(Equal("foo.example.com"))
+			Expect(infos[0].RemoteAddr).To(Equal(p.remoteAddr))
+			// the packet is not modified
+			Expect(p.data).To(Equal(data))
+		})
+
+		It("reassembles a ClientHello that spans multiple packets", func() {
+			connID := protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8}
+			clientHello := getClientHello("foo.example.com")
+			p1 := getInitial(connID, &wire.CryptoFrame{Data: clientHello[:100]})
+			p2 := getInitial(connID, &wire.CryptoFrame{Offset: 100, Data: clientHello[100:]})
+			_, _, ok := serv.routeConnection(p1, parseHeader(p1))
+			Expect(ok).To(BeFalse())
+			Expect(infos).To(BeEmpty())
+			Expect(serv.clientHellos).To(HaveLen(1))
+			r, queued, ok := serv.routeConnection(p2, parseHeader(p2))
+			Expect(ok).To(BeTrue())
+			Expect(r).To(BeIdenticalTo(route))
+			Expect(queued).To(Equal([]*receivedPacket{p1}))
+			Expect(infos).To(HaveLen(1))
+			Expect(infos[0].ServerName).To(Equal("foo.example.com"))
+			Expect(serv.clientHellos).To(BeEmpty())
+		})
+
+		It("limits the number of ClientHellos that are reassembled", func() {
+			for i := 0; i < protocol.MaxPendingClientHellos; i++ {
+				p := getInitial(protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, byte(i)}, &wire.CryptoFrame{Data: []byte{1}})
+				_, _, ok := serv.routeConnection(p, parseHeader(p))
+				Expect(ok).To(BeFalse())
+			}
+			Expect(serv.clientHellos).To(HaveLen(protocol.MaxPendingClientHellos))
+			// a ClientHello that fits into a single packet is still routed
+			p := getInitial(protocol.ConnectionID{8, 7, 6, 5, 4, 3, 2, 1}, &wire.CryptoFrame{Data: getClientHello("foo.example.com")})
+			r, _, ok := serv.routeConnection(p, parseHeader(p))
+			Expect(ok).To(BeTrue())
+			Expect(r).To(BeIdenticalTo(route))
+			Expect(infos).To(HaveLen(1))
+			// a ClientHello that would have to be reassembled is dropped
+			clientHello := getClientHello("bar.example.com")
+			p = getInitial(protocol.ConnectionID{8, 7, 6, 5, 4, 3, 2, 2}, &wire.CryptoFrame{Data: clientHello[:100]})
+			_, _, ok = serv.routeConnection(p, parseHeader(p))
+			Expect(ok).To(BeFalse())
+			Expect(serv.clientHellos).To(HaveLen(protocol.MaxPendingClientHellos))
+			Expect(serv.clientHellos).ToNot(HaveKey(string(protocol.ConnectionID{8, 7, 6, 5, 4, 3, 2, 2})))
+			// once the timeout expired, the pending ClientHellos are dropped
+			p = getInitial(protocol.ConnectionID{8, 7, 6, 5, 4, 3, 2, 2}, &wire.CryptoFrame{Data: clientHello[:100]})
+			p.rcvTime = time.Now().Add(protocol.ClientHelloReassemblyTimeout)
+			_, _, ok = serv.routeConnection(p, parseHeader(p))
+			Expect(ok).To(BeFalse())
+			Expect(serv.clientHellos).To(HaveLen(1))
+			Expect(serv.clientHellos).To(HaveKey(string(protocol.ConnectionID{8, 7, 6, 5, 4, 3, 2, 2})))
+		})
+
+		It("drops packets that can't be decrypted", func() {
+			p := getInitial(protocol.ConnectionID{1, 2, 3, 4, 5, 6, 7, 8}, &wire.CryptoFrame{Data: getClientHello("foo.example.com")})
+			p.data[len(p.data)-1] ^= 0xff
+			_, _, ok := serv.routeConnection(p, parseHeader(p))
+			Expect(ok).To(BeFalse())
+			Expect(infos).To(BeEmpty())
+		})
+
+		It("uses the Config of the listener for listener-level options", func() {
+			serv.config.StatelessResetKey = []byte("foobar")
+			routeTLSConf := &tls.Config{}
+			routeTLSConfig, config, err := serv.routeConfig(&Route{
+				TLSConfig: routeTLSConf,
+				Config: &Config{
+					MaxIdleTimeout:    42 * time.Second,
+					StatelessResetKey: []byte("raboof"),
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(routeTLSConfig).To(BeIdenticalTo(routeTLSConf))
+			Expect(config.MaxIdleTimeout).To(Equal(42 * time.Second))
+			Expect(config.HandshakeIdleTimeout).To(Equal(protocol.DefaultHandshakeIdleTimeout))
+			Expect(config.StatelessResetKey).To(Equal([]byte("foobar")))
+			Expect(config.Versions).To(Equal(serv.config.Versions))
+		})
+
+		It("uses the configuration of the listener if the route doesn't set it", func() {
+			routeTLSConfig, config, err := serv.routeConfig(&Route{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(routeTLSConfig).To(BeIdenticalTo(tlsConf))
+			Expect(config).To(BeIdenticalTo(serv.config))
+		})
+	})
+})
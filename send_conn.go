@@ -2,11 +2,17 @@ package quic
 
 import (
 	"net"
+	"time"
 )
 
 // A sendConn allows sending using a simple Write() on a non-connected packet conn.
 type sendConn interface {
 	Write([]byte) error
+	// EnableTxTime enables sending with WriteAt. It returns false if the conn doesn't support transmit times.
+	EnableTxTime() bool
+	// WriteAt hands a packet to the kernel, which sends it at txTime.
+	// If transmit times are not enabled, the packet is sent immediately.
+	WriteAt(b []byte, txTime time.Time) error
 	Close() error
 	LocalAddr() net.Addr
 	RemoteAddr() net.Addr
@@ -36,6 +42,20 @@ func (c *sconn) Write(p []byte) error {
 	return err
 }
 
+func (c *sconn) EnableTxTime() bool {
+	tc, ok := c.connection.(txTimeConn)
+	return ok && tc.EnableTxTime()
+}
+
+func (c *sconn) WriteAt(p []byte, txTime time.Time) error {
+	tc, ok := c.connection.(txTimeConn)
+	if !ok {
+		return c.Write(p)
+	}
+	_, err := tc.WritePacketAt(p, c.remoteAddr, c.oob, txTime)
+	return err
+}
+
 func (c *sconn) RemoteAddr() net.Addr {
 	return c.remoteAddr
 }
@@ -69,6 +89,12 @@ func (c *spconn) Write(p []byte) error {
 	return err
 }
 
+func (c *spconn) EnableTxTime() bool { return false }
+
+func (c *spconn) WriteAt(p []byte, _ time.Time) error {
+	return c.Write(p)
+}
+
 func (c *spconn) RemoteAddr() net.Addr {
 	return c.remoteAddr
 }
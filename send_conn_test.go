@@ -2,6 +2,7 @@ package quic
 
 import (
 	"net"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -25,6 +26,12 @@ var _ = Describe("Connection (for sending packets)", func() {
 		Expect(c.Write([]byte("foobar"))).To(Succeed())
 	})
 
+	It("doesn't support transmit times", func() {
+		Expect(c.EnableTxTime()).To(BeFalse())
+		packetConn.EXPECT().WriteTo([]byte("foobar"), addr)
+		Expect(c.WriteAt([]byte("foobar"), time.Now().Add(time.Hour))).To(Succeed())
+	})
+
 	It("gets the remote address", func() {
 		Expect(c.RemoteAddr().String()).To(Equal("192.168.100.200:1337"))
 	})
@@ -1,19 +1,39 @@
 package quic
 
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
 type sender interface {
 	Send(p *packetBuffer)
+	// SendAt sends out a packet with a transmit time, see sendConn.WriteAt.
+	SendAt(p *packetBuffer, txTime time.Time)
 	Run() error
 	WouldBlock() bool
 	Available() <-chan struct{}
 	Close()
 }
 
+type queuedPacket struct {
+	buffer *packetBuffer
+	txTime time.Time
+}
+
 type sendQueue struct {
-	queue       chan *packetBuffer
+	queue       chan queuedPacket
 	closeCalled chan struct{} // runStopped when Close() is called
 	runStopped  chan struct{} // runStopped when the run loop returns
 	available   chan struct{}
 	conn        sendConn
+
+	// only set when emulating the latency of a network
+	getDelay     func(time.Time) time.Duration
+	delayed      []queuedPacket // the txTime is the time when the packet is sent out
+	delayedTimer *utils.Timer
+	// only set when emulating the packet loss of a network
+	dropPacket func(time.Time) bool
 }
 
 var _ sender = &sendQueue{}
@@ -26,16 +46,35 @@ func newSendQueue(conn sendConn) sender {
 		runStopped:  make(chan struct{}),
 		closeCalled: make(chan struct{}),
 		available:   make(chan struct{}, 1),
-		queue:       make(chan *packetBuffer, sendQueueCapacity),
+		queue:       make(chan queuedPacket, sendQueueCapacity),
+	}
+}
+
+// newEmulatingSendQueue creates a send queue that emulates the latency and the packet loss of a network.
+// It holds back every packet by the delay returned by getDelay, and drops the packets for which dropPacket returns true.
+// Either function may be nil.
+// Delayed packets don't occupy space in the queue, and are sent out in the order they were queued.
+func newEmulatingSendQueue(conn sendConn, getDelay func(now time.Time) time.Duration, dropPacket func(now time.Time) bool) sender {
+	q := newSendQueue(conn).(*sendQueue)
+	q.dropPacket = dropPacket
+	if getDelay != nil {
+		q.getDelay = getDelay
+		q.delayedTimer = utils.NewTimer()
 	}
+	return q
 }
 
 // Send sends out a packet. It's guaranteed to not block.
 // Callers need to make sure that there's actually space in the send queue by calling WouldBlock.
 // Otherwise Send will panic.
 func (h *sendQueue) Send(p *packetBuffer) {
+	h.SendAt(p, time.Time{})
+}
+
+// SendAt is like Send, but the packet is held back by the kernel until txTime.
+func (h *sendQueue) SendAt(p *packetBuffer, txTime time.Time) {
 	select {
-	case h.queue <- p:
+	case h.queue <- queuedPacket{buffer: p, txTime: txTime}:
 	case <-h.runStopped:
 	default:
 		panic("sendQueue.Send would have blocked")
@@ -52,21 +91,37 @@ func (h *sendQueue) Available() <-chan struct{} {
 
 func (h *sendQueue) Run() error {
 	defer close(h.runStopped)
+	if h.delayedTimer != nil {
+		defer h.delayedTimer.Stop()
+	}
 	var shouldClose bool
+	var delayedTimerChan <-chan time.Time
+	if h.delayedTimer != nil {
+		delayedTimerChan = h.delayedTimer.Chan()
+	}
 	for {
 		if shouldClose && len(h.queue) == 0 {
-			return nil
+			// don't wait for the delay of the packets that are still held back
+			return h.sendDelayed(time.Time{})
 		}
 		select {
 		case <-h.closeCalled:
 			h.closeCalled = nil // prevent this case from being selected again
 			// make sure that all queued packets are actually sent out
 			shouldClose = true
+		case <-delayedTimerChan:
+			h.delayedTimer.SetRead()
+			if err := h.sendDelayed(time.Now()); err != nil {
+				return err
+			}
 		case p := <-h.queue:
-			if err := h.conn.Write(p.Data); err != nil {
+			if h.dropPacket != nil && h.dropPacket(time.Now()) {
+				p.buffer.Release()
+			} else if h.getDelay != nil {
+				h.delay(p)
+			} else if err := h.send(p); err != nil {
 				return err
 			}
-			p.Release()
 			select {
 			case h.available <- struct{}{}:
 			default:
@@ -75,6 +130,56 @@ func (h *sendQueue) Run() error {
 	}
 }
 
+func (h *sendQueue) send(p queuedPacket) error {
+	var err error
+	if p.txTime.IsZero() {
+		err = h.conn.Write(p.buffer.Data)
+	} else {
+		err = h.conn.WriteAt(p.buffer.Data, p.txTime)
+	}
+	if err != nil {
+		return err
+	}
+	p.buffer.Release()
+	return nil
+}
+
+func (h *sendQueue) delay(p queuedPacket) {
+	now := time.Now()
+	sendTime := now
+	if p.txTime.After(now) {
+		sendTime = p.txTime
+	}
+	sendTime = sendTime.Add(h.getDelay(now))
+	// Packets are never reordered, even if the delay decreases.
+	if l := len(h.delayed); l > 0 && sendTime.Before(h.delayed[l-1].txTime) {
+		sendTime = h.delayed[l-1].txTime
+	}
+	h.delayed = append(h.delayed, queuedPacket{buffer: p.buffer, txTime: sendTime})
+	if len(h.delayed) == 1 {
+		h.delayedTimer.Reset(sendTime)
+	}
+}
+
+// sendDelayed sends all delayed packets that are due at now.
+// If now is the zero value, all delayed packets are sent.
+func (h *sendQueue) sendDelayed(now time.Time) error {
+	for len(h.delayed) > 0 {
+		p := h.delayed[0]
+		if !now.IsZero() && p.txTime.After(now) {
+			h.delayedTimer.Reset(p.txTime)
+			return nil
+		}
+		h.delayed[0] = queuedPacket{}
+		h.delayed = h.delayed[1:]
+		if err := h.conn.Write(p.buffer.Data); err != nil {
+			return err
+		}
+		p.buffer.Release()
+	}
+	return nil
+}
+
 func (h *sendQueue) Close() {
 	close(h.closeCalled)
 	// wait until the run loop returned
@@ -2,6 +2,7 @@ package quic
 
 import (
 	"errors"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
@@ -42,6 +43,24 @@ var _ = Describe("Send Queue", func() {
 		Eventually(done).Should(BeClosed())
 	})
 
+	It("sends a packet with a transmit time", func() {
+		txTime := time.Now().Add(time.Millisecond)
+		q.SendAt(getPacket([]byte("foobar")), txTime)
+
+		written := make(chan struct{})
+		c.EXPECT().WriteAt([]byte("foobar"), txTime).Do(func([]byte, time.Time) { close(written) })
+		done := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			q.Run()
+			close(done)
+		}()
+
+		Eventually(written).Should(BeClosed())
+		q.Close()
+		Eventually(done).Should(BeClosed())
+	})
+
 	It("panics when Send() is called although there's no space in the queue", func() {
 		for i := 0; i < sendQueueCapacity; i++ {
 			Expect(q.WouldBlock()).To(BeFalse())
@@ -123,4 +142,95 @@ var _ = Describe("Send Queue", func() {
 		Eventually(done).Should(BeClosed())
 		Eventually(closed).Should(BeClosed())
 	})
+
+	Context("emulating a network", func() {
+		runQueue := func() <-chan struct{} {
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				q.Run()
+				close(done)
+			}()
+			return done
+		}
+
+		It("delays packets", func() {
+			delay := scaleDuration(25 * time.Millisecond)
+			q = newEmulatingSendQueue(c, func(time.Time) time.Duration { return delay }, nil)
+			written := make(chan time.Time, 1)
+			c.EXPECT().Write([]byte("foobar")).Do(func([]byte) { written <- time.Now() })
+			done := runQueue()
+			start := time.Now()
+			q.Send(getPacket([]byte("foobar")))
+			var writeTime time.Time
+			Eventually(written).Should(Receive(&writeTime))
+			Expect(writeTime.Sub(start)).To(BeNumerically(">=", delay))
+			q.Close()
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("delays packets with a transmit time", func() {
+			delay := scaleDuration(10 * time.Millisecond)
+			q = newEmulatingSendQueue(c, func(time.Time) time.Duration { return delay }, nil)
+			written := make(chan time.Time, 1)
+			c.EXPECT().Write([]byte("foobar")).Do(func([]byte) { written <- time.Now() })
+			done := runQueue()
+			txTime := time.Now().Add(scaleDuration(20 * time.Millisecond))
+			q.SendAt(getPacket([]byte("foobar")), txTime)
+			var writeTime time.Time
+			Eventually(written).Should(Receive(&writeTime))
+			Expect(writeTime).To(BeTemporally(">=", txTime.Add(delay)))
+			q.Close()
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("doesn't reorder packets when the delay decreases", func() {
+			delays := []time.Duration{scaleDuration(25 * time.Millisecond), 0}
+			q = newEmulatingSendQueue(c, func(time.Time) time.Duration {
+				d := delays[0]
+				delays = delays[1:]
+				return d
+			}, nil)
+			written := make(chan []byte, 2)
+			c.EXPECT().Write(gomock.Any()).Do(func(b []byte) { written <- append([]byte{}, b...) }).Times(2)
+			done := runQueue()
+			q.Send(getPacket([]byte("foo")))
+			q.Send(getPacket([]byte("bar")))
+			Eventually(written).Should(Receive(Equal([]byte("foo"))))
+			Eventually(written).Should(Receive(Equal([]byte("bar"))))
+			q.Close()
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("frees up queue space while packets are delayed", func() {
+			q = newEmulatingSendQueue(c, func(time.Time) time.Duration { return time.Hour }, nil)
+			c.EXPECT().Write(gomock.Any()).Times(sendQueueCapacity)
+			done := runQueue()
+			for i := 0; i < sendQueueCapacity; i++ {
+				q.Send(getPacket([]byte("foobar")))
+			}
+			Eventually(q.WouldBlock).Should(BeFalse())
+			Eventually(q.Available()).Should(Receive())
+			// delayed packets are sent out when the queue is closed
+			q.Close()
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("drops packets", func() {
+			var drop bool
+			q = newEmulatingSendQueue(c, nil, func(time.Time) bool {
+				drop = !drop
+				return drop
+			})
+			written := make(chan []byte, 2)
+			c.EXPECT().Write(gomock.Any()).Do(func(b []byte) { written <- append([]byte{}, b...) })
+			done := runQueue()
+			q.Send(getPacket([]byte("foo")))
+			q.Send(getPacket([]byte("bar")))
+			Eventually(written).Should(Receive(Equal([]byte("bar"))))
+			Consistently(written).ShouldNot(Receive())
+			q.Close()
+			Eventually(done).Should(BeClosed())
+		})
+	})
 })
@@ -54,6 +54,16 @@ type sendStream struct {
 
 	flowController flowcontrol.StreamFlowController
 
+	priority StreamPriority
+
+	// statistics, see Stats
+	creationTime            time.Time
+	completionTime          time.Time
+	bytesRetransmitted      protocol.ByteCount
+	flowControlBlockedSince time.Time // zero if not blocked by flow control
+	flowControlBlocked      time.Duration
+	congestionBlocked       time.Duration
+
 	version protocol.VersionNumber
 }
 
@@ -72,7 +82,9 @@ func newSendStream(
 		streamID:       streamID,
 		sender:         sender,
 		flowController: flowController,
+		priority:       DefaultStreamPriority,
 		writeChan:      make(chan struct{}, 1),
+		creationTime:   time.Now(),
 		version:        version,
 	}
 	s.ctx, s.ctxCancel = context.WithCancel(context.Background())
@@ -154,6 +166,8 @@ func (s *sendStream) Write(p []byte) (int, error) {
 			}
 		}
 
+		waitStart := time.Now()
+		flowControlBlockedBefore := s.flowControlBlockedDuration(waitStart)
 		s.mutex.Unlock()
 		if !notifiedSender {
 			s.sender.onHasStreamData(s.streamID) // must be called without holding the mutex
@@ -173,6 +187,10 @@ func (s *sendStream) Write(p []byte) (int, error) {
 			}
 		}
 		s.mutex.Lock()
+		now := time.Now()
+		if waited := now.Sub(waitStart) - (s.flowControlBlockedDuration(now) - flowControlBlockedBefore); waited > 0 {
+			s.congestionBlocked += waited
+		}
 	}
 
 	if bytesWritten == len(p) {
@@ -242,6 +260,9 @@ func (s *sendStream) popNewOrRetransmittedStreamFrame(maxBytes protocol.ByteCoun
 
 	sendWindow := s.flowController.SendWindowSize()
 	if sendWindow == 0 {
+		if s.flowControlBlockedSince.IsZero() {
+			s.flowControlBlockedSince = time.Now()
+		}
 		if isBlocked, offset := s.flowController.IsNewlyBlocked(); isBlocked {
 			s.sender.queueControlFrame(&wire.StreamDataBlockedFrame{
 				StreamID:          s.streamID,
@@ -251,6 +272,7 @@ func (s *sendStream) popNewOrRetransmittedStreamFrame(maxBytes protocol.ByteCoun
 		}
 		return nil, true
 	}
+	s.maybeUnblockFlowControl()
 
 	f, hasMoreData := s.popNewStreamFrame(maxBytes, sendWindow)
 	if dataLen := f.DataLen(); dataLen > 0 {
@@ -313,9 +335,13 @@ func (s *sendStream) maybeGetRetransmission(maxBytes protocol.ByteCount) (*wire.
 	f := s.retransmissionQueue[0]
 	newFrame, needsSplit := f.MaybeSplitOffFrame(maxBytes, s.version)
 	if needsSplit {
+		if newFrame != nil {
+			s.bytesRetransmitted += newFrame.DataLen()
+		}
 		return newFrame, true
 	}
 	s.retransmissionQueue = s.retransmissionQueue[1:]
+	s.bytesRetransmitted += f.DataLen()
 	return f, len(s.retransmissionQueue) > 0
 }
 
@@ -366,6 +392,9 @@ func (s *sendStream) isNewlyCompleted() bool {
 	completed := (s.finSent || s.canceledWrite) && s.numOutstandingFrames == 0 && len(s.retransmissionQueue) == 0
 	if completed && !s.completed {
 		s.completed = true
+		if !s.canceledWrite {
+			s.completionTime = time.Now()
+		}
 		return true
 	}
 	return false
@@ -414,7 +443,9 @@ func (s *sendStream) CancelWrite(errorCode StreamErrorCode) {
 // must be called after locking the mutex
 func (s *sendStream) cancelWriteImpl(errorCode qerr.StreamErrorCode, writeErr error) {
 	s.mutex.Lock()
-	if s.canceledWrite {
+	// After closeForShutdown, the stream ID might already be reused (after 0-RTT rejection).
+	// No RESET_STREAM frame must be sent for it.
+	if s.canceledWrite || s.closedForShutdown {
 		s.mutex.Unlock()
 		return
 	}
@@ -467,6 +498,59 @@ func (s *sendStream) SetWriteDeadline(t time.Time) error {
 	return nil
 }
 
+func (s *sendStream) Stats() StreamStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stats := StreamStats{
+		BytesSent:          uint64(s.writeOffset),
+		BytesRetransmitted: uint64(s.bytesRetransmitted),
+		FlowControlBlocked: s.flowControlBlockedDuration(time.Now()),
+		CongestionBlocked:  s.congestionBlocked,
+	}
+	if !s.completionTime.IsZero() {
+		stats.CompletionTime = s.completionTime.Sub(s.creationTime)
+	}
+	return stats
+}
+
+func (s *sendStream) SetPriority(p StreamPriority) {
+	if p.Urgency > 7 {
+		p.Urgency = 7
+	}
+	s.mutex.Lock()
+	changed := s.priority != p && !s.completed
+	s.priority = p
+	s.mutex.Unlock()
+	if changed {
+		s.sender.onStreamPriorityChanged(s.streamID, p) // must be called without holding the mutex
+	}
+}
+
+func (s *sendStream) Priority() StreamPriority {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.priority
+}
+
+// flowControlBlockedDuration returns the time the stream has been blocked by flow control, up to now.
+// must be called with the mutex locked
+func (s *sendStream) flowControlBlockedDuration(now time.Time) time.Duration {
+	if s.flowControlBlockedSince.IsZero() {
+		return s.flowControlBlocked
+	}
+	return s.flowControlBlocked + now.Sub(s.flowControlBlockedSince)
+}
+
+// must be called with the mutex locked
+func (s *sendStream) maybeUnblockFlowControl() {
+	if s.flowControlBlockedSince.IsZero() {
+		return
+	}
+	s.flowControlBlocked += time.Since(s.flowControlBlockedSince)
+	s.flowControlBlockedSince = time.Time{}
+}
+
 // CloseForShutdown closes a stream abruptly.
 // It makes Write unblock (and return the error) immediately.
 // The peer will NOT be informed about this: the stream is closed without sending a FIN or RST.
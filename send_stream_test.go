@@ -646,6 +646,14 @@ var _ = Describe("Send Stream", func() {
 				str.closeForShutdown(testErr)
 				Expect(str.Context().Done()).To(BeClosed())
 			})
+
+			It("doesn't queue a RESET_STREAM frame when canceled afterwards", func() {
+				str.closeForShutdown(testErr)
+				str.CancelWrite(1234) // no call to queueControlFrame EXPECTed
+				n, err := strWithTimeout.Write([]byte("foo"))
+				Expect(n).To(BeZero())
+				Expect(err).To(MatchError(testErr))
+			})
 		})
 	})
 
@@ -998,6 +1006,115 @@ var _ = Describe("Send Stream", func() {
 		})
 	})
 
+	Context("statistics", func() {
+		It("counts the bytes sent and retransmitted", func() {
+			mockSender.EXPECT().onHasStreamData(streamID).Times(2)
+			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount)
+			mockFC.EXPECT().AddBytesSent(protocol.ByteCount(6))
+			_, err := strWithTimeout.Write([]byte("foobar"))
+			Expect(err).ToNot(HaveOccurred())
+			frame, _ := str.popStreamFrame(protocol.MaxByteCount)
+			Expect(frame).ToNot(BeNil())
+			Expect(str.Stats().BytesSent).To(BeEquivalentTo(6))
+			Expect(str.Stats().BytesRetransmitted).To(BeZero())
+			frame.OnLost(frame.Frame)
+			frame, _ = str.popStreamFrame(protocol.MaxByteCount)
+			Expect(frame).ToNot(BeNil())
+			Expect(str.Stats().BytesSent).To(BeEquivalentTo(6))
+			Expect(str.Stats().BytesRetransmitted).To(BeEquivalentTo(6))
+		})
+
+		It("measures the time blocked by flow control", func() {
+			mockSender.EXPECT().onHasStreamData(streamID)
+			_, err := strWithTimeout.Write([]byte("foobar"))
+			Expect(err).ToNot(HaveOccurred())
+			mockFC.EXPECT().SendWindowSize().Return(protocol.ByteCount(0))
+			mockFC.EXPECT().IsNewlyBlocked()
+			frame, hasMoreData := str.popStreamFrame(protocol.MaxByteCount)
+			Expect(frame).To(BeNil())
+			Expect(hasMoreData).To(BeTrue())
+			delay := scaleDuration(20 * time.Millisecond)
+			time.Sleep(delay)
+			Expect(str.Stats().FlowControlBlocked).To(BeNumerically(">=", delay))
+			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount)
+			mockFC.EXPECT().AddBytesSent(protocol.ByteCount(6))
+			frame, _ = str.popStreamFrame(protocol.MaxByteCount)
+			Expect(frame).ToNot(BeNil())
+			blocked := str.Stats().FlowControlBlocked
+			Expect(blocked).To(BeNumerically(">=", delay))
+			time.Sleep(delay)
+			Expect(str.Stats().FlowControlBlocked).To(Equal(blocked))
+		})
+
+		It("measures the time Write waits for data to be sent", func() {
+			mockSender.EXPECT().onHasStreamData(streamID)
+			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount).AnyTimes()
+			mockFC.EXPECT().AddBytesSent(gomock.Any()).AnyTimes()
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				defer close(done)
+				_, err := str.Write(make([]byte, 5000))
+				Expect(err).ToNot(HaveOccurred())
+			}()
+			waitForWrite()
+			delay := scaleDuration(20 * time.Millisecond)
+			time.Sleep(delay)
+			for {
+				if _, hasMoreData := str.popStreamFrame(1000); !hasMoreData {
+					break
+				}
+			}
+			Eventually(done).Should(BeClosed())
+			Expect(str.Stats().CongestionBlocked).To(BeNumerically(">=", delay))
+			Expect(str.Stats().FlowControlBlocked).To(BeZero())
+		})
+
+		It("reports the completion time", func() {
+			mockSender.EXPECT().onHasStreamData(streamID).Times(2)
+			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount)
+			mockFC.EXPECT().AddBytesSent(protocol.ByteCount(6))
+			_, err := strWithTimeout.Write([]byte("foobar"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(str.Close()).To(Succeed())
+			delay := scaleDuration(10 * time.Millisecond)
+			time.Sleep(delay)
+			frame, _ := str.popStreamFrame(protocol.MaxByteCount)
+			Expect(frame).ToNot(BeNil())
+			Expect(str.Stats().CompletionTime).To(BeZero())
+			mockSender.EXPECT().onStreamCompleted(streamID)
+			frame.OnAcked(frame.Frame)
+			Expect(str.Stats().CompletionTime).To(BeNumerically(">=", delay))
+		})
+
+		It("doesn't report a completion time if sending was canceled", func() {
+			mockSender.EXPECT().queueControlFrame(gomock.Any())
+			mockSender.EXPECT().onStreamCompleted(streamID)
+			str.CancelWrite(1234)
+			Expect(str.Stats().CompletionTime).To(BeZero())
+		})
+	})
+
+	Context("priorities", func() {
+		It("uses the default priority", func() {
+			Expect(str.Priority()).To(Equal(DefaultStreamPriority))
+		})
+
+		It("reports priority changes", func() {
+			mockSender.EXPECT().onStreamPriorityChanged(streamID, StreamPriority{Urgency: 1})
+			str.SetPriority(StreamPriority{Urgency: 1})
+			Expect(str.Priority()).To(Equal(StreamPriority{Urgency: 1}))
+			// setting the same priority again is a no-op
+			str.SetPriority(StreamPriority{Urgency: 1})
+		})
+
+		It("limits the urgency", func() {
+			mockSender.EXPECT().onStreamPriorityChanged(streamID, StreamPriority{Urgency: 7, Incremental: true})
+			str.SetPriority(StreamPriority{Urgency: 42, Incremental: true})
+			Expect(str.Priority().Urgency).To(BeEquivalentTo(7))
+		})
+	})
+
 	Context("determining when a stream is completed", func() {
 		BeforeEach(func() {
 			mockFC.EXPECT().SendWindowSize().Return(protocol.MaxByteCount).AnyTimes()
@@ -85,6 +85,7 @@ type baseServer struct {
 		*Config,
 		*tls.Config,
 		*handshake.TokenGenerator,
+		[]byte, /* client ID */
 		bool, /* enable 0-RTT */
 		logging.ConnectionTracer,
 		uint64,
@@ -102,6 +103,12 @@ type baseServer struct {
 	sessionQueue    chan quicSession
 	sessionQueueLen int32 // to be used as an atomic
 
+	// only set if Config.MaxSessions is set
+	workers *sessionWorkerPool
+
+	// ClientHellos that span multiple Initial packets, only used if Config.GetRoute is set
+	clientHellos map[string]*pendingClientHello
+
 	logger utils.Logger
 	startAlgo		utils.StartAlgo
 	congestionAlgo	utils.CongestionAlgo
@@ -219,6 +226,12 @@ func listen(conn net.PacketConn, tlsConf *tls.Config, config *Config, acceptEarl
 		congestionAlgo:		 congestionAlgo,
 		acceptEarlySessions: acceptEarly,
 	}
+	if config.MaxSessions > 0 {
+		s.workers = newSessionWorkerPool(config.MaxSessions, config.SessionRunLoopAffinity)
+	}
+	if config.SessionTicketKeys != nil {
+		config.SessionTicketKeys.addTLSConfig(tlsConf)
+	}
 	go s.run()
 	sessionHandler.SetServer(s)
 	s.logger.Debugf("Listening for %s connections on %s", conn.LocalAddr().Network(), conn.LocalAddr().String())
@@ -301,6 +314,12 @@ func (s *baseServer) Close() error {
 
 	<-s.running
 	s.sessionHandler.CloseServer()
+	if s.workers != nil {
+		s.workers.Close()
+	}
+	if s.config.SessionTicketKeys != nil {
+		s.config.SessionTicketKeys.removeTLSConfig(s.tlsConf)
+	}
 	if createdPacketConn {
 		return s.sessionHandler.Destroy()
 	}
@@ -407,9 +426,36 @@ func (s *baseServer) handleInitialImpl(p *receivedPacket, hdr *wire.Header) erro
 		return errors.New("too short connection ID")
 	}
 
+	tlsConf := s.tlsConf
+	config := s.config
+	// the Initial packets that were buffered while reassembling the ClientHello
+	var queued []*receivedPacket
+	if s.config.GetRoute != nil {
+		route, packets, ok := s.routeConnection(p, hdr)
+		if !ok {
+			return nil
+		}
+		queued = packets
+		// If no session is created, the buffered packets are dropped.
+		defer func() {
+			for _, q := range queued {
+				q.buffer.Release()
+			}
+		}()
+		if route != nil {
+			var err error
+			tlsConf, config, err = s.routeConfig(route)
+			if err != nil {
+				p.buffer.Release()
+				return err
+			}
+		}
+	}
+
 	var (
 		token          *Token
 		retrySrcConnID *protocol.ConnectionID
+		clientID       []byte // only set for tokens received in a NEW_TOKEN frame
 	)
 	origDestConnID := hdr.DestConnectionID
 	if len(hdr.Token) > 0 {
@@ -423,6 +469,8 @@ func (s *baseServer) handleInitialImpl(p *receivedPacket, hdr *wire.Header) erro
 			if token.IsRetryToken {
 				origDestConnID = c.OriginalDestConnectionID
 				retrySrcConnID = &c.RetrySrcConnectionID
+			} else {
+				clientID = c.ClientID
 			}
 		}
 	}
@@ -453,8 +501,33 @@ func (s *baseServer) handleInitialImpl(p *receivedPacket, hdr *wire.Header) erro
 		return nil
 	}
 
+	if s.config.HandshakeSigner != nil && s.config.HandshakeSigner.Busy() {
+		s.logger.Debugf("Rejecting new connection. Too many pending signing operations.")
+		go func() {
+			defer p.buffer.Release()
+			if err := s.sendConnectionRefused(p.remoteAddr, hdr, p.info); err != nil {
+				s.logger.Debugf("Error rejecting connection: %s", err)
+			}
+		}()
+		return nil
+	}
+
+	if s.workers != nil && !s.workers.Reserve() {
+		s.logger.Debugf("Rejecting new connection. Reached the maximum of %d sessions.", s.config.MaxSessions)
+		go func() {
+			defer p.buffer.Release()
+			if err := s.sendConnectionRefused(p.remoteAddr, hdr, p.info); err != nil {
+				s.logger.Debugf("Error rejecting connection: %s", err)
+			}
+		}()
+		return nil
+	}
+
 	connID, err := protocol.GenerateConnectionID(s.config.ConnectionIDLength)
 	if err != nil {
+		if s.workers != nil {
+			s.workers.Release()
+		}
 		return err
 	}
 	s.logger.Debugf("Changing connection ID to %s.", connID)
@@ -483,9 +556,10 @@ func (s *baseServer) handleInitialImpl(p *receivedPacket, hdr *wire.Header) erro
 			hdr.SrcConnectionID,
 			connID,
 			s.sessionHandler.GetStatelessResetToken(connID),
-			s.config,
-			s.tlsConf,
+			config,
+			tlsConf,
 			s.tokenGenerator,
+			clientID,
 			s.acceptEarlySessions,
 			tracer,
 			tracingID,
@@ -494,12 +568,23 @@ func (s *baseServer) handleInitialImpl(p *receivedPacket, hdr *wire.Header) erro
 			s.startAlgo,
 			s.congestionAlgo,
 		)
+		for _, q := range queued {
+			sess.handlePacket(q)
+		}
+		queued = nil
 		sess.handlePacket(p)
 		return sess
 	}); !added {
+		if s.workers != nil {
+			s.workers.Release()
+		}
 		return nil
 	}
-	go sess.run()
+	if s.workers != nil {
+		s.workers.Run(sess)
+	} else {
+		go sess.run()
+	}
 	go s.handleNewSession(sess)
 	if sess == nil {
 		p.buffer.Release()
@@ -508,6 +593,148 @@ func (s *baseServer) handleInitialImpl(p *receivedPacket, hdr *wire.Header) erro
 	return nil
 }
 
+// A pendingClientHello is a ClientHello that spans multiple Initial packets.
+type pendingClientHello struct {
+	cryptoStream cryptoStream
+	packets      []*receivedPacket
+	created      time.Time
+}
+
+// routeConnection selects the Route of a new connection, based on the ClientHello.
+// If the ClientHello spans multiple Initial packets, the packets are buffered until the ClientHello is complete.
+// Once the Route is selected, it returns the packets that were buffered before p.
+func (s *baseServer) routeConnection(p *receivedPacket, hdr *wire.Header) (*Route, []*receivedPacket, bool) {
+	if s.clientHellos == nil {
+		s.clientHellos = make(map[string]*pendingClientHello)
+	}
+	key := string(hdr.DestConnectionID)
+	pending, ok := s.clientHellos[key]
+	if !ok {
+		pending = &pendingClientHello{cryptoStream: newCryptoStream(), created: p.rcvTime}
+	}
+	if err := readClientHello(pending.cryptoStream, p, hdr); err != nil {
+		s.logger.Debugf("Dropping Initial packet. Reading the ClientHello failed: %s", err)
+		s.dropClientHelloPacket(p)
+		return nil, nil, false
+	}
+	clientHello := pending.cryptoStream.GetCryptoData()
+	if clientHello == nil {
+		// Only ClientHellos that have to be buffered count towards the limit,
+		// such that ClientHellos that fit into a single packet are never dropped.
+		if !ok {
+			s.dropExpiredClientHellos(p.rcvTime)
+			if len(s.clientHellos) >= protocol.MaxPendingClientHellos {
+				s.logger.Debugf("Dropping Initial packet. Too many ClientHellos are being reassembled.")
+				s.dropClientHelloPacket(p)
+				return nil, nil, false
+			}
+		}
+		pending.packets = append(pending.packets, p)
+		if len(pending.packets) >= protocol.MaxClientHelloPackets {
+			s.logger.Debugf("Dropping Initial packets. The ClientHello spans more than %d packets.", protocol.MaxClientHelloPackets)
+			delete(s.clientHellos, key)
+			for _, q := range pending.packets {
+				s.dropClientHelloPacket(q)
+			}
+			return nil, nil, false
+		}
+		s.clientHellos[key] = pending
+		return nil, nil, false
+	}
+	delete(s.clientHellos, key)
+	serverName, err := handshake.ParseServerName(clientHello)
+	if err != nil {
+		// Let the TLS stack deal with the invalid ClientHello.
+		s.logger.Debugf("Parsing the server name failed: %s", err)
+	}
+	route := s.config.GetRoute(&ClientHelloInfo{ServerName: serverName, RemoteAddr: p.remoteAddr})
+	return route, pending.packets, true
+}
+
+func (s *baseServer) dropExpiredClientHellos(now time.Time) {
+	for key, pending := range s.clientHellos {
+		if now.Sub(pending.created) < protocol.ClientHelloReassemblyTimeout {
+			continue
+		}
+		delete(s.clientHellos, key)
+		for _, p := range pending.packets {
+			s.dropClientHelloPacket(p)
+		}
+	}
+}
+
+func (s *baseServer) dropClientHelloPacket(p *receivedPacket) {
+	if s.config.Tracer != nil {
+		s.config.Tracer.DroppedPacket(p.remoteAddr, logging.PacketTypeInitial, p.Size(), logging.PacketDropDOSPrevention)
+	}
+	p.buffer.Release()
+}
+
+// readClientHello decrypts an Initial packet, and passes the CRYPTO frames to the crypto stream.
+// The packet is not modified, so it can still be passed to the session.
+func readClientHello(str cryptoStream, p *receivedPacket, hdr *wire.Header) error {
+	packetLen := hdr.ParsedLen() + hdr.Length
+	if protocol.ByteCount(len(p.data)) < packetLen {
+		return errors.New("packet too short")
+	}
+	data := make([]byte, packetLen)
+	copy(data, p.data)
+	_, opener := handshake.NewInitialAEAD(hdr.DestConnectionID, protocol.PerspectiveServer, hdr.Version)
+	extHdr, err := unpackHeader(opener, hdr, data, hdr.Version)
+	if err != nil {
+		return err
+	}
+	extHdrLen := extHdr.ParsedLen()
+	pn := opener.DecodePacketNumber(extHdr.PacketNumber, extHdr.PacketNumberLen)
+	payload, err := opener.Open(data[extHdrLen:extHdrLen], data[extHdrLen:], pn, data[:extHdrLen])
+	if err != nil {
+		return err
+	}
+	r := bytes.NewReader(payload)
+	frameParser := wire.NewFrameParser(false, hdr.Version)
+	for {
+		frame, err := frameParser.ParseNext(r, protocol.EncryptionInitial)
+		if err != nil {
+			return err
+		}
+		if frame == nil {
+			return nil
+		}
+		if f, ok := frame.(*wire.CryptoFrame); ok {
+			if err := str.HandleCryptoFrame(f); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// routeConfig returns the tls.Config and the Config for a Route.
+func (s *baseServer) routeConfig(route *Route) (*tls.Config, *Config, error) {
+	tlsConf := s.tlsConf
+	if route.TLSConfig != nil {
+		tlsConf = route.TLSConfig
+	}
+	if route.Config == nil {
+		return tlsConf, s.config, nil
+	}
+	if err := validateConfig(route.Config); err != nil {
+		return nil, nil, err
+	}
+	config := populateServerConfig(route.Config)
+	// These options apply to the listener.
+	config.Versions = s.config.Versions
+	config.ConnectionIDLength = s.config.ConnectionIDLength
+	config.StatelessResetKey = s.config.StatelessResetKey
+	config.AcceptToken = s.config.AcceptToken
+	config.Tracer = s.config.Tracer
+	config.MaxSessions = s.config.MaxSessions
+	config.SessionRunLoopAffinity = s.config.SessionRunLoopAffinity
+	config.HandshakeSigner = s.config.HandshakeSigner
+	config.SessionTicketKeys = s.config.SessionTicketKeys
+	config.GetRoute = s.config.GetRoute
+	return tlsConf, config, nil
+}
+
 func (s *baseServer) handleNewSession(sess quicSession) {
 	sessCtx := sess.Context()
 	if s.acceptEarlySessions {
@@ -108,7 +108,7 @@ var _ = Describe("Server", func() {
 	})
 
 	It("errors when no tls.Config is given", func() {
-		_, err := ListenAddr("localhost:0", nil, nil)
+		_, err := ListenAddr("localhost:0", nil, nil, utils.ChooseHystart, utils.ChooseNewReno)
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(ContainSubstring("quic: tls.Config not set"))
 	})
@@ -159,7 +159,7 @@ var _ = Describe("Server", func() {
 
 	It("listens on a given address", func() {
 		addr := "127.0.0.1:13579"
-		ln, err := ListenAddr(addr, tlsConf, &Config{})
+		ln, err := ListenAddr(addr, tlsConf, &Config{}, utils.ChooseHystart, utils.ChooseNewReno)
 		Expect(err).ToNot(HaveOccurred())
 		Expect(ln.Addr().String()).To(Equal(addr))
 		// stop the listener
@@ -168,13 +168,13 @@ var _ = Describe("Server", func() {
 
 	It("errors if given an invalid address", func() {
 		addr := "127.0.0.1"
-		_, err := ListenAddr(addr, tlsConf, &Config{})
+		_, err := ListenAddr(addr, tlsConf, &Config{}, utils.ChooseHystart, utils.ChooseNewReno)
 		Expect(err).To(BeAssignableToTypeOf(&net.AddrError{}))
 	})
 
 	It("errors if given an invalid address", func() {
 		addr := "1.1.1.1:1111"
-		_, err := ListenAddr(addr, tlsConf, &Config{})
+		_, err := ListenAddr(addr, tlsConf, &Config{}, utils.ChooseHystart, utils.ChooseNewReno)
 		Expect(err).To(BeAssignableToTypeOf(&net.OpError{}))
 	})
 
@@ -336,11 +336,14 @@ var _ = Describe("Server", func() {
 					_ *Config,
 					_ *tls.Config,
 					_ *handshake.TokenGenerator,
+					_ []byte,
 					enable0RTT bool,
 					_ logging.ConnectionTracer,
 					_ uint64,
 					_ utils.Logger,
 					_ protocol.VersionNumber,
+					_ utils.StartAlgo,
+					_ utils.CongestionAlgo,
 				) quicSession {
 					Expect(enable0RTT).To(BeFalse())
 					Expect(origDestConnID).To(Equal(protocol.ConnectionID{0xde, 0xad, 0xc0, 0xde}))
@@ -615,11 +618,14 @@ var _ = Describe("Server", func() {
 					_ *Config,
 					_ *tls.Config,
 					_ *handshake.TokenGenerator,
+					_ []byte,
 					enable0RTT bool,
 					_ logging.ConnectionTracer,
 					_ uint64,
 					_ utils.Logger,
 					_ protocol.VersionNumber,
+					_ utils.StartAlgo,
+					_ utils.CongestionAlgo,
 				) quicSession {
 					Expect(enable0RTT).To(BeFalse())
 					Expect(origDestConnID).To(Equal(hdr.DestConnectionID))
@@ -675,11 +681,14 @@ var _ = Describe("Server", func() {
 					_ *Config,
 					_ *tls.Config,
 					_ *handshake.TokenGenerator,
+					_ []byte,
 					_ bool,
 					_ logging.ConnectionTracer,
 					_ uint64,
 					_ utils.Logger,
 					_ protocol.VersionNumber,
+					_ utils.StartAlgo,
+					_ utils.CongestionAlgo,
 				) quicSession {
 					<-acceptSession
 					atomic.AddUint32(&counter, 1)
@@ -729,11 +738,14 @@ var _ = Describe("Server", func() {
 					_ *Config,
 					_ *tls.Config,
 					_ *handshake.TokenGenerator,
+					_ []byte,
 					_ bool,
 					_ logging.ConnectionTracer,
 					_ uint64,
 					_ utils.Logger,
 					_ protocol.VersionNumber,
+					_ utils.StartAlgo,
+					_ utils.CongestionAlgo,
 				) quicSession {
 					createdSession = true
 					return sess
@@ -760,11 +772,14 @@ var _ = Describe("Server", func() {
 					_ *Config,
 					_ *tls.Config,
 					_ *handshake.TokenGenerator,
+					_ []byte,
 					_ bool,
 					_ logging.ConnectionTracer,
 					_ uint64,
 					_ utils.Logger,
 					_ protocol.VersionNumber,
+					_ utils.StartAlgo,
+					_ utils.CongestionAlgo,
 				) quicSession {
 					sess := NewMockQuicSession(mockCtrl)
 					sess.EXPECT().handlePacket(gomock.Any())
@@ -832,11 +847,14 @@ var _ = Describe("Server", func() {
 					_ *Config,
 					_ *tls.Config,
 					_ *handshake.TokenGenerator,
+					_ []byte,
 					_ bool,
 					_ logging.ConnectionTracer,
 					_ uint64,
 					_ utils.Logger,
 					_ protocol.VersionNumber,
+					_ utils.StartAlgo,
+					_ utils.CongestionAlgo,
 				) quicSession {
 					sess.EXPECT().handlePacket(p)
 					sess.EXPECT().run()
@@ -944,11 +962,14 @@ var _ = Describe("Server", func() {
 					_ *Config,
 					_ *tls.Config,
 					_ *handshake.TokenGenerator,
+					_ []byte,
 					_ bool,
 					_ logging.ConnectionTracer,
 					_ uint64,
 					_ utils.Logger,
 					_ protocol.VersionNumber,
+					_ utils.StartAlgo,
+					_ utils.CongestionAlgo,
 				) quicSession {
 					sess.EXPECT().handlePacket(gomock.Any())
 					sess.EXPECT().HandshakeComplete().Return(ctx)
@@ -980,7 +1001,7 @@ var _ = Describe("Server", func() {
 		)
 
 		BeforeEach(func() {
-			ln, err := ListenEarly(conn, tlsConf, nil)
+			ln, err := ListenEarly(conn, tlsConf, nil, utils.ChooseHystart, utils.ChooseNewReno)
 			Expect(err).ToNot(HaveOccurred())
 			serv = ln.(*earlyServer)
 			phm = NewMockPacketHandlerManager(mockCtrl)
@@ -1018,11 +1039,14 @@ var _ = Describe("Server", func() {
 				_ *Config,
 				_ *tls.Config,
 				_ *handshake.TokenGenerator,
+				_ []byte,
 				enable0RTT bool,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
 				_ protocol.VersionNumber,
+				_ utils.StartAlgo,
+				_ utils.CongestionAlgo,
 			) quicSession {
 				Expect(enable0RTT).To(BeTrue())
 				sess.EXPECT().handlePacket(gomock.Any())
@@ -1061,11 +1085,14 @@ var _ = Describe("Server", func() {
 				_ *Config,
 				_ *tls.Config,
 				_ *handshake.TokenGenerator,
+				_ []byte,
 				_ bool,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
 				_ protocol.VersionNumber,
+				_ utils.StartAlgo,
+				_ utils.CongestionAlgo,
 			) quicSession {
 				ready := make(chan struct{})
 				close(ready)
@@ -1125,11 +1152,14 @@ var _ = Describe("Server", func() {
 				_ *Config,
 				_ *tls.Config,
 				_ *handshake.TokenGenerator,
+				_ []byte,
 				_ bool,
 				_ logging.ConnectionTracer,
 				_ uint64,
 				_ utils.Logger,
 				_ protocol.VersionNumber,
+				_ utils.StartAlgo,
+				_ utils.CongestionAlgo,
 			) quicSession {
 				sess.EXPECT().handlePacket(p)
 				sess.EXPECT().run()
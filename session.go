@@ -3,6 +3,7 @@ package quic
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
 	"crypto/tls"
 	"errors"
 	"fmt"
@@ -14,6 +15,7 @@ import (
 	"time"
 
 	"github.com/lucas-clemente/quic-go/internal/ackhandler"
+	"github.com/lucas-clemente/quic-go/internal/congestion"
 	"github.com/lucas-clemente/quic-go/internal/flowcontrol"
 	"github.com/lucas-clemente/quic-go/internal/handshake"
 	"github.com/lucas-clemente/quic-go/internal/logutils"
@@ -48,6 +50,7 @@ type streamManager interface {
 	CloseWithError(error)
 	ResetFor0RTT()
 	UseResetMaps()
+	Compact()
 }
 
 type cryptoStreamHandler interface {
@@ -56,6 +59,7 @@ type cryptoStreamHandler interface {
 	SetLargest1RTTAcked(protocol.PacketNumber) error
 	SetHandshakeConfirmed()
 	GetSessionTicket() ([]byte, error)
+	SessionTicketKeyName() ([16]byte, bool)
 	io.Closer
 	ConnectionState() handshake.ConnectionState
 }
@@ -166,12 +170,16 @@ type session struct {
 	windowUpdateQueue     *windowUpdateQueue
 	connFlowController    flowcontrol.ConnectionFlowController
 	tokenStoreKey         string                    // only set for the client
+	congestionStateKey    string                    // only set if a CongestionStateStore is used
 	tokenGenerator        *handshake.TokenGenerator // only set for the server
+	clientID              []byte                    // only set for the server, if a CongestionStateStore is used
 
 	unpacker      unpacker
 	frameParser   wire.FrameParser
 	packer        packer
 	mtuDiscoverer mtuDiscoverer // initialized when the handshake completes
+	// the number of loss detection timeouts since a 1-RTT packet was last acknowledged, used to detect MTU black holes
+	lossTimeoutsSinceAck int
 
 	oneRTTStream        cryptoStream // only set for the server
 	cryptoStreamHandler cryptoStreamHandler
@@ -192,6 +200,9 @@ type session struct {
 	undecryptablePacketsToProcess []*receivedPacket
 
 	clientHelloWritten    <-chan *wire.TransportParameters
+	handshakeStarted      bool // set if the handshake was started by PrepareDial
+
+	registration *registeredConnection // the entry in the connection registry
 	earlySessionReadyChan chan struct{}
 	handshakeCompleteChan chan struct{} // is closed when the handshake completes
 	handshakeComplete     bool
@@ -200,6 +211,8 @@ type session struct {
 	receivedRetry       bool
 	versionNegotiated   bool
 	receivedFirstPacket bool
+	// the number of PTOs that expired before the first packet was received
+	firstFlightPTOs int
 
 	idleTimeout         time.Duration
 	sessionCreationTime time.Time
@@ -207,25 +220,62 @@ type session struct {
 	lastPacketReceivedTime time.Time
 	// ... and the time we sent a new ack-eliciting packet after receiving a packet.
 	firstAckElicitingPacketAfterIdleSentTime time.Time
+	// set by the application, and passed to the congestion controller when sending packets
+	appLimited         utils.AtomicBool
+	appLimitedSignaled bool
+	// set by the application (in bytes/s), and passed to the congestion controller when sending packets
+	maxSendRate         uint64 // accessed atomically
+	maxSendRateSignaled uint64
+	readLimiter         *readRateLimiter
 	// pacingDeadline is the time when the next packet should be sent
 	pacingDeadline time.Time
+	// if set, packets are handed to the kernel with their pacing deadline as the transmit time
+	kernelPacing bool
+	// nextCompactionTime is the time when the session state is compacted next
+	nextCompactionTime time.Time
+	// state of the delivery rate reporting extension: the time when the next DELIVERY_RATE frame is sent,
+	// and the time and the number of bytes received when the last frame was sent
+	nextDeliveryRateReport     time.Time
+	lastDeliveryRateReport     time.Time
+	lastDeliveryRateBytes      protocol.ByteCount
+	deliveryRateSeq            uint64
+	highestDeliveryRateSeqRcvd uint64
 
 	peerParams *wire.TransportParameters
 
-	timer *utils.Timer
+	timers *sessionTimers
 	// keepAlivePingSent stores whether a keep alive PING is in flight.
 	// It is reset as soon as we receive a packet from the peer.
 	keepAlivePingSent bool
 	keepAliveInterval time.Duration
+	// lastRTTProbeTime is the time when the last PING was sent to sample the RTT
+	lastRTTProbeTime time.Time
+	// nil if suspensions are not detected
+	suspendDetector *suspendDetector
 
 	datagramQueue *datagramQueue
 
+	// the address of this endpoint, as reported by the peer in OBSERVED_ADDRESS frames
+	observedAddrMutex sync.Mutex
+	observedAddr      net.Addr
+	observedAddrSeq   uint64
+
 	logID  string
 	tracer logging.ConnectionTracer
 	logger utils.Logger
 
 	startAlgo utils.StartAlgo
 	congestionAlgo utils.CongestionAlgo
+
+	// the algorithms selected by the congestion controller, if they are selected automatically
+	selectedAlgosMutex     sync.Mutex
+	selectedStartAlgo      utils.StartAlgo
+	selectedCongestionAlgo utils.CongestionAlgo
+
+	// RTT measurements, updated by the run loop, and read by ConnectionState
+	rttMutex     sync.Mutex
+	smoothedRTT  time.Duration
+	handshakeRTT time.Duration
 }
 
 var (
@@ -247,6 +297,7 @@ var newSession = func(
 	conf *Config,
 	tlsConf *tls.Config,
 	tokenGenerator *handshake.TokenGenerator,
+	clientID []byte,
 	enable0RTT bool,
 	tracer logging.ConnectionTracer,
 	tracingID uint64,
@@ -261,6 +312,7 @@ var newSession = func(
 		handshakeDestConnID:   destConnID,
 		srcConnIDLen:          srcConnID.Len(),
 		tokenGenerator:        tokenGenerator,
+		clientID:              clientID,
 		oneRTTStream:          newCryptoStream(),
 		perspective:           protocol.PerspectiveServer,
 		handshakeCompleteChan: make(chan struct{}),
@@ -275,25 +327,44 @@ var newSession = func(
 	} else {
 		s.logID = destConnID.String()
 	}
+	connLogger := utils.NewConnectionLogger(logger.WithFields(utils.Field{Key: "conn_id", Value: s.logID}))
+	s.logger = connLogger
 	s.connIDManager = newConnIDManager(
 		destConnID,
 		func(token protocol.StatelessResetToken) { runner.AddResetToken(token, s) },
 		runner.RemoveResetToken,
 		s.queueControlFrame,
 	)
+	s.registration = connRegistry.Register(tracingID, s.perspective, conn.RemoteAddr())
+	connRegistry.SetLogger(s.registration, connLogger)
+	connRegistry.AddConnectionID(s.registration, srcConnID)
+	if clientDestConnID != nil {
+		connRegistry.AddConnectionID(s.registration, clientDestConnID)
+	}
 	s.connIDGenerator = newConnIDGenerator(
 		srcConnID,
 		clientDestConnID,
-		func(connID protocol.ConnectionID) { runner.Add(connID, s) },
+		func(connID protocol.ConnectionID) {
+			runner.Add(connID, s)
+			connRegistry.AddConnectionID(s.registration, connID)
+		},
 		runner.GetStatelessResetToken,
-		runner.Remove,
-		runner.Retire,
+		func(connID protocol.ConnectionID) {
+			runner.Remove(connID)
+			connRegistry.RemoveConnectionID(s.registration, connID)
+		},
+		func(connID protocol.ConnectionID) {
+			runner.Retire(connID)
+			connRegistry.RemoveConnectionID(s.registration, connID)
+		},
 		runner.ReplaceWithClosed,
 		s.queueControlFrame,
 		s.version,
 	)
 	s.preSetup()
 	s.ctx, s.ctxCancel = context.WithCancel(context.WithValue(context.Background(), SessionTracingKey, tracingID))
+	// restoring the congestion state might change the algorithms
+	congestionConf := s.congestionConfig()
 	s.sentPacketHandler, s.receivedPacketHandler = ackhandler.NewAckHandler(
 		0,
 		getMaxPacketSize(s.conn.RemoteAddr()),
@@ -303,6 +374,17 @@ var newSession = func(
 		s.logger,
 		s.startAlgo,
 		s.congestionAlgo,
+		congestionConf,
+		&ackhandler.Config{
+			MinPacketNumberLength:       protocol.PacketNumberLen(s.config.PacketNumberLength),
+			DisablePacketNumberSkipping: s.config.DisablePacketNumberSkipping,
+			PacketNumberSkipPeriod:      protocol.PacketNumber(s.config.PacketNumberSkipPeriod),
+			MaxAckRanges:                s.config.MaxAckRanges,
+			MaxAckFrameSize:             protocol.ByteCount(s.config.MaxAckFrameSize),
+			AckRangeEviction:            s.config.AckRangeEviction,
+			AckThinning:                 s.config.AckThinning,
+			CongestionMetricsInterval:   s.config.CongestionMetricsInterval,
+		},
 		s.version,
 	)
 	initialStream := newCryptoStream()
@@ -327,6 +409,10 @@ var newSession = func(
 	if s.config.EnableDatagrams {
 		params.MaxDatagramFrameSize = protocol.MaxDatagramFrameSize
 	}
+	if s.config.EnableAddressDiscovery {
+		params.AddressDiscoveryMode = wire.AddressDiscoveryProvideAndReceive
+	}
+	params.DeliveryRateReportInterval = s.config.DeliveryRateReportInterval
 	if s.tracer != nil {
 		s.tracer.SentTransportParameters(params)
 	}
@@ -366,6 +452,8 @@ var newSession = func(
 		s.framer,
 		s.receivedPacketHandler,
 		s.datagramQueue,
+		s.config.PaddingProfile,
+		s.tracer,
 		s.perspective,
 		s.version,
 	)
@@ -385,6 +473,7 @@ var newClientSession = func(
 	initialPacketNumber protocol.PacketNumber,
 	enable0RTT bool,
 	hasNegotiatedVersion bool,
+	prepared *preparedHandshake,
 	tracer logging.ConnectionTracer,
 	tracingID uint64,
 	logger utils.Logger,
@@ -408,25 +497,45 @@ var newClientSession = func(
 		startAlgo: 			   startAlgo,
 		congestionAlgo:		   congestionAlgo,
 	}
+	connLogger := utils.NewConnectionLogger(logger.WithFields(utils.Field{Key: "conn_id", Value: s.logID}))
+	s.logger = connLogger
 	s.connIDManager = newConnIDManager(
 		destConnID,
 		func(token protocol.StatelessResetToken) { runner.AddResetToken(token, s) },
 		runner.RemoveResetToken,
 		s.queueControlFrame,
 	)
+	s.registration = connRegistry.Register(tracingID, s.perspective, conn.RemoteAddr())
+	connRegistry.SetLogger(s.registration, connLogger)
+	connRegistry.AddConnectionID(s.registration, srcConnID)
 	s.connIDGenerator = newConnIDGenerator(
 		srcConnID,
 		nil,
-		func(connID protocol.ConnectionID) { runner.Add(connID, s) },
+		func(connID protocol.ConnectionID) {
+			runner.Add(connID, s)
+			connRegistry.AddConnectionID(s.registration, connID)
+		},
 		runner.GetStatelessResetToken,
-		runner.Remove,
-		runner.Retire,
+		func(connID protocol.ConnectionID) {
+			runner.Remove(connID)
+			connRegistry.RemoveConnectionID(s.registration, connID)
+		},
+		func(connID protocol.ConnectionID) {
+			runner.Retire(connID)
+			connRegistry.RemoveConnectionID(s.registration, connID)
+		},
 		runner.ReplaceWithClosed,
 		s.queueControlFrame,
 		s.version,
 	)
+	if prepared != nil {
+		// the crypto setup was created with these RTT stats
+		s.rttStats = prepared.rttStats
+	}
 	s.preSetup()
 	s.ctx, s.ctxCancel = context.WithCancel(context.WithValue(context.Background(), SessionTracingKey, tracingID))
+	// restoring the congestion state might change the algorithms
+	congestionConf := s.congestionConfig()
 	s.sentPacketHandler, s.receivedPacketHandler = ackhandler.NewAckHandler(
 		initialPacketNumber,
 		getMaxPacketSize(s.conn.RemoteAddr()),
@@ -436,50 +545,64 @@ var newClientSession = func(
 		s.logger,
 		s.startAlgo,
 		s.congestionAlgo,
-		s.version,
-	)
-	initialStream := newCryptoStream()
-	handshakeStream := newCryptoStream()
-	params := &wire.TransportParameters{
-		InitialMaxStreamDataBidiRemote: protocol.ByteCount(s.config.InitialStreamReceiveWindow),
-		InitialMaxStreamDataBidiLocal:  protocol.ByteCount(s.config.InitialStreamReceiveWindow),
-		InitialMaxStreamDataUni:        protocol.ByteCount(s.config.InitialStreamReceiveWindow),
-		InitialMaxData:                 protocol.ByteCount(s.config.InitialConnectionReceiveWindow),
-		MaxIdleTimeout:                 s.config.MaxIdleTimeout,
-		MaxBidiStreamNum:               protocol.StreamNum(s.config.MaxIncomingStreams),
-		MaxUniStreamNum:                protocol.StreamNum(s.config.MaxIncomingUniStreams),
-		MaxAckDelay:                    protocol.MaxAckDelayInclGranularity,
-		AckDelayExponent:               protocol.AckDelayExponent,
-		DisableActiveMigration:         true,
-		ActiveConnectionIDLimit:        protocol.MaxActiveConnectionIDs,
-		InitialSourceConnectionID:      srcConnID,
-	}
-	if s.config.EnableDatagrams {
-		params.MaxDatagramFrameSize = protocol.MaxDatagramFrameSize
-	}
-	if s.tracer != nil {
-		s.tracer.SentTransportParameters(params)
-	}
-	cs, clientHelloWritten := handshake.NewCryptoSetupClient(
-		initialStream,
-		handshakeStream,
-		destConnID,
-		conn.LocalAddr(),
-		conn.RemoteAddr(),
-		params,
-		&handshakeRunner{
-			onReceivedParams:    s.handleTransportParameters,
-			onError:             s.closeLocal,
-			dropKeys:            s.dropEncryptionLevel,
-			onHandshakeComplete: func() { close(s.handshakeCompleteChan) },
+		congestionConf,
+		&ackhandler.Config{
+			MinPacketNumberLength:       protocol.PacketNumberLen(s.config.PacketNumberLength),
+			DisablePacketNumberSkipping: s.config.DisablePacketNumberSkipping,
+			PacketNumberSkipPeriod:      protocol.PacketNumber(s.config.PacketNumberSkipPeriod),
+			MaxAckRanges:                s.config.MaxAckRanges,
+			MaxAckFrameSize:             protocol.ByteCount(s.config.MaxAckFrameSize),
+			AckRangeEviction:            s.config.AckRangeEviction,
+			AckThinning:                 s.config.AckThinning,
+			CongestionMetricsInterval:   s.config.CongestionMetricsInterval,
 		},
-		tlsConf,
-		enable0RTT,
-		s.rttStats,
-		tracer,
-		logger,
 		s.version,
 	)
+	var (
+		initialStream, handshakeStream cryptoStream
+		cs                             handshake.CryptoSetup
+		clientHelloWritten             <-chan *wire.TransportParameters
+	)
+	hsRunner := &handshakeRunner{
+		onReceivedParams:    s.handleTransportParameters,
+		onError:             s.closeLocal,
+		dropKeys:            s.dropEncryptionLevel,
+		onHandshakeComplete: func() { close(s.handshakeCompleteChan) },
+	}
+	if prepared != nil {
+		// The handshake was started by PrepareDial, and the ClientHello was already written to the Initial stream.
+		initialStream = prepared.initialStream
+		handshakeStream = prepared.handshakeStream
+		*prepared.runner = *hsRunner
+		if s.tracer != nil {
+			s.tracer.SentTransportParameters(prepared.params)
+		}
+		cs = prepared.cryptoSetup
+		clientHelloWritten = prepared.clientHelloWritten
+		s.handshakeStarted = true
+	} else {
+		initialStream = newCryptoStream()
+		handshakeStream = newCryptoStream()
+		params := clientTransportParameters(s.config, srcConnID)
+		if s.tracer != nil {
+			s.tracer.SentTransportParameters(params)
+		}
+		cs, clientHelloWritten = handshake.NewCryptoSetupClient(
+			initialStream,
+			handshakeStream,
+			destConnID,
+			conn.LocalAddr(),
+			conn.RemoteAddr(),
+			params,
+			hsRunner,
+			tlsConf,
+			enable0RTT,
+			s.rttStats,
+			tracer,
+			logger,
+			s.version,
+		)
+	}
 	s.clientHelloWritten = clientHelloWritten
 	s.cryptoStreamHandler = cs
 	s.cryptoStreamManager = newCryptoStreamManager(cs, initialStream, handshakeStream, newCryptoStream())
@@ -496,6 +619,8 @@ var newClientSession = func(
 		s.framer,
 		s.receivedPacketHandler,
 		s.datagramQueue,
+		s.config.PaddingProfile,
+		s.tracer,
 		s.perspective,
 		s.version,
 	)
@@ -512,24 +637,165 @@ var newClientSession = func(
 	return s
 }
 
+func clientTransportParameters(config *Config, srcConnID protocol.ConnectionID) *wire.TransportParameters {
+	params := &wire.TransportParameters{
+		InitialMaxStreamDataBidiRemote: protocol.ByteCount(config.InitialStreamReceiveWindow),
+		InitialMaxStreamDataBidiLocal:  protocol.ByteCount(config.InitialStreamReceiveWindow),
+		InitialMaxStreamDataUni:        protocol.ByteCount(config.InitialStreamReceiveWindow),
+		InitialMaxData:                 protocol.ByteCount(config.InitialConnectionReceiveWindow),
+		MaxIdleTimeout:                 config.MaxIdleTimeout,
+		MaxBidiStreamNum:               protocol.StreamNum(config.MaxIncomingStreams),
+		MaxUniStreamNum:                protocol.StreamNum(config.MaxIncomingUniStreams),
+		MaxAckDelay:                    protocol.MaxAckDelayInclGranularity,
+		AckDelayExponent:               protocol.AckDelayExponent,
+		DisableActiveMigration:         true,
+		ActiveConnectionIDLimit:        protocol.MaxActiveConnectionIDs,
+		InitialSourceConnectionID:      srcConnID,
+	}
+	if config.EnableDatagrams {
+		params.MaxDatagramFrameSize = protocol.MaxDatagramFrameSize
+	}
+	if config.EnableAddressDiscovery {
+		params.AddressDiscoveryMode = wire.AddressDiscoveryProvideAndReceive
+	}
+	params.DeliveryRateReportInterval = config.DeliveryRateReportInterval
+	return params
+}
+
+func (s *session) congestionConfig() *congestion.Config {
+	state := s.restoreCongestionState()
+	conf := &congestion.Config{
+		InitialCongestionWindow:     protocol.ByteCount(s.config.InitialCongestionWindow),
+		MaxCongestionWindow:         protocol.ByteCount(s.config.MaxCongestionWindow),
+		MinCongestionWindow:         protocol.ByteCount(s.config.MinCongestionWindow),
+		HybridStartRTTSamples:       uint32(s.config.HyStartRTTSamples),
+		HybridStartMinRTTThreshold:  s.config.HyStartMinRTTThreshold,
+		HybridStartMaxRTTThreshold:  s.config.HyStartMaxRTTThreshold,
+		HybridStartLowWindow:        protocol.ByteCount(s.config.HyStartLowWindow),
+		HybridStartCSSGrowthDivisor: uint32(s.config.HyStartCSSGrowthDivisor),
+		RenoBeta:                    s.config.RenoBeta,
+		CubicC:                      s.config.CubicC,
+		CubicBeta:                   s.config.CubicBeta,
+		DisableCubicTCPFriendliness: s.config.DisableCubicTCPFriendliness,
+		CongestionWindowValidation:  s.config.EnableCongestionWindowValidation,
+		NonValidatedPeriod:          s.config.CongestionWindowValidationPeriod,
+		LossDifferentiation:         s.config.EnableLossDifferentiation,
+		PacerMaxBurst:               protocol.ByteCount(s.config.PacerMaxBurst),
+		PacingGain:                  s.config.PacingGain,
+		PacingGranularity:           s.config.PacingGranularity,
+		DisablePacing:               s.config.DisablePacing,
+		MaxSendRate:                 congestion.Bandwidth(s.config.MaxSendRate) * congestion.BytesPerSecond,
+		EmulationProfile:            s.config.emulatedRates(),
+		DisableMinRTTProbeDip:       s.config.minRTTAging(s.startAlgo).DisableProbeDip,
+		OnAlgorithmsSelected:        s.onAlgorithmsSelected,
+		NewSendAlgorithm:            s.config.CongestionControl,
+	}
+	if onCongestionEvent := s.config.OnCongestionEvent; onCongestionEvent != nil {
+		conf.OnCongestionEvent = func(e congestion.Event, cwnd protocol.ByteCount) {
+			onCongestionEvent(e, uint64(cwnd))
+		}
+	}
+	if state != nil {
+		s.logger.Debugf("Using Careful Resume. Saved congestion window: %d, min RTT: %s", state.CongestionWindow, state.MinRTT)
+		conf.ResumeCongestionWindow = protocol.ByteCount(state.CongestionWindow)
+		conf.ResumeRTT = state.MinRTT
+	}
+	return conf
+}
+
+// restoreCongestionState returns the saved congestion state of a previous connection to the same peer, if any.
+// On the server, a returning client is recognized by the client ID in the token it received in a NEW_TOKEN frame.
+// If the algorithms are selected automatically, the algorithms chosen on the previous connection are used.
+func (s *session) restoreCongestionState() *CongestionState {
+	if s.config.CongestionStateStore == nil {
+		return nil
+	}
+	s.congestionStateKey = congestionStateKey(s.conn.RemoteAddr())
+	var state *CongestionState
+	if s.perspective == protocol.PerspectiveServer {
+		if s.clientID != nil {
+			state = s.config.CongestionStateStore.Get(clientCongestionStateKey(s.clientID))
+		} else {
+			s.clientID = make([]byte, protocol.ClientIDLen)
+			rand.Read(s.clientID)
+		}
+	}
+	if state == nil {
+		state = s.config.CongestionStateStore.Get(s.congestionStateKey)
+	}
+	if state == nil || time.Since(state.SavedTime) > protocol.MaxCongestionStateAge {
+		return nil
+	}
+	if s.startAlgo == utils.ChooseAutoStart && state.StartAlgo != 0 && state.StartAlgo != utils.ChooseAutoStart {
+		s.logger.Debugf("Using the start algorithm %s chosen on a previous connection.", state.StartAlgo)
+		s.startAlgo = state.StartAlgo
+	}
+	if s.congestionAlgo == utils.ChooseAutoCongestion && state.CongestionAlgo != 0 && state.CongestionAlgo != utils.ChooseAutoCongestion {
+		s.logger.Debugf("Using the congestion control algorithm %s chosen on a previous connection.", state.CongestionAlgo)
+		s.congestionAlgo = state.CongestionAlgo
+	}
+	return state
+}
+
+func (s *session) saveCongestionState() {
+	if s.config.CongestionStateStore == nil || !s.handshakeConfirmed || s.rttStats.MinRTT() == 0 {
+		return
+	}
+	startAlgo, congestionAlgo := s.algorithms()
+	state := &CongestionState{
+		CongestionWindow: uint64(s.sentPacketHandler.GetCongestionWindow()),
+		MinRTT:           s.rttStats.MinRTT(),
+		SavedTime:        time.Now(),
+		StartAlgo:        startAlgo,
+		CongestionAlgo:   congestionAlgo,
+	}
+	s.config.CongestionStateStore.Put(s.congestionStateKey, state)
+	if s.clientID != nil {
+		s.config.CongestionStateStore.Put(clientCongestionStateKey(s.clientID), state)
+	}
+}
+
 func (s *session) preSetup() {
-	s.sendQueue = newSendQueue(s.conn)
+	start := time.Now()
+	if getDelay, dropPacket := s.config.emulatedDelay(start), s.config.emulatedLoss(start); getDelay != nil || dropPacket != nil {
+		s.sendQueue = newEmulatingSendQueue(s.conn, getDelay, dropPacket)
+	} else {
+		s.sendQueue = newSendQueue(s.conn)
+	}
+	if s.config.EnableKernelPacing {
+		if s.kernelPacing = s.conn.EnableTxTime(); !s.kernelPacing {
+			s.logger.Infof("Kernel pacing is not supported on this connection. Falling back to user-space pacing.")
+		}
+	}
 	s.retransmissionQueue = newRetransmissionQueue(s.version)
 	s.frameParser = wire.NewFrameParser(s.config.EnableDatagrams, s.version)
-	s.rttStats = &utils.RTTStats{}
+	if s.rttStats == nil {
+		s.rttStats = &utils.RTTStats{}
+	}
+	minRTTAging := s.config.minRTTAging(s.startAlgo)
+	s.rttStats.SetMinRTTExpiry(minRTTAging.Expiry)
+	s.rttStats.SetMinRTTProbeDuration(minRTTAging.ProbeDuration)
+	s.rttStats.SetRTTFilter(s.config.RTTFilter, s.config.RTTFilterWindow)
+	s.rttStats.SetUpdateCallback(s.onRTTUpdate)
+	s.maxSendRate = s.config.MaxSendRate
+	s.maxSendRateSignaled = s.config.MaxSendRate
 	s.connFlowController = flowcontrol.NewConnectionFlowController(
 		protocol.ByteCount(s.config.InitialConnectionReceiveWindow),
 		protocol.ByteCount(s.config.MaxConnectionReceiveWindow),
 		s.onHasConnectionWindowUpdate,
 		s.rttStats,
+		s.tracer,
 		s.logger,
 	)
 	s.earlySessionReadyChan = make(chan struct{})
+	s.readLimiter = newReadRateLimiter(s.config.MaxReceiveRate)
 	s.streamsMap = newStreamsMap(
 		s,
 		s.newFlowController,
 		uint64(s.config.MaxIncomingStreams),
 		uint64(s.config.MaxIncomingUniStreams),
+		protocol.ByteCount(s.config.StreamReceiveBufferSize),
+		s.readLimiter,
 		s.perspective,
 		s.version,
 	)
@@ -545,17 +811,23 @@ func (s *session) preSetup() {
 
 	s.windowUpdateQueue = newWindowUpdateQueue(s.streamsMap, s.connFlowController, s.framer.QueueControlFrame)
 	if s.config.EnableDatagrams {
-		s.datagramQueue = newDatagramQueue(s.scheduleSending, s.logger)
+		s.datagramQueue = newDatagramQueue(s.scheduleSending, s.tracer, s.logger)
 	}
 }
 
 // run the session main loop
 func (s *session) run() error {
 	defer s.ctxCancel()
+	defer connRegistry.Unregister(s.registration)
 
-	s.timer = utils.NewTimer()
+	s.timers = newSessionTimers()
+	if threshold := s.config.suspendThreshold(); threshold > 0 {
+		s.suspendDetector = newSuspendDetector(threshold, time.Now())
+	}
 
-	go s.cryptoStreamHandler.RunHandshake()
+	if !s.handshakeStarted {
+		go s.cryptoStreamHandler.RunHandshake()
+	}
 	go func() {
 		if err := s.sendQueue.Run(); err != nil {
 			s.destroyImpl(err)
@@ -593,6 +865,7 @@ runLoop:
 		}
 
 		s.maybeResetTimer()
+		deadline := s.timers.Deadline()
 
 		var processedUndecryptablePacket bool
 		if len(s.undecryptablePacketsToProcess) > 0 {
@@ -615,8 +888,8 @@ runLoop:
 			select {
 			case closeErr = <-s.closeChan:
 				break runLoop
-			case <-s.timer.Chan():
-				s.timer.SetRead()
+			case <-s.timers.Chan():
+				s.timers.SetRead()
 				// We do all the interesting stuff after the switch statement, so
 				// nothing to see here.
 			case <-s.sendingScheduled:
@@ -624,6 +897,8 @@ runLoop:
 				// nothing to see here.
 			case <-sendQueueAvailable:
 			case firstPacket := <-s.receivedPackets:
+				// Detect a suspension before processing the packet, so that ACKs aren't evaluated with stale timestamps.
+				s.maybeDetectSuspend(deadline)
 				wasProcessed := s.handlePacketImpl(firstPacket)
 				// Don't set timers and send packets if the packet made us close the session.
 				select {
@@ -664,6 +939,7 @@ runLoop:
 			}
 		}
 
+		s.maybeDetectSuspend(deadline)
 		now := time.Now()
 		if timeout := s.sentPacketHandler.GetLossDetectionTimeout(); !timeout.IsZero() && timeout.Before(now) {
 			// This could cause packets to be retransmitted.
@@ -671,6 +947,25 @@ runLoop:
 			if err := s.sentPacketHandler.OnLossDetectionTimeout(); err != nil {
 				s.closeLocal(err)
 			}
+			if !s.receivedFirstPacket {
+				s.firstFlightPTOs++
+			}
+			s.maybeDetectMTUBlackhole()
+		}
+
+		if !s.nextCompactionTime.IsZero() && !now.Before(s.nextCompactionTime) {
+			s.compactState()
+			s.nextCompactionTime = now.Add(protocol.StateCompactionInterval)
+		}
+
+		if !s.nextDeliveryRateReport.IsZero() && !now.Before(s.nextDeliveryRateReport) {
+			s.queueDeliveryRate(now)
+		}
+
+		if rttProbeTime := s.nextRTTProbeTime(); !rttProbeTime.IsZero() && !now.Before(rttProbeTime) {
+			s.logger.Debugf("Sending a PING to take a new RTT sample.")
+			s.framer.QueueControlFrame(&wire.PingFrame{})
+			s.lastRTTProbeTime = now
 		}
 
 		if keepAliveTime := s.nextKeepAliveTime(); !keepAliveTime.IsZero() && !now.Before(keepAliveTime) {
@@ -678,6 +973,12 @@ runLoop:
 			s.logger.Debugf("Sending a keep-alive PING to keep the connection alive.")
 			s.framer.QueueControlFrame(&wire.PingFrame{})
 			s.keepAlivePingSent = true
+		} else if s.detectedBlackhole() {
+			s.destroyImpl(qerr.ErrFallbackAdvised)
+			continue
+		} else if firstFlightDeadline := s.firstFlightDeadline(); !firstFlightDeadline.IsZero() && !now.Before(firstFlightDeadline) {
+			s.destroyImpl(qerr.ErrFirstFlightTimeout)
+			continue
 		} else if !s.handshakeComplete && now.Sub(s.sessionCreationTime) >= s.config.handshakeTimeout() {
 			s.destroyImpl(qerr.ErrHandshakeTimeout)
 			continue
@@ -710,10 +1011,10 @@ runLoop:
 	if e := (&errCloseForRecreating{}); !errors.As(closeErr.err, &e) && s.tracer != nil {
 		s.tracer.Close()
 	}
-	s.logger.Infof("Connection %s closed.", s.logID)
+	s.logger.WithFields(utils.Field{Key: "event", Value: "connection_closed"}).Infof("Connection %s closed.", s.logID)
 	s.cryptoStreamHandler.Close()
 	s.sendQueue.Close()
-	s.timer.Stop()
+	s.timers.Stop()
 	return closeErr.err
 }
 
@@ -735,12 +1036,55 @@ func (s *session) supportsDatagrams() bool {
 }
 
 func (s *session) ConnectionState() ConnectionState {
+	startAlgo, congestionAlgo := s.algorithms()
+	s.rttMutex.Lock()
+	smoothedRTT, handshakeRTT := s.smoothedRTT, s.handshakeRTT
+	s.rttMutex.Unlock()
 	return ConnectionState{
 		TLS:               s.cryptoStreamHandler.ConnectionState(),
 		SupportsDatagrams: s.supportsDatagrams(),
+		ObservedAddress:   s.observedAddress(),
+		StartAlgo:         startAlgo,
+		CongestionAlgo:    congestionAlgo,
+		SmoothedRTT:       smoothedRTT,
+		HandshakeRTT:      handshakeRTT,
 	}
 }
 
+// onRTTUpdate is called by the RTTStats for every RTT sample.
+func (s *session) onRTTUpdate(smoothed, latest, min time.Duration) {
+	s.rttMutex.Lock()
+	s.smoothedRTT = smoothed
+	s.rttMutex.Unlock()
+	if s.config.OnRTTUpdate != nil {
+		s.config.OnRTTUpdate(smoothed, latest, min)
+	}
+}
+
+func (s *session) onAlgorithmsSelected(startAlgo utils.StartAlgo, congestionAlgo utils.CongestionAlgo) {
+	s.logger.Debugf("Selected start algorithm %s and congestion control algorithm %s", startAlgo, congestionAlgo)
+	s.selectedAlgosMutex.Lock()
+	s.selectedStartAlgo = startAlgo
+	s.selectedCongestionAlgo = congestionAlgo
+	s.selectedAlgosMutex.Unlock()
+}
+
+// algorithms returns the algorithms used on this connection.
+func (s *session) algorithms() (utils.StartAlgo, utils.CongestionAlgo) {
+	s.selectedAlgosMutex.Lock()
+	defer s.selectedAlgosMutex.Unlock()
+	if s.selectedStartAlgo == 0 {
+		return s.startAlgo, s.congestionAlgo
+	}
+	return s.selectedStartAlgo, s.selectedCongestionAlgo
+}
+
+func (s *session) observedAddress() net.Addr {
+	s.observedAddrMutex.Lock()
+	defer s.observedAddrMutex.Unlock()
+	return s.observedAddr
+}
+
 // Time when the next keep-alive packet should be sent.
 // It returns a zero time if no keep-alive should be sent.
 func (s *session) nextKeepAliveTime() time.Time {
@@ -750,37 +1094,107 @@ func (s *session) nextKeepAliveTime() time.Time {
 	return s.lastPacketReceivedTime.Add(s.keepAliveInterval)
 }
 
+// Time when the next PING should be sent to take a new RTT sample.
+// A PING is sent when no RTT sample was taken for the RTTProbeInterval, e.g. because the session is idle or application-limited,
+// and then at most once per RTTProbeInterval.
+// It returns a zero time if RTT probes are disabled, or if no RTT sample was taken yet.
+func (s *session) nextRTTProbeTime() time.Time {
+	lastSample := s.rttStats.LastSampleTime()
+	if s.config.RTTProbeInterval == 0 || !s.handshakeComplete || lastSample.IsZero() {
+		return time.Time{}
+	}
+	return utils.MaxTime(lastSample, s.lastRTTProbeTime).Add(s.config.RTTProbeInterval)
+}
+
 func (s *session) maybeResetTimer() {
-	var deadline time.Time
 	if !s.handshakeComplete {
-		deadline = utils.MinTime(
+		handshakeDeadline := utils.MinTime(
 			s.sessionCreationTime.Add(s.config.handshakeTimeout()),
 			s.idleTimeoutStartTime().Add(s.config.HandshakeIdleTimeout),
 		)
-	} else {
-		if keepAliveTime := s.nextKeepAliveTime(); !keepAliveTime.IsZero() {
-			deadline = keepAliveTime
-		} else {
-			deadline = s.idleTimeoutStartTime().Add(s.idleTimeout)
+		if firstFlightDeadline := s.firstFlightDeadline(); !firstFlightDeadline.IsZero() {
+			handshakeDeadline = utils.MinTime(handshakeDeadline, firstFlightDeadline)
 		}
+		s.timers.Set(timerHandshake, handshakeDeadline)
+		s.timers.Set(timerIdle, time.Time{})
+		s.timers.Set(timerKeepAlive, time.Time{})
+	} else {
+		s.timers.Set(timerHandshake, time.Time{})
+		s.timers.Set(timerIdle, s.idleTimeoutStartTime().Add(s.idleTimeout))
+		s.timers.Set(timerKeepAlive, s.nextKeepAliveTime())
 	}
+	s.timers.Set(timerRTTProbe, s.nextRTTProbeTime())
+	var probeTime time.Time
 	if s.handshakeConfirmed && pathMTUDiscoveryEnabled(s.config) {
-		if probeTime := s.mtuDiscoverer.NextProbeTime(); !probeTime.IsZero() {
-			deadline = utils.MinTime(deadline, probeTime)
-		}
+		probeTime = s.mtuDiscoverer.NextProbeTime()
+	}
+	s.timers.Set(timerMTUProbe, probeTime)
+	s.timers.Set(timerAck, s.receivedPacketHandler.GetAlarmTimeout())
+	s.timers.Set(timerLossDetection, s.sentPacketHandler.GetLossDetectionTimeout())
+	s.timers.Set(timerPacing, s.pacingDeadline)
+	s.timers.Set(timerCompaction, s.nextCompactionTime)
+	s.timers.Set(timerDeliveryRate, s.nextDeliveryRateReport)
+	s.timers.Reset()
+}
+
+// compactState releases memory held for streams and packets that are not tracked any more,
+// and stops acknowledging packets that were acknowledged for a long time.
+// Long-lived connections would otherwise retain the memory needed at their peak.
+func (s *session) compactState() {
+	s.logger.Debugf("Compacting session state.")
+	s.streamsMap.Compact()
+	s.sentPacketHandler.Compact()
+	s.receivedPacketHandler.Compact()
+}
+
+// maybeDetectSuspend checks if the system was suspended while the run loop was waiting for the timer with the given deadline.
+func (s *session) maybeDetectSuspend(deadline time.Time) {
+	if s.suspendDetector == nil {
+		return
 	}
-
-	if ackAlarm := s.receivedPacketHandler.GetAlarmTimeout(); !ackAlarm.IsZero() {
-		deadline = utils.MinTime(deadline, ackAlarm)
+	suspended, clockJump := s.suspendDetector.Check(time.Now(), deadline)
+	if suspended == 0 {
+		return
+	}
+	s.logger.Infof("Detected that the system was suspended for %s.", suspended)
+	if s.tracer != nil {
+		s.tracer.DetectedSuspend(suspended)
 	}
-	if lossTime := s.sentPacketHandler.GetLossDetectionTimeout(); !lossTime.IsZero() {
-		deadline = utils.MinTime(deadline, lossTime)
+	// Timers based on the monotonic clock fired late.
+	// Shift them, such that the connection doesn't time out and packets aren't declared lost right away.
+	if clockJump > 0 {
+		s.lastPacketReceivedTime = s.lastPacketReceivedTime.Add(clockJump)
+		if !s.firstAckElicitingPacketAfterIdleSentTime.IsZero() {
+			s.firstAckElicitingPacketAfterIdleSentTime = s.firstAckElicitingPacketAfterIdleSentTime.Add(clockJump)
+		}
+		if !s.lastRTTProbeTime.IsZero() {
+			s.lastRTTProbeTime = s.lastRTTProbeTime.Add(clockJump)
+		}
 	}
-	if !s.pacingDeadline.IsZero() {
-		deadline = utils.MinTime(deadline, s.pacingDeadline)
+	s.sentPacketHandler.OnSuspend(clockJump, s.config.ProbeAfterSuspend)
+	if s.config.ProbeAfterSuspend && s.handshakeComplete {
+		s.logger.Debugf("Sending a PING to probe the path after the suspension.")
+		s.framer.QueueControlFrame(&wire.PingFrame{})
 	}
+}
 
-	s.timer.Reset(deadline)
+// firstFlightDeadline returns the time until which the client has to receive the first packet from the server.
+// It returns the zero value if the first packet was already received, or if there's no deadline.
+func (s *session) firstFlightDeadline() time.Time {
+	if s.perspective == protocol.PerspectiveServer || s.receivedFirstPacket || s.config.FirstFlightTimeout == 0 {
+		return time.Time{}
+	}
+	return s.sessionCreationTime.Add(s.config.FirstFlightTimeout)
+}
+
+// detectedBlackhole says if the client didn't receive any packet from the server,
+// although the Initial was retransmitted multiple times.
+// Receiving a Retry proves that UDP packets reach the server.
+func (s *session) detectedBlackhole() bool {
+	if s.perspective == protocol.PerspectiveServer || !s.config.EnableBlackholeDetection {
+		return false
+	}
+	return !s.receivedFirstPacket && !s.receivedRetry && s.firstFlightPTOs >= protocol.BlackholeDetectionPTOs
 }
 
 func (s *session) idleTimeoutStartTime() time.Time {
@@ -797,6 +1211,19 @@ func (s *session) handleHandshakeComplete() {
 
 	s.connIDManager.SetHandshakeComplete()
 	s.connIDGenerator.SetHandshakeComplete()
+	s.rttMutex.Lock()
+	s.handshakeRTT = s.rttStats.SmoothedRTT()
+	s.rttMutex.Unlock()
+	s.nextCompactionTime = time.Now().Add(protocol.StateCompactionInterval)
+
+	if s.config.EnableAddressDiscovery && s.peerParams.AddressDiscoveryMode.Receives() {
+		s.queueObservedAddress()
+	}
+	if s.config.DeliveryRateReportInterval > 0 && s.peerParams.DeliveryRateReportInterval > 0 {
+		s.lastDeliveryRateReport = time.Now()
+		s.lastDeliveryRateBytes = s.connFlowController.BytesReceived()
+		s.nextDeliveryRateReport = s.lastDeliveryRateReport.Add(s.deliveryRateReportInterval())
+	}
 
 	if s.perspective == protocol.PerspectiveClient {
 		s.applyTransportParameters()
@@ -805,6 +1232,10 @@ func (s *session) handleHandshakeComplete() {
 
 	s.handleHandshakeConfirmed()
 
+	if s.config.SessionTicketKeys != nil {
+		s.reportAcceptedSessionTicket()
+	}
+
 	ticket, err := s.cryptoStreamHandler.GetSessionTicket()
 	if err != nil {
 		s.closeLocal(err)
@@ -815,7 +1246,7 @@ func (s *session) handleHandshakeComplete() {
 			s.queueControlFrame(s.oneRTTStream.PopCryptoFrame(protocol.MaxPostHandshakeCryptoFrameSize))
 		}
 	}
-	token, err := s.tokenGenerator.NewToken(s.conn.RemoteAddr())
+	token, err := s.tokenGenerator.NewToken(s.conn.RemoteAddr(), s.clientID)
 	if err != nil {
 		s.closeLocal(err)
 	}
@@ -823,6 +1254,17 @@ func (s *session) handleHandshakeComplete() {
 	s.queueControlFrame(&wire.HandshakeDoneFrame{})
 }
 
+// reportAcceptedSessionTicket counts the session ticket that was used to resume the session, if any.
+func (s *session) reportAcceptedSessionTicket() {
+	state := s.cryptoStreamHandler.ConnectionState()
+	if !state.DidResume {
+		return
+	}
+	if name, ok := s.cryptoStreamHandler.SessionTicketKeyName(); ok {
+		s.config.SessionTicketKeys.onSessionTicketAccepted(name, state.Used0RTT)
+	}
+}
+
 func (s *session) handleHandshakeConfirmed() {
 	s.handshakeConfirmed = true
 	s.sentPacketHandler.SetHandshakeConfirmed()
@@ -838,14 +1280,36 @@ func (s *session) handleHandshakeConfirmed() {
 			s.rttStats,
 			getMaxPacketSize(s.conn.RemoteAddr()),
 			maxPacketSize,
-			func(size protocol.ByteCount) {
-				s.sentPacketHandler.SetMaxDatagramSize(size)
-				s.packer.SetMaxPacketSize(size)
-			},
+			s.setMaxDatagramSize,
 		)
 	}
 }
 
+func (s *session) setMaxDatagramSize(size protocol.ByteCount) {
+	s.sentPacketHandler.SetMaxDatagramSize(size)
+	s.packer.SetMaxPacketSize(size)
+	if s.tracer != nil {
+		s.tracer.UpdatedMaxDatagramSize(size)
+	}
+}
+
+// maybeDetectMTUBlackhole is called when the loss detection timer expires.
+// If packets of the size discovered by Path MTU Discovery are repeatedly lost (e.g. because the path changed),
+// the datagram size is reduced to the initial value.
+func (s *session) maybeDetectMTUBlackhole() {
+	if s.mtuDiscoverer == nil {
+		return
+	}
+	s.lossTimeoutsSinceAck++
+	if s.lossTimeoutsSinceAck < protocol.MTUBlackholeDetectionTimeouts {
+		return
+	}
+	s.lossTimeoutsSinceAck = 0
+	if s.mtuDiscoverer.Reset() {
+		s.logger.Infof("Detected an MTU black hole. Reducing the datagram size.")
+	}
+}
+
 func (s *session) handlePacketImpl(rp *receivedPacket) bool {
 	s.sentPacketHandler.ReceivedBytes(rp.Size())
 
@@ -986,8 +1450,21 @@ func (s *session) handleSinglePacket(p *receivedPacket, hdr *wire.Header) bool /
 		return false
 	}
 
+	if s.tracer != nil {
+		buf := &bytes.Buffer{}
+		if err := packet.hdr.Write(buf, s.version); err == nil {
+			buf.Write(packet.data)
+			s.tracer.ReceivedPlaintextPacket(packet.encryptionLevel, buf.Bytes())
+		}
+	}
+
 	if s.logger.Debug() {
-		s.logger.Debugf("<- Reading packet %d (%d bytes) for connection %s, %s", packet.packetNumber, p.Size(), hdr.DestConnectionID, packet.encryptionLevel)
+		s.logger.WithFields(
+			utils.Field{Key: "event", Value: "packet_received"},
+			utils.Field{Key: "packet_number", Value: packet.packetNumber},
+			utils.Field{Key: "size", Value: p.Size()},
+			utils.Field{Key: "encryption_level", Value: packet.encryptionLevel.String()},
+		).Debugf("<- Reading packet %d (%d bytes) for connection %s, %s", packet.packetNumber, p.Size(), hdr.DestConnectionID, packet.encryptionLevel)
 		packet.hdr.Log(s.logger)
 	}
 
@@ -1263,6 +1740,10 @@ func (s *session) handleFrame(f wire.Frame, encLevel protocol.EncryptionLevel, d
 		err = s.handleHandshakeDoneFrame()
 	case *wire.DatagramFrame:
 		err = s.handleDatagramFrame(frame)
+	case *wire.ObservedAddressFrame:
+		err = s.handleObservedAddressFrame(frame)
+	case *wire.DeliveryRateFrame:
+		err = s.handleDeliveryRateFrame(frame)
 	default:
 		err = fmt.Errorf("unexpected frame type: %s", reflect.ValueOf(&frame).Elem().Type().Name())
 	}
@@ -1271,6 +1752,9 @@ func (s *session) handleFrame(f wire.Frame, encLevel protocol.EncryptionLevel, d
 
 // handlePacket is called by the server with a new packet
 func (s *session) handlePacket(p *receivedPacket) {
+	if s.tracer != nil {
+		s.tracer.ReceivedDatagram(p.data)
+	}
 	// Discard packets once the amount of queued packets is larger than
 	// the channel size, protocol.MaxSessionUnprocessedPackets
 	select {
@@ -1417,6 +1901,7 @@ func (s *session) handleAckFrame(frame *wire.AckFrame, encLevel protocol.Encrypt
 	if !acked1RTTPacket {
 		return nil
 	}
+	s.lossTimeoutsSinceAck = 0
 	if s.perspective == protocol.PerspectiveClient && !s.handshakeConfirmed {
 		s.handleHandshakeConfirmed()
 	}
@@ -1434,6 +1919,74 @@ func (s *session) handleDatagramFrame(f *wire.DatagramFrame) error {
 	return nil
 }
 
+func (s *session) handleObservedAddressFrame(f *wire.ObservedAddressFrame) error {
+	if !s.config.EnableAddressDiscovery || s.peerParams == nil || !s.peerParams.AddressDiscoveryMode.Provides() {
+		return &qerr.TransportError{
+			ErrorCode:    qerr.ProtocolViolation,
+			ErrorMessage: "received OBSERVED_ADDRESS frame although address discovery wasn't negotiated",
+		}
+	}
+	s.observedAddrMutex.Lock()
+	defer s.observedAddrMutex.Unlock()
+	// Only the address with the highest sequence number is used.
+	if s.observedAddr != nil && f.SequenceNumber <= s.observedAddrSeq {
+		return nil
+	}
+	s.observedAddr = f.Addr()
+	s.observedAddrSeq = f.SequenceNumber
+	return nil
+}
+
+// queueObservedAddress reports the address of the peer, as observed by this endpoint.
+// Since there's no support for connection migration, the address only needs to be sent once.
+func (s *session) queueObservedAddress() {
+	addr, ok := s.conn.RemoteAddr().(*net.UDPAddr)
+	if !ok {
+		return
+	}
+	s.queueControlFrame(&wire.ObservedAddressFrame{IP: addr.IP, Port: uint16(addr.Port)})
+}
+
+func (s *session) handleDeliveryRateFrame(f *wire.DeliveryRateFrame) error {
+	if s.config.DeliveryRateReportInterval == 0 || s.peerParams == nil || s.peerParams.DeliveryRateReportInterval == 0 {
+		return &qerr.TransportError{
+			ErrorCode:    qerr.ProtocolViolation,
+			ErrorMessage: "received DELIVERY_RATE frame although delivery rate reporting wasn't negotiated",
+		}
+	}
+	// Reordered (and retransmitted) reports are outdated, if a newer report was already received.
+	if f.SequenceNumber < s.highestDeliveryRateSeqRcvd {
+		return nil
+	}
+	s.highestDeliveryRateSeqRcvd = f.SequenceNumber
+	s.sentPacketHandler.ReceivedDeliveryRate(f.Rate, f.Interval, time.Now())
+	return nil
+}
+
+// deliveryRateReportInterval is the interval at which DELIVERY_RATE frames are sent, as requested by the peer.
+func (s *session) deliveryRateReportInterval() time.Duration {
+	return utils.MaxDuration(s.peerParams.DeliveryRateReportInterval, protocol.MinDeliveryRateReportInterval)
+}
+
+// queueDeliveryRate reports the goodput at which stream data was received since the last report.
+func (s *session) queueDeliveryRate(now time.Time) {
+	bytesReceived := s.connFlowController.BytesReceived()
+	interval := now.Sub(s.lastDeliveryRateReport)
+	var rate uint64
+	if interval > 0 {
+		rate = uint64(float64(bytesReceived-s.lastDeliveryRateBytes) / interval.Seconds())
+	}
+	s.queueControlFrame(&wire.DeliveryRateFrame{
+		SequenceNumber: s.deliveryRateSeq,
+		Rate:           rate,
+		Interval:       interval,
+	})
+	s.deliveryRateSeq++
+	s.lastDeliveryRateReport = now
+	s.lastDeliveryRateBytes = bytesReceived
+	s.nextDeliveryRateReport = now.Add(s.deliveryRateReportInterval())
+}
+
 // closeLocal closes the session and send a CONNECTION_CLOSE containing the error
 func (s *session) closeLocal(e error) {
 	s.closeOnce.Do(func() {
@@ -1486,6 +2039,25 @@ func (s *session) CloseWithError(code ApplicationErrorCode, desc string) error {
 	return nil
 }
 
+// summary summarizes the connection, when it is closed with the error e.
+func (s *session) summary(e error) ConnectionSummary {
+	stats := s.sentPacketHandler.Stats()
+	summary := ConnectionSummary{
+		Duration:            time.Since(s.sessionCreationTime),
+		BytesSent:           uint64(stats.BytesSent),
+		BytesReceived:       uint64(stats.BytesReceived),
+		PaddingBytesSent:    uint64(stats.PaddingBytesSent),
+		PacketsSent:         stats.PacketsSent,
+		PacketsLost:         stats.PacketsLost,
+		MaxCongestionWindow: uint64(stats.MaxCongestionWindow),
+		Err:                 e,
+	}
+	if stats.PacketsSent > 0 {
+		summary.LossRate = float64(stats.PacketsLost) / float64(stats.PacketsSent)
+	}
+	return summary
+}
+
 func (s *session) handleCloseError(closeErr *closeError) {
 	e := closeErr.err
 	if e == nil {
@@ -1506,6 +2078,8 @@ func (s *session) handleCloseError(closeErr *closeError) {
 	switch {
 	case errors.Is(e, qerr.ErrIdleTimeout),
 		errors.Is(e, qerr.ErrHandshakeTimeout),
+		errors.Is(e, qerr.ErrFirstFlightTimeout),
+		errors.Is(e, qerr.ErrFallbackAdvised),
 		errors.As(e, &statelessResetErr),
 		errors.As(e, &versionNegotiationErr),
 		errors.As(e, &recreateErr),
@@ -1520,6 +2094,9 @@ func (s *session) handleCloseError(closeErr *closeError) {
 
 	s.streamsMap.CloseWithError(e)
 	s.connIDManager.Close()
+	if !errors.As(e, &recreateErr) {
+		s.saveCongestionState()
+	}
 	if s.datagramQueue != nil {
 		s.datagramQueue.CloseWithError(e)
 	}
@@ -1527,6 +2104,9 @@ func (s *session) handleCloseError(closeErr *closeError) {
 	if s.tracer != nil && !errors.As(e, &recreateErr) {
 		s.tracer.ClosedConnection(e)
 	}
+	if s.config.OnClose != nil && !errors.As(e, &recreateErr) {
+		s.config.OnClose(s.summary(e))
+	}
 
 	// If this is a remote close we're done here
 	if closeErr.remote {
@@ -1629,7 +2209,11 @@ func (s *session) applyTransportParameters() {
 	params := s.peerParams
 	// Our local idle timeout will always be > 0.
 	s.idleTimeout = utils.MinNonZeroDuration(s.config.MaxIdleTimeout, params.MaxIdleTimeout)
-	s.keepAliveInterval = utils.MinDuration(s.idleTimeout/2, protocol.MaxKeepAliveInterval)
+	keepAlivePeriod := protocol.MaxKeepAliveInterval
+	if s.config.KeepAlivePeriod > 0 {
+		keepAlivePeriod = s.config.KeepAlivePeriod
+	}
+	s.keepAliveInterval = utils.MinDuration(s.idleTimeout/2, keepAlivePeriod)
 	s.streamsMap.UpdateLimits(params)
 	s.packer.HandleTransportParameters(params)
 	s.frameParser.SetAckDelayExponent(params.AckDelayExponent)
@@ -1648,23 +2232,41 @@ func (s *session) applyTransportParameters() {
 
 func (s *session) sendPackets() error {
 	s.pacingDeadline = time.Time{}
+	if appLimited := s.appLimited.Get(); appLimited != s.appLimitedSignaled {
+		s.appLimitedSignaled = appLimited
+		s.sentPacketHandler.SetApplicationLimited(appLimited)
+	}
+	if maxSendRate := atomic.LoadUint64(&s.maxSendRate); maxSendRate != s.maxSendRateSignaled {
+		s.maxSendRateSignaled = maxSendRate
+		s.sentPacketHandler.SetMaxSendRate(maxSendRate)
+	}
 
 	var sentPacket bool // only used in for packets sent in send mode SendAny
 	for {
+		var txTime time.Time
 		sendMode := s.sentPacketHandler.SendMode()
 		if sendMode == ackhandler.SendAny && s.handshakeComplete && !s.sentPacketHandler.HasPacingBudget() {
 			deadline := s.sentPacketHandler.TimeUntilSend()
-			if deadline.IsZero() {
-				deadline = deadlineSendImmediately
+			switch {
+			case s.kernelPacing && !deadline.IsZero() && time.Until(deadline) <= protocol.KernelPacingHorizon:
+				// Don't wait for the pacing deadline. The kernel holds back the packet until then.
+				txTime = deadline
+			case s.kernelPacing && !deadline.IsZero():
+				s.pacingDeadline = deadline.Add(-protocol.KernelPacingHorizon)
+			case deadline.IsZero():
+				s.pacingDeadline = deadlineSendImmediately
+			default:
+				s.pacingDeadline = deadline
 			}
-			s.pacingDeadline = deadline
-			// Allow sending of an ACK if we're pacing limit (if we haven't sent out a packet yet).
-			// This makes sure that a peer that is mostly receiving data (and thus has an inaccurate cwnd estimate)
-			// sends enough ACKs to allow its peer to utilize the bandwidth.
-			if sentPacket {
-				return nil
+			if txTime.IsZero() {
+				// Allow sending of an ACK if we're pacing limit (if we haven't sent out a packet yet).
+				// This makes sure that a peer that is mostly receiving data (and thus has an inaccurate cwnd estimate)
+				// sends enough ACKs to allow its peer to utilize the bandwidth.
+				if sentPacket {
+					return nil
+				}
+				sendMode = ackhandler.SendAck
 			}
-			sendMode = ackhandler.SendAck
 		}
 		switch sendMode {
 		case ackhandler.SendNone:
@@ -1693,7 +2295,7 @@ func (s *session) sendPackets() error {
 				return err
 			}
 		case ackhandler.SendAny:
-			sent, err := s.sendPacket()
+			sent, err := s.sendPacket(txTime)
 			if err != nil || !sent {
 				return err
 			}
@@ -1766,13 +2368,21 @@ func (s *session) sendProbePacket(encLevel protocol.EncryptionLevel) error {
 	return nil
 }
 
-func (s *session) sendPacket() (bool, error) {
+// sendPacket sends a packet. If txTime is set, the packet is sent with this transmit time (see Config.EnableKernelPacing).
+func (s *session) sendPacket(txTime time.Time) (bool, error) {
 	if isBlocked, offset := s.connFlowController.IsNewlyBlocked(); isBlocked {
 		s.framer.QueueControlFrame(&wire.DataBlockedFrame{MaximumData: offset})
 	}
 	s.windowUpdateQueue.QueueAll()
 
 	now := time.Now()
+	// Packets held back by the kernel are sent at their transmit time.
+	sendTime := now
+	if txTime.After(now) {
+		sendTime = txTime
+	} else {
+		txTime = time.Time{}
+	}
 	if !s.handshakeConfirmed {
 		packet, err := s.packer.PackCoalescedPacket()
 		if err != nil || packet == nil {
@@ -1781,12 +2391,12 @@ func (s *session) sendPacket() (bool, error) {
 		s.logCoalescedPacket(packet)
 		for _, p := range packet.packets {
 			if s.firstAckElicitingPacketAfterIdleSentTime.IsZero() && p.IsAckEliciting() {
-				s.firstAckElicitingPacketAfterIdleSentTime = now
+				s.firstAckElicitingPacketAfterIdleSentTime = sendTime
 			}
-			s.sentPacketHandler.SentPacket(p.ToAckHandlerPacket(now, s.retransmissionQueue))
+			s.sentPacketHandler.SentPacket(p.ToAckHandlerPacket(sendTime, s.retransmissionQueue))
 		}
 		s.connIDManager.SentPacket()
-		s.sendQueue.Send(packet.buffer)
+		s.sendBuffer(packet.buffer, txTime)
 		return true, nil
 	}
 	if pathMTUDiscoveryEnabled(s.config) && s.mtuDiscoverer.ShouldSendProbe(now) {
@@ -1794,25 +2404,40 @@ func (s *session) sendPacket() (bool, error) {
 		if err != nil {
 			return false, err
 		}
-		s.sendPackedPacket(packet, now)
+		s.sendPackedPacketAt(packet, sendTime, txTime)
 		return true, nil
 	}
 	packet, err := s.packer.PackPacket()
 	if err != nil || packet == nil {
 		return false, err
 	}
-	s.sendPackedPacket(packet, now)
+	s.sendPackedPacketAt(packet, sendTime, txTime)
 	return true, nil
 }
 
 func (s *session) sendPackedPacket(packet *packedPacket, now time.Time) {
+	s.sendPackedPacketAt(packet, now, time.Time{})
+}
+
+func (s *session) sendPackedPacketAt(packet *packedPacket, sendTime, txTime time.Time) {
 	if s.firstAckElicitingPacketAfterIdleSentTime.IsZero() && packet.IsAckEliciting() {
-		s.firstAckElicitingPacketAfterIdleSentTime = now
+		s.firstAckElicitingPacketAfterIdleSentTime = sendTime
 	}
 	s.logPacket(packet)
-	s.sentPacketHandler.SentPacket(packet.ToAckHandlerPacket(now, s.retransmissionQueue))
+	s.sentPacketHandler.SentPacket(packet.ToAckHandlerPacket(sendTime, s.retransmissionQueue))
 	s.connIDManager.SentPacket()
-	s.sendQueue.Send(packet.buffer)
+	s.sendBuffer(packet.buffer, txTime)
+}
+
+func (s *session) sendBuffer(buf *packetBuffer, txTime time.Time) {
+	if s.tracer != nil {
+		s.tracer.SentDatagram(buf.Data)
+	}
+	if txTime.IsZero() {
+		s.sendQueue.Send(buf)
+	} else {
+		s.sendQueue.SendAt(buf, txTime)
+	}
 }
 
 func (s *session) sendConnectionClose(e error) ([]byte, error) {
@@ -1834,6 +2459,9 @@ func (s *session) sendConnectionClose(e error) ([]byte, error) {
 		return nil, err
 	}
 	s.logCoalescedPacket(packet)
+	if s.tracer != nil {
+		s.tracer.SentDatagram(packet.buffer.Data)
+	}
 	return packet.buffer.Data, s.conn.Write(packet.buffer.Data)
 }
 
@@ -1865,7 +2493,7 @@ func (s *session) logCoalescedPacket(packet *coalescedPacket) {
 		if len(packet.packets) > 1 {
 			s.logger.Debugf("-> Sending coalesced packet (%d parts, %d bytes) for connection %s", len(packet.packets), packet.buffer.Len(), s.logID)
 		} else {
-			s.logger.Debugf("-> Sending packet %d (%d bytes) for connection %s, %s", packet.packets[0].header.PacketNumber, packet.buffer.Len(), s.logID, packet.packets[0].EncryptionLevel())
+			s.logSentPacket(packet.packets[0].header.PacketNumber, packet.buffer.Len(), packet.packets[0].EncryptionLevel())
 		}
 	}
 	for _, p := range packet.packets {
@@ -1875,35 +2503,66 @@ func (s *session) logCoalescedPacket(packet *coalescedPacket) {
 
 func (s *session) logPacket(packet *packedPacket) {
 	if s.logger.Debug() {
-		s.logger.Debugf("-> Sending packet %d (%d bytes) for connection %s, %s", packet.header.PacketNumber, packet.buffer.Len(), s.logID, packet.EncryptionLevel())
+		s.logSentPacket(packet.header.PacketNumber, packet.buffer.Len(), packet.EncryptionLevel())
 	}
 	s.logPacketContents(packet.packetContents)
 }
 
+func (s *session) logSentPacket(pn protocol.PacketNumber, size protocol.ByteCount, encLevel protocol.EncryptionLevel) {
+	s.logger.WithFields(
+		utils.Field{Key: "event", Value: "packet_sent"},
+		utils.Field{Key: "packet_number", Value: pn},
+		utils.Field{Key: "size", Value: size},
+		utils.Field{Key: "encryption_level", Value: encLevel.String()},
+	).Debugf("-> Sending packet %d (%d bytes) for connection %s, %s", pn, size, s.logID, encLevel)
+}
+
 // AcceptStream returns the next stream openend by the peer
 func (s *session) AcceptStream(ctx context.Context) (Stream, error) {
-	return s.streamsMap.AcceptStream(ctx)
+	return s.maybeWrapStream(s.streamsMap.AcceptStream(ctx))
 }
 
 func (s *session) AcceptUniStream(ctx context.Context) (ReceiveStream, error) {
-	return s.streamsMap.AcceptUniStream(ctx)
+	return s.maybeWrapReceiveStream(s.streamsMap.AcceptUniStream(ctx))
 }
 
 // OpenStream opens a stream
 func (s *session) OpenStream() (Stream, error) {
-	return s.streamsMap.OpenStream()
+	return s.maybeWrapStream(s.streamsMap.OpenStream())
 }
 
 func (s *session) OpenStreamSync(ctx context.Context) (Stream, error) {
-	return s.streamsMap.OpenStreamSync(ctx)
+	return s.maybeWrapStream(s.streamsMap.OpenStreamSync(ctx))
 }
 
 func (s *session) OpenUniStream() (SendStream, error) {
-	return s.streamsMap.OpenUniStream()
+	return s.maybeWrapSendStream(s.streamsMap.OpenUniStream())
 }
 
 func (s *session) OpenUniStreamSync(ctx context.Context) (SendStream, error) {
-	return s.streamsMap.OpenUniStreamSync(ctx)
+	return s.maybeWrapSendStream(s.streamsMap.OpenUniStreamSync(ctx))
+}
+
+// maybeWrapStream adds checksums to the stream data, if enabled in the config.
+func (s *session) maybeWrapStream(str Stream, err error) (Stream, error) {
+	if err != nil || !s.config.EnableStreamChecksums {
+		return str, err
+	}
+	return newChecksumStream(str, s.logger), nil
+}
+
+func (s *session) maybeWrapSendStream(str SendStream, err error) (SendStream, error) {
+	if err != nil || !s.config.EnableStreamChecksums {
+		return str, err
+	}
+	return newChecksumSendStream(str), nil
+}
+
+func (s *session) maybeWrapReceiveStream(str ReceiveStream, err error) (ReceiveStream, error) {
+	if err != nil || !s.config.EnableStreamChecksums {
+		return str, err
+	}
+	return newChecksumReceiveStream(str, s.logger), nil
 }
 
 func (s *session) newFlowController(id protocol.StreamID) flowcontrol.StreamFlowController {
@@ -1923,6 +2582,7 @@ func (s *session) newFlowController(id protocol.StreamID) flowcontrol.StreamFlow
 		initialSendWindow,
 		s.onHasStreamWindowUpdate,
 		s.rttStats,
+		s.tracer,
 		s.logger,
 	)
 }
@@ -1973,7 +2633,12 @@ func (s *session) onHasStreamData(id protocol.StreamID) {
 	s.scheduleSending()
 }
 
+func (s *session) onStreamPriorityChanged(id protocol.StreamID, p StreamPriority) {
+	s.framer.SetStreamPriority(id, p)
+}
+
 func (s *session) onStreamCompleted(id protocol.StreamID) {
+	s.framer.SetStreamPriority(id, DefaultStreamPriority) // removes the stream's entry
 	if err := s.streamsMap.DeleteStream(id); err != nil {
 		s.closeLocal(err)
 	}
@@ -1993,6 +2658,24 @@ func (s *session) ReceiveMessage() ([]byte, error) {
 	return s.datagramQueue.Receive()
 }
 
+func (s *session) SetLabels(labels map[string]string) {
+	connRegistry.SetLabels(s.registration, labels)
+}
+
+func (s *session) SetApplicationLimited(limited bool) {
+	s.appLimited.Set(limited)
+	s.scheduleSending()
+}
+
+func (s *session) SetMaxSendRate(bytesPerSecond uint64) {
+	atomic.StoreUint64(&s.maxSendRate, bytesPerSecond)
+	s.scheduleSending()
+}
+
+func (s *session) SetMaxReceiveRate(bytesPerSecond uint64) {
+	s.readLimiter.SetRate(bytesPerSecond)
+}
+
 func (s *session) LocalAddr() net.Addr {
 	return s.conn.LocalAddr()
 }
@@ -94,6 +94,8 @@ var _ = Describe("Session", func() {
 		tracer.EXPECT().NegotiatedVersion(gomock.Any(), gomock.Any(), gomock.Any()).MaxTimes(1)
 		tracer.EXPECT().SentTransportParameters(gomock.Any())
 		tracer.EXPECT().UpdatedKeyFromTLS(gomock.Any(), gomock.Any()).AnyTimes()
+		tracer.EXPECT().SentDatagram(gomock.Any()).AnyTimes()
+		tracer.EXPECT().ReceivedDatagram(gomock.Any()).AnyTimes()
 		tracer.EXPECT().UpdatedCongestionState(gomock.Any())
 		sess = newSession(
 			mconn,
@@ -107,11 +109,14 @@ var _ = Describe("Session", func() {
 			populateServerConfig(&Config{DisablePathMTUDiscovery: true}),
 			nil, // tls.Config
 			tokenGenerator,
+			nil,
 			false,
 			tracer,
 			1234,
 			utils.DefaultLogger,
 			protocol.VersionTLS,
+			utils.ChooseHystart,
+			utils.ChooseNewReno,
 		).(*session)
 		streamManager = NewMockStreamManager(mockCtrl)
 		sess.streamsMap = streamManager
@@ -301,6 +306,80 @@ var _ = Describe("Session", func() {
 			Expect(frames).To(Equal([]ackhandler.Frame{{Frame: &wire.PathResponseFrame{Data: data}}}))
 		})
 
+		It("handles OBSERVED_ADDRESS frames", func() {
+			sess.config.EnableAddressDiscovery = true
+			sess.peerParams = &wire.TransportParameters{AddressDiscoveryMode: wire.AddressDiscoveryProvideAndReceive}
+			Expect(sess.observedAddress()).To(BeNil())
+			ip := net.IPv4(192, 168, 0, 1)
+			Expect(sess.handleFrame(&wire.ObservedAddressFrame{SequenceNumber: 1, IP: ip, Port: 1234}, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
+			Expect(sess.observedAddress()).To(Equal(&net.UDPAddr{IP: ip, Port: 1234}))
+			// reordered frames are ignored
+			Expect(sess.handleFrame(&wire.ObservedAddressFrame{SequenceNumber: 0, IP: ip, Port: 4321}, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
+			Expect(sess.observedAddress()).To(Equal(&net.UDPAddr{IP: ip, Port: 1234}))
+			Expect(sess.handleFrame(&wire.ObservedAddressFrame{SequenceNumber: 2, IP: ip, Port: 4321}, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
+			Expect(sess.observedAddress()).To(Equal(&net.UDPAddr{IP: ip, Port: 4321}))
+		})
+
+		It("rejects OBSERVED_ADDRESS frames, if address discovery wasn't negotiated", func() {
+			sess.config.EnableAddressDiscovery = true
+			sess.peerParams = &wire.TransportParameters{AddressDiscoveryMode: wire.AddressDiscoveryReceive}
+			err := sess.handleFrame(&wire.ObservedAddressFrame{IP: net.IPv4(192, 168, 0, 1), Port: 1234}, protocol.Encryption1RTT, protocol.ConnectionID{})
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(BeAssignableToTypeOf(&qerr.TransportError{}))
+			Expect(err.(*qerr.TransportError).ErrorCode).To(Equal(qerr.ProtocolViolation))
+			Expect(sess.observedAddress()).To(BeNil())
+		})
+
+		It("reports the observed address of the peer", func() {
+			sess.queueObservedAddress()
+			frames, _ := sess.framer.AppendControlFrames(nil, 1000)
+			Expect(frames).To(Equal([]ackhandler.Frame{{Frame: &wire.ObservedAddressFrame{IP: remoteAddr.IP, Port: uint16(remoteAddr.Port)}}}))
+		})
+
+		It("passes DELIVERY_RATE frames to the sent packet handler", func() {
+			sess.config.DeliveryRateReportInterval = 25 * time.Millisecond
+			sess.peerParams = &wire.TransportParameters{DeliveryRateReportInterval: 10 * time.Millisecond}
+			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sess.sentPacketHandler = sph
+			gomock.InOrder(
+				sph.EXPECT().ReceivedDeliveryRate(uint64(1e6), 25*time.Millisecond, gomock.Any()),
+				sph.EXPECT().ReceivedDeliveryRate(uint64(2e6), 25*time.Millisecond, gomock.Any()),
+			)
+			Expect(sess.handleFrame(&wire.DeliveryRateFrame{SequenceNumber: 1, Rate: 1e6, Interval: 25 * time.Millisecond}, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
+			// reordered frames are ignored
+			Expect(sess.handleFrame(&wire.DeliveryRateFrame{SequenceNumber: 0, Rate: 3e6, Interval: 25 * time.Millisecond}, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
+			Expect(sess.handleFrame(&wire.DeliveryRateFrame{SequenceNumber: 2, Rate: 2e6, Interval: 25 * time.Millisecond}, protocol.Encryption1RTT, protocol.ConnectionID{})).To(Succeed())
+		})
+
+		It("rejects DELIVERY_RATE frames, if delivery rate reporting wasn't negotiated", func() {
+			sess.config.DeliveryRateReportInterval = 25 * time.Millisecond
+			sess.peerParams = &wire.TransportParameters{}
+			err := sess.handleFrame(&wire.DeliveryRateFrame{Rate: 1e6, Interval: 25 * time.Millisecond}, protocol.Encryption1RTT, protocol.ConnectionID{})
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(BeAssignableToTypeOf(&qerr.TransportError{}))
+			Expect(err.(*qerr.TransportError).ErrorCode).To(Equal(qerr.ProtocolViolation))
+		})
+
+		It("reports the delivery rate", func() {
+			sess.peerParams = &wire.TransportParameters{DeliveryRateReportInterval: 10 * time.Millisecond}
+			connFC := mocks.NewMockConnectionFlowController(mockCtrl)
+			sess.connFlowController = connFC
+			now := time.Now()
+			sess.lastDeliveryRateReport = now.Add(-20 * time.Millisecond)
+			sess.lastDeliveryRateBytes = 1000
+			connFC.EXPECT().BytesReceived().Return(protocol.ByteCount(21000))
+			sess.queueDeliveryRate(now)
+			frames, _ := sess.framer.AppendControlFrames(nil, 1000)
+			Expect(frames).To(Equal([]ackhandler.Frame{{Frame: &wire.DeliveryRateFrame{SequenceNumber: 0, Rate: 1e6, Interval: 20 * time.Millisecond}}}))
+			Expect(sess.nextDeliveryRateReport).To(Equal(now.Add(10 * time.Millisecond)))
+			Expect(sess.lastDeliveryRateBytes).To(Equal(protocol.ByteCount(21000)))
+			// the next frame uses the next sequence number
+			connFC.EXPECT().BytesReceived().Return(protocol.ByteCount(21000))
+			sess.queueDeliveryRate(now.Add(10 * time.Millisecond))
+			frames, _ = sess.framer.AppendControlFrames(nil, 1000)
+			Expect(frames).To(Equal([]ackhandler.Frame{{Frame: &wire.DeliveryRateFrame{SequenceNumber: 1, Rate: 0, Interval: 10 * time.Millisecond}}}))
+		})
+
 		It("rejects NEW_TOKEN frames", func() {
 			err := sess.handleNewTokenFrame(&wire.NewTokenFrame{})
 			Expect(err).To(HaveOccurred())
@@ -456,6 +535,52 @@ var _ = Describe("Session", func() {
 			Expect(sess.Context().Done()).To(BeClosed())
 		})
 
+		It("calls the OnClose callback with a summary of the connection", func() {
+			summaries := make(chan ConnectionSummary, 1)
+			sess.config.OnClose = func(s ConnectionSummary) { summaries <- s }
+			runSession()
+			streamManager.EXPECT().CloseWithError(gomock.Any())
+			expectReplaceWithClosed()
+			cryptoSetup.EXPECT().Close()
+			packer.EXPECT().PackApplicationClose(gomock.Any()).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
+			mconn.EXPECT().Write(gomock.Any())
+			tracer.EXPECT().ClosedConnection(gomock.Any())
+			tracer.EXPECT().Close()
+			sess.CloseWithError(0x1337, "foobar")
+			Eventually(areSessionsRunning).Should(BeFalse())
+			var summary ConnectionSummary
+			Expect(summaries).To(Receive(&summary))
+			Expect(summary.Duration).To(BeNumerically(">", 0))
+			var appErr *ApplicationError
+			Expect(errors.As(summary.Err, &appErr)).To(BeTrue())
+			Expect(appErr.ErrorCode).To(BeEquivalentTo(0x1337))
+		})
+
+		It("registers the session in the connection registry until it is closed", func() {
+			info, ok := LookupConnectionID(srcConnID)
+			Expect(ok).To(BeTrue())
+			Expect(info.TracingID).To(BeEquivalentTo(1234))
+			Expect(info.Perspective).To(Equal(protocol.PerspectiveServer))
+			Expect(info.RemoteAddr).To(Equal(remoteAddr))
+			Expect(info.ConnectionIDs).To(ContainElement(clientDestConnID.Bytes()))
+			sess.SetLabels(map[string]string{"tenant": "acme"})
+			info, ok = LookupConnectionID(clientDestConnID)
+			Expect(ok).To(BeTrue())
+			Expect(info.Labels).To(Equal(map[string]string{"tenant": "acme"}))
+			runSession()
+			streamManager.EXPECT().CloseWithError(gomock.Any())
+			expectReplaceWithClosed()
+			cryptoSetup.EXPECT().Close()
+			packer.EXPECT().PackApplicationClose(gomock.Any()).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
+			mconn.EXPECT().Write(gomock.Any())
+			tracer.EXPECT().ClosedConnection(gomock.Any())
+			tracer.EXPECT().Close()
+			sess.shutdown()
+			Eventually(areSessionsRunning).Should(BeFalse())
+			_, ok = LookupConnectionID(srcConnID)
+			Expect(ok).To(BeFalse())
+		})
+
 		It("only closes once", func() {
 			runSession()
 			streamManager.EXPECT().CloseWithError(gomock.Any())
@@ -582,6 +707,7 @@ var _ = Describe("Session", func() {
 				}, nil
 			})
 			gomock.InOrder(
+				tracer.EXPECT().ReceivedPlaintextPacket(protocol.Encryption1RTT, gomock.Any()),
 				tracer.EXPECT().StartedConnection(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()),
 				tracer.EXPECT().ReceivedPacket(gomock.Any(), gomock.Any(), gomock.Any()),
 				tracer.EXPECT().ClosedConnection(gomock.Any()),
@@ -756,6 +882,7 @@ var _ = Describe("Session", func() {
 			sess.receivedPacketHandler = rph
 			packet.rcvTime = rcvTime
 			tracer.EXPECT().StartedConnection(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			tracer.EXPECT().ReceivedPlaintextPacket(gomock.Any(), gomock.Any())
 			tracer.EXPECT().ReceivedPacket(hdr, protocol.ByteCount(len(packet.data)), []logging.Frame{})
 			Expect(sess.handlePacketImpl(packet)).To(BeTrue())
 		})
@@ -785,6 +912,7 @@ var _ = Describe("Session", func() {
 			sess.receivedPacketHandler = rph
 			packet.rcvTime = rcvTime
 			tracer.EXPECT().StartedConnection(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			tracer.EXPECT().ReceivedPlaintextPacket(gomock.Any(), gomock.Any())
 			tracer.EXPECT().ReceivedPacket(hdr, protocol.ByteCount(len(packet.data)), []logging.Frame{&logging.PingFrame{}})
 			Expect(sess.handlePacketImpl(packet)).To(BeTrue())
 		})
@@ -805,6 +933,8 @@ var _ = Describe("Session", func() {
 			rph := mockackhandler.NewMockReceivedPacketHandler(mockCtrl)
 			rph.EXPECT().IsPotentiallyDuplicate(protocol.PacketNumber(0x1337), protocol.Encryption1RTT).Return(true)
 			sess.receivedPacketHandler = rph
+			// the header is followed by the decrypted payload
+			tracer.EXPECT().ReceivedPlaintextPacket(protocol.Encryption1RTT, append(append([]byte{}, packet.data...), []byte("foobar")...))
 			tracer.EXPECT().DroppedPacket(logging.PacketType1RTT, protocol.ByteCount(len(packet.data)), logging.PacketDropDuplicate)
 			Expect(sess.handlePacketImpl(packet)).To(BeFalse())
 		})
@@ -851,10 +981,11 @@ var _ = Describe("Session", func() {
 					data:            []byte{0}, // PADDING frame
 					encryptionLevel: protocol.Encryption1RTT,
 					packetNumber:    pn,
-					hdr:             &wire.ExtendedHeader{Header: *hdr},
+					hdr:             &wire.ExtendedHeader{Header: *hdr, PacketNumberLen: protocol.PacketNumberLen2},
 				}, nil
 			}).Times(3)
 			tracer.EXPECT().StartedConnection(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			tracer.EXPECT().ReceivedPlaintextPacket(protocol.Encryption1RTT, gomock.Any()).Times(3)
 			tracer.EXPECT().ReceivedPacket(gomock.Any(), gomock.Any(), gomock.Any()).Do(func(hdr *wire.ExtendedHeader, _ protocol.ByteCount, _ []logging.Frame) {
 			}).Times(3)
 			packer.EXPECT().PackCoalescedPacket() // only expect a single call
@@ -896,10 +1027,11 @@ var _ = Describe("Session", func() {
 					data:            []byte{0}, // PADDING frame
 					encryptionLevel: protocol.Encryption1RTT,
 					packetNumber:    pn,
-					hdr:             &wire.ExtendedHeader{Header: *hdr},
+					hdr:             &wire.ExtendedHeader{Header: *hdr, PacketNumberLen: protocol.PacketNumberLen2},
 				}, nil
 			}).Times(3)
 			tracer.EXPECT().StartedConnection(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			tracer.EXPECT().ReceivedPlaintextPacket(protocol.Encryption1RTT, gomock.Any()).Times(3)
 			tracer.EXPECT().ReceivedPacket(gomock.Any(), gomock.Any(), gomock.Any()).Do(func(hdr *wire.ExtendedHeader, _ protocol.ByteCount, _ []logging.Frame) {
 			}).Times(3)
 			packer.EXPECT().PackCoalescedPacket().Times(3) // only expect a single call
@@ -1014,7 +1146,7 @@ var _ = Describe("Session", func() {
 
 		It("rejects packets with empty payload", func() {
 			unpacker.EXPECT().Unpack(gomock.Any(), gomock.Any(), gomock.Any()).Return(&unpackedPacket{
-				hdr:             &wire.ExtendedHeader{},
+				hdr:             &wire.ExtendedHeader{PacketNumberLen: protocol.PacketNumberLen1},
 				data:            []byte{}, // no payload
 				encryptionLevel: protocol.Encryption1RTT,
 			}, nil)
@@ -1033,6 +1165,7 @@ var _ = Describe("Session", func() {
 			}()
 			expectReplaceWithClosed()
 			mconn.EXPECT().Write(gomock.Any())
+			tracer.EXPECT().ReceivedPlaintextPacket(protocol.Encryption1RTT, gomock.Any())
 			tracer.EXPECT().ClosedConnection(gomock.Any())
 			tracer.EXPECT().Close()
 			sess.handlePacket(getPacket(&wire.ExtendedHeader{
@@ -1077,6 +1210,7 @@ var _ = Describe("Session", func() {
 			}, nil)
 			p1 := getPacket(hdr1, nil)
 			tracer.EXPECT().StartedConnection(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+			tracer.EXPECT().ReceivedPlaintextPacket(gomock.Any(), gomock.Any())
 			tracer.EXPECT().ReceivedPacket(gomock.Any(), protocol.ByteCount(len(p1.data)), gomock.Any())
 			Expect(sess.handlePacketImpl(p1)).To(BeTrue())
 			// The next packet has to be ignored, since the source connection ID doesn't match.
@@ -1110,7 +1244,7 @@ var _ = Describe("Session", func() {
 			It("doesn't support connection migration", func() {
 				unpacker.EXPECT().Unpack(gomock.Any(), gomock.Any(), gomock.Any()).Return(&unpackedPacket{
 					encryptionLevel: protocol.Encryption1RTT,
-					hdr:             &wire.ExtendedHeader{},
+					hdr:             &wire.ExtendedHeader{PacketNumberLen: protocol.PacketNumberLen1},
 					data:            []byte{0}, // one PADDING frame
 				}, nil)
 				packet := getPacket(&wire.ExtendedHeader{
@@ -1119,6 +1253,7 @@ var _ = Describe("Session", func() {
 				}, nil)
 				packet.remoteAddr = &net.IPAddr{IP: net.IPv4(192, 168, 0, 100)}
 				tracer.EXPECT().StartedConnection(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+				tracer.EXPECT().ReceivedPlaintextPacket(gomock.Any(), gomock.Any())
 				tracer.EXPECT().ReceivedPacket(gomock.Any(), protocol.ByteCount(len(packet.data)), gomock.Any())
 				Expect(sess.handlePacketImpl(packet)).To(BeTrue())
 			})
@@ -1154,9 +1289,10 @@ var _ = Describe("Session", func() {
 					return &unpackedPacket{
 						encryptionLevel: protocol.EncryptionHandshake,
 						data:            []byte{0},
-						hdr:             &wire.ExtendedHeader{},
+						hdr:             &wire.ExtendedHeader{PacketNumberLen: protocol.PacketNumberLen1},
 					}, nil
 				})
+				tracer.EXPECT().ReceivedPlaintextPacket(gomock.Any(), gomock.Any())
 				tracer.EXPECT().ReceivedPacket(gomock.Any(), protocol.ByteCount(len(packet.data)), gomock.Any())
 				Expect(sess.handlePacketImpl(packet)).To(BeTrue())
 			})
@@ -1169,7 +1305,7 @@ var _ = Describe("Session", func() {
 						encryptionLevel: protocol.EncryptionHandshake,
 						data:            []byte{0},
 						packetNumber:    1,
-						hdr:             &wire.ExtendedHeader{Header: wire.Header{SrcConnectionID: destConnID}},
+						hdr:             &wire.ExtendedHeader{Header: wire.Header{SrcConnectionID: destConnID}, PacketNumberLen: protocol.PacketNumberLen1},
 					}, nil
 				})
 				hdrLen2, packet2 := getPacketWithLength(srcConnID, 123)
@@ -1179,11 +1315,13 @@ var _ = Describe("Session", func() {
 						encryptionLevel: protocol.EncryptionHandshake,
 						data:            []byte{0},
 						packetNumber:    2,
-						hdr:             &wire.ExtendedHeader{Header: wire.Header{SrcConnectionID: destConnID}},
+						hdr:             &wire.ExtendedHeader{Header: wire.Header{SrcConnectionID: destConnID}, PacketNumberLen: protocol.PacketNumberLen1},
 					}, nil
 				})
 				gomock.InOrder(
+					tracer.EXPECT().ReceivedPlaintextPacket(gomock.Any(), gomock.Any()),
 					tracer.EXPECT().ReceivedPacket(gomock.Any(), protocol.ByteCount(len(packet1.data)), gomock.Any()),
+					tracer.EXPECT().ReceivedPlaintextPacket(gomock.Any(), gomock.Any()),
 					tracer.EXPECT().ReceivedPacket(gomock.Any(), protocol.ByteCount(len(packet2.data)), gomock.Any()),
 				)
 				packet1.data = append(packet1.data, packet2.data...)
@@ -1201,12 +1339,13 @@ var _ = Describe("Session", func() {
 						return &unpackedPacket{
 							encryptionLevel: protocol.EncryptionHandshake,
 							data:            []byte{0},
-							hdr:             &wire.ExtendedHeader{},
+							hdr:             &wire.ExtendedHeader{PacketNumberLen: protocol.PacketNumberLen1},
 						}, nil
 					}),
 				)
 				gomock.InOrder(
 					tracer.EXPECT().BufferedPacket(gomock.Any()),
+					tracer.EXPECT().ReceivedPlaintextPacket(gomock.Any(), gomock.Any()),
 					tracer.EXPECT().ReceivedPacket(gomock.Any(), protocol.ByteCount(len(packet2.data)), gomock.Any()),
 				)
 				packet1.data = append(packet1.data, packet2.data...)
@@ -1225,12 +1364,13 @@ var _ = Describe("Session", func() {
 					return &unpackedPacket{
 						encryptionLevel: protocol.EncryptionHandshake,
 						data:            []byte{0},
-						hdr:             &wire.ExtendedHeader{},
+						hdr:             &wire.ExtendedHeader{PacketNumberLen: protocol.PacketNumberLen1},
 					}, nil
 				})
 				_, packet2 := getPacketWithLength(wrongConnID, 123)
 				// don't EXPECT any more calls to unpacker.Unpack()
 				gomock.InOrder(
+					tracer.EXPECT().ReceivedPlaintextPacket(gomock.Any(), gomock.Any()),
 					tracer.EXPECT().ReceivedPacket(gomock.Any(), protocol.ByteCount(len(packet1.data)), gomock.Any()),
 					tracer.EXPECT().DroppedPacket(gomock.Any(), protocol.ByteCount(len(packet2.data)), logging.PacketDropUnknownConnectionID),
 				)
@@ -1298,6 +1438,57 @@ var _ = Describe("Session", func() {
 			Eventually(sent).Should(BeClosed())
 		})
 
+		It("passes the application-limited state to the sent packet handler", func() {
+			sess.handshakeConfirmed = true
+			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().TimeUntilSend().AnyTimes()
+			sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
+			sph.EXPECT().SendMode().Return(ackhandler.SendAny).AnyTimes()
+			sph.EXPECT().HasPacingBudget().Return(true).AnyTimes()
+			sess.sentPacketHandler = sph
+			packer.EXPECT().PackPacket().Return(nil, nil).AnyTimes()
+			runSession()
+			limited := make(chan bool, 2)
+			sph.EXPECT().SetApplicationLimited(gomock.Any()).Do(func(l bool) { limited <- l }).Times(2)
+			sess.SetApplicationLimited(true)
+			Eventually(limited).Should(Receive(BeTrue()))
+			// setting the same value again doesn't change anything
+			sess.SetApplicationLimited(true)
+			sess.SetApplicationLimited(false)
+			Eventually(limited).Should(Receive(BeFalse()))
+		})
+
+		It("passes the maximum send rate to the sent packet handler", func() {
+			sess.handshakeConfirmed = true
+			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sph.EXPECT().TimeUntilSend().AnyTimes()
+			sph.EXPECT().GetLossDetectionTimeout().AnyTimes()
+			sph.EXPECT().SendMode().Return(ackhandler.SendAny).AnyTimes()
+			sph.EXPECT().HasPacingBudget().Return(true).AnyTimes()
+			sess.sentPacketHandler = sph
+			packer.EXPECT().PackPacket().Return(nil, nil).AnyTimes()
+			runSession()
+			rates := make(chan uint64, 2)
+			sph.EXPECT().SetMaxSendRate(gomock.Any()).Do(func(r uint64) { rates <- r }).Times(2)
+			sess.SetMaxSendRate(1 << 20)
+			Eventually(rates).Should(Receive(BeEquivalentTo(1 << 20)))
+			sess.SetMaxSendRate(0)
+			Eventually(rates).Should(Receive(BeZero()))
+		})
+
+		It("reports the algorithms selected by the congestion controller", func() {
+			sess.startAlgo = utils.ChooseAutoStart
+			sess.congestionAlgo = utils.ChooseAutoCongestion
+			runSession()
+			startAlgo, congestionAlgo := sess.algorithms()
+			Expect(startAlgo).To(Equal(utils.ChooseAutoStart))
+			Expect(congestionAlgo).To(Equal(utils.ChooseAutoCongestion))
+			sess.congestionConfig().OnAlgorithmsSelected(utils.ChooseSearch, utils.ChooseCubic)
+			startAlgo, congestionAlgo = sess.algorithms()
+			Expect(startAlgo).To(Equal(utils.ChooseSearch))
+			Expect(congestionAlgo).To(Equal(utils.ChooseCubic))
+		})
+
 		It("doesn't send packets if there's nothing to send", func() {
 			sess.handshakeConfirmed = true
 			runSession()
@@ -1560,6 +1751,35 @@ var _ = Describe("Session", func() {
 			Eventually(written, 2*pacingDelay).Should(HaveLen(2))
 		})
 
+		It("hands packets to the kernel ahead of the pacing deadline, when using kernel pacing", func() {
+			sess.kernelPacing = true
+			deadline := time.Now().Add(protocol.KernelPacingHorizon / 2)
+			sph.EXPECT().SendMode().Return(ackhandler.SendAny).AnyTimes()
+			gomock.InOrder(
+				sph.EXPECT().HasPacingBudget().Return(true),
+				packer.EXPECT().PackPacket().Return(getPacket(100), nil),
+				sph.EXPECT().SentPacket(gomock.Any()),
+				sender.EXPECT().Send(gomock.Any()),
+				sph.EXPECT().HasPacingBudget(),
+				sph.EXPECT().TimeUntilSend().Return(deadline),
+				packer.EXPECT().PackPacket().Return(getPacket(101), nil),
+				sph.EXPECT().SentPacket(gomock.Any()).Do(func(p *ackhandler.Packet) {
+					Expect(p.SendTime).To(Equal(deadline))
+				}),
+				sender.EXPECT().SendAt(gomock.Any(), deadline),
+				sph.EXPECT().HasPacingBudget(),
+				sph.EXPECT().TimeUntilSend().Return(time.Now().Add(time.Hour)),
+			)
+			sender.EXPECT().WouldBlock().AnyTimes()
+			go func() {
+				defer GinkgoRecover()
+				cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
+				sess.run()
+			}()
+			sess.scheduleSending()
+			time.Sleep(50 * time.Millisecond) // make sure that only 2 packets are sent
+		})
+
 		It("sends multiple packets at once", func() {
 			sph.EXPECT().SentPacket(gomock.Any()).Times(3)
 			sph.EXPECT().HasPacingBudget().Return(true).Times(3)
@@ -1910,6 +2130,45 @@ var _ = Describe("Session", func() {
 		Eventually(sess.Context().Done()).Should(BeClosed())
 	})
 
+	It("counts the accepted session ticket when the handshake completes", func() {
+		sess.config.SessionTicketKeys = NewSessionTicketKeys([32]byte{1}, [32]byte{2})
+		packer.EXPECT().PackCoalescedPacket().AnyTimes()
+		finishHandshake := make(chan struct{})
+		sessionRunner.EXPECT().Retire(clientDestConnID)
+		var state handshake.ConnectionState
+		state.DidResume = true
+		state.Used0RTT = true
+		go func() {
+			defer GinkgoRecover()
+			<-finishHandshake
+			cryptoSetup.EXPECT().RunHandshake()
+			cryptoSetup.EXPECT().SetHandshakeConfirmed()
+			cryptoSetup.EXPECT().ConnectionState().Return(state)
+			cryptoSetup.EXPECT().SessionTicketKeyName().Return(SessionTicketKeyName([32]byte{2}), true)
+			cryptoSetup.EXPECT().GetSessionTicket()
+			close(sess.handshakeCompleteChan)
+			sess.run()
+		}()
+		handshakeCtx := sess.HandshakeComplete()
+		close(finishHandshake)
+		Eventually(handshakeCtx.Done()).Should(BeClosed())
+		stats := sess.config.SessionTicketKeys.Stats()
+		Expect(stats).To(HaveLen(2))
+		Expect(stats[0].Accepted).To(BeZero())
+		Expect(stats[1].Accepted).To(BeEquivalentTo(1))
+		Expect(stats[1].Accepted0RTT).To(BeEquivalentTo(1))
+		// make sure the go routine returns
+		streamManager.EXPECT().CloseWithError(gomock.Any())
+		expectReplaceWithClosed()
+		packer.EXPECT().PackApplicationClose(gomock.Any()).Return(&coalescedPacket{buffer: getPacketBuffer()}, nil)
+		cryptoSetup.EXPECT().Close()
+		mconn.EXPECT().Write(gomock.Any())
+		tracer.EXPECT().ClosedConnection(gomock.Any())
+		tracer.EXPECT().Close()
+		sess.shutdown()
+		Eventually(sess.Context().Done()).Should(BeClosed())
+	})
+
 	It("sends a session ticket when the handshake completes", func() {
 		const size = protocol.MaxPostHandshakeCryptoFrameSize * 3 / 2
 		packer.EXPECT().PackCoalescedPacket().AnyTimes()
@@ -1990,7 +2249,7 @@ var _ = Describe("Session", func() {
 		done := make(chan struct{})
 		sessionRunner.EXPECT().Retire(clientDestConnID)
 		packer.EXPECT().PackPacket().DoAndReturn(func() (*packedPacket, error) {
-			frames, _ := sess.framer.AppendControlFrames(nil, protocol.MaxByteCount)
+			frames, _ := sess.framer.AppendControlFrames(nil, 1000)
 			Expect(frames).ToNot(BeEmpty())
 			Expect(frames[0].Frame).To(BeEquivalentTo(&wire.HandshakeDoneFrame{}))
 			defer close(done)
@@ -2084,6 +2343,7 @@ var _ = Describe("Session", func() {
 			sessionRunner.EXPECT().GetStatelessResetToken(gomock.Any()).Times(2)
 			sessionRunner.EXPECT().Add(gomock.Any(), sess).Times(2)
 			tracer.EXPECT().ReceivedTransportParameters(params)
+			tracer.EXPECT().ReceivedFlowControlUpdate(logging.InvalidStreamID, protocol.ByteCount(0x5000), time.Duration(0))
 			sess.handleTransportParameters(params)
 			Expect(sess.earlySessionReady()).To(BeClosed())
 		})
@@ -2152,6 +2412,20 @@ var _ = Describe("Session", func() {
 			Eventually(sent).Should(BeClosed())
 		})
 
+		It("sends a PING after the configured keep-alive period", func() {
+			sess.config.MaxIdleTimeout = time.Hour
+			sess.config.KeepAlivePeriod = 5 * time.Second
+			setRemoteIdleTimeout(time.Hour)
+			sess.lastPacketReceivedTime = time.Now().Add(-5 * time.Second).Add(-time.Millisecond)
+			sent := make(chan struct{})
+			packer.EXPECT().PackCoalescedPacket().Do(func() (*packedPacket, error) {
+				close(sent)
+				return nil, nil
+			})
+			runSession()
+			Eventually(sent).Should(BeClosed())
+		})
+
 		It("doesn't send a PING packet if keep-alive is disabled", func() {
 			setRemoteIdleTimeout(5 * time.Second)
 			sess.config.KeepAlive = false
@@ -2161,6 +2435,38 @@ var _ = Describe("Session", func() {
 			time.Sleep(50 * time.Millisecond)
 		})
 
+		It("sends a PING when no RTT sample was taken for the RTT probe interval", func() {
+			sess.config.KeepAlive = false
+			sess.config.RTTProbeInterval = time.Second
+			setRemoteIdleTimeout(5 * time.Second)
+			sess.rttStats.UpdateRTT(10*time.Millisecond, 0, time.Now().Add(-time.Second))
+			sent := make(chan struct{})
+			packer.EXPECT().PackCoalescedPacket().Do(func() (*packedPacket, error) {
+				close(sent)
+				return nil, nil
+			})
+			runSession()
+			Eventually(sent).Should(BeClosed())
+		})
+
+		It("sends RTT probes at most once per interval", func() {
+			sess.config.KeepAlive = false
+			sess.config.RTTProbeInterval = time.Second
+			now := time.Now()
+			Expect(sess.nextRTTProbeTime()).To(BeZero())
+			sess.rttStats.UpdateRTT(10*time.Millisecond, 0, now)
+			Expect(sess.nextRTTProbeTime()).To(Equal(now.Add(time.Second)))
+			sess.lastRTTProbeTime = now.Add(time.Second)
+			Expect(sess.nextRTTProbeTime()).To(Equal(now.Add(2 * time.Second)))
+			sess.rttStats.UpdateRTT(10*time.Millisecond, 0, now.Add(1500*time.Millisecond))
+			Expect(sess.nextRTTProbeTime()).To(Equal(now.Add(2500 * time.Millisecond)))
+			sess.config.RTTProbeInterval = 0
+			Expect(sess.nextRTTProbeTime()).To(BeZero())
+			runSession()
+			// don't EXPECT() any calls to mconn.Write()
+			time.Sleep(50 * time.Millisecond)
+		})
+
 		It("doesn't send a PING if the handshake isn't completed yet", func() {
 			sess.config.HandshakeIdleTimeout = time.Hour
 			sess.handshakeComplete = false
@@ -2173,6 +2479,41 @@ var _ = Describe("Session", func() {
 		})
 	})
 
+	Context("suspension", func() {
+		It("shifts the timers when the system was suspended", func() {
+			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sess.sentPacketHandler = sph
+			now := time.Now()
+			sess.lastPacketReceivedTime = now.Add(-time.Hour)
+			sess.suspendDetector = newSuspendDetector(time.Second, now.Add(-time.Hour))
+			var clockJump time.Duration
+			sph.EXPECT().OnSuspend(gomock.Any(), false).Do(func(d time.Duration, _ bool) { clockJump = d })
+			tracer.EXPECT().DetectedSuspend(gomock.Any())
+			sess.maybeDetectSuspend(now.Add(-time.Hour).Add(time.Minute))
+			Expect(clockJump).To(BeNumerically("~", 59*time.Minute, time.Second))
+			Expect(sess.lastPacketReceivedTime).To(Equal(now.Add(-time.Hour).Add(clockJump)))
+		})
+
+		It("probes the path after a suspension", func() {
+			sph := mockackhandler.NewMockSentPacketHandler(mockCtrl)
+			sess.sentPacketHandler = sph
+			sess.config.ProbeAfterSuspend = true
+			sess.handshakeComplete = true
+			sess.suspendDetector = newSuspendDetector(time.Second, time.Now().Add(-time.Hour))
+			sph.EXPECT().OnSuspend(gomock.Any(), true)
+			tracer.EXPECT().DetectedSuspend(gomock.Any())
+			sess.maybeDetectSuspend(time.Now().Add(-time.Hour))
+			frames, _ := sess.framer.AppendControlFrames(nil, 1000)
+			Expect(frames).To(HaveLen(1))
+			Expect(frames[0].Frame).To(Equal(&wire.PingFrame{}))
+		})
+
+		It("doesn't detect suspensions if disabled", func() {
+			sess.suspendDetector = nil
+			sess.maybeDetectSuspend(time.Now().Add(-time.Hour))
+		})
+	})
+
 	Context("timeouts", func() {
 		BeforeEach(func() {
 			streamManager.EXPECT().CloseWithError(gomock.Any())
@@ -2227,6 +2568,29 @@ var _ = Describe("Session", func() {
 			Eventually(done).Should(BeClosed())
 		})
 
+		It("times out due to non-completed handshake, using the configured handshake timeout", func() {
+			sess.handshakeComplete = false
+			sess.config.HandshakeTimeout = time.Second
+			sess.sessionCreationTime = time.Now().Add(-2 * time.Second)
+			sessionRunner.EXPECT().Remove(gomock.Any()).Times(2)
+			cryptoSetup.EXPECT().Close()
+			gomock.InOrder(
+				tracer.EXPECT().ClosedConnection(gomock.Any()).Do(func(e error) {
+					Expect(e).To(MatchError(&HandshakeTimeoutError{}))
+				}),
+				tracer.EXPECT().Close(),
+			)
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
+				err := sess.run()
+				Expect(err).To(MatchError(qerr.ErrHandshakeTimeout))
+				close(done)
+			}()
+			Eventually(done).Should(BeClosed())
+		})
+
 		It("does not use the idle timeout before the handshake complete", func() {
 			sess.handshakeComplete = false
 			sess.config.HandshakeIdleTimeout = 9999 * time.Second
@@ -2414,6 +2778,18 @@ var _ = Describe("Session", func() {
 	It("returns the remote address", func() {
 		Expect(sess.RemoteAddr()).To(Equal(remoteAddr))
 	})
+
+	It("reports the smoothed RTT in the ConnectionState", func() {
+		var rtts []time.Duration
+		sess.config.OnRTTUpdate = func(smoothed, _, _ time.Duration) { rtts = append(rtts, smoothed) }
+		sess.peerParams = &wire.TransportParameters{}
+		cryptoSetup.EXPECT().ConnectionState().AnyTimes()
+		Expect(sess.ConnectionState().SmoothedRTT).To(BeZero())
+		sess.rttStats.UpdateRTT(50*time.Millisecond, 0, time.Now())
+		Expect(sess.ConnectionState().SmoothedRTT).To(Equal(50 * time.Millisecond))
+		Expect(sess.ConnectionState().HandshakeRTT).To(BeZero())
+		Expect(rtts).To(Equal([]time.Duration{50 * time.Millisecond}))
+	})
 })
 
 var _ = Describe("Client Session", func() {
@@ -2465,6 +2841,8 @@ var _ = Describe("Client Session", func() {
 		tracer.EXPECT().NegotiatedVersion(gomock.Any(), gomock.Any(), gomock.Any()).MaxTimes(1)
 		tracer.EXPECT().SentTransportParameters(gomock.Any())
 		tracer.EXPECT().UpdatedKeyFromTLS(gomock.Any(), gomock.Any()).AnyTimes()
+		tracer.EXPECT().SentDatagram(gomock.Any()).AnyTimes()
+		tracer.EXPECT().ReceivedDatagram(gomock.Any()).AnyTimes()
 		tracer.EXPECT().UpdatedCongestionState(gomock.Any())
 		sess = newClientSession(
 			mconn,
@@ -2476,9 +2854,12 @@ var _ = Describe("Client Session", func() {
 			42, // initial packet number
 			false,
 			false,
+			nil,
 			tracer,
 			1234,
 			utils.DefaultLogger,
+			utils.ChooseHystart,
+			utils.ChooseNewReno,
 			protocol.VersionTLS,
 		).(*session)
 		packer = NewMockPacker(mockCtrl)
@@ -2492,7 +2873,7 @@ var _ = Describe("Client Session", func() {
 		unpacker.EXPECT().Unpack(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(hdr *wire.Header, _ time.Time, data []byte) (*unpackedPacket, error) {
 			return &unpackedPacket{
 				encryptionLevel: protocol.Encryption1RTT,
-				hdr:             &wire.ExtendedHeader{Header: *hdr},
+				hdr:             &wire.ExtendedHeader{Header: *hdr, PacketNumberLen: protocol.PacketNumberLen2},
 				data:            []byte{0}, // one PADDING frame
 			}, nil
 		})
@@ -2514,6 +2895,7 @@ var _ = Describe("Client Session", func() {
 			},
 			PacketNumberLen: protocol.PacketNumberLen2,
 		}, []byte("foobar"))
+		tracer.EXPECT().ReceivedPlaintextPacket(gomock.Any(), gomock.Any())
 		tracer.EXPECT().ReceivedPacket(gomock.Any(), p.Size(), []logging.Frame{})
 		Expect(sess.handlePacketImpl(p)).To(BeTrue())
 		// make sure the go routine returns
@@ -2527,6 +2909,102 @@ var _ = Describe("Client Session", func() {
 		Eventually(sess.Context().Done()).Should(BeClosed())
 	})
 
+	Context("first flight timeout", func() {
+		BeforeEach(func() {
+			quicConf.FirstFlightTimeout = time.Second
+		})
+
+		It("times out if the server doesn't respond", func() {
+			sess.sessionCreationTime = time.Now().Add(-2 * time.Second)
+			clientHelloWritten := make(chan *wire.TransportParameters, 1)
+			clientHelloWritten <- nil
+			sess.clientHelloWritten = clientHelloWritten
+			packer.EXPECT().PackCoalescedPacket().AnyTimes()
+			sessionRunner.EXPECT().Remove(gomock.Any()).AnyTimes()
+			cryptoSetup.EXPECT().Close()
+			gomock.InOrder(
+				tracer.EXPECT().ClosedConnection(gomock.Any()).Do(func(e error) {
+					Expect(e).To(MatchError(&FirstFlightTimeoutError{}))
+				}),
+				tracer.EXPECT().Close(),
+			)
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
+				err := sess.run()
+				nerr, ok := err.(net.Error)
+				Expect(ok).To(BeTrue())
+				Expect(nerr.Timeout()).To(BeTrue())
+				Expect(err).To(MatchError(qerr.ErrFirstFlightTimeout))
+				close(done)
+			}()
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("doesn't apply once the first packet from the server was received", func() {
+			Expect(sess.firstFlightDeadline()).To(Equal(sess.sessionCreationTime.Add(time.Second)))
+			sess.receivedFirstPacket = true
+			Expect(sess.firstFlightDeadline()).To(BeZero())
+		})
+	})
+
+	Context("blackhole detection", func() {
+		BeforeEach(func() {
+			quicConf.EnableBlackholeDetection = true
+		})
+
+		It("advises a fallback if the server doesn't respond", func() {
+			sess.firstFlightPTOs = protocol.BlackholeDetectionPTOs
+			clientHelloWritten := make(chan *wire.TransportParameters, 1)
+			clientHelloWritten <- nil
+			sess.clientHelloWritten = clientHelloWritten
+			packer.EXPECT().PackCoalescedPacket().AnyTimes()
+			sessionRunner.EXPECT().Remove(gomock.Any()).AnyTimes()
+			cryptoSetup.EXPECT().Close()
+			gomock.InOrder(
+				tracer.EXPECT().ClosedConnection(gomock.Any()).Do(func(e error) {
+					Expect(e).To(MatchError(&FallbackAdvisedError{}))
+				}),
+				tracer.EXPECT().Close(),
+			)
+			done := make(chan struct{})
+			go func() {
+				defer GinkgoRecover()
+				cryptoSetup.EXPECT().RunHandshake().MaxTimes(1)
+				err := sess.run()
+				nerr, ok := err.(net.Error)
+				Expect(ok).To(BeTrue())
+				Expect(nerr.Timeout()).To(BeTrue())
+				Expect(err).To(MatchError(qerr.ErrFallbackAdvised))
+				close(done)
+			}()
+			Eventually(done).Should(BeClosed())
+		})
+
+		It("only detects a blackhole after multiple PTOs", func() {
+			sess.firstFlightPTOs = protocol.BlackholeDetectionPTOs - 1
+			Expect(sess.detectedBlackhole()).To(BeFalse())
+			sess.firstFlightPTOs++
+			Expect(sess.detectedBlackhole()).To(BeTrue())
+		})
+
+		It("doesn't detect a blackhole once a packet from the server was received", func() {
+			sess.firstFlightPTOs = protocol.BlackholeDetectionPTOs
+			sess.receivedFirstPacket = true
+			Expect(sess.detectedBlackhole()).To(BeFalse())
+			sess.receivedFirstPacket = false
+			sess.receivedRetry = true
+			Expect(sess.detectedBlackhole()).To(BeFalse())
+		})
+
+		It("doesn't detect a blackhole if not enabled", func() {
+			sess.config.EnableBlackholeDetection = false
+			sess.firstFlightPTOs = protocol.BlackholeDetectionPTOs
+			Expect(sess.detectedBlackhole()).To(BeFalse())
+		})
+	})
+
 	It("continues accepting Long Header packets after using a new connection ID", func() {
 		unpacker := NewMockUnpacker(mockCtrl)
 		sess.unpacker = unpacker
@@ -2540,7 +3018,7 @@ var _ = Describe("Client Session", func() {
 		// now receive a packet with the original source connection ID
 		unpacker.EXPECT().Unpack(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(hdr *wire.Header, _ time.Time, _ []byte) (*unpackedPacket, error) {
 			return &unpackedPacket{
-				hdr:             &wire.ExtendedHeader{Header: *hdr},
+				hdr:             &wire.ExtendedHeader{Header: *hdr, PacketNumberLen: protocol.PacketNumberLen2},
 				data:            []byte{0},
 				encryptionLevel: protocol.EncryptionHandshake,
 			}, nil
@@ -2551,6 +3029,7 @@ var _ = Describe("Client Session", func() {
 			DestConnectionID: srcConnID,
 			SrcConnectionID:  destConnID,
 		}
+		tracer.EXPECT().ReceivedPlaintextPacket(gomock.Any(), gomock.Any())
 		tracer.EXPECT().ReceivedPacket(gomock.Any(), gomock.Any(), gomock.Any())
 		Expect(sess.handleSinglePacket(&receivedPacket{buffer: getPacketBuffer()}, hdr)).To(BeTrue())
 	})
@@ -2962,6 +3441,7 @@ var _ = Describe("Client Session", func() {
 				hdr:             hdr1,
 				data:            []byte{0}, // one PADDING frame
 			}, nil)
+			tracer.EXPECT().ReceivedPlaintextPacket(gomock.Any(), gomock.Any())
 			tracer.EXPECT().ReceivedPacket(gomock.Any(), gomock.Any(), gomock.Any())
 			Expect(sess.handlePacketImpl(getPacket(hdr1, nil))).To(BeTrue())
 			// The next packet has to be ignored, since the source connection ID doesn't match.
@@ -2990,6 +3470,7 @@ var _ = Describe("Client Session", func() {
 		It("fails on Initial-level ACK for unsent packet", func() {
 			ack := &wire.AckFrame{AckRanges: []wire.AckRange{{Smallest: 2, Largest: 2}}}
 			initialPacket := testutils.ComposeInitialPacket(destConnID, srcConnID, sess.version, destConnID, []wire.Frame{ack})
+			tracer.EXPECT().ReceivedPlaintextPacket(gomock.Any(), gomock.Any())
 			tracer.EXPECT().ReceivedPacket(gomock.Any(), gomock.Any(), gomock.Any())
 			Expect(sess.handlePacketImpl(wrapPacket(initialPacket))).To(BeFalse())
 		})
@@ -3002,6 +3483,7 @@ var _ = Describe("Client Session", func() {
 				ReasonPhrase:       "mitm attacker",
 			}
 			initialPacket := testutils.ComposeInitialPacket(destConnID, srcConnID, sess.version, destConnID, []wire.Frame{connCloseFrame})
+			tracer.EXPECT().ReceivedPlaintextPacket(gomock.Any(), gomock.Any())
 			tracer.EXPECT().ReceivedPacket(gomock.Any(), gomock.Any(), gomock.Any())
 			Expect(sess.handlePacketImpl(wrapPacket(initialPacket))).To(BeTrue())
 		})
@@ -0,0 +1,220 @@
+package quic
+
+import (
+	"crypto/sha512"
+	"crypto/tls"
+	"sync"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+// SessionTicketKeys manages the keys that a server uses to encrypt and decrypt session tickets.
+// Servers that use the same keys accept each other's session tickets,
+// so that session resumption and 0-RTT keep working in a server fleet, e.g. while servers are restarted during a deploy.
+//
+// New session tickets are encrypted with the primary key.
+// Session tickets encrypted with the primary key or with one of the fallback keys are accepted.
+// Keys that are not used any more should be removed, since they protect the forward secrecy of resumed sessions.
+//
+// If the SessionTicketKeys is set in the Config, the keys are installed on the tls.Config passed to Listen,
+// using tls.Config.SetSessionTicketKeys. Keys set on a tls.Config returned by GetConfigForClient are not managed.
+type SessionTicketKeys struct {
+	mutex sync.Mutex
+	// the primary key comes first
+	keys     []*sessionTicketKey
+	tlsConfs map[*tls.Config]struct{}
+
+	stopRotation chan struct{}
+	rotationDone chan struct{}
+
+	logger utils.Logger
+}
+
+type sessionTicketKey struct {
+	key  [32]byte
+	name [16]byte
+
+	accepted     uint64
+	accepted0RTT uint64
+}
+
+// SessionTicketKeyStats contains statistics about a session ticket key.
+type SessionTicketKeyStats struct {
+	// Name is the name of the key, which is sent at the beginning of every session ticket encrypted with the key.
+	// It is derived from the key, but doesn't reveal the key.
+	Name    [16]byte
+	Primary bool
+	// Accepted is the number of session tickets encrypted with this key that were accepted.
+	Accepted uint64
+	// Accepted0RTT is the number of those resumed sessions that accepted 0-RTT data.
+	Accepted0RTT uint64
+}
+
+// NewSessionTicketKeys creates a new SessionTicketKeys.
+func NewSessionTicketKeys(primary [32]byte, fallback ...[32]byte) *SessionTicketKeys {
+	k := &SessionTicketKeys{
+		tlsConfs: make(map[*tls.Config]struct{}),
+		logger:   utils.DefaultLogger.WithPrefix("session ticket keys"),
+	}
+	k.SetKeys(primary, fallback...)
+	return k
+}
+
+// SessionTicketKeyName returns the name of a session ticket key, as reported in the SessionTicketKeyStats.
+func SessionTicketKeyName(key [32]byte) [16]byte {
+	// This is how crypto/tls derives the name of the key.
+	hash := sha512.Sum512(key[:])
+	var name [16]byte
+	copy(name[:], hash[:16])
+	return name
+}
+
+// SetKeys replaces the keys.
+// The statistics of keys that were already used are kept.
+func (k *SessionTicketKeys) SetKeys(primary [32]byte, fallback ...[32]byte) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	k.setKeysLocked(append([][32]byte{primary}, fallback...))
+}
+
+// Rotate makes key the primary key.
+// The previous primary key becomes the first fallback key, so that session tickets encrypted with it are still accepted.
+// The number of fallback keys is kept (but there's at least one fallback key after the rotation), so the oldest fallback key is removed.
+func (k *SessionTicketKeys) Rotate(key [32]byte) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	numKeys := utils.Max(len(k.keys), 2)
+	keys := [][32]byte{key}
+	for _, old := range k.keys {
+		if len(keys) == numKeys {
+			break
+		}
+		keys = append(keys, old.key)
+	}
+	k.setKeysLocked(keys)
+}
+
+func (k *SessionTicketKeys) setKeysLocked(keys [][32]byte) {
+	old := make(map[[32]byte]*sessionTicketKey, len(k.keys))
+	for _, key := range k.keys {
+		old[key.key] = key
+	}
+	k.keys = make([]*sessionTicketKey, 0, len(keys))
+	for _, key := range keys {
+		if ticketKey, ok := old[key]; ok {
+			k.keys = append(k.keys, ticketKey)
+			continue
+		}
+		k.keys = append(k.keys, &sessionTicketKey{key: key, name: SessionTicketKeyName(key)})
+	}
+	for conf := range k.tlsConfs {
+		conf.SetSessionTicketKeys(keys)
+	}
+}
+
+// StartRotation rotates the keys every period.
+// The rotations are aligned to multiples of the period, so that all servers rotate their keys at the same time
+// (assuming that their clocks are synchronized).
+// getKey is called with the time of the rotation, and returns the new primary key.
+// Servers can use the time to derive the key from a shared secret, or to fetch the key from a key server.
+// If it returns an error, the keys are not rotated.
+// Calling StartRotation again replaces the previous rotation schedule.
+func (k *SessionTicketKeys) StartRotation(period time.Duration, getKey func(time.Time) ([32]byte, error)) {
+	k.StopRotation()
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	k.stopRotation = make(chan struct{})
+	k.rotationDone = make(chan struct{})
+	go k.runRotation(period, getKey, k.stopRotation, k.rotationDone)
+}
+
+func (k *SessionTicketKeys) runRotation(period time.Duration, getKey func(time.Time) ([32]byte, error), stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	for {
+		next := time.Now().Truncate(period).Add(period)
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		key, err := getKey(next)
+		if err != nil {
+			k.logger.Errorf("Not rotating the session ticket keys: %s", err)
+			continue
+		}
+		k.Rotate(key)
+	}
+}
+
+// StopRotation stops the rotation started by StartRotation.
+func (k *SessionTicketKeys) StopRotation() {
+	k.mutex.Lock()
+	stop := k.stopRotation
+	done := k.rotationDone
+	k.stopRotation = nil
+	k.rotationDone = nil
+	k.mutex.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// Stats returns the statistics of the keys, starting with the primary key.
+func (k *SessionTicketKeys) Stats() []SessionTicketKeyStats {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	stats := make([]SessionTicketKeyStats, 0, len(k.keys))
+	for i, key := range k.keys {
+		stats = append(stats, SessionTicketKeyStats{
+			Name:         key.name,
+			Primary:      i == 0,
+			Accepted:     key.accepted,
+			Accepted0RTT: key.accepted0RTT,
+		})
+	}
+	return stats
+}
+
+func (k *SessionTicketKeys) addTLSConfig(conf *tls.Config) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	keys := make([][32]byte, 0, len(k.keys))
+	for _, key := range k.keys {
+		keys = append(keys, key.key)
+	}
+	conf.SetSessionTicketKeys(keys)
+	k.tlsConfs[conf] = struct{}{}
+}
+
+func (k *SessionTicketKeys) removeTLSConfig(conf *tls.Config) {
+	k.mutex.Lock()
+	delete(k.tlsConfs, conf)
+	k.mutex.Unlock()
+}
+
+// onSessionTicketAccepted is called when a session was resumed using a session ticket encrypted with the key called name.
+func (k *SessionTicketKeys) onSessionTicketAccepted(name [16]byte, used0RTT bool) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	for _, key := range k.keys {
+		if key.name != name {
+			continue
+		}
+		key.accepted++
+		if used0RTT {
+			key.accepted0RTT++
+		}
+		return
+	}
+}
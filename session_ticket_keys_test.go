@@ -0,0 +1,152 @@
+package quic
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/testdata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Session Ticket Keys", func() {
+	names := func(k *SessionTicketKeys) [][16]byte {
+		var names [][16]byte
+		for _, s := range k.Stats() {
+			names = append(names, s.Name)
+		}
+		return names
+	}
+
+	It("reports the keys, starting with the primary key", func() {
+		k := NewSessionTicketKeys([32]byte{1}, [32]byte{2}, [32]byte{3})
+		stats := k.Stats()
+		Expect(stats).To(HaveLen(3))
+		Expect(stats[0].Primary).To(BeTrue())
+		Expect(stats[1].Primary).To(BeFalse())
+		Expect(stats[2].Primary).To(BeFalse())
+		Expect(names(k)).To(Equal([][16]byte{
+			SessionTicketKeyName([32]byte{1}),
+			SessionTicketKeyName([32]byte{2}),
+			SessionTicketKeyName([32]byte{3}),
+		}))
+	})
+
+	It("counts the accepted session tickets", func() {
+		k := NewSessionTicketKeys([32]byte{1}, [32]byte{2})
+		k.onSessionTicketAccepted(SessionTicketKeyName([32]byte{2}), false)
+		k.onSessionTicketAccepted(SessionTicketKeyName([32]byte{2}), true)
+		k.onSessionTicketAccepted(SessionTicketKeyName([32]byte{1}), false)
+		k.onSessionTicketAccepted(SessionTicketKeyName([32]byte{42}), true) // unknown key
+		stats := k.Stats()
+		Expect(stats[0].Accepted).To(BeEquivalentTo(1))
+		Expect(stats[0].Accepted0RTT).To(BeZero())
+		Expect(stats[1].Accepted).To(BeEquivalentTo(2))
+		Expect(stats[1].Accepted0RTT).To(BeEquivalentTo(1))
+	})
+
+	It("keeps the statistics of keys when setting the keys", func() {
+		k := NewSessionTicketKeys([32]byte{1}, [32]byte{2})
+		k.onSessionTicketAccepted(SessionTicketKeyName([32]byte{2}), false)
+		k.SetKeys([32]byte{2}, [32]byte{3})
+		stats := k.Stats()
+		Expect(stats).To(HaveLen(2))
+		Expect(stats[0].Name).To(Equal(SessionTicketKeyName([32]byte{2})))
+		Expect(stats[0].Accepted).To(BeEquivalentTo(1))
+		Expect(stats[1].Accepted).To(BeZero())
+	})
+
+	It("rotates the keys", func() {
+		k := NewSessionTicketKeys([32]byte{1})
+		k.Rotate([32]byte{2})
+		Expect(names(k)).To(Equal([][16]byte{SessionTicketKeyName([32]byte{2}), SessionTicketKeyName([32]byte{1})}))
+		k.Rotate([32]byte{3})
+		Expect(names(k)).To(Equal([][16]byte{SessionTicketKeyName([32]byte{3}), SessionTicketKeyName([32]byte{2})}))
+		k.SetKeys([32]byte{3}, [32]byte{2}, [32]byte{1})
+		k.Rotate([32]byte{4})
+		Expect(names(k)).To(Equal([][16]byte{
+			SessionTicketKeyName([32]byte{4}),
+			SessionTicketKeyName([32]byte{3}),
+			SessionTicketKeyName([32]byte{2}),
+		}))
+	})
+
+	It("rotates the keys periodically", func() {
+		const period = 20 * time.Millisecond
+		k := NewSessionTicketKeys([32]byte{1})
+		var mutex sync.Mutex
+		var epochs []time.Time
+		k.StartRotation(period, func(epoch time.Time) ([32]byte, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			epochs = append(epochs, epoch)
+			if len(epochs) == 1 {
+				return [32]byte{}, errors.New("key server unavailable")
+			}
+			return [32]byte{byte(len(epochs))}, nil
+		})
+		Eventually(func() [16]byte { return k.Stats()[0].Name }).Should(Equal(SessionTicketKeyName([32]byte{3})))
+		k.StopRotation()
+		Expect(names(k)).To(Equal([][16]byte{SessionTicketKeyName([32]byte{3}), SessionTicketKeyName([32]byte{2})}))
+		mutex.Lock()
+		defer mutex.Unlock()
+		Expect(epochs).To(HaveLen(3))
+		for _, epoch := range epochs {
+			Expect(epoch.Truncate(period)).To(Equal(epoch))
+		}
+	})
+
+	Context("installing keys on the tls.Config", func() {
+		// resumes does a TLS handshake with the server, and says if the session was resumed.
+		resumes := func(clientConf, serverConf *tls.Config) bool {
+			c, s := net.Pipe()
+			defer c.Close()
+			defer s.Close()
+			go func() {
+				defer GinkgoRecover()
+				server := tls.Server(s, serverConf)
+				if err := server.Handshake(); err != nil {
+					return
+				}
+				server.Write([]byte("foobar"))
+			}()
+			client := tls.Client(c, clientConf)
+			Expect(client.Handshake()).To(Succeed())
+			// make the client receive the session ticket
+			_, err := client.Read(make([]byte, 6))
+			Expect(err).ToNot(HaveOccurred())
+			return client.ConnectionState().DidResume
+		}
+
+		It("resumes session tickets issued by another server", func() {
+			k := NewSessionTicketKeys([32]byte{1})
+			serverConf1 := testdata.GetTLSConfig()
+			serverConf2 := testdata.GetTLSConfig()
+			k.addTLSConfig(serverConf1)
+			k.addTLSConfig(serverConf2)
+			clientConf := &tls.Config{
+				RootCAs:            testdata.GetRootCA(),
+				ServerName:         "localhost",
+				ClientSessionCache: tls.NewLRUClientSessionCache(10),
+				MinVersion:         tls.VersionTLS13,
+			}
+			Expect(resumes(clientConf, serverConf1)).To(BeFalse())
+			Expect(resumes(clientConf, serverConf2)).To(BeTrue())
+			// The old primary key is still accepted after a rotation.
+			k.Rotate([32]byte{2})
+			Expect(resumes(clientConf, serverConf1)).To(BeTrue())
+			// Once the key is removed, the ticket is not accepted any more.
+			k.SetKeys([32]byte{3})
+			Expect(resumes(clientConf, serverConf2)).To(BeFalse())
+			// The server doesn't install the keys any more after it was removed.
+			k.removeTLSConfig(serverConf2)
+			k.SetKeys([32]byte{4})
+			Expect(resumes(clientConf, serverConf1)).To(BeFalse())
+			Expect(resumes(clientConf, serverConf2)).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,74 @@
+package quic
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+// The timers of a session.
+type sessionTimer uint8
+
+const (
+	// handshake timeout and handshake idle timeout
+	timerHandshake sessionTimer = iota
+	timerIdle
+	timerKeepAlive
+	timerRTTProbe
+	timerMTUProbe
+	timerAck
+	timerLossDetection
+	timerPacing
+	timerCompaction
+	timerDeliveryRate
+	numSessionTimers
+)
+
+// sessionTimers multiplexes all timers of a session onto a single runtime timer.
+// A session only has a small, fixed number of timers, so the earliest deadline is found by scanning all of them.
+// It must only be used from the session's run loop.
+type sessionTimers struct {
+	timer     *utils.Timer
+	deadlines [numSessionTimers]time.Time
+}
+
+func newSessionTimers() *sessionTimers {
+	return &sessionTimers{timer: utils.NewTimer()}
+}
+
+// Set sets the deadline of a timer.
+// A zero deadline disables the timer.
+// The runtime timer is only updated when Reset is called.
+func (t *sessionTimers) Set(timer sessionTimer, deadline time.Time) {
+	t.deadlines[timer] = deadline
+}
+
+// Deadline returns the earliest deadline of all timers.
+// It returns a zero time if no timer is set.
+func (t *sessionTimers) Deadline() time.Time {
+	var deadline time.Time
+	for _, d := range t.deadlines {
+		deadline = utils.MinNonZeroTime(deadline, d)
+	}
+	return deadline
+}
+
+// Reset sets the runtime timer to the earliest deadline.
+func (t *sessionTimers) Reset() {
+	t.timer.Reset(t.Deadline())
+}
+
+// Chan returns the channel of the runtime timer.
+func (t *sessionTimers) Chan() <-chan time.Time {
+	return t.timer.Chan()
+}
+
+// SetRead should be called after the value from the chan was read.
+func (t *sessionTimers) SetRead() {
+	t.timer.SetRead()
+}
+
+// Stop stops the runtime timer.
+func (t *sessionTimers) Stop() {
+	t.timer.Stop()
+}
@@ -0,0 +1,48 @@
+package quic
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Session Timers", func() {
+	var timers *sessionTimers
+
+	BeforeEach(func() {
+		timers = newSessionTimers()
+	})
+
+	AfterEach(func() {
+		timers.Stop()
+	})
+
+	It("doesn't have a deadline if no timer is set", func() {
+		Expect(timers.Deadline()).To(BeZero())
+	})
+
+	It("returns the earliest deadline", func() {
+		now := time.Now()
+		timers.Set(timerIdle, now.Add(time.Hour))
+		timers.Set(timerAck, now.Add(time.Second))
+		timers.Set(timerPacing, now.Add(time.Minute))
+		Expect(timers.Deadline()).To(Equal(now.Add(time.Second)))
+	})
+
+	It("ignores disabled timers", func() {
+		now := time.Now()
+		timers.Set(timerIdle, now.Add(time.Hour))
+		timers.Set(timerAck, now.Add(time.Second))
+		timers.Set(timerAck, time.Time{})
+		Expect(timers.Deadline()).To(Equal(now.Add(time.Hour)))
+	})
+
+	It("fires at the earliest deadline", func() {
+		timers.Set(timerIdle, time.Now().Add(time.Hour))
+		timers.Set(timerLossDetection, time.Now().Add(scaleDuration(10*time.Millisecond)))
+		timers.Reset()
+		Eventually(timers.Chan()).Should(Receive())
+		timers.SetRead()
+	})
+})
@@ -0,0 +1,89 @@
+package quic
+
+import (
+	"runtime"
+	"sync"
+)
+
+// A sessionWorkerPool limits the number of sessions of a server (see Config.MaxSessions).
+// Every worker runs the run loop of one session at a time.
+// A worker has to be reserved before a new session is created,
+// so that connection attempts can be refused when the limit is reached.
+type sessionWorkerPool struct {
+	// one element for every reserved worker
+	reserved chan struct{}
+	sessions chan quicSession
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// newSessionWorkerPool starts numWorkers workers.
+// If affinity is set, every worker is locked to its OS thread,
+// and affinity is called on that thread with the index of the worker.
+// This only pins the run loops, not the other goroutines of the sessions.
+func newSessionWorkerPool(numWorkers int, affinity func(worker int)) *sessionWorkerPool {
+	p := &sessionWorkerPool{
+		reserved: make(chan struct{}, numWorkers),
+		sessions: make(chan quicSession, numWorkers),
+		closed:   make(chan struct{}),
+	}
+	for i := 0; i < numWorkers; i++ {
+		go p.runWorker(i, affinity)
+	}
+	return p
+}
+
+func (p *sessionWorkerPool) runWorker(worker int, affinity func(int)) {
+	if affinity != nil {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		affinity(worker)
+	}
+	for {
+		select {
+		case <-p.closed:
+			// Run the sessions that were queued before the pool was closed.
+			select {
+			case sess := <-p.sessions:
+				p.runSession(sess)
+			default:
+				return
+			}
+		case sess := <-p.sessions:
+			p.runSession(sess)
+		}
+	}
+}
+
+func (p *sessionWorkerPool) runSession(sess quicSession) {
+	sess.run()
+	<-p.reserved
+}
+
+// Reserve reserves a worker.
+// It returns false if all workers are busy.
+func (p *sessionWorkerPool) Reserve() bool {
+	select {
+	case p.reserved <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Release releases a worker that was reserved, but not used to run a session.
+func (p *sessionWorkerPool) Release() {
+	<-p.reserved
+}
+
+// Run runs a session on a worker that was reserved before.
+func (p *sessionWorkerPool) Run(sess quicSession) {
+	p.sessions <- sess
+}
+
+// Close stops all idle workers.
+// Workers that are running a session stop as soon as the session's run loop returns.
+func (p *sessionWorkerPool) Close() {
+	p.closeOnce.Do(func() { close(p.closed) })
+}
@@ -0,0 +1,69 @@
+package quic
+
+import (
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Session Worker Pool", func() {
+	It("limits the number of workers that can be reserved", func() {
+		p := newSessionWorkerPool(2, nil)
+		defer p.Close()
+		Expect(p.Reserve()).To(BeTrue())
+		Expect(p.Reserve()).To(BeTrue())
+		Expect(p.Reserve()).To(BeFalse())
+		p.Release()
+		Expect(p.Reserve()).To(BeTrue())
+	})
+
+	It("runs sessions, and frees the worker when the session's run loop returns", func() {
+		p := newSessionWorkerPool(1, nil)
+		defer p.Close()
+		sess := NewMockQuicSession(mockCtrl)
+		running := make(chan struct{})
+		done := make(chan struct{})
+		sess.EXPECT().run().Do(func() error {
+			close(running)
+			<-done
+			return nil
+		})
+		Expect(p.Reserve()).To(BeTrue())
+		p.Run(sess)
+		Eventually(running).Should(BeClosed())
+		Expect(p.Reserve()).To(BeFalse())
+		close(done)
+		Eventually(p.Reserve).Should(BeTrue())
+	})
+
+	It("calls the affinity callback for every worker", func() {
+		var mutex sync.Mutex
+		var workers []int
+		p := newSessionWorkerPool(3, func(worker int) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			workers = append(workers, worker)
+		})
+		defer p.Close()
+		Eventually(func() []int {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return workers
+		}).Should(ConsistOf(0, 1, 2))
+	})
+
+	It("runs queued sessions after being closed", func() {
+		p := newSessionWorkerPool(1, nil)
+		sess := NewMockQuicSession(mockCtrl)
+		done := make(chan struct{})
+		sess.EXPECT().run().Do(func() error {
+			close(done)
+			return nil
+		})
+		Expect(p.Reserve()).To(BeTrue())
+		p.Run(sess)
+		p.Close()
+		Eventually(done).Should(BeClosed())
+	})
+})
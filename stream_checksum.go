@@ -0,0 +1,201 @@
+package quic
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+// When Config.EnableStreamChecksums is set, the data written to a stream is split into chunks.
+// Every chunk is preceded by a header consisting of the length of the chunk and its CRC-32C checksum (both 4 bytes).
+// The receiver buffers every chunk, and only passes it to the application after validating the checksum.
+const (
+	checksumHeaderLen    = 8
+	maxChecksumChunkSize = 16 * 1024
+)
+
+var checksumTable = crc32.MakeTable(crc32.Castagnoli)
+
+type checksumWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+func (w *checksumWriter) Write(p []byte) (int, error) {
+	var n int
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > maxChecksumChunkSize {
+			chunk = chunk[:maxChecksumChunkSize]
+		}
+		if w.buf == nil {
+			w.buf = make([]byte, 0, checksumHeaderLen+maxChecksumChunkSize)
+		}
+		w.buf = w.buf[:checksumHeaderLen]
+		binary.BigEndian.PutUint32(w.buf[:4], uint32(len(chunk)))
+		binary.BigEndian.PutUint32(w.buf[4:], crc32.Checksum(chunk, checksumTable))
+		w.buf = append(w.buf, chunk...)
+		written, err := w.w.Write(w.buf)
+		if written > checksumHeaderLen {
+			n += written - checksumHeaderLen
+		}
+		if err != nil {
+			return n, err
+		}
+		p = p[len(chunk):]
+	}
+	return n, nil
+}
+
+type checksumReader struct {
+	r        io.Reader
+	streamID StreamID
+	logger   utils.Logger
+
+	header     [checksumHeaderLen]byte
+	headerRead int
+	chunk      []byte
+	chunkRead  int
+	validated  bool // if the checksum of the current chunk was validated
+	delivered  int  // number of bytes of the current chunk that were passed to the application
+
+	chunkNum uint64
+	offset   uint64 // offset of the current chunk in the application data
+	err      error
+}
+
+func (r *checksumReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	for {
+		if r.validated {
+			if r.delivered < len(r.chunk) {
+				n := copy(p, r.chunk[r.delivered:])
+				r.delivered += n
+				return n, nil
+			}
+			r.offset += uint64(len(r.chunk))
+			r.chunkNum++
+			r.headerRead = 0
+			r.chunkRead = 0
+			r.delivered = 0
+			r.validated = false
+		}
+		for r.headerRead < checksumHeaderLen {
+			n, err := r.r.Read(r.header[r.headerRead:])
+			r.headerRead += n
+			if r.headerRead == checksumHeaderLen {
+				break
+			}
+			if err == io.EOF && r.headerRead == 0 {
+				return 0, io.EOF
+			}
+			if err != nil {
+				return 0, r.readError(err)
+			}
+		}
+		if r.chunkRead == 0 {
+			length := binary.BigEndian.Uint32(r.header[:4])
+			if length > maxChecksumChunkSize {
+				r.err = fmt.Errorf("stream %d: chunk %d has an invalid length (%d bytes)", r.streamID, r.chunkNum, length)
+				r.logger.Errorf("Checksum validation failed: %s", r.err)
+				return 0, r.err
+			}
+			if cap(r.chunk) < int(length) {
+				r.chunk = make([]byte, length, maxChecksumChunkSize)
+			}
+			r.chunk = r.chunk[:length]
+		}
+		for r.chunkRead < len(r.chunk) {
+			n, err := r.r.Read(r.chunk[r.chunkRead:])
+			r.chunkRead += n
+			if r.chunkRead == len(r.chunk) {
+				break
+			}
+			if err != nil {
+				return 0, r.readError(err)
+			}
+		}
+		expected := binary.BigEndian.Uint32(r.header[4:])
+		if actual := crc32.Checksum(r.chunk, checksumTable); actual != expected {
+			r.err = &StreamChecksumError{
+				StreamID: r.streamID,
+				Chunk:    r.chunkNum,
+				Offset:   r.offset,
+				Length:   len(r.chunk),
+				Expected: expected,
+				Actual:   actual,
+			}
+			r.logger.Errorf("Checksum validation failed: %s. First bytes of the chunk: %x", r.err, r.chunk[:utils.Min(len(r.chunk), 16)])
+			return 0, r.err
+		}
+		r.validated = true
+	}
+}
+
+// readError handles an error returned by the underlying stream while a chunk is partially received.
+// Errors like deadline errors are returned to the application, which can resume reading later.
+// If the stream ends in the middle of a chunk, the stream data was truncated.
+func (r *checksumReader) readError(err error) error {
+	if err != io.EOF {
+		return err
+	}
+	r.err = fmt.Errorf("stream %d: stream ended in the middle of chunk %d: %w", r.streamID, r.chunkNum, io.ErrUnexpectedEOF)
+	r.logger.Errorf("Checksum validation failed: %s", r.err)
+	return r.err
+}
+
+type checksumStream struct {
+	Stream
+	w checksumWriter
+	r checksumReader
+}
+
+var _ Stream = &checksumStream{}
+
+func newChecksumStream(str Stream, logger utils.Logger) *checksumStream {
+	return &checksumStream{
+		Stream: str,
+		w:      checksumWriter{w: str},
+		r:      checksumReader{r: str, streamID: str.StreamID(), logger: logger},
+	}
+}
+
+func (s *checksumStream) Write(p []byte) (int, error) { return s.w.Write(p) }
+func (s *checksumStream) Read(p []byte) (int, error)  { return s.r.Read(p) }
+
+type checksumSendStream struct {
+	SendStream
+	w checksumWriter
+}
+
+var _ SendStream = &checksumSendStream{}
+
+func newChecksumSendStream(str SendStream) *checksumSendStream {
+	return &checksumSendStream{
+		SendStream: str,
+		w:          checksumWriter{w: str},
+	}
+}
+
+func (s *checksumSendStream) Write(p []byte) (int, error) { return s.w.Write(p) }
+
+type checksumReceiveStream struct {
+	ReceiveStream
+	r checksumReader
+}
+
+var _ ReceiveStream = &checksumReceiveStream{}
+
+func newChecksumReceiveStream(str ReceiveStream, logger utils.Logger) *checksumReceiveStream {
+	return &checksumReceiveStream{
+		ReceiveStream: str,
+		r:             checksumReader{r: str, streamID: str.StreamID(), logger: logger},
+	}
+}
+
+func (s *checksumReceiveStream) Read(p []byte) (int, error) { return s.r.Read(p) }
@@ -0,0 +1,120 @@
+package quic
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+
+	"github.com/lucas-clemente/quic-go/internal/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// stutteringReader returns at most 3 bytes per Read, and an error on every other Read.
+type stutteringReader struct {
+	r       io.Reader
+	err     error
+	numRead int
+}
+
+func (r *stutteringReader) Read(p []byte) (int, error) {
+	r.numRead++
+	if r.numRead%2 == 0 {
+		return 0, r.err
+	}
+	if len(p) > 3 {
+		p = p[:3]
+	}
+	return r.r.Read(p)
+}
+
+var _ = Describe("Stream checksums", func() {
+	var (
+		buf    *bytes.Buffer
+		writer *checksumWriter
+		reader *checksumReader
+	)
+
+	BeforeEach(func() {
+		buf = &bytes.Buffer{}
+		writer = &checksumWriter{w: buf}
+		reader = &checksumReader{r: buf, streamID: 42, logger: utils.DefaultLogger}
+	})
+
+	It("transfers data", func() {
+		data := make([]byte, 5*maxChecksumChunkSize/2)
+		for i := range data {
+			data[i] = byte(i)
+		}
+		n, err := writer.Write(data)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(n).To(Equal(len(data)))
+		_, err = writer.Write([]byte("foobar"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(buf.Len()).To(Equal(len(data) + 6 + 4*checksumHeaderLen))
+		b, err := ioutil.ReadAll(reader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(b).To(Equal(append(data, []byte("foobar")...)))
+	})
+
+	It("detects corrupted data", func() {
+		_, err := writer.Write(make([]byte, maxChecksumChunkSize+100))
+		Expect(err).ToNot(HaveOccurred())
+		buf.Bytes()[2*checksumHeaderLen+maxChecksumChunkSize+10] ^= 0xff
+		b := make([]byte, 2*maxChecksumChunkSize)
+		n, err := reader.Read(b)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(n).To(Equal(maxChecksumChunkSize))
+		_, err = reader.Read(b)
+		Expect(err).To(HaveOccurred())
+		var cerr *StreamChecksumError
+		Expect(errors.As(err, &cerr)).To(BeTrue())
+		Expect(cerr.StreamID).To(Equal(StreamID(42)))
+		Expect(cerr.Chunk).To(BeEquivalentTo(1))
+		Expect(cerr.Offset).To(BeEquivalentTo(maxChecksumChunkSize))
+		Expect(cerr.Length).To(Equal(100))
+		Expect(cerr.Expected).ToNot(Equal(cerr.Actual))
+		// all subsequent calls return the same error
+		_, err = reader.Read(b)
+		Expect(err).To(Equal(cerr))
+	})
+
+	It("detects chunks with an invalid length", func() {
+		buf.Write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0})
+		_, err := reader.Read(make([]byte, 100))
+		Expect(err).To(MatchError("stream 42: chunk 0 has an invalid length (4294967295 bytes)"))
+	})
+
+	It("detects truncated stream data", func() {
+		_, err := writer.Write([]byte("foobar"))
+		Expect(err).ToNot(HaveOccurred())
+		buf.Truncate(buf.Len() - 1)
+		_, err = reader.Read(make([]byte, 100))
+		Expect(err).To(MatchError(io.ErrUnexpectedEOF))
+	})
+
+	It("resumes reading after an error", func() {
+		_, err := writer.Write([]byte("foobar"))
+		Expect(err).ToNot(HaveOccurred())
+		testErr := errors.New("deadline exceeded")
+		reader.r = &stutteringReader{r: buf, err: testErr}
+		var numErrors int
+		var data []byte
+		for {
+			b := make([]byte, 100)
+			n, err := reader.Read(b)
+			data = append(data, b[:n]...)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				Expect(err).To(MatchError(testErr))
+				numErrors++
+			}
+		}
+		Expect(data).To(Equal([]byte("foobar")))
+		Expect(numErrors).To(BeNumerically(">", 2))
+	})
+})
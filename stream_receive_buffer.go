@@ -0,0 +1,107 @@
+package quic
+
+import (
+	"errors"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+// The streamReceiveBuffer is used instead of the frameSorter if Config.StreamReceiveBufferSize is set.
+// STREAM frames are copied into a contiguous ring buffer, and the frame's buffer is released right away.
+// Pop returns all contiguous data in one slice (unless it wraps around the end of the ring buffer).
+//
+// The size of the buffer must be at least the maximum stream flow control window:
+// Data popped from the buffer is only overwritten once it was read by the application,
+// i.e. once bytes read were reported to the flow controller and a window update was sent.
+type streamReceiveBuffer struct {
+	size    protocol.ByteCount
+	buf     []byte // allocated when the first frame is received
+	readPos protocol.ByteCount
+	gaps    *utils.ByteIntervalList
+}
+
+var _ streamFrameQueue = &streamReceiveBuffer{}
+
+func newStreamReceiveBuffer(size protocol.ByteCount) *streamReceiveBuffer {
+	s := streamReceiveBuffer{
+		size: size,
+		gaps: utils.NewByteIntervalList(),
+	}
+	s.gaps.PushFront(utils.ByteInterval{Start: 0, End: protocol.MaxByteCount})
+	return &s
+}
+
+func (s *streamReceiveBuffer) Push(data []byte, offset protocol.ByteCount, doneCb func()) error {
+	err := s.push(data, offset)
+	if doneCb != nil {
+		doneCb()
+	}
+	return err
+}
+
+func (s *streamReceiveBuffer) push(data []byte, offset protocol.ByteCount) error {
+	start := offset
+	end := offset + protocol.ByteCount(len(data))
+	if end <= s.readPos {
+		return nil
+	}
+	if start < s.readPos {
+		data = data[s.readPos-start:]
+		start = s.readPos
+	}
+	if end > s.readPos+s.size {
+		return errors.New("stream data exceeds the receive buffer")
+	}
+
+	if s.buf == nil {
+		s.buf = make([]byte, s.size)
+	}
+	// Only fill the gaps. Data that was already received is not overwritten.
+	var next *utils.ByteIntervalElement
+	for gap := s.gaps.Front(); gap != nil && gap.Value.Start < end; gap = next {
+		next = gap.Next()
+		if gap.Value.End <= start {
+			continue
+		}
+		fillStart := utils.MaxByteCount(start, gap.Value.Start)
+		fillEnd := utils.MinByteCount(end, gap.Value.End)
+		s.write(data[fillStart-start:fillEnd-start], fillStart)
+		switch {
+		case start <= gap.Value.Start && end >= gap.Value.End:
+			// The data covers the whole gap.
+			s.gaps.Remove(gap)
+		case start <= gap.Value.Start:
+			gap.Value.Start = end
+		case end >= gap.Value.End:
+			gap.Value.End = start
+		default:
+			// The data split the gap into two.
+			s.gaps.InsertAfter(utils.ByteInterval{Start: end, End: gap.Value.End}, gap)
+			gap.Value.End = start
+		}
+	}
+	if s.gaps.Len() > protocol.MaxStreamFrameSorterGaps {
+		return errors.New("too many gaps in received data")
+	}
+	return nil
+}
+
+// write copies data to the ring buffer, wrapping around at the end of the buffer.
+func (s *streamReceiveBuffer) write(data []byte, offset protocol.ByteCount) {
+	if n := copy(s.buf[offset%s.size:], data); n < len(data) {
+		copy(s.buf, data[n:])
+	}
+}
+
+func (s *streamReceiveBuffer) Pop() (protocol.ByteCount, []byte, func()) {
+	end := s.gaps.Front().Value.Start
+	if end == s.readPos {
+		return s.readPos, nil, nil
+	}
+	pos := s.readPos % s.size
+	n := utils.MinByteCount(end-s.readPos, s.size-pos)
+	offset := s.readPos
+	s.readPos += n
+	return offset, s.buf[pos : pos+n], nil
+}
@@ -0,0 +1,169 @@
+package quic
+
+import (
+	"bytes"
+	"math/rand"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Stream Receive Buffer", func() {
+	var s *streamReceiveBuffer
+
+	BeforeEach(func() {
+		s = newStreamReceiveBuffer(16)
+	})
+
+	checkGaps := func(expectedGaps []utils.ByteInterval) {
+		var gaps []utils.ByteInterval
+		for gap := s.gaps.Front(); gap != nil; gap = gap.Next() {
+			gaps = append(gaps, gap.Value)
+		}
+		ExpectWithOffset(1, gaps).To(Equal(expectedGaps))
+	}
+
+	It("returns nothing when empty", func() {
+		offset, data, doneCb := s.Pop()
+		Expect(offset).To(BeZero())
+		Expect(data).To(BeNil())
+		Expect(doneCb).To(BeNil())
+		Expect(s.buf).To(BeNil())
+	})
+
+	It("copies the data and releases the frame's buffer", func() {
+		var called bool
+		frame := []byte("foobar")
+		Expect(s.Push(frame, 0, func() { called = true })).To(Succeed())
+		Expect(called).To(BeTrue())
+		Expect(s.buf).To(HaveLen(16))
+		copy(frame, "xxxxxx")
+		offset, data, doneCb := s.Pop()
+		Expect(offset).To(BeZero())
+		Expect(data).To(Equal([]byte("foobar")))
+		Expect(doneCb).To(BeNil())
+		offset, data, _ = s.Pop()
+		Expect(offset).To(BeEquivalentTo(6))
+		Expect(data).To(BeNil())
+	})
+
+	It("pops all contiguous data at once", func() {
+		Expect(s.Push([]byte("foo"), 0, nil)).To(Succeed())
+		Expect(s.Push([]byte("bar"), 3, nil)).To(Succeed())
+		Expect(s.Push([]byte("baz"), 6, nil)).To(Succeed())
+		checkGaps([]utils.ByteInterval{{Start: 9, End: protocol.MaxByteCount}})
+		_, data, _ := s.Pop()
+		Expect(data).To(Equal([]byte("foobarbaz")))
+	})
+
+	It("reassembles out-of-order data", func() {
+		Expect(s.Push([]byte("baz"), 6, nil)).To(Succeed())
+		checkGaps([]utils.ByteInterval{
+			{Start: 0, End: 6},
+			{Start: 9, End: protocol.MaxByteCount},
+		})
+		Expect(s.Push([]byte("bar"), 3, nil)).To(Succeed())
+		checkGaps([]utils.ByteInterval{
+			{Start: 0, End: 3},
+			{Start: 9, End: protocol.MaxByteCount},
+		})
+		_, data, _ := s.Pop()
+		Expect(data).To(BeNil())
+		Expect(s.Push([]byte("foo"), 0, nil)).To(Succeed())
+		_, data, _ = s.Pop()
+		Expect(data).To(Equal([]byte("foobarbaz")))
+	})
+
+	It("splits a gap", func() {
+		Expect(s.Push([]byte("ob"), 2, nil)).To(Succeed())
+		Expect(s.Push([]byte("z"), 8, nil)).To(Succeed())
+		Expect(s.Push([]byte("r"), 5, nil)).To(Succeed())
+		checkGaps([]utils.ByteInterval{
+			{Start: 0, End: 2},
+			{Start: 4, End: 5},
+			{Start: 6, End: 8},
+			{Start: 9, End: protocol.MaxByteCount},
+		})
+		Expect(s.Push([]byte("foobarbaz"), 0, nil)).To(Succeed())
+		checkGaps([]utils.ByteInterval{{Start: 9, End: protocol.MaxByteCount}})
+		_, data, _ := s.Pop()
+		Expect(data).To(Equal([]byte("foobarbaz")))
+	})
+
+	It("doesn't overwrite data that was already received", func() {
+		Expect(s.Push([]byte("foo"), 0, nil)).To(Succeed())
+		Expect(s.Push([]byte("bar"), 4, nil)).To(Succeed())
+		Expect(s.Push([]byte("xxxxxxxx"), 0, nil)).To(Succeed())
+		_, data, _ := s.Pop()
+		Expect(data).To(Equal([]byte("fooxbarx")))
+	})
+
+	It("ignores data that was already popped", func() {
+		Expect(s.Push([]byte("foobar"), 0, nil)).To(Succeed())
+		_, data, _ := s.Pop()
+		Expect(data).To(Equal([]byte("foobar")))
+		var called bool
+		Expect(s.Push([]byte("foo"), 0, func() { called = true })).To(Succeed())
+		Expect(called).To(BeTrue())
+		Expect(s.Push([]byte("barbaz"), 3, nil)).To(Succeed())
+		offset, data, _ := s.Pop()
+		Expect(offset).To(BeEquivalentTo(6))
+		Expect(data).To(Equal([]byte("baz")))
+	})
+
+	It("wraps around at the end of the buffer", func() {
+		Expect(s.Push(bytes.Repeat([]byte{'a'}, 12), 0, nil)).To(Succeed())
+		_, data, _ := s.Pop()
+		Expect(data).To(HaveLen(12))
+		Expect(s.Push([]byte("foobarbaz"), 12, nil)).To(Succeed())
+		offset, data, _ := s.Pop()
+		Expect(offset).To(BeEquivalentTo(12))
+		Expect(data).To(Equal([]byte("foob")))
+		offset, data, _ = s.Pop()
+		Expect(offset).To(BeEquivalentTo(16))
+		Expect(data).To(Equal([]byte("arbaz")))
+	})
+
+	It("errors when the data doesn't fit into the buffer", func() {
+		Expect(s.Push(make([]byte, 17), 0, nil)).To(MatchError("stream data exceeds the receive buffer"))
+		Expect(s.Push(make([]byte, 10), 0, nil)).To(Succeed())
+		s.Pop()
+		Expect(s.Push(make([]byte, 16), 10, nil)).To(Succeed())
+		Expect(s.Push([]byte{0}, 26, nil)).To(MatchError("stream data exceeds the receive buffer"))
+	})
+
+	It("errors when there are too many gaps", func() {
+		s = newStreamReceiveBuffer(4 * protocol.MaxStreamFrameSorterGaps)
+		for i := 0; i < protocol.MaxStreamFrameSorterGaps-1; i++ {
+			Expect(s.Push([]byte{0}, protocol.ByteCount(2*i+1), nil)).To(Succeed())
+		}
+		Expect(s.Push([]byte{0}, protocol.ByteCount(2*protocol.MaxStreamFrameSorterGaps+1), nil)).To(MatchError("too many gaps in received data"))
+	})
+
+	It("reassembles randomly ordered data", func() {
+		const size = 1 << 10
+		s = newStreamReceiveBuffer(size)
+		expected := make([]byte, 4*size)
+		rand.Read(expected)
+		var received []byte
+		for start := 0; start < len(expected); start += size {
+			// push the next window's worth of data in random order, with overlaps
+			for _, i := range rand.Perm(size / 16) {
+				offset := start + i*16
+				end := utils.Min(offset+32, start+size)
+				Expect(s.Push(expected[offset:end], protocol.ByteCount(offset), nil)).To(Succeed())
+			}
+			for {
+				_, data, _ := s.Pop()
+				if data == nil {
+					break
+				}
+				received = append(received, data...)
+			}
+		}
+		Expect(received).To(Equal(expected))
+	})
+})
@@ -50,6 +50,8 @@ type streamsMap struct {
 
 	maxIncomingBidiStreams uint64
 	maxIncomingUniStreams  uint64
+	receiveBufferSize      protocol.ByteCount
+	readLimiter            *readRateLimiter
 
 	sender            streamSender
 	newFlowController func(protocol.StreamID) flowcontrol.StreamFlowController
@@ -69,6 +71,8 @@ func newStreamsMap(
 	newFlowController func(protocol.StreamID) flowcontrol.StreamFlowController,
 	maxIncomingBidiStreams uint64,
 	maxIncomingUniStreams uint64,
+	receiveBufferSize protocol.ByteCount,
+	readLimiter *readRateLimiter,
 	perspective protocol.Perspective,
 	version protocol.VersionNumber,
 ) streamManager {
@@ -77,6 +81,8 @@ func newStreamsMap(
 		newFlowController:      newFlowController,
 		maxIncomingBidiStreams: maxIncomingBidiStreams,
 		maxIncomingUniStreams:  maxIncomingUniStreams,
+		receiveBufferSize:      receiveBufferSize,
+		readLimiter:            readLimiter,
 		sender:                 sender,
 		version:                version,
 	}
@@ -88,14 +94,14 @@ func (m *streamsMap) initMaps() {
 	m.outgoingBidiStreams = newOutgoingBidiStreamsMap(
 		func(num protocol.StreamNum) streamI {
 			id := num.StreamID(protocol.StreamTypeBidi, m.perspective)
-			return newStream(id, m.sender, m.newFlowController(id), m.version)
+			return newStream(id, m.sender, m.newFlowController(id), m.receiveBufferSize, m.readLimiter, m.version)
 		},
 		m.sender.queueControlFrame,
 	)
 	m.incomingBidiStreams = newIncomingBidiStreamsMap(
 		func(num protocol.StreamNum) streamI {
 			id := num.StreamID(protocol.StreamTypeBidi, m.perspective.Opposite())
-			return newStream(id, m.sender, m.newFlowController(id), m.version)
+			return newStream(id, m.sender, m.newFlowController(id), m.receiveBufferSize, m.readLimiter, m.version)
 		},
 		m.maxIncomingBidiStreams,
 		m.sender.queueControlFrame,
@@ -110,7 +116,7 @@ func (m *streamsMap) initMaps() {
 	m.incomingUniStreams = newIncomingUniStreamsMap(
 		func(num protocol.StreamNum) receiveStreamI {
 			id := num.StreamID(protocol.StreamTypeUni, m.perspective.Opposite())
-			return newReceiveStream(id, m.sender, m.newFlowController(id), m.version)
+			return newReceiveStream(id, m.sender, m.newFlowController(id), m.receiveBufferSize, m.readLimiter, m.version)
 		},
 		m.maxIncomingUniStreams,
 		m.sender.queueControlFrame,
@@ -297,6 +303,14 @@ func (m *streamsMap) CloseWithError(err error) {
 	m.incomingUniStreams.CloseWithError(err)
 }
 
+// Compact releases memory held by the streams maps for streams that were already deleted.
+func (m *streamsMap) Compact() {
+	m.outgoingBidiStreams.Compact()
+	m.outgoingUniStreams.Compact()
+	m.incomingBidiStreams.Compact()
+	m.incomingUniStreams.Compact()
+}
+
 // ResetFor0RTT resets is used when 0-RTT is rejected. In that case, the streams maps are
 // 1. closed with an Err0RTTRejected, making calls to Open{Uni}Stream{Sync} / Accept{Uni}Stream return that error.
 // 2. reset to their initial state, such that we can immediately process new incoming stream data.
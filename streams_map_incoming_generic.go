@@ -22,6 +22,8 @@ type incomingItemsMap struct {
 	newStreamChan chan struct{}
 
 	streams map[protocol.StreamNum]itemEntry
+	// the maximum number of entries in streams since the map was allocated
+	peakNumStreams int
 
 	nextStreamToAccept protocol.StreamNum // the next stream that will be returned by AcceptStream()
 	nextStreamToOpen   protocol.StreamNum // the highest stream that the peer opened
@@ -128,6 +130,9 @@ func (m *incomingItemsMap) GetOrOpenStream(num protocol.StreamNum) (item, error)
 		}
 	}
 	m.nextStreamToOpen = num + 1
+	if l := len(m.streams); l > m.peakNumStreams {
+		m.peakNumStreams = l
+	}
 	entry := m.streams[num]
 	m.mutex.Unlock()
 	return entry.stream, nil
@@ -179,6 +184,23 @@ func (m *incomingItemsMap) deleteStream(num protocol.StreamNum) error {
 	return nil
 }
 
+// Compact reallocates the streams map, if most of the streams were deleted since it was allocated.
+// Go maps never shrink, so a long-lived connection would otherwise retain the memory used by a burst of streams.
+func (m *incomingItemsMap) Compact() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.peakNumStreams < protocol.MinMapCompactionSize || len(m.streams) > m.peakNumStreams/4 {
+		return
+	}
+	streams := make(map[protocol.StreamNum]itemEntry, len(m.streams))
+	for num, entry := range m.streams {
+		streams[num] = entry
+	}
+	m.streams = streams
+	m.peakNumStreams = len(streams)
+}
+
 func (m *incomingItemsMap) CloseWithError(err error) {
 	m.mutex.Lock()
 	m.closeErr = err
@@ -237,6 +237,31 @@ var _ = Describe("Streams Map (incoming)", func() {
 		Expect(m.DeleteStream(4)).To(Succeed())
 	})
 
+	Context("compacting", func() {
+		BeforeEach(func() { maxNumStreams = 1000 })
+
+		It("compacts the map after most streams were deleted", func() {
+			const num = 2 * protocol.MinMapCompactionSize
+			_, err := m.GetOrOpenStream(num)
+			Expect(err).ToNot(HaveOccurred())
+			for i := 0; i < num; i++ {
+				_, err := m.AcceptStream(context.Background())
+				Expect(err).ToNot(HaveOccurred())
+			}
+			m.Compact()
+			Expect(m.peakNumStreams).To(Equal(num))
+			mockSender.EXPECT().queueControlFrame(gomock.Any()).AnyTimes()
+			for i := protocol.StreamNum(1); i < num; i++ {
+				Expect(m.DeleteStream(i)).To(Succeed())
+			}
+			m.Compact()
+			Expect(m.peakNumStreams).To(Equal(1))
+			str, err := m.GetOrOpenStream(num)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(str.(*mockGenericStream).num).To(Equal(protocol.StreamNum(num)))
+		})
+	})
+
 	Context("using high stream limits", func() {
 		BeforeEach(func() { maxNumStreams = uint64(protocol.MaxStreamCount) - 2 })
 
@@ -24,6 +24,8 @@ type incomingUniStreamsMap struct {
 	newStreamChan chan struct{}
 
 	streams map[protocol.StreamNum]receiveStreamIEntry
+	// the maximum number of entries in streams since the map was allocated
+	peakNumStreams int
 
 	nextStreamToAccept protocol.StreamNum // the next stream that will be returned by AcceptStream()
 	nextStreamToOpen   protocol.StreamNum // the highest stream that the peer opened
@@ -130,6 +132,9 @@ func (m *incomingUniStreamsMap) GetOrOpenStream(num protocol.StreamNum) (receive
 		}
 	}
 	m.nextStreamToOpen = num + 1
+	if l := len(m.streams); l > m.peakNumStreams {
+		m.peakNumStreams = l
+	}
 	entry := m.streams[num]
 	m.mutex.Unlock()
 	return entry.stream, nil
@@ -181,6 +186,23 @@ func (m *incomingUniStreamsMap) deleteStream(num protocol.StreamNum) error {
 	return nil
 }
 
+// Compact reallocates the streams map, if most of the streams were deleted since it was allocated.
+// Go maps never shrink, so a long-lived connection would otherwise retain the memory used by a burst of streams.
+func (m *incomingUniStreamsMap) Compact() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.peakNumStreams < protocol.MinMapCompactionSize || len(m.streams) > m.peakNumStreams/4 {
+		return
+	}
+	streams := make(map[protocol.StreamNum]receiveStreamIEntry, len(m.streams))
+	for num, entry := range m.streams {
+		streams[num] = entry
+	}
+	m.streams = streams
+	m.peakNumStreams = len(streams)
+}
+
 func (m *incomingUniStreamsMap) CloseWithError(err error) {
 	m.mutex.Lock()
 	m.closeErr = err
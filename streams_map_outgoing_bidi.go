@@ -16,6 +16,8 @@ type outgoingBidiStreamsMap struct {
 	mutex sync.RWMutex
 
 	streams map[protocol.StreamNum]streamI
+	// the maximum number of entries in streams since the map was allocated
+	peakNumStreams int
 
 	openQueue      map[uint64]chan struct{}
 	lowestInQueue  uint64
@@ -115,6 +117,9 @@ func (m *outgoingBidiStreamsMap) OpenStreamSync(ctx context.Context) (streamI, e
 func (m *outgoingBidiStreamsMap) openStream() streamI {
 	s := m.newStream(m.nextStream)
 	m.streams[m.nextStream] = s
+	if l := len(m.streams); l > m.peakNumStreams {
+		m.peakNumStreams = l
+	}
 	m.nextStream++
 	return s
 }
@@ -211,6 +216,23 @@ func (m *outgoingBidiStreamsMap) unblockOpenSync() {
 	}
 }
 
+// Compact reallocates the streams map, if most of the streams were deleted since it was allocated.
+// Go maps never shrink, so a long-lived connection would otherwise retain the memory used by a burst of streams.
+func (m *outgoingBidiStreamsMap) Compact() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.peakNumStreams < protocol.MinMapCompactionSize || len(m.streams) > m.peakNumStreams/4 {
+		return
+	}
+	streams := make(map[protocol.StreamNum]streamI, len(m.streams))
+	for num, str := range m.streams {
+		streams[num] = str
+	}
+	m.streams = streams
+	m.peakNumStreams = len(streams)
+}
+
 func (m *outgoingBidiStreamsMap) CloseWithError(err error) {
 	m.mutex.Lock()
 	m.closeErr = err
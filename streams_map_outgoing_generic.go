@@ -14,6 +14,8 @@ type outgoingItemsMap struct {
 	mutex sync.RWMutex
 
 	streams map[protocol.StreamNum]item
+	// the maximum number of entries in streams since the map was allocated
+	peakNumStreams int
 
 	openQueue      map[uint64]chan struct{}
 	lowestInQueue  uint64
@@ -113,6 +115,9 @@ func (m *outgoingItemsMap) OpenStreamSync(ctx context.Context) (item, error) {
 func (m *outgoingItemsMap) openStream() item {
 	s := m.newStream(m.nextStream)
 	m.streams[m.nextStream] = s
+	if l := len(m.streams); l > m.peakNumStreams {
+		m.peakNumStreams = l
+	}
 	m.nextStream++
 	return s
 }
@@ -209,6 +214,23 @@ func (m *outgoingItemsMap) unblockOpenSync() {
 	}
 }
 
+// Compact reallocates the streams map, if most of the streams were deleted since it was allocated.
+// Go maps never shrink, so a long-lived connection would otherwise retain the memory used by a burst of streams.
+func (m *outgoingItemsMap) Compact() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.peakNumStreams < protocol.MinMapCompactionSize || len(m.streams) > m.peakNumStreams/4 {
+		return
+	}
+	streams := make(map[protocol.StreamNum]item, len(m.streams))
+	for num, str := range m.streams {
+		streams[num] = str
+	}
+	m.streams = streams
+	m.peakNumStreams = len(streams)
+}
+
 func (m *outgoingItemsMap) CloseWithError(err error) {
 	m.mutex.Lock()
 	m.closeErr = err
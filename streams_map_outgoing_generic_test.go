@@ -100,6 +100,23 @@ var _ = Describe("Streams Map (outgoing)", func() {
 			Expect(err.(streamError).TestError()).To(MatchError("tried to delete unknown outgoing stream 1"))
 		})
 
+		It("compacts the map after most streams were deleted", func() {
+			for i := 0; i < 2*protocol.MinMapCompactionSize; i++ {
+				_, err := m.OpenStream()
+				Expect(err).ToNot(HaveOccurred())
+			}
+			m.Compact()
+			Expect(m.peakNumStreams).To(Equal(2 * protocol.MinMapCompactionSize))
+			for num := protocol.StreamNum(1); num < 2*protocol.MinMapCompactionSize; num++ {
+				Expect(m.DeleteStream(num)).To(Succeed())
+			}
+			m.Compact()
+			Expect(m.peakNumStreams).To(Equal(1))
+			str, err := m.GetStream(2 * protocol.MinMapCompactionSize)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(str.(*mockGenericStream).num).To(Equal(protocol.StreamNum(2 * protocol.MinMapCompactionSize)))
+		})
+
 		It("closes all streams when CloseWithError is called", func() {
 			str1, err := m.OpenStream()
 			Expect(err).ToNot(HaveOccurred())
@@ -16,6 +16,8 @@ type outgoingUniStreamsMap struct {
 	mutex sync.RWMutex
 
 	streams map[protocol.StreamNum]sendStreamI
+	// the maximum number of entries in streams since the map was allocated
+	peakNumStreams int
 
 	openQueue      map[uint64]chan struct{}
 	lowestInQueue  uint64
@@ -115,6 +117,9 @@ func (m *outgoingUniStreamsMap) OpenStreamSync(ctx context.Context) (sendStreamI
 func (m *outgoingUniStreamsMap) openStream() sendStreamI {
 	s := m.newStream(m.nextStream)
 	m.streams[m.nextStream] = s
+	if l := len(m.streams); l > m.peakNumStreams {
+		m.peakNumStreams = l
+	}
 	m.nextStream++
 	return s
 }
@@ -211,6 +216,23 @@ func (m *outgoingUniStreamsMap) unblockOpenSync() {
 	}
 }
 
+// Compact reallocates the streams map, if most of the streams were deleted since it was allocated.
+// Go maps never shrink, so a long-lived connection would otherwise retain the memory used by a burst of streams.
+func (m *outgoingUniStreamsMap) Compact() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.peakNumStreams < protocol.MinMapCompactionSize || len(m.streams) > m.peakNumStreams/4 {
+		return
+	}
+	streams := make(map[protocol.StreamNum]sendStreamI, len(m.streams))
+	for num, str := range m.streams {
+		streams[num] = str
+	}
+	m.streams = streams
+	m.peakNumStreams = len(streams)
+}
+
 func (m *outgoingUniStreamsMap) CloseWithError(err error) {
 	m.mutex.Lock()
 	m.closeErr = err
@@ -0,0 +1,50 @@
+package quic
+
+import (
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/utils"
+)
+
+// A suspendDetector detects that the system was suspended, e.g. when the lid of a laptop was closed.
+// Depending on the platform, the monotonic clock either stops while the system is suspended, or it keeps running.
+// In the first case, the suspension is detected by comparing the elapsed wall clock time to the elapsed monotonic time.
+// In the second case, it is detected when the run loop wakes up long after the deadline of its timer.
+// A large step of the wall clock (e.g. when the time is set manually) is also reported as a suspension.
+type suspendDetector struct {
+	threshold time.Duration
+
+	lastMono time.Time // contains a monotonic clock reading
+	lastWall time.Time // only contains a wall clock reading
+}
+
+func newSuspendDetector(threshold time.Duration, now time.Time) *suspendDetector {
+	return &suspendDetector{
+		threshold: threshold,
+		lastMono:  now,
+		lastWall:  now.Round(0),
+	}
+}
+
+// Check is called every time the run loop wakes up.
+// deadline is the deadline of the timer the run loop was waiting for, or the zero value if no timer was set.
+// If the system was suspended for longer than the threshold since the last call, it returns the duration of the suspension.
+// clockJump is the part of the suspension that the monotonic clock advanced by.
+func (d *suspendDetector) Check(now, deadline time.Time) (suspended, clockJump time.Duration) {
+	monoElapsed := now.Sub(d.lastMono)
+	wallElapsed := now.Round(0).Sub(d.lastWall)
+	if !deadline.IsZero() {
+		clockJump = now.Sub(utils.MaxTime(deadline, d.lastMono))
+	}
+	d.lastMono = now
+	d.lastWall = now.Round(0)
+
+	if clockJump < d.threshold {
+		clockJump = 0
+	}
+	suspended = utils.MaxDuration(clockJump, wallElapsed-monoElapsed)
+	if suspended < d.threshold {
+		return 0, 0
+	}
+	return suspended, clockJump
+}
@@ -0,0 +1,58 @@
+package quic
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Suspend Detector", func() {
+	const threshold = 5 * time.Second
+
+	It("doesn't detect a suspension when the timer fires on time", func() {
+		now := time.Now()
+		d := newSuspendDetector(threshold, now)
+		deadline := now.Add(time.Minute)
+		suspended, clockJump := d.Check(deadline.Add(time.Millisecond), deadline)
+		Expect(suspended).To(BeZero())
+		Expect(clockJump).To(BeZero())
+	})
+
+	It("doesn't detect a suspension when the run loop is woken up before the deadline", func() {
+		now := time.Now()
+		d := newSuspendDetector(threshold, now)
+		suspended, _ := d.Check(now.Add(time.Minute), now.Add(2*time.Minute))
+		Expect(suspended).To(BeZero())
+	})
+
+	It("detects a suspension when the timer fires much later than its deadline", func() {
+		now := time.Now()
+		d := newSuspendDetector(threshold, now)
+		deadline := now.Add(time.Second)
+		suspended, clockJump := d.Check(deadline.Add(time.Hour), deadline)
+		Expect(suspended).To(Equal(time.Hour))
+		Expect(clockJump).To(Equal(time.Hour))
+		// the next check starts from the time of this check
+		suspended, _ = d.Check(deadline.Add(time.Hour+time.Second), deadline.Add(time.Hour+time.Second))
+		Expect(suspended).To(BeZero())
+	})
+
+	It("detects a suspension when the wall clock advanced more than the monotonic clock", func() {
+		now := time.Now()
+		d := newSuspendDetector(threshold, now)
+		// move the wall clock of the last check back by an hour
+		d.lastWall = d.lastWall.Add(-time.Hour)
+		suspended, clockJump := d.Check(now.Add(time.Second), now.Add(time.Second))
+		Expect(suspended).To(Equal(time.Hour))
+		Expect(clockJump).To(BeZero())
+	})
+
+	It("ignores delays below the threshold", func() {
+		now := time.Now()
+		d := newSuspendDetector(threshold, now)
+		suspended, clockJump := d.Check(now.Add(threshold-time.Millisecond), now)
+		Expect(suspended).To(BeZero())
+		Expect(clockJump).To(BeZero())
+	})
+})
@@ -0,0 +1,110 @@
+package telemetry
+
+import (
+	"io"
+	"net/http"
+	"sync"
+)
+
+type roundTripper struct {
+	rt    http.RoundTripper
+	spans SpanTracer
+}
+
+// NewRoundTripper wraps a RoundTripper (usually an http3.RoundTripper), such that a span is emitted for every request.
+// The span ends when the response body was read completely or closed.
+func NewRoundTripper(rt http.RoundTripper, spans SpanTracer) http.RoundTripper {
+	return &roundTripper{rt: rt, spans: spans}
+}
+
+func (r *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := r.spans.Start(req.Context(), "HTTP "+req.Method, requestAttributes(req)...)
+	rsp, err := r.rt.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.End()
+		return nil, err
+	}
+	span.SetAttributes(Attribute{Key: "http.status_code", Value: int64(rsp.StatusCode)})
+	rsp.Body = &spanBody{ReadCloser: rsp.Body, span: span}
+	return rsp, nil
+}
+
+// spanBody ends the span of a request when the response body is read completely or closed.
+type spanBody struct {
+	io.ReadCloser
+
+	span Span
+	once sync.Once
+}
+
+func (b *spanBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil {
+		b.end(err)
+	}
+	return n, err
+}
+
+func (b *spanBody) Close() error {
+	b.end(nil)
+	return b.ReadCloser.Close()
+}
+
+func (b *spanBody) end(err error) {
+	b.once.Do(func() {
+		if err != nil && err != io.EOF {
+			b.span.RecordError(err)
+		}
+		b.span.End()
+	})
+}
+
+// NewHandler wraps an http.Handler, such that a span is emitted for every request that it serves.
+// The handler receives a request whose context contains this span.
+func NewHandler(h http.Handler, spans SpanTracer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx, span := spans.Start(req.Context(), "HTTP "+req.Method, requestAttributes(req)...)
+		defer span.End()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(sw, req.WithContext(ctx))
+		span.SetAttributes(Attribute{Key: "http.status_code", Value: int64(sw.status)})
+	})
+}
+
+// statusWriter records the status code of a response.
+type statusWriter struct {
+	http.ResponseWriter
+
+	status      int
+	wroteHeader bool
+}
+
+var _ http.Flusher = &statusWriter{}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func requestAttributes(req *http.Request) []Attribute {
+	return []Attribute{
+		{Key: "http.method", Value: req.Method},
+		{Key: "http.url", Value: req.URL.String()},
+		{Key: "http.flavor", Value: "3"},
+	}
+}
@@ -0,0 +1,92 @@
+package telemetry
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+var _ = Describe("HTTP", func() {
+	var spans *mockSpanTracer
+
+	BeforeEach(func() {
+		spans = &mockSpanTracer{}
+	})
+
+	Context("round tripper", func() {
+		It("emits a span for a request", func() {
+			var reqSpan *mockSpan
+			rt := NewRoundTripper(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				reqSpan = req.Context().Value(spanKey{}).(*mockSpan)
+				return &http.Response{StatusCode: 404, Body: ioutil.NopCloser(strings.NewReader("foobar"))}, nil
+			}), spans)
+			req := httptest.NewRequest(http.MethodGet, "https://quic.clemente.io/foo", nil)
+			rsp, err := rt.RoundTrip(req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(spans.spans).To(HaveLen(1))
+			Expect(reqSpan).To(Equal(spans.spans[0]))
+			Expect(reqSpan.name).To(Equal("HTTP GET"))
+			Expect(reqSpan.attrs).To(HaveKeyWithValue("http.method", "GET"))
+			Expect(reqSpan.attrs).To(HaveKeyWithValue("http.url", "https://quic.clemente.io/foo"))
+			Expect(reqSpan.attrs).To(HaveKeyWithValue("http.status_code", int64(404)))
+			Expect(reqSpan.ended).To(BeFalse())
+			data, err := ioutil.ReadAll(rsp.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(data)).To(Equal("foobar"))
+			Expect(reqSpan.ended).To(BeTrue())
+			Expect(reqSpan.errs).To(BeEmpty())
+			Expect(rsp.Body.Close()).To(Succeed())
+		})
+
+		It("records errors", func() {
+			testErr := errors.New("test error")
+			rt := NewRoundTripper(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				return nil, testErr
+			}), spans)
+			_, err := rt.RoundTrip(httptest.NewRequest(http.MethodPost, "https://quic.clemente.io/foo", nil))
+			Expect(err).To(MatchError(testErr))
+			Expect(spans.spans).To(HaveLen(1))
+			Expect(spans.spans[0].errs).To(Equal([]error{testErr}))
+			Expect(spans.spans[0].ended).To(BeTrue())
+		})
+	})
+
+	Context("handler", func() {
+		It("emits a span for a request", func() {
+			var reqSpan *mockSpan
+			h := NewHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				reqSpan = req.Context().Value(spanKey{}).(*mockSpan)
+				w.WriteHeader(http.StatusTeapot)
+				w.Write([]byte("foobar"))
+			}), spans)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/foo", nil))
+			Expect(w.Code).To(Equal(http.StatusTeapot))
+			Expect(w.Body.String()).To(Equal("foobar"))
+			Expect(reqSpan.name).To(Equal("HTTP GET"))
+			Expect(reqSpan.attrs).To(HaveKeyWithValue("http.url", "/foo"))
+			Expect(reqSpan.attrs).To(HaveKeyWithValue("http.status_code", int64(http.StatusTeapot)))
+			Expect(reqSpan.ended).To(BeTrue())
+		})
+
+		It("uses 200 if the handler doesn't write a status code", func() {
+			h := NewHandler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.Write([]byte("foobar"))
+				w.(http.Flusher).Flush()
+			}), spans)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/foo", nil))
+			Expect(w.Flushed).To(BeTrue())
+			Expect(spans.spans[0].attrs).To(HaveKeyWithValue("http.status_code", int64(http.StatusOK)))
+		})
+	})
+})
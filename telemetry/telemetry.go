@@ -0,0 +1,313 @@
+// Package telemetry emits spans and metrics for QUIC connections and HTTP/3 requests,
+// to make quic-go traffic visible in distributed tracing pipelines like OpenTelemetry.
+//
+// To avoid a dependency on a particular tracing library, spans and metrics are emitted using the small
+// SpanTracer, Span and Meter interfaces. They follow the OpenTelemetry API, so adapting an OpenTelemetry
+// trace.Tracer and metric.Meter only takes a few lines of code.
+package telemetry
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/logging"
+)
+
+// An Attribute is a key-value pair describing a span or a measurement.
+// The value is a string, an int64, a float64 or a bool.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// A SpanTracer starts spans.
+type SpanTracer interface {
+	// Start starts a span. The parent of the span is the span contained in the context, if any.
+	// The returned context contains the new span.
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// A Span is a single operation within a trace.
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	AddEvent(name string, attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// A Meter records metrics.
+type Meter interface {
+	// Add adds a value to the counter with the given name.
+	Add(ctx context.Context, counter string, value int64, attrs ...Attribute)
+}
+
+// names of the spans
+const (
+	SpanConnection = "quic.connection"
+	SpanHandshake  = "quic.handshake"
+)
+
+// names of the counters
+const (
+	CounterConnections     = "quic.connections"
+	CounterPacketsSent     = "quic.packets.sent"
+	CounterPacketsReceived = "quic.packets.received"
+	CounterPacketsLost     = "quic.packets.lost"
+	CounterPacketsDropped  = "quic.packets.dropped"
+	CounterBytesSent       = "quic.bytes.sent"
+	CounterBytesReceived   = "quic.bytes.received"
+)
+
+type tracer struct {
+	spans SpanTracer
+	meter Meter
+}
+
+var _ logging.Tracer = &tracer{}
+
+// NewTracer creates a new tracer that emits a span for every connection and for its handshake,
+// and records transport counters.
+// The span of a connection is a child of the span contained in the context passed to Dial.
+// Either spans or meter may be nil.
+func NewTracer(spans SpanTracer, meter Meter) logging.Tracer {
+	return &tracer{spans: spans, meter: meter}
+}
+
+func (t *tracer) TracerForConnection(ctx context.Context, p logging.Perspective, odcid logging.ConnectionID) logging.ConnectionTracer {
+	perspective := Attribute{Key: "quic.perspective", Value: perspectiveString(p)}
+	ct := &connectionTracer{
+		ctx:         ctx,
+		meter:       t.meter,
+		perspective: perspective,
+	}
+	if t.spans != nil {
+		ct.ctx, ct.connSpan = t.spans.Start(ctx, SpanConnection, perspective, Attribute{Key: "quic.odcid", Value: odcid.String()})
+		_, ct.handshakeSpan = t.spans.Start(ct.ctx, SpanHandshake, perspective)
+	}
+	return ct
+}
+
+func (t *tracer) SentPacket(net.Addr, *logging.Header, logging.ByteCount, []logging.Frame) {}
+
+func (t *tracer) DroppedPacket(_ net.Addr, _ logging.PacketType, _ logging.ByteCount, reason logging.PacketDropReason) {
+	if t.meter != nil {
+		t.meter.Add(context.Background(), CounterPacketsDropped, 1, Attribute{Key: "quic.drop_reason", Value: dropReasonString(reason)})
+	}
+}
+
+type connectionTracer struct {
+	ctx         context.Context
+	meter       Meter
+	perspective Attribute
+
+	connSpan Span // nil if no SpanTracer is used
+
+	mutex         sync.Mutex
+	handshakeSpan Span // nil once the handshake completed
+	smoothedRTT   time.Duration
+	minRTT        time.Duration
+	closeErr      error
+}
+
+var _ logging.ConnectionTracer = &connectionTracer{}
+
+func (t *connectionTracer) add(counter string, value int64, attrs ...Attribute) {
+	if t.meter != nil {
+		t.meter.Add(t.ctx, counter, value, append(attrs, t.perspective)...)
+	}
+}
+
+func (t *connectionTracer) StartedConnection(local, remote net.Addr, srcConnID, destConnID logging.ConnectionID) {
+	t.add(CounterConnections, 1)
+	if t.connSpan != nil {
+		t.connSpan.SetAttributes(
+			Attribute{Key: "net.sock.host.addr", Value: local.String()},
+			Attribute{Key: "net.sock.peer.addr", Value: remote.String()},
+			Attribute{Key: "quic.src_connection_id", Value: srcConnID.String()},
+			Attribute{Key: "quic.dest_connection_id", Value: destConnID.String()},
+		)
+	}
+}
+
+func (t *connectionTracer) NegotiatedVersion(chosen logging.VersionNumber, _, _ []logging.VersionNumber) {
+	if t.connSpan != nil {
+		t.connSpan.SetAttributes(Attribute{Key: "quic.version", Value: chosen.String()})
+	}
+}
+
+func (t *connectionTracer) ClosedConnection(err error) {
+	t.mutex.Lock()
+	t.closeErr = err
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) SentTransportParameters(*logging.TransportParameters)     {}
+func (t *connectionTracer) ReceivedTransportParameters(*logging.TransportParameters) {}
+func (t *connectionTracer) RestoredTransportParameters(*logging.TransportParameters) {}
+
+func (t *connectionTracer) SentPacket(_ *logging.ExtendedHeader, size logging.ByteCount, _ *logging.AckFrame, _ []logging.Frame) {
+	t.add(CounterPacketsSent, 1)
+	t.add(CounterBytesSent, int64(size))
+}
+
+func (t *connectionTracer) ComposedPacket(logging.EncryptionLevel, logging.PacketNumber, *logging.PacketComposition) {
+}
+
+func (t *connectionTracer) ReceivedVersionNegotiationPacket(*logging.Header, []logging.VersionNumber) {
+	t.addHandshakeEvent("version_negotiation")
+}
+
+func (t *connectionTracer) ReceivedRetry(*logging.Header) {
+	t.addHandshakeEvent("retry")
+}
+
+func (t *connectionTracer) ReceivedPacket(_ *logging.ExtendedHeader, size logging.ByteCount, _ []logging.Frame) {
+	t.add(CounterPacketsReceived, 1)
+	t.add(CounterBytesReceived, int64(size))
+}
+
+func (t *connectionTracer) SentDatagram([]byte)                                                {}
+func (t *connectionTracer) ReceivedDatagram([]byte)                                            {}
+func (t *connectionTracer) SentPlaintextPacket(logging.EncryptionLevel, []byte)                {}
+func (t *connectionTracer) ReceivedPlaintextPacket(logging.EncryptionLevel, []byte)            {}
+func (t *connectionTracer) SentDatagramFrame(logging.ByteCount)                                {}
+func (t *connectionTracer) ReceivedDatagramFrame(logging.ByteCount)                            {}
+func (t *connectionTracer) DroppedDatagramFrame(logging.ByteCount, logging.DatagramDropReason) {}
+func (t *connectionTracer) SentFlowControlUpdate(logging.StreamID, logging.ByteCount, logging.ByteCount) {
+}
+func (t *connectionTracer) ReceivedFlowControlUpdate(logging.StreamID, logging.ByteCount, time.Duration) {
+}
+func (t *connectionTracer) BlockedByFlowControl(logging.StreamID, logging.ByteCount) {}
+func (t *connectionTracer) BufferedPacket(logging.PacketType)                        {}
+
+func (t *connectionTracer) DroppedPacket(_ logging.PacketType, _ logging.ByteCount, reason logging.PacketDropReason) {
+	t.add(CounterPacketsDropped, 1, Attribute{Key: "quic.drop_reason", Value: dropReasonString(reason)})
+}
+
+func (t *connectionTracer) UpdatedMetrics(rttStats *logging.RTTStats, _, _ logging.ByteCount, _ int) {
+	t.mutex.Lock()
+	t.smoothedRTT = rttStats.SmoothedRTT()
+	t.minRTT = rttStats.MinRTT()
+	t.mutex.Unlock()
+}
+
+func (t *connectionTracer) UpdatedCongestionMetrics(_, _, _ logging.ByteCount, _ uint64)     {}
+func (t *connectionTracer) AcknowledgedPacket(logging.EncryptionLevel, logging.PacketNumber) {}
+
+func (t *connectionTracer) LostPacket(_ logging.EncryptionLevel, _ logging.PacketNumber, reason logging.PacketLossReason) {
+	t.add(CounterPacketsLost, 1, Attribute{Key: "quic.loss_reason", Value: lossReasonString(reason)})
+}
+
+func (t *connectionTracer) DetectedPersistentCongestion(time.Duration)                     {}
+func (t *connectionTracer) DetectedSuspend(time.Duration)                                  {}
+func (t *connectionTracer) ClassifiedPacketLoss(logging.PacketNumber, bool)                {}
+func (t *connectionTracer) PacerDelayedSend(logging.ByteCount, time.Time)                  {}
+func (t *connectionTracer) LimitedAckRanges(logging.EncryptionLevel, int, int)             {}
+func (t *connectionTracer) SkippedPacketNumber(logging.PacketNumber)                       {}
+func (t *connectionTracer) UpdatedCongestionState(logging.CongestionState)                 {}
+func (t *connectionTracer) UpdatedPTOCount(uint32)                                         {}
+func (t *connectionTracer) UpdatedMaxDatagramSize(logging.ByteCount)                       {}
+func (t *connectionTracer) UpdatedKeyFromTLS(logging.EncryptionLevel, logging.Perspective) {}
+func (t *connectionTracer) UpdatedKey(logging.KeyPhase, bool)                              {}
+
+func (t *connectionTracer) DroppedEncryptionLevel(encLevel logging.EncryptionLevel) {
+	// The Handshake keys are dropped when the handshake is confirmed.
+	if encLevel != logging.EncryptionHandshake {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.handshakeSpan != nil {
+		t.handshakeSpan.End()
+		t.handshakeSpan = nil
+	}
+}
+
+func (t *connectionTracer) DroppedKey(logging.KeyPhase)                                        {}
+func (t *connectionTracer) Accepted0RTT()                                                      {}
+func (t *connectionTracer) Rejected0RTT(logging.ZeroRTTRejectReason)                           {}
+func (t *connectionTracer) SetLossTimer(logging.TimerType, logging.EncryptionLevel, time.Time) {}
+func (t *connectionTracer) LossTimerExpired(logging.TimerType, logging.EncryptionLevel)        {}
+func (t *connectionTracer) LossTimerCanceled()                                                 {}
+
+func (t *connectionTracer) Close() {
+	if t.connSpan == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.handshakeSpan != nil {
+		if t.closeErr != nil {
+			t.handshakeSpan.RecordError(t.closeErr)
+		}
+		t.handshakeSpan.End()
+		t.handshakeSpan = nil
+	}
+	t.connSpan.SetAttributes(
+		Attribute{Key: "quic.smoothed_rtt_ms", Value: float64(t.smoothedRTT) / float64(time.Millisecond)},
+		Attribute{Key: "quic.min_rtt_ms", Value: float64(t.minRTT) / float64(time.Millisecond)},
+	)
+	if t.closeErr != nil {
+		t.connSpan.RecordError(t.closeErr)
+	}
+	t.connSpan.End()
+}
+
+func (t *connectionTracer) Debug(string, string) {}
+
+func (t *connectionTracer) addHandshakeEvent(name string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.handshakeSpan != nil {
+		t.handshakeSpan.AddEvent(name)
+	}
+}
+
+func perspectiveString(p logging.Perspective) string {
+	if p == logging.PerspectiveClient {
+		return "client"
+	}
+	return "server"
+}
+
+func lossReasonString(r logging.PacketLossReason) string {
+	switch r {
+	case logging.PacketLossReorderingThreshold:
+		return "reordering_threshold"
+	case logging.PacketLossTimeThreshold:
+		return "time_threshold"
+	default:
+		return "unknown"
+	}
+}
+
+func dropReasonString(r logging.PacketDropReason) string {
+	switch r {
+	case logging.PacketDropKeyUnavailable:
+		return "key_unavailable"
+	case logging.PacketDropUnknownConnectionID:
+		return "unknown_connection_id"
+	case logging.PacketDropHeaderParseError:
+		return "header_parse_error"
+	case logging.PacketDropPayloadDecryptError:
+		return "payload_decrypt_error"
+	case logging.PacketDropProtocolViolation:
+		return "protocol_violation"
+	case logging.PacketDropDOSPrevention:
+		return "dos_prevention"
+	case logging.PacketDropUnsupportedVersion:
+		return "unsupported_version"
+	case logging.PacketDropUnexpectedPacket:
+		return "unexpected_packet"
+	case logging.PacketDropUnexpectedSourceConnectionID:
+		return "unexpected_source_connection_id"
+	case logging.PacketDropUnexpectedVersion:
+		return "unexpected_version"
+	case logging.PacketDropDuplicate:
+		return "duplicate"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,192 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/lucas-clemente/quic-go/internal/protocol"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type spanKey struct{}
+
+type mockSpan struct {
+	name   string
+	parent *mockSpan
+	attrs  map[string]interface{}
+	events []string
+	errs   []error
+	ended  bool
+}
+
+func (s *mockSpan) SetAttributes(attrs ...Attribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *mockSpan) AddEvent(name string, _ ...Attribute) { s.events = append(s.events, name) }
+func (s *mockSpan) RecordError(err error)                { s.errs = append(s.errs, err) }
+func (s *mockSpan) End()                                 { s.ended = true }
+
+type mockSpanTracer struct {
+	spans []*mockSpan
+}
+
+func (t *mockSpanTracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	s := &mockSpan{name: name, attrs: make(map[string]interface{})}
+	s.parent, _ = ctx.Value(spanKey{}).(*mockSpan)
+	s.SetAttributes(attrs...)
+	t.spans = append(t.spans, s)
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+type mockMeter struct {
+	mutex    sync.Mutex
+	counters map[string]int64
+	attrs    map[string][]Attribute
+}
+
+func newMockMeter() *mockMeter {
+	return &mockMeter{counters: make(map[string]int64), attrs: make(map[string][]Attribute)}
+}
+
+func (m *mockMeter) Add(_ context.Context, counter string, value int64, attrs ...Attribute) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.counters[counter] += value
+	m.attrs[counter] = attrs
+}
+
+var _ = Describe("Tracer", func() {
+	var (
+		spans  *mockSpanTracer
+		meter  *mockMeter
+		tracer logging.Tracer
+	)
+
+	BeforeEach(func() {
+		spans = &mockSpanTracer{}
+		meter = newMockMeter()
+		tracer = NewTracer(spans, meter)
+	})
+
+	It("counts dropped packets that don't belong to a connection", func() {
+		tracer.DroppedPacket(nil, logging.PacketTypeInitial, 1000, logging.PacketDropDOSPrevention)
+		Expect(meter.counters).To(HaveKeyWithValue(CounterPacketsDropped, int64(1)))
+		Expect(meter.attrs[CounterPacketsDropped]).To(ContainElement(Attribute{Key: "quic.drop_reason", Value: "dos_prevention"}))
+	})
+
+	It("works without a SpanTracer and a Meter", func() {
+		t := NewTracer(nil, nil)
+		t.DroppedPacket(nil, logging.PacketTypeInitial, 1000, logging.PacketDropDOSPrevention)
+		ct := t.TracerForConnection(context.Background(), logging.PerspectiveClient, logging.ConnectionID{1, 2, 3, 4})
+		ct.SentPacket(&logging.ExtendedHeader{}, 1234, nil, nil)
+		ct.DroppedEncryptionLevel(logging.EncryptionHandshake)
+		ct.ClosedConnection(errors.New("test"))
+		ct.Close()
+	})
+
+	Context("connections", func() {
+		var (
+			parent *mockSpan
+			ct     logging.ConnectionTracer
+		)
+
+		BeforeEach(func() {
+			ctx, s := spans.Start(context.Background(), "request")
+			parent = s.(*mockSpan)
+			ct = tracer.TracerForConnection(ctx, logging.PerspectiveClient, logging.ConnectionID{0xde, 0xad, 0xbe, 0xef})
+			Expect(spans.spans).To(HaveLen(3))
+		})
+
+		connSpan := func() *mockSpan { return spans.spans[1] }
+		handshakeSpan := func() *mockSpan { return spans.spans[2] }
+
+		It("emits a span for the connection, as a child of the span in the context", func() {
+			Expect(connSpan().name).To(Equal(SpanConnection))
+			Expect(connSpan().parent).To(Equal(parent))
+			Expect(connSpan().attrs).To(HaveKeyWithValue("quic.odcid", "deadbeef"))
+			Expect(connSpan().attrs).To(HaveKeyWithValue("quic.perspective", "client"))
+			ct.StartedConnection(
+				&net.UDPAddr{IP: net.IPv4(192, 168, 13, 37), Port: 42},
+				&net.UDPAddr{IP: net.IPv4(1, 2, 3, 4), Port: 443},
+				logging.ConnectionID{1, 2},
+				logging.ConnectionID{3, 4},
+			)
+			ct.NegotiatedVersion(protocol.VersionTLS, nil, nil)
+			Expect(connSpan().attrs).To(HaveKeyWithValue("net.sock.host.addr", "192.168.13.37:42"))
+			Expect(connSpan().attrs).To(HaveKeyWithValue("net.sock.peer.addr", "1.2.3.4:443"))
+			Expect(connSpan().attrs).To(HaveKeyWithValue("quic.src_connection_id", "0102"))
+			Expect(connSpan().attrs).To(HaveKeyWithValue("quic.dest_connection_id", "0304"))
+			Expect(connSpan().attrs).To(HaveKey("quic.version"))
+			rttStats := &utils.RTTStats{}
+			rttStats.UpdateRTT(20*time.Millisecond, 0, time.Now())
+			ct.UpdatedMetrics(rttStats, 0, 0, 0)
+			Expect(connSpan().ended).To(BeFalse())
+			ct.Close()
+			Expect(connSpan().ended).To(BeTrue())
+			Expect(connSpan().errs).To(BeEmpty())
+			Expect(connSpan().attrs).To(HaveKeyWithValue("quic.smoothed_rtt_ms", 20.0))
+			Expect(connSpan().attrs).To(HaveKeyWithValue("quic.min_rtt_ms", 20.0))
+		})
+
+		It("records the error that the connection was closed with", func() {
+			ct.DroppedEncryptionLevel(logging.EncryptionHandshake)
+			testErr := errors.New("test error")
+			ct.ClosedConnection(testErr)
+			ct.Close()
+			Expect(connSpan().errs).To(Equal([]error{testErr}))
+			Expect(handshakeSpan().errs).To(BeEmpty())
+		})
+
+		It("emits a span for the handshake", func() {
+			Expect(handshakeSpan().name).To(Equal(SpanHandshake))
+			Expect(handshakeSpan().parent).To(Equal(connSpan()))
+			ct.ReceivedRetry(&logging.Header{})
+			Expect(handshakeSpan().events).To(Equal([]string{"retry"}))
+			ct.DroppedEncryptionLevel(logging.EncryptionInitial)
+			Expect(handshakeSpan().ended).To(BeFalse())
+			ct.DroppedEncryptionLevel(logging.EncryptionHandshake)
+			Expect(handshakeSpan().ended).To(BeTrue())
+			Expect(connSpan().ended).To(BeFalse())
+		})
+
+		It("ends the handshake span when the connection is closed during the handshake", func() {
+			testErr := errors.New("handshake failed")
+			ct.ClosedConnection(testErr)
+			ct.Close()
+			Expect(handshakeSpan().ended).To(BeTrue())
+			Expect(handshakeSpan().errs).To(Equal([]error{testErr}))
+			Expect(connSpan().ended).To(BeTrue())
+		})
+
+		It("records transport counters", func() {
+			ct.StartedConnection(&net.UDPAddr{}, &net.UDPAddr{}, nil, nil)
+			ct.SentPacket(&logging.ExtendedHeader{}, 1000, nil, nil)
+			ct.SentPacket(&logging.ExtendedHeader{}, 500, nil, nil)
+			ct.ReceivedPacket(&logging.ExtendedHeader{}, 1200, nil)
+			ct.LostPacket(logging.Encryption1RTT, 42, logging.PacketLossTimeThreshold)
+			ct.DroppedPacket(logging.PacketType1RTT, 100, logging.PacketDropDuplicate)
+			Expect(meter.counters).To(Equal(map[string]int64{
+				CounterConnections:     1,
+				CounterPacketsSent:     2,
+				CounterBytesSent:       1500,
+				CounterPacketsReceived: 1,
+				CounterBytesReceived:   1200,
+				CounterPacketsLost:     1,
+				CounterPacketsDropped:  1,
+			}))
+			Expect(meter.attrs[CounterPacketsSent]).To(ContainElement(Attribute{Key: "quic.perspective", Value: "client"}))
+			Expect(meter.attrs[CounterPacketsLost]).To(ContainElement(Attribute{Key: "quic.loss_reason", Value: "time_threshold"}))
+			Expect(meter.attrs[CounterPacketsDropped]).To(ContainElement(Attribute{Key: "quic.drop_reason", Value: "duplicate"}))
+		})
+	})
+})
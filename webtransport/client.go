@@ -0,0 +1,95 @@
+package webtransport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/lucas-clemente/quic-go/http3"
+)
+
+// Dialer establishes WebTransport sessions.
+// Sessions to the same host share a single QUIC connection.
+type Dialer struct {
+	// RoundTripper is used to send the extended CONNECT requests.
+	// If nil, a RoundTripper with default values is used.
+	// Dialer sets the settings and stream hijackers required for WebTransport.
+	// Datagrams are enabled.
+	RoundTripper *http3.RoundTripper
+
+	initOnce sync.Once
+	managers sessionManagers
+}
+
+func (d *Dialer) init() {
+	d.initOnce.Do(func() {
+		if d.RoundTripper == nil {
+			d.RoundTripper = &http3.RoundTripper{}
+		}
+		d.RoundTripper.EnableDatagrams = true
+		settings := make(map[uint64]uint64, len(d.RoundTripper.AdditionalSettings)+1)
+		for id, val := range d.RoundTripper.AdditionalSettings {
+			settings[id] = val
+		}
+		settings[settingEnableWebTransport] = 1
+		d.RoundTripper.AdditionalSettings = settings
+		d.RoundTripper.StreamHijacker = d.managers.hijackStream
+		d.RoundTripper.UniStreamHijacker = d.managers.hijackUniStream
+	})
+}
+
+// Dial establishes a WebTransport session to the given URL.
+// The context is used for sending the extended CONNECT request and receiving the response.
+// The session is independent from the context once it was established.
+// If the server responds with a status code other than 2xx, the response is returned together with an error.
+func (d *Dialer) Dial(ctx context.Context, urlStr string, reqHdr http.Header) (*http.Response, *Session, error) {
+	d.init()
+
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if reqHdr == nil {
+		reqHdr = http.Header{}
+	} else {
+		reqHdr = reqHdr.Clone()
+	}
+	reqHdr.Set(versionHeaderClient, "1")
+	req := (&http.Request{
+		Method: http.MethodConnect,
+		Header: reqHdr,
+		Proto:  protocolName,
+		Host:   u.Host,
+		URL:    u,
+	}).WithContext(ctx)
+	rsp, err := d.RoundTripper.RoundTrip(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if rsp.StatusCode < 200 || rsp.StatusCode >= 300 {
+		rsp.Body.Close()
+		return rsp, nil, fmt.Errorf("webtransport: received status %d", rsp.StatusCode)
+	}
+	streamer, ok := rsp.Body.(http3.DataStreamer)
+	if !ok {
+		rsp.Body.Close()
+		return rsp, nil, errors.New("webtransport: response body doesn't implement http3.DataStreamer")
+	}
+	hijacker, ok := rsp.Body.(http3.Hijacker)
+	if !ok {
+		rsp.Body.Close()
+		return rsp, nil, errors.New("webtransport: response body doesn't implement http3.Hijacker")
+	}
+	str := streamer.DataStream()
+	return rsp, d.managers.get(hijacker.Session()).establishSession(str, rsp.Body), nil
+}
+
+// Close closes the QUIC connections used by the Dialer.
+// All WebTransport sessions are closed.
+func (d *Dialer) Close() error {
+	d.init()
+	return d.RoundTripper.Close()
+}
@@ -0,0 +1,107 @@
+package webtransport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/lucas-clemente/quic-go/http3"
+	"github.com/lucas-clemente/quic-go/quicvarint"
+)
+
+// This package implements draft-ietf-webtrans-http3-02.
+const (
+	// settingEnableWebTransport is the SETTINGS_ENABLE_WEBTRANSPORT setting
+	settingEnableWebTransport = 0x2b603742
+	// frameTypeWebTransportStream is the signal value at the beginning of a bidirectional WebTransport stream
+	frameTypeWebTransportStream = 0x41
+	// streamTypeWebTransportStream is the stream type of a unidirectional WebTransport stream
+	streamTypeWebTransportStream = 0x54
+
+	capsuleTypeCloseSession = 0x2843
+
+	protocolName             = "webtransport"
+	versionHeaderClient      = "Sec-Webtransport-Http3-Draft02"
+	versionHeaderServer      = "Sec-Webtransport-Http3-Draft"
+	versionHeaderServerValue = "draft02"
+
+	// H3_REQUEST_REJECTED, used to reject streams for unknown sessions
+	errorCodeRequestRejected = 0x10b
+
+	maxCloseMessageLen = 1024
+)
+
+// SessionID is the ID of a WebTransport session.
+// It is the stream ID of the stream that the extended CONNECT request was sent on.
+type SessionID uint64
+
+// SessionErrorCode is an application error code used when closing a WebTransport session.
+type SessionErrorCode uint32
+
+// A SessionError is returned when a WebTransport session was closed.
+type SessionError struct {
+	Remote    bool
+	ErrorCode SessionErrorCode
+	Message   string
+}
+
+func (e *SessionError) Error() string {
+	if len(e.Message) == 0 {
+		return fmt.Sprintf("webtransport: session closed (error code %d)", e.ErrorCode)
+	}
+	return fmt.Sprintf("webtransport: session closed (error code %d): %s", e.ErrorCode, e.Message)
+}
+
+// streamHeader is the header that associates a stream with a session.
+func streamHeader(typ uint64, id SessionID) []byte {
+	b := &bytes.Buffer{}
+	quicvarint.Write(b, typ)
+	quicvarint.Write(b, uint64(id))
+	return b.Bytes()
+}
+
+// writeCloseSessionCapsule writes a CLOSE_WEBTRANSPORT_SESSION capsule.
+// Capsules are sent in HTTP/3 DATA frames on the request stream.
+func writeCloseSessionCapsule(w io.Writer, code SessionErrorCode, msg string) error {
+	if len(msg) > maxCloseMessageLen {
+		msg = msg[:maxCloseMessageLen]
+	}
+	b := make([]byte, 4, 4+len(msg))
+	binary.BigEndian.PutUint32(b, uint32(code))
+	b = append(b, msg...)
+	return http3.WriteCapsule(w, capsuleTypeCloseSession, b)
+}
+
+// readCloseSessionCapsule reads the capsules from r (the payload of the DATA frames on the request stream),
+// until the stream is closed.
+// If a CLOSE_WEBTRANSPORT_SESSION capsule is received, it is returned.
+// All other capsules are ignored.
+func readCloseSessionCapsule(r io.Reader) (*SessionError, error) {
+	qr := quicvarint.NewReader(r)
+	for {
+		typ, cr, err := http3.ParseCapsule(qr)
+		if err != nil {
+			return nil, err
+		}
+		if typ != capsuleTypeCloseSession {
+			if _, err := io.Copy(ioutil.Discard, cr); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		b, err := ioutil.ReadAll(io.LimitReader(cr, 4+maxCloseMessageLen+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(b) < 4 || len(b) > 4+maxCloseMessageLen {
+			return nil, fmt.Errorf("invalid length for CLOSE_WEBTRANSPORT_SESSION capsule: %d", len(b))
+		}
+		return &SessionError{
+			Remote:    true,
+			ErrorCode: SessionErrorCode(binary.BigEndian.Uint32(b[:4])),
+			Message:   string(b[4:]),
+		}, nil
+	}
+}
@@ -0,0 +1,87 @@
+package webtransport
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/lucas-clemente/quic-go/quicvarint"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Protocol", func() {
+	It("writes the stream header", func() {
+		b := bytes.NewReader(streamHeader(streamTypeWebTransportStream, 1337))
+		typ, err := quicvarint.Read(b)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(typ).To(BeEquivalentTo(streamTypeWebTransportStream))
+		id, err := quicvarint.Read(b)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(id).To(BeEquivalentTo(1337))
+		Expect(b.Len()).To(BeZero())
+	})
+
+	Context("CLOSE_WEBTRANSPORT_SESSION capsules", func() {
+		// writeCapsule writes a CLOSE_WEBTRANSPORT_SESSION capsule,
+		// and returns the payload of the DATA frame it was written in.
+		writeCapsule := func(code SessionErrorCode, msg string) []byte {
+			buf := &bytes.Buffer{}
+			Expect(writeCloseSessionCapsule(buf, code, msg)).To(Succeed())
+			typ, err := quicvarint.Read(buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(typ).To(BeZero()) // DATA frame
+			l, err := quicvarint.Read(buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buf.Len()).To(BeEquivalentTo(l))
+			return buf.Bytes()
+		}
+
+		It("writes and reads", func() {
+			serr, err := readCloseSessionCapsule(bytes.NewReader(writeCapsule(0xdeadbeef, "foobar")))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(serr.Remote).To(BeTrue())
+			Expect(serr.ErrorCode).To(Equal(SessionErrorCode(0xdeadbeef)))
+			Expect(serr.Message).To(Equal("foobar"))
+		})
+
+		It("truncates long messages", func() {
+			serr, err := readCloseSessionCapsule(bytes.NewReader(writeCapsule(42, string(make([]byte, 2*maxCloseMessageLen)))))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(serr.Message).To(HaveLen(maxCloseMessageLen))
+		})
+
+		It("skips other capsules", func() {
+			buf := &bytes.Buffer{}
+			// an unknown capsule
+			quicvarint.Write(buf, 0x1337)
+			quicvarint.Write(buf, 3)
+			buf.Write([]byte("bar"))
+			buf.Write(writeCapsule(42, ""))
+			serr, err := readCloseSessionCapsule(buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(serr.ErrorCode).To(BeEquivalentTo(42))
+			Expect(serr.Message).To(BeEmpty())
+		})
+
+		It("returns the error if the stream ends without a capsule", func() {
+			_, err := readCloseSessionCapsule(&bytes.Buffer{})
+			Expect(err).To(MatchError(io.EOF))
+		})
+
+		It("detects truncated capsules", func() {
+			capsule := writeCapsule(42, "foobar")
+			_, err := readCloseSessionCapsule(bytes.NewReader(capsule[:len(capsule)-1]))
+			Expect(err).To(MatchError(io.ErrUnexpectedEOF))
+		})
+
+		It("rejects capsules with an invalid length", func() {
+			buf := &bytes.Buffer{}
+			quicvarint.Write(buf, capsuleTypeCloseSession)
+			quicvarint.Write(buf, 2)
+			buf.Write([]byte("foo"))
+			_, err := readCloseSessionCapsule(buf)
+			Expect(err).To(MatchError("invalid length for CLOSE_WEBTRANSPORT_SESSION capsule: 2"))
+		})
+	})
+})
@@ -0,0 +1,119 @@
+package webtransport
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/lucas-clemente/quic-go/http3"
+)
+
+// Server is a WebTransport server.
+// WebTransport sessions are established by HTTP/3 handlers, by calling Upgrade.
+type Server struct {
+	// H3 is the HTTP/3 server that handles the extended CONNECT requests.
+	// Server sets the settings and stream hijackers required for WebTransport.
+	// Datagrams are enabled.
+	H3 http3.Server
+
+	// CheckOrigin is used to validate the Origin header of a request.
+	// If nil, only requests without an Origin header,
+	// or with an Origin header that matches the Host of the request are accepted.
+	CheckOrigin func(r *http.Request) bool
+
+	initOnce sync.Once
+	managers sessionManagers
+}
+
+func (s *Server) init() {
+	s.initOnce.Do(func() {
+		s.H3.EnableDatagrams = true
+		settings := make(map[uint64]uint64, len(s.H3.AdditionalSettings)+1)
+		for id, val := range s.H3.AdditionalSettings {
+			settings[id] = val
+		}
+		settings[settingEnableWebTransport] = 1
+		s.H3.AdditionalSettings = settings
+		s.H3.StreamHijacker = s.managers.hijackStream
+		s.H3.UniStreamHijacker = s.managers.hijackUniStream
+	})
+}
+
+// ListenAndServe listens on the UDP address s.H3.Addr and serves HTTP/3 requests.
+func (s *Server) ListenAndServe() error {
+	s.init()
+	return s.H3.ListenAndServe()
+}
+
+// ListenAndServeTLS listens on the UDP address s.H3.Addr and serves HTTP/3 requests.
+func (s *Server) ListenAndServeTLS(certFile, keyFile string) error {
+	s.init()
+	return s.H3.ListenAndServeTLS(certFile, keyFile)
+}
+
+// Serve serves HTTP/3 requests on an existing UDP connection.
+func (s *Server) Serve(conn net.PacketConn) error {
+	s.init()
+	return s.H3.Serve(conn)
+}
+
+// Close closes the server.
+// All QUIC connections, and therefore all WebTransport sessions, are closed.
+func (s *Server) Close() error {
+	return s.H3.Close()
+}
+
+// Upgrade establishes a WebTransport session.
+// It must be called from an HTTP/3 handler of the Server.
+// If the request is not a valid WebTransport request, Upgrade responds with an HTTP error and returns an error.
+// After a successful upgrade, the handler may return, the session stays open until it is closed.
+func (s *Server) Upgrade(w http.ResponseWriter, r *http.Request) (*Session, error) {
+	if r.Method != http.MethodConnect {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return nil, fmt.Errorf("webtransport: expected CONNECT request, got %s", r.Method)
+	}
+	if r.Proto != protocolName {
+		w.WriteHeader(http.StatusBadRequest)
+		return nil, fmt.Errorf("webtransport: unexpected protocol: %s", r.Proto)
+	}
+	if v := r.Header.Get(versionHeaderClient); v != "1" {
+		w.WriteHeader(http.StatusBadRequest)
+		return nil, fmt.Errorf("webtransport: missing or invalid %s header: %q", versionHeaderClient, v)
+	}
+	checkOrigin := s.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = checkSameOrigin
+	}
+	if !checkOrigin(r) {
+		w.WriteHeader(http.StatusForbidden)
+		return nil, errors.New("webtransport: request origin not allowed")
+	}
+	streamer, ok := w.(http3.DataStreamer)
+	if !ok {
+		return nil, errors.New("webtransport: response writer doesn't implement http3.DataStreamer")
+	}
+	hijacker, ok := w.(http3.Hijacker)
+	if !ok {
+		return nil, errors.New("webtransport: response writer doesn't implement http3.Hijacker")
+	}
+
+	w.Header().Set(versionHeaderServer, versionHeaderServerValue)
+	w.WriteHeader(http.StatusOK)
+	str := streamer.DataStream()
+	return s.managers.get(hijacker.Session()).establishSession(str, r.Body), nil
+}
+
+func checkSameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return u.Host == r.Host
+}
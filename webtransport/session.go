@@ -0,0 +1,377 @@
+package webtransport
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/http3"
+	"github.com/lucas-clemente/quic-go/quicvarint"
+)
+
+const (
+	// maxQueuedStreams is the maximum number of streams that are queued for a session before they are accepted.
+	maxQueuedStreams = 100
+	// maxQueuedDatagrams is the maximum number of datagrams that are queued for a session before they are received.
+	maxQueuedDatagrams = 32
+)
+
+// A Session is a WebTransport session.
+// Streams and datagrams of a session are sent on the QUIC connection that the session was established on.
+// Multiple sessions can share a single QUIC connection.
+type Session struct {
+	id    SessionID
+	qsess quic.Session
+
+	streamHdr    []byte
+	uniStreamHdr []byte
+	datagramHdr  []byte
+
+	onClose func() // removes the session from the sessionManager
+
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+
+	mutex               sync.Mutex
+	established         bool
+	requestStr          quic.Stream // only set once the session is established
+	unregisterDatagrams func()      // only set once the session is established
+	closeErr            error
+
+	acceptQueue    []quic.Stream
+	uniAcceptQueue []quic.ReceiveStream
+	streamQueued   chan struct{}
+	datagrams      chan []byte
+}
+
+func newSession(id SessionID, qsess quic.Session, onClose func()) *Session {
+	datagramHdr := &bytes.Buffer{}
+	// The quarter stream ID, see RFC 9297
+	quicvarint.Write(datagramHdr, uint64(id)/4)
+	ctx, cancel := context.WithCancel(qsess.Context())
+	return &Session{
+		id:           id,
+		qsess:        qsess,
+		streamHdr:    streamHeader(frameTypeWebTransportStream, id),
+		uniStreamHdr: streamHeader(streamTypeWebTransportStream, id),
+		datagramHdr:  datagramHdr.Bytes(),
+		onClose:      onClose,
+		ctx:          ctx,
+		ctxCancel:    cancel,
+		streamQueued: make(chan struct{}, 1),
+		datagrams:    make(chan []byte, maxQueuedDatagrams),
+	}
+}
+
+// establish is called once the extended CONNECT request was accepted.
+// r reads the payload of the DATA frames on the request stream.
+func (s *Session) establish(str quic.Stream, r io.Reader) {
+	s.mutex.Lock()
+	s.established = true
+	s.requestStr = str
+	s.unregisterDatagrams = http3.RegisterDatagramHandler(s.qsess, str.StreamID(), s.handleDatagram)
+	s.mutex.Unlock()
+
+	go func() {
+		serr, err := readCloseSessionCapsule(r)
+		if serr == nil {
+			// The request stream was closed without a CLOSE_WEBTRANSPORT_SESSION capsule.
+			serr = &SessionError{Remote: true}
+			if err != io.EOF {
+				serr.Message = err.Error()
+			}
+		}
+		s.closeWithError(serr)
+	}()
+}
+
+func (s *Session) isEstablished() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.established
+}
+
+// ID returns the session ID.
+func (s *Session) ID() SessionID {
+	return s.id
+}
+
+func (s *Session) addStream(str quic.Stream) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closeErr != nil || len(s.acceptQueue) >= maxQueuedStreams {
+		str.CancelRead(errorCodeRequestRejected)
+		str.CancelWrite(errorCodeRequestRejected)
+		return
+	}
+	s.acceptQueue = append(s.acceptQueue, str)
+	s.signalStreamQueued()
+}
+
+func (s *Session) addUniStream(str quic.ReceiveStream) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closeErr != nil || len(s.uniAcceptQueue) >= maxQueuedStreams {
+		str.CancelRead(errorCodeRequestRejected)
+		return
+	}
+	s.uniAcceptQueue = append(s.uniAcceptQueue, str)
+	s.signalStreamQueued()
+}
+
+func (s *Session) signalStreamQueued() {
+	select {
+	case s.streamQueued <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Session) handleDatagram(data []byte) {
+	select {
+	case s.datagrams <- data:
+	default:
+		// drop the datagram if the application doesn't receive datagrams fast enough
+	}
+}
+
+// AcceptStream accepts the next bidirectional stream that the peer opened for this session.
+func (s *Session) AcceptStream(ctx context.Context) (quic.Stream, error) {
+	for {
+		s.mutex.Lock()
+		if s.closeErr != nil {
+			s.mutex.Unlock()
+			return nil, s.closeErr
+		}
+		if len(s.acceptQueue) > 0 {
+			str := s.acceptQueue[0]
+			s.acceptQueue = s.acceptQueue[1:]
+			s.mutex.Unlock()
+			return str, nil
+		}
+		s.mutex.Unlock()
+
+		if err := s.waitForStream(ctx); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// AcceptUniStream accepts the next unidirectional stream that the peer opened for this session.
+func (s *Session) AcceptUniStream(ctx context.Context) (quic.ReceiveStream, error) {
+	for {
+		s.mutex.Lock()
+		if s.closeErr != nil {
+			s.mutex.Unlock()
+			return nil, s.closeErr
+		}
+		if len(s.uniAcceptQueue) > 0 {
+			str := s.uniAcceptQueue[0]
+			s.uniAcceptQueue = s.uniAcceptQueue[1:]
+			s.mutex.Unlock()
+			return str, nil
+		}
+		s.mutex.Unlock()
+
+		if err := s.waitForStream(ctx); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (s *Session) waitForStream(ctx context.Context) error {
+	select {
+	case <-s.streamQueued:
+		// The signal might have been meant for the other accept queue.
+		// Make sure that concurrent calls waiting for the other queue don't miss it.
+		s.signalStreamQueued()
+		return nil
+	case <-s.ctx.Done():
+		return s.getCloseErr()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// OpenStream opens a new bidirectional stream.
+// It doesn't block, and returns an error if the peer's stream limit is reached.
+func (s *Session) OpenStream() (quic.Stream, error) {
+	if err := s.getCloseErr(); err != nil {
+		return nil, err
+	}
+	str, err := s.qsess.OpenStream()
+	if err != nil {
+		return nil, err
+	}
+	return s.initStream(str)
+}
+
+// OpenStreamSync opens a new bidirectional stream.
+// It blocks until the peer's stream limit allows opening the stream.
+func (s *Session) OpenStreamSync(ctx context.Context) (quic.Stream, error) {
+	if err := s.getCloseErr(); err != nil {
+		return nil, err
+	}
+	str, err := s.qsess.OpenStreamSync(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.initStream(str)
+}
+
+func (s *Session) initStream(str quic.Stream) (quic.Stream, error) {
+	if _, err := str.Write(s.streamHdr); err != nil {
+		str.CancelRead(errorCodeRequestRejected)
+		str.CancelWrite(errorCodeRequestRejected)
+		return nil, err
+	}
+	return str, nil
+}
+
+// OpenUniStream opens a new unidirectional stream.
+// It doesn't block, and returns an error if the peer's stream limit is reached.
+func (s *Session) OpenUniStream() (quic.SendStream, error) {
+	if err := s.getCloseErr(); err != nil {
+		return nil, err
+	}
+	str, err := s.qsess.OpenUniStream()
+	if err != nil {
+		return nil, err
+	}
+	return s.initUniStream(str)
+}
+
+// OpenUniStreamSync opens a new unidirectional stream.
+// It blocks until the peer's stream limit allows opening the stream.
+func (s *Session) OpenUniStreamSync(ctx context.Context) (quic.SendStream, error) {
+	if err := s.getCloseErr(); err != nil {
+		return nil, err
+	}
+	str, err := s.qsess.OpenUniStreamSync(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.initUniStream(str)
+}
+
+func (s *Session) initUniStream(str quic.SendStream) (quic.SendStream, error) {
+	if _, err := str.Write(s.uniStreamHdr); err != nil {
+		str.CancelWrite(errorCodeRequestRejected)
+		return nil, err
+	}
+	return str, nil
+}
+
+// SendMessage sends a datagram.
+// Datagrams are only available if the peer enabled support for datagrams.
+func (s *Session) SendMessage(b []byte) error {
+	if err := s.getCloseErr(); err != nil {
+		return err
+	}
+	data := make([]byte, 0, len(s.datagramHdr)+len(b))
+	data = append(data, s.datagramHdr...)
+	data = append(data, b...)
+	return s.qsess.SendMessage(data)
+}
+
+// ReceiveMessage receives a datagram.
+// It blocks until a datagram is received, the session is closed or the context is canceled.
+func (s *Session) ReceiveMessage(ctx context.Context) ([]byte, error) {
+	select {
+	case data := <-s.datagrams:
+		return data, nil
+	case <-s.ctx.Done():
+		return nil, s.getCloseErr()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Context returns a context that is canceled when the session is closed.
+func (s *Session) Context() context.Context {
+	return s.ctx
+}
+
+// LocalAddr returns the local address of the QUIC connection.
+func (s *Session) LocalAddr() net.Addr {
+	return s.qsess.LocalAddr()
+}
+
+// RemoteAddr returns the remote address of the QUIC connection.
+func (s *Session) RemoteAddr() net.Addr {
+	return s.qsess.RemoteAddr()
+}
+
+// CloseWithError closes the session.
+// The error code and the message are sent to the peer in a CLOSE_WEBTRANSPORT_SESSION capsule.
+// The QUIC connection is not closed, since it might be used by other sessions.
+// Streams that were opened for this session are not reset, this is the responsibility of the application.
+func (s *Session) CloseWithError(code SessionErrorCode, msg string) error {
+	s.mutex.Lock()
+	str := s.requestStr
+	s.mutex.Unlock()
+
+	if !s.closeWithError(&SessionError{ErrorCode: code, Message: msg}) {
+		return nil
+	}
+	if str == nil {
+		return nil
+	}
+	err := writeCloseSessionCapsule(str, code, msg)
+	str.CancelRead(0)
+	str.Close()
+	return err
+}
+
+// closeWithError closes the session.
+// It returns false if the session was already closed.
+func (s *Session) closeWithError(e error) bool {
+	s.mutex.Lock()
+	if s.closeErr != nil {
+		s.mutex.Unlock()
+		return false
+	}
+	s.closeErr = e
+	acceptQueue := s.acceptQueue
+	uniAcceptQueue := s.uniAcceptQueue
+	s.acceptQueue = nil
+	s.uniAcceptQueue = nil
+	unregisterDatagrams := s.unregisterDatagrams
+	s.mutex.Unlock()
+
+	if unregisterDatagrams != nil {
+		unregisterDatagrams()
+	}
+
+	// reject all streams that were not accepted by the application yet
+	for _, str := range acceptQueue {
+		str.CancelRead(errorCodeRequestRejected)
+		str.CancelWrite(errorCodeRequestRejected)
+	}
+	for _, str := range uniAcceptQueue {
+		str.CancelRead(errorCodeRequestRejected)
+	}
+	s.ctxCancel()
+	s.onClose()
+	return true
+}
+
+func (s *Session) getCloseErr() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closeErr != nil {
+		return s.closeErr
+	}
+	// The QUIC connection might have been closed.
+	select {
+	case <-s.ctx.Done():
+		return errors.New("webtransport: QUIC connection closed")
+	default:
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package webtransport
+
+import (
+	"io"
+	"sync"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/http3"
+	"github.com/lucas-clemente/quic-go/internal/utils"
+	"github.com/lucas-clemente/quic-go/quicvarint"
+)
+
+// maxPendingSessions is the maximum number of sessions per QUIC connection that streams are buffered for,
+// before the extended CONNECT request establishing the session was processed.
+const maxPendingSessions = 16
+
+// A sessionManager manages the WebTransport sessions of a QUIC connection.
+// It passes the streams received on the connection to the respective session.
+type sessionManager struct {
+	qsess  quic.Session
+	logger utils.Logger
+
+	mutex    sync.Mutex
+	sessions map[SessionID]*Session
+}
+
+func newSessionManager(qsess quic.Session, logger utils.Logger) *sessionManager {
+	m := &sessionManager{
+		qsess:    qsess,
+		logger:   logger,
+		sessions: make(map[SessionID]*Session),
+	}
+	return m
+}
+
+// getSession returns the session with the given ID.
+// Streams might arrive before the extended CONNECT request that establishes the session was processed,
+// so the session is created if it doesn't exist yet.
+// It returns nil if there are too many sessions that haven't been established yet.
+func (m *sessionManager) getSession(id SessionID) *Session {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if sess, ok := m.sessions[id]; ok {
+		return sess
+	}
+	var numPending int
+	for _, sess := range m.sessions {
+		if !sess.isEstablished() {
+			numPending++
+		}
+	}
+	if numPending >= maxPendingSessions {
+		return nil
+	}
+	sess := newSession(id, m.qsess, func() { m.removeSession(id) })
+	m.sessions[id] = sess
+	return sess
+}
+
+func (m *sessionManager) removeSession(id SessionID) {
+	m.mutex.Lock()
+	delete(m.sessions, id)
+	m.mutex.Unlock()
+}
+
+// establishSession establishes a session, after the extended CONNECT request was accepted.
+// r reads the payload of the DATA frames on the request stream.
+func (m *sessionManager) establishSession(str quic.Stream, r io.Reader) *Session {
+	id := SessionID(str.StreamID())
+	m.mutex.Lock()
+	sess, ok := m.sessions[id]
+	if !ok {
+		sess = newSession(id, m.qsess, func() { m.removeSession(id) })
+		m.sessions[id] = sess
+	}
+	m.mutex.Unlock()
+	sess.establish(str, r)
+	return sess
+}
+
+func (m *sessionManager) handleStream(str quic.Stream) {
+	id, err := quicvarint.Read(quicvarint.NewReader(str))
+	if err != nil {
+		m.logger.Debugf("reading the session ID on stream %d failed: %s", str.StreamID(), err)
+		str.CancelRead(errorCodeRequestRejected)
+		str.CancelWrite(errorCodeRequestRejected)
+		return
+	}
+	sess := m.getSession(SessionID(id))
+	if sess == nil {
+		m.logger.Debugf("rejecting stream %d for unknown session %d", str.StreamID(), id)
+		str.CancelRead(errorCodeRequestRejected)
+		str.CancelWrite(errorCodeRequestRejected)
+		return
+	}
+	sess.addStream(str)
+}
+
+func (m *sessionManager) handleUniStream(str quic.ReceiveStream) {
+	id, err := quicvarint.Read(quicvarint.NewReader(str))
+	if err != nil {
+		m.logger.Debugf("reading the session ID on stream %d failed: %s", str.StreamID(), err)
+		str.CancelRead(errorCodeRequestRejected)
+		return
+	}
+	sess := m.getSession(SessionID(id))
+	if sess == nil {
+		m.logger.Debugf("rejecting stream %d for unknown session %d", str.StreamID(), id)
+		str.CancelRead(errorCodeRequestRejected)
+		return
+	}
+	sess.addUniStream(str)
+}
+
+// sessionManagers holds the sessionManager of every QUIC connection.
+// It implements the stream hijackers that are passed to HTTP/3.
+type sessionManagers struct {
+	logger utils.Logger
+
+	mutex    sync.Mutex
+	managers map[quic.Session]*sessionManager
+}
+
+func (m *sessionManagers) get(qsess quic.Session) *sessionManager {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.managers == nil {
+		m.managers = make(map[quic.Session]*sessionManager)
+	}
+	if manager, ok := m.managers[qsess]; ok {
+		return manager
+	}
+	if m.logger == nil {
+		m.logger = utils.DefaultLogger.WithPrefix("webtransport")
+	}
+	manager := newSessionManager(qsess, m.logger)
+	m.managers[qsess] = manager
+	go func() {
+		<-qsess.Context().Done()
+		m.mutex.Lock()
+		delete(m.managers, qsess)
+		m.mutex.Unlock()
+	}()
+	return manager
+}
+
+func (m *sessionManagers) hijackStream(ft http3.FrameType, qsess quic.Session, str quic.Stream) bool {
+	if ft != frameTypeWebTransportStream {
+		return false
+	}
+	m.get(qsess).handleStream(str)
+	return true
+}
+
+func (m *sessionManagers) hijackUniStream(st http3.StreamType, qsess quic.Session, str quic.ReceiveStream) bool {
+	if st != streamTypeWebTransportStream {
+		return false
+	}
+	m.get(qsess).handleUniStream(str)
+	return true
+}
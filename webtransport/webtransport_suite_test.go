@@ -0,0 +1,13 @@
+package webtransport
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestWebTransport(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "WebTransport Suite")
+}
@@ -0,0 +1,170 @@
+package webtransport
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/lucas-clemente/quic-go/http3"
+	"github.com/lucas-clemente/quic-go/internal/testdata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WebTransport", func() {
+	var (
+		server   *Server
+		dialer   *Dialer
+		sessChan chan *Session
+		url      string
+	)
+
+	BeforeEach(func() {
+		sessChan = make(chan *Session, 1)
+		server = &Server{H3: http3.Server{Server: &http.Server{TLSConfig: testdata.GetTLSConfig()}}}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/webtransport", func(w http.ResponseWriter, r *http.Request) {
+			sess, err := server.Upgrade(w, r)
+			if err != nil {
+				return
+			}
+			sessChan <- sess
+		})
+		server.H3.Handler = mux
+		conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		Expect(err).ToNot(HaveOccurred())
+		go server.Serve(conn)
+		url = fmt.Sprintf("https://localhost:%d/webtransport", conn.LocalAddr().(*net.UDPAddr).Port)
+
+		dialer = &Dialer{RoundTripper: &http3.RoundTripper{
+			TLSClientConfig: &tls.Config{RootCAs: testdata.GetRootCA()},
+			QuicConfig:      &quic.Config{Versions: []quic.VersionNumber{quic.VersionDraft29}},
+		}}
+	})
+
+	AfterEach(func() {
+		Expect(dialer.Close()).To(Succeed())
+		Expect(server.Close()).To(Succeed())
+	})
+
+	establish := func() (*Session, *Session) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		rsp, clientSess, err := dialer.Dial(ctx, url, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+		var serverSess *Session
+		Eventually(sessChan).Should(Receive(&serverSess))
+		Expect(serverSess.ID()).To(Equal(clientSess.ID()))
+		return clientSess, serverSess
+	}
+
+	It("rejects requests to other handlers", func() {
+		rsp, sess, err := dialer.Dial(context.Background(), url+"/foo", nil)
+		Expect(err).To(MatchError("webtransport: received status 404"))
+		Expect(rsp.StatusCode).To(Equal(http.StatusNotFound))
+		Expect(sess).To(BeNil())
+	})
+
+	It("rejects requests from other origins", func() {
+		rsp, _, err := dialer.Dial(context.Background(), url, http.Header{"Origin": []string{"https://example.com"}})
+		Expect(err).To(HaveOccurred())
+		Expect(rsp.StatusCode).To(Equal(http.StatusForbidden))
+	})
+
+	It("opens bidirectional streams", func() {
+		clientSess, serverSess := establish()
+		// the client opens a stream
+		str, err := clientSess.OpenStreamSync(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		_, err = str.Write([]byte("foo"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(str.Close()).To(Succeed())
+		sstr, err := serverSess.AcceptStream(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		data, err := ioutil.ReadAll(sstr)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal([]byte("foo")))
+		// the server opens a stream
+		sstr, err = serverSess.OpenStreamSync(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		_, err = sstr.Write([]byte("bar"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(sstr.Close()).To(Succeed())
+		str, err = clientSess.AcceptStream(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		data, err = ioutil.ReadAll(str)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal([]byte("bar")))
+	})
+
+	It("opens unidirectional streams", func() {
+		clientSess, serverSess := establish()
+		str, err := serverSess.OpenUniStreamSync(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		_, err = str.Write([]byte("foobar"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(str.Close()).To(Succeed())
+		rstr, err := clientSess.AcceptUniStream(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		data, err := ioutil.ReadAll(rstr)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal([]byte("foobar")))
+	})
+
+	It("sends datagrams", func() {
+		clientSess, serverSess := establish()
+		Expect(clientSess.SendMessage([]byte("ping"))).To(Succeed())
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		data, err := serverSess.ReceiveMessage(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal([]byte("ping")))
+		Expect(serverSess.SendMessage([]byte("pong"))).To(Succeed())
+		data, err = clientSess.ReceiveMessage(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal([]byte("pong")))
+	})
+
+	It("runs multiple sessions on one connection", func() {
+		clientSess1, serverSess1 := establish()
+		clientSess2, serverSess2 := establish()
+		Expect(clientSess1.ID()).ToNot(Equal(clientSess2.ID()))
+		str, err := clientSess2.OpenUniStreamSync(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		_, err = str.Write([]byte("session 2"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(str.Close()).To(Succeed())
+		rstr, err := serverSess2.AcceptUniStream(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		data, err := ioutil.ReadAll(rstr)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(data).To(Equal([]byte("session 2")))
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err = serverSess1.AcceptUniStream(ctx)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+	})
+
+	It("closes sessions", func() {
+		clientSess, serverSess := establish()
+		errChan := make(chan error, 1)
+		go func() {
+			_, err := clientSess.AcceptStream(context.Background())
+			errChan <- err
+		}()
+		Expect(serverSess.CloseWithError(1337, "done")).To(Succeed())
+		var err error
+		Eventually(errChan).Should(Receive(&err))
+		Expect(err).To(Equal(&SessionError{Remote: true, ErrorCode: 1337, Message: "done"}))
+		Eventually(clientSess.Context().Done()).Should(BeClosed())
+		_, err = serverSess.OpenStream()
+		Expect(err).To(Equal(&SessionError{ErrorCode: 1337, Message: "done"}))
+	})
+})